@@ -1,57 +1,85 @@
 #!/usr/bin/env python3
 
-import sys
+import os
 import subprocess
+import sys
+
+import tui_runner
+
+ROOT = os.path.dirname(os.path.abspath(__file__))
+
+# foss_token actions handled by `tools-tui secrets`, with the same arguments
+SECRETS_ACTIONS = {"store": "set", "get": "get", "list": "list", "delete": "delete", "rotate": "rotate"}
 
-def run_command(cmd):
-    subprocess.run(cmd, shell=True)
+def run_command(script, args=()):
+    # Resolve the script relative to this file so cli.py also works when
+    # invoked from a sub-project directory; the arguments are passed on
+    # unchanged
+    parts = ["python", os.path.join(ROOT, script), *args]
+    # Runs through tools-tui when it is built, for its streaming, timeout
+    # and run history, and exits with the command's status
+    name = os.path.splitext(os.path.basename(script))[0]
+    result = tui_runner.run(parts, name=name)
+    if not result.ok:
+        sys.exit(result.returncode)
 
 if __name__ == "__main__":
     if len(sys.argv) < 2:
         print("Usage: python cli.py <command> [args...]")
-        print("Commands: review, test, deploy, validate_openapi, fetch_data, convert_format, handle_webhook, automate, manage_linear, get_token, foss_token, memory, analyze_code, create_project, memory_config, hierarchical_memory, vector_db, agent_comm, multiagent, research")
+        print("Commands: review, test, deploy, validate_openapi, fetch_data, download, contract_test, mock_server, convert_format, handle_webhook, automate, manage_linear, get_token, foss_token, memory, analyze_code, create_project, memory_config, hierarchical_memory, vector_db, agent_comm, multiagent, research")
         sys.exit(1)
     command = sys.argv[1]
     args = sys.argv[2:]
     if command == "review":
-        run_command(f"python agents/code_reviewer.py {' '.join(args)}")
+        run_command("agents/code_reviewer.py", args)
     elif command == "test":
-        run_command("python agents/tester.py")
+        run_command("agents/tester.py")
     elif command == "deploy":
-        run_command(f"python agents/deployer.py {' '.join(args)}")
+        run_command("agents/deployer.py", args)
     elif command == "validate_openapi":
-        run_command(f"python tools/openapi_validator.py {' '.join(args)}")
+        run_command("tools/openapi_validator.py", args)
     elif command == "fetch_data":
-        run_command(f"python tools/data_fetcher.py {' '.join(args)}")
+        run_command("tools/data_fetcher.py", args)
+    elif command == "download":
+        run_command("tools/data_fetcher.py", ["download", *args])
+    elif command == "contract_test":
+        run_command("tools/contract_tester.py", args)
+    elif command == "mock_server":
+        run_command("tools/mock_server.py", args)
     elif command == "convert_format":
-        run_command(f"python tools/format_converter.py {' '.join(args)}")
+        run_command("tools/format_converter.py", args)
     elif command == "handle_webhook":
-        run_command(f"python integrations/webhook_handler.py {' '.join(args)}")
+        run_command("integrations/webhook_handler.py", args)
     elif command == "automate":
-        run_command(f"python integrations/automation.py {' '.join(args)}")
+        run_command("integrations/automation.py", args)
     elif command == "manage_linear":
-        run_command(f"python integrations/linear_manager.py {' '.join(args)}")
+        run_command("integrations/linear_manager.py", args)
     elif command == "get_token":
-        run_command(f"python configs/token_manager.py {' '.join(args)}")
+        run_command("configs/token_manager.py", args)
     elif command == "memory":
-        run_command(f"python tools/memory_manager.py {' '.join(args)}")
+        run_command("tools/memory_manager.py", args)
     elif command == "analyze_code":
-        run_command(f"python tools/code_analyzer.py {' '.join(args)}")
+        run_command("tools/code_analyzer.py", args)
     elif command == "create_project":
-        run_command(f"python tools/project_manager.py {' '.join(args)}")
+        run_command("tools/project_manager.py", args)
     elif command == "memory_config":
-        run_command(f"python configs/memory_config.py {' '.join(args)}")
+        run_command("configs/memory_config.py", args)
     elif command == "hierarchical_memory":
-        run_command(f"python tools/hierarchical_memory.py {' '.join(args)}")
+        run_command("tools/hierarchical_memory.py", args)
     elif command == "foss_token":
-        run_command(f"python configs/foss_token_manager.py {' '.join(args)}")
+        # The secrets store of tools-tui (OS keyring, a passphrase-encrypted
+        # file as fallback) replaces the Fernet file for the actions it has
+        runner = tui_runner.find_runner()
+        if runner and args and args[0] in SECRETS_ACTIONS:
+            sys.exit(subprocess.call([runner, "secrets", SECRETS_ACTIONS[args[0]]] + args[1:]))
+        run_command("configs/foss_token_manager.py", args)
     elif command == "vector_db":
-        run_command(f"python tools/vector_database.py {' '.join(args)}")
+        run_command("tools/vector_database.py", args)
     elif command == "agent_comm":
-        run_command(f"python tools/agent_communication.py {' '.join(args)}")
+        run_command("tools/agent_communication.py", args)
     elif command == "multiagent":
-        run_command(f"python agents/multiagent_coordinator.py {' '.join(args)}")
+        run_command("agents/multiagent_coordinator.py", args)
     elif command == "research":
-        run_command(f"python tools/research_assistant.py {' '.join(args)}")
+        run_command("tools/research_assistant.py", args)
     else:
         print(f"Unknown command: {command}")
\ No newline at end of file
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// action is a user-visible command. The registry below is the single
+// source for key dispatch on the tool screen, the cheat sheet, the
+// command palette, macros and the action names used in config.json.
+type action struct {
+	name        string
+	description string
+	group       string
+	binding     func(k *KeyMap) *key.Binding
+	// available reports whether the action applies right now; nil
+	// means always
+	available func(m Model) bool
+	// run performs the action; nil for keys handled by their own
+	// screen or prompt
+	run func(m *Model) tea.Cmd
+}
+
+// isAvailable reports whether the action can run in the model's state
+func (a action) isAvailable(m Model) bool {
+	return a.run != nil && (a.available == nil || a.available(m))
+}
+
+// inList is true while browsing the tool list
+func inList(m Model) bool { return !m.detailMode && !m.searchMode }
+
+// inDetail is true while a tool's details are shown
+func inDetail(m Model) bool { return m.detailMode && m.selectedTool != nil }
+
+// hasOutput is true while the detail view shows output to export
+func hasOutput(m Model) bool { return inDetail(m) && m.commandOutput != "" }
+
+// providesServer is true in the details of a tool backed by an MCP server
+func providesServer(m Model) bool { return inDetail(m) && m.selectedTool.MCPServer != "" }
+
+// cursorTool returns the tool under the cursor in the list, or nil
+func (m Model) cursorTool() *Tool {
+	if m.currentCat >= len(m.categories) {
+		return nil
+	}
+	category := m.categories[m.currentCat]
+	if m.currentTool >= m.visibleTools(category) {
+		return nil
+	}
+	return &category.Tools[m.currentTool]
+}
+
+// notSearching is true unless the search input has focus
+func notSearching(m Model) bool { return !m.searchMode }
+
+// actions lists every action in cheat sheet order
+var actions = []action{
+	{"up", "move up", "Navigation", func(k *KeyMap) *key.Binding { return &k.Up }, inList, func(m *Model) tea.Cmd {
+		if m.currentTool > 0 {
+			m.currentTool--
+		}
+		return nil
+	}},
+	{"down", "move down", "Navigation", func(k *KeyMap) *key.Binding { return &k.Down }, inList, func(m *Model) tea.Cmd {
+		if m.currentTool < m.visibleTools(m.categories[m.currentCat])-1 {
+			m.currentTool++
+		}
+		return nil
+	}},
+	{"left", "previous category", "Navigation", func(k *KeyMap) *key.Binding { return &k.Left }, inList, func(m *Model) tea.Cmd {
+		if m.currentCat > 0 {
+			m.currentCat--
+			m.currentTool = 0
+		}
+		return nil
+	}},
+	{"right", "next category", "Navigation", func(k *KeyMap) *key.Binding { return &k.Right }, inList, func(m *Model) tea.Cmd {
+		if m.currentCat < len(m.categories)-1 {
+			m.currentCat++
+			m.currentTool = 0
+		}
+		return nil
+	}},
+	{"enter", "open tool details", "Navigation", func(k *KeyMap) *key.Binding { return &k.Enter }, func(m Model) bool { return !m.detailMode }, func(m *Model) tea.Cmd {
+		category := m.categories[m.currentCat]
+		if m.visibleTools(category) > 0 {
+			m.selectedTool = &category.Tools[m.currentTool]
+			if category.Favorites {
+				// edit the catalog entry rather than the favorites copy
+				if tool := findTool(m.categories, m.selectedTool.Key()); tool != nil {
+					m.selectedTool = tool
+				}
+			}
+			m.detailMode = true
+			m.statusMessage = ""
+			m.warning = ""
+			m.attachDetail()
+		}
+		return nil
+	}},
+	{"back", "back to the tool list", "Navigation", func(k *KeyMap) *key.Binding { return &k.Back }, inDetail, func(m *Model) tea.Cmd {
+		m.detailMode = false
+		m.selectedTool = nil
+		m.commandOutput = ""
+		m.statusMessage = ""
+		m.warning = ""
+		m.refreshFavorites()
+		return nil
+	}},
+	{"toggle_category", "collapse or expand category", "Navigation", func(k *KeyMap) *key.Binding { return &k.ToggleCategory }, inList, func(m *Model) tea.Cmd {
+		if m.currentCat < len(m.categories) {
+			m.categories[m.currentCat].Active = !m.categories[m.currentCat].Active
+		}
+		return nil
+	}},
+	{"split", "show the list alone or next to a preview of the tool", "Navigation", func(k *KeyMap) *key.Binding { return &k.Split }, inList, func(m *Model) tea.Cmd {
+		return m.showToast(m.toggleSplit())
+	}},
+	{"narrower", "give the list less of the width", "Navigation", func(k *KeyMap) *key.Binding { return &k.Narrower }, func(m Model) bool { return inList(m) && m.split() }, func(m *Model) tea.Cmd {
+		return m.showToast(m.resizeSplit(-listPercentStep))
+	}},
+	{"wider", "give the list more of the width", "Navigation", func(k *KeyMap) *key.Binding { return &k.Wider }, func(m Model) bool { return inList(m) && m.split() }, func(m *Model) tea.Cmd {
+		return m.showToast(m.resizeSplit(listPercentStep))
+	}},
+	{"tabs", "switch to the Tools, MCP, Extensions, History or Logs tab", "Navigation", func(k *KeyMap) *key.Binding { return &k.Tabs }, nil, nil},
+
+	{"search", "search tools", "Tools", func(k *KeyMap) *key.Binding { return &k.Search }, notSearching, func(m *Model) tea.Cmd {
+		m.searchMode = true
+		m.searchInput.Focus()
+		return textinput.Blink
+	}},
+	{"palette", "command palette", "Tools", func(k *KeyMap) *key.Binding { return &k.Palette }, notSearching, (*Model).openPalette},
+	{"recent", "run a recently executed tool again", "Tools", func(k *KeyMap) *key.Binding { return &k.Recent }, notSearching, (*Model).openRecent},
+	{"execute", "execute the tool's command", "Tools", func(k *KeyMap) *key.Binding { return &k.Execute }, inDetail, func(m *Model) tea.Cmd {
+		m.presetArgs = nil
+		return m.executeSelectedTool()
+	}},
+	{"dry_run", "toggle dry run: show the resolved command, directory and environment instead of running tools (Maintenance: report instead of removing)", "Tools", func(k *KeyMap) *key.Binding { return &k.DryRun }, notSearching, func(m *Model) tea.Cmd {
+		m.dryRun = !m.dryRun
+		text := "Dry run off, tools run again"
+		if m.dryRun {
+			text = "Dry run on, tools show what they would run"
+		}
+		if m.detailMode {
+			m.statusMessage = text
+			return nil
+		}
+		return m.showToast(text)
+	}},
+	{"confirm", "confirm running a sandboxed or destructive tool", "Tools", func(k *KeyMap) *key.Binding { return &k.Confirm }, nil, nil},
+	{"override", "override quiet hours", "Tools", func(k *KeyMap) *key.Binding { return &k.Override }, nil, nil},
+	{"trust", "cycle trust tier", "Tools", func(k *KeyMap) *key.Binding { return &k.Trust }, inDetail, func(m *Model) tea.Cmd {
+		m.selectedTool.Trust = m.selectedTool.Trust.Next()
+		if err := SaveTrustOverride(m.selectedTool.Key(), m.selectedTool.Trust); err != nil {
+			m.statusMessage = fmt.Sprintf("Could not save trust tier: %v", err)
+		} else {
+			m.statusMessage = fmt.Sprintf("Trust tier set to %s", m.selectedTool.Trust)
+		}
+		return nil
+	}},
+	{"auto_approve", "let workflows and schedules run the tool without asking", "Tools", func(k *KeyMap) *key.Binding { return &k.Auto }, inDetail, func(m *Model) tea.Cmd {
+		m.selectedTool.Auto = !m.selectedTool.Auto
+		if err := SaveAutoOverride(m.selectedTool.Key(), m.selectedTool.Auto); err != nil {
+			m.statusMessage = fmt.Sprintf("Could not save auto-approval: %v", err)
+		} else {
+			m.statusMessage = m.selectedTool.AutoLabel()
+		}
+		return nil
+	}},
+	{"refresh", "reload the inventory; other views reload their own data", "Tools", func(k *KeyMap) *key.Binding { return &k.Refresh }, inList, (*Model).refreshCatalog},
+	{"favorite", "add or remove the tool from the favorites", "Tools", func(k *KeyMap) *key.Binding { return &k.Favorite }, func(m Model) bool { return inDetail(m) || (inList(m) && m.cursorTool() != nil) }, func(m *Model) tea.Cmd {
+		if !m.detailMode {
+			return m.showToast(m.toggleFavorite(m.cursorTool()))
+		}
+		m.statusMessage = m.toggleFavorite(m.selectedTool)
+		return nil
+	}},
+	{"output_mode", "cycle the tool's output between normal, quiet and verbose", "Tools", func(k *KeyMap) *key.Binding { return &k.OutputMode }, func(m Model) bool { return inDetail(m) || (inList(m) && m.cursorTool() != nil) }, func(m *Model) tea.Cmd {
+		if !m.detailMode {
+			return m.showToast(m.cycleOutputMode(m.cursorTool()))
+		}
+		m.statusMessage = m.cycleOutputMode(m.selectedTool)
+		return nil
+	}},
+	{"inapplicable", "show or hide inapplicable tools", "Tools", func(k *KeyMap) *key.Binding { return &k.Inapplicable }, inList, func(m *Model) tea.Cmd {
+		m.showInapplicable = !m.showInapplicable
+		if visible := m.visibleTools(m.categories[m.currentCat]); m.currentTool >= visible && visible > 0 {
+			m.currentTool = visible - 1
+		}
+		return nil
+	}},
+	{"sort_tools", "sort the category by catalog order, name, status, last or most used", "Tools", func(k *KeyMap) *key.Binding { return &k.SortTools }, inList, func(m *Model) tea.Cmd {
+		return m.showToast(m.cycleToolSort())
+	}},
+	{"project", "select project", "Tools", func(k *KeyMap) *key.Binding { return &k.Project }, inList, func(m *Model) tea.Cmd {
+		m.openProjectPicker()
+		return nil
+	}},
+
+	{"verify", "verify extension checksums", "Extensions", func(k *KeyMap) *key.Binding { return &k.Verify }, inDetail, func(m *Model) tea.Cmd {
+		m.verifySelectedTool()
+		return nil
+	}},
+	{"rollback", "roll back extension", "Extensions", func(k *KeyMap) *key.Binding { return &k.Rollback }, inDetail, func(m *Model) tea.Cmd {
+		m.rollbackSelectedTool()
+		return nil
+	}},
+	{"install", "install extension", "Extensions", func(k *KeyMap) *key.Binding { return &k.Install }, inDetail, func(m *Model) tea.Cmd {
+		return m.installSelectedTool()
+	}},
+	{"upgrade", "upgrade extension", "Extensions", func(k *KeyMap) *key.Binding { return &k.Upgrade }, inDetail, func(m *Model) tea.Cmd {
+		return m.upgradeSelectedTool()
+	}},
+	{"changelog", "release notes", "Extensions", func(k *KeyMap) *key.Binding { return &k.Changelog }, inDetail, func(m *Model) tea.Cmd {
+		dir, ok := ExtensionDir(m.selectedTool)
+		if !ok {
+			m.statusMessage = "Not an extension, no release notes"
+			return nil
+		}
+		m.statusMessage = "Fetching release notes..."
+		return fetchChangelogCmd(m.selectedTool.Name, dir)
+	}},
+
+	{"notes", "project notes", "Notes", func(k *KeyMap) *key.Binding { return &k.Notes }, notSearching, (*Model).openNotes},
+	{"append_note", "append output to notes", "Notes", func(k *KeyMap) *key.Binding { return &k.AppendNote }, inDetail, func(m *Model) tea.Cmd {
+		if m.commandOutput == "" {
+			m.statusMessage = "Run the tool first, there is no output to append"
+		} else if err := AppendNoteSnippet(m.selectedTool, m.commandOutput); err != nil {
+			m.statusMessage = fmt.Sprintf("Could not append to notes: %v", err)
+		} else {
+			m.statusMessage = "Command and output appended to notes"
+		}
+		return nil
+	}},
+	{"annotate", "edit annotation", "Notes", func(k *KeyMap) *key.Binding { return &k.Annotate }, inDetail, func(m *Model) tea.Cmd {
+		m.annotating = true
+		m.annotateTeam = false
+		m.annotation.SetValue(m.selectedTool.Annotation)
+		m.annotation.CursorEnd()
+		return m.annotation.Focus()
+	}},
+
+	{"footprint", "disk footprint", "Disk footprint", func(k *KeyMap) *key.Binding { return &k.Footprint }, inList, (*Model).openFootprint},
+	{"sort", "cycle sort", "Disk footprint", func(k *KeyMap) *key.Binding { return &k.Sort }, nil, nil},
+	{"clean", "clean caches, or clear the cookies of the request builder", "Disk footprint", func(k *KeyMap) *key.Binding { return &k.Clean }, nil, nil},
+
+	{"maintenance", "maintenance", "Maintenance", func(k *KeyMap) *key.Binding { return &k.Maintenance }, inList, (*Model).openMaintenance},
+	{"delete", "delete selected", "Maintenance", func(k *KeyMap) *key.Binding { return &k.Delete }, nil, nil},
+
+	{"files", "file manager", "File manager", func(k *KeyMap) *key.Binding { return &k.Files }, inList, func(m *Model) tea.Cmd {
+		m.openFileManager()
+		return nil
+	}},
+	{"file_copy", "copy to other pane", "File manager", func(k *KeyMap) *key.Binding { return &k.FileCopy }, nil, nil},
+	{"file_move", "move to other pane", "File manager", func(k *KeyMap) *key.Binding { return &k.FileMove }, nil, nil},
+	{"file_rename", "rename", "File manager", func(k *KeyMap) *key.Binding { return &k.FileRename }, nil, nil},
+	{"unpack", "unpack archive into extensions/", "File manager", func(k *KeyMap) *key.Binding { return &k.Unpack }, nil, nil},
+	{"package", "package directory", "File manager", func(k *KeyMap) *key.Binding { return &k.Package }, nil, nil},
+
+	{"history", "execution history", "History", func(k *KeyMap) *key.Binding { return &k.History }, inList, func(m *Model) tea.Cmd {
+		m.openHistory()
+		return nil
+	}},
+	{"range", "cycle time range", "History", func(k *KeyMap) *key.Binding { return &k.Range }, nil, nil},
+	{"filter", "filter by tool", "History", func(k *KeyMap) *key.Binding { return &k.Filter }, nil, nil},
+	{"compare", "compare run environments", "History", func(k *KeyMap) *key.Binding { return &k.Compare }, nil, nil},
+	{"diff_output", "compare the output of a run with the previous run of its tool", "History", func(k *KeyMap) *key.Binding { return &k.DiffOutput }, nil, nil},
+	{"promote", "save the run's arguments as a named example of its tool", "History", func(k *KeyMap) *key.Binding { return &k.Promote }, nil, nil},
+
+	{"workflows", "workflows", "Workflows", func(k *KeyMap) *key.Binding { return &k.Workflows }, inList, func(m *Model) tea.Cmd {
+		m.openWorkflows()
+		return nil
+	}},
+	{"scheduled", "Scheduled: tools and workflows with a schedule, their next runs and the result of the last one", "Workflows", func(k *KeyMap) *key.Binding { return &k.Scheduled }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openScheduled},
+	{"retry", "retry failed steps", "Workflows", func(k *KeyMap) *key.Binding { return &k.Retry }, nil, nil},
+
+	{"panes", "output panes of running and recent jobs", "Panes", func(k *KeyMap) *key.Binding { return &k.Panes }, notSearching, (*Model).openPanes},
+	{"next_pane", "focus next pane", "Panes", func(k *KeyMap) *key.Binding { return &k.NextPane }, nil, nil},
+	{"close_pane", "close finished pane", "Panes", func(k *KeyMap) *key.Binding { return &k.ClosePane }, nil, nil},
+	{"tasks", "task list of queued, running and finished jobs, and those of a running daemon", "Panes", func(k *KeyMap) *key.Binding { return &k.Tasks }, notSearching, (*Model).openTasks},
+	{"expand_log", "expand the mini log into its pane, from any view", "Panes", func(k *KeyMap) *key.Binding { return &k.ExpandLog }, func(m Model) bool { return m.miniLogJob() != nil }, func(m *Model) tea.Cmd {
+		return m.openPanesAt(m.miniLogJob())
+	}},
+	{"log_view", "view a job's output with colours, search and export", "Log", func(k *KeyMap) *key.Binding { return &k.LogView }, func(m Model) bool { return inDetail(m) && m.job(m.detailJob) != nil }, func(m *Model) tea.Cmd {
+		return m.openLog(m.job(m.detailJob))
+	}},
+	{"next_match", "next match", "Log", func(k *KeyMap) *key.Binding { return &k.NextMatch }, nil, nil},
+	{"previous_match", "previous match", "Log", func(k *KeyMap) *key.Binding { return &k.PrevMatch }, nil, nil},
+	{"wrap", "toggle line wrap", "Log", func(k *KeyMap) *key.Binding { return &k.Wrap }, nil, nil},
+	{"export", "export the output to a timestamped file", "Output", func(k *KeyMap) *key.Binding { return &k.Export }, hasOutput, func(m *Model) tea.Cmd {
+		m.exportDetailOutput()
+		return nil
+	}},
+	{"copy", "copy the output to the clipboard", "Output", func(k *KeyMap) *key.Binding { return &k.Copy }, hasOutput, func(m *Model) tea.Cmd {
+		return copyCmd(m.commandOutput)
+	}},
+
+	{"mcp", "MCP servers: list and call their tools", "MCP", func(k *KeyMap) *key.Binding { return &k.MCP }, inList, (*Model).openMCP},
+	{"server_toggle", "start or stop the tool's MCP server", "MCP", func(k *KeyMap) *key.Binding { return &k.ServerToggle }, providesServer, func(m *Model) tea.Cmd {
+		m.statusMessage = m.toggleServer(m.selectedTool.MCPServer)
+		return nil
+	}},
+	{"server_restart", "restart the tool's MCP server", "MCP", func(k *KeyMap) *key.Binding { return &k.ServerRestart }, providesServer, func(m *Model) tea.Cmd {
+		m.statusMessage = m.restartServer(m.selectedTool.MCPServer)
+		return nil
+	}},
+
+	{"github", "GitHub: open issues and pull requests, new issues with the output attached", "GitHub", func(k *KeyMap) *key.Binding { return &k.GitHub }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openGitHub},
+	{"new_issue", "new issue", "GitHub", func(k *KeyMap) *key.Binding { return &k.NewIssue }, nil, nil},
+	{"store_token", "store the panel's API token in the keyring", "GitHub", func(k *KeyMap) *key.Binding { return &k.StoreToken }, nil, nil},
+	{"linear", "Linear: my open issues, state changes, new issues from output or review findings", "Linear", func(k *KeyMap) *key.Binding { return &k.Linear }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openLinear},
+	{"change_state", "move the Linear issue to another state", "Linear", func(k *KeyMap) *key.Binding { return &k.ChangeState }, nil, nil},
+	{"graphql", "explore Linear's GraphQL API in the request builder, with completion from its schema", "Linear", func(k *KeyMap) *key.Binding { return &k.GraphQL }, nil, nil},
+
+	{"git", "Git: branch, changed files, recent commits and branches of the project", "Git", func(k *KeyMap) *key.Binding { return &k.Git }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openGit},
+	{"commit_run", "commit the working tree with the last run's command and output as the message", "Git", func(k *KeyMap) *key.Binding { return &k.CommitRun }, nil, nil},
+
+	{"secrets", "Secrets: tokens per service in the keyring or a passphrase-encrypted vault file, injected into tool environments", "Secrets", func(k *KeyMap) *key.Binding { return &k.Secrets }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openSecrets},
+	{"rotate", "replace the value of the secret", "Secrets", func(k *KeyMap) *key.Binding { return &k.Rotate }, nil, nil},
+	{"import", "import the tokens of foss_token_manager.py, or the requests of a HAR file", "Secrets", func(k *KeyMap) *key.Binding { return &k.Import }, nil, nil},
+
+	{"http", "Requests: build and send HTTP requests, keep them in project collections with per-environment variables, replay the history and import or export HAR", "Requests", func(k *KeyMap) *key.Binding { return &k.HTTP }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openHTTP},
+	{"edit_request", "edit the request in the request builder", "Requests", func(k *KeyMap) *key.Binding { return &k.EditRequest }, nil, nil},
+	{"new_request", "write a new request", "Requests", func(k *KeyMap) *key.Binding { return &k.NewRequest }, nil, nil},
+	{"environment", "switch the environment whose values replace the {{variables}} of saved requests", "Requests", func(k *KeyMap) *key.Binding { return &k.Environment }, nil, nil},
+
+	{"memory", "Memory: sessions, nodes and tags of the hierarchical memory database", "Memory", func(k *KeyMap) *key.Binding { return &k.Memory }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openMemory},
+	{"memory_relations", "list the relations of the selected memory node and follow them", "Memory", func(k *KeyMap) *key.Binding { return &k.Graph }, nil, nil},
+	{"mark_node", "mark a memory node for a bulk edit", "Memory", func(k *KeyMap) *key.Binding { return &k.Mark }, nil, nil},
+	{"tag_nodes", "add and remove tags of the marked memory nodes", "Memory", func(k *KeyMap) *key.Binding { return &k.Tag }, nil, nil},
+	{"move_nodes", "move the marked memory nodes under the selected one", "Memory", func(k *KeyMap) *key.Binding { return &k.Reparent }, nil, nil},
+	{"merge_nodes", "merge the marked memory nodes into the selected one", "Memory", func(k *KeyMap) *key.Binding { return &k.MergeNodes }, nil, nil},
+	{"undo_memory", "undo the latest bulk edit of the memory database", "Memory", func(k *KeyMap) *key.Binding { return &k.Undo }, nil, nil},
+	{"review_suggestions", "review the tags auto-organization suggested", "Memory", func(k *KeyMap) *key.Binding { return &k.Review }, nil, nil},
+	{"reject_suggestion", "reject the selected tag suggestion", "Memory", func(k *KeyMap) *key.Binding { return &k.Reject }, nil, nil},
+	{"memory_stats", "size, growth, tags and orphans of the memory database", "Memory", func(k *KeyMap) *key.Binding { return &k.Stats }, nil, nil},
+	{"retention", "delete the conversation turns older than the retention", "Memory", func(k *KeyMap) *key.Binding { return &k.Retention }, nil, nil},
+	{"vacuum", "vacuum the memory database", "Memory", func(k *KeyMap) *key.Binding { return &k.Vacuum }, nil, nil},
+	{"ai_sessions", "AI Sessions: BM25 search across local Claude Code, Gemini CLI, Codex and OpenCode sessions and the memory databases", "Memory", func(k *KeyMap) *key.Binding { return &k.AISessions }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openAISessions},
+
+	{"events", "Events: webhook deliveries received with --listen, run the tool a rule maps them to", "General", func(k *KeyMap) *key.Binding { return &k.Events }, func(m Model) bool { return inList(m) || inDetail(m) }, (*Model).openEvents},
+	{"health", "details of the problems in the warning banner, from any view", "General", func(k *KeyMap) *key.Binding { return &k.Health }, func(m Model) bool { return len(m.healthProblems()) > 0 }, (*Model).openHealth},
+	{"help", "cheat sheet", "General", func(k *KeyMap) *key.Binding { return &k.Help }, notSearching, func(m *Model) tea.Cmd {
+		m.openCheatSheet()
+		return nil
+	}},
+	{"tour", "replay the tour", "General", func(k *KeyMap) *key.Binding { return &k.Tour }, nil, func(m *Model) tea.Cmd {
+		m.startTour()
+		return nil
+	}},
+	{"quit", "quit", "General", func(k *KeyMap) *key.Binding { return &k.Quit }, nil, func(m *Model) tea.Cmd {
+		return tea.Quit
+	}},
+}
+
+// findAction returns the action with the given name
+func findAction(name string) (action, bool) {
+	for _, a := range actions {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return action{}, false
+}
+
+// dispatchAction runs the first available action bound to msg. It
+// reports false when no action handled the key.
+func (m *Model) dispatchAction(msg tea.KeyMsg) (tea.Cmd, bool) {
+	for _, a := range actions {
+		if key.Matches(msg, *a.binding(&m.keys)) && a.isAvailable(*m) {
+			return a.run(m), true
+		}
+	}
+	return nil, false
+}
+
+// runMacro runs the actions of a macro in order, stopping at the first
+// one that is unknown or not available
+func (m *Model) runMacro(name string, steps []string) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, step := range steps {
+		a, ok := findAction(step)
+		if !ok || !a.isAvailable(*m) {
+			m.statusMessage = fmt.Sprintf("Macro %s stopped: %s is not available here", name, step)
+			break
+		}
+		cmds = append(cmds, a.run(m))
+	}
+	return tea.Batch(cmds...)
+}
+
+// validateMacros reports macros that use unknown actions
+func validateMacros(macros map[string][]string) error {
+	var problems []string
+	for name, steps := range macros {
+		for _, step := range steps {
+			if a, ok := findAction(step); !ok || a.run == nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, step))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("macros use unknown actions: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
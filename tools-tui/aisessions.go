@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// aiMessage is a turn of an AI coding session
+type aiMessage struct {
+	Role string
+	Text string
+	Time time.Time
+}
+
+// aiSession is a conversation with an AI coding assistant read from its
+// local session files
+type aiSession struct {
+	// Source is the assistant: claude, gemini, codex or opencode, or the
+	// memory database: memory or hierarchical
+	Source   string
+	ID       string
+	Path     string
+	Project  string
+	Title    string
+	Started  time.Time
+	Updated  time.Time
+	Messages []aiMessage
+}
+
+// sessionSource finds the session files of an assistant under its data
+// directory and reads one
+type sessionSource struct {
+	Name  string
+	Dir   func(home string) string
+	Files func(dir string) []string
+	Read  func(path string) (*aiSession, error)
+}
+
+// sessionSources are the assistants whose sessions are indexed
+var sessionSources = []sessionSource{
+	{"claude", claudeDir, func(dir string) []string { return globFiles(filepath.Join(dir, "projects", "*", "*.jsonl")) }, readClaudeSession},
+	{"gemini", func(home string) string { return filepath.Join(home, ".gemini") }, func(dir string) []string { return globFiles(filepath.Join(dir, "tmp", "*", "chats", "*.json")) }, readGeminiSession},
+	{"codex", codexDir, func(dir string) []string { return walkFiles(filepath.Join(dir, "sessions"), ".jsonl") }, readCodexSession},
+	{"opencode", opencodeDir, func(dir string) []string { return globFiles(filepath.Join(dir, "storage", "session", "*", "*.json")) }, readOpencodeSession},
+}
+
+// claudeDir is Claude Code's data directory, $CLAUDE_CONFIG_DIR when set
+func claudeDir(home string) string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// codexDir is Codex's data directory, $CODEX_HOME when set
+func codexDir(home string) string {
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".codex")
+}
+
+// opencodeDir is OpenCode's data directory under $XDG_DATA_HOME
+func opencodeDir(home string) string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "opencode")
+	}
+	return filepath.Join(home, ".local", "share", "opencode")
+}
+
+// globFiles returns the files matching pattern
+func globFiles(pattern string) []string {
+	paths, _ := filepath.Glob(pattern)
+	return paths
+}
+
+// walkFiles returns the files under dir with the extension ext
+func walkFiles(dir, ext string) []string {
+	var paths []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ext {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// eachJSONLine calls fn with every line of a JSON Lines file; lines
+// are read whole however long, as sessions embed images and tool output
+func eachJSONLine(path string, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			fn(line)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// contentText returns the text of a message content that is either a
+// string or a list of blocks, leaving out tool calls, tool results and
+// images
+func contentText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(raw, &blocks)
+	var parts []string
+	for _, block := range blocks {
+		switch block.Type {
+		case "text", "input_text", "output_text":
+			if block.Text != "" {
+				parts = append(parts, block.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// injected reports whether a user message was added by the assistant's
+// harness rather than typed, such as command output or instructions
+// wrapped in tags
+func injected(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "<")
+}
+
+// add appends a message and tracks the session's time span
+func (s *aiSession) add(role, text string, at time.Time) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	s.Messages = append(s.Messages, aiMessage{Role: role, Text: text, Time: at})
+	if !at.IsZero() && (s.Started.IsZero() || at.Before(s.Started)) {
+		s.Started = at
+	}
+	if at.After(s.Updated) {
+		s.Updated = at
+	}
+}
+
+// finish titles an untitled session after its first prompt
+func (s *aiSession) finish() {
+	if s.Title != "" {
+		return
+	}
+	for _, message := range s.Messages {
+		if message.Role == "user" {
+			s.Title = firstLine(message.Text)
+			return
+		}
+	}
+}
+
+// readClaudeSession reads a Claude Code transcript
+func readClaudeSession(path string) (*aiSession, error) {
+	s := &aiSession{Source: "claude", Path: path, ID: strings.TrimSuffix(filepath.Base(path), ".jsonl"), Project: filepath.Base(filepath.Dir(path))}
+	cwd := ""
+	err := eachJSONLine(path, func(line []byte) {
+		var entry struct {
+			Type      string    `json:"type"`
+			Summary   string    `json:"summary"`
+			Cwd       string    `json:"cwd"`
+			IsMeta    bool      `json:"isMeta"`
+			Timestamp time.Time `json:"timestamp"`
+			Message   struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if json.Unmarshal(line, &entry) != nil {
+			return
+		}
+		if cwd == "" && entry.Cwd != "" {
+			cwd = entry.Cwd
+		}
+		switch entry.Type {
+		case "summary":
+			s.Title = entry.Summary
+		case "user", "assistant":
+			text := contentText(entry.Message.Content)
+			if entry.IsMeta || (entry.Type == "user" && injected(text)) {
+				return
+			}
+			s.add(entry.Type, text, entry.Timestamp)
+		}
+	})
+	if cwd != "" {
+		s.Project = cwd
+	}
+	s.finish()
+	return s, err
+}
+
+// readGeminiSession reads a saved Gemini CLI chat
+func readGeminiSession(path string) (*aiSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var chat struct {
+		SessionID string `json:"sessionId"`
+		Messages  []struct {
+			Type      string          `json:"type"`
+			Content   json.RawMessage `json:"content"`
+			Timestamp time.Time       `json:"timestamp"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return nil, err
+	}
+	s := &aiSession{Source: "gemini", Path: path, ID: chat.SessionID, Project: filepath.Base(filepath.Dir(filepath.Dir(path)))}
+	for _, message := range chat.Messages {
+		role := message.Type
+		switch role {
+		case "gemini", "model":
+			role = "assistant"
+		case "user":
+		default:
+			continue
+		}
+		s.add(role, contentText(message.Content), message.Timestamp)
+	}
+	s.finish()
+	return s, nil
+}
+
+// readCodexSession reads a Codex rollout
+func readCodexSession(path string) (*aiSession, error) {
+	s := &aiSession{Source: "codex", Path: path, ID: strings.TrimSuffix(filepath.Base(path), ".jsonl")}
+	err := eachJSONLine(path, func(line []byte) {
+		var entry struct {
+			Type      string    `json:"type"`
+			Timestamp time.Time `json:"timestamp"`
+			Payload   struct {
+				Type    string          `json:"type"`
+				ID      string          `json:"id"`
+				Cwd     string          `json:"cwd"`
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"payload"`
+		}
+		if json.Unmarshal(line, &entry) != nil {
+			return
+		}
+		switch {
+		case entry.Type == "session_meta":
+			s.ID, s.Project = valueOr(entry.Payload.ID, s.ID), entry.Payload.Cwd
+		case entry.Type == "response_item" && entry.Payload.Type == "message":
+			text := contentText(entry.Payload.Content)
+			if entry.Payload.Role == "user" && injected(text) {
+				return
+			}
+			if entry.Payload.Role == "user" || entry.Payload.Role == "assistant" {
+				s.add(entry.Payload.Role, text, entry.Timestamp)
+			}
+		}
+	})
+	s.finish()
+	return s, err
+}
+
+// readOpencodeSession reads an OpenCode session, whose messages and
+// their parts are stored as separate files next to it
+func readOpencodeSession(path string) (*aiSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		ID        string `json:"id"`
+		Title     string `json:"title"`
+		Directory string `json:"directory"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	s := &aiSession{Source: "opencode", Path: path, ID: info.ID, Title: info.Title, Project: info.Directory}
+	storage := filepath.Dir(filepath.Dir(filepath.Dir(path)))
+	type message struct {
+		ID   string `json:"id"`
+		Role string `json:"role"`
+		Time struct {
+			Created int64 `json:"created"`
+		} `json:"time"`
+	}
+	var messages []message
+	for _, file := range globFiles(filepath.Join(storage, "message", info.ID, "*.json")) {
+		var msg message
+		if data, err := os.ReadFile(file); err == nil && json.Unmarshal(data, &msg) == nil {
+			messages = append(messages, msg)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Time.Created < messages[j].Time.Created })
+	for _, msg := range messages {
+		var parts []string
+		for _, file := range globFiles(filepath.Join(storage, "part", msg.ID, "*.json")) {
+			var part struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			}
+			if data, err := os.ReadFile(file); err == nil && json.Unmarshal(data, &part) == nil && part.Type == "text" {
+				parts = append(parts, part.Text)
+			}
+		}
+		s.add(msg.Role, strings.Join(parts, "\n"), time.UnixMilli(msg.Time.Created))
+	}
+	s.finish()
+	return s, nil
+}
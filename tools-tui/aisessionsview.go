@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sessionListHits is how many hits are listed around the cursor, each
+// with its snippet
+const sessionListHits = 8
+
+// sessionIndexMsg carries a rebuilt index of the AI sessions
+type sessionIndexMsg struct{ index *sessionIndex }
+
+// aiSessionsView holds the state of the AI Sessions screen
+type aiSessionsView struct {
+	index    *sessionIndex
+	indexing bool
+	// databases are the memory databases searched with the sessions
+	databases []string
+	query     textinput.Model
+	// searching types the query; hits are ranked as it changes
+	searching bool
+	hits      []sessionHit
+	cursor    int
+	// open is the session shown in the transcript, with the messages
+	// holding query terms and the line each message starts on
+	open       *aiSession
+	matches    []int
+	match      int
+	offsets    []int
+	transcript viewport.Model
+	message    string
+}
+
+// buildSessionIndexCmd indexes the session files and memory databases
+// in the background
+func buildSessionIndexCmd(prev *sessionIndex, databases []string) tea.Cmd {
+	return func() tea.Msg {
+		return sessionIndexMsg{buildSessionIndex(prev, databases)}
+	}
+}
+
+// openAISessions shows the AI Sessions screen and refreshes its index,
+// which only reads the session files changed since the last time, with
+// the memory databases of the current project
+func (m *Model) openAISessions() tea.Cmd {
+	v := &m.aiSessions
+	v.databases = []string{basicMemoryPath(m.memoryConfig, m.projectDir()), memoryPath(m.memoryConfig, m.projectDir())}
+	query := newTextInput()
+	query.Prompt = ""
+	query.Placeholder = "words to rank sessions by"
+	query.Width = max(m.width-10, 20)
+	if v.index == nil {
+		v.query = query
+	} else {
+		v.rank()
+	}
+	v.open, v.message, v.indexing = nil, "", true
+	v.transcript = viewport.New(max(m.width-4, 20), max(m.height-8, 5))
+	m.screen = screenAISessions
+	return buildSessionIndexCmd(v.index, v.databases)
+}
+
+// rank runs the query against the index
+func (v *aiSessionsView) rank() {
+	v.hits = nil
+	if v.index != nil {
+		v.hits = v.index.Search(v.query.Value())
+	}
+	v.cursor = min(v.cursor, max(len(v.hits)-1, 0))
+}
+
+// openSession shows the transcript of the selected hit, scrolled to its
+// best matching message
+func (v *aiSessionsView) openSession() {
+	if v.cursor >= len(v.hits) {
+		return
+	}
+	hit := v.hits[v.cursor]
+	v.open = hit.Session
+	terms := queryTerms(v.query.Value())
+	v.matches, v.match = nil, 0
+	for i, message := range v.open.Messages {
+		if len(termPositions(message.Text, terms)) > 0 {
+			if i == hit.Message {
+				v.match = len(v.matches)
+			}
+			v.matches = append(v.matches, i)
+		}
+	}
+	v.renderTranscript(terms)
+	v.showMatch()
+}
+
+// renderTranscript lays the open session out in the viewport with the
+// query terms emphasised and matching messages marked in the gutter
+func (v *aiSessionsView) renderTranscript(terms []string) {
+	width := v.transcript.Width - 2
+	matched := map[int]bool{}
+	for _, i := range v.matches {
+		matched[i] = true
+	}
+	wrap := lipgloss.NewStyle().Width(width)
+	var content strings.Builder
+	v.offsets = make([]int, len(v.open.Messages))
+	row := 0
+	for i, message := range v.open.Messages {
+		gutter := "  "
+		if matched[i] {
+			gutter = featureStyle.Render("» ")
+		}
+		v.offsets[i] = row
+		heading := message.Role
+		if !message.Time.IsZero() {
+			heading += " · " + message.Time.Local().Format("2006-01-02 15:04")
+		}
+		content.WriteString(gutter + descriptionStyle.Bold(true).Render(heading) + "\n")
+		row++
+		text := highlightMatches(message.Text, runePositions(termPositions(message.Text, terms)), 0)
+		for _, line := range strings.Split(wrap.Render(text), "\n") {
+			content.WriteString("  " + line + "\n")
+			row++
+		}
+		content.WriteString("\n")
+		row++
+	}
+	v.transcript.SetContent(strings.TrimSuffix(content.String(), "\n"))
+}
+
+// showMatch scrolls to the current matching message
+func (v *aiSessionsView) showMatch() {
+	if len(v.matches) == 0 {
+		v.transcript.GotoTop()
+		return
+	}
+	v.transcript.SetYOffset(v.offsets[v.matches[v.match]])
+	v.message = fmt.Sprintf("Matching message %d of %d", v.match+1, len(v.matches))
+}
+
+// updateAISessions handles input on the AI Sessions screen
+func (m Model) updateAISessions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.aiSessions
+	if msg, ok := msg.(sessionIndexMsg); ok {
+		v.index, v.indexing = msg.index, false
+		v.rank()
+		if len(msg.index.Errors) > 0 {
+			v.message = fmt.Sprintf("Could not read %d session files, e.g. %s", len(msg.index.Errors), msg.index.Errors[0])
+		}
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if v.searching {
+		switch keyMsg.Type {
+		case tea.KeyEnter, tea.KeyEsc:
+			v.searching = false
+			v.query.Blur()
+			if keyMsg.Type == tea.KeyEsc {
+				v.query.SetValue("")
+				v.rank()
+			}
+			return m, nil
+		case tea.KeyUp, tea.KeyDown:
+			// browse the hits without leaving the query
+		default:
+			var cmd tea.Cmd
+			v.query, cmd = v.query.Update(keyMsg)
+			v.cursor = 0
+			v.rank()
+			return m, cmd
+		}
+	}
+
+	if v.open != nil {
+		switch {
+		case key.Matches(keyMsg, m.keys.Back):
+			v.open, v.message = nil, ""
+		case key.Matches(keyMsg, m.keys.NextMatch):
+			if len(v.matches) > 0 {
+				v.match = (v.match + 1) % len(v.matches)
+				v.showMatch()
+			}
+		case key.Matches(keyMsg, m.keys.PrevMatch):
+			if len(v.matches) > 0 {
+				v.match = (v.match - 1 + len(v.matches)) % len(v.matches)
+				v.showMatch()
+			}
+		default:
+			var cmd tea.Cmd
+			v.transcript, cmd = v.transcript.Update(keyMsg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		if v.query.Value() != "" {
+			v.query.SetValue("")
+			v.cursor = 0
+			v.rank()
+			return m, nil
+		}
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.hits)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Search):
+		v.searching = true
+		return m, v.query.Focus()
+	case key.Matches(keyMsg, m.keys.Enter):
+		v.openSession()
+	case key.Matches(keyMsg, m.keys.Refresh):
+		if !v.indexing {
+			v.indexing = true
+			return m, buildSessionIndexCmd(v.index, v.databases)
+		}
+	}
+	return m, nil
+}
+
+// sessionSnippet returns the part of the hit's best message around the
+// first query term, with the terms emphasised
+func sessionSnippet(hit sessionHit, terms []string, width int) string {
+	if hit.Message < 0 {
+		return ""
+	}
+	message := hit.Session.Messages[hit.Message]
+	text := strings.Join(strings.Fields(message.Text), " ")
+	return message.Role + ": " + highlightMatches(text, runePositions(termPositions(text, terms)), width)
+}
+
+// renderAISessions renders the query, the ranked sessions with their
+// snippets, or the transcript of the open one
+func (m Model) renderAISessions() string {
+	v := m.aiSessions
+	k := m.keys
+	var content strings.Builder
+	title := titleStyle.Render("🔎 AI Sessions")
+	summary := "indexing…"
+	if v.index != nil {
+		var sources []string
+		for name, count := range v.index.Counts {
+			sources = append(sources, fmt.Sprintf("%s %d", name, count))
+		}
+		sort.Strings(sources)
+		summary = fmt.Sprintf("%d sessions indexed", v.index.Size())
+		if len(sources) > 0 {
+			summary += " (" + strings.Join(sources, ", ") + ")"
+		}
+		if v.indexing {
+			summary += " · reindexing…"
+		}
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	if s := v.open; s != nil {
+		content.WriteString(descriptionStyle.Bold(true).Render(truncate(s.Title, max(m.width-4, 20))))
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render(fmt.Sprintf("%s · %s · %s", s.Source, s.Project, s.Path)))
+		content.WriteString("\n")
+		content.WriteString(v.transcript.View())
+		content.WriteString("\n")
+		if v.message != "" {
+			content.WriteString(featureStyle.Render(v.message))
+			content.WriteString("\n")
+		}
+		content.WriteString(footerStyle.Render(strings.Join([]string{hint("scroll", k.Up, k.Down), "pgup/pgdn: page", hint("next/previous match", k.NextMatch, k.PrevMatch), hint("back", k.Back)}, " | ")))
+		return content.String()
+	}
+
+	prompt := "🔍 " + v.query.View()
+	if v.searching || v.query.Value() != "" {
+		content.WriteString(commandStyle.Render(prompt))
+	} else {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("%s searches the sessions of Claude Code, Gemini CLI, Codex and OpenCode and the memory databases", primaryKey(k.Search))))
+	}
+	content.WriteString("\n\n")
+
+	terms := queryTerms(v.query.Value())
+	heading := "Recent sessions"
+	if len(terms) > 0 {
+		heading = fmt.Sprintf("Best matches (%d)", len(v.hits))
+	}
+	start := max(0, min(v.cursor-sessionListHits/2, len(v.hits)-sessionListHits))
+	end := min(start+sessionListHits, len(v.hits))
+	var lines []string
+	for _, hit := range v.hits[start:end] {
+		s := hit.Session
+		line := fmt.Sprintf("%-12s %-20s %-50s %s", s.Source, truncate(filepath.Base(s.Project), 20), truncate(s.Title, 50), s.Updated.Local().Format("2006-01-02 15:04"))
+		if len(terms) > 0 {
+			line += helpStyle.Render(fmt.Sprintf(" %.2f", hit.Score))
+		}
+		if snippet := sessionSnippet(hit, terms, max(m.width-16, 40)); snippet != "" && len(terms) > 0 {
+			line += "\n    " + snippet
+		}
+		lines = append(lines, line)
+	}
+	if v.index == nil {
+		content.WriteString(statusStyle.Render("Reading session files…"))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(renderMCPList(heading, lines, v.cursor-start, true))
+	}
+	content.WriteString("\n")
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	hints := []string{hint("select", k.Up, k.Down), hint("search", k.Search), hint("open", k.Enter), hint("reindex", k.Refresh), hint("back", k.Back)}
+	if v.searching {
+		hints = []string{"type to rank", "↑/↓: select", "enter: done", "esc: clear"}
+	}
+	content.WriteString(footerStyle.Render(strings.Join(hints, " | ")))
+	return content.String()
+}
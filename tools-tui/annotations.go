@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// annotationsFile stores free-form notes attached to tools and runs
+const annotationsFile = "annotations.json"
+
+// Annotations holds user notes keyed by tool name and by run id
+type Annotations struct {
+	Tools map[string]string `json:"tools"`
+	Runs  map[string]string `json:"runs"`
+}
+
+// LoadAnnotations reads all saved annotations
+func LoadAnnotations() Annotations {
+	a := Annotations{}
+	loadJSON(annotationsFile, &a)
+	if a.Tools == nil {
+		a.Tools = map[string]string{}
+	}
+	if a.Runs == nil {
+		a.Runs = map[string]string{}
+	}
+	return a
+}
+
+// save writes the annotations back to disk
+func (a Annotations) save() error {
+	return saveJSON(annotationsFile, a)
+}
+
+// SetToolAnnotation stores (or clears, when empty) the annotation of the
+// tool with the given key
+func SetToolAnnotation(toolName, text string) error {
+	a := LoadAnnotations()
+	if text = strings.TrimSpace(text); text == "" {
+		delete(a.Tools, toolName)
+	} else {
+		a.Tools[toolName] = text
+	}
+	return a.save()
+}
+
+// SetRunAnnotation stores (or clears, when empty) a run's annotation
+func SetRunAnnotation(runID, text string) error {
+	a := LoadAnnotations()
+	if text = strings.TrimSpace(text); text == "" {
+		delete(a.Runs, runID)
+	} else {
+		a.Runs[runID] = text
+	}
+	return a.save()
+}
+
+// applyAnnotations merges the team metadata committed to the repository
+// with the user's private annotations. Shared status overrides replace
+// the inventory status.
+func applyAnnotations(categories []Category) {
+	a := LoadAnnotations()
+	shared := map[string]SharedMetadata{}
+	for i := range categories {
+		for j := range categories[i].Tools {
+			tool := &categories[i].Tools[j]
+			tool.Annotation = a.Tools[tool.Key()]
+			meta, ok := shared[tool.WorkDir()]
+			if !ok {
+				meta, _ = LoadSharedMetadata(tool.WorkDir())
+				shared[tool.WorkDir()] = meta
+			}
+			entry := meta.Tools[tool.Name]
+			tool.SharedAnnotation = entry.Annotation
+			if entry.Status != "" {
+				tool.Status = entry.Status
+			}
+		}
+	}
+}
+
+// SharedToolMetadata is the team-wide metadata kept for one tool
+type SharedToolMetadata struct {
+	Annotation string `json:"annotation,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+// SharedMetadata is committed to the repository so annotations and
+// status overrides travel with git. Keys are written sorted, one entry
+// per block, which keeps merges between teammates clean.
+type SharedMetadata struct {
+	Tools map[string]SharedToolMetadata `json:"tools"`
+}
+
+// sharedMetadataPath is the metadata file inside a repository
+func sharedMetadataPath(root string) string {
+	return filepath.Join(root, ".opencode", "catalog-metadata.json")
+}
+
+// LoadSharedMetadata reads the metadata file of the repository at root
+func LoadSharedMetadata(root string) (SharedMetadata, error) {
+	meta := SharedMetadata{Tools: map[string]SharedToolMetadata{}}
+	data, err := os.ReadFile(sharedMetadataPath(root))
+	if os.IsNotExist(err) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("%s: %w", sharedMetadataPath(root), err)
+	}
+	if meta.Tools == nil {
+		meta.Tools = map[string]SharedToolMetadata{}
+	}
+	return meta, nil
+}
+
+// SetSharedAnnotation stores (or clears) a team annotation for a tool in
+// the metadata file of the tool's repository
+func SetSharedAnnotation(tool *Tool, text string) error {
+	root, toolName := tool.WorkDir(), tool.Name
+	meta, err := LoadSharedMetadata(root)
+	if err != nil {
+		return err
+	}
+	entry := meta.Tools[toolName]
+	entry.Annotation = strings.TrimSpace(text)
+	if entry == (SharedToolMetadata{}) {
+		delete(meta.Tools, toolName)
+	} else {
+		meta.Tools[toolName] = entry
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sharedMetadataPath(root)), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileContent(sharedMetadataPath(root), string(data)+"\n")
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tools-tui/control"
+)
+
+// apiCategory is a category of the catalog as the REST API lists it
+type apiCategory struct {
+	Name    string     `json:"name"`
+	Purpose string     `json:"purpose"`
+	Tools   []toolInfo `json:"tools"`
+}
+
+// apiServer exposes the catalog, the jobs of a control server and the
+// run history over HTTP, for automation and web clients that cannot
+// use the control socket, and lets admins manage the daemon
+type apiServer struct {
+	jobs   *controlServer
+	daemon *daemon
+	access AccessConfig
+}
+
+// newAPIMux registers the endpoints of the REST API. Reading needs the
+// viewer role, starting and cancelling jobs the operator role, editing
+// the inventory and managing MCP servers the admin role.
+func newAPIMux(d *daemon, access AccessConfig) *http.ServeMux {
+	a := apiServer{jobs: d.server, daemon: d, access: access}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/whoami", access.handleWhoami)
+	mux.HandleFunc("/api/status", access.requireRole(viewRole, a.handleStatus))
+	mux.HandleFunc("/api/categories", access.requireRole(viewRole, a.handleCategories))
+	mux.HandleFunc("/api/tools", access.requireRole(viewRole, a.handleTools))
+	mux.HandleFunc("/api/tools/", access.requireRole(viewRole, a.handleTool))
+	mux.HandleFunc("/api/jobs", a.handleJobs)
+	mux.HandleFunc("/api/jobs/", a.handleJob)
+	mux.HandleFunc("/api/history", access.requireRole(viewRole, a.handleHistory))
+	mux.HandleFunc("/api/inventory", access.requireRole(adminRole, a.handleInventory))
+	mux.HandleFunc("/api/servers", access.requireRole(viewRole, a.handleServers))
+	mux.HandleFunc("/api/servers/", access.requireRole(adminRole, a.handleServer))
+	return mux
+}
+
+// writeJSON answers with v as JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// writeError answers with an error as JSON
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// allowMethods answers 405 unless the request uses one of methods
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed here", r.Method))
+	return false
+}
+
+// handleStatus answers GET /api/status with the status of the server
+func (a apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if allowMethods(w, r, http.MethodGet) {
+		writeJSON(w, http.StatusOK, a.jobs.status())
+	}
+}
+
+// handleCategories answers GET /api/categories with the catalog
+func (a apiServer) handleCategories(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	categories := a.jobs.catalog()
+	result := make([]apiCategory, len(categories))
+	for i := range categories {
+		result[i] = apiCategory{Name: categories[i].Name, Purpose: categories[i].Purpose, Tools: []toolInfo{}}
+		for j := range categories[i].Tools {
+			result[i].Tools = append(result[i].Tools, newToolInfo(categories[i].Name, &categories[i].Tools[j]))
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleTools answers GET /api/tools with every tool, like the list
+// subcommand; ?category= keeps the categories containing its text
+func (a apiServer) handleTools(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	category := strings.ToLower(r.URL.Query().Get("category"))
+	categories := a.jobs.catalog()
+	tools := []toolInfo{}
+	for i := range categories {
+		if category != "" && !strings.Contains(strings.ToLower(categories[i].Name), category) {
+			continue
+		}
+		for j := range categories[i].Tools {
+			tools = append(tools, newToolInfo(categories[i].Name, &categories[i].Tools[j]))
+		}
+	}
+	writeJSON(w, http.StatusOK, tools)
+}
+
+// handleTool answers GET /api/tools/<key or name> with one tool
+func (a apiServer) handleTool(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/api/tools/")
+	categories := a.jobs.catalog()
+	tool, err := lookupTool(categories, name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	for i := range categories {
+		for j := range categories[i].Tools {
+			if &categories[i].Tools[j] == tool {
+				writeJSON(w, http.StatusOK, newToolInfo(categories[i].Name, tool))
+				return
+			}
+		}
+	}
+}
+
+// handleJobs answers GET /api/jobs with every job and POST /api/jobs,
+// whose body is a control.Submit, by starting one
+func (a apiServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		a.access.requireRole(viewRole, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, a.jobs.states())
+		})(w, r)
+		return
+	}
+	a.access.requireRole(executeRole, func(w http.ResponseWriter, r *http.Request) {
+		var submit control.Submit
+		if err := json.NewDecoder(r.Body).Decode(&submit); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("the body must be a JSON submit: %v", err))
+			return
+		}
+		if submit.Tool == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("submit needs a tool"))
+			return
+		}
+		job, err := a.jobs.submit(submit)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/api/jobs/%d", job.ID))
+		writeJSON(w, http.StatusCreated, job)
+	})(w, r)
+}
+
+// handleJob answers /api/jobs/<id>: GET with the job, DELETE by
+// cancelling it, and GET /api/jobs/<id>/events with its output
+func (a apiServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/jobs/")
+	idText, sub, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idText)
+	if err != nil || (sub != "" && sub != "events") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("expected /api/jobs/<id> or /api/jobs/<id>/events"))
+		return
+	}
+	if sub == "events" {
+		if allowMethods(w, r, http.MethodGet) {
+			a.access.requireStreamRole(viewRole, func(w http.ResponseWriter, r *http.Request) {
+				a.streamJob(w, r, id)
+			})(w, r)
+		}
+		return
+	}
+	if !allowMethods(w, r, http.MethodGet, http.MethodDelete) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		a.access.requireRole(viewRole, func(w http.ResponseWriter, r *http.Request) {
+			job, err := a.jobs.state(id)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, job)
+		})(w, r)
+		return
+	}
+	a.access.requireRole(executeRole, func(w http.ResponseWriter, r *http.Request) {
+		job, err := a.jobs.cancel(id)
+		switch {
+		case err != nil && job.ID == 0:
+			writeError(w, http.StatusNotFound, err)
+		case err != nil:
+			writeError(w, http.StatusConflict, err)
+		default:
+			writeJSON(w, http.StatusAccepted, job)
+		}
+	})(w, r)
+}
+
+// streamJob sends the output of a job as server-sent events: "output"
+// events carry chunks of output as JSON strings until a "done" event
+// carries the finished job. With ?follow=0 it stops at the output
+// written so far.
+func (a apiServer) streamJob(w http.ResponseWriter, r *http.Request, id int) {
+	if _, err := a.jobs.state(id); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	event := func(name string, v interface{}) error {
+		data, _ := json.Marshal(v)
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return r.Context().Err()
+	}
+	req := control.Request{Method: control.MethodLogs, Job: id, Follow: r.URL.Query().Get("follow") != "0"}
+	a.jobs.logs(req, func(resp control.Response) error {
+		if resp.Error != "" {
+			return event("error", resp.Error)
+		}
+		if resp.Output != "" {
+			if err := event("output", resp.Output); err != nil {
+				return err
+			}
+		}
+		if resp.Done {
+			return event("done", resp.Job)
+		}
+		return nil
+	})
+}
+
+// handleHistory answers GET /api/history with the recorded runs, newest
+// first. ?tool= keeps the runs of a tool, ?since= and ?until= take the
+// bounds of the history subcommand and ?limit= caps the count.
+func (a apiServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	now := time.Now()
+	since, err := parseTimeBound(query.Get("since"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	until, err := parseTimeBound(query.Get("until"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit := 0
+	if text := query.Get("limit"); text != "" {
+		if limit, err = strconv.Atoi(text); err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", text))
+			return
+		}
+	}
+	records, err := LoadHistory()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	tool := query.Get("tool")
+	runs := []RunRecord{}
+	records = filterHistory(records, since, until)
+	for i := len(records) - 1; i >= 0 && (limit == 0 || len(runs) < limit); i-- {
+		if tool == "" || strings.EqualFold(records[i].Tool, tool) {
+			runs = append(runs, records[i])
+		}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxInventoryBody bounds the manifest an admin may upload
+const maxInventoryBody = 4 << 20
+
+// apiMCPServer is an MCP server of the daemon as the REST API lists it
+type apiMCPServer struct {
+	Name     string      `json:"name"`
+	Remote   bool        `json:"remote"`
+	State    ServerState `json:"state"`
+	PID      int         `json:"pid,omitempty"`
+	Started  *time.Time  `json:"started,omitempty"`
+	Restarts int         `json:"restarts,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// handleInventory answers GET /api/inventory with the inventory
+// manifest, the built-in one when there is none, and PUT by replacing
+// it with the body once it validates. The daemon reloads the catalog
+// right away.
+func (a apiServer) handleInventory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+	path := manifestPath()
+	if r.Method == http.MethodGet {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			data, err = builtinInventory, nil
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxInventoryBody+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(data) > maxInventoryBody {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("the manifest is larger than %s", formatBytes(maxInventoryBody)))
+		return
+	}
+	categories, err := parseManifest(path, data)
+	var manifestErr *ManifestError
+	if errors.As(err, &manifestErr) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": "the manifest is invalid", "problems": manifestErr.Problems})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := replaceFile(path, data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.daemon.watch()
+	tools := 0
+	for _, category := range categories {
+		tools += len(category.Tools)
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"categories": len(categories), "tools": tools})
+}
+
+// replaceFile writes data to path through a temporary file, so readers
+// never see half a manifest
+func replaceFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, bytes.NewReader(data)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// handleServers answers GET /api/servers with the configured MCP
+// servers and the state of those the daemon runs
+func (a apiServer) handleServers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	configured := a.daemon.mcpServers()
+	servers := []apiMCPServer{}
+	for name, cfg := range configured {
+		status := a.daemon.servers.Status(name)
+		server := apiMCPServer{Name: name, Remote: cfg.URL != "", State: status.State, PID: status.PID, Restarts: status.Restarts, Error: status.Error}
+		if !status.Started.IsZero() {
+			server.Started = &status.Started
+		}
+		servers = append(servers, server)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	writeJSON(w, http.StatusOK, servers)
+}
+
+// handleServer answers POST /api/servers/<name>/start, /stop and
+// /restart by managing that MCP server's process
+func (a apiServer) handleServer(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/servers/"), "/")
+	if name == "" || (action != "start" && action != "stop" && action != "restart") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("expected /api/servers/<name>/start, stop or restart"))
+		return
+	}
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	cfg, ok := a.daemon.mcpServers()[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s is not configured in config.json or %s", name, mcpSettingsFile))
+		return
+	}
+	var err error
+	switch action {
+	case "start":
+		err = a.daemon.servers.Start(name, cfg)
+	case "stop":
+		err = a.daemon.servers.Stop(name)
+	case "restart":
+		err = a.daemon.servers.Restart(name, cfg)
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"name": name, "state": a.daemon.servers.Status(name).State})
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// packageIgnoreFile lists extra exclude patterns inside an extension
+const packageIgnoreFile = ".packageignore"
+
+// defaultPackageExcludes are never shipped in a packaged extension
+var defaultPackageExcludes = []string{
+	".git", "node_modules", ".venv", "venv", "__pycache__", "*.pyc",
+	".cache", ".pytest_cache", ".mypy_cache", "*.log", ".DS_Store",
+}
+
+// IsArchive reports whether path has a supported archive extension
+func IsArchive(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// archiveBaseName strips the archive extension from a file name
+func archiveBaseName(path string) string {
+	name := filepath.Base(path)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// archiveEntry is a single file read from an archive
+type archiveEntry struct {
+	name string
+	mode os.FileMode
+	dir  bool
+	open func() (io.ReadCloser, error)
+}
+
+// ExtractExtension unpacks an archive into extensions/<name> and records
+// the unpacked content hash so later updates can be verified. A single
+// top-level directory inside the archive is stripped.
+func ExtractExtension(archive string) (string, error) {
+	dest := filepath.Join(extensionsDir(), archiveBaseName(archive))
+	if fileExists(dest) {
+		return dest, fmt.Errorf("%s already exists", dest)
+	}
+	if err := ExtractArchive(archive, dest); err != nil {
+		os.RemoveAll(dest)
+		return dest, err
+	}
+	if hash, err := HashDirectory(dest); err == nil {
+		RecordExtensionHash(dest, hash)
+	}
+	return dest, nil
+}
+
+// ExtractArchive unpacks a zip or tar.gz archive into dest
+func ExtractArchive(archive, dest string) error {
+	var entries []archiveEntry
+
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			f := f
+			entries = append(entries, archiveEntry{
+				name: f.Name,
+				mode: f.Mode(),
+				dir:  f.FileInfo().IsDir(),
+				open: func() (io.ReadCloser, error) { return f.Open() },
+			})
+		}
+		return writeEntries(entries, dest)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	// tar is a stream, so buffer each file before writing it out
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeDir {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, archiveEntry{
+			name: hdr.Name,
+			mode: os.FileMode(hdr.Mode).Perm(),
+			dir:  hdr.Typeflag == tar.TypeDir,
+			open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(string(data))), nil },
+		})
+	}
+	return writeEntries(entries, dest)
+}
+
+// writeEntries writes archive entries below dest, refusing paths that
+// would escape it and stripping a shared top-level directory.
+func writeEntries(entries []archiveEntry, dest string) error {
+	prefix := commonTopDir(entries)
+
+	for _, entry := range entries {
+		name := strings.TrimPrefix(filepath.ToSlash(entry.name), prefix)
+		if name == "" {
+			continue
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q escapes the destination", entry.name)
+		}
+
+		if entry.dir {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		rc, err := entry.open()
+		if err != nil {
+			return err
+		}
+		mode := entry.mode.Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+		if err != nil {
+			rc.Close()
+			return err
+		}
+		_, err = io.Copy(out, rc)
+		rc.Close()
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commonTopDir returns "dir/" when every entry lives under one directory
+func commonTopDir(entries []archiveEntry) string {
+	top := ""
+	for _, entry := range entries {
+		name := filepath.ToSlash(entry.name)
+		first, _, found := strings.Cut(name, "/")
+		if !found && !entry.dir {
+			return ""
+		}
+		if top == "" {
+			top = first
+		} else if top != first {
+			return ""
+		}
+	}
+	if top == "" {
+		return ""
+	}
+	return top + "/"
+}
+
+// loadPackageExcludes combines the default excludes with .packageignore
+func loadPackageExcludes(dir string) []string {
+	excludes := append([]string{}, defaultPackageExcludes...)
+	f, err := os.Open(filepath.Join(dir, packageIgnoreFile))
+	if err != nil {
+		return excludes
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			excludes = append(excludes, strings.TrimSuffix(line, "/"))
+		}
+	}
+	return excludes
+}
+
+// excluded reports whether a file or directory name matches a pattern
+func excluded(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageExtension writes dir into a distributable tar.gz under
+// <project>/dist and returns the archive path.
+func PackageExtension(dir string) (string, error) {
+	outDir := filepath.Join(defaultWorkDir, "dist")
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", err
+	}
+	out := filepath.Join(outDir, filepath.Base(dir)+".tar.gz")
+	excludes := loadPackageExcludes(dir)
+
+	f, err := os.Create(out)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	base := filepath.Base(dir)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && excluded(info.Name(), excludes) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(base, rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	return out, nil
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// argHistoryFile stores the values entered for command placeholders
+const argHistoryFile = "args.json"
+
+// maxArgHistory is how many values are remembered per placeholder
+const maxArgHistory = 10
+
+// placeholderPattern matches required <name> and optional [name]
+// placeholders; either may carry a default as <name:default>
+var placeholderPattern = regexp.MustCompile(`<([A-Za-z_][\w-]*)(?::([^>]*))?>|\[([A-Za-z_][\w-]*)(?::([^\]]*))?\]`)
+
+// placeholder is an argument a command expects from the user. Flag,
+// Switch, Choices and Help come from an argument schema.
+type placeholder struct {
+	Name     string
+	Default  string
+	Optional bool
+	Flag     string
+	Switch   bool
+	Choices  []string
+	Help     string
+}
+
+// parsePlaceholders lists the placeholders of a command in order,
+// each name once
+func parsePlaceholders(command string) []placeholder {
+	var result []placeholder
+	seen := map[string]bool{}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(command, -1) {
+		p := placeholder{Name: match[1], Default: match[2]}
+		if p.Name == "" {
+			p = placeholder{Name: match[3], Default: match[4], Optional: true}
+		}
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// substitutePlaceholders fills in the values of a command's
+// placeholders, quoted so each stays one argument. Optional
+// placeholders left empty are dropped.
+func substitutePlaceholders(command string, values map[string]string) string {
+	var words []string
+	for _, field := range strings.Fields(command) {
+		filled := placeholderPattern.ReplaceAllStringFunc(field, func(token string) string {
+			match := placeholderPattern.FindStringSubmatch(token)
+			if value := values[match[1]+match[3]]; value != "" {
+				return quoteArg(value)
+			}
+			return ""
+		})
+		if filled != "" {
+			words = append(words, filled)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// argValues returns the non-empty values, or nil when there are none
+func argValues(values map[string]string) map[string]string {
+	kept := map[string]string{}
+	for name, value := range values {
+		if value != "" {
+			kept[name] = value
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// ArgHistory maps tool keys to the values entered per placeholder,
+// most recent first
+type ArgHistory map[string]map[string][]string
+
+// LoadArgHistory reads the remembered placeholder values
+func LoadArgHistory() ArgHistory {
+	history := ArgHistory{}
+	loadJSON(argHistoryFile, &history)
+	return history
+}
+
+// Remember records the values used for a tool's placeholders
+func (h ArgHistory) Remember(toolKey string, values map[string]string) error {
+	if h[toolKey] == nil {
+		h[toolKey] = map[string][]string{}
+	}
+	for name, value := range values {
+		if value == "" {
+			continue
+		}
+		kept := []string{value}
+		for _, old := range h[toolKey][name] {
+			if old != value && len(kept) < maxArgHistory {
+				kept = append(kept, old)
+			}
+		}
+		h[toolKey][name] = kept
+	}
+	return saveJSON(argHistoryFile, h)
+}
+
+// argsForm asks for the values of a command's placeholders. Each field
+// starts with the last value used, or the placeholder's default.
+type argsForm struct {
+	active       bool
+	placeholders []placeholder
+	inputs       []textinput.Model
+	history      [][]string
+	historyPos   []int
+	focus        int
+	err          string
+	// note confirms a save without leaving the form
+	note string
+	// saving reads the name and description the values are saved under
+	// as an example; editing is the saved example the form was opened to
+	// change, which enter saves instead of running
+	saving      bool
+	editing     string
+	exampleName textinput.Model
+	exampleDesc textinput.Model
+}
+
+// openArgsForm shows the argument form for the selected tool. Values
+// preset by a re-run from the history take precedence.
+func (m *Model) openArgsForm(placeholders []placeholder) tea.Cmd {
+	history := LoadArgHistory()[m.selectedTool.Key()]
+	preset := m.presetArgs
+	m.presetArgs = nil
+	form := argsForm{active: true, placeholders: placeholders}
+	for _, p := range placeholders {
+		input := newTextInput()
+		input.Prompt = ""
+		input.Placeholder = p.Default
+		input.CharLimit = 500
+		input.Width = 50
+		past := history[p.Name]
+		if value, ok := preset[p.Name]; ok {
+			input.SetValue(value)
+		} else if len(past) > 0 {
+			input.SetValue(past[0])
+		} else {
+			input.SetValue(p.Default)
+		}
+		input.CursorEnd()
+		form.inputs = append(form.inputs, input)
+		form.history = append(form.history, past)
+		form.historyPos = append(form.historyPos, 0)
+	}
+	m.argsForm = form
+	m.statusMessage = ""
+	return m.argsForm.inputs[0].Focus()
+}
+
+// values returns the entered value of every placeholder
+func (f argsForm) values() map[string]string {
+	values := map[string]string{}
+	for i, p := range f.placeholders {
+		values[p.Name] = strings.TrimSpace(f.inputs[i].Value())
+	}
+	return values
+}
+
+// setFocus moves the cursor to field i
+func (f *argsForm) setFocus(i int) tea.Cmd {
+	f.inputs[f.focus].Blur()
+	f.focus = (i + len(f.inputs)) % len(f.inputs)
+	return f.inputs[f.focus].Focus()
+}
+
+// recall replaces the focused field with an older (delta 1) or newer
+// (delta -1) value from its history
+func (f *argsForm) recall(delta int) {
+	past := f.history[f.focus]
+	pos := f.historyPos[f.focus] + delta
+	if pos < 0 || pos >= len(past) {
+		return
+	}
+	f.historyPos[f.focus] = pos
+	f.inputs[f.focus].SetValue(past[pos])
+	f.inputs[f.focus].CursorEnd()
+}
+
+// cycle steps the focused field through its choices, or toggles it when
+// it is a switch. It reports false for fields taking free text.
+func (f *argsForm) cycle(delta int) bool {
+	p := f.placeholders[f.focus]
+	options := p.Choices
+	switch {
+	case p.Switch:
+		options = []string{"", switchOn}
+	case len(options) == 0:
+		return false
+	case p.Optional:
+		options = append([]string{""}, options...)
+	}
+	current := 0
+	for i, option := range options {
+		if option == strings.TrimSpace(f.inputs[f.focus].Value()) {
+			current = i
+		}
+	}
+	f.inputs[f.focus].SetValue(options[(current+delta+len(options))%len(options)])
+	f.inputs[f.focus].CursorEnd()
+	return true
+}
+
+// check validates the entered values, moving the cursor to the first
+// field that is wrong
+func (f *argsForm) check() (map[string]string, tea.Cmd, bool) {
+	values := f.values()
+	for i, p := range f.placeholders {
+		if err := p.checkArg(values[p.Name]); err != nil {
+			f.err = err.Error()
+			return nil, f.setFocus(i), false
+		}
+	}
+	return values, nil, true
+}
+
+// startSaving asks for the name and description to save the values
+// under, those of the edited example to begin with
+func (f *argsForm) startSaving(tool *Tool) tea.Cmd {
+	f.saving, f.err, f.note = true, "", ""
+	f.exampleName = newTextInput()
+	f.exampleName.Prompt = "Example name: "
+	f.exampleName.CharLimit = 80
+	f.exampleName.Width = 40
+	f.exampleDesc = newTextInput()
+	f.exampleDesc.Prompt = "Description:  "
+	f.exampleDesc.Placeholder = "what these arguments do"
+	f.exampleDesc.CharLimit = 200
+	f.exampleDesc.Width = 60
+	if example, ok := tool.example(f.editing); ok && f.editing != "" {
+		f.exampleName.SetValue(example.Name)
+		f.exampleDesc.SetValue(example.Description)
+	}
+	f.inputs[f.focus].Blur()
+	return f.exampleName.Focus()
+}
+
+// editExample opens the argument form filled with a saved example, to
+// save it again with other values, name or description
+func (m *Model) editExample(example Example) tea.Cmd {
+	m.presetArgs = m.selectedTool.exampleValues(example)
+	cmd := m.openArgsForm(m.selectedTool.placeholders())
+	m.argsForm.editing = example.Name
+	return cmd
+}
+
+// updateSaveExample handles keys while the values of the form are saved
+// as an example: tab moves between name and description, enter saves
+// and esc returns to the form
+func (m Model) updateSaveExample(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := &m.argsForm
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.saving = false
+		return m, f.inputs[f.focus].Focus()
+	case tea.KeyTab, tea.KeyShiftTab:
+		if f.exampleName.Focused() {
+			f.exampleName.Blur()
+			return m, f.exampleDesc.Focus()
+		}
+		f.exampleDesc.Blur()
+		return m, f.exampleName.Focus()
+	case tea.KeyEnter:
+		name := strings.TrimSpace(f.exampleName.Value())
+		if name == "" {
+			f.err = "The example needs a name"
+			return m, nil
+		}
+		if name != f.editing && !m.selectedTool.isSaved(name) {
+			if _, ok := m.selectedTool.example(name); ok {
+				f.err = fmt.Sprintf("The inventory already has an example %q", name)
+				return m, nil
+			}
+		}
+		tool := m.selectedTool
+		example := Example{Name: name, Description: strings.TrimSpace(f.exampleDesc.Value()), Args: f.values()}
+		err := SaveExample(tool.Key(), example)
+		if err == nil && f.editing != "" && f.editing != name {
+			err = DeleteExample(tool.Key(), f.editing)
+		}
+		if err != nil {
+			f.err = fmt.Sprintf("Could not save the example: %v", err)
+			return m, nil
+		}
+		tool.SavedExamples = LoadSavedExamples()[tool.Key()]
+		f.saving = false
+		if f.editing != "" {
+			f.active = false
+			m.openExamplePicker()
+			for i, other := range tool.examples() {
+				if other.Name == name {
+					m.examplePicker.cursor = i + 1
+				}
+			}
+			m.statusMessage = fmt.Sprintf("Saved example %q", name)
+			return m, nil
+		}
+		f.note = fmt.Sprintf("Saved as example %q, offered before the form from now on", name)
+		return m, f.inputs[f.focus].Focus()
+	}
+	var cmd tea.Cmd
+	if f.exampleName.Focused() {
+		f.exampleName, cmd = f.exampleName.Update(msg)
+	} else {
+		f.exampleDesc, cmd = f.exampleDesc.Update(msg)
+	}
+	f.err = ""
+	return m, cmd
+}
+
+// updateArgsForm handles keys while the argument form is shown: tab
+// moves between fields, up/down recall earlier values, left/right or
+// space pick a choice or toggle a switch, ctrl+s saves the values as an
+// example and enter runs
+func (m Model) updateArgsForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := &m.argsForm
+	if f.saving {
+		return m.updateSaveExample(msg)
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.active = false
+		m.statusMessage = "Execution cancelled"
+		return m, nil
+	case tea.KeyTab:
+		return m, f.setFocus(f.focus + 1)
+	case tea.KeyShiftTab:
+		return m, f.setFocus(f.focus - 1)
+	case tea.KeyUp:
+		f.recall(1)
+		return m, nil
+	case tea.KeyDown:
+		f.recall(-1)
+		return m, nil
+	case tea.KeyLeft, tea.KeyRight, tea.KeySpace:
+		delta := 1
+		if msg.Type == tea.KeyLeft {
+			delta = -1
+		}
+		if f.cycle(delta) {
+			f.err = ""
+			return m, nil
+		}
+	case tea.KeyCtrlS:
+		if _, cmd, ok := f.check(); !ok {
+			return m, cmd
+		}
+		return m, f.startSaving(m.selectedTool)
+	case tea.KeyEnter:
+		values, cmd, ok := f.check()
+		if !ok {
+			return m, cmd
+		}
+		if f.editing != "" {
+			return m, f.startSaving(m.selectedTool)
+		}
+		f.active = false
+		if err := LoadArgHistory().Remember(m.selectedTool.Key(), values); err != nil {
+			m.statusMessage = fmt.Sprintf("Could not save argument history: %v", err)
+		}
+		return m, m.confirmAndRun(m.selectedTool.fillCommand(values), values)
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	f.err, f.note = "", ""
+	return m, cmd
+}
+
+// renderArgsForm renders the placeholder fields and the resulting command
+func (m Model) renderArgsForm() string {
+	f := m.argsForm
+	var content strings.Builder
+	if f.editing != "" {
+		content.WriteString(descriptionStyle.Bold(true).Render(fmt.Sprintf("Arguments of example %q:\n", f.editing)))
+	} else {
+		content.WriteString(descriptionStyle.Bold(true).Render("Arguments:\n"))
+	}
+	for i, p := range f.placeholders {
+		line := fmt.Sprintf("%-18s %s", p.Label(), f.inputs[i].View())
+		if len(p.Choices) > 0 {
+			line += helpStyle.Render("  " + strings.Join(p.Choices, " | "))
+		}
+		if i == f.focus {
+			content.WriteString(selectedItemStyle.Render("▶ ") + line)
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	if help := f.placeholders[f.focus].Help; help != "" {
+		content.WriteString(descriptionStyle.Render(help))
+		content.WriteString("\n")
+	}
+	content.WriteString(commandStyle.Render("$ " + m.selectedTool.fillCommand(f.values())))
+	content.WriteString("\n")
+	if f.saving {
+		content.WriteString(commandStyle.Render("📖 " + f.exampleName.View()))
+		content.WriteString("\n")
+		content.WriteString(commandStyle.Render("   " + f.exampleDesc.View()))
+		content.WriteString("\n")
+	}
+	if f.err != "" {
+		content.WriteString(warningStyle.Render(f.err))
+		content.WriteString("\n")
+	}
+	if f.note != "" {
+		content.WriteString(featureStyle.Render(f.note))
+		content.WriteString("\n")
+	}
+	switch {
+	case f.saving:
+		content.WriteString(helpStyle.Render("enter: save | tab: name/description | esc: back to the arguments"))
+	case f.editing != "":
+		content.WriteString(helpStyle.Render("enter: save | tab: next field | ↑/↓: previous values | ←/→: choices | esc: cancel"))
+	default:
+		content.WriteString(helpStyle.Render("enter: run | ctrl+s: save as example | tab: next field | ↑/↓: previous values | ←/→: choices | esc: cancel"))
+	}
+	content.WriteString("\n\n")
+	return content.String()
+}
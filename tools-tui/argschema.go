@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgSpec describes an argument of a tool's command, as captured from
+// argparse help by inventory import. A tool with Args has no
+// placeholders in its command; the arguments are appended instead.
+type ArgSpec struct {
+	Name string `json:"name"`
+	// Flag is the option the value follows, such as --depth; positional
+	// arguments have none
+	Flag string `json:"flag,omitempty"`
+	// Switch marks a flag that takes no value
+	Switch bool `json:"switch,omitempty"`
+	// Choices are the only values the argument accepts
+	Choices  []string `json:"choices,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Help     string   `json:"help,omitempty"`
+}
+
+// switchOn is the value of a switch that is set
+const switchOn = "yes"
+
+// placeholder turns the spec into a field of the argument form
+func (a ArgSpec) placeholder() placeholder {
+	return placeholder{
+		Name:     a.Name,
+		Default:  a.Default,
+		Optional: !a.Required,
+		Flag:     a.Flag,
+		Switch:   a.Switch,
+		Choices:  a.Choices,
+		Help:     a.Help,
+	}
+}
+
+// placeholders lists the arguments the tool's command expects: its Args
+// when it has a schema, else the placeholders of its command
+func (t *Tool) placeholders() []placeholder {
+	if len(t.Args) == 0 {
+		return parsePlaceholders(t.Command)
+	}
+	result := make([]placeholder, len(t.Args))
+	for i, arg := range t.Args {
+		result[i] = arg.placeholder()
+	}
+	return result
+}
+
+// fillCommand returns the tool's command with the argument values
+// filled in: appended after it in schema order when the tool has Args,
+// else substituted for its placeholders
+func (t *Tool) fillCommand(values map[string]string) string {
+	if len(t.Args) == 0 {
+		return substitutePlaceholders(t.Command, values)
+	}
+	words := []string{strings.TrimSpace(t.Command)}
+	for _, arg := range t.Args {
+		value := values[arg.Name]
+		switch {
+		case arg.Switch:
+			if value == switchOn {
+				words = append(words, arg.Flag)
+			}
+		case value == "":
+		case arg.Flag != "":
+			words = append(words, arg.Flag, quoteArg(value))
+		default:
+			words = append(words, quoteArg(value))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// checkArg reports a value the argument does not accept
+func (p placeholder) checkArg(value string) error {
+	if p.Switch {
+		if value != "" && value != switchOn {
+			return fmt.Errorf("%s is a switch: %q or empty", p.Flag, switchOn)
+		}
+		return nil
+	}
+	if !p.Optional && value == "" {
+		return fmt.Errorf("%s is required", p.Label())
+	}
+	if value == "" || len(p.Choices) == 0 {
+		return nil
+	}
+	for _, choice := range p.Choices {
+		if value == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s", p.Label(), strings.Join(p.Choices, ", "))
+}
+
+// Label shows the argument the way the usage of its command does
+func (p placeholder) Label() string {
+	switch {
+	case p.Switch:
+		return p.Flag
+	case p.Flag != "" && p.Optional:
+		return "[" + p.Flag + " " + p.Name + "]"
+	case p.Flag != "":
+		return p.Flag + " <" + p.Name + ">"
+	case p.Optional:
+		return "[" + p.Name + "]"
+	}
+	return "<" + p.Name + ">"
+}
+
+// validateArgs reports problems with the argument schema of an
+// inventory entry
+func validateArgs(tool Tool) []string {
+	if len(tool.Args) == 0 {
+		return nil
+	}
+	var problems []string
+	if len(parsePlaceholders(tool.Command)) > 0 {
+		problems = append(problems, "args and <placeholders> in the command cannot be combined")
+	}
+	seen := map[string]bool{}
+	for _, arg := range tool.Args {
+		switch {
+		case arg.Name == "":
+			problems = append(problems, "argument without a name")
+		case seen[arg.Name]:
+			problems = append(problems, fmt.Sprintf("duplicate argument %q", arg.Name))
+		case arg.Flag != "" && !strings.HasPrefix(arg.Flag, "-"):
+			problems = append(problems, fmt.Sprintf("argument %q: flag %q must start with -", arg.Name, arg.Flag))
+		case arg.Switch && (arg.Flag == "" || len(arg.Choices) > 0):
+			problems = append(problems, fmt.Sprintf("argument %q: a switch needs a flag and takes no choices", arg.Name))
+		case arg.Default != "" && arg.placeholder().checkArg(arg.Default) != nil:
+			problems = append(problems, fmt.Sprintf("argument %q: default %q is not a choice", arg.Name, arg.Default))
+		}
+		seen[arg.Name] = true
+	}
+	return problems
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// autoFile stores the auto-approval flags toggled from the detail view
+const autoFile = "auto.json"
+
+// applyAutoOverrides applies the auto-approval flags saved per tool over
+// those of the inventory
+func applyAutoOverrides(categories []Category) {
+	overrides := map[string]bool{}
+	if err := loadJSON(autoFile, &overrides); err != nil {
+		return
+	}
+	for i := range categories {
+		for j := range categories[i].Tools {
+			tool := &categories[i].Tools[j]
+			if auto, ok := overrides[tool.Key()]; ok {
+				tool.Auto = auto
+			}
+		}
+	}
+}
+
+// SaveAutoOverride persists the auto-approval flag chosen for a tool
+func SaveAutoOverride(toolKey string, auto bool) error {
+	overrides := map[string]bool{}
+	if err := loadJSON(autoFile, &overrides); err != nil {
+		return err
+	}
+	overrides[toolKey] = auto
+	return saveJSON(autoFile, overrides)
+}
+
+// AutoLabel summarises whether workflows may run the tool unattended
+func (t *Tool) AutoLabel() string {
+	switch {
+	case t.Auto && t.Trust.RequiresConfirmation():
+		return "auto-approved, but downloaded tools always ask before they run"
+	case t.Auto:
+		return "⚡ auto-approved (workflows and schedules run it without asking)"
+	}
+	return "asks before workflows run it"
+}
+
+// Approval says how a run of a tool was started
+type Approval int
+
+const (
+	// Unattended runs start with nobody to ask: schedules, webhook rules
+	// and the steps of workflows that were not confirmed
+	Unattended Approval = iota
+	// Attended runs were started by a person who did not confirm them,
+	// such as `tools-tui run` without --yes
+	Attended
+	// Confirmed runs were confirmed by a person, in the TUI or with --yes
+	Confirmed
+)
+
+// runApproval returns why a tool may not run, or "" when it may. Every
+// way of starting a tool asks it: downloaded tools only run once
+// confirmed, and unattended runs only start auto-approved tools.
+func runApproval(tool *Tool, approval Approval) string {
+	if reason := tool.UnsupportedReason(); reason != "" {
+		return reason
+	}
+	switch {
+	case approval == Confirmed:
+		return ""
+	case tool.Trust.RequiresConfirmation() && approval == Unattended:
+		return "downloaded tools never run unattended, start it from the TUI or with --yes"
+	case tool.Trust.RequiresConfirmation():
+		return fmt.Sprintf("it is a %s tool, confirm it with --yes", tool.Trust)
+	case !tool.Auto && approval == Unattended:
+		return "not auto-approved, confirm the run from the TUI or mark the tool auto"
+	}
+	return ""
+}
+
+// unapprovedTools lists the tools of the given steps that only run once
+// confirmed, each once and in step order
+func unapprovedTools(categories []Category, tools []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range tools {
+		tool := findTool(categories, name)
+		if tool == nil || seen[name] || runApproval(tool, Unattended) == "" || runApproval(tool, Confirmed) != "" {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// confirmPrompt asks to run the tools of a workflow that need
+// confirmation
+func confirmPrompt(names []string, confirm string) string {
+	return fmt.Sprintf("⚠ %d tools are not auto-approved or were downloaded: %s. Press '%s' to run them, any other key to cancel", len(names), strings.Join(names, ", "), confirm)
+}
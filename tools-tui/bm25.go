@@ -0,0 +1,288 @@
+package main
+
+import (
+	"math"
+	"os"
+	"sort"
+	"time"
+	"unicode"
+)
+
+// BM25 parameters: how quickly repeated terms stop adding to the score
+// and how much long sessions are penalised
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// maxSessionHits is how many sessions a search returns
+const maxSessionHits = 100
+
+// indexedFile is a parsed session file, or the sessions of a memory
+// database, with the state it was read in
+type indexedFile struct {
+	mod      time.Time
+	size     int64
+	sessions []*aiSession
+}
+
+// posting is how often a term occurs in a session
+type posting struct {
+	doc int
+	tf  int
+}
+
+// sessionIndex is a BM25 index of AI sessions, one document per session
+type sessionIndex struct {
+	sessions  []*aiSession
+	postings  map[string][]posting
+	lengths   []int
+	avgLength float64
+	files     map[string]indexedFile
+	// Counts are the sessions read per source
+	Counts map[string]int
+	Errors []string
+	Built  time.Time
+}
+
+// sessionHit is a session matching a query
+type sessionHit struct {
+	Session *aiSession
+	Score   float64
+	// Message is the message with the most query terms, shown as the
+	// snippet and scrolled to when the session is opened
+	Message int
+}
+
+// tokenSpan is a token of a text with its rune offsets
+type tokenSpan struct {
+	Token      string
+	Start, End int
+}
+
+// tokenSpans splits text into lowercase words of letters and digits,
+// leaving out single characters
+func tokenSpans(text string) []tokenSpan {
+	var spans []tokenSpan
+	start := -1
+	var word []rune
+	i := 0
+	flush := func() {
+		if len(word) > 1 {
+			spans = append(spans, tokenSpan{string(word), start, i})
+		}
+		word, start = word[:0], -1
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			word = append(word, unicode.ToLower(r))
+		} else {
+			flush()
+		}
+		i++
+	}
+	flush()
+	return spans
+}
+
+// queryTerms returns the distinct words of a query
+func queryTerms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, span := range tokenSpans(query) {
+		if !seen[span.Token] {
+			seen[span.Token] = true
+			terms = append(terms, span.Token)
+		}
+	}
+	return terms
+}
+
+// buildSessionIndex reads the session files of every source and the
+// memory databases and indexes them, reusing the sessions of prev whose
+// files did not change
+func buildSessionIndex(prev *sessionIndex, databases []string) *sessionIndex {
+	idx := &sessionIndex{postings: map[string][]posting{}, files: map[string]indexedFile{}, Counts: map[string]int{}, Built: time.Now()}
+	index := func(path string, read func(path string) ([]*aiSession, error)) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		mod, size := info.ModTime(), info.Size()
+		// a database in write-ahead mode changes its log before the file
+		if wal, err := os.Stat(path + "-wal"); err == nil {
+			size += wal.Size()
+			if wal.ModTime().After(mod) {
+				mod = wal.ModTime()
+			}
+		}
+		file, ok := indexedFile{}, false
+		if prev != nil {
+			file, ok = prev.files[path]
+			ok = ok && file.mod.Equal(mod) && file.size == size
+		}
+		if !ok {
+			sessions, err := read(path)
+			if err != nil {
+				idx.Errors = append(idx.Errors, path+": "+err.Error())
+				return
+			}
+			file = indexedFile{mod: mod, size: size, sessions: sessions}
+		}
+		idx.files[path] = file
+		for _, session := range file.sessions {
+			if len(session.Messages) > 0 {
+				idx.sessions = append(idx.sessions, session)
+				idx.Counts[session.Source]++
+			}
+		}
+	}
+	home, _ := os.UserHomeDir()
+	for _, source := range sessionSources {
+		read := func(path string) ([]*aiSession, error) {
+			session, err := source.Read(path)
+			if err != nil {
+				return nil, err
+			}
+			return []*aiSession{session}, nil
+		}
+		for _, path := range source.Files(source.Dir(home)) {
+			index(path, read)
+		}
+	}
+	for _, path := range databases {
+		index(path, readMemoryDB)
+	}
+	sort.SliceStable(idx.sessions, func(i, j int) bool { return idx.sessions[i].Updated.After(idx.sessions[j].Updated) })
+
+	total := 0
+	for doc, session := range idx.sessions {
+		tf := map[string]int{}
+		length := 0
+		for _, text := range append([]string{session.Title}, messageTexts(session)...) {
+			for _, span := range tokenSpans(text) {
+				tf[span.Token]++
+				length++
+			}
+		}
+		for term, n := range tf {
+			idx.postings[term] = append(idx.postings[term], posting{doc, n})
+		}
+		idx.lengths = append(idx.lengths, length)
+		total += length
+	}
+	if len(idx.sessions) > 0 {
+		idx.avgLength = float64(total) / float64(len(idx.sessions))
+	}
+	return idx
+}
+
+// messageTexts returns the texts of a session's messages
+func messageTexts(s *aiSession) []string {
+	texts := make([]string, len(s.Messages))
+	for i, message := range s.Messages {
+		texts[i] = message.Text
+	}
+	return texts
+}
+
+// Size returns how many sessions are indexed
+func (idx *sessionIndex) Size() int {
+	return len(idx.sessions)
+}
+
+// Recent returns the most recently updated sessions, shown before a
+// query is typed
+func (idx *sessionIndex) Recent() []sessionHit {
+	hits := make([]sessionHit, 0, min(len(idx.sessions), maxSessionHits))
+	for _, session := range idx.sessions[:min(len(idx.sessions), maxSessionHits)] {
+		hits = append(hits, sessionHit{Session: session, Message: -1})
+	}
+	return hits
+}
+
+// Search ranks the sessions containing any term of query by BM25
+func (idx *sessionIndex) Search(query string) []sessionHit {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return idx.Recent()
+	}
+	n := float64(len(idx.sessions))
+	scores := map[int]float64{}
+	for _, term := range terms {
+		list := idx.postings[term]
+		if len(list) == 0 {
+			continue
+		}
+		idf := math.Log((n-float64(len(list))+0.5)/(float64(len(list))+0.5) + 1)
+		for _, p := range list {
+			tf := float64(p.tf)
+			norm := 1 - bm25B + bm25B*float64(idx.lengths[p.doc])/idx.avgLength
+			scores[p.doc] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	hits := make([]sessionHit, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, sessionHit{Session: idx.sessions[doc], Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Session.Updated.After(hits[j].Session.Updated)
+	})
+	if len(hits) > maxSessionHits {
+		hits = hits[:maxSessionHits]
+	}
+	for i := range hits {
+		hits[i].Message = bestMessage(hits[i].Session, terms)
+	}
+	return hits
+}
+
+// bestMessage returns the message holding the most distinct terms, then
+// the most occurrences, or -1 when only the title matches
+func bestMessage(s *aiSession, terms []string) int {
+	best, bestDistinct, bestCount := -1, 0, 0
+	for i, message := range s.Messages {
+		seen := map[string]bool{}
+		matches := termPositions(message.Text, terms)
+		for _, span := range matches {
+			seen[span.Token] = true
+		}
+		distinct, count := len(seen), len(matches)
+		if distinct > bestDistinct || (distinct == bestDistinct && count > bestCount) {
+			best, bestDistinct, bestCount = i, distinct, count
+		}
+	}
+	return best
+}
+
+// termPositions returns the spans of text that are one of terms
+func termPositions(text string, terms []string) []tokenSpan {
+	var matches []tokenSpan
+	for _, span := range tokenSpans(text) {
+		for _, term := range terms {
+			if span.Token == term {
+				matches = append(matches, span)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// runePositions expands spans to the rune positions highlightMatches
+// emphasises
+func runePositions(spans []tokenSpan) []int {
+	var positions []int
+	for _, span := range spans {
+		for i := span.Start; i < span.End; i++ {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
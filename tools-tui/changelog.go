@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// changelogNames are the files searched for release notes
+var changelogNames = []string{"CHANGELOG.md", "CHANGELOG", "CHANGES.md", "HISTORY.md", "RELEASES.md"}
+
+// githubRemote extracts owner/repo from a GitHub remote URL
+var githubRemote = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(\.git)?$`)
+
+// changelogMsg carries fetched release notes back to the UI
+type changelogMsg struct {
+	tool  string
+	notes string
+	err   error
+}
+
+// githubRelease is the subset of the releases API we render
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// fetchChangelogCmd loads release notes for an extension in the background
+func fetchChangelogCmd(toolName, dir string) tea.Cmd {
+	return func() tea.Msg {
+		notes, err := FetchChangelog(dir)
+		return changelogMsg{tool: toolName, notes: notes, err: err}
+	}
+}
+
+// FetchChangelog gathers what an update would bring in: pending upstream
+// commits, the upstream CHANGELOG and the latest GitHub releases. It falls
+// back to the local CHANGELOG when the extension is not a git checkout.
+func FetchChangelog(dir string) (string, error) {
+	var sections []string
+
+	if _, err := gitHead(dir); err == nil {
+		exec.Command("git", "-C", dir, "fetch", "--quiet").Run()
+
+		if out, err := exec.Command("git", "-C", dir, "log", "--oneline", "HEAD..@{u}").Output(); err == nil {
+			pending := strings.TrimSpace(string(out))
+			if pending == "" {
+				pending = "Already up to date"
+			}
+			sections = append(sections, "# Pending upstream commits\n\n"+pending)
+		}
+
+		for _, name := range changelogNames {
+			if out, err := exec.Command("git", "-C", dir, "show", "@{u}:"+name).Output(); err == nil {
+				sections = append(sections, "# Upstream "+name+"\n\n"+string(out))
+				break
+			}
+		}
+
+		if releases, err := fetchGitHubReleases(dir); err == nil && releases != "" {
+			sections = append(sections, releases)
+		}
+	}
+
+	if len(sections) == 0 {
+		for _, name := range changelogNames {
+			if content, err := ReadFileContent(filepath.Join(dir, name)); err == nil {
+				sections = append(sections, "# "+name+"\n\n"+content)
+				break
+			}
+		}
+	}
+
+	if len(sections) == 0 {
+		return "", fmt.Errorf("no changelog or release notes found for %s", filepath.Base(dir))
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// fetchGitHubReleases renders the latest releases of the origin repository
+func fetchGitHubReleases(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", err
+	}
+	match := githubRemote.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if match == nil {
+		return "", fmt.Errorf("origin is not a GitHub repository")
+	}
+
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases?per_page=5", match[1], match[2])
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, release := range releases {
+		title := release.Name
+		if title == "" {
+			title = release.TagName
+		}
+		fmt.Fprintf(&b, "# Release %s (%s)\n\n%s\n\n", title, release.PublishedAt.Format("2006-01-02"), strings.TrimSpace(release.Body))
+	}
+	return b.String(), nil
+}
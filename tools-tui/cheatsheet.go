@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// cheatColumnWidth is the width of one column of the cheat sheet
+const cheatColumnWidth = 62
+
+// keyGroup is a titled set of bindings listed together
+type keyGroup struct {
+	title   string
+	actions []action
+}
+
+// actionGroups sorts the registered actions into the sections of the
+// cheat sheet, in registry order
+func actionGroups() []keyGroup {
+	var groups []keyGroup
+	index := map[string]int{}
+	for _, a := range actions {
+		i, ok := index[a.group]
+		if !ok {
+			i = len(groups)
+			index[a.group] = i
+			groups = append(groups, keyGroup{title: a.group})
+		}
+		groups[i].actions = append(groups[i].actions, a)
+	}
+	return groups
+}
+
+// cheatSheetView holds the scroll position of the cheat sheet
+type cheatSheetView struct {
+	offset int
+}
+
+// openCheatSheet shows the cheat sheet
+func (m *Model) openCheatSheet() {
+	m.cheatSheet.offset = 0
+	m.screen = screenCheatSheet
+}
+
+// updateCheatSheet handles input on the cheat sheet
+func (m Model) updateCheatSheet(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back), key.Matches(keyMsg, m.keys.Help):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if m.cheatSheet.offset > 0 {
+			m.cheatSheet.offset--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if m.cheatSheet.offset < len(m.cheatSheetLines())-m.cheatSheetHeight() {
+			m.cheatSheet.offset++
+		}
+	}
+	return m, nil
+}
+
+// renderKeyGroup lists a group's actions with all their keys. Actions
+// remapped in keys.toml or config.json are marked, and the action name
+// is shown so it can be looked up for remapping.
+func renderKeyGroup(group keyGroup, keys *KeyMap) string {
+	defaults := DefaultKeyMap()
+	var b strings.Builder
+	b.WriteString(featureStyle.Render(group.title))
+	b.WriteString("\n")
+	for _, a := range group.actions {
+		binding := a.binding(keys)
+		if !binding.Enabled() {
+			continue
+		}
+		names := make([]string, len(binding.Keys()))
+		for i, k := range binding.Keys() {
+			if k == " " {
+				k = "space"
+			}
+			names[i] = k
+		}
+		mark := " "
+		if strings.Join(a.binding(&defaults).Keys(), " ") != strings.Join(binding.Keys(), " ") {
+			mark = "✎"
+		}
+		b.WriteString(fmt.Sprintf("%s %-13s %-28s %s\n", mark, strings.Join(names, " / "), a.description, helpStyle.Render(a.name)))
+	}
+	return b.String()
+}
+
+// renderMacros lists the configured macros
+func renderMacros(macros map[string][]string) string {
+	names := make([]string, 0, len(macros))
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(featureStyle.Render("Macros"))
+	b.WriteString("\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("  %-13s %s\n", name, strings.Join(macros[name], " → ")))
+	}
+	return b.String()
+}
+
+// cheatSheetHeight is how many lines of the cheat sheet fit on screen
+func (m Model) cheatSheetHeight() int {
+	if visible := m.height - 6; visible > 5 {
+		return visible
+	}
+	return 5
+}
+
+// cheatSheetLines lays the key groups out in as many columns as fit
+func (m Model) cheatSheetLines() []string {
+	keys := m.keys
+	blocks := []string{}
+	for _, group := range actionGroups() {
+		blocks = append(blocks, renderKeyGroup(group, &keys))
+	}
+	if len(m.macros) > 0 {
+		blocks = append(blocks, renderMacros(m.macros))
+	}
+
+	columns := (m.width - 2) / cheatColumnWidth
+	if columns < 1 {
+		columns = 1
+	}
+	layout := make([][]string, columns)
+	heights := make([]int, columns)
+	for _, block := range blocks {
+		// add each group to the shortest column
+		shortest := 0
+		for i := range heights {
+			if heights[i] < heights[shortest] {
+				shortest = i
+			}
+		}
+		layout[shortest] = append(layout[shortest], block)
+		heights[shortest] += lipgloss.Height(block) + 1
+	}
+	rendered := make([]string, columns)
+	for i, column := range layout {
+		rendered[i] = lipgloss.NewStyle().Width(cheatColumnWidth).Render(strings.Join(column, "\n"))
+	}
+	return strings.Split(lipgloss.JoinHorizontal(lipgloss.Top, rendered...), "\n")
+}
+
+// renderCheatSheet renders the visible part of the cheat sheet
+func (m Model) renderCheatSheet() string {
+	lines := m.cheatSheetLines()
+	offset := m.cheatSheet.offset
+	if max := len(lines) - m.cheatSheetHeight(); offset > max {
+		offset = max
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	end := offset + m.cheatSheetHeight()
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("⌨️  Cheat Sheet"))
+	content.WriteString("  ")
+	content.WriteString(helpStyle.Render("✎ remapped in keys.toml or config.json, grey names are the actions to remap"))
+	content.WriteString("\n\n")
+	content.WriteString(strings.Join(lines[offset:end], "\n"))
+	content.WriteString("\n\n")
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("scroll", m.keys.Up, m.keys.Down), hint("close", m.keys.Back, m.keys.Help), hint("quit", m.keys.Quit)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,588 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// cliImportCategory holds the inventory entries generated from cli.py;
+// the importer rewrites it, other categories are left alone
+const cliImportCategory = "🧰 cli.py Commands"
+
+// cliHelpTimeout bounds each help invocation of the importer
+const cliHelpTimeout = 10 * time.Second
+
+// cliScriptLine matches the dispatch of an opencode-style cli.py
+// command to its script, such as
+//
+//	elif command == "review":
+//	    run_command("agents/code_reviewer.py", args)
+//
+// or, in older versions, run_command(f"python agents/code_reviewer.py …")
+var cliScriptLine = regexp.MustCompile(`command == "([\w-]+)":\s*\n\s*run_command\((?:f?["']python3? |["'])([^\s"'{]+)`)
+
+// cliChoices matches the subcommand choices of an argparse usage line
+var cliChoices = regexp.MustCompile(`\{([\w-]+(?:,[\w-]+)+)\}`)
+
+// argumentParsers are imports of scripts that answer --help without
+// doing anything else
+var argumentParsers = regexp.MustCompile(`(?m)^\s*(import|from)\s+(argparse|click|typer|optparse)\b`)
+
+// cliDefault matches the default argparse adds to help with
+// ArgumentDefaultsHelpFormatter
+var cliDefault = regexp.MustCompile(`\s*\(default: ([^)]*)\)`)
+
+// cliCommand is a subcommand discovered in cli.py
+type cliCommand struct {
+	Name    string
+	Summary string
+	// Args is the argument schema read from the command's help
+	Args []ArgSpec
+	// Help tells whether the command's help could be read; without it
+	// the command is imported without arguments
+	Help bool
+}
+
+// Command returns the inventory command running c; its arguments are
+// described by Args
+func (c cliCommand) Command() string {
+	return "python cli.py " + c.Name
+}
+
+// helpEntry is an argument listed in the sections of an argparse help
+type helpEntry struct {
+	Flags []string
+	Help  string
+}
+
+// longFlag returns the last --flag of the entry, or ""
+func (e helpEntry) longFlag() string {
+	long := ""
+	for _, flag := range e.Flags {
+		if strings.HasPrefix(flag, "--") {
+			long = flag
+		}
+	}
+	return long
+}
+
+// parseHelpEntries reads the argument lists of an argparse help, such as
+//
+//	positional arguments:
+//	  path                  file to scan
+//	options:
+//	  -d DEPTH, --depth DEPTH
+//	                        how deep to look (default: 3)
+//
+// keyed by each option string and by the name of each positional
+func parseHelpEntries(lines []string) map[string]*helpEntry {
+	entries := map[string]*helpEntry{}
+	var last *helpEntry
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case trimmed == "" || indent == 0:
+			last = nil
+		case indent <= 4:
+			invocation, help, _ := strings.Cut(trimmed, "  ")
+			last = &helpEntry{Help: strings.TrimSpace(help)}
+			for _, part := range strings.Split(invocation, ", ") {
+				if fields := strings.Fields(part); len(fields) > 0 {
+					last.Flags = append(last.Flags, fields[0])
+					entries[fields[0]] = last
+				}
+			}
+		case last != nil:
+			last.Help = strings.TrimSpace(last.Help + " " + trimmed)
+		}
+	}
+	return entries
+}
+
+// choicesOf returns the choices of a {a,b} metavar
+func choicesOf(metavar string) []string {
+	if !strings.HasPrefix(metavar, "{") || !strings.HasSuffix(metavar, "}") {
+		return nil
+	}
+	return strings.Split(strings.Trim(metavar, "{}"), ",")
+}
+
+// usageArg reads one argument of a usage line: a positional, or a flag
+// followed by its metavar if it takes a value
+func usageArg(words []string, required bool) ArgSpec {
+	arg := ArgSpec{Required: required}
+	if !strings.HasPrefix(words[0], "-") {
+		arg.Name = argName(words[0])
+		arg.Choices = choicesOf(words[0])
+		return arg
+	}
+	arg.Flag, arg.Name = words[0], argName(words[0])
+	if len(words) == 1 || words[1] == "..." {
+		arg.Switch = true
+		return arg
+	}
+	if arg.Choices = choicesOf(words[1]); arg.Choices == nil {
+		arg.Name = argName(words[1])
+	}
+	return arg
+}
+
+// cliInterpreter returns the Python the importer runs cli.py with
+func cliInterpreter() (string, error) {
+	for _, name := range []string{"python", "python3"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("neither python nor python3 is on PATH")
+}
+
+// cliHelp runs cli.py with args in the sandbox of downloaded tools and
+// returns what it printed, whatever its exit status
+func cliHelp(python, root string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), cliHelpTimeout)
+	defer cancel()
+	cmd, cleanup, err := sandboxCommand(ctx, root, append([]string{python, "cli.py"}, args...), []string{"PYTHONDONTWRITEBYTECODE=1"})
+	if err != nil {
+		return ""
+	}
+	defer cleanup()
+	output, _ := cmd.CombinedOutput()
+	return string(output)
+}
+
+// parseCLICommands returns the subcommands named by a help or usage
+// text: the "Commands:" line of opencode-style cli.py files or the
+// choices of an argparse usage line
+func parseCLICommands(help string) []string {
+	var list string
+	if match := cliCommandsLine.FindStringSubmatch(help); match != nil {
+		list = match[1]
+	} else if match := cliChoices.FindStringSubmatch(help); match != nil {
+		list = match[1]
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// usageTokens splits the arguments of a usage line into words and
+// bracketed groups
+func usageTokens(usage string) []string {
+	var tokens []string
+	var current strings.Builder
+	depth := 0
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range usage {
+		switch {
+		case r == '[' || r == '{' || r == '(':
+			if depth == 0 {
+				flush()
+			}
+			depth++
+			current.WriteRune(r)
+		case r == ']' || r == '}' || r == ')':
+			current.WriteRune(r)
+			if depth--; depth == 0 {
+				flush()
+			}
+		case unicode.IsSpace(r) && depth == 0:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// argName turns a metavar or positional into a placeholder name
+func argName(word string) string {
+	word = strings.Trim(word, ".")
+	if strings.HasPrefix(word, "{") {
+		return "choice"
+	}
+	return strings.ToLower(strings.Trim(word, "-"))
+}
+
+// parseCLIHelp reads the arguments and summary of the argparse-style
+// help text of a subcommand. It reports false when the text is not one.
+func parseCLIHelp(help, name string) (cliCommand, bool) {
+	var command cliCommand
+	lines := strings.Split(help, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "usage:") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return command, false
+	}
+	usage := strings.TrimSpace(lines[start])[len("usage:"):]
+	end := start + 1
+	for ; end < len(lines) && strings.HasPrefix(lines[end], " ") && strings.TrimSpace(lines[end]) != ""; end++ {
+		usage += " " + strings.TrimSpace(lines[end])
+	}
+	tokens := usageTokens(usage)
+	// skip the program name, which ends with the subcommand's
+	skip := 1
+	for i, token := range tokens {
+		if token == name {
+			skip = i + 1
+			break
+		}
+	}
+	if skip > len(tokens) {
+		skip = len(tokens)
+	}
+	tokens = tokens[skip:]
+	entries := parseHelpEntries(lines[end:])
+	seen := map[string]int{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		var arg ArgSpec
+		key := token
+		switch {
+		case token == "..." || token == "-h" || token == "--help":
+			continue
+		case strings.HasPrefix(token, "["):
+			words := strings.Fields(strings.Trim(token, "[]"))
+			if len(words) == 0 || words[0] == "-h" || words[0] == "--help" {
+				continue
+			}
+			arg, key = usageArg(words, false), words[0]
+		case strings.HasPrefix(token, "-"):
+			words := []string{token}
+			if i+1 < len(tokens) && !strings.HasPrefix(tokens[i+1], "-") && !strings.HasPrefix(tokens[i+1], "[") &&
+				(strings.ToUpper(tokens[i+1]) == tokens[i+1] || choicesOf(tokens[i+1]) != nil) {
+				i++
+				words = append(words, tokens[i])
+			}
+			arg = usageArg(words, true)
+		default:
+			arg = usageArg([]string{token}, true)
+		}
+		if entry := entries[key]; entry != nil {
+			if match := cliDefault.FindStringSubmatch(entry.Help); match != nil {
+				if value := match[1]; value != "None" && value != "False" && value != "==SUPPRESS==" {
+					arg.Default = value
+				}
+				entry.Help = cliDefault.ReplaceAllString(entry.Help, "")
+			}
+			arg.Help = entry.Help
+			if long := entry.longFlag(); arg.Flag != "" && long != "" {
+				arg.Flag = long
+				if arg.Switch || len(arg.Choices) > 0 {
+					arg.Name = argName(long)
+				}
+			}
+		}
+		// a switch is left off unless set, whatever it stores
+		if arg.Switch {
+			arg.Default = ""
+		}
+		if seen[arg.Name]++; seen[arg.Name] > 1 {
+			arg.Name = fmt.Sprintf("%s%d", arg.Name, seen[arg.Name])
+		}
+		command.Args = append(command.Args, arg)
+	}
+	for _, line := range lines[end:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if command.Summary != "" {
+				break
+			}
+			continue
+		}
+		if strings.HasSuffix(line, ":") {
+			break
+		}
+		command.Summary = strings.TrimSpace(command.Summary + " " + line)
+	}
+	return command, true
+}
+
+// cliScripts maps the commands of an opencode-style cli.py to the
+// script each one runs
+func cliScripts(source string) map[string]string {
+	scripts := map[string]string{}
+	for _, match := range cliScriptLine.FindAllStringSubmatch(source, -1) {
+		scripts[match[1]] = match[2]
+	}
+	return scripts
+}
+
+// helpIsSafe reports whether `cli.py <command> --help` only prints help:
+// cli.py parses its own arguments, or the script behind the command
+// does. Other scripts may take --help for a value, so their help is not
+// run.
+func helpIsSafe(root, source, script string) bool {
+	if argumentParsers.MatchString(source) {
+		return true
+	}
+	if script == "" {
+		return false
+	}
+	content, err := ReadFileContent(filepath.Join(root, script))
+	return err == nil && argumentParsers.MatchString(content)
+}
+
+// cliCommandNames returns the subcommands of the cli.py in root from its
+// help, falling back to its usage line and source
+func cliCommandNames(python, root, source string) []string {
+	names := parseCLICommands(cliHelp(python, root, "--help"))
+	if len(names) == 0 {
+		names = parseCLICommands(cliHelp(python, root))
+	}
+	if len(names) == 0 {
+		names = parseCLICommands(source)
+	}
+	return names
+}
+
+// IntrospectCLI discovers the subcommands of the cli.py in root from its
+// help, falling back to its usage line and source, and reads the
+// arguments of each from its own help where that is safe. Help runs in
+// the sandbox of downloaded tools.
+func IntrospectCLI(root string) ([]cliCommand, error) {
+	source, err := ReadFileContent(filepath.Join(root, "cli.py"))
+	if err != nil {
+		return nil, err
+	}
+	python, err := cliInterpreter()
+	if err != nil {
+		return nil, err
+	}
+	names := cliCommandNames(python, root, source)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s/cli.py does not list its commands", root)
+	}
+	scripts := cliScripts(source)
+	commands := make([]cliCommand, len(names))
+	for i, name := range names {
+		commands[i] = cliCommand{Name: name}
+		if !helpIsSafe(root, source, scripts[name]) {
+			continue
+		}
+		if parsed, ok := parseCLIHelp(cliHelp(python, root, name, "--help"), name); ok {
+			parsed.Name, parsed.Help = name, true
+			commands[i] = parsed
+		}
+	}
+	return commands, nil
+}
+
+// cliCommandOf returns the cli.py subcommand a tool runs, if any
+func cliCommandOf(tool Tool) (string, bool) {
+	fields := strings.Fields(tool.Command)
+	if len(fields) < 3 || !strings.HasPrefix(fields[0], "python") || filepath.Base(fields[1]) != "cli.py" {
+		return "", false
+	}
+	return fields[2], true
+}
+
+// cliRoot returns the directory of the cli.py a tool runs, for tools
+// cliCommandOf accepts
+func cliRoot(tool Tool) string {
+	script := strings.Fields(tool.Command)[1]
+	if filepath.IsAbs(script) {
+		return filepath.Dir(script)
+	}
+	return filepath.Join(tool.WorkDir(), filepath.Dir(script))
+}
+
+// closestCLICommand returns the subcommand most like a missing one, such
+// as scan_repo for scan, or "" when none looks alike
+func closestCLICommand(missing string, names []string) string {
+	best, bestScore := "", 0
+	for _, name := range names {
+		score, _, ok := fuzzyMatch(missing, name)
+		if !ok {
+			score, _, ok = fuzzyMatch(name, missing)
+		}
+		if ok && score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	return best
+}
+
+// cliToolName turns a subcommand into a tool name, such as
+// "validate_openapi" into "Validate Openapi"
+func cliToolName(command string) string {
+	words := strings.FieldsFunc(command, func(r rune) bool { return r == '_' || r == '-' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// cliImportReport lists the tools an import changed
+type cliImportReport struct {
+	Added, Updated, Removed []string
+}
+
+// Changed reports whether the import changes the inventory
+func (r cliImportReport) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) > 0
+}
+
+// mergeCLIImport rewrites the generated category of the inventory from
+// the discovered commands. Commands that another category already runs
+// are left to it; generated entries keep the fields edited by hand,
+// only their command, description and argument schema follow cli.py.
+func mergeCLIImport(categories []Category, commands []cliCommand) ([]Category, cliImportReport) {
+	var report cliImportReport
+	covered := map[string]bool{}
+	names := map[string]bool{}
+	previous := map[string]Tool{}
+	for _, category := range categories {
+		for _, tool := range category.Tools {
+			command, ok := cliCommandOf(tool)
+			if category.Name == cliImportCategory && ok {
+				previous[command] = tool
+				continue
+			}
+			names[tool.Name] = true
+			if ok {
+				covered[command] = true
+			}
+		}
+	}
+
+	var tools []Tool
+	for _, command := range commands {
+		if covered[command.Name] {
+			continue
+		}
+		description := command.Summary
+		if description == "" {
+			description = "Imported from cli.py"
+		}
+		tool, existed := previous[command.Name]
+		delete(previous, command.Name)
+		if !existed {
+			tool = Tool{Name: cliToolName(command.Name), Purpose: "cli.py " + command.Name, Status: "❔ Unknown"}
+			if names[tool.Name] {
+				tool.Name = "cli.py " + command.Name
+			}
+		}
+		// keep arguments written by hand when the help cannot be read
+		if command.Help || !existed {
+			updated := tool
+			updated.Command, updated.Description, updated.Args = command.Command(), description, command.Args
+			if existed && (updated.Command != tool.Command || updated.Description != tool.Description || !reflect.DeepEqual(updated.Args, tool.Args)) {
+				report.Updated = append(report.Updated, tool.Name)
+			}
+			tool = updated
+		}
+		if !existed {
+			report.Added = append(report.Added, tool.Name)
+		}
+		names[tool.Name] = true
+		tools = append(tools, tool)
+	}
+	for _, tool := range previous {
+		report.Removed = append(report.Removed, tool.Name)
+	}
+
+	merged := categories[:0:0]
+	for _, category := range categories {
+		if category.Name != cliImportCategory {
+			merged = append(merged, category)
+		}
+	}
+	if len(tools) > 0 {
+		merged = append(merged, Category{Name: cliImportCategory, Purpose: "Commands discovered in cli.py by tools-tui inventory import", Tools: tools})
+	}
+	return merged, report
+}
+
+// encodeManifest renders categories the way inventory export writes them
+func encodeManifest(categories []Category) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(categories); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// importInventory implements inventory import: it introspects cli.py
+// and updates the generated entries of the inventory
+func importInventory(args []string) error {
+	fs := flag.NewFlagSet("inventory import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the changes without writing the inventory")
+	root := fs.String("root", "", "directory of cli.py, the inventory's when empty")
+	fs.Parse(args)
+	path := manifestPath()
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if *root == "" {
+		*root = filepath.Dir(path)
+	}
+
+	var categories []Category
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &categories); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	commands, err := IntrospectCLI(*root)
+	if err != nil {
+		return err
+	}
+	merged, report := mergeCLIImport(categories, commands)
+	for _, change := range []struct {
+		label string
+		names []string
+	}{{"added", report.Added}, {"updated", report.Updated}, {"removed", report.Removed}} {
+		for _, name := range change.names {
+			fmt.Printf("%-8s %s\n", change.label, name)
+		}
+	}
+	if !report.Changed() {
+		fmt.Printf("%s is up to date with cli.py (%d commands)\n", path, len(commands))
+		return nil
+	}
+	if *dryRun {
+		return nil
+	}
+	data, err := encodeManifest(merged)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// subcommand is a headless entry point selected by the first argument
+type subcommand struct {
+	summary string
+	run     func(args []string) error
+}
+
+// subcommands lists the commands available besides the interactive TUI
+var subcommands = map[string]subcommand{
+	"control":   {"run jobs submitted over a Unix socket, or submit, follow and cancel them", runControl},
+	"daemon":    {"run submitted jobs, scheduled tools and webhook rules without the TUI, controlled over a Unix socket or a REST API", runDaemon},
+	"diff":      {"show how the output of a tool changed since its previous run", runDiff},
+	"digest":    {"print or e-mail a digest of scheduled jobs, health checks and workflow runs", runDigest},
+	"exec":      {"run a program with streaming, a timeout and run history, for cli.py", runExec},
+	"inventory": {"validate the inventory manifest, export the built-in catalog to it or import cli.py's commands", runInventory},
+	"list":      {"print the tool catalog as JSON", runList},
+	"release":   {"cross-compile Go tools, write checksums and draft a GitHub release", runRelease},
+	"replay":    {"repeat a run from its manifest, reporting what differs from the original", runReplay},
+	"repos":     {"list, add or remove repositories merged into the catalog", runRepos},
+	"requests":  {"run the assertions of a project's saved requests, for CI", runRequests},
+	"run":       {"run a tool without the TUI and print the run record as JSON", runRun},
+	"schedule":  {"list upcoming scheduled workflow and tool runs, export them as iCal/JSON or run scheduled tools as a daemon", runSchedule},
+	"search":    {"print the tools matching a query as JSON, best first", runSearch},
+	"secrets":   {"list, get, set, rotate or delete tokens in the keyring-backed secrets store", runSecrets},
+	"selftest":  {"smoke-test inventory, commands, sandbox, SQLite, HTTP and rendering", runSelftest},
+	"serve":     {"serve release artifacts and a one-line /install.sh over HTTP", runServe},
+	"stats":     {"local-only usage report: most used, failing and slowest tools", runStats},
+	"workflow":  {"run a workflow unattended, only auto-approved tools unless --yes", runWorkflowSubcommand},
+}
+
+// runSubcommand dispatches os.Args to a subcommand. It reports false
+// when the arguments do not name one and the TUI should start instead.
+func runSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage()
+		return true
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		return false
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Println("Usage: tools-tui [command] [flags]")
+	fmt.Println()
+	fmt.Println("Without a command the interactive TUI is started.")
+	fmt.Println()
+	fmt.Println("Commands:")
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-12s %s\n", name, subcommands[name].summary)
+	}
+}
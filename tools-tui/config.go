@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// configFile is the main user configuration file
+const configFile = "config.json"
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = time.Second
+
+// toastDuration is how long transient notifications stay visible
+const toastDuration = 3 * time.Second
+
+// Config is the user configuration loaded from config.json
+type Config struct {
+	Theme  Theme               `json:"theme"`
+	Keys   map[string][]string `json:"keys"`
+	Digest *DigestConfig       `json:"digest,omitempty"`
+	// QuietHours defers scheduled runs and guards dangerous tools
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// Macros name sequences of actions run from the command palette
+	Macros map[string][]string `json:"macros,omitempty"`
+	// Panes is how many job output panes are kept, 4 when unset
+	Panes int `json:"panes,omitempty"`
+	// MCPServers are the MCP servers the TUI connects to, in addition to
+	// those of the repository's mcp_settings_local.json
+	MCPServers map[string]MCPServerConfig `json:"mcp_servers,omitempty"`
+	// Output is the output mode of tools without their own choice
+	Output OutputMode `json:"output,omitempty"`
+	// Manifests writes a run manifest for every execution
+	Manifests bool `json:"manifests,omitempty"`
+	// ReducedMotion shows static text instead of spinners, blinking
+	// cursors and ticking timers
+	ReducedMotion bool `json:"reduced_motion,omitempty"`
+	// Tmux runs executions in a new tmux pane or window when the TUI
+	// runs inside tmux
+	Tmux TmuxMode `json:"tmux,omitempty"`
+	// OutputDir is where output is exported, logs/ in the config
+	// directory when unset
+	OutputDir string `json:"output_dir,omitempty"`
+	// GitHub configures the GitHub panel
+	GitHub *GitHubConfig `json:"github,omitempty"`
+	// Linear configures the Linear panel
+	Linear *LinearConfig `json:"linear,omitempty"`
+	// Webhooks configures the listener started with --listen
+	Webhooks *WebhookConfig `json:"webhooks,omitempty"`
+	// Memory configures the Memory browser
+	Memory *MemoryConfig `json:"memory,omitempty"`
+}
+
+// configTickMsg triggers a check of the config file modification time
+type configTickMsg time.Time
+
+// clearToastMsg hides a toast unless a newer one replaced it
+type clearToastMsg struct{ id int }
+
+// configPath returns the location of the main config file
+func configPath() string {
+	return filepath.Join(ConfigDir(), configFile)
+}
+
+// LoadConfig reads config.json, returning an empty config when absent.
+// The bindings of keys.toml replace those of config.json.
+func LoadConfig() (Config, error) {
+	var cfg Config
+	if err := loadJSON(configFile, &cfg); err != nil {
+		return cfg, err
+	}
+	keys, err := LoadKeyFile()
+	if err != nil {
+		return cfg, err
+	}
+	if len(keys) > 0 && cfg.Keys == nil {
+		cfg.Keys = map[string][]string{}
+	}
+	for action, bound := range keys {
+		cfg.Keys[action] = bound
+	}
+	return cfg, nil
+}
+
+// configModTime returns the latest modification time of config.json
+// and keys.toml, or zero when neither exists
+func configModTime() time.Time {
+	var latest time.Time
+	for _, path := range []string{configPath(), keysPath()} {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// watchConfigCmd schedules the next config file check
+func watchConfigCmd() tea.Cmd {
+	return tea.Tick(configPollInterval, func(t time.Time) tea.Msg {
+		return configTickMsg(t)
+	})
+}
+
+// bindings maps config action names to the bindings they customise
+func (k *KeyMap) bindings() map[string]*key.Binding {
+	bindings := make(map[string]*key.Binding, len(actions))
+	for _, a := range actions {
+		bindings[a.name] = a.binding(k)
+	}
+	return bindings
+}
+
+// applyKeyOverrides rebinds actions named in overrides
+func (k *KeyMap) applyKeyOverrides(overrides map[string][]string) error {
+	bindings := k.bindings()
+	var unknown []string
+	for action, keys := range overrides {
+		binding, ok := bindings[action]
+		if !ok {
+			unknown = append(unknown, action)
+			continue
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown key actions: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// applyConfig applies theme and key bindings from cfg to the model
+func (m *Model) applyConfig(cfg Config) error {
+	applyTheme(cfg.Theme)
+	reducedMotion = cfg.ReducedMotion
+	m.searchInput.Cursor.SetMode(cursorMode())
+	m.annotation.Cursor.SetMode(cursorMode())
+	keys := DefaultKeyMap()
+	err := keys.applyKeyOverrides(cfg.Keys)
+	if conflicts := keys.resolveKeyConflicts(); len(conflicts) > 0 && err == nil {
+		err = fmt.Errorf("key conflicts, defaults kept: %s", strings.Join(conflicts, "; "))
+	}
+	m.keys = keys
+	m.quietHours = cfg.QuietHours
+	m.scheduler.SetQuietHours(cfg.QuietHours)
+	m.macros = cfg.Macros
+	m.maxPanes = defaultMaxPanes
+	if cfg.Panes > 0 {
+		m.maxPanes = cfg.Panes
+	}
+	if qerr := cfg.QuietHours.validate(); qerr != nil && err == nil {
+		err = qerr
+	}
+	if merr := validateMacros(cfg.Macros); merr != nil && err == nil {
+		err = merr
+	}
+	m.defaultOutput = cfg.Output
+	m.manifests = cfg.Manifests
+	if oerr := cfg.Output.validate(); oerr != nil && err == nil {
+		err = oerr
+	}
+	m.tmux = cfg.Tmux
+	m.outputDir = cfg.OutputDir
+	m.githubConfig = cfg.GitHub
+	m.linearConfig = cfg.Linear
+	m.memoryConfig = cfg.Memory
+	if terr := cfg.Tmux.validate(); terr != nil && err == nil {
+		err = terr
+	}
+	m.webhookConfig = cfg.Webhooks
+	if werr := cfg.Webhooks.validate(); werr != nil && err == nil {
+		err = werr
+	}
+	m.mcpServers = cfg.MCPServers
+	if merr := validateMCPServers(cfg.MCPServers); merr != nil && err == nil {
+		err = merr
+	}
+	return err
+}
+
+// reloadConfig re-reads config.json after it changed on disk
+func (m *Model) reloadConfig() tea.Cmd {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return m.showToast(fmt.Sprintf("Config error: %v", err))
+	}
+	if err := m.applyConfig(cfg); err != nil {
+		return m.showToast(fmt.Sprintf("Config reloaded with warnings: %v", err))
+	}
+	return m.showToast("Config reloaded")
+}
+
+// showToast displays a transient notification
+func (m *Model) showToast(text string) tea.Cmd {
+	m.toastID++
+	m.toast = text
+	id := m.toastID
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return clearToastMsg{id: id}
+	})
+}
@@ -0,0 +1,135 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Client drives a control server over one connection. Requests are
+// sent one at a time; a Client is safe for concurrent use.
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	nextID int
+}
+
+// Dial connects to the control server listening on the Unix socket at
+// path
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a client speaking the protocol over conn
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// call sends a request and passes each response to handle until the
+// last one
+func (c *Client) call(req Request, handle func(Response) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	req.ID = c.nextID
+	if err := WriteFrame(c.conn, req); err != nil {
+		return err
+	}
+	for {
+		var resp Response
+		if err := ReadFrame(c.conn, &resp); err != nil {
+			return err
+		}
+		if resp.ID != req.ID {
+			return fmt.Errorf("response to request %d while waiting for %d", resp.ID, req.ID)
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if err := handle(resp); err != nil {
+			return err
+		}
+		if resp.Done {
+			return nil
+		}
+	}
+}
+
+// job calls a method answered with a single job
+func (c *Client) job(req Request) (Job, error) {
+	var job Job
+	err := c.call(req, func(resp Response) error {
+		if resp.Job == nil {
+			return fmt.Errorf("%s answered without a job", req.Method)
+		}
+		job = *resp.Job
+		return nil
+	})
+	return job, err
+}
+
+// Submit starts a job and returns it as it started
+func (c *Client) Submit(submit Submit) (Job, error) {
+	return c.job(Request{Method: MethodSubmit, Submit: &submit})
+}
+
+// Job returns the state of a job
+func (c *Client) Job(id int) (Job, error) {
+	return c.job(Request{Method: MethodState, Job: id})
+}
+
+// Jobs returns the state of every job, oldest first
+func (c *Client) Jobs() ([]Job, error) {
+	var jobs []Job
+	err := c.call(Request{Method: MethodState}, func(resp Response) error {
+		jobs = resp.Jobs
+		return nil
+	})
+	return jobs, err
+}
+
+// Cancel stops a running job and returns its state
+func (c *Client) Cancel(id int) (Job, error) {
+	return c.job(Request{Method: MethodCancel, Job: id})
+}
+
+// Logs passes the output of a job so far to output and, with follow,
+// what it writes until it finishes. It returns the job's state as of
+// the last chunk. An error returned by output leaves the connection
+// mid-stream, so the client must be closed after it.
+func (c *Client) Logs(id int, follow bool, output func(chunk string) error) (Job, error) {
+	var job Job
+	err := c.call(Request{Method: MethodLogs, Job: id, Follow: follow}, func(resp Response) error {
+		if resp.Job != nil {
+			job = *resp.Job
+		}
+		if resp.Output == "" {
+			return nil
+		}
+		return output(resp.Output)
+	})
+	return job, err
+}
+
+// Status describes the server and what it runs
+func (c *Client) Status() (Status, error) {
+	var status Status
+	err := c.call(Request{Method: MethodStatus}, func(resp Response) error {
+		if resp.Status == nil {
+			return fmt.Errorf("status answered without a status")
+		}
+		status = *resp.Status
+		return nil
+	})
+	return status, err
+}
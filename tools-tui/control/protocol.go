@@ -0,0 +1,162 @@
+// Package control is the control protocol of tools-tui: jobs are
+// submitted, their output streamed and their state queried over a
+// stream connection, usually the Unix socket of `tools-tui daemon` or
+// `tools-tui control serve`.
+//
+// Every message is a frame: a 4-byte big-endian length followed by that
+// many bytes of JSON. A client writes a Request and reads Responses
+// carrying the request's ID until one is marked Done; only logs with
+// Follow answers with more than one.
+package control
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MaxFrame is the largest frame either side accepts
+const MaxFrame = 16 << 20
+
+// Methods of a Request
+const (
+	MethodSubmit = "submit"
+	MethodState  = "state"
+	MethodLogs   = "logs"
+	MethodCancel = "cancel"
+	MethodStatus = "status"
+)
+
+// States of a Job
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// Request asks the server to do one thing
+type Request struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	// Job is the job that state, logs and cancel act on; state lists
+	// every job when it is 0
+	Job int `json:"job,omitempty"`
+	// Submit describes the job to start
+	Submit *Submit `json:"submit,omitempty"`
+	// Follow keeps logs streaming the job's output until it finishes
+	Follow bool `json:"follow,omitempty"`
+}
+
+// Submit describes a run of a catalog tool. Yes and Override answer the
+// prompts of the TUI: Yes confirms sandboxed tools and Override runs
+// dangerous tools during quiet hours.
+type Submit struct {
+	Tool     string            `json:"tool"`
+	Args     map[string]string `json:"args,omitempty"`
+	Project  string            `json:"project,omitempty"`
+	Yes      bool              `json:"yes,omitempty"`
+	Override bool              `json:"override,omitempty"`
+}
+
+// Origins of a Job that was not submitted by a client
+const (
+	OriginSchedule = "schedule"
+	OriginWebhook  = "webhook"
+)
+
+// Job is the state of a submitted run
+type Job struct {
+	ID   int    `json:"id"`
+	Tool string `json:"tool"`
+	// Origin is what started the job when no client submitted it
+	Origin   string     `json:"origin,omitempty"`
+	Command  string     `json:"command"`
+	Dir      string     `json:"dir,omitempty"`
+	State    string     `json:"state"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	ExitCode int        `json:"exit_code,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	// RunID is the run history record of a finished job
+	RunID string `json:"run_id,omitempty"`
+}
+
+// Done reports whether the job has finished
+func (j Job) Done() bool {
+	return j.State != StateRunning
+}
+
+// Schedule is a tool the daemon runs on a cron schedule
+type Schedule struct {
+	Tool string    `json:"tool"`
+	Cron string    `json:"cron"`
+	Next time.Time `json:"next,omitempty"`
+}
+
+// Status describes the server: what it runs besides submitted jobs
+type Status struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	// Daemon is set when the server is `tools-tui daemon`, which also
+	// runs the schedules and the webhook listener
+	Daemon    bool       `json:"daemon,omitempty"`
+	Running   int        `json:"running"`
+	Jobs      int        `json:"jobs"`
+	Schedules []Schedule `json:"schedules,omitempty"`
+	// Webhooks is the address of the webhook listener, if any
+	Webhooks string `json:"webhooks,omitempty"`
+	// Reloaded is when the catalog or config was last read again
+	Reloaded *time.Time `json:"reloaded,omitempty"`
+}
+
+// Response answers a Request
+type Response struct {
+	ID     int     `json:"id"`
+	Error  string  `json:"error,omitempty"`
+	Job    *Job    `json:"job,omitempty"`
+	Jobs   []Job   `json:"jobs,omitempty"`
+	Status *Status `json:"status,omitempty"`
+	// Output is a chunk of the job's combined output
+	Output string `json:"output,omitempty"`
+	// Done marks the last response to a request
+	Done bool `json:"done"`
+}
+
+// WriteFrame writes v as one frame
+func WriteFrame(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(data) > MaxFrame {
+		return fmt.Errorf("frame of %d bytes exceeds %d", len(data), MaxFrame)
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadFrame reads one frame into v. It returns io.EOF only when the
+// stream ends between frames.
+func ReadFrame(r io.Reader, v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MaxFrame {
+		return fmt.Errorf("frame of %d bytes exceeds %d", n, MaxFrame)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,112 @@
+package control
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		out  interface{}
+	}{
+		{"request", &Request{ID: 7, Method: MethodLogs, Job: 3, Follow: true}, &Request{}},
+		{"submit", &Request{ID: 1, Method: MethodSubmit, Submit: &Submit{Tool: "Tester", Args: map[string]string{"file": "a b", "name": "ü"}}}, &Request{}},
+		{"response", &Response{ID: 7, Output: "line\n\x1b[31mred\x1b[0m\n", Done: true}, &Response{}},
+		{"empty", &Response{}, &Response{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteFrame(&buf, tt.in); err != nil {
+				t.Fatal(err)
+			}
+			if size := binary.BigEndian.Uint32(buf.Bytes()); int(size) != buf.Len()-4 {
+				t.Fatalf("length prefix %d, frame of %d bytes", size, buf.Len()-4)
+			}
+			if err := ReadFrame(&buf, tt.out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.in, tt.out) {
+				t.Errorf("read %+v, wrote %+v", tt.out, tt.in)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("%d bytes left after the frame", buf.Len())
+			}
+		})
+	}
+}
+
+func TestFrameSequence(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 1; i <= 3; i++ {
+		if err := WriteFrame(&buf, Response{ID: i, Done: i == 3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		var resp Response
+		if err := ReadFrame(&buf, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.ID != i || resp.Done != (i == 3) {
+			t.Errorf("frame %d read as %+v", i, resp)
+		}
+	}
+	if err := ReadFrame(&buf, &Response{}); err != io.EOF {
+		t.Errorf("after the last frame: %v, want EOF", err)
+	}
+}
+
+func TestWriteFrameOversize(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteFrame(&buf, Response{Output: strings.Repeat("x", MaxFrame)})
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("WriteFrame of an oversize frame: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %d bytes of a rejected frame", buf.Len())
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	frame := func(size uint32, body string) []byte {
+		data := binary.BigEndian.AppendUint32(nil, size)
+		return append(data, body...)
+	}
+	tests := []struct {
+		name  string
+		input []byte
+		want  func(error) bool
+	}{
+		{"no data", nil, func(err error) bool { return err == io.EOF }},
+		{"truncated length", []byte{0, 0}, func(err error) bool { return errors.Is(err, io.ErrUnexpectedEOF) }},
+		{"truncated body", frame(10, `{"id":`), func(err error) bool { return errors.Is(err, io.ErrUnexpectedEOF) }},
+		{"length without body", frame(2, ""), func(err error) bool { return errors.Is(err, io.ErrUnexpectedEOF) }},
+		{"oversize length", frame(MaxFrame+1, "{}"), func(err error) bool { return err != nil && strings.Contains(err.Error(), "exceeds") }},
+		{"largest length", frame(1<<32-1, ""), func(err error) bool { return err != nil && strings.Contains(err.Error(), "exceeds") }},
+		{"invalid JSON", frame(3, "{x}"), func(err error) bool {
+			var syntax *json.SyntaxError
+			return errors.As(err, &syntax)
+		}},
+		{"empty frame", frame(0, ""), func(err error) bool {
+			var syntax *json.SyntaxError
+			return errors.As(err, &syntax)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp Response
+			if err := ReadFrame(bytes.NewReader(tt.input), &resp); !tt.want(err) {
+				t.Errorf("ReadFrame: unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,586 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"tools-tui/control"
+)
+
+// controlSocket is the Unix socket of the control server in the config
+// directory
+const controlSocket = "control.sock"
+
+// controlChunk is the most output sent in one logs response
+const controlChunk = 1 << 20
+
+// controlOutputLimit is the most output of a job kept in memory; what
+// comes before is dropped, the whole output being kept in the run history
+const controlOutputLimit = 1 << 20
+
+// jobOutput is the tail of a job's output
+type jobOutput struct {
+	text strings.Builder
+	// dropped counts the bytes discarded from the front
+	dropped int
+}
+
+// write appends a chunk, dropping the front once the output is twice
+// the limit so that trimming happens rarely
+func (o *jobOutput) write(chunk string) {
+	o.text.WriteString(chunk)
+	if o.text.Len() <= 2*controlOutputLimit {
+		return
+	}
+	text := o.text.String()
+	cut := len(text) - controlOutputLimit
+	for cut < len(text) && !utf8.RuneStart(text[cut]) {
+		cut++
+	}
+	o.text.Reset()
+	o.text.WriteString(text[cut:])
+	o.dropped += cut
+}
+
+// controlJob is a job run by the control server
+type controlJob struct {
+	info   control.Job
+	output jobOutput
+	cancel context.CancelFunc
+	// changed is closed and replaced whenever output is added or the job
+	// finishes, waking the clients that follow its logs
+	changed chan struct{}
+	// done is closed once the job has finished
+	done chan struct{}
+}
+
+// notify wakes the clients following the job; the server's lock must
+// be held
+func (j *controlJob) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// controlServer runs the jobs submitted over the control protocol with
+// the checks and executor of the run subcommand
+type controlServer struct {
+	mu         sync.Mutex
+	categories []Category
+	jobs       []*controlJob
+	started    time.Time
+	// describe adds what the daemon runs to the status
+	describe func(*control.Status)
+}
+
+// newControlServer returns a server running the tools of the catalog
+func newControlServer(categories []Category) *controlServer {
+	return &controlServer{categories: categories, started: time.Now()}
+}
+
+// SetCatalog replaces the tools jobs are looked up in
+func (s *controlServer) SetCatalog(categories []Category) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories = categories
+}
+
+// catalog returns the tools jobs are looked up in
+func (s *controlServer) catalog() []Category {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.categories
+}
+
+// Serve answers the connections accepted by l until it is closed
+func (s *controlServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers the requests of one connection in order
+func (s *controlServer) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var req control.Request
+		if err := control.ReadFrame(conn, &req); err != nil {
+			return
+		}
+		send := func(resp control.Response) error {
+			resp.ID = req.ID
+			return control.WriteFrame(conn, resp)
+		}
+		if err := s.dispatch(req, send); err != nil {
+			return
+		}
+	}
+}
+
+// dispatch answers one request. It returns an error only when the
+// connection failed; errors of the request are sent to the client.
+func (s *controlServer) dispatch(req control.Request, send func(control.Response) error) error {
+	fail := func(err error) error {
+		return send(control.Response{Error: err.Error(), Done: true})
+	}
+	switch req.Method {
+	case control.MethodSubmit:
+		if req.Submit == nil {
+			return fail(fmt.Errorf("submit needs a tool"))
+		}
+		job, err := s.submit(*req.Submit)
+		if err != nil {
+			return fail(err)
+		}
+		return send(control.Response{Job: &job, Done: true})
+	case control.MethodState:
+		if req.Job == 0 {
+			return send(control.Response{Jobs: s.states(), Done: true})
+		}
+		job, err := s.state(req.Job)
+		if err != nil {
+			return fail(err)
+		}
+		return send(control.Response{Job: &job, Done: true})
+	case control.MethodCancel:
+		job, err := s.cancel(req.Job)
+		if err != nil {
+			return fail(err)
+		}
+		return send(control.Response{Job: &job, Done: true})
+	case control.MethodLogs:
+		return s.logs(req, send)
+	case control.MethodStatus:
+		status := s.status()
+		return send(control.Response{Status: &status, Done: true})
+	}
+	return fail(fmt.Errorf("unknown method %q", req.Method))
+}
+
+// job returns the job with the given id; the lock must be held
+func (s *controlServer) job(id int) (*controlJob, error) {
+	if id < 1 || id > len(s.jobs) {
+		return nil, fmt.Errorf("no job %d", id)
+	}
+	return s.jobs[id-1], nil
+}
+
+// states returns the state of every job
+func (s *controlServer) states() []control.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]control.Job, len(s.jobs))
+	for i, job := range s.jobs {
+		jobs[i] = job.info
+	}
+	return jobs
+}
+
+// state returns the state of one job
+func (s *controlServer) state(id int) (control.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, err := s.job(id)
+	if err != nil {
+		return control.Job{}, err
+	}
+	return job.info, nil
+}
+
+// status describes the server and, for the daemon, what else it runs
+func (s *controlServer) status() control.Status {
+	s.mu.Lock()
+	status := control.Status{PID: os.Getpid(), Started: s.started, Jobs: len(s.jobs)}
+	for _, job := range s.jobs {
+		if !job.info.Done() {
+			status.Running++
+		}
+	}
+	s.mu.Unlock()
+	if s.describe != nil {
+		s.describe(&status)
+	}
+	return status
+}
+
+// cancel stops a running job; its state changes once it has exited
+func (s *controlServer) cancel(id int) (control.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, err := s.job(id)
+	if err != nil {
+		return control.Job{}, err
+	}
+	if job.info.Done() {
+		return job.info, fmt.Errorf("job %d has already %s", id, job.info.State)
+	}
+	job.cancel()
+	return job.info, nil
+}
+
+// cancelAll stops every running job
+func (s *controlServer) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		job.cancel()
+	}
+}
+
+// submit checks a run like the run subcommand and starts it
+func (s *controlServer) submit(submit control.Submit) (control.Job, error) {
+	tool, err := lookupTool(s.catalog(), submit.Tool)
+	if err != nil {
+		return control.Job{}, err
+	}
+	values := map[string]string{}
+	for name, value := range submit.Args {
+		values[name] = value
+	}
+	run, err := prepareRun(tool, values, submit.Yes, submit.Override)
+	if err != nil {
+		return control.Job{}, err
+	}
+	_, info := s.start(run, submit.Project, values, "")
+	return info, nil
+}
+
+// start runs a checked tool as a new job and returns it with its state
+// as it started; origin says what started it when no client did
+func (s *controlServer) start(run Tool, projectDir string, values map[string]string, origin string) (*controlJob, control.Job) {
+	dir, _ := scopedCommand(&run, projectDir)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	job := &controlJob{
+		info: control.Job{
+			ID:      len(s.jobs) + 1,
+			Tool:    run.Key(),
+			Origin:  origin,
+			Command: run.Command,
+			Dir:     dir,
+			State:   control.StateRunning,
+			Started: time.Now(),
+		},
+		cancel:  cancel,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.jobs = append(s.jobs, job)
+	info := job.info
+	s.mu.Unlock()
+
+	go s.run(ctx, job, &run, projectDir, values)
+	return job, info
+}
+
+// runStep runs a due step of the scheduler as a job, so clients can
+// list it and follow its output, and waits for it to finish
+func (s *controlServer) runStep(categories []Category, step StepResult) StepResult {
+	run, defaults, step, ok := prepareStep(categories, step, false)
+	if !ok {
+		return step
+	}
+	job, _ := s.start(run, step.Project, defaults, control.OriginSchedule)
+	<-job.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	step.RunID = job.info.RunID
+	step.DurationMs = job.info.Finished.Sub(job.info.Started).Milliseconds()
+	step.Success = job.info.State == control.StateSucceeded
+	step.Error = job.info.Error
+	step.Output = truncateOutput(job.output.text.String(), maxStepOutput)
+	return step
+}
+
+// run executes a job, collecting its output, and records it in the run
+// history
+func (s *controlServer) run(ctx context.Context, job *controlJob, tool *Tool, projectDir string, values map[string]string) {
+	env := CaptureEnv(job.info.Dir)
+	var output string
+	err := fmt.Errorf("%s ended without an exit status", tool.Name)
+	for msg := range StreamTool(ctx, tool, projectDir) {
+		switch msg := msg.(type) {
+		case streamOutputMsg:
+			s.mu.Lock()
+			job.output.write(msg.chunk)
+			job.notify()
+			s.mu.Unlock()
+		case streamDoneMsg:
+			output, err = msg.output, msg.err
+		}
+	}
+	record := newRunRecord(tool, projectDir, job.info.Started, env, output, err)
+	record.Args = argValues(values)
+	if herr := AppendHistory(record, output); herr != nil {
+		log.Printf("job %d: could not record run history: %v", job.info.ID, herr)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	finished := time.Now()
+	job.info.Finished, job.info.RunID = &finished, record.ID
+	switch {
+	case ctx.Err() != nil:
+		job.info.State = control.StateCancelled
+	case err != nil:
+		job.info.State = control.StateFailed
+	default:
+		job.info.State = control.StateSucceeded
+	}
+	if err != nil {
+		job.info.ExitCode, job.info.Error = exitCode(err), err.Error()
+	}
+	job.cancel()
+	job.notify()
+	close(job.done)
+	log.Printf("job %d: %s %s", job.info.ID, tool.Name, job.info.State)
+}
+
+// logs sends the output of a job so far and, when following, what it
+// writes until it finishes. Output dropped before it was sent is skipped.
+func (s *controlServer) logs(req control.Request, send func(control.Response) error) error {
+	offset := 0
+	for {
+		s.mu.Lock()
+		job, err := s.job(req.Job)
+		if err != nil {
+			s.mu.Unlock()
+			return send(control.Response{Error: err.Error(), Done: true})
+		}
+		output, dropped := job.output.text.String(), job.output.dropped
+		info, changed := job.info, job.changed
+		s.mu.Unlock()
+
+		start := max(offset-dropped, 0)
+		end := len(output)
+		if end-start > controlChunk {
+			end = start + controlChunk
+			for end > start && !utf8.RuneStart(output[end]) {
+				end--
+			}
+		}
+		chunk, more := output[start:end], end < len(output)
+		offset = dropped + end
+		done := !more && (!req.Follow || info.Done())
+		if chunk != "" || done {
+			if err := send(control.Response{Job: &info, Output: chunk, Done: done}); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+		if !more {
+			<-changed
+		}
+	}
+}
+
+// controlSocketPath returns the default location of the control socket
+func controlSocketPath() string {
+	return filepath.Join(ConfigDir(), controlSocket)
+}
+
+// listenControl listens on the control socket, replacing a socket left
+// behind by a server that is no longer running
+func listenControl(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a control server is already listening on %s", path)
+	}
+	os.Remove(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// only the user may submit jobs: the socket is created in a private
+	// directory and moved into place once only the user can connect
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".control-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	private := filepath.Join(dir, controlSocket)
+	l, err := net.Listen("unix", private)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(private, 0600); err == nil {
+		err = os.Rename(private, path)
+	}
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &controlListener{Listener: l, path: path}, nil
+}
+
+// controlListener removes the control socket from where it was moved
+// when closed, as the listener only knows the path it was created at
+type controlListener struct {
+	net.Listener
+	path string
+}
+
+// Close stops listening and removes the socket
+func (l *controlListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// controlUsage lists the control subcommands
+const controlUsage = `usage: tools-tui control <command> [--socket path]
+  serve                                run submitted jobs until interrupted
+  submit <tool> [--arg name=value]... [--project dir] [--yes] [--override] [--follow]
+  state [job]                          print one job or every job as JSON
+  logs <job> [--follow]                print a job's output
+  cancel <job>                         stop a running job
+  status                               describe the server, and the schedules and webhooks of a daemon`
+
+// runControl implements the control subcommand: a server running jobs
+// submitted over the control socket, and a client for it
+func runControl(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", controlUsage)
+	}
+	command, args := args[0], args[1:]
+	var positional string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("control "+command, flag.ExitOnError)
+	socket := fs.String("socket", controlSocketPath(), "Unix socket of the control server")
+	values := argFlags{}
+	fs.Var(values, "arg", "value for a command placeholder as name=value (repeatable)")
+	project := fs.String("project", "", "sub-project directory scoped tools run in")
+	yes := fs.Bool("yes", false, "run tools whose trust tier asks for confirmation")
+	override := fs.Bool("override", false, "run dangerous tools during quiet hours")
+	follow := fs.Bool("follow", false, "stream the job's output until it finishes")
+	fs.Parse(args)
+
+	if command == "serve" {
+		return serveControl(*socket)
+	}
+	client, err := control.Dial(*socket)
+	if err != nil {
+		return fmt.Errorf("no control server on %s, start one with tools-tui daemon or tools-tui control serve: %v", *socket, err)
+	}
+	defer client.Close()
+	jobID := func() (int, error) {
+		id, err := strconv.Atoi(positional)
+		if err != nil {
+			return 0, fmt.Errorf("%s needs a job number", command)
+		}
+		return id, nil
+	}
+	printLogs := func(id int) error {
+		job, err := client.Logs(id, *follow, func(chunk string) error {
+			_, err := fmt.Print(chunk)
+			return err
+		})
+		if err == nil && job.State == control.StateFailed {
+			err = fmt.Errorf("job %d failed: %s", id, job.Error)
+		}
+		return err
+	}
+
+	switch command {
+	case "submit":
+		if positional == "" {
+			return fmt.Errorf("submit needs a tool")
+		}
+		job, err := client.Submit(control.Submit{Tool: positional, Args: values, Project: *project, Yes: *yes, Override: *override})
+		if err != nil {
+			return err
+		}
+		if *follow {
+			return printLogs(job.ID)
+		}
+		return printJSON(job)
+	case "state":
+		if positional == "" {
+			jobs, err := client.Jobs()
+			if err != nil {
+				return err
+			}
+			return printJSON(jobs)
+		}
+		id, err := jobID()
+		if err != nil {
+			return err
+		}
+		job, err := client.Job(id)
+		if err != nil {
+			return err
+		}
+		return printJSON(job)
+	case "status":
+		status, err := client.Status()
+		if err != nil {
+			return err
+		}
+		return printJSON(status)
+	case "logs":
+		id, err := jobID()
+		if err != nil {
+			return err
+		}
+		return printLogs(id)
+	case "cancel":
+		id, err := jobID()
+		if err != nil {
+			return err
+		}
+		job, err := client.Cancel(id)
+		if err != nil {
+			return err
+		}
+		return printJSON(job)
+	}
+	return fmt.Errorf("unknown control command %q\n%s", command, controlUsage)
+}
+
+// serveControl runs the control server on the socket until interrupted,
+// then cancels the jobs still running
+func serveControl(path string) error {
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	l, err := listenControl(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	server := newControlServer(categories)
+	log.Printf("control server listening on %s", path)
+	err = server.Serve(l)
+	server.cancelAll()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
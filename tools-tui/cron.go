@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronAliases expands the shorthand schedules
+var cronAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+// cronField bounds the values of one cron field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+// cronFields are the five fields of a cron expression in order
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// CronSpec is a parsed five-field cron expression. Each field is a
+// bitset of the values it matches.
+type CronSpec struct {
+	Expr                          string
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// ParseCron parses "minute hour day-of-month month day-of-week" with
+// lists, ranges and steps, or one of the @hourly/@daily/... aliases.
+// Day of week 7 is accepted as Sunday.
+func ParseCron(expr string) (CronSpec, error) {
+	spec := CronSpec{Expr: expr}
+	if alias, ok := cronAliases[strings.TrimSpace(expr)]; ok {
+		expr = alias
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return spec, fmt.Errorf("schedule %q: expected 5 fields (minute hour day month weekday)", spec.Expr)
+	}
+	sets := make([]uint64, len(parts))
+	for i, part := range parts {
+		field := cronFields[i]
+		if i == 4 {
+			field.max = 7
+		}
+		set, err := parseCronField(part, field)
+		if err != nil {
+			return spec, fmt.Errorf("schedule %q: %v", spec.Expr, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+	spec.minute, spec.hour, spec.dom, spec.month, spec.dow = sets[0], sets[1], sets[2], sets[3], sets[4]
+	spec.domAny, spec.dowAny = parts[2] == "*", parts[4] == "*"
+	return spec, nil
+}
+
+// parseCronField parses one comma separated field into a bitset
+func parseCronField(text string, field cronField) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(text, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, field.name)
+			}
+			step = n
+		}
+		lo, hi := field.min, field.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", field.name, item)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", field.name, item)
+				}
+			} else if hasStep {
+				hi = field.max
+			}
+		}
+		if lo < field.min || hi > field.max || lo > hi {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", field.name, item, field.min, field.max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// matchesDay applies cron's rule that a restricted day of month and day
+// of week match when either does
+func (c CronSpec) matchesDay(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowOK
+	case c.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// Next returns the first matching minute strictly after t, or the zero
+// time when none occurs within five years. Times skipped when clocks go
+// forward do not occur, and a time repeated when they go back matches
+// once.
+func (c CronSpec) Next(t time.Time) time.Time {
+	after := wallClock(t)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = advanceTo(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()))
+		case !c.matchesDay(t):
+			t = advanceTo(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			// by elapsed minutes: the local hour after a gap does not exist
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0 || !wallClock(t).After(after):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// advanceTo returns the start of a later day or month, or t plus an
+// hour when that midnight falls in a daylight saving gap and resolves
+// to no later than t
+func advanceTo(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Hour)
+}
+
+// wallClock is the local date and time of t read as UTC, which orders
+// times by what the clock showed
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// Between lists the matching times in (from, to], at most max of them
+func (c CronSpec) Between(from, to time.Time, max int) []time.Time {
+	var times []time.Time
+	for t := c.Next(from); !t.IsZero() && !t.After(to) && len(times) < max; t = c.Next(t) {
+		times = append(times, t)
+	}
+	return times
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	utc := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	ny := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, newYork)
+	}
+	// the second 01:30 of the night clocks go back
+	repeated := ny(2026, 11, 1, 1, 30).Add(time.Hour)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"strictly after", "30 7 * * *", utc(2026, 5, 4, 7, 30), utc(2026, 5, 5, 7, 30)},
+		{"seconds truncated", "* * * * *", utc(2026, 5, 4, 7, 30).Add(59 * time.Second), utc(2026, 5, 4, 7, 31)},
+		{"next hour", "0 * * * *", utc(2026, 5, 4, 23, 0), utc(2026, 5, 5, 0, 0)},
+		{"end of month", "0 0 1 * *", utc(2026, 1, 31, 12, 0), utc(2026, 2, 1, 0, 0)},
+		{"end of year", "0 0 * * *", utc(2026, 12, 31, 23, 59), utc(2027, 1, 1, 0, 0)},
+		{"31st skips short months", "0 0 31 * *", utc(2026, 1, 31, 0, 0), utc(2026, 3, 31, 0, 0)},
+		{"30th skips February", "0 9 30 * *", utc(2026, 1, 30, 9, 0), utc(2026, 3, 30, 9, 0)},
+		{"leap day", "0 0 29 2 *", utc(2026, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"never", "0 0 30 2 *", utc(2026, 1, 1, 0, 0), time.Time{}},
+		{"day of week", "0 9 * * 1", utc(2026, 5, 6, 0, 0), utc(2026, 5, 11, 9, 0)},
+		{"7 is Sunday", "0 9 * * 7", utc(2026, 5, 4, 0, 0), utc(2026, 5, 10, 9, 0)},
+		{"weekdays range", "0 9 * * 1-5", utc(2026, 5, 8, 10, 0), utc(2026, 5, 11, 9, 0)},
+		{"day of month or week, week first", "0 0 13 * 5", utc(2026, 5, 4, 0, 0), utc(2026, 5, 8, 0, 0)},
+		{"day of month or week, month first", "0 0 13 * 5", utc(2026, 5, 9, 0, 0), utc(2026, 5, 13, 0, 0)},
+		{"day of month with any weekday", "0 0 13 * *", utc(2026, 5, 4, 0, 0), utc(2026, 5, 13, 0, 0)},
+		{"month and weekday", "0 0 * 2 0", utc(2026, 5, 4, 0, 0), utc(2027, 2, 7, 0, 0)},
+		{"steps", "*/20 */6 * * *", utc(2026, 5, 4, 6, 41), utc(2026, 5, 4, 12, 0)},
+		{"alias", "@monthly", utc(2026, 5, 4, 0, 0), utc(2026, 6, 1, 0, 0)},
+		{"gap skips the missing time", "30 2 * * *", ny(2026, 3, 7, 3, 0), ny(2026, 3, 9, 2, 30)},
+		{"gap hourly", "0 * * * *", ny(2026, 3, 8, 1, 0), ny(2026, 3, 8, 3, 0)},
+		{"gap minutely", "* * * * *", ny(2026, 3, 8, 1, 59), ny(2026, 3, 8, 3, 0)},
+		{"repeated time first", "30 1 * * *", ny(2026, 11, 1, 0, 0), ny(2026, 11, 1, 1, 30)},
+		{"repeated time once", "30 1 * * *", ny(2026, 11, 1, 1, 30), ny(2026, 11, 2, 1, 30)},
+		{"repeated hour once", "0 * * * *", ny(2026, 11, 1, 1, 0), ny(2026, 11, 1, 2, 0)},
+		{"inside the repeated hour", "45 * * * *", repeated, repeated.Add(15 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spec.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) of %q = %s, want %s", tt.from, tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"tools-tui/control"
+)
+
+// daemon runs the jobs of the control server, the tools with a schedule,
+// the webhook rules and the e-mail digest without the TUI, reloading the
+// catalog and config when their files change
+type daemon struct {
+	server    *controlServer
+	scheduler *Scheduler
+	webhooks  *webhookServer
+	// servers runs the MCP servers admins start over the REST API
+	servers *Supervisor
+
+	mu         sync.Mutex
+	categories []Category
+	quiet      *QuietHours
+	rules      *WebhookConfig
+	mcp        map[string]MCPServerConfig
+	digest     *DigestConfig
+	digesting  bool
+	catalogMod time.Time
+	configMod  time.Time
+	reloaded   *time.Time
+}
+
+// runDaemon implements `tools-tui daemon`: the control server on its
+// Unix socket, the scheduler, with --listen the webhook listener and
+// with --serve the REST API, until interrupted
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socket := fs.String("socket", controlSocketPath(), "Unix socket of the control server")
+	listen := fs.String("listen", "", "accept webhook deliveries on this address, - for the one in config.json")
+	serve := fs.String("serve", "", "serve the REST API on this address, such as :8080")
+	fs.Parse(args)
+
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	cfg, err := LoadConfig()
+	if err == nil {
+		err = cfg.validateDaemon()
+	}
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	l, err := listenControl(*socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(*socket)
+
+	d := &daemon{
+		server:     newControlServer(categories),
+		scheduler:  NewScheduler(categories, cfg.QuietHours),
+		categories: categories,
+		quiet:      cfg.QuietHours,
+		rules:      cfg.Webhooks,
+		servers:    NewSupervisor(),
+		mcp:        cfg.MCPServers,
+		digest:     cfg.Digest,
+		catalogMod: fileModTime(manifestPath()),
+		configMod:  configModTime(),
+	}
+	d.scheduler.runner = d.server.runStep
+	d.server.describe = d.describe
+	var events chan webhookEvent
+	if *listen != "" {
+		addr := *listen
+		if addr == "-" {
+			addr = defaultWebhookAddr
+			if cfg.Webhooks != nil && cfg.Webhooks.Addr != "" {
+				addr = cfg.Webhooks.Addr
+			}
+		}
+		if d.webhooks, err = startWebhooks(addr); err != nil {
+			l.Close()
+			return fmt.Errorf("webhook listener: %v", err)
+		}
+		defer d.webhooks.Stop()
+		events = d.webhooks.events
+		log.Printf("accepting webhook deliveries on %s", d.webhooks.addr)
+	}
+
+	if *serve != "" {
+		access, err := LoadAccessConfig()
+		if err != nil {
+			l.Close()
+			return err
+		}
+		api := &http.Server{Handler: newAPIMux(d, access)}
+		al, err := net.Listen("tcp", *serve)
+		if err != nil {
+			l.Close()
+			return fmt.Errorf("REST API: %v", err)
+		}
+		go api.Serve(al)
+		defer api.Close()
+		log.Printf("serving the REST API on %s", al.Addr())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	served := make(chan error, 1)
+	go func() { served <- d.server.Serve(l) }()
+	d.scheduler.Start()
+	log.Printf("daemon listening on %s with %d scheduled tools", *socket, len(scheduledTools(categories)))
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.Close()
+			d.shutdown()
+			return nil
+		case err := <-served:
+			d.shutdown()
+			return err
+		case <-ticker.C:
+			d.watch()
+			d.sendDigest()
+		case result := <-d.scheduler.results:
+			log.Print(result.Label())
+		case e := <-events:
+			d.receive(e)
+		}
+	}
+}
+
+// shutdown stops scheduling, cancels the jobs still running and stops
+// the MCP servers
+func (d *daemon) shutdown() {
+	d.scheduler.Stop()
+	d.server.cancelAll()
+	d.servers.StopAll()
+}
+
+// validateDaemon checks the parts of config.json the daemon acts on
+func (c *Config) validateDaemon() error {
+	if err := c.Webhooks.validate(); err != nil {
+		return err
+	}
+	if c.Digest != nil {
+		return c.Digest.validate()
+	}
+	return nil
+}
+
+// sendDigest sends the digest in the background when config.json has a
+// digest section and its schedule is due
+func (d *daemon) sendDigest() {
+	d.mu.Lock()
+	cfg, categories := d.digest, d.categories
+	if cfg == nil || d.digesting {
+		d.mu.Unlock()
+		return
+	}
+	d.digesting = true
+	d.mu.Unlock()
+	go func() {
+		sent, err := sendDueDigest(cfg, categories, time.Now())
+		switch {
+		case err != nil:
+			log.Printf("digest: %v", err)
+		case sent:
+			log.Printf("sent the digest to %s", strings.Join(cfg.To, ", "))
+		}
+		d.mu.Lock()
+		d.digesting = false
+		d.mu.Unlock()
+	}()
+}
+
+// mcpServers returns the MCP servers of the repository and config.json
+func (d *daemon) mcpServers() map[string]MCPServerConfig {
+	d.mu.Lock()
+	configured := d.mcp
+	d.mu.Unlock()
+	return LoadMCPServers(configured)
+}
+
+// fileModTime returns when a file last changed, or zero when it is
+// missing
+func fileModTime(path string) time.Time {
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// watch reloads the catalog and config when the manifest, config.json
+// or keys.toml changed. A file that no longer loads is reported and the
+// previous catalog and config are kept.
+func (d *daemon) watch() {
+	catalogMod, configMod := fileModTime(manifestPath()), configModTime()
+	d.mu.Lock()
+	changed := !catalogMod.Equal(d.catalogMod) || !configMod.Equal(d.configMod)
+	d.catalogMod, d.configMod = catalogMod, configMod
+	d.mu.Unlock()
+	if !changed {
+		return
+	}
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		log.Printf("keeping the previous catalog: %v", err)
+		return
+	}
+	cfg, err := LoadConfig()
+	if err == nil {
+		err = cfg.validateDaemon()
+	}
+	if err != nil {
+		log.Printf("keeping the previous config: %v", err)
+		return
+	}
+	d.server.SetCatalog(categories)
+	d.scheduler.SetCatalog(categories)
+	d.scheduler.SetQuietHours(cfg.QuietHours)
+	now := time.Now()
+	d.mu.Lock()
+	d.categories, d.quiet, d.rules, d.mcp, d.reloaded = categories, cfg.QuietHours, cfg.Webhooks, cfg.MCPServers, &now
+	d.digest = cfg.Digest
+	d.mu.Unlock()
+	log.Printf("reloaded the catalog and config: %d scheduled tools", len(scheduledTools(categories)))
+}
+
+// receive starts the tools of the auto rules matching a webhook event,
+// when they are approved for unattended runs. Rules that are not auto
+// need the TUI to start their tool.
+func (d *daemon) receive(e webhookEvent) {
+	d.mu.Lock()
+	categories, quiet, rules := d.categories, d.quiet, d.rules
+	d.mu.Unlock()
+	log.Printf("webhook: %s %s %s", e.Source, e.Label(), e.Repo)
+	if rules == nil {
+		return
+	}
+	for _, rule := range rules.Rules {
+		if !rule.matches(e) || !rule.Auto {
+			continue
+		}
+		tool := findTool(categories, rule.Tool)
+		if tool == nil {
+			log.Printf("webhook: %s is not in the catalog", rule.Tool)
+			continue
+		}
+		if reason := runApproval(tool, Unattended); reason != "" {
+			log.Printf("webhook: %s not started: %s", tool.Name, reason)
+			continue
+		}
+		if active, reason := quiet.Active(time.Now()); active && tool.Dangerous {
+			log.Printf("webhook: %s is dangerous and it is %s", tool.Name, reason)
+			continue
+		}
+		values := rule.values(e)
+		var err error
+		for _, p := range tool.placeholders() {
+			if _, ok := values[p.Name]; !ok {
+				values[p.Name] = p.Default
+			}
+			if err == nil {
+				err = p.checkArg(values[p.Name])
+			}
+		}
+		if err != nil {
+			log.Printf("webhook: %s not started: %v", tool.Name, err)
+			continue
+		}
+		run := *tool
+		run.Command = tool.fillCommand(values)
+		_, job := d.server.start(run, "", values, control.OriginWebhook)
+		log.Printf("webhook: started %s as job %d", tool.Name, job.ID)
+	}
+}
+
+// describe adds the schedules, the webhook listener and the last reload
+// to the status of the control server
+func (d *daemon) describe(status *control.Status) {
+	d.mu.Lock()
+	categories, reloaded := d.categories, d.reloaded
+	d.mu.Unlock()
+	status.Daemon, status.Reloaded = true, reloaded
+	now := time.Now()
+	for _, tool := range scheduledTools(categories) {
+		schedule := control.Schedule{Tool: tool.Key(), Cron: tool.Schedule}
+		if spec, err := ParseCron(tool.Schedule); err == nil {
+			schedule.Next = spec.Next(now)
+		}
+		status.Schedules = append(status.Schedules, schedule)
+	}
+	if d.webhooks != nil {
+		status.Webhooks = d.webhooks.addr
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffContext is how many unchanged lines surround each change
+const diffContext = 3
+
+// maxDiffEdits bounds the search for the shortest edit script; texts
+// that differ in more lines are shown as replaced whole
+const maxDiffEdits = 1000
+
+// diffLine is a line of an edit script: kind is ' ' for a line both
+// texts share, '-' for a removed one and '+' for an added one
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// splitLines splits text into lines, ignoring a final newline
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns an edit script turning a into b. The common start
+// and end are kept aside so the search only covers what changed.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	lines = append(lines, shortestEdit(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// shortestEdit finds the shortest edit script with Myers' algorithm.
+// trace[d] holds the furthest x reached on each diagonal k = x-y after
+// d edits, at index k+d.
+func shortestEdit(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	var trace [][]int
+	var prev []int
+search:
+	for d := 0; ; d++ {
+		if d > maxDiffEdits {
+			lines := make([]diffLine, 0, n+m)
+			for _, text := range a {
+				lines = append(lines, diffLine{'-', text})
+			}
+			for _, text := range b {
+				lines = append(lines, diffLine{'+', text})
+			}
+			return lines
+		}
+		cur := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			switch {
+			case d == 0:
+			case k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]):
+				x = prev[k+1+d-1]
+			default:
+				x = prev[k-1+d-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			cur[k+d] = x
+			if x >= n && y >= m {
+				trace = append(trace, cur)
+				break search
+			}
+		}
+		trace = append(trace, cur)
+		prev = cur
+	}
+
+	var reversed []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffLine{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			reversed = append(reversed, diffLine{'+', b[y-1]})
+		} else {
+			reversed = append(reversed, diffLine{'-', a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for ; x > 0; x, y = x-1, y-1 {
+		reversed = append(reversed, diffLine{' ', a[x-1]})
+	}
+	lines := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
+
+// UnifiedDiff compares two texts line by line in the unified format of
+// diff -u, with oldName and newName as the file headers. It returns ""
+// when they are the same.
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+	var b strings.Builder
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(lines); {
+		first := i
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		// a hunk runs until more than twice the context is unchanged
+		end := first + 1
+		for j := first + 1; j < len(lines) && j-end < 2*diffContext; j++ {
+			if lines[j].kind != ' ' {
+				end = j + 1
+			}
+		}
+		start, stop := max(first-diffContext, i), min(end+diffContext, len(lines))
+		// the lines skipped since the last hunk are all unchanged
+		oldLine, newLine = oldLine+start-i, newLine+start-i
+		oldCount, newCount := 0, 0
+		for _, line := range lines[start:stop] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, line := range lines[start:stop] {
+			b.WriteByte(line.kind)
+			b.WriteString(line.text)
+			b.WriteByte('\n')
+		}
+		oldLine, newLine = oldLine+oldCount, newLine+newCount
+		i = stop
+	}
+	return b.String()
+}
+
+// hunkRange writes the start and length of a hunk as diff -u does: an
+// empty range starts at the line before it
+func hunkRange(before, count int) string {
+	start := before + 1
+	if count == 0 {
+		start = before
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// colorDiff colours the lines of a unified diff for the terminal
+func colorDiff(diff string) string {
+	added := lipgloss.NewStyle().Foreground(commandStyle.GetForeground())
+	removed := lipgloss.NewStyle().Foreground(statusStyle.GetBackground())
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case i < 2 && (strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ")):
+			lines[i] = descriptionStyle.Bold(true).Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = featureStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = added.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removed.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDigestKeyringService is the keyring service holding the SMTP password
+const defaultDigestKeyringService = "tools-tui-smtp"
+
+// defaultDigestSchedule is when the daemon sends the digest: daily at 7
+const defaultDigestSchedule = "0 7 * * *"
+
+// digestStateFile records when the daemon last sent the digest
+const digestStateFile = "digest_state.json"
+
+// DigestConfig configures the e-mail digest of scheduled jobs, health
+// checks and workflow runs. The SMTP password is read from the system
+// keyring, never from config.json. The daemon sends it on Schedule, a
+// cron expression, daily at 7 when empty.
+type DigestConfig struct {
+	SMTPHost       string   `json:"smtp_host"`
+	SMTPPort       int      `json:"smtp_port"`
+	Username       string   `json:"username"`
+	From           string   `json:"from"`
+	To             []string `json:"to"`
+	KeyringService string   `json:"keyring_service,omitempty"`
+	Schedule       string   `json:"schedule,omitempty"`
+}
+
+// validate reports missing settings
+func (c *DigestConfig) validate() error {
+	if c == nil {
+		return fmt.Errorf("no \"digest\" section in %s", configFile)
+	}
+	if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+		return fmt.Errorf("digest needs smtp_host, from and to in %s", configFile)
+	}
+	if _, err := c.schedule(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// schedule parses when the daemon sends the digest
+func (c *DigestConfig) schedule() (CronSpec, error) {
+	expr := c.Schedule
+	if expr == "" {
+		expr = defaultDigestSchedule
+	}
+	spec, err := ParseCron(expr)
+	if err != nil {
+		return spec, fmt.Errorf("digest schedule %q: %v", expr, err)
+	}
+	return spec, nil
+}
+
+// digestState is what the daemon last did for the digest
+type digestState struct {
+	// Due is the latest time of the schedule that was handled
+	Due time.Time `json:"due"`
+	// Sent is when a digest was last delivered; the next one covers
+	// what happened since
+	Sent  time.Time `json:"sent"`
+	Error string    `json:"error,omitempty"`
+}
+
+// BuildDigest summarises the scheduled jobs and failing health checks of
+// the catalog, and the workflow runs and failing tools since a point in
+// time
+func BuildDigest(categories []Category, since time.Time) (subject, body string, err error) {
+	batches, err := LoadBatches()
+	if err != nil {
+		return "", "", err
+	}
+	records, err := LoadHistory()
+	if err != nil {
+		return "", "", err
+	}
+	schedule, err := LoadScheduleState()
+	if err != nil {
+		return "", "", err
+	}
+	records = filterHistory(records, since, time.Time{})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "tools-tui digest since %s\n\n", since.Local().Format("2006-01-02 15:04"))
+
+	scheduled, failedScheduled := writeScheduledJobs(&b, categories, schedule, records, since)
+	failingChecks := writeHealthChecks(&b, categories)
+
+	runs, failedRuns := 0, 0
+	b.WriteString("\nWorkflow runs\n-------------\n")
+	for _, batch := range batches {
+		if batch.Updated.Before(since) {
+			continue
+		}
+		runs++
+		if batch.Failed() > 0 {
+			failedRuns++
+		}
+		b.WriteString(batch.Label() + "\n")
+		for _, step := range batch.Steps {
+			if !step.Success {
+				fmt.Fprintf(&b, "    ✘ %s: %s\n", step.Tool, step.Error)
+			}
+		}
+	}
+	if runs == 0 {
+		b.WriteString("No workflow runs.\n")
+	}
+
+	stats := ComputeStats(records, since, time.Time{})
+	b.WriteString("\nFailing tools\n-------------\n")
+	failing := 0
+	for _, t := range stats.Tools {
+		if t.Failures > 0 {
+			failing++
+			fmt.Fprintf(&b, "%-32s %d of %d runs failed\n", t.Tool, t.Failures, t.Runs)
+		}
+	}
+	if failing == 0 {
+		b.WriteString("None.\n")
+	}
+	fmt.Fprintf(&b, "\n%d tool runs in total.\n", stats.Runs)
+
+	subject = fmt.Sprintf("[tools-tui] %d of %d scheduled jobs failed, %d failing health checks, %d of %d workflow runs failed",
+		failedScheduled, scheduled, failingChecks, failedRuns, runs)
+	return subject, b.String(), nil
+}
+
+// writeScheduledJobs lists the scheduled tools with the outcome of their
+// last run, their runs in the period and their next run, and returns
+// how many there are and how many last failed
+func writeScheduledJobs(b *strings.Builder, categories []Category, state map[string]scheduleState, records []RunRecord, since time.Time) (scheduled, failed int) {
+	b.WriteString("Scheduled jobs\n--------------\n")
+	for _, tool := range scheduledTools(categories) {
+		scheduled++
+		st := state[tool.Key()]
+		mark, outcome := "·", "not run yet"
+		switch {
+		case st.Running:
+			outcome = "running since " + st.Started.Local().Format("01-02 15:04")
+		case st.Started.IsZero():
+		case st.Success:
+			mark, outcome = "✔", "succeeded "+st.Started.Local().Format("01-02 15:04")
+		default:
+			mark, outcome = "✘", "failed "+st.Started.Local().Format("01-02 15:04")+": "+st.Error
+			failed++
+		}
+		if st.Deferred {
+			outcome += ", deferred for quiet hours"
+		}
+		runs, failures := 0, 0
+		for _, record := range records {
+			if record.Tool == tool.Key() {
+				runs++
+				if !record.Success {
+					failures++
+				}
+			}
+		}
+		fmt.Fprintf(b, "%s %-32s %-12s %s\n", mark, tool.Key(), tool.Schedule, outcome)
+		next := "none"
+		if spec, err := ParseCron(tool.Schedule); err == nil {
+			if at := spec.Next(time.Now()); !at.IsZero() {
+				next = at.Local().Format("01-02 15:04")
+			}
+		}
+		fmt.Fprintf(b, "    %d runs since %s, %d failed; next %s\n", runs, since.Local().Format("01-02 15:04"), failures, next)
+	}
+	if scheduled == 0 {
+		b.WriteString("No scheduled tools.\n")
+	}
+	return scheduled, failed
+}
+
+// writeHealthChecks probes the catalog and lists the tools whose health
+// check fails, returning how many
+func writeHealthChecks(b *strings.Builder, categories []Category) int {
+	b.WriteString("\nFailing health checks\n---------------------\n")
+	results := ProbeCatalog(categories)
+	keys := make([]string, 0, len(results))
+	for key, result := range results {
+		if result.State == ProbeFail {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(b, "✘ %-32s %s\n", key, results[key].Detail)
+	}
+	if len(keys) == 0 {
+		fmt.Fprintf(b, "None of %d tools.\n", len(results))
+	}
+	return len(keys)
+}
+
+// sendDueDigest sends the digest when its schedule is due at now,
+// covering what happened since the last one was sent, records the
+// outcome and reports whether it tried. The first call only starts the
+// schedule.
+func sendDueDigest(cfg *DigestConfig, categories []Category, now time.Time) (bool, error) {
+	spec, err := cfg.schedule()
+	if err != nil {
+		return false, err
+	}
+	var state digestState
+	if err := loadJSON(digestStateFile, &state); err != nil {
+		return false, err
+	}
+	if state.Due.IsZero() {
+		state.Due = now
+		return false, saveJSON(digestStateFile, state)
+	}
+	next := spec.Next(state.Due)
+	if next.IsZero() || next.After(now) {
+		return false, nil
+	}
+	for ; !next.IsZero() && !next.After(now); next = spec.Next(next) {
+		state.Due = next
+	}
+	since := state.Sent
+	if since.IsZero() {
+		since = now.Add(-24 * time.Hour)
+	}
+	subject, body, err := BuildDigest(categories, since)
+	if err == nil {
+		err = SendDigest(cfg, subject, body)
+	}
+	state.Error = ""
+	if err != nil {
+		state.Error = err.Error()
+	} else {
+		state.Sent = now
+	}
+	if serr := saveJSON(digestStateFile, state); err == nil {
+		err = serr
+	}
+	return true, err
+}
+
+// SendDigest delivers the digest over SMTP
+func SendDigest(cfg *DigestConfig, subject, body string) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		service := cfg.KeyringService
+		if service == "" {
+			service = defaultDigestKeyringService
+		}
+		password, err := keyringLookup(service, cfg.Username)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", cfg.Username, password, cfg.SMTPHost)
+	}
+
+	msg := "From: " + cfg.From + "\r\n" +
+		"To: " + strings.Join(cfg.To, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	addr := cfg.SMTPHost + ":" + strconv.Itoa(port)
+	return smtp.SendMail(addr, auth, cfg.From, cfg.To, []byte(msg))
+}
+
+// runDigest implements the digest subcommand
+func runDigest(args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	sinceFlag := fs.String("since", "24h", "start of the period (2006-01-02, 7d or 24h)")
+	send := fs.Bool("send", false, "e-mail the digest instead of printing it")
+	fs.Parse(args)
+
+	since, err := parseTimeBound(*sinceFlag, time.Now())
+	if err != nil {
+		return err
+	}
+	categories, err := LoadToolsFromInventory()
+	if err != nil {
+		return err
+	}
+	subject, body, err := BuildDigest(categories, since)
+	if err != nil {
+		return err
+	}
+	if !*send {
+		fmt.Println(subject)
+		fmt.Println()
+		fmt.Print(body)
+		return nil
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := SendDigest(cfg.Digest, subject, body); err != nil {
+		return err
+	}
+	fmt.Printf("Digest sent to %s\n", strings.Join(cfg.Digest.To, ", "))
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// DryRun describes what running a tool whose command is filled in
+// would do, without running it: the directory, the program and its
+// arguments, the placeholder values, the variables added to the
+// environment with secret values masked, and the sandbox
+func DryRun(tool *Tool, projectDir string, args map[string]string) (string, error) {
+	dir, command := scopedCommand(tool, projectDir)
+	dir, parts, err := tool.argv(dir, command)
+	if err != nil {
+		return "", err
+	}
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = quoteArg(part)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "cwd:      %s\n", dir)
+	fmt.Fprintf(&b, "command:  %s\n", command)
+	fmt.Fprintf(&b, "argv:     %s\n", strings.Join(quoted, " "))
+
+	names := make([]string, 0, len(args))
+	for name, value := range args {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		label := ""
+		if i == 0 {
+			label = "args:"
+		}
+		fmt.Fprintf(&b, "%-9s %s=%s\n", label, name, args[name])
+	}
+
+	var env []string
+	for _, entry := range tool.envList() {
+		name, _, _ := strings.Cut(entry, "=")
+		if _, secret := tool.Secrets[name]; !secret {
+			env = append(env, entry)
+		}
+	}
+	secrets := make([]string, 0, len(tool.Secrets))
+	for name := range tool.Secrets {
+		secrets = append(secrets, name)
+	}
+	sort.Strings(secrets)
+	for _, name := range secrets {
+		service := tool.Secrets[name]
+		if _, err := GetSecret(service); err != nil {
+			env = append(env, fmt.Sprintf("%s not set, secret %s is not stored", name, service))
+		} else {
+			env = append(env, fmt.Sprintf("%s=•••••• from secret %s", name, service))
+		}
+	}
+	for i, entry := range env {
+		label := ""
+		if i == 0 {
+			label = "env:"
+		}
+		fmt.Fprintf(&b, "%-9s %s\n", label, entry)
+	}
+	if len(env) == 0 {
+		b.WriteString("env:      inherited unchanged\n")
+	}
+
+	switch {
+	case !tool.Trust.Sandboxed():
+	case currentSandbox() == SandboxBwrap:
+		b.WriteString("sandbox:  bwrap with a read-only filesystem, a throwaway HOME and only the variables above\n")
+	case currentSandbox() == SandboxEnvOnly:
+		b.WriteString("sandbox:  NONE, bwrap is missing: only a throwaway HOME and the variables above (" + unsandboxedEnv + " is set)\n")
+	default:
+		b.WriteString("sandbox:  refused, bwrap is missing: install it or set " + unsandboxedEnv + "=1\n")
+	}
+	return b.String(), nil
+}
+
+// onPath reports whether a program is on the PATH
+func onPath(program string) bool {
+	_, err := exec.LookPath(program)
+	return err == nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvSnapshot captures the parts of the environment that commonly
+// explain why a run behaves differently: tool versions, selected
+// environment variables and the git revision. Keys are prefixed with
+// "tool:", "env:" or "git:".
+type EnvSnapshot map[string]string
+
+// envVersionCommands are the toolchains whose versions are recorded
+var envVersionCommands = map[string][]string{
+	"python": {"python3", "--version"},
+	"node":   {"node", "--version"},
+	"npm":    {"npm", "--version"},
+	"go":     {"go", "version"},
+	"git":    {"git", "--version"},
+	"pip":    {"pip3", "--version"},
+}
+
+// envVariables are recorded when set. Values of variables whose name
+// suggests a credential are never stored, only whether they are set.
+var envVariables = []string{
+	"PATH", "VIRTUAL_ENV", "CONDA_DEFAULT_ENV", "PYTHONPATH", "NODE_ENV",
+	"NODE_OPTIONS", "GOPATH", "GOFLAGS", "LANG", "SHELL",
+	"GITHUB_TOKEN", "OPENAI_API_KEY", "ANTHROPIC_API_KEY", "LINEAR_API_KEY",
+}
+
+// envVersionTTL is how long probed tool versions are reused
+const envVersionTTL = time.Minute
+
+// envVersionCache avoids probing every toolchain on each run
+var envVersionCache struct {
+	sync.Mutex
+	at       time.Time
+	versions map[string]string
+}
+
+// isSecretName reports whether an environment variable likely holds a credential
+func isSecretName(name string) bool {
+	for _, marker := range []string{"TOKEN", "KEY", "SECRET", "PASSWORD"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolVersions probes the versions of the known toolchains
+func toolVersions() map[string]string {
+	envVersionCache.Lock()
+	defer envVersionCache.Unlock()
+	if envVersionCache.versions != nil && time.Since(envVersionCache.at) < envVersionTTL {
+		return envVersionCache.versions
+	}
+	versions := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, argv := range envVersionCommands {
+		wg.Add(1)
+		go func(name string, argv []string) {
+			defer wg.Done()
+			version := "not found"
+			if out, err := exec.Command(argv[0], argv[1:]...).Output(); err == nil {
+				version = strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+			}
+			mu.Lock()
+			versions[name] = version
+			mu.Unlock()
+		}(name, argv)
+	}
+	wg.Wait()
+	envVersionCache.at = time.Now()
+	envVersionCache.versions = versions
+	return versions
+}
+
+// CaptureEnv records the environment a command in dir runs with
+func CaptureEnv(dir string) EnvSnapshot {
+	snap := EnvSnapshot{}
+	for name, version := range toolVersions() {
+		snap["tool:"+name] = version
+	}
+	for _, name := range envVariables {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if isSecretName(name) {
+			value = "(set)"
+		}
+		snap["env:"+name] = value
+	}
+	if out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output(); err == nil {
+		snap["git:sha"] = strings.TrimSpace(string(out))
+		if out, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+			snap["git:branch"] = strings.TrimSpace(string(out))
+		}
+		dirty := "clean"
+		if out, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output(); err == nil && len(out) > 0 {
+			dirty = "uncommitted changes"
+		}
+		snap["git:worktree"] = dirty
+	}
+	return snap
+}
+
+// EnvChange is one difference between two snapshots. An empty Old or
+// New means the key was added or removed.
+type EnvChange struct {
+	Key, Old, New string
+}
+
+// DiffEnv lists the keys whose values differ between two snapshots
+func DiffEnv(old, new EnvSnapshot) []EnvChange {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	var changes []EnvChange
+	for k := range keys {
+		if old[k] != new[k] {
+			changes = append(changes, EnvChange{Key: k, Old: old[k], New: new[k]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
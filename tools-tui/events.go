@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// runEvent is one line of the --events-json stream of the run
+// subcommand: "started" with the tool, command and directory, "output"
+// with a chunk of the combined output as it is written, and "finished"
+// with the run record, whose output was already streamed
+type runEvent struct {
+	Event   string     `json:"event"`
+	Time    time.Time  `json:"time"`
+	Tool    string     `json:"tool,omitempty"`
+	Command string     `json:"command,omitempty"`
+	Dir     string     `json:"dir,omitempty"`
+	Data    string     `json:"data,omitempty"`
+	Record  *RunRecord `json:"record,omitempty"`
+}
+
+// newEventEmitter writes events to w as newline-delimited JSON
+func newEventEmitter(w io.Writer) func(runEvent) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return func(event runEvent) {
+		event.Time = time.Now()
+		enc.Encode(event)
+	}
+}
+
+// streamEvents runs a tool with the executor of the TUI, emitting an
+// output event per chunk, and returns the complete output
+func streamEvents(tool *Tool, projectDir string, emit func(runEvent)) (string, error) {
+	for msg := range StreamTool(context.Background(), tool, projectDir) {
+		switch msg := msg.(type) {
+		case streamOutputMsg:
+			emit(runEvent{Event: "output", Data: msg.chunk})
+		case streamDoneMsg:
+			return msg.output, msg.err
+		}
+	}
+	return "", fmt.Errorf("%s ended without an exit status", tool.Name)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// examplesFile stores the examples saved from the argument form or
+// promoted from the run history
+const examplesFile = "examples.json"
+
+// Example is a named invocation of a tool: values for the arguments of
+// its command, with a description of what they do
+type Example struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Args        map[string]string `json:"args"`
+}
+
+// SavedExamples holds the examples promoted from the history, keyed by
+// tool key
+type SavedExamples map[string][]Example
+
+// LoadSavedExamples reads the promoted examples
+func LoadSavedExamples() SavedExamples {
+	examples := SavedExamples{}
+	loadJSON(examplesFile, &examples)
+	return examples
+}
+
+// SaveExample stores an example of a tool, replacing one of the same
+// name
+func SaveExample(toolKey string, example Example) error {
+	examples := LoadSavedExamples()
+	kept := examples[toolKey][:0:0]
+	for _, other := range examples[toolKey] {
+		if other.Name != example.Name {
+			kept = append(kept, other)
+		}
+	}
+	examples[toolKey] = append(kept, example)
+	return saveJSON(examplesFile, examples)
+}
+
+// DeleteExample removes a promoted example of a tool
+func DeleteExample(toolKey, name string) error {
+	examples := LoadSavedExamples()
+	kept := examples[toolKey][:0:0]
+	for _, other := range examples[toolKey] {
+		if other.Name != name {
+			kept = append(kept, other)
+		}
+	}
+	if len(kept) == 0 {
+		delete(examples, toolKey)
+	} else {
+		examples[toolKey] = kept
+	}
+	return saveJSON(examplesFile, examples)
+}
+
+// applyExamples attaches the promoted examples to their tools
+func applyExamples(categories []Category) {
+	saved := LoadSavedExamples()
+	for i := range categories {
+		for j := range categories[i].Tools {
+			tool := &categories[i].Tools[j]
+			tool.SavedExamples = saved[tool.Key()]
+		}
+	}
+}
+
+// examples lists the examples of the tool: those of the inventory, then
+// the promoted ones
+func (t *Tool) examples() []Example {
+	return append(append([]Example(nil), t.Examples...), t.SavedExamples...)
+}
+
+// example returns the example of the tool called name
+func (t *Tool) example(name string) (Example, bool) {
+	for _, example := range t.examples() {
+		if example.Name == name {
+			return example, true
+		}
+	}
+	return Example{}, false
+}
+
+// isSaved reports whether the example was promoted rather than written
+// in the inventory
+func (t *Tool) isSaved(name string) bool {
+	for _, example := range t.SavedExamples {
+		if example.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExamples reports problems with the examples of an inventory
+// entry: every example needs a unique name and may only give values for
+// arguments the command has
+func validateExamples(tool Tool) []string {
+	if len(tool.Examples) == 0 {
+		return nil
+	}
+	var problems []string
+	known := map[string]bool{}
+	for _, p := range tool.placeholders() {
+		known[p.Name] = true
+	}
+	seen := map[string]bool{}
+	for _, example := range tool.Examples {
+		switch {
+		case strings.TrimSpace(example.Name) == "":
+			problems = append(problems, "example without a name")
+			continue
+		case seen[example.Name]:
+			problems = append(problems, fmt.Sprintf("duplicate example %q", example.Name))
+		}
+		seen[example.Name] = true
+		var unknown []string
+		for name := range example.Args {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			problems = append(problems, fmt.Sprintf("example %q: the command has no argument %q", example.Name, name))
+		}
+	}
+	return problems
+}
+
+// examplePicker offers the examples of the selected tool before its
+// argument form; entry 0 is the form with the usual values
+type examplePicker struct {
+	active bool
+	cursor int
+	err    string
+}
+
+// openExamplePicker asks which example to start the selected tool with
+func (m *Model) openExamplePicker() {
+	m.examplePicker = examplePicker{active: true}
+	m.statusMessage = ""
+}
+
+// updateExamplePicker handles keys while an example is picked: enter
+// opens the argument form filled with it, ctrl+e edits and ctrl+d
+// deletes a saved example
+func (m Model) updateExamplePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.examplePicker
+	examples := m.selectedTool.examples()
+	switch {
+	case msg.Type == tea.KeyEsc:
+		p.active = false
+		m.statusMessage = "Execution cancelled"
+	case key.Matches(msg, m.keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if p.cursor < len(examples) {
+			p.cursor++
+		}
+	case msg.Type == tea.KeyCtrlE:
+		if p.cursor == 0 || !m.selectedTool.isSaved(examples[p.cursor-1].Name) {
+			p.err = "Only saved examples can be edited; edit the inventory for the others"
+			return m, nil
+		}
+		p.active = false
+		return m, m.editExample(examples[p.cursor-1])
+	case msg.Type == tea.KeyCtrlD:
+		if p.cursor == 0 || !m.selectedTool.isSaved(examples[p.cursor-1].Name) {
+			p.err = "Only saved examples can be deleted; edit the inventory for the others"
+			return m, nil
+		}
+		name := examples[p.cursor-1].Name
+		if err := DeleteExample(m.selectedTool.Key(), name); err != nil {
+			p.err = fmt.Sprintf("Could not delete the example: %v", err)
+			return m, nil
+		}
+		m.selectedTool.SavedExamples = LoadSavedExamples()[m.selectedTool.Key()]
+		p.cursor = min(p.cursor, len(m.selectedTool.examples()))
+		p.err = ""
+		m.statusMessage = fmt.Sprintf("Deleted example %q", name)
+	case key.Matches(msg, m.keys.Enter):
+		p.active = false
+		if p.cursor > 0 {
+			m.presetArgs = m.selectedTool.exampleValues(examples[p.cursor-1])
+		}
+		return m, m.askArgs(m.selectedTool.placeholders())
+	}
+	return m, nil
+}
+
+// renderExamplePicker renders the examples of the selected tool with
+// the command each one runs
+func (m Model) renderExamplePicker() string {
+	p := m.examplePicker
+	var content strings.Builder
+	content.WriteString(descriptionStyle.Bold(true).Render("Run with:\n"))
+	entries := []string{"Enter arguments" + helpStyle.Render("  the last values used")}
+	for _, example := range m.selectedTool.examples() {
+		entry := example.Name
+		if m.selectedTool.isSaved(example.Name) {
+			entry += helpStyle.Render(" (saved)")
+		}
+		if example.Description != "" {
+			entry += "  " + descriptionStyle.Render(example.Description)
+		}
+		entry += "\n    " + commandStyle.Render("$ "+m.selectedTool.exampleCommand(example))
+		entries = append(entries, entry)
+	}
+	for i, entry := range entries {
+		if i == p.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ ") + entry)
+		} else {
+			content.WriteString("  " + entry)
+		}
+		content.WriteString("\n")
+	}
+	if p.err != "" {
+		content.WriteString(warningStyle.Render(p.err))
+		content.WriteString("\n")
+	}
+	content.WriteString(helpStyle.Render("enter: fill in the arguments | ↑/↓: select | ctrl+e: edit saved example | ctrl+d: delete saved example | esc: cancel"))
+	content.WriteString("\n\n")
+	return content.String()
+}
+
+// exampleValues returns the value of every argument in an example, the
+// default for those it leaves out
+func (t *Tool) exampleValues(example Example) map[string]string {
+	values := map[string]string{}
+	for _, p := range t.placeholders() {
+		values[p.Name] = p.Default
+		if value, ok := example.Args[p.Name]; ok {
+			values[p.Name] = value
+		}
+	}
+	return values
+}
+
+// exampleCommand returns the command an example runs
+func (t *Tool) exampleCommand(example Example) string {
+	return t.fillCommand(t.exampleValues(example))
+}
+
+// renderExamples lists the examples of the selected tool in its details
+func (m Model) renderExamples() string {
+	examples := m.selectedTool.examples()
+	if len(examples) == 0 {
+		return ""
+	}
+	var content strings.Builder
+	content.WriteString(descriptionStyle.Bold(true).Render("Examples:\n"))
+	for _, example := range examples {
+		line := fmt.Sprintf("  %s %s", featureStyle.Render("•"), example.Name)
+		if example.Description != "" {
+			line += " — " + example.Description
+		}
+		content.WriteString(line + "\n")
+		content.WriteString("    " + commandStyle.Render("$ "+m.selectedTool.exampleCommand(example)) + "\n")
+	}
+	content.WriteString("\n")
+	return content.String()
+}
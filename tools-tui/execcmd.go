@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// execToolPrefix marks the history entries of the exec subcommand
+const execToolPrefix = "exec:"
+
+// execTimeoutStatus is the exit status of a program that timed out, the
+// same as timeout(1)'s
+const execTimeoutStatus = 124
+
+// eventWriter emits an output event per write and keeps the output
+type eventWriter struct {
+	mu     sync.Mutex
+	output strings.Builder
+	emit   func(runEvent)
+	echo   io.Writer
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.output.Write(p)
+	if w.emit != nil {
+		w.emit(runEvent{Event: "output", Data: string(p)})
+	}
+	if w.echo != nil {
+		w.echo.Write(p)
+	}
+	return len(p), nil
+}
+
+// runExec implements the exec subcommand: it runs a program given as
+// separate arguments, so callers such as cli.py need no quoting, with
+// the TUI's streaming, timeout and run history. Runs started by a tool
+// run are left to that run's record. The process exits with the
+// program's exit status.
+func runExec(args []string) error {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	jsonEvents := fs.Bool("json", false, "stream newline-delimited JSON events (started, output, finished) instead of the plain output")
+	timeout := fs.Duration("timeout", 0, "stop the program after this long, e.g. 90s or 5m")
+	dir := fs.String("dir", "", "directory to run in, the current one when empty")
+	name := fs.String("name", "", "name recorded in the run history, the program's when empty")
+	env := argFlags{}
+	fs.Var(env, "env", "environment variable as NAME=value (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: tools-tui exec [--json] [--timeout d] [--dir dir] [--env NAME=value]... [--name name] -- program [args...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	argv := fs.Args()
+	if len(argv) == 0 {
+		fs.Usage()
+		return fmt.Errorf("exec needs a program")
+	}
+	if *dir == "" {
+		*dir = GetWorkingDirectory()
+	}
+	if *name == "" {
+		*name = filepath.Base(argv[0])
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	tool := &Tool{Name: execToolPrefix + *name, Command: strings.Join(argv, " "), RepoRoot: *dir, Env: env}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = *dir
+	cmd.Env = tool.environ()
+	// programs such as the webhook handler read their input from stdin
+	cmd.Stdin = os.Stdin
+	cmd.WaitDelay = streamWaitDelay
+	killGroupOnCancel(cmd)
+	w := &eventWriter{echo: os.Stdout}
+	if *jsonEvents {
+		emit := newEventEmitter(os.Stdout)
+		w = &eventWriter{emit: emit}
+		emit(runEvent{Event: "started", Tool: tool.Key(), Command: tool.Command, Dir: *dir})
+	}
+	cmd.Stdout, cmd.Stderr = w, w
+
+	envSnapshot := CaptureEnv(*dir)
+	started := time.Now()
+	runErr := cmd.Start()
+	if runErr == nil {
+		// the program has a process group of its own, so signals sent to
+		// exec, such as a cancelled job's, are passed on to it
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			for sig := range signals {
+				signalGroup(cmd, sig)
+			}
+		}()
+		runErr = cmd.Wait()
+		signal.Stop(signals)
+		close(signals)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		runErr = fmt.Errorf("timed out after %s", *timeout)
+	}
+	record := newRunRecord(tool, "", started, envSnapshot, w.output.String(), runErr)
+	if os.Getenv(recordedEnv) == "" {
+		if err := AppendHistory(record, w.output.String()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record run history: %v\n", err)
+		}
+	}
+	if *jsonEvents {
+		finished := record
+		finished.Output = ""
+		w.emit(runEvent{Event: "finished", Record: &finished})
+	} else if runErr != nil && exitCode(runErr) < 0 {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+	}
+	if runErr != nil {
+		code := exitCode(runErr)
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			code = execTimeoutStatus
+		case code <= 0:
+			code = 1
+		}
+		os.Exit(code)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logsDir is the directory of the config directory output is exported
+// to unless output_dir is set
+const logsDir = "logs"
+
+// clipboards are the commands that copy their input to the system
+// clipboard, tried in order
+var clipboards = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// plainText returns output as it reads on a terminal, without colours
+func plainText(output string) string {
+	return stripANSI(cleanTerminalOutput(output))
+}
+
+// exportDir returns the directory output is exported to, with a
+// leading ~ standing for the home directory
+func (m Model) exportDir() string {
+	if rest, ok := strings.CutPrefix(m.outputDir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if m.outputDir != "" {
+		return m.outputDir
+	}
+	return filepath.Join(ConfigDir(), logsDir)
+}
+
+// ExportOutput writes output without colours to a file named after the
+// tool and the time in dir and returns its path
+func ExportOutput(dir, toolName, output string, at time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(toolName, "-"), "-")
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", name, at.Format("20060102-150405")))
+	return path, WriteFileContent(path, strings.TrimRight(plainText(output), "\n")+"\n")
+}
+
+// exportDetailOutput writes the output of the detail view to a file
+func (m *Model) exportDetailOutput() {
+	path, err := ExportOutput(m.exportDir(), m.selectedTool.Name, m.commandOutput, time.Now())
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Could not export the output: %v", err)
+		return
+	}
+	m.statusMessage = "Exported to " + path
+}
+
+// clipboardMsg reports how output was copied to the clipboard
+type clipboardMsg struct {
+	lines  int
+	method string
+	err    error
+}
+
+// Message describes the copy for the status line
+func (c clipboardMsg) Message() string {
+	if c.err != nil {
+		return fmt.Sprintf("Could not copy to the clipboard: %v", c.err)
+	}
+	return fmt.Sprintf("Copied %d lines to the clipboard with %s", c.lines, c.method)
+}
+
+// sshSession reports whether the TUI runs over SSH, where a clipboard
+// command would copy to the remote machine's clipboard
+func sshSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// copyCmd copies output without colours to the system clipboard. Over
+// SSH, or when no clipboard command is installed, the text is sent to
+// the terminal with OSC 52, which copies it to the clipboard of the
+// machine the terminal runs on.
+func copyCmd(output string) tea.Cmd {
+	text := strings.TrimRight(plainText(output), "\n")
+	lines := strings.Count(text, "\n") + 1
+	return func() tea.Msg {
+		if !sshSession() {
+			for _, clipboard := range clipboards {
+				if _, err := exec.LookPath(clipboard[0]); err != nil {
+					continue
+				}
+				cmd := exec.Command(clipboard[0], clipboard[1:]...)
+				cmd.Stdin = strings.NewReader(text)
+				if err := cmd.Run(); err == nil {
+					return clipboardMsg{lines: lines, method: clipboard[0]}
+				}
+			}
+		}
+		return clipboardMsg{lines: lines, method: "OSC 52", err: writeOSC52(text)}
+	}
+}
+
+// writeOSC52 sets the terminal's clipboard, passed through tmux to the
+// outer terminal when the TUI runs inside tmux
+func writeOSC52(text string) error {
+	seq := fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err := fmt.Fprint(os.Stdout, seq)
+	return err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// extensionRow is a tool of the catalog installed under extensions/
+type extensionRow struct {
+	category string
+	tool     *Tool
+	dir      string
+}
+
+// extensionsView holds the state of the Extensions screen: the
+// extensions with their last verification, pending update and the
+// snapshot a rollback restores
+type extensionsView struct {
+	rows      []extensionRow
+	verified  map[string]IntegrityRecord
+	snapshots map[string]Snapshot
+	cursor    int
+	// report is the result of the last verification
+	report  string
+	message string
+}
+
+// openExtensions lists the extensions of the catalog
+func (m *Model) openExtensions() tea.Cmd {
+	m.extensions.message, m.extensions.report = "", ""
+	m.extensions.load(m.categories)
+	m.screen = screenExtensions
+	return nil
+}
+
+// load collects the extension tools and what is recorded about them,
+// keeping the cursor within the list
+func (v *extensionsView) load(categories []Category) {
+	v.rows = nil
+	for i := range categories {
+		if categories[i].Favorites {
+			continue
+		}
+		for j := range categories[i].Tools {
+			tool := &categories[i].Tools[j]
+			if dir, ok := ExtensionDir(tool); ok {
+				v.rows = append(v.rows, extensionRow{category: categories[i].Name, tool: tool, dir: dir})
+			}
+		}
+	}
+	v.verified = map[string]IntegrityRecord{}
+	if err := loadJSON(integrityFile, &v.verified); err != nil {
+		v.message = fmt.Sprintf("Could not read the verifications: %v", err)
+	}
+	v.snapshots = map[string]Snapshot{}
+	states, _ := loadExtensionStates()
+	for _, row := range v.rows {
+		if snap, ok := states.latest(row.dir); ok {
+			v.snapshots[row.dir] = snap
+		}
+	}
+	v.cursor = min(v.cursor, max(len(v.rows)-1, 0))
+}
+
+// updateExtensions handles keys on the Extensions screen: enter opens
+// the details of the extension, where it is installed, upgraded and
+// rolled back, and the verify key checks it in place
+func (m Model) updateExtensions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	v := &m.extensions
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+			v.report = ""
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.rows)-1 {
+			v.cursor++
+			v.report = ""
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if v.cursor < len(v.rows) {
+			m.screen = screenTools
+			m.selectedTool = v.rows[v.cursor].tool
+			m.detailMode = true
+			m.statusMessage = ""
+			m.warning = ""
+			m.attachDetail()
+		}
+	case key.Matches(keyMsg, m.keys.Verify):
+		if v.cursor >= len(v.rows) {
+			return m, nil
+		}
+		row := v.rows[v.cursor]
+		report, err := VerifyExtension(row.dir)
+		switch {
+		case err != nil:
+			v.message = fmt.Sprintf("Verification of %s failed: %v", row.tool.Name, err)
+		case report.Failed():
+			v.message = fmt.Sprintf("⚠ %s: integrity check FAILED", row.tool.Name)
+		case report.Changed:
+			v.message = fmt.Sprintf("⚠ %s: content changed unexpectedly since the last verification", row.tool.Name)
+		default:
+			v.message = fmt.Sprintf("%s verified", row.tool.Name)
+		}
+		v.report = report.Summary()
+		if err == nil && !report.Failed() && !report.Changed {
+			RecordExtensionHash(row.dir, report.Hash)
+		}
+		v.load(m.categories)
+	case key.Matches(keyMsg, m.keys.Refresh):
+		v.load(m.categories)
+		v.message = "Checking for updates…"
+		return m, checkUpdatesCmd(m.categories)
+	}
+	return m, nil
+}
+
+// renderExtensions lists the extensions with their state and the
+// details of the selected one
+func (m Model) renderExtensions() string {
+	v := m.extensions
+	var content strings.Builder
+	title := titleStyle.Render("📦 Extensions")
+	updates := 0
+	for _, row := range v.rows {
+		if info, ok := m.updates[row.tool.Key()]; ok && info.Available() {
+			updates++
+		}
+	}
+	summary := fmt.Sprintf("%d installed under %s · %d with updates", len(v.rows), extensionsDir(), updates)
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	var lines []string
+	for _, row := range v.rows {
+		verified := "never verified"
+		if record, ok := v.verified[filepath.Base(row.dir)]; ok {
+			verified = "verified " + record.VerifiedAt.Format("2006-01-02 15:04")
+		}
+		lines = append(lines, fmt.Sprintf("%-28s %-24s %s", truncate(row.tool.Name, 28), truncate(m.statusBadge(row.tool), 24),
+			helpStyle.Render(verified+" · "+filepath.Base(row.dir))))
+	}
+	start := max(0, min(v.cursor-memoryListRows/2, len(lines)-memoryListRows))
+	end := min(start+memoryListRows, len(lines))
+	content.WriteString(renderMCPList(fmt.Sprintf("Extensions (%d)", len(lines)), lines[start:end], v.cursor-start, true))
+	content.WriteString("\n")
+
+	if v.cursor < len(v.rows) {
+		row := v.rows[v.cursor]
+		fmt.Fprintf(&content, "%s %s\n", descriptionStyle.Bold(true).Render("Category:"), row.category)
+		fmt.Fprintf(&content, "%s %s\n", descriptionStyle.Bold(true).Render("Directory:"), row.dir)
+		if info, ok := m.updates[row.tool.Key()]; ok {
+			fmt.Fprintf(&content, "%s %s\n", descriptionStyle.Bold(true).Render("Upstream:"), info.Summary())
+		}
+		rollback := "no snapshot, nothing to roll back to"
+		if snap, ok := v.snapshots[row.dir]; ok {
+			rollback = fmt.Sprintf("%s, taken %s", snap.Label(), snap.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		fmt.Fprintf(&content, "%s %s\n", descriptionStyle.Bold(true).Render("Rollback:"), rollback)
+		if v.report != "" {
+			content.WriteString("\n")
+			content.WriteString(descriptionStyle.Render(v.report))
+		}
+	} else {
+		content.WriteString(helpStyle.Render("No tool of the catalog is installed under extensions/; unpack an archive from the file manager or add one to the inventory"))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	hints := []string{hint("select", k.Up, k.Down), hint("details, install, upgrade, rollback", k.Enter), hint("verify", k.Verify), hint("check updates", k.Refresh), tabsHint(k.Tabs), hint("back", k.Back)}
+	content.WriteString(footerStyle.Render(strings.Join(hints, " | ")))
+	return content.String()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// faultFlag enables fault injection. It is deliberately left out of the
+// usage text; it exists so maintainers can check how failures surface.
+const faultFlag = "--inject-faults"
+
+// defaultFaultRate is used when the flag is given without a value
+const defaultFaultRate = 0.3
+
+// faultRate is the probability that a tool execution is disturbed
+var faultRate float64
+
+// faultKinds lists the disturbances picked from at random
+var faultKinds = []string{"fail", "slow", "malformed"}
+
+// parseFaultFlag enables fault injection when args contain
+// --inject-faults[=rate] and returns the remaining arguments
+func parseFaultFlag(args []string) ([]string, error) {
+	rest := args[:0:0]
+	for _, arg := range args {
+		if arg != faultFlag && !strings.HasPrefix(arg, faultFlag+"=") {
+			rest = append(rest, arg)
+			continue
+		}
+		faultRate = defaultFaultRate
+		if value, ok := strings.CutPrefix(arg, faultFlag+"="); ok {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 || rate > 1 {
+				return nil, fmt.Errorf("%s expects a rate between 0 and 1, got %q", faultFlag, value)
+			}
+			faultRate = rate
+		}
+	}
+	return rest, nil
+}
+
+// faultsEnabled reports whether fault injection is active
+func faultsEnabled() bool {
+	return faultRate > 0
+}
+
+// injectFault randomly replaces a command result with an executor
+// failure, delays it like a stalled stream, or garbles the output
+func injectFault(output string, err error) (string, error) {
+	if !faultsEnabled() || rand.Float64() >= faultRate {
+		return output, err
+	}
+	switch faultKinds[rand.Intn(len(faultKinds))] {
+	case "fail":
+		return output, fmt.Errorf("injected fault: executor failure")
+	case "slow":
+		time.Sleep(time.Duration(2+rand.Intn(4)) * time.Second)
+		return output, err
+	default:
+		return malformOutput(output), err
+	}
+}
+
+// malformOutput truncates output mid-line and mixes in invalid UTF-8,
+// stray escape sequences and a broken JSON fragment
+func malformOutput(output string) string {
+	if len(output) > 0 {
+		output = output[:rand.Intn(len(output))]
+	}
+	return output + "\xff\xfe\x1b[31;" + `{"status": "ok", "results": [` + "\x00"
+}
@@ -0,0 +1,97 @@
+package main
+
+import "fmt"
+
+// favoritesFile stores the keys of the tools marked as favorites, in the
+// order they were added
+const favoritesFile = "favorites.json"
+
+// favoritesCategory is the name of the synthetic category listing the
+// favorite tools
+const favoritesCategory = "⭐ Favorites"
+
+// LoadFavorites reads the keys of the favorite tools
+func LoadFavorites() []string {
+	var keys []string
+	loadJSON(favoritesFile, &keys)
+	return keys
+}
+
+// SaveFavorites persists the keys of the favorite tools
+func SaveFavorites(keys []string) error {
+	return saveJSON(favoritesFile, keys)
+}
+
+// isFavorite reports whether the tool with the given key is a favorite
+func (m Model) isFavorite(key string) bool {
+	for _, k := range m.favorites {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleFavorite adds a tool to the favorites or removes it and returns
+// a message describing the outcome
+func (m *Model) toggleFavorite(tool *Tool) string {
+	key := tool.Key()
+	var message string
+	if m.isFavorite(key) {
+		keys := m.favorites[:0:0]
+		for _, k := range m.favorites {
+			if k != key {
+				keys = append(keys, k)
+			}
+		}
+		m.favorites = keys
+		message = fmt.Sprintf("Removed %s from favorites", tool.Name)
+	} else {
+		m.favorites = append(m.favorites, key)
+		message = fmt.Sprintf("Added %s to favorites", tool.Name)
+	}
+	if err := SaveFavorites(m.favorites); err != nil {
+		message = fmt.Sprintf("Could not save favorites: %v", err)
+	}
+	m.refreshFavorites()
+	return message
+}
+
+// refreshFavorites rebuilds the favorites category at the top of the
+// catalog from copies of the favorite tools. The category is only shown
+// when there are favorites; the selection stays on the same category.
+func (m *Model) refreshFavorites() {
+	hadFavorites := len(m.categories) > 0 && m.categories[0].Favorites
+	active := true
+	if hadFavorites {
+		active = m.categories[0].Active
+		m.categories = m.categories[1:]
+	}
+	var tools []Tool
+	for _, key := range m.favorites {
+		if tool := findTool(m.categories, key); tool != nil {
+			tools = append(tools, *tool)
+		}
+	}
+	if len(tools) > 0 {
+		favorites := Category{
+			Name:      favoritesCategory,
+			Purpose:   "Tools you marked as favorites",
+			Tools:     tools,
+			Active:    active,
+			Favorites: true,
+		}
+		m.categories = append([]Category{favorites}, m.categories...)
+	}
+
+	switch hasFavorites := len(tools) > 0; {
+	case hasFavorites && !hadFavorites:
+		m.currentCat++
+	case !hasFavorites && hadFavorites && m.currentCat > 0:
+		m.currentCat--
+	case !hasFavorites && hadFavorites:
+		m.currentTool = 0
+	case hasFavorites && m.currentCat == 0 && m.currentTool >= len(tools):
+		m.currentTool = len(tools) - 1
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fileOp is a pending file manager operation awaiting confirmation
+type fileOp int
+
+const (
+	fileOpNone fileOp = iota
+	fileOpCopy
+	fileOpMove
+	fileOpDelete
+	fileOpRename
+	fileOpExtract
+	fileOpPackage
+)
+
+// filePane is one side of the dual-pane file manager
+type filePane struct {
+	dir     string
+	entries []os.DirEntry
+	cursor  int
+}
+
+// fileManagerView holds the state of the file manager screen
+type fileManagerView struct {
+	root    string
+	panes   [2]filePane
+	active  int
+	pending fileOp
+	input   textinput.Model
+	message string
+}
+
+// newFileManager creates a file manager scoped to root
+func newFileManager(root string) fileManagerView {
+	input := newTextInput()
+	input.Placeholder = "new name"
+	input.CharLimit = 255
+
+	fm := fileManagerView{root: root, input: input}
+	right := root
+	if fileExists(extensionsDir()) {
+		right = extensionsDir()
+	}
+	fm.panes[0].dir = root
+	fm.panes[1].dir = right
+	fm.refresh()
+	return fm
+}
+
+// load reads the entries of the pane's directory, directories first
+func (p *filePane) load() error {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return entries[i].Name() < entries[j].Name()
+	})
+	p.entries = entries
+	if p.cursor >= len(entries) {
+		p.cursor = max(len(entries)-1, 0)
+	}
+	return nil
+}
+
+// selected returns the path under the cursor
+func (p filePane) selected() (string, bool) {
+	if p.cursor >= len(p.entries) {
+		return "", false
+	}
+	return filepath.Join(p.dir, p.entries[p.cursor].Name()), true
+}
+
+// refresh reloads both panes
+func (v *fileManagerView) refresh() {
+	for i := range v.panes {
+		if err := v.panes[i].load(); err != nil {
+			v.message = err.Error()
+		}
+	}
+}
+
+// within reports whether path is inside the file manager's root
+func (v fileManagerView) within(path string) bool {
+	rel, err := filepath.Rel(v.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// relative shows path relative to the root
+func (v fileManagerView) relative(path string) string {
+	rel, err := filepath.Rel(v.root, path)
+	if err != nil {
+		return path
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// describePending explains the operation awaiting confirmation
+func (v fileManagerView) describePending() string {
+	src, _ := v.panes[v.active].selected()
+	dst := v.panes[1-v.active].dir
+	switch v.pending {
+	case fileOpCopy:
+		return fmt.Sprintf("Copy %s to %s?", v.relative(src), v.relative(dst))
+	case fileOpMove:
+		return fmt.Sprintf("Move %s to %s?", v.relative(src), v.relative(dst))
+	case fileOpDelete:
+		return fmt.Sprintf("Delete %s permanently?", v.relative(src))
+	case fileOpExtract:
+		return fmt.Sprintf("Unpack %s into %s?", v.relative(src), v.relative(filepath.Join(extensionsDir(), archiveBaseName(src))))
+	case fileOpPackage:
+		return fmt.Sprintf("Package %s into %s?", v.relative(src), v.relative(filepath.Join(defaultWorkDir, "dist", filepath.Base(src)+".tar.gz")))
+	}
+	return ""
+}
+
+// execute performs the confirmed operation
+func (v *fileManagerView) execute() error {
+	src, ok := v.panes[v.active].selected()
+	if !ok {
+		return fmt.Errorf("nothing selected")
+	}
+
+	switch v.pending {
+	case fileOpCopy, fileOpMove:
+		dst := filepath.Join(v.panes[1-v.active].dir, filepath.Base(src))
+		if fileExists(dst) {
+			return fmt.Errorf("%s already exists", v.relative(dst))
+		}
+		if v.pending == fileOpMove {
+			if err := os.Rename(src, dst); err == nil {
+				return nil
+			}
+		}
+		info, err := os.Lstat(src)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			err = copyTree(src, dst, nil)
+		} else {
+			err = copyFile(src, dst, info.Mode().Perm())
+		}
+		if err != nil || v.pending == fileOpCopy {
+			return err
+		}
+		return os.RemoveAll(src)
+
+	case fileOpDelete:
+		return os.RemoveAll(src)
+
+	case fileOpExtract:
+		dest, err := ExtractExtension(src)
+		if err == nil {
+			v.message = fmt.Sprintf("Unpacked into %s as a downloaded extension, verify and install it from the Extensions category", v.relative(dest))
+		}
+		return err
+
+	case fileOpPackage:
+		out, err := PackageExtension(src)
+		if err == nil {
+			v.message = fmt.Sprintf("Packaged into %s", v.relative(out))
+		}
+		return err
+
+	case fileOpRename:
+		name := strings.TrimSpace(v.input.Value())
+		if name == "" || strings.ContainsRune(name, filepath.Separator) {
+			return fmt.Errorf("invalid name %q", name)
+		}
+		dst := filepath.Join(filepath.Dir(src), name)
+		if fileExists(dst) {
+			return fmt.Errorf("%s already exists", v.relative(dst))
+		}
+		return os.Rename(src, dst)
+	}
+	return nil
+}
+
+// openFileManager switches to the file manager screen
+func (m *Model) openFileManager() {
+	m.screen = screenFiles
+	if m.files.root == "" {
+		m.files = newFileManager(defaultWorkDir)
+	} else {
+		m.files.refresh()
+	}
+}
+
+// updateFileManager handles input on the file manager screen
+func (m Model) updateFileManager(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.files
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if v.pending == fileOpRename {
+		switch keyMsg.Type {
+		case tea.KeyEnter:
+			if err := v.execute(); err != nil {
+				v.message = err.Error()
+			} else {
+				v.message = "Renamed"
+			}
+			v.pending = fileOpNone
+			v.input.Blur()
+			v.refresh()
+			return m, nil
+		case tea.KeyEsc:
+			v.pending = fileOpNone
+			v.input.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		v.input, cmd = v.input.Update(msg)
+		return m, cmd
+	}
+
+	if v.pending != fileOpNone {
+		if key.Matches(keyMsg, m.keys.Confirm) {
+			v.message = "Done"
+			if err := v.execute(); err != nil {
+				v.message = err.Error()
+			}
+			v.refresh()
+		} else {
+			v.message = "Cancelled"
+		}
+		v.pending = fileOpNone
+		return m, nil
+	}
+
+	pane := &v.panes[v.active]
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.ToggleCategory):
+		v.active = 1 - v.active
+	case key.Matches(keyMsg, m.keys.Up):
+		if pane.cursor > 0 {
+			pane.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if pane.cursor < len(pane.entries)-1 {
+			pane.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Enter), key.Matches(keyMsg, m.keys.Right):
+		if path, ok := pane.selected(); ok && pane.entries[pane.cursor].IsDir() {
+			pane.dir = path
+			pane.cursor = 0
+			pane.load()
+		}
+	case key.Matches(keyMsg, m.keys.Left):
+		if parent := filepath.Dir(pane.dir); pane.dir != v.root && v.within(parent) {
+			pane.dir = parent
+			pane.cursor = 0
+			pane.load()
+		}
+	case key.Matches(keyMsg, m.keys.FileCopy):
+		v.pending = fileOpCopy
+	case key.Matches(keyMsg, m.keys.FileMove):
+		v.pending = fileOpMove
+	case key.Matches(keyMsg, m.keys.Delete):
+		v.pending = fileOpDelete
+	case key.Matches(keyMsg, m.keys.Unpack):
+		if path, ok := pane.selected(); ok && IsArchive(path) {
+			v.pending = fileOpExtract
+		} else {
+			v.message = "Select a .zip or .tar.gz archive to unpack"
+		}
+	case key.Matches(keyMsg, m.keys.Package):
+		if _, ok := pane.selected(); ok && pane.entries[pane.cursor].IsDir() {
+			v.pending = fileOpPackage
+		} else {
+			v.message = "Select a directory to package"
+		}
+	case key.Matches(keyMsg, m.keys.FileRename):
+		if path, ok := pane.selected(); ok {
+			v.pending = fileOpRename
+			v.input.SetValue(filepath.Base(path))
+			v.input.Focus()
+			return m, textinput.Blink
+		}
+	}
+
+	if v.pending != fileOpNone {
+		if _, ok := pane.selected(); !ok {
+			v.pending = fileOpNone
+			v.message = "Nothing selected"
+		}
+	}
+	return m, nil
+}
+
+// renderFileManager renders both panes side by side
+func (m Model) renderFileManager() string {
+	v := m.files
+	paneWidth := max((m.width-4)/2, 30)
+	paneHeight := max(m.height-8, 5)
+
+	var panes []string
+	for i, pane := range v.panes {
+		var b strings.Builder
+		header := v.relative(pane.dir)
+		if i == v.active {
+			b.WriteString(titleStyle.Render(header))
+		} else {
+			b.WriteString(helpStyle.Render(header))
+		}
+		b.WriteString("\n")
+
+		start := 0
+		if pane.cursor >= paneHeight {
+			start = pane.cursor - paneHeight + 1
+		}
+		for j := start; j < len(pane.entries) && j < start+paneHeight; j++ {
+			name := pane.entries[j].Name()
+			if pane.entries[j].IsDir() {
+				name += "/"
+			}
+			if i == v.active && j == pane.cursor {
+				b.WriteString(selectedItemStyle.Render("▶ " + name))
+			} else if pane.entries[j].IsDir() {
+				b.WriteString("  " + featureStyle.Render(name))
+			} else {
+				b.WriteString("  " + name)
+			}
+			b.WriteString("\n")
+		}
+		panes = append(panes, lipgloss.NewStyle().Width(paneWidth).Render(b.String()))
+	}
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("📁 Files"))
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, panes[0], "  ", panes[1]))
+	content.WriteString("\n")
+
+	switch {
+	case v.pending == fileOpRename:
+		content.WriteString(commandStyle.Render("Rename to: " + v.input.View()))
+	case v.pending != fileOpNone:
+		content.WriteString(warningStyle.Render("⚠ " + v.describePending() + " Press '" + primaryKey(m.keys.Confirm) + "' to confirm"))
+	case v.message != "":
+		content.WriteString(featureStyle.Render(v.message))
+	}
+	content.WriteString("\n")
+
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("switch pane", k.ToggleCategory), hint("open", k.Enter, k.Right), hint("up", k.Left), hint("copy", k.FileCopy), hint("move", k.FileMove), hint("rename", k.FileRename), hint("delete", k.Delete), hint("unpack", k.Unpack), hint("package", k.Package), hint("back", k.Back)}, " | ")))
+	return content.String()
+}
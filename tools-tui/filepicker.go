@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filePickerRows is how many entries of a directory are shown around
+// the cursor
+const filePickerRows = 10
+
+// filePicker browses the directories of a project to pick a file, such
+// as one to upload. Hidden entries are left out.
+type filePicker struct {
+	active  bool
+	root    string
+	dir     string
+	entries []os.DirEntry
+	cursor  int
+	err     error
+}
+
+// open shows the entries of dir; root is the directory picked paths are
+// made relative to
+func (p *filePicker) open(root, dir string) {
+	p.active, p.root = true, root
+	p.read(dir)
+}
+
+// read lists the entries of dir, the directories first
+func (p *filePicker) read(dir string) {
+	entries, err := os.ReadDir(dir)
+	p.dir, p.cursor, p.err, p.entries = dir, 0, err, nil
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") {
+			p.entries = append(p.entries, e)
+		}
+	}
+	sort.SliceStable(p.entries, func(i, j int) bool {
+		return p.entries[i].IsDir() && !p.entries[j].IsDir()
+	})
+}
+
+// update handles a key: ↑/↓ move, enter opens a directory or picks a
+// file, ←/backspace goes up and esc closes the picker. It returns the
+// picked file relative to the root when it is inside it.
+func (p *filePicker) update(msg tea.KeyMsg, keys KeyMap) (string, bool) {
+	switch {
+	case msg.Type == tea.KeyEsc:
+		p.active = false
+	case msg.Type == tea.KeyLeft || msg.Type == tea.KeyBackspace:
+		if parent := filepath.Dir(p.dir); parent != p.dir {
+			name := filepath.Base(p.dir)
+			p.read(parent)
+			for i, e := range p.entries {
+				if e.Name() == name {
+					p.cursor = i
+				}
+			}
+		}
+	case key.Matches(msg, keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if p.cursor < len(p.entries)-1 {
+			p.cursor++
+		}
+	case msg.Type == tea.KeyEnter || msg.Type == tea.KeyRight:
+		if p.cursor >= len(p.entries) {
+			return "", false
+		}
+		path := filepath.Join(p.dir, p.entries[p.cursor].Name())
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			p.read(path)
+			return "", false
+		}
+		if msg.Type == tea.KeyRight {
+			return "", false
+		}
+		p.active = false
+		if rel, err := filepath.Rel(p.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel, true
+		}
+		return path, true
+	}
+	return "", false
+}
+
+// view lists the entries around the cursor below the directory shown
+func (p filePicker) view(width int) string {
+	var b strings.Builder
+	b.WriteString(descriptionStyle.Bold(true).Render(truncate(p.dir, max(width-4, 20))))
+	b.WriteString("\n")
+	switch {
+	case p.err != nil:
+		b.WriteString(warningStyle.Render(p.err.Error()))
+		b.WriteString("\n")
+	case len(p.entries) == 0:
+		b.WriteString(helpStyle.Render("  (empty)"))
+		b.WriteString("\n")
+	}
+	start := max(0, min(p.cursor-filePickerRows/2, len(p.entries)-filePickerRows))
+	end := min(start+filePickerRows, len(p.entries))
+	for i := start; i < end; i++ {
+		e := p.entries[i]
+		name := "  " + e.Name()
+		if e.IsDir() {
+			name += "/"
+		} else if info, err := e.Info(); err == nil {
+			name += helpStyle.Render("  " + formatBytes(info.Size()))
+		}
+		if i == p.cursor {
+			name = selectedItemStyle.Render("▸ " + strings.TrimPrefix(name, "  "))
+		}
+		b.WriteString(name)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
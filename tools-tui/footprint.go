@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// extensionsDir is where downloaded extensions live
+func extensionsDir() string {
+	return filepath.Join(defaultWorkDir, "extensions")
+}
+
+// depDirs, venvDirs and cacheDirs classify generated directories
+var (
+	depDirs   = map[string]bool{"node_modules": true}
+	venvDirs  = map[string]bool{".venv": true, "venv": true, "env": true}
+	cacheDirs = map[string]bool{
+		"__pycache__": true, ".cache": true, ".pytest_cache": true, ".mypy_cache": true,
+		".ruff_cache": true, ".parcel-cache": true, ".next": true, "dist": true, "build": true,
+	}
+)
+
+// ExtensionFootprint is the disk usage breakdown of one extension
+type ExtensionFootprint struct {
+	Name   string
+	Dir    string
+	Total  int64
+	Deps   int64
+	Venvs  int64
+	Caches int64
+}
+
+// footprintSorts are the orderings cycled with the sort key
+var footprintSorts = []string{"total", "deps", "venvs", "caches", "name"}
+
+// footprintView holds the state of the footprint report screen
+type footprintView struct {
+	items        []ExtensionFootprint
+	cursor       int
+	sortBy       int
+	loading      bool
+	confirmClean bool
+	message      string
+}
+
+// footprintMsg delivers a finished disk usage scan
+type footprintMsg struct {
+	items []ExtensionFootprint
+	err   error
+}
+
+// ScanFootprints measures every directory under extensions/
+func ScanFootprints() ([]ExtensionFootprint, error) {
+	entries, err := os.ReadDir(extensionsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ExtensionFootprint
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(extensionsDir(), entry.Name())
+		fp := ExtensionFootprint{Name: entry.Name(), Dir: dir}
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() && path != dir {
+				switch name := info.Name(); {
+				case depDirs[name]:
+					fp.Deps += dirSize(path)
+				case venvDirs[name]:
+					fp.Venvs += dirSize(path)
+				case cacheDirs[name]:
+					fp.Caches += dirSize(path)
+				default:
+					return nil
+				}
+				return filepath.SkipDir
+			}
+			if info.Mode().IsRegular() {
+				fp.Total += info.Size()
+			}
+			return nil
+		})
+		fp.Total += fp.Deps + fp.Venvs + fp.Caches
+		items = append(items, fp)
+	}
+	return items, nil
+}
+
+// CleanCaches removes cache directories from an extension and returns
+// the number of bytes freed. Dependencies and venvs are left alone.
+func CleanCaches(dir string) (int64, error) {
+	var freed int64
+	var targets []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == dir {
+			return nil
+		}
+		if depDirs[info.Name()] || venvDirs[info.Name()] || info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if cacheDirs[info.Name()] {
+			targets = append(targets, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	for _, path := range targets {
+		size := dirSize(path)
+		if err := os.RemoveAll(path); err != nil {
+			return freed, err
+		}
+		freed += size
+	}
+	return freed, nil
+}
+
+// scanFootprintsCmd runs the disk usage scan in the background
+func scanFootprintsCmd() tea.Cmd {
+	return func() tea.Msg {
+		items, err := ScanFootprints()
+		return footprintMsg{items: items, err: err}
+	}
+}
+
+// sortFootprints orders items by the active sort column
+func (v *footprintView) sortFootprints() {
+	by := footprintSorts[v.sortBy]
+	sort.SliceStable(v.items, func(i, j int) bool {
+		a, b := v.items[i], v.items[j]
+		switch by {
+		case "deps":
+			return a.Deps > b.Deps
+		case "venvs":
+			return a.Venvs > b.Venvs
+		case "caches":
+			return a.Caches > b.Caches
+		case "name":
+			return a.Name < b.Name
+		}
+		return a.Total > b.Total
+	})
+}
+
+// openFootprint switches to the footprint screen and starts a scan
+func (m *Model) openFootprint() tea.Cmd {
+	m.screen = screenFootprint
+	m.footprint.loading = true
+	m.footprint.message = ""
+	return scanFootprintsCmd()
+}
+
+// updateFootprint handles input on the footprint screen
+func (m Model) updateFootprint(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.footprint
+
+	switch msg := msg.(type) {
+	case footprintMsg:
+		v.loading = false
+		if msg.err != nil {
+			v.message = fmt.Sprintf("Scan failed: %v", msg.err)
+			return m, nil
+		}
+		v.items = msg.items
+		v.sortFootprints()
+		if v.cursor >= len(v.items) {
+			v.cursor = 0
+		}
+
+	case tea.KeyMsg:
+		if v.confirmClean {
+			v.confirmClean = false
+			if key.Matches(msg, m.keys.Confirm) && v.cursor < len(v.items) {
+				freed, err := CleanCaches(v.items[v.cursor].Dir)
+				if err != nil {
+					v.message = fmt.Sprintf("Clean failed: %v", err)
+				} else {
+					v.message = fmt.Sprintf("Freed %s from %s", formatBytes(freed), v.items[v.cursor].Name)
+				}
+				v.loading = true
+				return m, scanFootprintsCmd()
+			}
+			v.message = "Clean cancelled"
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back):
+			m.screen = screenTools
+		case key.Matches(msg, m.keys.Up):
+			if v.cursor > 0 {
+				v.cursor--
+			}
+		case key.Matches(msg, m.keys.Down):
+			if v.cursor < len(v.items)-1 {
+				v.cursor++
+			}
+		case key.Matches(msg, m.keys.Refresh):
+			if !v.loading {
+				v.loading = true
+				return m, scanFootprintsCmd()
+			}
+		case key.Matches(msg, m.keys.Sort):
+			v.sortBy = (v.sortBy + 1) % len(footprintSorts)
+			v.sortFootprints()
+		case key.Matches(msg, m.keys.Clean):
+			if v.cursor < len(v.items) && v.items[v.cursor].Caches > 0 {
+				v.confirmClean = true
+			}
+		}
+	}
+	return m, nil
+}
+
+// renderFootprint renders the disk usage report
+func (m Model) renderFootprint() string {
+	v := m.footprint
+	var content strings.Builder
+
+	var total int64
+	for _, item := range v.items {
+		total += item.Total
+	}
+	title := titleStyle.Render("💾 Extension Footprint")
+	status := statusStyle.Render(fmt.Sprintf("%s total | sorted by %s", formatBytes(total), footprintSorts[v.sortBy]))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	if v.loading {
+		content.WriteString(descriptionStyle.Render("Scanning extensions..."))
+		content.WriteString("\n")
+	}
+
+	header := fmt.Sprintf("  %-32s %10s %12s %10s %10s", "Extension", "Total", "node_modules", "venvs", "caches")
+	content.WriteString(featureStyle.Render(header))
+	content.WriteString("\n")
+	var list strings.Builder
+	for i, item := range v.items {
+		line := fmt.Sprintf("%-32s %10s %12s %10s %10s", item.Name,
+			formatBytes(item.Total), formatBytes(item.Deps), formatBytes(item.Venvs), formatBytes(item.Caches))
+		if i == v.cursor {
+			list.WriteString(selectedItemStyle.Render("▶ " + line))
+		} else {
+			list.WriteString("  " + line)
+		}
+		list.WriteString("\n")
+	}
+	content.WriteString(renderStale(v.loading, list.String()))
+
+	content.WriteString("\n")
+	if v.confirmClean {
+		prompt := fmt.Sprintf("⚠ Remove %s of caches from %s? Press '%s' to confirm", formatBytes(v.items[v.cursor].Caches), v.items[v.cursor].Name, primaryKey(m.keys.Confirm))
+		content.WriteString(warningStyle.Render(prompt))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("sort", k.Sort), hint("clean caches", k.Clean), hint("rescan", k.Refresh), hint("back", k.Back), hint("quit", k.Quit)}, " | ")))
+	return content.String()
+}
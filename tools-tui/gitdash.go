@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gitLogLimit is how many recent commits the Git dashboard lists
+const gitLogLimit = 30
+
+// gitCommitOutputLines is how many lines of a run's output go into the
+// body of the commit made from it
+const gitCommitOutputLines = 40
+
+// gitFile is a changed file of the working tree: XY is the porcelain
+// status of the index and the worktree, "??" for untracked files
+type gitFile struct {
+	XY   string
+	Path string
+	// From is the old path of a rename or copy
+	From string
+}
+
+// gitCommit is a recent commit
+type gitCommit struct {
+	Hash    string
+	Subject string
+	Author  string
+	When    string
+}
+
+// gitBranch is a local branch
+type gitBranch struct {
+	Name     string
+	Current  bool
+	Upstream string
+	Track    string
+	Subject  string
+	When     string
+}
+
+// gitState is the state of a repository shown by the Git dashboard
+type gitState struct {
+	Root     string
+	Branch   string
+	Detached bool
+	Upstream string
+	Ahead    int
+	Behind   int
+	Files    []gitFile
+	Commits  []gitCommit
+	Branches []gitBranch
+}
+
+// git runs a git command in dir, returning its output or an error with
+// what git printed
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return string(out), fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return string(out), fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// loadGitState reads the branch, changed files, recent commits and local
+// branches of the repository dir is in
+func loadGitState(dir string) (*gitState, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	s := &gitState{Root: strings.TrimSpace(root)}
+	status, err := git(s.Root, "status", "--porcelain=v2", "--branch", "-z")
+	if err != nil {
+		return nil, err
+	}
+	s.parseStatus(status)
+
+	// an unborn branch has no log yet
+	if log, err := git(s.Root, "log", "-n", strconv.Itoa(gitLogLimit), "--format=%h%x1f%s%x1f%an%x1f%cr"); err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(log), "\n") {
+			if f := strings.Split(line, "\x1f"); len(f) == 4 {
+				s.Commits = append(s.Commits, gitCommit{Hash: f[0], Subject: f[1], Author: f[2], When: f[3]})
+			}
+		}
+	}
+	refs, err := git(s.Root, "for-each-ref", "--sort=-committerdate", "refs/heads",
+		"--format=%(HEAD)%1f%(refname:short)%1f%(upstream:short)%1f%(upstream:track)%1f%(contents:subject)%1f%(committerdate:relative)")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(refs), "\n") {
+		if f := strings.Split(line, "\x1f"); len(f) == 6 {
+			s.Branches = append(s.Branches, gitBranch{Current: f[0] == "*", Name: f[1], Upstream: f[2], Track: f[3], Subject: f[4], When: f[5]})
+		}
+	}
+	return s, nil
+}
+
+// parseStatus reads the NUL-separated records of git status
+// --porcelain=v2 --branch -z
+func (s *gitState) parseStatus(status string) {
+	records := strings.Split(status, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		switch {
+		case strings.HasPrefix(record, "# branch.head "):
+			s.Branch = strings.TrimPrefix(record, "# branch.head ")
+			s.Detached = s.Branch == "(detached)"
+		case strings.HasPrefix(record, "# branch.upstream "):
+			s.Upstream = strings.TrimPrefix(record, "# branch.upstream ")
+		case strings.HasPrefix(record, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(record, "# branch.ab "), "+%d -%d", &s.Ahead, &s.Behind)
+		case strings.HasPrefix(record, "1 "):
+			// the path is the last field and may contain spaces
+			fields := strings.SplitN(record, " ", 9)
+			s.Files = append(s.Files, gitFile{XY: fields[1], Path: fields[len(fields)-1]})
+		case strings.HasPrefix(record, "u "):
+			fields := strings.SplitN(record, " ", 11)
+			s.Files = append(s.Files, gitFile{XY: fields[1], Path: fields[len(fields)-1]})
+		case strings.HasPrefix(record, "2 "):
+			// renames and copies are followed by the original path
+			fields := strings.SplitN(record, " ", 10)
+			file := gitFile{XY: fields[1], Path: fields[len(fields)-1]}
+			if i+1 < len(records) {
+				i++
+				file.From = records[i]
+			}
+			s.Files = append(s.Files, file)
+		case strings.HasPrefix(record, "? "):
+			s.Files = append(s.Files, gitFile{XY: "??", Path: strings.TrimPrefix(record, "? ")})
+		}
+	}
+}
+
+// Label describes a changed file as git status --short does
+func (f gitFile) Label() string {
+	xy := strings.ReplaceAll(f.XY, ".", " ")
+	if f.From != "" {
+		return xy + " " + f.From + " → " + f.Path
+	}
+	return xy + " " + f.Path
+}
+
+// Untracked reports whether git does not track the file yet
+func (f gitFile) Untracked() bool {
+	return f.XY == "??"
+}
+
+// gitFileDiff returns the changes of a file against HEAD, or its content
+// when it is untracked
+func gitFileDiff(root string, f gitFile) string {
+	var out string
+	var err error
+	if f.Untracked() {
+		out, err = git(root, "diff", "--no-index", "--", "/dev/null", f.Path)
+		// --no-index exits 1 when the files differ
+		if out != "" {
+			err = nil
+		}
+	} else {
+		out, err = git(root, "diff", "HEAD", "--", f.Path)
+		if err != nil {
+			// no commit yet: show what is staged
+			out, err = git(root, "diff", "--cached", "--", f.Path)
+		}
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return out
+}
+
+// gitCommitSummary returns the message and changed files of a commit
+func gitCommitSummary(root, hash string) string {
+	out, err := git(root, "show", "--stat", "--format=%H%nAuthor: %an <%ae>%nDate:   %cd%n%n%B", hash)
+	if err != nil {
+		return err.Error()
+	}
+	return out
+}
+
+// switchBranch checks out a local branch
+func switchBranch(root, branch string) error {
+	_, err := git(root, "switch", branch)
+	return err
+}
+
+// runCommitMessage builds the message of a commit recording the changes
+// of a tool run: the tool and command in the subject, the end of its
+// output in the body
+func runCommitMessage(tool, command, output string) string {
+	lines := strings.Split(strings.TrimRight(ansiSequence.ReplaceAllString(output, ""), "\n"), "\n")
+	if len(lines) > gitCommitOutputLines {
+		lines = append([]string{"[…]"}, lines[len(lines)-gitCommitOutputLines:]...)
+	}
+	return fmt.Sprintf("Run %s\n\n$ %s\n\n%s\n", tool, command, strings.Join(lines, "\n"))
+}
+
+// commitAll stages every change of the working tree and commits it
+// with message, returning the new commit's short hash
+func commitAll(root, message string) (string, error) {
+	if _, err := git(root, "add", "-A"); err != nil {
+		return "", err
+	}
+	cmd := exec.Command("git", "-C", root, "commit", "--quiet", "-F", "-")
+	cmd.Stdin = strings.NewReader(message)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git commit: %s", strings.TrimSpace(string(out)))
+	}
+	hash, err := git(root, "rev-parse", "--short", "HEAD")
+	return strings.TrimSpace(hash), err
+}
+
+// trackPattern matches the ahead/behind counts of %(upstream:track)
+var trackPattern = regexp.MustCompile(`(ahead|behind) (\d+)`)
+
+// trackLabel shortens an upstream track such as "[ahead 1, behind 2]"
+// to ↑1 ↓2
+func trackLabel(track string) string {
+	if track == "[gone]" {
+		return "upstream gone"
+	}
+	var parts []string
+	for _, match := range trackPattern.FindAllStringSubmatch(track, -1) {
+		arrow := "↑"
+		if match[1] == "behind" {
+			arrow = "↓"
+		}
+		parts = append(parts, arrow+match[2])
+	}
+	return strings.Join(parts, " ")
+}
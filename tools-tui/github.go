@@ -0,0 +1,606 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The API token of the GitHub panel is kept in the system keyring
+// under this service and account
+const (
+	githubKeyringService = "tools-tui-github"
+	githubKeyringAccount = "api-token"
+)
+
+// defaultGitHubAPI is the REST API of github.com
+const defaultGitHubAPI = "https://api.github.com"
+
+// githubTimeout bounds a request of the GitHub panel
+const githubTimeout = 20 * time.Second
+
+// maxIssueOutput is how much of a command's output is attached to an
+// issue; GitHub rejects bodies over 65536 characters
+const maxIssueOutput = 60000
+
+// GitHubConfig configures the GitHub panel
+type GitHubConfig struct {
+	// Repo is the owner/name the panel shows, the origin remote's when
+	// empty
+	Repo string `json:"repo,omitempty"`
+	// API is the root of the REST API, for GitHub Enterprise
+	API string `json:"api,omitempty"`
+}
+
+// githubToken returns the API token and where it was found:
+// $GITHUB_TOKEN, $GH_TOKEN, the keyring or the secrets store
+func githubToken() (string, string) {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(name); token != "" {
+			return token, "$" + name
+		}
+	}
+	if token, err := keyringLookup(githubKeyringService, githubKeyringAccount); err == nil {
+		return token, "keyring"
+	}
+	if token, err := GetSecret("github"); err == nil {
+		return token, "secrets store"
+	}
+	return "", ""
+}
+
+// GitHubClient calls the REST API for one repository
+type GitHubClient struct {
+	API   string
+	Repo  string
+	Token string
+	HTTP  *http.Client
+}
+
+// newGitHubClient returns a client for repo with the configured API
+func newGitHubClient(cfg *GitHubConfig, repo, token string) *GitHubClient {
+	api := defaultGitHubAPI
+	if cfg != nil && cfg.API != "" {
+		api = strings.TrimSuffix(cfg.API, "/")
+	}
+	return &GitHubClient{API: api, Repo: repo, Token: token, HTTP: &http.Client{Timeout: githubTimeout}}
+}
+
+// do sends a request with in as its JSON body and decodes the response
+// into out. Errors carry the message of GitHub's error response.
+func (c *GitHubClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.API+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		if failure.Message != "" {
+			return fmt.Errorf("GitHub API returned %s: %s", resp.Status, failure.Message)
+		}
+		return fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// githubIssue is an issue or pull request as the issues API lists them
+type githubIssue struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	HTMLURL   string    `json:"html_url"`
+	Comments  int       `json:"comments"`
+	Draft     bool      `json:"draft"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	// PullRequest is set for pull requests
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// Login returns the user the token belongs to
+func (c *GitHubClient) Login(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	err := c.do(ctx, http.MethodGet, "/user", nil, &user)
+	return user.Login, err
+}
+
+// OpenItems lists the open issues and pull requests of the repository,
+// most recently updated first
+func (c *GitHubClient) OpenItems(ctx context.Context) (issues, pulls []githubIssue, err error) {
+	var items []githubIssue
+	if err := c.do(ctx, http.MethodGet, "/repos/"+c.Repo+"/issues?state=open&sort=updated&per_page=100", nil, &items); err != nil {
+		return nil, nil, err
+	}
+	for _, item := range items {
+		if item.PullRequest != nil {
+			pulls = append(pulls, item)
+		} else {
+			issues = append(issues, item)
+		}
+	}
+	return issues, pulls, nil
+}
+
+// CreateIssue opens an issue in the repository
+func (c *GitHubClient) CreateIssue(ctx context.Context, title, body string) (githubIssue, error) {
+	var issue githubIssue
+	err := c.do(ctx, http.MethodPost, "/repos/"+c.Repo+"/issues", map[string]string{"title": title, "body": body}, &issue)
+	return issue, err
+}
+
+// issueOutput formats the output of a command for an issue body, keeping
+// the end of long output
+func issueOutput(toolName, command, output string) string {
+	output = strings.TrimRight(plainText(output), "\n")
+	if len(output) > maxIssueOutput {
+		output = "…" + output[len(output)-maxIssueOutput:]
+	}
+	fence := "```"
+	for strings.Contains(output, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("### Output of %s\n\n`$ %s`\n\n%s\n%s\n%s\n", toolName, command, fence, output, fence)
+}
+
+// githubView holds the state of the GitHub screen
+type githubView struct {
+	client *GitHubClient
+	// source tells where the token came from
+	source    string
+	login     string
+	issues    []githubIssue
+	pulls     []githubIssue
+	showPulls bool
+	cursor    int
+	body      viewport.Model
+	form      githubForm
+	// token asks for an API token to store in the keyring
+	token    textinput.Model
+	askToken bool
+	busy     string
+	message  string
+}
+
+// githubForm asks for the title and body of a new issue
+type githubForm struct {
+	active bool
+	title  textinput.Model
+	body   textarea.Model
+	// focusBody moves the cursor to the body
+	focusBody bool
+	err       string
+}
+
+// githubListedMsg carries the open issues and pull requests
+type githubListedMsg struct {
+	login         string
+	issues, pulls []githubIssue
+	err           error
+}
+
+// githubCreatedMsg reports a new issue
+type githubCreatedMsg struct {
+	issue githubIssue
+	err   error
+}
+
+// githubTokenMsg reports the outcome of storing a token
+type githubTokenMsg struct {
+	login string
+	err   error
+}
+
+// listGitHubCmd lists the open issues and pull requests and the user
+// the token belongs to
+func listGitHubCmd(client *GitHubClient) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), githubTimeout)
+		defer cancel()
+		// tokens of GitHub Apps cannot read /user but can list
+		login, _ := client.Login(ctx)
+		issues, pulls, err := client.OpenItems(ctx)
+		return githubListedMsg{login: login, issues: issues, pulls: pulls, err: err}
+	}
+}
+
+// createIssueCmd opens an issue
+func createIssueCmd(client *GitHubClient, title, body string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), githubTimeout)
+		defer cancel()
+		issue, err := client.CreateIssue(ctx, title, body)
+		return githubCreatedMsg{issue: issue, err: err}
+	}
+}
+
+// storeGitHubTokenCmd checks a token and stores it in the keyring
+func storeGitHubTokenCmd(client *GitHubClient, token string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), githubTimeout)
+		defer cancel()
+		check := *client
+		check.Token = token
+		login, err := check.Login(ctx)
+		if err != nil {
+			return githubTokenMsg{err: err}
+		}
+		return githubTokenMsg{login: login, err: keyringStore(githubKeyringService, githubKeyringAccount, token)}
+	}
+}
+
+// openGitHub shows the open issues and pull requests of the repository
+// of the current project. From the details of a tool with output the
+// issue form opens with the output attached.
+func (m *Model) openGitHub() tea.Cmd {
+	v := &m.github
+	dir := m.projectDir()
+	if dir == "" {
+		dir = defaultWorkDir
+	}
+	repo := originRepo(dir)
+	if m.githubConfig != nil && m.githubConfig.Repo != "" {
+		repo = m.githubConfig.Repo
+	}
+	token, source := githubToken()
+	*v = githubView{client: newGitHubClient(m.githubConfig, repo, token), source: source}
+	v.body = viewport.New(max(m.width-4, 20), max(m.height-22, 5))
+	m.screen = screenGitHub
+	if repo == "" {
+		v.message = fmt.Sprintf("%s has no GitHub origin remote: set \"github\": {\"repo\": \"owner/name\"} in config.json", dir)
+		return nil
+	}
+	var cmd tea.Cmd
+	if hasOutput(*m) {
+		cmd = m.openIssueForm(fmt.Sprintf("%s: ", m.selectedTool.Name), issueOutput(m.selectedTool.Name, m.selectedTool.Command, m.commandOutput))
+	}
+	if token == "" {
+		v.message = "No API token: set $GITHUB_TOKEN or press " + primaryKey(m.keys.StoreToken) + " to store one in the keyring"
+		return cmd
+	}
+	v.busy = "Listing " + repo + "…"
+	return tea.Batch(cmd, listGitHubCmd(v.client))
+}
+
+// openIssueForm asks for a new issue, starting with title and body
+func (m *Model) openIssueForm(title, body string) tea.Cmd {
+	f := githubForm{active: true, title: newTextInput(), body: newTextArea()}
+	f.title.Prompt = ""
+	f.title.CharLimit = 256
+	f.title.Width = max(m.width-16, 20)
+	f.title.SetValue(title)
+	f.body.CharLimit = 0
+	f.body.SetWidth(max(m.width-4, 20))
+	f.body.SetHeight(max(m.height-16, 5))
+	f.body.SetValue(body)
+	f.body.Blur()
+	m.github.form = f
+	return m.github.form.title.Focus()
+}
+
+// latestOutput returns the tool, command and output of the most recent
+// finished job with output
+func (m Model) latestOutput() (string, string, string, bool) {
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		if job := m.jobs[i]; job.done && job.output != "" {
+			return job.tool.Name, job.tool.Command, job.output, true
+		}
+	}
+	return "", "", "", false
+}
+
+// updateIssueForm handles keys while an issue is written: tab moves
+// between title and body, ctrl+a attaches the latest output, ctrl+s
+// creates the issue
+func (m Model) updateIssueForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.github
+	f := &v.form
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.active = false
+		return m, nil
+	case tea.KeyTab, tea.KeyShiftTab:
+		f.focusBody = !f.focusBody
+		if f.focusBody {
+			f.title.Blur()
+			return m, f.body.Focus()
+		}
+		f.body.Blur()
+		return m, f.title.Focus()
+	case tea.KeyCtrlA:
+		name, command, output, ok := m.latestOutput()
+		if !ok {
+			f.err = "No finished job with output to attach"
+			return m, nil
+		}
+		f.body.SetValue(strings.TrimRight(f.body.Value(), "\n") + "\n\n" + issueOutput(name, command, output))
+		return m, nil
+	case tea.KeyCtrlS:
+		title := strings.TrimSpace(f.title.Value())
+		if title == "" {
+			f.err = "The issue needs a title"
+			return m, nil
+		}
+		if v.client.Token == "" {
+			f.err = "No API token to create the issue with"
+			return m, nil
+		}
+		f.active = false
+		v.busy = "Creating the issue…"
+		return m, createIssueCmd(v.client, title, f.body.Value())
+	case tea.KeyEnter:
+		if !f.focusBody {
+			f.focusBody = true
+			f.title.Blur()
+			return m, f.body.Focus()
+		}
+	}
+	var cmd tea.Cmd
+	if f.focusBody {
+		f.body, cmd = f.body.Update(msg)
+	} else {
+		f.title, cmd = f.title.Update(msg)
+	}
+	f.err = ""
+	return m, cmd
+}
+
+// selectedItem returns the issue or pull request under the cursor
+func (v githubView) selectedItem() *githubIssue {
+	items := v.issues
+	if v.showPulls {
+		items = v.pulls
+	}
+	if v.cursor < len(items) {
+		return &items[v.cursor]
+	}
+	return nil
+}
+
+// showItem renders the body of the selected item
+func (v *githubView) showItem() {
+	item := v.selectedItem()
+	if item == nil {
+		v.body.SetContent("")
+		return
+	}
+	body := strings.TrimSpace(item.Body)
+	if body == "" {
+		body = "_No description._"
+	}
+	v.body.SetContent(renderMarkdown(fmt.Sprintf("# #%d %s\n\n%s\n\n%s", item.Number, item.Title, item.HTMLURL, body)))
+	v.body.GotoTop()
+}
+
+// updateGitHub handles input and responses on the GitHub screen
+func (m Model) updateGitHub(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.github
+	switch msg := msg.(type) {
+	case githubListedMsg:
+		v.busy = ""
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			return m, nil
+		}
+		v.login, v.issues, v.pulls = msg.login, msg.issues, msg.pulls
+		v.cursor = 0
+		v.message = fmt.Sprintf("%d open issues and %d pull requests", len(v.issues), len(v.pulls))
+		v.showItem()
+		return m, nil
+	case githubCreatedMsg:
+		v.busy = ""
+		if msg.err != nil {
+			v.message = "Could not create the issue: " + msg.err.Error()
+			v.form.active = true
+			return m, nil
+		}
+		v.message = fmt.Sprintf("Created #%d %s", msg.issue.Number, msg.issue.HTMLURL)
+		v.showPulls = false
+		v.issues = append([]githubIssue{msg.issue}, v.issues...)
+		v.cursor = 0
+		v.showItem()
+		return m, nil
+	case githubTokenMsg:
+		v.busy = ""
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			return m, nil
+		}
+		v.message = "Stored the token of " + msg.login + " in the keyring"
+		v.client.Token, _ = keyringLookup(githubKeyringService, githubKeyringAccount)
+		v.source = "keyring"
+		v.busy = "Listing " + v.client.Repo + "…"
+		return m, listGitHubCmd(v.client)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if v.form.active {
+		return m.updateIssueForm(keyMsg)
+	}
+	if v.askToken {
+		switch keyMsg.Type {
+		case tea.KeyEsc:
+			v.askToken = false
+		case tea.KeyEnter:
+			v.askToken = false
+			if token := strings.TrimSpace(v.token.Value()); token != "" {
+				v.busy = "Checking the token…"
+				return m, storeGitHubTokenCmd(v.client, token)
+			}
+		default:
+			var cmd tea.Cmd
+			v.token, cmd = v.token.Update(keyMsg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	items := len(v.issues)
+	if v.showPulls {
+		items = len(v.pulls)
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.ToggleCategory):
+		v.showPulls = !v.showPulls
+		v.cursor = 0
+		v.showItem()
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+			v.showItem()
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < items-1 {
+			v.cursor++
+			v.showItem()
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		if v.client.Repo == "" || v.client.Token == "" || v.busy != "" {
+			return m, nil
+		}
+		v.busy = "Listing " + v.client.Repo + "…"
+		return m, listGitHubCmd(v.client)
+	case key.Matches(keyMsg, m.keys.NewIssue):
+		if v.client.Repo == "" {
+			return m, nil
+		}
+		return m, m.openIssueForm("", "")
+	case key.Matches(keyMsg, m.keys.StoreToken):
+		v.token = newTextInput()
+		v.token.Prompt = "Token: "
+		v.token.EchoMode = textinput.EchoPassword
+		v.token.Width = 50
+		v.askToken = true
+		return m, v.token.Focus()
+	default:
+		var cmd tea.Cmd
+		v.body, cmd = v.body.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// renderGitHub renders the open issues or pull requests, the selected
+// one's description and the issue form
+func (m Model) renderGitHub() string {
+	v := m.github
+	var content strings.Builder
+	title := titleStyle.Render("🐙 GitHub")
+	summary := v.client.Repo
+	if v.login != "" {
+		summary += " | signed in as " + v.login + " (" + v.source + ")"
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	if v.form.active {
+		f := v.form
+		content.WriteString(descriptionStyle.Bold(true).Render("New issue in " + v.client.Repo))
+		content.WriteString("\n")
+		content.WriteString("Title: " + f.title.View())
+		content.WriteString("\n\n")
+		content.WriteString(f.body.View())
+		content.WriteString("\n")
+		if f.err != "" {
+			content.WriteString(warningStyle.Render(f.err))
+			content.WriteString("\n")
+		}
+		content.WriteString(footerStyle.Render("ctrl+s: create | tab: title/body | ctrl+a: attach latest output | esc: cancel"))
+		return content.String()
+	}
+
+	items, label := v.issues, "Issues"
+	if v.showPulls {
+		items, label = v.pulls, "Pull requests"
+	}
+	var lines []string
+	for _, item := range items {
+		var labels []string
+		for _, l := range item.Labels {
+			labels = append(labels, l.Name)
+		}
+		if item.Draft {
+			labels = append(labels, "draft")
+		}
+		line := fmt.Sprintf("#%-5d %s", item.Number, truncate(item.Title, 60))
+		meta := fmt.Sprintf(" %s · %s", item.User.Login, item.UpdatedAt.Format("2006-01-02"))
+		if len(labels) > 0 {
+			meta += " · " + strings.Join(labels, ", ")
+		}
+		lines = append(lines, line+helpStyle.Render(meta))
+	}
+	content.WriteString(renderMCPList(fmt.Sprintf("%s (%d)", label, len(items)), lines, v.cursor, true))
+	content.WriteString("\n")
+	content.WriteString(v.body.View())
+	content.WriteString("\n")
+
+	if v.askToken {
+		content.WriteString(v.token.View())
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render("enter: check and store in the keyring | esc: cancel"))
+		content.WriteString("\n")
+	}
+	if v.busy != "" {
+		content.WriteString(commandStyle.Render("⏳ " + v.busy))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("issues/pull requests", k.ToggleCategory), hint("new issue", k.NewIssue), hint("refresh", k.Refresh), hint("store token", k.StoreToken), "pgup/pgdn: scroll", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// gitListRows is how many rows of a Git dashboard list are shown around
+// the cursor
+const gitListRows = 10
+
+// gitSection is a list of the Git dashboard
+type gitSection int
+
+const (
+	gitChanges gitSection = iota
+	gitCommits
+	gitBranches
+)
+
+// gitSectionNames are the headings of the sections, in tab order
+var gitSectionNames = []string{"Changes", "Commits", "Branches"}
+
+// gitView holds the state of the Git dashboard
+type gitView struct {
+	dir     string
+	state   *gitState
+	err     error
+	section gitSection
+	cursors [3]int
+	body    viewport.Model
+	// commitMessage is the message of a commit of the last run's
+	// changes waiting to be confirmed
+	commitMessage string
+	busy          string
+	message       string
+}
+
+// gitMsg carries the state of the repository after a load, branch switch
+// or commit
+type gitMsg struct {
+	state *gitState
+	err   error
+	done  string
+}
+
+// loadGitCmd reads the repository in the background, after change when
+// it is set; an error of change is reported, the state is read anyway
+func loadGitCmd(dir string, change func() (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		var done string
+		var changeErr error
+		if change != nil {
+			done, changeErr = change()
+		}
+		state, err := loadGitState(dir)
+		if changeErr != nil {
+			err = changeErr
+		}
+		return gitMsg{state: state, err: err, done: done}
+	}
+}
+
+// openGit shows the Git dashboard of the current project
+func (m *Model) openGit() tea.Cmd {
+	dir := m.projectDir()
+	if dir == "" {
+		dir = defaultWorkDir
+	}
+	m.git = gitView{dir: dir, busy: "Reading " + dir + "…"}
+	m.git.body = viewport.New(max(m.width-4, 20), max(m.height-gitListRows-12, 5))
+	m.screen = screenGit
+	return loadGitCmd(dir, nil)
+}
+
+// count returns how many rows a section has
+func (v gitView) count(section gitSection) int {
+	if v.state == nil {
+		return 0
+	}
+	switch section {
+	case gitChanges:
+		return len(v.state.Files)
+	case gitCommits:
+		return len(v.state.Commits)
+	}
+	return len(v.state.Branches)
+}
+
+// rows returns how many rows the current section has
+func (v gitView) rows() int {
+	return v.count(v.section)
+}
+
+// show fills the body with the diff of the selected file, the summary
+// of the selected commit or the last commit of the selected branch
+func (v *gitView) show() {
+	content := ""
+	cursor := v.cursors[v.section]
+	switch {
+	case v.state == nil || cursor >= v.rows():
+	case v.section == gitChanges:
+		content = gitFileDiff(v.state.Root, v.state.Files[cursor])
+	case v.section == gitCommits:
+		content = gitCommitSummary(v.state.Root, v.state.Commits[cursor].Hash)
+	default:
+		b := v.state.Branches[cursor]
+		content = gitCommitSummary(v.state.Root, b.Name)
+	}
+	v.body.SetContent(content)
+	v.body.GotoTop()
+}
+
+// updateGit handles input on the Git dashboard
+func (m Model) updateGit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.git
+	if msg, ok := msg.(gitMsg); ok {
+		v.busy = ""
+		v.state, v.err = msg.state, msg.err
+		v.message = msg.done
+		if msg.err != nil && msg.state != nil {
+			// the change failed but the repository was read
+			v.message, v.err = msg.err.Error(), nil
+		}
+		for s := range v.cursors {
+			v.cursors[s] = min(v.cursors[s], max(v.count(gitSection(s))-1, 0))
+		}
+		v.show()
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if v.commitMessage != "" {
+		message := v.commitMessage
+		v.commitMessage = ""
+		if !key.Matches(keyMsg, m.keys.Confirm) {
+			v.message = "Nothing committed"
+			return m, nil
+		}
+		root := v.state.Root
+		v.busy = "Committing…"
+		return m, loadGitCmd(root, func() (string, error) {
+			hash, err := commitAll(root, message)
+			return "Committed " + hash + " " + firstLine(message), err
+		})
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.ToggleCategory):
+		v.section = (v.section + 1) % gitSection(len(gitSectionNames))
+		v.show()
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursors[v.section] > 0 {
+			v.cursors[v.section]--
+			v.show()
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursors[v.section] < v.rows()-1 {
+			v.cursors[v.section]++
+			v.show()
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if v.section != gitBranches || v.rows() == 0 || v.busy != "" {
+			return m, nil
+		}
+		b := v.state.Branches[v.cursors[gitBranches]]
+		if b.Current {
+			v.message = "Already on " + b.Name
+			return m, nil
+		}
+		root := v.state.Root
+		v.busy = "Switching to " + b.Name + "…"
+		return m, loadGitCmd(root, func() (string, error) {
+			return "Switched to " + b.Name, switchBranch(root, b.Name)
+		})
+	case key.Matches(keyMsg, m.keys.CommitRun):
+		if v.state == nil || v.busy != "" {
+			return m, nil
+		}
+		tool, command, output, ok := m.latestOutput()
+		switch {
+		case !ok:
+			v.message = "No finished run with output to commit"
+		case len(v.state.Files) == 0:
+			v.message = "The working tree is clean: " + tool + " changed nothing to commit"
+		default:
+			v.commitMessage = runCommitMessage(tool, command, output)
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		if v.busy == "" {
+			v.busy = "Reading " + v.dir + "…"
+			return m, loadGitCmd(v.dir, nil)
+		}
+	default:
+		var cmd tea.Cmd
+		v.body, cmd = v.body.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// renderGit renders the branch, the selected list and the diff or
+// summary of its selected row
+func (m Model) renderGit() string {
+	v := m.git
+	k := m.keys
+	var content strings.Builder
+	title := titleStyle.Render("🌿 Git")
+	summary := v.dir
+	if s := v.state; s != nil {
+		summary = "⎇ " + s.Branch
+		if s.Upstream != "" {
+			summary += " → " + s.Upstream
+			if s.Ahead > 0 {
+				summary += fmt.Sprintf(" ↑%d", s.Ahead)
+			}
+			if s.Behind > 0 {
+				summary += fmt.Sprintf(" ↓%d", s.Behind)
+			}
+		}
+		summary += fmt.Sprintf(" · %d changed · %s", len(s.Files), s.Root)
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	if v.err != nil {
+		content.WriteString(warningStyle.Render(v.err.Error()))
+		content.WriteString("\n\n")
+		content.WriteString(footerStyle.Render(strings.Join([]string{hint("reload", k.Refresh), hint("back", k.Back)}, " | ")))
+		return content.String()
+	}
+
+	if s := v.state; s != nil {
+		var tabs []string
+		for i, name := range gitSectionNames {
+			label := fmt.Sprintf("%s (%d)", name, v.count(gitSection(i)))
+			if gitSection(i) == v.section {
+				tabs = append(tabs, selectedItemStyle.Render(label))
+			} else {
+				tabs = append(tabs, helpStyle.Render(label))
+			}
+		}
+		content.WriteString(strings.Join(tabs, helpStyle.Render(" | ")))
+		content.WriteString("\n")
+
+		var lines []string
+		switch v.section {
+		case gitChanges:
+			for _, f := range s.Files {
+				lines = append(lines, f.Label())
+			}
+		case gitCommits:
+			for _, c := range s.Commits {
+				lines = append(lines, fmt.Sprintf("%s %s", commandStyle.Render(c.Hash), truncate(c.Subject, 60))+helpStyle.Render(fmt.Sprintf(" %s · %s", c.Author, c.When)))
+			}
+		case gitBranches:
+			for _, b := range s.Branches {
+				marker := "  "
+				if b.Current {
+					marker = "* "
+				}
+				meta := " " + b.When
+				if b.Upstream != "" {
+					meta = fmt.Sprintf(" %s %s ·%s", b.Upstream, trackLabel(b.Track), meta)
+				}
+				lines = append(lines, marker+fmt.Sprintf("%-24s %s", b.Name, truncate(b.Subject, 50))+helpStyle.Render(meta))
+			}
+		}
+		cursor := v.cursors[v.section]
+		start := max(0, min(cursor-gitListRows/2, len(lines)-gitListRows))
+		end := min(start+gitListRows, len(lines))
+		content.WriteString(renderMCPList("", lines[start:end], cursor-start, true))
+		content.WriteString(v.body.View())
+		content.WriteString("\n")
+	}
+
+	if v.commitMessage != "" {
+		content.WriteString(warningStyle.Render(fmt.Sprintf("Commit the %d changed files of %s with this message? %s to confirm", len(v.state.Files), v.state.Root, primaryKey(k.Confirm))))
+		content.WriteString("\n")
+		preview := strings.Split(v.commitMessage, "\n")
+		if len(preview) > 8 {
+			preview = append(preview[:8], "…")
+		}
+		content.WriteString(descriptionStyle.Render(strings.Join(preview, "\n")))
+		content.WriteString("\n")
+	}
+	if v.busy != "" {
+		content.WriteString(commandStyle.Render("⏳ " + v.busy))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("changes/commits/branches", k.ToggleCategory), hint("switch branch", k.Enter), hint("commit last run", k.CommitRun), hint("refresh", k.Refresh), "pgup/pgdn: scroll", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxGraphQLSchema bounds the introspection result, which is large for
+// APIs such as Linear's
+const maxGraphQLSchema = 32 << 20
+
+// maxGraphQLSuggestions is how many completions are offered at once
+const maxGraphQLSuggestions = 8
+
+// introspectionQuery asks a GraphQL server for its types and their
+// fields, as far as completion needs them
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      name
+      kind
+      fields(includeDeprecated: false) {
+        name
+        description
+        args { name type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+      inputFields { name type { ...TypeRef } }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// graphQLQuery is the query and variables of a GraphQL request, sent
+// as a JSON POST body. Variables are kept as text so they can hold
+// {{variables}} of the environment.
+type graphQLQuery struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
+}
+
+// graphQLPayload returns the JSON body of a GraphQL request
+func graphQLPayload(q graphQLQuery) (string, error) {
+	payload := map[string]interface{}{"query": q.Query}
+	if vars := strings.TrimSpace(q.Variables); vars != "" {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(vars), &decoded); err != nil {
+			return "", fmt.Errorf("the variables are not a JSON object: %w", err)
+		}
+		payload["variables"] = decoded
+	}
+	data, err := json.Marshal(payload)
+	return string(data), err
+}
+
+// gqlTypeRef is a possibly wrapped type of an introspection result
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+// String writes the type as GraphQL does, such as [Issue!]!
+func (t *gqlTypeRef) String() string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Kind == "NON_NULL":
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// Named returns the type without its list and non-null wrappers
+func (t *gqlTypeRef) Named() string {
+	for t != nil && t.OfType != nil {
+		t = t.OfType
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name
+}
+
+// gqlField is a field of an object type, or an argument or input field
+type gqlField struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Args        []gqlField  `json:"args"`
+	Type        *gqlTypeRef `json:"type"`
+}
+
+// gqlType is a named type of the schema
+type gqlType struct {
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	Fields      []gqlField `json:"fields"`
+	InputFields []gqlField `json:"inputFields"`
+}
+
+// gqlSchema is what introspection says about a GraphQL API
+type gqlSchema struct {
+	Query        string
+	Mutation     string
+	Subscription string
+	Types        map[string]gqlType
+}
+
+// gqlSuggestion is a completion of the name under the cursor
+type gqlSuggestion struct {
+	Name   string
+	Detail string
+}
+
+// introspectGraphQL fetches the schema of the GraphQL API r is sent to,
+// with the headers of r
+func introspectGraphQL(r httpRequest) (*gqlSchema, error) {
+	body, err := graphQLPayload(graphQLQuery{Query: introspectionQuery})
+	if err != nil {
+		return nil, err
+	}
+	r.Method, r.Body = http.MethodPost, body
+	req, err := newHTTPRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: httpTimeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxGraphQLSchema))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Data struct {
+			Schema struct {
+				QueryType        *gqlTypeRef `json:"queryType"`
+				MutationType     *gqlTypeRef `json:"mutationType"`
+				SubscriptionType *gqlTypeRef `json:"subscriptionType"`
+				Types            []gqlType   `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%s did not answer with a GraphQL result (%s): %w", r.URL, resp.Status, err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", result.Errors[0].Message)
+	}
+	s := result.Data.Schema
+	if s.QueryType == nil {
+		return nil, fmt.Errorf("%s returned no schema (%s)", r.URL, resp.Status)
+	}
+	schema := &gqlSchema{Query: s.QueryType.Name, Mutation: s.MutationType.Named(), Subscription: s.SubscriptionType.Named(), Types: map[string]gqlType{}}
+	for _, t := range s.Types {
+		schema.Types[t.Name] = t
+	}
+	return schema, nil
+}
+
+// field returns the field called name of a type
+func (s *gqlSchema) field(typeName, name string) (gqlField, bool) {
+	for _, f := range s.Types[typeName].Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return gqlField{}, false
+}
+
+// isNameRune reports whether r can be part of a GraphQL name
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scope reads a query up to the cursor and returns the type whose
+// fields can be selected there, or the field whose arguments are being
+// written, and the start of the name being typed
+func (s *gqlSchema) scope(text string) (typeName string, argsOf *gqlField, prefix string) {
+	runes := []rune(text)
+	var stack []string
+	var last, beforeLast, operation, argsField string
+	parens := 0
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case isNameRune(c) && !unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && isNameRune(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			i--
+			if parens > 0 {
+				continue
+			}
+			if len(stack) == 0 && operation == "" && (word == "query" || word == "mutation" || word == "subscription") {
+				operation = word
+			}
+			last, beforeLast = word, last
+		case c == '(':
+			if parens == 0 {
+				argsField = last
+			}
+			parens++
+		case c == ')':
+			parens = max(parens-1, 0)
+		case c == '{' && parens == 0:
+			typ := ""
+			switch {
+			case beforeLast == "on":
+				typ = last
+			case len(stack) == 0 && operation == "mutation":
+				typ = s.Mutation
+			case len(stack) == 0 && operation == "subscription":
+				typ = s.Subscription
+			case len(stack) == 0:
+				typ = s.Query
+			default:
+				if f, ok := s.field(stack[len(stack)-1], last); ok {
+					typ = f.Type.Named()
+				}
+			}
+			stack = append(stack, typ)
+			last, beforeLast = "", ""
+		case c == '}' && parens == 0:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			last, beforeLast = "", ""
+		}
+	}
+	start := len(runes)
+	for start > 0 && isNameRune(runes[start-1]) {
+		start--
+	}
+	prefix = string(runes[start:])
+	if len(stack) == 0 {
+		return "", nil, prefix
+	}
+	typeName = stack[len(stack)-1]
+	if parens > 0 {
+		// a name right after a colon is a value, not an argument
+		if before := strings.TrimRightFunc(string(runes[:start]), unicode.IsSpace); strings.HasSuffix(before, ":") {
+			return "", nil, prefix
+		}
+		if f, ok := s.field(typeName, argsField); ok {
+			return "", &f, prefix
+		}
+		return "", nil, prefix
+	}
+	return typeName, nil, prefix
+}
+
+// Complete returns the fields or arguments that can be written at the
+// end of text and start with what is being typed there
+func (s *gqlSchema) Complete(text string) (prefix string, suggestions []gqlSuggestion) {
+	typeName, argsOf, prefix := s.scope(text)
+	var candidates []gqlField
+	switch {
+	case argsOf != nil:
+		candidates = argsOf.Args
+	case typeName != "":
+		t := s.Types[typeName]
+		candidates = append(append([]gqlField{}, t.Fields...), t.InputFields...)
+	}
+	lower := strings.ToLower(prefix)
+	for _, f := range candidates {
+		if !strings.HasPrefix(strings.ToLower(f.Name), lower) || f.Name == prefix {
+			continue
+		}
+		detail := f.Type.String()
+		if len(f.Args) > 0 {
+			names := make([]string, len(f.Args))
+			for i, arg := range f.Args {
+				names[i] = arg.Name
+			}
+			detail = "(" + strings.Join(names, ", ") + "): " + detail
+		}
+		if f.Description != "" {
+			detail += " · " + firstLine(f.Description)
+		}
+		suggestions = append(suggestions, gqlSuggestion{Name: f.Name, Detail: detail})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		// names that match the case as typed come first
+		return strings.HasPrefix(suggestions[i].Name, prefix) && !strings.HasPrefix(suggestions[j].Name, prefix)
+	})
+	if len(suggestions) > maxGraphQLSuggestions {
+		suggestions = suggestions[:maxGraphQLSuggestions]
+	}
+	return prefix, suggestions
+}
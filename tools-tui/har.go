@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// harLog is the root of a HAR 1.2 file
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// harCreator names the program that wrote a HAR file
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// harEntry is a request and its response
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Error is what prevented a response, as browsers record it
+	Error string `json:"_error,omitempty"`
+}
+
+// harPair is a header, query parameter or form field
+type harPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harRequest is the request of an entry
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harPair    `json:"cookies"`
+	Headers     []harPair    `json:"headers"`
+	QueryString []harPair    `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// harPostData is the body of a request, as text or form parameters
+type harPostData struct {
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text,omitempty"`
+	Params   []harParam `json:"params,omitempty"`
+}
+
+// harParam is a parameter of a form; FileName is set for an uploaded
+// file
+type harParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// harResponse is the response of an entry
+type harResponse struct {
+	Status      int        `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []harPair  `json:"cookies"`
+	Headers     []harPair  `json:"headers"`
+	Content     harContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int64      `json:"bodySize"`
+}
+
+// harContent is the body of a response
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harTimings splits the time of an entry; only the wait is measured
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harSkippedHeaders are request headers a replay must not copy: the
+// HTTP/2 pseudo-headers start with ":" and these are set by the client
+var harSkippedHeaders = map[string]bool{
+	"content-length":    true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"transfer-encoding": true,
+}
+
+// ImportHAR reads the requests of a HAR file, as exported by browser
+// devtools, skipping the ones that are not HTTP such as data: URLs
+func ImportHAR(path string) ([]httpRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("%s is not a HAR file: %w", filepath.Base(path), err)
+	}
+	if len(har.Log.Entries) == 0 {
+		return nil, fmt.Errorf("%s has no entries", filepath.Base(path))
+	}
+	var requests []httpRequest
+	for _, entry := range har.Log.Entries {
+		r := entry.Request
+		if !strings.HasPrefix(r.URL, "http://") && !strings.HasPrefix(r.URL, "https://") {
+			continue
+		}
+		request := httpRequest{Method: strings.ToUpper(r.Method), URL: r.URL}
+		for _, h := range r.Headers {
+			if strings.HasPrefix(h.Name, ":") || harSkippedHeaders[strings.ToLower(h.Name)] {
+				continue
+			}
+			request.Headers = append(request.Headers, httpHeader{h.Name, h.Value})
+		}
+		if p := r.PostData; p != nil {
+			request.Body = p.Text
+			mimeType := p.MimeType
+			switch {
+			case p.Text != "" || len(p.Params) == 0:
+			case strings.HasPrefix(p.MimeType, "multipart/form-data"):
+				// the boundary of the recorded body no longer applies
+				for _, param := range p.Params {
+					request.Form = append(request.Form, httpFormField{Name: param.Name, Value: param.Value, File: param.FileName})
+				}
+				mimeType = ""
+			default:
+				form := url.Values{}
+				for _, param := range p.Params {
+					form.Add(param.Name, param.Value)
+				}
+				request.Body = form.Encode()
+			}
+			if mimeType != "" && headerValue(request.Headers, "Content-Type") == "" {
+				request.Headers = append(request.Headers, httpHeader{"Content-Type", mimeType})
+			}
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+// harPairs converts headers to HAR name/value pairs
+func harPairs(headers []httpHeader) []harPair {
+	pairs := []harPair{}
+	for _, h := range headers {
+		pairs = append(pairs, harPair{h.Name, h.Value})
+	}
+	return pairs
+}
+
+// harProtocol returns the protocol of a response as HAR spells it
+func harProtocol(response *httpResponse) string {
+	if response == nil || response.Protocol == "" {
+		return "HTTP/1.1"
+	}
+	return response.Protocol
+}
+
+// harEntryOf describes an exchange of the request builder as a HAR entry
+func harEntryOf(e httpExchange) harEntry {
+	ms := float64(e.DurationMs)
+	entry := harEntry{
+		StartedDateTime: e.Started.Format(time.RFC3339Nano),
+		Time:            ms,
+		Timings:         harTimings{Wait: ms},
+		Error:           e.Error,
+	}
+	r := e.Request
+	entry.Request = harRequest{
+		Method:      r.Method,
+		URL:         r.URL,
+		HTTPVersion: harProtocol(e.Response),
+		Cookies:     []harPair{},
+		Headers:     harPairs(r.Headers),
+		QueryString: []harPair{},
+		HeadersSize: -1,
+		BodySize:    len(r.Body),
+	}
+	if u, err := url.Parse(r.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harPair{name, value})
+			}
+		}
+	}
+	switch {
+	case len(r.Form) > 0:
+		entry.Request.PostData = &harPostData{MimeType: "multipart/form-data"}
+		for _, f := range r.Form {
+			param := harParam{Name: f.Name, Value: f.Value}
+			if f.File != "" {
+				param.FileName, param.ContentType = f.File, fileContentType(f.File)
+			}
+			entry.Request.PostData.Params = append(entry.Request.PostData.Params, param)
+		}
+	case r.Body != "":
+		entry.Request.PostData = &harPostData{MimeType: r.Header("Content-Type"), Text: r.Body}
+	}
+	entry.Response = harResponse{HTTPVersion: harProtocol(e.Response), Cookies: []harPair{}, Headers: []harPair{}, HeadersSize: -1, BodySize: -1}
+	if resp := e.Response; resp != nil {
+		entry.Response.Status = resp.Status
+		entry.Response.StatusText = resp.Text
+		entry.Response.Headers = harPairs(resp.Headers)
+		entry.Response.RedirectURL = headerValue(resp.Headers, "Location")
+		entry.Response.BodySize = resp.Size
+		entry.Response.Content = harContent{Size: resp.Size, MimeType: headerValue(resp.Headers, "Content-Type"), Text: resp.Body, Encoding: resp.Encoding}
+	}
+	return entry
+}
+
+// ExportHAR writes exchanges, oldest first, to a HAR file named after
+// the time in dir and returns its path
+func ExportHAR(dir string, exchanges []httpExchange, at time.Time) (string, error) {
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "opencode-tools-tui", Version: "1.0"}
+	har.Log.Entries = []harEntry{}
+	for i := len(exchanges) - 1; i >= 0; i-- {
+		har.Log.Entries = append(har.Log.Entries, harEntryOf(exchanges[i]))
+	}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("requests-%s.har", at.Format("20060102-150405")))
+	return path, WriteFileContent(path, string(data)+"\n")
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// toolInfo is the machine-readable description of a catalog entry
+type toolInfo struct {
+	Key          string   `json:"key"`
+	Category     string   `json:"category"`
+	Tool         Tool     `json:"tool"`
+	Repo         string   `json:"repo,omitempty"`
+	Annotation   string   `json:"annotation,omitempty"`
+	Placeholders []string `json:"placeholders,omitempty"`
+	Unsupported  string   `json:"unsupported,omitempty"`
+}
+
+// newToolInfo describes tool as found in category
+func newToolInfo(category string, tool *Tool) toolInfo {
+	info := toolInfo{
+		Key:         tool.Key(),
+		Category:    category,
+		Tool:        *tool,
+		Repo:        tool.Repo,
+		Annotation:  tool.Annotation,
+		Unsupported: tool.UnsupportedReason(),
+	}
+	for _, p := range tool.placeholders() {
+		info.Placeholders = append(info.Placeholders, p.Name)
+	}
+	return info
+}
+
+// searchHit is a search result of the search subcommand
+type searchHit struct {
+	toolInfo
+	Field string `json:"field"`
+	Text  string `json:"text"`
+	Score int    `json:"score"`
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// loadCatalogStrict loads the catalog for headless use, where a broken
+// manifest is an error rather than a toast
+func loadCatalogStrict() ([]Category, error) {
+	categories, err := LoadToolsFromInventory()
+	if err != nil {
+		return nil, fmt.Errorf("inventory: %v", err)
+	}
+	return categories, nil
+}
+
+// lookupTool finds a tool by key, or by name ignoring case
+func lookupTool(categories []Category, name string) (*Tool, error) {
+	if tool := findTool(categories, name); tool != nil {
+		return tool, nil
+	}
+	var found []*Tool
+	for i := range categories {
+		for j := range categories[i].Tools {
+			if strings.EqualFold(categories[i].Tools[j].Name, name) {
+				found = append(found, &categories[i].Tools[j])
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no tool named %q, see `tools-tui list`", name)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%q is ambiguous, use the tool key (repo/name)", name)
+	}
+}
+
+// runList prints the catalog as JSON
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	category := fs.String("category", "", "only list tools of categories containing this text")
+	fs.Parse(args)
+
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	tools := []toolInfo{}
+	for i := range categories {
+		if *category != "" && !strings.Contains(strings.ToLower(categories[i].Name), strings.ToLower(*category)) {
+			continue
+		}
+		for j := range categories[i].Tools {
+			tools = append(tools, newToolInfo(categories[i].Name, &categories[i].Tools[j]))
+		}
+	}
+	return printJSON(tools)
+}
+
+// runSearch prints the tools matching a query as JSON, best first
+func runSearch(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tools-tui search <query>")
+	}
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	hits := []searchHit{}
+	for _, result := range searchCatalog(categories, strings.Join(args, " ")) {
+		category := categories[result.cat]
+		hits = append(hits, searchHit{
+			toolInfo: newToolInfo(category.Name, &category.Tools[result.tool]),
+			Field:    result.field,
+			Text:     result.text,
+			Score:    result.score,
+		})
+	}
+	return printJSON(hits)
+}
+
+// argFlags collects repeated --arg name=value flags
+type argFlags map[string]string
+
+func (a argFlags) String() string { return fmt.Sprint(map[string]string(a)) }
+
+func (a argFlags) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	a[name] = val
+	return nil
+}
+
+// prepareRun applies the checks the TUI makes before running a tool
+// and returns the tool with its placeholders filled from values, which
+// receives the defaults of those not given. The prompts of the TUI are
+// answered by yes, which confirms sandboxed and destructive tools, and
+// override, which runs dangerous tools during quiet hours.
+func prepareRun(tool *Tool, values map[string]string, yes, override bool) (Tool, error) {
+	approval := Attended
+	if yes {
+		approval = Confirmed
+	}
+	if reason := runApproval(tool, approval); reason != "" {
+		return Tool{}, fmt.Errorf("cannot run %s: %s", tool.Name, reason)
+	}
+	if quiet, reason := loadQuietHours().Active(time.Now()); quiet && tool.Dangerous && !override {
+		return Tool{}, fmt.Errorf("%s is dangerous and it is %s, pass --override to run it anyway", tool.Name, reason)
+	}
+	if tool.Destructive && !yes {
+		return Tool{}, fmt.Errorf("%s is destructive, pass --yes to confirm or --dry-run to see what it would run", tool.Name)
+	}
+	if dir, ok := ExtensionDir(tool); ok {
+		if report, err := VerifyExtension(dir); err == nil && report.Failed() {
+			return Tool{}, fmt.Errorf("integrity check failed, refusing to run:\n%s", report.Summary())
+		}
+	}
+
+	var missing []string
+	for _, p := range tool.placeholders() {
+		if _, ok := values[p.Name]; !ok {
+			values[p.Name] = p.Default
+		}
+		if !p.Optional && values[p.Name] == "" {
+			missing = append(missing, p.Name)
+		} else if err := p.checkArg(values[p.Name]); err != nil {
+			return Tool{}, err
+		}
+	}
+	if len(missing) > 0 {
+		return Tool{}, fmt.Errorf("missing arguments, pass --arg %s=...", strings.Join(missing, "=... --arg "))
+	}
+
+	run := *tool
+	run.Command = tool.fillCommand(values)
+	return run, nil
+}
+
+// runRun executes a tool with the same checks and executor as the TUI
+// and prints the run record as JSON. Prompts of the TUI become flags:
+// placeholders are filled with --arg, sandboxed and destructive tools
+// need --yes and dangerous tools need --override during quiet hours.
+// --dry-run prints the resolved command instead.
+func runRun(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("usage: tools-tui run <tool> [--example name] [--arg name=value]... [--project dir] [--yes] [--override] [--dry-run] [--events-json]")
+	}
+	name, args := args[0], args[1:]
+	values := argFlags{}
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Var(values, "arg", "value for a command placeholder as name=value (repeatable)")
+	example := fs.String("example", "", "start from the argument values of a named example of the tool; --arg overrides them")
+	project := fs.String("project", "", "sub-project directory scoped tools run in")
+	yes := fs.Bool("yes", false, "run tools whose trust tier asks for confirmation")
+	override := fs.Bool("override", false, "run dangerous tools during quiet hours")
+	writeManifest := fs.Bool("manifest", false, "write a run manifest for `tools-tui replay`")
+	dryRun := fs.Bool("dry-run", false, "print the directory, command and environment the tool would run with, without running it")
+	events := fs.Bool("events-json", false, "stream newline-delimited JSON events (started, output, finished) instead of printing the run record")
+	fs.Parse(args)
+
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	tool, err := lookupTool(categories, name)
+	if err != nil {
+		return err
+	}
+	if *example != "" {
+		chosen, ok := tool.example(*example)
+		if !ok {
+			var names []string
+			for _, other := range tool.examples() {
+				names = append(names, other.Name)
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("%s has no examples", tool.Name)
+			}
+			return fmt.Errorf("%s has no example %q (examples: %s)", tool.Name, *example, strings.Join(names, ", "))
+		}
+		for name, value := range tool.exampleValues(chosen) {
+			if _, ok := values[name]; !ok {
+				values[name] = value
+			}
+		}
+	}
+	run, err := prepareRun(tool, values, *yes || *dryRun, *override || *dryRun)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		plan, err := DryRun(&run, *project, argValues(values))
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan)
+		return nil
+	}
+	dir, _ := scopedCommand(&run, *project)
+	env := CaptureEnv(dir)
+	started := time.Now()
+	var output string
+	var runErr error
+	emit := newEventEmitter(os.Stdout)
+	if *events {
+		emit(runEvent{Event: "started", Tool: run.Key(), Command: run.Command, Dir: dir})
+		output, runErr = streamEvents(&run, *project, emit)
+	} else {
+		output, runErr = ExecuteTool(&run, *project)
+	}
+	record := newRunRecord(&run, *project, started, env, output, runErr)
+	record.Args = argValues(values)
+	record.Output = output
+	if err := AppendHistory(record, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record run history: %v\n", err)
+	}
+	if cfg, _ := LoadConfig(); *writeManifest || cfg.Manifests {
+		if path, err := WriteRunManifest(newRunManifest(record, &run)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not write run manifest: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Manifest: %s\n", path)
+		}
+	}
+	if *events {
+		finished := record
+		finished.Output = ""
+		emit(runEvent{Event: "finished", Record: &finished})
+	} else if err := printJSON(record); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s failed: %v", tool.Name, runErr)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// healthRecheckInterval is how often the environment checks run again
+const healthRecheckInterval = 5 * time.Minute
+
+// healthProblem is a critical problem with the environment that is
+// shown in the warning banner until it is fixed
+type healthProblem struct {
+	Title  string
+	Detail string
+	Fix    string
+	// Server is set when the problem is a supervised MCP server, which
+	// enter on the health screen jumps to
+	Server string
+	// Category and Tool name an inventory entry enter selects
+	Category, Tool string
+}
+
+// healthView holds the state of the health details screen
+type healthView struct {
+	cursor     int
+	refreshing bool
+}
+
+// healthMsg carries the result of the environment checks. Scheduled
+// checks schedule the next one, checks asked for with refresh do not.
+type healthMsg struct {
+	problems  []healthProblem
+	scheduled bool
+}
+
+// checkHealthCmd runs the environment checks in the background
+func checkHealthCmd(categories []Category, scheduled bool) tea.Cmd {
+	return func() tea.Msg {
+		return healthMsg{problems: checkEnvironment(categories), scheduled: scheduled}
+	}
+}
+
+// recheckHealthCmd runs the environment checks again after a while
+func recheckHealthCmd(categories []Category) tea.Cmd {
+	return tea.Tick(healthRecheckInterval, func(time.Time) tea.Msg {
+		return healthMsg{problems: checkEnvironment(categories), scheduled: true}
+	})
+}
+
+// checkEnvironment looks for missing interpreters, rejected tokens and
+// inventory entries that run cli.py commands which no longer exist
+func checkEnvironment(categories []Category) []healthProblem {
+	var problems []healthProblem
+	if _, err := exec.LookPath("python3"); err != nil {
+		problems = append(problems, healthProblem{
+			Title:  "python3 not found",
+			Detail: "Most tools and the local MCP servers are Python scripts and cannot run without python3 on PATH.",
+			Fix:    "Install Python 3, make sure python3 is on PATH and restart the TUI.",
+		})
+	}
+	if problem := checkGitHubToken(); problem != nil {
+		problems = append(problems, *problem)
+	}
+	return append(problems, checkCLICommands(categories)...)
+}
+
+// checkCLICommands compares the cli.py commands of the inventory with
+// the subcommands the importer discovers, so entries of renamed or
+// removed commands show up before they fail with a usage error. A
+// cli.py that cannot be read or lists no commands is not judged.
+func checkCLICommands(categories []Category) []healthProblem {
+	python, err := cliInterpreter()
+	if err != nil {
+		return nil
+	}
+	known := map[string][]string{}
+	var problems []healthProblem
+	for _, category := range categories {
+		for _, tool := range category.Tools {
+			command, ok := cliCommandOf(tool)
+			if !ok {
+				continue
+			}
+			root := cliRoot(tool)
+			names, seen := known[root]
+			if !seen {
+				if source, err := ReadFileContent(filepath.Join(root, "cli.py")); err == nil {
+					names = cliCommandNames(python, root, source)
+				}
+				known[root] = names
+			}
+			if len(names) == 0 || slices.Contains(names, command) {
+				continue
+			}
+			fix := fmt.Sprintf("Fix the command of %s in the inventory, or run `tools-tui inventory import` to regenerate the imported cli.py entries.", tool.Name)
+			if closest := closestCLICommand(command, names); closest != "" {
+				script := strings.Fields(tool.Command)[1]
+				at := strings.Index(tool.Command, script) + len(script)
+				renamed := tool.Command[:at] + strings.Replace(tool.Command[at:], command, closest, 1)
+				fix = fmt.Sprintf("If %s was renamed to %s, change the command to `%s`; `tools-tui inventory import` regenerates the imported cli.py entries.", command, closest, renamed)
+			}
+			problems = append(problems, healthProblem{
+				Title:    fmt.Sprintf("Stale inventory entry: %s", tool.Name),
+				Detail:   fmt.Sprintf("%s runs `%s`, but %s/cli.py has no %s command (it has %s).", tool.Name, tool.Command, root, command, strings.Join(names, ", ")),
+				Fix:      fix,
+				Category: category.Name,
+				Tool:     tool.Name,
+			})
+		}
+	}
+	return problems
+}
+
+// checkGitHubToken asks GitHub whether GITHUB_TOKEN is still accepted.
+// Network failures are not reported, only a token GitHub rejects.
+func checkGitHubToken() *healthProblem {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/user", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil
+	}
+	return &healthProblem{
+		Title:  "GITHUB_TOKEN expired",
+		Detail: "GitHub rejected GITHUB_TOKEN (" + resp.Status + "), so release notes and publishing releases fail.",
+		Fix:    "Create a new token at https://github.com/settings/tokens and export it as GITHUB_TOKEN.",
+	}
+}
+
+// healthProblems returns the environment problems followed by the
+// supervised MCP servers that crashed or stopped answering
+func (m Model) healthProblems() []healthProblem {
+	problems := append([]healthProblem(nil), m.health...)
+	statuses := m.supervisor.Statuses()
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		status := statuses[name]
+		if status.State != ServerCrashed && status.State != ServerUnhealthy {
+			continue
+		}
+		problem := healthProblem{
+			Title:  fmt.Sprintf("MCP server %s %s", name, status.State),
+			Detail: status.Error,
+			Fix:    fmt.Sprintf("Press %s to open the MCP screen, %s restarts the server.", primaryKey(m.keys.Enter), primaryKey(m.keys.ServerRestart)),
+			Server: name,
+		}
+		if status.State == ServerCrashed && status.Restarts > 0 {
+			problem.Detail += fmt.Sprintf(" (restarted %d times)", status.Restarts)
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// renderHealthBanner renders the sticky warning shown at the top of
+// every view while there are problems
+func (m Model) renderHealthBanner(problems []healthProblem) string {
+	text := "⚠ " + problems[0].Title
+	if len(problems) > 1 {
+		text += fmt.Sprintf(" (+%d more)", len(problems)-1)
+	}
+	text += " · " + strings.Join(m.keys.Health.Keys(), "/") + ": details"
+	return warningStyle.Copy().Width(max(m.width-2, 20)).Render(truncate(text, max(m.width-4, 20)))
+}
+
+// openHealth shows the details of the current problems
+func (m *Model) openHealth() tea.Cmd {
+	m.healthView.cursor = 0
+	m.screen = screenHealth
+	return nil
+}
+
+// updateHealth handles input on the health screen; enter on an MCP
+// server problem jumps to that server
+func (m Model) updateHealth(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	problems := m.healthProblems()
+	v := &m.healthView
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Refresh):
+		if !v.refreshing {
+			v.refreshing = true
+			return m, checkHealthCmd(m.categories, false)
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(problems)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if v.cursor < len(problems) && problems[v.cursor].Tool != "" {
+			m.selectEntry(problems[v.cursor].Category, problems[v.cursor].Tool)
+			return m, nil
+		}
+		if v.cursor < len(problems) && problems[v.cursor].Server != "" {
+			cmd := m.openMCP()
+			for i, name := range m.mcp.names {
+				if name == problems[v.cursor].Server {
+					m.mcp.cursor = i
+				}
+			}
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// selectEntry shows an inventory entry in the tool list
+func (m *Model) selectEntry(categoryName, toolName string) {
+	for c, category := range m.categories {
+		if category.Name != categoryName {
+			continue
+		}
+		for t, tool := range category.Tools {
+			if tool.Name == toolName {
+				m.jumpToTool(c, t)
+				m.detailMode = false
+				m.screen = screenTools
+				return
+			}
+		}
+	}
+}
+
+// renderHealth lists every problem with its details and how to fix it
+func (m Model) renderHealth() string {
+	problems := m.healthProblems()
+	var content strings.Builder
+	title := titleStyle.Render("🩺 System Health")
+	summary := fmt.Sprintf("%d problems", len(problems))
+	if m.healthView.refreshing {
+		summary += " | " + refreshingLabel
+	}
+	status := statusStyle.Render(summary)
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	var list strings.Builder
+	if len(problems) == 0 {
+		list.WriteString(featureStyle.Render("✔ No problems detected"))
+		list.WriteString("\n")
+	}
+	width := max(m.width-6, 20)
+	for i, problem := range problems {
+		if i == m.healthView.cursor {
+			list.WriteString(selectedItemStyle.Render("▶ " + problem.Title))
+		} else {
+			list.WriteString("  " + problem.Title)
+		}
+		list.WriteString("\n")
+		if problem.Detail != "" {
+			list.WriteString(descriptionStyle.Copy().Width(width).PaddingLeft(4).Render(problem.Detail))
+			list.WriteString("\n")
+		}
+		list.WriteString(helpStyle.Copy().Width(width).PaddingLeft(4).Render(problem.Fix))
+		list.WriteString("\n\n")
+	}
+	content.WriteString(renderStale(m.healthView.refreshing, list.String()))
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("open MCP server or entry", k.Enter), hint("check again", k.Refresh), hint("back", k.Back)}, " | ")))
+	return content.String()
+}
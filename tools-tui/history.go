@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyFile is the append-only log of tool executions, one JSON
+// record per line. It never leaves this machine.
+const historyFile = "history.jsonl"
+
+// maxHistoryOutput is how much of a run's output is kept, from the end
+const maxHistoryOutput = 4000
+
+// RunRecord describes a single tool execution
+type RunRecord struct {
+	ID         string    `json:"id"`
+	Tool       string    `json:"tool"`
+	Command    string    `json:"command"`
+	Dir        string    `json:"dir"`
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"duration_ms"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	// Project is the selected sub-project and Args the values entered
+	// for the command's placeholders, so the run can be repeated
+	Project  string            `json:"project,omitempty"`
+	Args     map[string]string `json:"args,omitempty"`
+	ExitCode int               `json:"exit_code"`
+	Output   string            `json:"output,omitempty"`
+	// Env is the environment captured just before the run
+	Env EnvSnapshot `json:"env,omitempty"`
+}
+
+// Duration returns how long the run took
+func (r RunRecord) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// newRunID returns an identifier for a run started at t. The
+// nanoseconds are zero-padded so identifiers sort as their start times.
+func newRunID(t time.Time) string {
+	return fmt.Sprintf("%s-%09d", t.UTC().Format("20060102T150405"), t.Nanosecond())
+}
+
+// newRunRecord describes an execution of tool that started at started
+// and has just finished with output and err
+func newRunRecord(tool *Tool, projectDir string, started time.Time, env EnvSnapshot, output string, err error) RunRecord {
+	dir, command := scopedCommand(tool, projectDir)
+	record := RunRecord{
+		ID:         newRunID(started),
+		Tool:       tool.Key(),
+		Command:    command,
+		Dir:        dir,
+		Started:    started,
+		DurationMs: time.Since(started).Milliseconds(),
+		Success:    err == nil,
+		Project:    projectDir,
+		ExitCode:   exitCode(err),
+		Output:     truncateOutput(output, maxHistoryOutput),
+		Env:        env,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// exitCode returns the exit status behind err: 0 on success and -1
+// when the process did not exit normally
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	default:
+		return -1
+	}
+}
+
+// truncateOutput keeps the last limit bytes of output
+func truncateOutput(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	return "…" + strings.ToValidUTF8(output[len(output)-limit:], "")
+}
+
+// historyPath returns the location of the run log
+func historyPath() string {
+	return filepath.Join(ConfigDir(), historyFile)
+}
+
+// AppendHistory adds a run to the log and keeps its whole output for
+// comparisons with later runs
+func AppendHistory(record RunRecord, output string) error {
+	if err := SaveRunOutput(record, output); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(historyPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// LoadHistory reads all recorded runs, oldest first. Lines that cannot
+// be parsed are skipped.
+func LoadHistory() ([]RunRecord, error) {
+	f, err := os.Open(historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []RunRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record RunRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// filterHistory keeps the runs started within [since, until). Zero
+// bounds are open.
+func filterHistory(records []RunRecord, since, until time.Time) []RunRecord {
+	var kept []RunRecord
+	for _, record := range records {
+		if !since.IsZero() && record.Started.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !record.Started.Before(until) {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// parseTimeBound accepts a date (2006-01-02) or a look-back such as
+// "7d", "12h" or "2w" relative to now. An empty value is no bound.
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1]]; ok {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use 2006-01-02, 7d, 2w or 12h)", value)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRunIDSortsByStart(t *testing.T) {
+	base := time.Date(2026, 5, 4, 7, 30, 0, 0, time.UTC)
+	starts := []time.Time{
+		base,
+		base.Add(time.Nanosecond),
+		base.Add(35 * time.Nanosecond),
+		base.Add(36 * time.Nanosecond),
+		base.Add(time.Millisecond),
+		base.Add(999999999 * time.Nanosecond),
+		base.Add(time.Second),
+		base.Add(time.Second + 5*time.Nanosecond),
+	}
+	for i := 1; i < len(starts); i++ {
+		before, after := newRunID(starts[i-1]), newRunID(starts[i])
+		if before >= after {
+			t.Errorf("run ID %s of %s does not sort before %s of %s", before, starts[i-1].Format(time.RFC3339Nano), after, starts[i].Format(time.RFC3339Nano))
+		}
+	}
+	if id := newRunID(base.Add(36 * time.Nanosecond)); id != "20260504T073000-000000036" {
+		t.Errorf("newRunID = %s", id)
+	}
+}
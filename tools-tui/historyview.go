@@ -0,0 +1,682 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// historyRange is a look-back window selectable in the history view
+type historyRange struct {
+	label string
+	span  time.Duration
+}
+
+// historyRanges are cycled through with the range key; a zero span
+// shows every recorded run
+var historyRanges = []historyRange{
+	{"7 days", 7 * 24 * time.Hour},
+	{"30 days", 30 * 24 * time.Hour},
+	{"90 days", 90 * 24 * time.Hour},
+	{"all", 0},
+	{"24h", 24 * time.Hour},
+}
+
+// maxChartBuckets caps the number of columns in the history charts
+const maxChartBuckets = 60
+
+// historyRows is the number of runs listed at once
+const historyRows = 15
+
+// historyOutputLines is how much output of the selected run is shown
+const historyOutputLines = 6
+
+// sparkBlocks are the block characters used for charts, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// historyView holds the state of the execution history screen
+type historyView struct {
+	records    []RunRecord
+	notes      map[string]string
+	rangeIdx   int
+	toolFilter string
+	cursor     int
+	// marked is the run selected as the base of an environment comparison
+	marked     string
+	comparing  string
+	annotating bool
+	annotation textinput.Model
+	// promoting asks for the name and description of an example made
+	// from the selected run
+	promoting   bool
+	exampleName textinput.Model
+	exampleDesc textinput.Model
+	// query filters runs by tool, command, arguments and error
+	searching  bool
+	query      textinput.Model
+	message    string
+	refreshing bool
+	// diffing shows how the output of the selected run differs from the
+	// previous run of its tool
+	diffing bool
+	diff    viewport.Model
+}
+
+// dayBucket aggregates the runs of one chart column
+type dayBucket struct {
+	start    time.Time
+	runs     int
+	failures int
+}
+
+// openHistory loads the run log and shows the history screen
+func (m *Model) openHistory() {
+	v := &m.history
+	records, err := LoadHistory()
+	v.message = ""
+	if err != nil {
+		v.message = fmt.Sprintf("Could not read history: %v", err)
+	}
+	v.records = records
+	v.notes = LoadAnnotations().Runs
+	v.cursor = 0
+	v.refreshing = false
+	if v.annotation.CharLimit == 0 {
+		v.annotation = newTextInput()
+		v.annotation.Placeholder = "e.g. flaky because staging was down"
+		v.annotation.CharLimit = 500
+		v.annotation.Width = 60
+		v.query = newTextInput()
+		v.query.Placeholder = "tool, command, argument or error"
+		v.query.CharLimit = 100
+		v.query.Width = 40
+		v.exampleName = newTextInput()
+		v.exampleName.Prompt = "Example name: "
+		v.exampleName.CharLimit = 80
+		v.exampleName.Width = 40
+		v.exampleDesc = newTextInput()
+		v.exampleDesc.Prompt = "Description:  "
+		v.exampleDesc.Placeholder = "what these arguments do"
+		v.exampleDesc.CharLimit = 200
+		v.exampleDesc.Width = 60
+	}
+	m.screen = screenHistory
+}
+
+// since returns the start of the selected range
+func (v historyView) since(now time.Time) time.Time {
+	span := historyRanges[v.rangeIdx].span
+	if span == 0 {
+		return time.Time{}
+	}
+	return now.Add(-span)
+}
+
+// matches reports whether a run mentions the query
+func (r RunRecord) matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	texts := []string{r.Tool, r.Command, r.Error}
+	for name, value := range r.Args {
+		texts = append(texts, name+"="+value)
+	}
+	for _, text := range texts {
+		if strings.Contains(strings.ToLower(text), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns the runs in range matching the tool filter and the
+// query, newest first
+func (v historyView) visible() []RunRecord {
+	var runs []RunRecord
+	for _, record := range filterHistory(v.records, v.since(time.Now()), time.Time{}) {
+		if (v.toolFilter == "" || record.Tool == v.toolFilter) && record.matches(v.query.Value()) {
+			runs = append(runs, record)
+		}
+	}
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs
+}
+
+// buckets groups runs into at most maxChartBuckets columns of whole days
+func (v historyView) buckets(runs []RunRecord, now time.Time) []dayBucket {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	first := today
+	if since := v.since(now); !since.IsZero() {
+		first = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, now.Location())
+	}
+	for _, run := range runs {
+		if started := run.Started.Local(); started.Before(first) {
+			first = time.Date(started.Year(), started.Month(), started.Day(), 0, 0, 0, 0, now.Location())
+		}
+	}
+	days := int(today.Sub(first).Hours()/24) + 1
+	width := int(math.Ceil(float64(days) / maxChartBuckets))
+	buckets := make([]dayBucket, (days+width-1)/width)
+	for i := range buckets {
+		buckets[i].start = first.AddDate(0, 0, i*width)
+	}
+	for _, run := range runs {
+		i := int(run.Started.Local().Sub(first).Hours()/24) / width
+		if i >= 0 && i < len(buckets) {
+			buckets[i].runs++
+			if !run.Success {
+				buckets[i].failures++
+			}
+		}
+	}
+	return buckets
+}
+
+// sparkline renders values as a row of block characters scaled to max.
+// Zero values are drawn as a space so empty days stand out.
+func sparkline(values []float64, max float64) string {
+	var b strings.Builder
+	for _, value := range values {
+		if value <= 0 || max <= 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		idx := int(math.Ceil(value/max*float64(len(sparkBlocks)))) - 1
+		if idx >= len(sparkBlocks) {
+			idx = len(sparkBlocks) - 1
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// findRun returns the recorded run with the given ID
+func (v historyView) findRun(id string) (RunRecord, bool) {
+	for _, record := range v.records {
+		if record.ID == id {
+			return record, true
+		}
+	}
+	return RunRecord{}, false
+}
+
+// renderEnvDiff lists how the environment changed from the marked run
+// to the compared one, older run first
+func (v historyView) renderEnvDiff() string {
+	a, okA := v.findRun(v.marked)
+	b, okB := v.findRun(v.comparing)
+	if !okA || !okB {
+		return ""
+	}
+	if b.Started.Before(a.Started) {
+		a, b = b, a
+	}
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Environment: %s → %s",
+		a.Started.Local().Format("Jan 2 15:04"), b.Started.Local().Format("Jan 2 15:04"))))
+	content.WriteString("\n")
+	if a.Env == nil || b.Env == nil {
+		content.WriteString(helpStyle.Render("One of the runs was recorded without an environment snapshot"))
+		content.WriteString("\n")
+		return content.String()
+	}
+	changes := DiffEnv(a.Env, b.Env)
+	if a.Command != b.Command {
+		changes = append([]EnvChange{{Key: "command", Old: a.Command, New: b.Command}}, changes...)
+	}
+	if len(changes) == 0 {
+		content.WriteString(descriptionStyle.Render("No differences in the recorded environment"))
+		content.WriteString("\n")
+	}
+	for _, change := range changes {
+		switch {
+		case change.Old == "":
+			content.WriteString(featureStyle.Render("+ "+change.Key) + " " + change.New)
+		case change.New == "":
+			content.WriteString(helpStyle.Render("- "+change.Key) + " " + change.Old)
+		default:
+			content.WriteString(statusStyle.Render("~ "+change.Key) + " " + change.Old + " → " + change.New)
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// rerun repeats a recorded run: its tool is opened in the run's project
+// and started with the recorded arguments, which can still be edited
+func (m *Model) rerun(run RunRecord) (tea.Cmd, error) {
+	tool := findTool(m.categories, run.Tool)
+	if tool == nil {
+		return nil, fmt.Errorf("%s is no longer in the catalog", run.Tool)
+	}
+	if tool.Scoped && run.Project != m.projectDir() {
+		if len(m.projects) == 0 {
+			m.projects = DetectProjects(defaultWorkDir)
+		}
+		found := -1
+		for i, project := range m.projects {
+			if i > 0 && project.Path == run.Project {
+				found = i
+			}
+		}
+		if run.Project == "" {
+			found = 0
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("project %s no longer exists", run.Project)
+		}
+		m.currentProject = found
+		m.applyProjectRelevance()
+	}
+	m.screen = screenTools
+	m.detailMode = true
+	m.selectedTool = tool
+	m.statusMessage = ""
+	m.warning = ""
+	m.attachDetail()
+	m.presetArgs = run.Args
+	return m.executeSelectedTool(), nil
+}
+
+// renderRunDetails shows what a run executed and the end of its output
+func renderRunDetails(run RunRecord) string {
+	var content strings.Builder
+	content.WriteString(commandStyle.Render("$ " + run.Command))
+	content.WriteString(" " + helpStyle.Render("in "+run.Dir))
+	content.WriteString("\n")
+	details := []string{fmt.Sprintf("exit %d", run.ExitCode)}
+	if len(run.Args) > 0 {
+		var args []string
+		for name, value := range run.Args {
+			args = append(args, name+"="+value)
+		}
+		sort.Strings(args)
+		details = append(details, "args: "+strings.Join(args, " "))
+	}
+	content.WriteString(helpStyle.Render(strings.Join(details, " · ")))
+	content.WriteString("\n")
+	if output := strings.TrimRight(run.Output, "\n"); output != "" {
+		lines := strings.Split(output, "\n")
+		if len(lines) > historyOutputLines {
+			lines = lines[len(lines)-historyOutputLines:]
+		}
+		content.WriteString(descriptionStyle.Render(strings.Join(lines, "\n")))
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// updateHistory handles input on the history screen
+func (m Model) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.history
+	if msg, ok := msg.(historyMsg); ok {
+		v.refreshing = false
+		if msg.err != nil {
+			v.message = fmt.Sprintf("Could not read history: %v", msg.err)
+			return m, nil
+		}
+		v.records, v.notes = msg.records, msg.notes
+		if runs := v.visible(); v.cursor >= len(runs) {
+			v.cursor = max(len(runs)-1, 0)
+		}
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	runs := v.visible()
+
+	if v.diffing {
+		switch {
+		case key.Matches(keyMsg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(keyMsg, m.keys.Back), key.Matches(keyMsg, m.keys.DiffOutput):
+			v.diffing = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		v.diff, cmd = v.diff.Update(keyMsg)
+		return m, cmd
+	}
+
+	if v.annotating {
+		switch keyMsg.Type {
+		case tea.KeyEnter:
+			v.annotating = false
+			v.annotation.Blur()
+			id := runs[v.cursor].ID
+			text := strings.TrimSpace(v.annotation.Value())
+			if err := SetRunAnnotation(id, text); err != nil {
+				v.message = fmt.Sprintf("Could not save annotation: %v", err)
+			} else {
+				v.notes = LoadAnnotations().Runs
+				v.message = "Run annotation saved"
+			}
+		case tea.KeyEsc:
+			v.annotating = false
+			v.annotation.Blur()
+		default:
+			var cmd tea.Cmd
+			v.annotation, cmd = v.annotation.Update(keyMsg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	if v.promoting {
+		return m.updatePromote(keyMsg)
+	}
+
+	if v.searching {
+		switch keyMsg.Type {
+		case tea.KeyEnter, tea.KeyEsc:
+			v.searching = false
+			v.query.Blur()
+			if keyMsg.Type == tea.KeyEsc {
+				v.query.SetValue("")
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		v.query, cmd = v.query.Update(keyMsg)
+		v.cursor = 0
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Search):
+		v.searching = true
+		return m, v.query.Focus()
+	case key.Matches(keyMsg, m.keys.Execute):
+		if v.cursor < len(runs) {
+			cmd, err := m.rerun(runs[v.cursor])
+			if err != nil {
+				v.message = err.Error()
+			}
+			return m, cmd
+		}
+	case key.Matches(keyMsg, m.keys.Back):
+		if v.query.Value() != "" {
+			v.query.SetValue("")
+			v.cursor = 0
+			return m, nil
+		}
+		if v.comparing != "" || v.marked != "" {
+			v.comparing, v.marked = "", ""
+			return m, nil
+		}
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Compare):
+		if v.cursor >= len(runs) {
+			break
+		}
+		id := runs[v.cursor].ID
+		switch {
+		case v.marked == "" || v.marked == id:
+			v.marked, v.comparing = id, ""
+			v.message = fmt.Sprintf("Marked run; press '%s' on another run to compare environments", primaryKey(m.keys.Compare))
+		default:
+			v.comparing = id
+			v.message = ""
+		}
+	case key.Matches(keyMsg, m.keys.DiffOutput):
+		if v.cursor >= len(runs) {
+			break
+		}
+		diff, prev, err := CompareWithPrevious(v.records, runs[v.cursor])
+		switch {
+		case err != nil:
+			v.message = err.Error()
+		case diff == "":
+			v.message = "Same output as the run of " + prev.Started.Local().Format("2006-01-02 15:04:05")
+		default:
+			v.message, v.diffing = "", true
+			v.diff = viewport.New(max(m.width-4, 20), max(m.height-6, 5))
+			v.diff.SetContent(colorDiff(diff))
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(runs)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		if !v.refreshing {
+			v.refreshing = true
+			return m, loadHistoryCmd()
+		}
+	case key.Matches(keyMsg, m.keys.Range):
+		v.rangeIdx = (v.rangeIdx + 1) % len(historyRanges)
+		v.cursor = 0
+	case key.Matches(keyMsg, m.keys.Filter):
+		if v.toolFilter != "" {
+			v.toolFilter = ""
+		} else if v.cursor < len(runs) {
+			v.toolFilter = runs[v.cursor].Tool
+		}
+		v.cursor = 0
+	case key.Matches(keyMsg, m.keys.Promote):
+		if v.cursor >= len(runs) {
+			break
+		}
+		run := runs[v.cursor]
+		if tool := findTool(m.categories, run.Tool); tool == nil {
+			v.message = fmt.Sprintf("%s is no longer in the catalog", run.Tool)
+		} else if len(tool.placeholders()) == 0 {
+			v.message = fmt.Sprintf("%s takes no arguments, so it has no examples", tool.Name)
+		} else {
+			v.promoting = true
+			v.exampleName.SetValue("")
+			v.exampleDesc.SetValue(v.notes[run.ID])
+			v.exampleDesc.Blur()
+			return m, v.exampleName.Focus()
+		}
+	case key.Matches(keyMsg, m.keys.Annotate):
+		if v.cursor < len(runs) {
+			v.annotating = true
+			v.annotation.SetValue(v.notes[runs[v.cursor].ID])
+			v.annotation.CursorEnd()
+			return m, v.annotation.Focus()
+		}
+	}
+	return m, nil
+}
+
+// updatePromote handles keys while a run is saved as an example: tab
+// moves between name and description, enter saves
+func (m Model) updatePromote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.history
+	switch msg.Type {
+	case tea.KeyEsc:
+		v.promoting = false
+		return m, nil
+	case tea.KeyTab, tea.KeyShiftTab:
+		if v.exampleName.Focused() {
+			v.exampleName.Blur()
+			return m, v.exampleDesc.Focus()
+		}
+		v.exampleDesc.Blur()
+		return m, v.exampleName.Focus()
+	case tea.KeyEnter:
+		name := strings.TrimSpace(v.exampleName.Value())
+		if name == "" {
+			v.message = "The example needs a name"
+			return m, nil
+		}
+		v.promoting = false
+		run := v.visible()[v.cursor]
+		tool := findTool(m.categories, run.Tool)
+		if tool == nil {
+			return m, nil
+		}
+		example := Example{Name: name, Description: strings.TrimSpace(v.exampleDesc.Value()), Args: run.Args}
+		if err := SaveExample(tool.Key(), example); err != nil {
+			v.message = fmt.Sprintf("Could not save example: %v", err)
+			return m, nil
+		}
+		tool.SavedExamples = LoadSavedExamples()[tool.Key()]
+		v.message = fmt.Sprintf("Saved example %q of %s", name, tool.Name)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	if v.exampleName.Focused() {
+		v.exampleName, cmd = v.exampleName.Update(msg)
+	} else {
+		v.exampleDesc, cmd = v.exampleDesc.Update(msg)
+	}
+	return m, cmd
+}
+
+// renderOutputDiff renders the output of the selected run compared
+// with the previous run of its tool
+func (m Model) renderOutputDiff() string {
+	v := m.history
+	k := m.keys
+	var content strings.Builder
+	title := titleStyle.Render("📜 Output Diff")
+	status := statusStyle.Render(fmt.Sprintf("%.0f%%", v.diff.ScrollPercent()*100))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+	content.WriteString(v.diff.View())
+	content.WriteString("\n")
+	content.WriteString(footerStyle.Render(strings.Join([]string{"↑/↓/pgup/pgdn: scroll", hint("close", k.Back, k.DiffOutput), hint("quit", k.Quit)}, " | ")))
+	return content.String()
+}
+
+// renderHistory renders run charts and the list of runs
+func (m Model) renderHistory() string {
+	v := m.history
+	if v.diffing {
+		return m.renderOutputDiff()
+	}
+	now := time.Now()
+	runs := v.visible()
+	failStyle := lipgloss.NewStyle().Foreground(warningStyle.GetBackground())
+	var content strings.Builder
+
+	failures := 0
+	for _, run := range runs {
+		if !run.Success {
+			failures++
+		}
+	}
+	scope := "all tools"
+	if v.toolFilter != "" {
+		scope = v.toolFilter
+	}
+	if query := v.query.Value(); query != "" {
+		scope += fmt.Sprintf(" matching %q", query)
+	}
+	summary := fmt.Sprintf("%s | %s | %d runs, %d failed", historyRanges[v.rangeIdx].label, scope, len(runs), failures)
+	if v.refreshing {
+		summary += " | " + refreshingLabel
+	}
+	title := titleStyle.Render("📜 Execution History")
+	status := statusStyle.Render(summary)
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	buckets := v.buckets(runs, now)
+	counts := make([]float64, len(buckets))
+	rates := make([]float64, len(buckets))
+	peak := 0.0
+	for i, bucket := range buckets {
+		counts[i] = float64(bucket.runs)
+		if counts[i] > peak {
+			peak = counts[i]
+		}
+		if bucket.runs > 0 {
+			// keep all-green days visible as the lowest block
+			rates[i] = math.Max(float64(bucket.failures)/float64(bucket.runs), 0.01)
+		}
+	}
+	if len(buckets) > 0 {
+		axis := fmt.Sprintf("%s … %s", buckets[0].start.Format("Jan 2"), buckets[len(buckets)-1].start.Format("Jan 2"))
+		content.WriteString(fmt.Sprintf("%-14s %s  %s\n", "Runs/day", featureStyle.Render(sparkline(counts, peak)), helpStyle.Render(fmt.Sprintf("max %.0f", peak))))
+		content.WriteString(fmt.Sprintf("%-14s %s  %s\n", "Failure rate", failStyle.Render(sparkline(rates, 1)), helpStyle.Render("0–100%")))
+		content.WriteString(fmt.Sprintf("%-14s %s\n\n", "", helpStyle.Render(axis)))
+	}
+
+	if len(runs) == 0 {
+		content.WriteString(descriptionStyle.Render("No runs recorded in this range."))
+		content.WriteString("\n")
+	}
+	start := 0
+	if v.cursor >= historyRows {
+		start = v.cursor - historyRows + 1
+	}
+	var list strings.Builder
+	for i := start; i < len(runs) && i < start+historyRows; i++ {
+		run := runs[i]
+		mark := "✔"
+		if !run.Success {
+			mark = "✘"
+		}
+		if run.ID == v.marked || run.ID == v.comparing {
+			mark = "="
+		}
+		line := fmt.Sprintf("%s %s  %-28s %8s", mark, run.Started.Local().Format("2006-01-02 15:04"), run.Tool, run.Duration().Round(time.Millisecond))
+		if i == v.cursor {
+			list.WriteString(selectedItemStyle.Render("▶ " + line))
+		} else if !run.Success {
+			list.WriteString("  " + failStyle.Render(line))
+		} else {
+			list.WriteString("  " + line)
+		}
+		if note := v.notes[run.ID]; note != "" {
+			list.WriteString(" " + helpStyle.Render("📌 "+note))
+		}
+		list.WriteString("\n")
+	}
+	content.WriteString(renderStale(v.refreshing, list.String()))
+
+	content.WriteString("\n")
+	if v.comparing != "" {
+		content.WriteString(v.renderEnvDiff())
+		content.WriteString("\n")
+	}
+	if v.searching {
+		content.WriteString(commandStyle.Render("🔍 " + v.query.View()))
+		content.WriteString("\n")
+	} else if v.annotating {
+		content.WriteString(commandStyle.Render("📌 " + v.annotation.View()))
+		content.WriteString("\n")
+	} else if v.promoting {
+		content.WriteString(commandStyle.Render("📖 " + v.exampleName.View()))
+		content.WriteString("\n")
+		content.WriteString(commandStyle.Render("   " + v.exampleDesc.View()))
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render("enter: save | tab: name/description | esc: cancel"))
+		content.WriteString("\n")
+	} else if v.cursor < len(runs) && v.comparing == "" {
+		content.WriteString(renderRunDetails(runs[v.cursor]))
+		if runs[v.cursor].Error != "" {
+			content.WriteString(warningStyle.Render(runs[v.cursor].Error))
+			content.WriteString("\n")
+		}
+	}
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("re-run", k.Execute), hint("search", k.Search), hint("range", k.Range), hint("refresh", k.Refresh), hint("filter tool", k.Filter), hint("annotate", k.Annotate), hint("save as example", k.Promote), hint("compare env", k.Compare), hint("diff output", k.DiffOutput), hint("back", k.Back), hint("quit", k.Quit)}, " | ")))
+	return content.String()
+}
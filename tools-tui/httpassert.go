@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpAssertion is a check of the response of a saved request. Each
+// sets one of: the status, a value at a JSONPath of the JSON body, or
+// the longest the request may take.
+type httpAssertion struct {
+	Status int    `json:"status,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Equals is the JSON value at Path; without it the path only has
+	// to be present
+	Equals json.RawMessage `json:"equals,omitempty"`
+	MaxMs  int64           `json:"max_ms,omitempty"`
+}
+
+// assertionResult is the outcome of an assertion for one exchange
+type assertionResult struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// String writes the assertion as the editor's assert field reads it
+func (a httpAssertion) String() string {
+	switch {
+	case a.Status != 0:
+		return fmt.Sprintf("status %d", a.Status)
+	case a.MaxMs != 0:
+		return fmt.Sprintf("time < %dms", a.MaxMs)
+	case len(a.Equals) > 0:
+		return a.Path + " == " + string(a.Equals)
+	}
+	return a.Path
+}
+
+// parseAssertions reads the assert field of the request editor:
+// assertions separated by ";", each "status 200", "time < 500ms", a
+// JSONPath such as "$.items[0].id" that must be present, or a JSONPath
+// followed by "==" and the JSON value it must have
+func parseAssertions(text string) ([]httpAssertion, error) {
+	var assertions []httpAssertion
+	for _, part := range splitAssertions(text) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		a, err := parseAssertion(part)
+		if err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// splitAssertions splits text at the semicolons outside of JSON strings
+func splitAssertions(text string) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// parseAssertion reads one assertion of the assert field
+func parseAssertion(text string) (httpAssertion, error) {
+	var a httpAssertion
+	fields := strings.Fields(text)
+	switch {
+	case fields[0] == "status":
+		status, err := strconv.Atoi(strings.Join(fields[1:], ""))
+		if err != nil || status < 100 || status > 599 {
+			return a, fmt.Errorf("assertion %q: the status is a number such as 200", text)
+		}
+		a.Status = status
+	case fields[0] == "time":
+		limit := strings.TrimPrefix(strings.Join(fields[1:], ""), "<")
+		d, err := time.ParseDuration(limit)
+		if err != nil || d < time.Millisecond {
+			return a, fmt.Errorf("assertion %q: the time is a duration such as < 500ms", text)
+		}
+		a.MaxMs = d.Milliseconds()
+	case strings.HasPrefix(text, "$"):
+		path, value, equals := strings.Cut(text, "==")
+		a.Path = strings.TrimSpace(path)
+		if _, err := parseJSONPath(a.Path); err != nil {
+			return a, fmt.Errorf("assertion %q: %v", text, err)
+		}
+		if equals {
+			value = strings.TrimSpace(value)
+			if !json.Valid([]byte(value)) {
+				return a, fmt.Errorf("assertion %q: %s is not a JSON value, quote strings", text, value)
+			}
+			a.Equals = json.RawMessage(value)
+		}
+	default:
+		return a, fmt.Errorf("assertion %q: use status 200, time < 500ms, $.path or $.path == value", text)
+	}
+	return a, nil
+}
+
+// formatAssertions writes assertions as parseAssertions reads them
+func formatAssertions(assertions []httpAssertion) string {
+	parts := make([]string, len(assertions))
+	for i, a := range assertions {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Check evaluates the assertion against an exchange
+func (a httpAssertion) Check(e httpExchange) assertionResult {
+	result := assertionResult{Assertion: a.String()}
+	if e.Response == nil {
+		result.Detail = "no response: " + e.Error
+		return result
+	}
+	if a.MaxMs != 0 {
+		result.Passed = e.DurationMs <= a.MaxMs
+		result.Detail = fmt.Sprintf("took %dms", e.DurationMs)
+		return result
+	}
+	if a.Status != 0 {
+		result.Passed = e.Response.Status == a.Status
+		result.Detail = fmt.Sprintf("got %d", e.Response.Status)
+		return result
+	}
+	var body interface{}
+	if err := json.Unmarshal([]byte(e.Response.Body), &body); err != nil {
+		result.Detail = "the body is not JSON"
+		return result
+	}
+	steps, _ := parseJSONPath(a.Path)
+	value, ok := evalJSONPath(body, steps)
+	if !ok {
+		result.Detail = "not present"
+		return result
+	}
+	actual, _ := json.Marshal(value)
+	result.Detail = "got " + truncate(string(actual), 80)
+	if len(a.Equals) == 0 {
+		result.Passed = true
+		return result
+	}
+	var expected interface{}
+	if err := json.Unmarshal(a.Equals, &expected); err != nil {
+		result.Detail = "invalid expected value: " + err.Error()
+		return result
+	}
+	result.Passed = reflect.DeepEqual(value, expected)
+	return result
+}
+
+// checkAssertions evaluates every assertion of a request
+func checkAssertions(assertions []httpAssertion, e httpExchange) []assertionResult {
+	var results []assertionResult
+	for _, a := range assertions {
+		results = append(results, a.Check(e))
+	}
+	return results
+}
+
+// failedAssertions counts the assertions an exchange failed
+func (e httpExchange) failedAssertions() int {
+	failed := 0
+	for _, r := range e.Assertions {
+		if !r.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// jsonPathStep is a key of an object, or an index of an array when key
+// is empty; negative indexes count from the end
+type jsonPathStep struct {
+	key   string
+	index int
+}
+
+// parseJSONPath reads the subset of JSONPath assertions use: $ followed
+// by .key, ['key'] or ["key"] and [index] steps
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("a JSONPath starts with $")
+	}
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in %s", path)
+			}
+			steps = append(steps, jsonPathStep{key: key})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %s", path)
+			}
+			inside := rest[1:end]
+			if len(inside) >= 2 && (inside[0] == '\'' || inside[0] == '"') && inside[len(inside)-1] == inside[0] {
+				steps = append(steps, jsonPathStep{key: inside[1 : len(inside)-1]})
+			} else if index, err := strconv.Atoi(inside); err == nil {
+				steps = append(steps, jsonPathStep{index: index})
+			} else {
+				return nil, fmt.Errorf("[%s] in %s is neither an index nor a quoted key", inside, path)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %s", rest[0], path)
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath follows steps from a decoded JSON value
+func evalJSONPath(value interface{}, steps []jsonPathStep) (interface{}, bool) {
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[step.key]
+			if step.key == "" || !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			index := step.index
+			if index < 0 {
+				index += len(v)
+			}
+			if step.key != "" || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// runRequests implements `tools-tui requests test`: the saved requests
+// with assertions of a project, sent in order, for CI
+func runRequests(args []string) error {
+	const usage = "usage: tools-tui requests test [--project dir] [--env name] [--collection name] [--json]"
+	if len(args) == 0 || args[0] != "test" {
+		return fmt.Errorf("%s", usage)
+	}
+	fs := flag.NewFlagSet("requests test", flag.ExitOnError)
+	project := fs.String("project", ".", "project whose .opencode/requests.json is run")
+	env := fs.String("env", "", "environment the variables are resolved in, the one last picked in the TUI by default")
+	collection := fs.String("collection", "", "only run the collections containing this text")
+	asJSON := fs.Bool("json", false, "print the exchanges with their assertion results as JSON")
+	fs.Parse(args[1:])
+
+	root, err := filepath.Abs(*project)
+	if err != nil {
+		return err
+	}
+	workspace, _, err := LoadHTTPWorkspace(root)
+	if err != nil {
+		return err
+	}
+	if *env == "" {
+		*env = LoadHTTPEnvironment(root)
+	}
+	if *env != "" && workspace.Environments[*env] == nil {
+		return fmt.Errorf("no environment %q in %s", *env, httpWorkspacePath(root))
+	}
+
+	exchanges := []httpExchange{}
+	ran, failed := 0, 0
+	for _, c := range workspace.Collections {
+		if *collection != "" && !strings.Contains(strings.ToLower(c.Name), strings.ToLower(*collection)) {
+			continue
+		}
+		for _, r := range c.Requests {
+			if len(r.Assert) == 0 {
+				continue
+			}
+			ran++
+			resolved, err := workspace.Resolve(workspace.Inherit(c.Name, r), *env)
+			if err == nil {
+				resolved, err = resolved.wire(root)
+			}
+			var exchange httpExchange
+			if err != nil {
+				exchange = httpExchange{Request: r, Error: err.Error(), Started: time.Now()}
+				exchange.Assertions = checkAssertions(r.Assert, exchange)
+			} else {
+				exchange = sendHTTP(resolved)
+			}
+			exchange.Environment = *env
+			if err := AppendHTTPHistory(exchange); err != nil {
+				fmt.Fprintf(os.Stderr, "could not log the request: %v\n", err)
+			}
+			exchanges = append(exchanges, exchange)
+			if exchange.failedAssertions() > 0 {
+				failed++
+			}
+			if *asJSON {
+				continue
+			}
+			mark := "✓"
+			if exchange.failedAssertions() > 0 {
+				mark = "✗"
+			}
+			fmt.Printf("%s %s / %s · %s · %s\n", mark, c.Name, r.Label(), exchange.Outcome(), exchange.Duration().Round(time.Millisecond))
+			for _, result := range exchange.Assertions {
+				if !result.Passed {
+					fmt.Printf("    ✗ %s: %s\n", result.Assertion, result.Detail)
+				}
+			}
+		}
+	}
+	if *asJSON {
+		if err := printJSON(exchanges); err != nil {
+			return err
+		}
+	}
+	switch {
+	case ran == 0:
+		return fmt.Errorf("no saved request in %s has assertions", httpWorkspacePath(root))
+	case failed > 0:
+		return fmt.Errorf("%d of %d requests failed their assertions", failed, ran)
+	}
+	if !*asJSON {
+		fmt.Printf("%d requests passed their assertions\n", ran)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpCookiesFile keeps the cookies servers set, so sessions survive a
+// restart
+const httpCookiesFile = "http_cookies.json"
+
+// Authentication schemes of a request
+const (
+	authNone   = "none"
+	authBasic  = "basic"
+	authBearer = "bearer"
+	authOAuth2 = "oauth2"
+)
+
+// httpAuth is how a request authenticates. The secret is not written to
+// the request file: Secret names the service of the secrets store that
+// holds the password, the token or the OAuth2 client secret. A request
+// without one uses the auth of its collection; "none" sends neither.
+type httpAuth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Secret   string `json:"secret,omitempty"`
+	// TokenURL, ClientID and Scopes are those of an OAuth2 client
+	// credentials grant
+	TokenURL string   `json:"token_url,omitempty"`
+	ClientID string   `json:"client_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// parseAuth reads the auth field of the request editor: empty to
+// inherit the collection's, "none", "basic USER SECRET", "bearer
+// SECRET" or "oauth2 SECRET CLIENT_ID TOKEN_URL [SCOPE...]"
+func parseAuth(text string) (*httpAuth, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	a := &httpAuth{Type: strings.ToLower(fields[0])}
+	args := fields[1:]
+	switch {
+	case a.Type == authNone && len(args) == 0:
+	case a.Type == authBasic && len(args) == 2:
+		a.Username, a.Secret = args[0], args[1]
+	case a.Type == authBearer && len(args) == 1:
+		a.Secret = args[0]
+	case a.Type == authOAuth2 && len(args) >= 3:
+		a.Secret, a.ClientID, a.TokenURL, a.Scopes = args[0], args[1], args[2], args[3:]
+	default:
+		return nil, fmt.Errorf("auth is none, basic USER SECRET, bearer SECRET or oauth2 SECRET CLIENT_ID TOKEN_URL [SCOPE...]")
+	}
+	return a, nil
+}
+
+// String writes the auth as the editor's auth field reads it
+func (a *httpAuth) String() string {
+	if a == nil {
+		return ""
+	}
+	switch a.Type {
+	case authBasic:
+		return strings.Join([]string{a.Type, a.Username, a.Secret}, " ")
+	case authBearer:
+		return a.Type + " " + a.Secret
+	case authOAuth2:
+		return strings.Join(append([]string{a.Type, a.Secret, a.ClientID, a.TokenURL}, a.Scopes...), " ")
+	}
+	return a.Type
+}
+
+// Describe says how a request authenticates, for the request pane
+func (a *httpAuth) Describe() string {
+	switch {
+	case a == nil || a.Type == authNone:
+		return "no auth"
+	case a.Type == authBasic:
+		return fmt.Sprintf("basic auth as %s, password from the secret %s", a.Username, a.Secret)
+	case a.Type == authBearer:
+		return "bearer token from the secret " + a.Secret
+	case a.Type == authOAuth2:
+		return fmt.Sprintf("OAuth2 client %s at %s, client secret from the secret %s", a.ClientID, a.TokenURL, a.Secret)
+	}
+	return "unknown auth " + a.Type
+}
+
+// Inherit gives a request without auth of its own the auth of the
+// collection called name
+func (w httpWorkspace) Inherit(name string, r httpRequest) httpRequest {
+	if r.Auth != nil {
+		return r
+	}
+	for _, c := range w.Collections {
+		if c.Name == name {
+			r.Auth = c.Auth
+		}
+	}
+	return r
+}
+
+// oauthToken is an access token of a client credentials grant
+type oauthToken struct {
+	value   string
+	expires time.Time
+}
+
+// oauthTokens caches the access tokens by client until shortly before
+// they expire
+var (
+	oauthTokensMu sync.Mutex
+	oauthTokens   = map[string]oauthToken{}
+)
+
+// authorization returns the Authorization header of a, reading its
+// secret from the store and, for OAuth2, fetching an access token
+func (a *httpAuth) authorization() (string, error) {
+	if a == nil || a.Type == authNone {
+		return "", nil
+	}
+	if a.Secret == "" {
+		return "", fmt.Errorf("%s auth needs the name of a secret", a.Type)
+	}
+	secret, err := GetSecret(a.Secret)
+	if err != nil {
+		return "", fmt.Errorf("%s auth: %w", a.Type, err)
+	}
+	switch a.Type {
+	case authBasic:
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(a.Username, secret)
+		return req.Header.Get("Authorization"), nil
+	case authBearer:
+		return "Bearer " + secret, nil
+	case authOAuth2:
+		token, err := a.clientCredentials(secret)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	return "", fmt.Errorf("unknown auth %q", a.Type)
+}
+
+// clientCredentials returns an access token of the OAuth2 client,
+// asking the token endpoint for one when none is cached
+func (a *httpAuth) clientCredentials(secret string) (string, error) {
+	key := strings.Join(append([]string{a.TokenURL, a.ClientID}, a.Scopes...), " ")
+	oauthTokensMu.Lock()
+	defer oauthTokensMu.Unlock()
+	if t, ok := oauthTokens[key]; ok && time.Now().Before(t.expires) {
+		return t.value, nil
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(secret))
+	resp, err := (&http.Client{Timeout: httpTimeout}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("OAuth2 token: %s answered %s: %w", a.TokenURL, resp.Status, err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("OAuth2 token: %s answered %s: %s %s", a.TokenURL, resp.Status, result.Error, result.Description)
+	}
+	t := oauthToken{value: result.AccessToken, expires: time.Now().Add(time.Hour)}
+	if result.ExpiresIn > 0 {
+		// renew a little early so a request does not race the expiry
+		t.expires = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - 30*time.Second)
+	}
+	oauthTokens[key] = t
+	return t.value, nil
+}
+
+// savedCookie is a cookie with the URL that set it, which the jar needs
+// to scope it again when it is loaded
+type savedCookie struct {
+	URL    string      `json:"url"`
+	Cookie http.Cookie `json:"cookie"`
+}
+
+// cookieJar is a cookie jar that keeps its cookies in the config
+// directory. Session cookies are kept too: the point is to stay logged
+// in between runs of the request builder.
+type cookieJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	cookies map[string]savedCookie
+}
+
+var (
+	httpCookiesOnce sync.Once
+	httpCookies     *cookieJar
+)
+
+// sharedCookieJar returns the jar of the request builder, loading the
+// saved cookies the first time
+func sharedCookieJar() *cookieJar {
+	httpCookiesOnce.Do(func() {
+		httpCookies = loadCookieJar()
+	})
+	return httpCookies
+}
+
+// loadCookieJar reads the saved cookies, leaving out expired ones
+func loadCookieJar() *cookieJar {
+	jar, _ := cookiejar.New(nil)
+	j := &cookieJar{jar: jar, cookies: map[string]savedCookie{}}
+	var saved []savedCookie
+	loadJSON(httpCookiesFile, &saved)
+	now := time.Now()
+	for _, c := range saved {
+		u, err := url.Parse(c.URL)
+		if err != nil || (!c.Cookie.Expires.IsZero() && c.Cookie.Expires.Before(now)) {
+			continue
+		}
+		cookie := c.Cookie
+		j.jar.SetCookies(u, []*http.Cookie{&cookie})
+		j.cookies[cookieKey(u, &cookie)] = c
+	}
+	return j
+}
+
+// cookieKey identifies a cookie the way the jar replaces one: by the
+// domain it applies to, its path and its name
+func cookieKey(u *url.URL, c *http.Cookie) string {
+	domain := c.Domain
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	return strings.TrimPrefix(strings.ToLower(domain), ".") + "|" + c.Path + "|" + c.Name
+}
+
+// Cookies returns the cookies to send to u
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// SetCookies stores the cookies of a response and saves the jar
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+	now := time.Now()
+	for _, c := range cookies {
+		cookie := *c
+		key := cookieKey(u, &cookie)
+		// Max-Age is relative to now, so it is saved as an expiry date
+		switch {
+		case cookie.MaxAge < 0, !cookie.Expires.IsZero() && cookie.Expires.Before(now):
+			delete(j.cookies, key)
+			continue
+		case cookie.MaxAge > 0:
+			cookie.Expires, cookie.MaxAge = now.Add(time.Duration(cookie.MaxAge)*time.Second), 0
+		}
+		cookie.Raw = ""
+		j.cookies[key] = savedCookie{URL: (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(), Cookie: cookie}
+	}
+	j.save()
+}
+
+// save writes the cookies to the config directory; a jar that cannot
+// be saved still works for this run
+func (j *cookieJar) save() {
+	saved := make([]savedCookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		saved = append(saved, c)
+	}
+	savePrivateJSON(httpCookiesFile, saved)
+}
+
+// Len returns how many cookies the jar holds
+func (j *cookieJar) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.cookies)
+}
+
+// Clear forgets every cookie and returns how many there were
+func (j *cookieJar) Clear() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := len(j.cookies)
+	j.jar, _ = cookiejar.New(nil)
+	j.cookies = map[string]savedCookie{}
+	j.save()
+	return n
+}
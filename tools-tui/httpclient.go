@@ -0,0 +1,554 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// httpRequestsFile held the saved requests before they moved into the
+// collections of each project
+const httpRequestsFile = "http_requests.json"
+
+// httpHistoryFile is the append-only log of sent requests, one JSON
+// exchange per line
+const httpHistoryFile = "http_history.jsonl"
+
+// maxHTTPHistory is how many of the latest exchanges are loaded
+const maxHTTPHistory = 200
+
+// maxHTTPBody is how much of a response body is kept, from the start
+const maxHTTPBody = 256 << 10
+
+// httpTimeout bounds a request of the request builder
+const httpTimeout = 30 * time.Second
+
+// requestMethods are the methods the request builder cycles through
+var requestMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// httpHeader is a header of a request or response, in order
+type httpHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// httpRequest is a request of the request builder
+type httpRequest struct {
+	Name    string       `json:"name,omitempty"`
+	Method  string       `json:"method"`
+	URL     string       `json:"url"`
+	Headers []httpHeader `json:"headers,omitempty"`
+	Body    string       `json:"body,omitempty"`
+	// GraphQL makes the request a query of a GraphQL API, whose body is
+	// built from it when the request is sent
+	GraphQL *graphQLQuery `json:"graphql,omitempty"`
+	// Auth is how the request authenticates, its collection's when nil
+	Auth *httpAuth `json:"auth,omitempty"`
+	// Form is sent as multipart/form-data instead of the body, and
+	// BodyFile is a file whose content is the body, for uploads
+	Form     []httpFormField `json:"form,omitempty"`
+	BodyFile string          `json:"body_file,omitempty"`
+	// Assert is checked against every response, making a collection a
+	// test suite for `tools-tui requests test`
+	Assert []httpAssertion `json:"assert,omitempty"`
+}
+
+// httpFormField is a part of a multipart form: a value, or the content
+// of a file when File is set
+type httpFormField struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	File  string `json:"file,omitempty"`
+}
+
+// httpResponse is what a server answered
+type httpResponse struct {
+	Status   int          `json:"status"`
+	Text     string       `json:"status_text"`
+	Protocol string       `json:"protocol"`
+	Headers  []httpHeader `json:"headers,omitempty"`
+	Body     string       `json:"body,omitempty"`
+	// Encoding is "base64" when the body is not text
+	Encoding string `json:"encoding,omitempty"`
+	// Size is the length of the whole body, which may be truncated
+	Size int64 `json:"size"`
+}
+
+// httpExchange is a sent request with its response or the error that
+// prevented one
+type httpExchange struct {
+	Request    httpRequest   `json:"request"`
+	Response   *httpResponse `json:"response,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Started    time.Time     `json:"started"`
+	DurationMs int64         `json:"duration_ms"`
+	// Environment is the one the request's variables were resolved in
+	Environment string `json:"environment,omitempty"`
+	// Assertions are the results of the request's assertions
+	Assertions []assertionResult `json:"assertions,omitempty"`
+}
+
+// Label describes a request as its method and URL, or its name
+func (r httpRequest) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Method + " " + r.URL
+}
+
+// Header returns the value of the first header called name
+func (r httpRequest) Header(name string) string {
+	return headerValue(r.Headers, name)
+}
+
+// headerValue returns the value of the first header called name
+func headerValue(headers []httpHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// parseHeaders reads "Name: value" lines, skipping blank ones
+func parseHeaders(text string) ([]httpHeader, error) {
+	var headers []httpHeader
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("header line %d is not Name: value", i+1)
+		}
+		headers = append(headers, httpHeader{strings.TrimSpace(name), strings.TrimSpace(value)})
+	}
+	return headers, nil
+}
+
+// formatHeaders writes headers as "Name: value" lines
+func formatHeaders(headers []httpHeader) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		lines[i] = h.Name + ": " + h.Value
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseForm reads the fields of a multipart form, one per line as
+// name=value or name=@path to send the content of a file
+func parseForm(text string) ([]httpFormField, error) {
+	var form []httpFormField
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("form line %d is not name=value or name=@file", i+1)
+		}
+		field := httpFormField{Name: strings.TrimSpace(name), Value: value}
+		if path, ok := strings.CutPrefix(strings.TrimSpace(value), "@"); ok {
+			field.Value, field.File = "", path
+		}
+		form = append(form, field)
+	}
+	return form, nil
+}
+
+// formatForm writes the fields of a form as parseForm reads them
+func formatForm(form []httpFormField) string {
+	lines := make([]string, len(form))
+	for i, f := range form {
+		lines[i] = f.Name + "=" + f.Value
+		if f.File != "" {
+			lines[i] = f.Name + "=@" + f.File
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wire returns the request as it is sent: a GraphQL query becomes a
+// JSON POST, and the files it uploads are found relative to root
+func (r httpRequest) wire(root string) (httpRequest, error) {
+	if r.BodyFile != "" {
+		r.BodyFile = resolvePath(root, r.BodyFile)
+	}
+	if len(r.Form) > 0 {
+		form := make([]httpFormField, len(r.Form))
+		for i, f := range r.Form {
+			if f.File != "" {
+				f.File = resolvePath(root, f.File)
+			}
+			form[i] = f
+		}
+		r.Form = form
+	}
+	if r.GraphQL == nil {
+		return r, nil
+	}
+	body, err := graphQLPayload(*r.GraphQL)
+	if err != nil {
+		return r, err
+	}
+	r.Method, r.Body = http.MethodPost, body
+	if r.Header("Content-Type") == "" {
+		r.Headers = append(append([]httpHeader{}, r.Headers...), httpHeader{"Content-Type", "application/json"})
+	}
+	return r, nil
+}
+
+// newHTTPRequest builds the request to send for r. Requests without an
+// Authorization header get the one of their auth, and requests to
+// Linear's API the key of the Linear panel; both are left out of r so
+// they are not logged.
+func newHTTPRequest(r httpRequest) (*http.Request, error) {
+	body, contentType, size, err := r.payload()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(r.Method, r.URL, body)
+	if err != nil {
+		if c, ok := body.(io.Closer); ok {
+			c.Close()
+		}
+		return nil, err
+	}
+	req.ContentLength = size
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, "Host") {
+			req.Host = h.Value
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+	// the boundary of a form is only known here
+	if len(r.Form) > 0 || (req.Header.Get("Content-Type") == "" && contentType != "") {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if req.Header.Get("Authorization") == "" {
+		authorization, err := r.Auth.authorization()
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+	}
+	if r.URL == linearAPI && req.Header.Get("Authorization") == "" {
+		if token, _ := linearToken(); strings.HasPrefix(token, "lin_api_") {
+			req.Header.Set("Authorization", token)
+		} else if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return req, nil
+}
+
+// payload returns the body of a request with its content type and
+// length: the text of the body, a multipart form or the content of a
+// file, which is read as it is sent
+func (r httpRequest) payload() (io.Reader, string, int64, error) {
+	switch {
+	case len(r.Form) > 0:
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for _, f := range r.Form {
+			if f.File == "" {
+				if err := w.WriteField(f.Name, f.Value); err != nil {
+					return nil, "", 0, err
+				}
+				continue
+			}
+			part, err := w.CreatePart(textproto.MIMEHeader{
+				"Content-Disposition": {mime.FormatMediaType("form-data", map[string]string{"name": f.Name, "filename": filepath.Base(f.File)})},
+				"Content-Type":        {fileContentType(f.File)},
+			})
+			if err != nil {
+				return nil, "", 0, err
+			}
+			file, err := os.Open(f.File)
+			if err != nil {
+				return nil, "", 0, fmt.Errorf("form field %s: %w", f.Name, err)
+			}
+			_, err = io.Copy(part, file)
+			file.Close()
+			if err != nil {
+				return nil, "", 0, fmt.Errorf("form field %s: %w", f.Name, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", 0, err
+		}
+		return &buf, w.FormDataContentType(), int64(buf.Len()), nil
+	case r.BodyFile != "":
+		file, err := os.Open(r.BodyFile)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("body file: %w", err)
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, "", 0, fmt.Errorf("body file: %w", err)
+		}
+		return file, fileContentType(r.BodyFile), info.Size(), nil
+	}
+	return strings.NewReader(r.Body), "", int64(len(r.Body)), nil
+}
+
+// fileContentType guesses the media type of an uploaded file from its
+// extension
+func fileContentType(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// sendHTTP sends a request with the cookies of earlier responses,
+// records how the server answered and checks the request's assertions
+func sendHTTP(r httpRequest) httpExchange {
+	exchange := exchangeHTTP(r)
+	exchange.Assertions = checkAssertions(r.Assert, exchange)
+	return exchange
+}
+
+// exchangeHTTP sends a request with the cookies of earlier responses and
+// records how the server answered
+func exchangeHTTP(r httpRequest) httpExchange {
+	exchange := httpExchange{Request: r, Started: time.Now()}
+	req, err := newHTTPRequest(r)
+	if err != nil {
+		exchange.Error = err.Error()
+		return exchange
+	}
+	client := &http.Client{Timeout: httpTimeout, Jar: sharedCookieJar()}
+	resp, err := client.Do(req)
+	if err != nil {
+		exchange.Error = err.Error()
+		exchange.DurationMs = time.Since(exchange.Started).Milliseconds()
+		return exchange
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	size := int64(len(body))
+	if err == nil {
+		// count the rest without keeping it
+		rest, _ := io.Copy(io.Discard, resp.Body)
+		size += rest
+	}
+	exchange.DurationMs = time.Since(exchange.Started).Milliseconds()
+	response := &httpResponse{
+		Status:   resp.StatusCode,
+		Text:     strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		Protocol: resp.Proto,
+		Size:     size,
+	}
+	for name, values := range resp.Header {
+		for _, value := range values {
+			response.Headers = append(response.Headers, httpHeader{name, value})
+		}
+	}
+	sort.SliceStable(response.Headers, func(i, j int) bool { return response.Headers[i].Name < response.Headers[j].Name })
+	if utf8.Valid(body) {
+		response.Body = string(body)
+	} else {
+		response.Body, response.Encoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	exchange.Response = response
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	return exchange
+}
+
+// Duration returns how long the exchange took
+func (e httpExchange) Duration() time.Duration {
+	return time.Duration(e.DurationMs) * time.Millisecond
+}
+
+// Outcome summarises the exchange as its status or error, and how many
+// of its assertions failed
+func (e httpExchange) Outcome() string {
+	outcome := e.status()
+	if n := len(e.Assertions); n > 0 {
+		if failed := e.failedAssertions(); failed > 0 {
+			outcome += fmt.Sprintf(" · ✗ %d of %d assertions failed", failed, n)
+		} else {
+			outcome += fmt.Sprintf(" · ✓ %d assertions", n)
+		}
+	}
+	return outcome
+}
+
+// status summarises the exchange as its status or error
+func (e httpExchange) status() string {
+	if e.Response == nil {
+		return "✘ " + e.Error
+	}
+	outcome := fmt.Sprintf("%d %s", e.Response.Status, e.Response.Text)
+	if e.Request.GraphQL != nil {
+		// GraphQL servers report errors in the body, often with a 200
+		var result struct {
+			Errors []json.RawMessage `json:"errors"`
+		}
+		if json.Unmarshal([]byte(e.Response.Body), &result) == nil && len(result.Errors) > 0 {
+			outcome += fmt.Sprintf(" · GraphQL errors: %d", len(result.Errors))
+		}
+	}
+	return outcome
+}
+
+// Render describes the exchange for the response pane: the status line,
+// the headers and the body, indented when it is JSON
+func (e httpExchange) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", e.Request.Method, e.Request.URL)
+	for _, result := range e.Assertions {
+		mark := "✓"
+		if !result.Passed {
+			mark = "✗"
+		}
+		fmt.Fprintf(&b, "%s %s · %s\n", mark, result.Assertion, result.Detail)
+	}
+	if e.Response == nil {
+		fmt.Fprintf(&b, "\n%s\n", e.Error)
+		return b.String()
+	}
+	r := e.Response
+	fmt.Fprintf(&b, "%s %d %s · %s · %s\n\n", r.Protocol, r.Status, r.Text, e.Duration().Round(time.Millisecond), formatBytes(r.Size))
+	b.WriteString(formatHeaders(r.Headers))
+	b.WriteString("\n\n")
+	switch {
+	case r.Encoding == "base64":
+		fmt.Fprintf(&b, "[%s of binary content]\n", formatBytes(r.Size))
+	default:
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(r.Body), "", "  ") == nil {
+			b.WriteString(indented.String())
+		} else {
+			b.WriteString(r.Body)
+		}
+		if r.Size > int64(len(r.Body)) {
+			fmt.Fprintf(&b, "\n[… %s more]", formatBytes(r.Size-int64(len(r.Body))))
+		}
+	}
+	if e.Error != "" {
+		fmt.Fprintf(&b, "\n%s\n", e.Error)
+	}
+	return b.String()
+}
+
+// httpHistoryPath returns the location of the sent requests log
+func httpHistoryPath() string {
+	return filepath.Join(ConfigDir(), httpHistoryFile)
+}
+
+// redactedHeaders carry credentials and are left out of the log
+var redactedHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// redactedValue replaces the value of a redacted header
+const redactedValue = "[redacted]"
+
+// replayed returns the exchange's request to send again or save, without
+// the headers redacted from the log; the auth and the cookie jar supply
+// those again
+func (e httpExchange) replayed() httpRequest {
+	r := e.Request
+	r.Headers = nil
+	for _, h := range e.Request.Headers {
+		if h.Value != redactedValue || !redactedHeaders[strings.ToLower(h.Name)] {
+			r.Headers = append(r.Headers, h)
+		}
+	}
+	return r
+}
+
+// redactHeaders returns headers with the values of credential headers
+// replaced
+func redactHeaders(headers []httpHeader) []httpHeader {
+	redacted := make([]httpHeader, len(headers))
+	for i, h := range headers {
+		if redactedHeaders[strings.ToLower(h.Name)] {
+			h.Value = redactedValue
+		}
+		redacted[i] = h
+	}
+	return redacted
+}
+
+// AppendHTTPHistory adds an exchange to the log, which only the user
+// may read, with its credential headers redacted
+func AppendHTTPHistory(exchange httpExchange) error {
+	if err := os.MkdirAll(ConfigDir(), 0755); err != nil {
+		return err
+	}
+	exchange.Request.Headers = redactHeaders(exchange.Request.Headers)
+	if exchange.Response != nil {
+		response := *exchange.Response
+		response.Headers = redactHeaders(response.Headers)
+		exchange.Response = &response
+	}
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(httpHistoryPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Chmod(0600); err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// LoadHTTPHistory returns the latest exchanges, newest first
+func LoadHTTPHistory() ([]httpExchange, error) {
+	f, err := os.Open(httpHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var exchanges []httpExchange
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*maxHTTPBody)
+	for scanner.Scan() {
+		var exchange httpExchange
+		if json.Unmarshal(scanner.Bytes(), &exchange) == nil {
+			exchanges = append(exchanges, exchange)
+		}
+	}
+	if len(exchanges) > maxHTTPHistory {
+		exchanges = exchanges[len(exchanges)-maxHTTPHistory:]
+	}
+	for i, j := 0, len(exchanges)-1; i < j; i, j = i+1, j-1 {
+		exchanges[i], exchanges[j] = exchanges[j], exchanges[i]
+	}
+	return exchanges, scanner.Err()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// httpEnvironmentsFile remembers the active environment of each project
+const httpEnvironmentsFile = "http_environments.json"
+
+// defaultCollection takes the requests saved without naming a collection
+const defaultCollection = "Saved"
+
+// httpVariable matches a {{name}} placeholder of a saved request
+var httpVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// httpCollection is a named group of saved requests; Auth is that of
+// its requests without their own
+type httpCollection struct {
+	Name     string        `json:"name"`
+	Auth     *httpAuth     `json:"auth,omitempty"`
+	Requests []httpRequest `json:"requests"`
+}
+
+// httpWorkspace is the request file of a project: its collections and
+// the values of the {{variables}} in each environment, such as dev and
+// prod. It is meant to be committed, so values may refer to the
+// process environment as $NAME to keep tokens out of the repository.
+// Volatile names the response fields, such as timestamps, that are
+// expected to differ when environments are compared.
+type httpWorkspace struct {
+	Environments map[string]map[string]string `json:"environments,omitempty"`
+	Volatile     []string                     `json:"volatile,omitempty"`
+	Collections  []httpCollection             `json:"collections"`
+}
+
+// httpWorkspacePath is the request file inside a project
+func httpWorkspacePath(root string) string {
+	return filepath.Join(root, ".opencode", "requests.json")
+}
+
+// LoadHTTPWorkspace reads the request file of the project at root. The
+// first time, the requests saved by earlier versions outside of any
+// project are moved into it; moved is how many there were.
+func LoadHTTPWorkspace(root string) (w httpWorkspace, moved int, err error) {
+	path := httpWorkspacePath(root)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		var legacy []httpRequest
+		if err := loadJSON(httpRequestsFile, &legacy); err != nil || len(legacy) == 0 {
+			return w, 0, err
+		}
+		w.Collections = []httpCollection{{Name: defaultCollection, Requests: legacy}}
+		if err := w.Save(root); err != nil {
+			return w, 0, err
+		}
+		return w, len(legacy), os.Remove(filepath.Join(ConfigDir(), httpRequestsFile))
+	}
+	if err != nil {
+		return w, 0, err
+	}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return w, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, 0, nil
+}
+
+// Save writes the request file of the project at root
+func (w httpWorkspace) Save(root string) error {
+	path := httpWorkspacePath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileContent(path, string(data)+"\n")
+}
+
+// EnvironmentNames returns the environments in alphabetical order
+func (w httpWorkspace) EnvironmentNames() []string {
+	names := make([]string, 0, len(w.Environments))
+	for name := range w.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SavedCount returns how many requests the collections hold
+func (w httpWorkspace) SavedCount() int {
+	n := 0
+	for _, c := range w.Collections {
+		n += len(c.Requests)
+	}
+	return n
+}
+
+// Add appends a request to the collection called name, creating it, and
+// returns where it was put
+func (w *httpWorkspace) Add(name string, r httpRequest) (collection, index int) {
+	for i, c := range w.Collections {
+		if c.Name == name {
+			w.Collections[i].Requests = append(c.Requests, r)
+			return i, len(c.Requests)
+		}
+	}
+	w.Collections = append(w.Collections, httpCollection{Name: name, Requests: []httpRequest{r}})
+	return len(w.Collections) - 1, 0
+}
+
+// Remove deletes a request, and its collection when it was the last one
+func (w *httpWorkspace) Remove(collection, index int) {
+	c := &w.Collections[collection]
+	c.Requests = append(c.Requests[:index], c.Requests[index+1:]...)
+	if len(c.Requests) == 0 {
+		w.Collections = append(w.Collections[:collection], w.Collections[collection+1:]...)
+	}
+}
+
+// Resolve substitutes the {{variables}} of a request with their values
+// in env, expanding $NAME references to the process environment. A
+// variable env does not define is an error rather than being sent as is.
+func (w httpWorkspace) Resolve(r httpRequest, env string) (httpRequest, error) {
+	values := w.Environments[env]
+	var missing []string
+	substitute := func(text string) string {
+		return httpVariable.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := httpVariable.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				missing = append(missing, placeholder)
+				return placeholder
+			}
+			return os.ExpandEnv(value)
+		})
+	}
+	resolved := httpRequest{Name: r.Name, Method: r.Method, URL: substitute(r.URL), Body: substitute(r.Body), BodyFile: substitute(r.BodyFile)}
+	for _, h := range r.Headers {
+		resolved.Headers = append(resolved.Headers, httpHeader{h.Name, substitute(h.Value)})
+	}
+	for _, f := range r.Form {
+		resolved.Form = append(resolved.Form, httpFormField{f.Name, substitute(f.Value), substitute(f.File)})
+	}
+	for _, a := range r.Assert {
+		a.Equals = json.RawMessage(substitute(string(a.Equals)))
+		resolved.Assert = append(resolved.Assert, a)
+	}
+	if q := r.GraphQL; q != nil {
+		resolved.GraphQL = &graphQLQuery{Query: substitute(q.Query), Variables: substitute(q.Variables)}
+	}
+	if a := r.Auth; a != nil {
+		auth := *a
+		auth.Username, auth.ClientID, auth.TokenURL = substitute(a.Username), substitute(a.ClientID), substitute(a.TokenURL)
+		resolved.Auth = &auth
+	}
+	switch {
+	case len(missing) == 0:
+		return resolved, nil
+	case env == "":
+		return resolved, fmt.Errorf("no environment is selected for %s", strings.Join(missing, ", "))
+	}
+	return resolved, fmt.Errorf("%s not defined in the %s environment", strings.Join(missing, ", "), env)
+}
+
+// LoadHTTPEnvironment returns the environment last picked for a project
+func LoadHTTPEnvironment(root string) string {
+	var active map[string]string
+	loadJSON(httpEnvironmentsFile, &active)
+	return active[root]
+}
+
+// SaveHTTPEnvironment remembers the environment picked for a project
+func SaveHTTPEnvironment(root, env string) error {
+	active := map[string]string{}
+	if err := loadJSON(httpEnvironmentsFile, &active); err != nil {
+		return err
+	}
+	if env == "" {
+		delete(active, root)
+	} else {
+		active[root] = env
+	}
+	return saveJSON(httpEnvironmentsFile, active)
+}
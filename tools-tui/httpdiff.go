@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ignoredValue replaces the value of a volatile field, so the field
+// still shows on both sides
+const ignoredValue = "<ignored>"
+
+// envComparison is a saved request sent in two environments
+type envComparison struct {
+	envs      [2]string
+	exchanges [2]httpExchange
+}
+
+// compareEnvironments sends a request resolved in each of two
+// environments at the same time and logs both exchanges; root is the
+// project its files are uploaded from
+func compareEnvironments(w httpWorkspace, root string, r httpRequest, envs [2]string) (envComparison, error) {
+	c := envComparison{envs: envs}
+	var requests [2]httpRequest
+	for i, env := range envs {
+		resolved, err := w.Resolve(r, env)
+		if err == nil {
+			resolved, err = resolved.wire(root)
+		}
+		if err != nil {
+			return c, err
+		}
+		requests[i] = resolved
+	}
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.exchanges[i] = sendHTTP(requests[i])
+			c.exchanges[i].Environment = envs[i]
+		}(i)
+	}
+	wg.Wait()
+	for _, exchange := range c.exchanges {
+		if err := AppendHTTPHistory(exchange); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// volatileMatch reports whether the field at path, the keys from the
+// root with array indexes, is volatile. An entry without a dot matches
+// a key at any depth; a dotted one matches a whole path, with * for any
+// key or index.
+func volatileMatch(volatile []string, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	for _, entry := range volatile {
+		parts := strings.Split(entry, ".")
+		if len(parts) == 1 {
+			if path[len(path)-1] == entry {
+				return true
+			}
+			continue
+		}
+		if len(parts) != len(path) {
+			continue
+		}
+		match := true
+		for i, part := range parts {
+			if part != "*" && part != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// maskVolatile replaces the values of volatile fields
+func maskVolatile(value interface{}, volatile []string, path []string) interface{} {
+	if volatileMatch(volatile, path) {
+		return ignoredValue
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			masked[key] = maskVolatile(child, volatile, append(path[:len(path):len(path)], key))
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, child := range v {
+			masked[i] = maskVolatile(child, volatile, append(path[:len(path):len(path)], fmt.Sprint(i)))
+		}
+		return masked
+	}
+	return value
+}
+
+// jsonType names the type of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// jsonShape records the type of every field of a JSON value by path,
+// such as data.items[].id, merging the elements of arrays
+func jsonShape(value interface{}, path string, shape map[string]string) {
+	typ := jsonType(value)
+	if previous, ok := shape[path]; ok && !strings.Contains("|"+previous+"|", "|"+typ+"|") {
+		types := append(strings.Split(previous, "|"), typ)
+		sort.Strings(types)
+		typ = strings.Join(types, "|")
+	}
+	shape[path] = typ
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			jsonShape(child, childPath, shape)
+		}
+	case []interface{}:
+		for _, child := range v {
+			jsonShape(child, path+"[]", shape)
+		}
+	}
+}
+
+// shapeUnknown reports whether a path is missing from a shape only
+// because a parent is null or an empty array there, which says nothing
+// about the contract
+func shapeUnknown(shape map[string]string, path string) bool {
+	for i := range path {
+		if path[i] != '.' && path[i] != '[' {
+			continue
+		}
+		parent := path[:i]
+		if shape[parent] == "null" {
+			return true
+		}
+		if _, hasElements := shape[parent+"[]"]; shape[parent] == "array" && !hasElements {
+			return true
+		}
+	}
+	return false
+}
+
+// shapeDrift lists the fields whose type differs between two JSON
+// values or that only one of them has
+func shapeDrift(a, b interface{}, envs [2]string) []string {
+	shapes := [2]map[string]string{{}, {}}
+	jsonShape(a, "", shapes[0])
+	jsonShape(b, "", shapes[1])
+	paths := map[string]bool{}
+	for _, shape := range shapes {
+		for path := range shape {
+			paths[path] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	var drift []string
+	for _, path := range sorted {
+		ta, inA := shapes[0][path]
+		tb, inB := shapes[1][path]
+		label := path
+		if label == "" {
+			label = "(root)"
+		}
+		switch {
+		case inA && inB && ta != tb:
+			drift = append(drift, fmt.Sprintf("~ %s: %s in %s, %s in %s", label, ta, envs[0], tb, envs[1]))
+		case inA && !inB && !shapeUnknown(shapes[1], path):
+			drift = append(drift, fmt.Sprintf("- %s: %s, only in %s", label, ta, envs[0]))
+		case inB && !inA && !shapeUnknown(shapes[0], path):
+			drift = append(drift, fmt.Sprintf("+ %s: %s, only in %s", label, tb, envs[1]))
+		}
+	}
+	return drift
+}
+
+// normalizedBody returns a response body for comparison: JSON indented
+// with sorted keys and volatile fields masked, other bodies as they are
+func normalizedBody(e httpExchange, volatile []string) (string, interface{}, bool) {
+	if e.Response == nil {
+		return e.Error, nil, false
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(e.Response.Body), &decoded); err != nil {
+		return e.Response.Body, nil, false
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(maskVolatile(decoded, volatile, nil)); err != nil {
+		return e.Response.Body, nil, false
+	}
+	return b.String(), decoded, true
+}
+
+// Report describes how the responses of the two environments differ:
+// the status, the drift of the JSON structure and a diff of the values,
+// with volatile fields masked. differences counts the drifted fields
+// and changed lines.
+func (c envComparison) Report(volatile []string) (report string, differences int) {
+	var b strings.Builder
+	a, z := c.exchanges[0], c.exchanges[1]
+	for _, e := range c.exchanges {
+		fmt.Fprintf(&b, "%-8s %s %s · %s · %s\n", e.Environment, e.Request.Method, e.Request.URL, e.Outcome(), e.Duration().Round(time.Millisecond))
+	}
+	if len(volatile) > 0 {
+		fmt.Fprintf(&b, "Ignoring %s\n", strings.Join(volatile, ", "))
+	}
+
+	var drift []string
+	if a.Response != nil && z.Response != nil && a.Response.Status != z.Response.Status {
+		drift = append(drift, fmt.Sprintf("~ status: %d in %s, %d in %s", a.Response.Status, c.envs[0], z.Response.Status, c.envs[1]))
+	}
+	bodyA, jsonA, okA := normalizedBody(a, volatile)
+	bodyZ, jsonZ, okZ := normalizedBody(z, volatile)
+	if okA && okZ {
+		drift = append(drift, shapeDrift(jsonA, jsonZ, c.envs)...)
+	}
+	if len(drift) > 0 {
+		fmt.Fprintf(&b, "\nContract drift (%d):\n%s\n", len(drift), strings.Join(drift, "\n"))
+	}
+
+	diff := UnifiedDiff(c.envs[0], c.envs[1], bodyA, bodyZ)
+	changed := 0
+	lines := splitLines(diff)
+	// the first two lines name the environments
+	for _, line := range lines[min(len(lines), 2):] {
+		if line[0] == '+' || line[0] == '-' {
+			changed++
+		}
+	}
+	switch {
+	case diff == "" && len(drift) == 0:
+		fmt.Fprintf(&b, "\nSame response in %s and %s\n", c.envs[0], c.envs[1])
+	case diff == "":
+		b.WriteString("\nSame body\n")
+	default:
+		fmt.Fprintf(&b, "\nValues (%d lines changed):\n%s\n", changed, colorDiff(diff))
+	}
+	return b.String(), len(drift) + changed
+}
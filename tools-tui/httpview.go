@@ -0,0 +1,1210 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// httpListRows is how many rows of a request list are shown around the
+// cursor
+const httpListRows = 8
+
+// httpSection is a list of the request builder
+type httpSection int
+
+const (
+	httpSaved httpSection = iota
+	httpHistory
+)
+
+// httpSectionNames are the headings of the lists, in tab order
+var httpSectionNames = []string{"Requests", "History"}
+
+// httpField is a field of the request editor, in tab order
+type httpField int
+
+const (
+	httpFieldCollection httpField = iota
+	httpFieldName
+	httpFieldMethod
+	httpFieldURL
+	httpFieldAuth
+	httpFieldAssert
+	httpFieldHeaders
+	httpFieldBody
+	httpFieldVariables
+	httpFieldCount
+)
+
+// graphQLMethod is the position of GraphQL after the HTTP methods in the
+// editor's method field
+var graphQLMethod = len(requestMethods)
+
+// httpRow is a row of the Requests list: the heading of a collection
+// when request is -1, or one of its requests
+type httpRow struct {
+	collection int
+	request    int
+}
+
+// httpView holds the state of the request builder
+type httpView struct {
+	// root is the project whose collections are shown
+	root      string
+	workspace httpWorkspace
+	env       string
+	collapsed map[string]bool
+	history   []httpExchange
+	section   httpSection
+	cursors   [2]int
+	response  viewport.Model
+	// editing shows the editor; edited is the saved request it changes,
+	// with a request of -1 for a new one
+	editing    bool
+	edited     httpRow
+	field      httpField
+	method     int
+	collection textinput.Model
+	name       textinput.Model
+	url        textinput.Model
+	auth       textinput.Model
+	assert     textinput.Model
+	headers    textarea.Model
+	body       textarea.Model
+	bodyHeight int
+	variables  textarea.Model
+	// multipart makes the body the fields of a multipart form; files
+	// picks a file to upload
+	multipart bool
+	files     filePicker
+	// schemas are the introspected GraphQL schemas by URL; completing
+	// lists the fields that can be written at the cursor of the query
+	schemas       map[string]*gqlSchema
+	introspecting bool
+	completing    bool
+	suggestions   []gqlSuggestion
+	prefix        string
+	suggestion    int
+	// importing asks for the path of a HAR file
+	importing bool
+	path      textinput.Model
+	deleting  bool
+	// comparing picks the environment, against, to compare the active
+	// one with
+	comparing bool
+	against   string
+	sending   bool
+	message   string
+}
+
+// httpExchangeMsg carries the outcome of a sent request
+type httpExchangeMsg struct {
+	exchange httpExchange
+	err      error
+}
+
+// envComparisonMsg carries the responses of a request sent in two
+// environments
+type envComparisonMsg struct {
+	comparison envComparison
+	err        error
+}
+
+// graphQLSchemaMsg carries the schema of a GraphQL API
+type graphQLSchemaMsg struct {
+	url    string
+	schema *gqlSchema
+	err    error
+}
+
+// introspectCmd reads the schema of the API r is sent to in the
+// background
+func introspectCmd(r httpRequest) tea.Cmd {
+	return func() tea.Msg {
+		schema, err := introspectGraphQL(r)
+		return graphQLSchemaMsg{url: r.URL, schema: schema, err: err}
+	}
+}
+
+// sendHTTPCmd sends a request resolved in env in the background and
+// logs the exchange
+func sendHTTPCmd(r httpRequest, env string) tea.Cmd {
+	return func() tea.Msg {
+		exchange := sendHTTP(r)
+		exchange.Environment = env
+		return httpExchangeMsg{exchange: exchange, err: AppendHTTPHistory(exchange)}
+	}
+}
+
+// compareCmd sends a request in two environments in the background
+func compareCmd(w httpWorkspace, root string, r httpRequest, envs [2]string) tea.Cmd {
+	return func() tea.Msg {
+		c, err := compareEnvironments(w, root, r, envs)
+		return envComparisonMsg{comparison: c, err: err}
+	}
+}
+
+// openHTTP shows the request builder with the collections of the
+// current project and the latest sent requests
+func (m *Model) openHTTP() tea.Cmd {
+	v := &m.http
+	root := m.projectDir()
+	if root == "" {
+		root = defaultWorkDir
+	}
+	*v = httpView{root: root, collapsed: map[string]bool{}, edited: httpRow{-1, -1}, schemas: map[string]*gqlSchema{}}
+	workspace, moved, err := LoadHTTPWorkspace(root)
+	v.workspace = workspace
+	switch {
+	case err != nil:
+		v.message = "Could not read the collections: " + err.Error()
+	case moved > 0:
+		v.message = fmt.Sprintf("Moved %d saved requests into %s", moved, httpWorkspacePath(root))
+	}
+	if env := LoadHTTPEnvironment(root); v.workspace.Environments[env] != nil {
+		v.env = env
+	}
+	if v.history, err = LoadHTTPHistory(); err != nil {
+		v.message = "Could not read the request history: " + err.Error()
+	}
+	if len(v.workspace.Collections) == 0 && len(v.history) > 0 {
+		v.section = httpHistory
+	}
+	v.collection = newTextInput()
+	v.collection.Prompt = ""
+	v.collection.Placeholder = "none: send without saving"
+	v.collection.Width = 30
+	v.name = newTextInput()
+	v.name.Prompt = ""
+	v.name.Placeholder = "optional"
+	v.name.Width = 30
+	v.url = newTextInput()
+	v.url.Prompt = ""
+	v.url.Placeholder = "https://api.example.com/items"
+	v.url.Width = max(m.width-16, 20)
+	v.auth = newTextInput()
+	v.auth.Prompt = ""
+	v.auth.Placeholder = "the collection's: none, basic USER SECRET, bearer SECRET or oauth2 SECRET CLIENT_ID TOKEN_URL [SCOPE...]"
+	v.auth.Width = max(m.width-16, 20)
+	v.assert = newTextInput()
+	v.assert.Prompt = ""
+	v.assert.Placeholder = "checked after each run: status 200; time < 500ms; $.items[0].id == 42; $.next"
+	v.assert.Width = max(m.width-16, 20)
+	v.headers = newTextArea()
+	v.headers.Placeholder = "Name: value, one header per line"
+	v.headers.SetWidth(max(m.width-4, 20))
+	v.headers.SetHeight(4)
+	v.body = newTextArea()
+	v.body.CharLimit = 0
+	v.body.SetWidth(max(m.width-4, 20))
+	v.bodyHeight = max(m.height-httpListRows-21, 4)
+	v.body.SetHeight(v.bodyHeight)
+	v.variables = newTextArea()
+	v.variables.Placeholder = `{"first": 10}`
+	v.variables.SetWidth(max(m.width-4, 20))
+	v.variables.SetHeight(3)
+	v.path = newTextInput()
+	v.path.Prompt = "HAR file: "
+	v.path.Width = max(m.width-16, 20)
+	v.response = viewport.New(max(m.width-4, 20), max(m.height-httpListRows-12, 5))
+	v.show()
+	m.screen = screenHTTP
+	return nil
+}
+
+// rows returns the rows of the Requests list: each collection followed
+// by its requests unless it is collapsed
+func (v httpView) rows() []httpRow {
+	var rows []httpRow
+	for i, c := range v.workspace.Collections {
+		rows = append(rows, httpRow{i, -1})
+		if v.collapsed[c.Name] {
+			continue
+		}
+		for j := range c.Requests {
+			rows = append(rows, httpRow{i, j})
+		}
+	}
+	return rows
+}
+
+// openGraphQLConsole shows the request editor with a GraphQL query of
+// the API at url
+func (m *Model) openGraphQLConsole(url, query string) tea.Cmd {
+	m.openHTTP()
+	v := &m.http
+	v.edit(httpRequest{URL: url, GraphQL: &graphQLQuery{Query: query}}, httpRow{-1, -1}, "")
+	return v.focus(httpFieldBody)
+}
+
+// count returns how many rows a list has
+func (v httpView) count(section httpSection) int {
+	if section == httpSaved {
+		return len(v.rows())
+	}
+	return len(v.history)
+}
+
+// row returns the row of the Requests list under the cursor
+func (v httpView) row() (httpRow, bool) {
+	rows := v.rows()
+	if cursor := v.cursors[httpSaved]; cursor < len(rows) {
+		return rows[cursor], true
+	}
+	return httpRow{-1, -1}, false
+}
+
+// saved returns the request of a row of the Requests list
+func (v httpView) saved(row httpRow) httpRequest {
+	return v.workspace.Collections[row.collection].Requests[row.request]
+}
+
+// currentCollection returns the collection of the row under the cursor
+// of the Requests list, where requests are saved by default
+func (v httpView) currentCollection() string {
+	if row, ok := v.row(); ok {
+		return v.workspace.Collections[row.collection].Name
+	}
+	return defaultCollection
+}
+
+// selected returns the request under the cursor and the row of the
+// saved request it is, with a request of -1 for one of the history
+func (v httpView) selected() (httpRequest, httpRow, bool) {
+	if v.section == httpHistory {
+		cursor := v.cursors[httpHistory]
+		if cursor >= len(v.history) {
+			return httpRequest{}, httpRow{-1, -1}, false
+		}
+		return v.history[cursor].replayed(), httpRow{-1, -1}, true
+	}
+	row, ok := v.row()
+	if !ok || row.request < 0 {
+		return httpRequest{}, row, false
+	}
+	return v.saved(row), row, true
+}
+
+// locate moves the cursor of the Requests list to row, expanding its
+// collection
+func (v *httpView) locate(row httpRow) {
+	v.section = httpSaved
+	delete(v.collapsed, v.workspace.Collections[row.collection].Name)
+	for i, r := range v.rows() {
+		if r == row {
+			v.cursors[httpSaved] = i
+		}
+	}
+	v.show()
+}
+
+// show fills the response pane with the selected exchange, the selected
+// saved request as it will be sent in the active environment, or the
+// variables a collection's requests use
+func (v *httpView) show() {
+	content := ""
+	cursor := v.cursors[v.section]
+	row, _ := v.row()
+	switch {
+	case cursor >= v.count(v.section):
+	case v.section == httpHistory:
+		content = v.history[cursor].Render()
+	case row.request < 0:
+		content = v.describeCollection(v.workspace.Collections[row.collection])
+	default:
+		r, err := v.workspace.Resolve(v.workspace.Inherit(v.workspace.Collections[row.collection].Name, v.saved(row)), v.env)
+		content = r.Method + " " + r.URL + "\n" + formatHeaders(r.Headers) + "\n\n" + r.Body
+		switch {
+		case len(r.Form) > 0:
+			content += "multipart/form-data\n" + formatForm(r.Form)
+		case r.BodyFile != "":
+			content += "Content of " + r.BodyFile
+		}
+		if q := r.GraphQL; q != nil {
+			content = "GraphQL " + r.URL + "\n" + formatHeaders(r.Headers) + "\n\n" + q.Query + "\n\n" + q.Variables
+		}
+		if r.Auth != nil {
+			content = "🔑 " + r.Auth.Describe() + "\n" + content
+		}
+		if len(r.Assert) > 0 {
+			content = "✔ " + formatAssertions(r.Assert) + "\n" + content
+		}
+		if err != nil {
+			content = "⚠ " + err.Error() + "\n\n" + content
+		}
+	}
+	v.response.SetContent(content)
+	v.response.GotoTop()
+}
+
+// describeCollection lists the variables the requests of a collection
+// use with their values in the active environment, as written in the
+// request file
+func (v httpView) describeCollection(c httpCollection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s · %d requests · %s\n", c.Name, len(c.Requests), httpWorkspacePath(v.root))
+	fmt.Fprintf(&b, "Auth of its requests: %s\n\n", c.Auth.Describe())
+	seen := map[string]bool{}
+	var names []string
+	for _, r := range c.Requests {
+		texts := []string{r.URL, r.Body, r.BodyFile}
+		for _, a := range r.Assert {
+			texts = append(texts, string(a.Equals))
+		}
+		for _, f := range r.Form {
+			texts = append(texts, f.Value, f.File)
+		}
+		if q := r.GraphQL; q != nil {
+			texts = append(texts, q.Query, q.Variables)
+		}
+		for _, h := range r.Headers {
+			texts = append(texts, h.Value)
+		}
+		for _, text := range texts {
+			for _, match := range httpVariable.FindAllStringSubmatch(text, -1) {
+				if !seen[match[1]] {
+					seen[match[1]] = true
+					names = append(names, match[1])
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		b.WriteString("No {{variables}}\n")
+		return b.String()
+	}
+	env := v.env
+	if env == "" {
+		env = "no environment"
+	}
+	fmt.Fprintf(&b, "Variables in %s:\n", env)
+	values := v.workspace.Environments[v.env]
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			value = "(not defined)"
+		}
+		fmt.Fprintf(&b, "  %-16s %s\n", name, value)
+	}
+	return b.String()
+}
+
+// send resolves the variables of a request of a collection in the
+// active environment and sends it with the collection's auth unless it
+// has its own
+func (v *httpView) send(collection string, r httpRequest) tea.Cmd {
+	resolved, err := v.workspace.Resolve(v.workspace.Inherit(collection, r), v.env)
+	if err == nil {
+		resolved, err = resolved.wire(v.root)
+	}
+	if err != nil {
+		v.message = err.Error()
+		return nil
+	}
+	v.sending = true
+	return sendHTTPCmd(resolved, v.env)
+}
+
+// others returns the environments other than the active one
+func (v httpView) others() []string {
+	var names []string
+	for _, name := range v.workspace.EnvironmentNames() {
+		if name != v.env {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// save writes the collections to the project, reporting a failure
+func (v *httpView) save() {
+	if err := v.workspace.Save(v.root); err != nil {
+		v.message = "Could not save the collections: " + err.Error()
+	}
+}
+
+// edit opens the editor with r; saved is the saved request it changes
+// and collection the one it is saved to
+func (v *httpView) edit(r httpRequest, saved httpRow, collection string) tea.Cmd {
+	v.editing, v.edited = true, saved
+	v.collection.SetValue(collection)
+	v.name.SetValue(r.Name)
+	v.method = 0
+	for i, method := range requestMethods {
+		if method == r.Method {
+			v.method = i
+		}
+	}
+	v.url.SetValue(r.URL)
+	v.auth.SetValue(r.Auth.String())
+	v.assert.SetValue(formatAssertions(r.Assert))
+	v.headers.SetValue(formatHeaders(r.Headers))
+	v.body.SetValue(r.Body)
+	v.variables.SetValue("")
+	v.multipart = len(r.Form) > 0
+	switch {
+	case v.multipart:
+		v.body.SetValue(formatForm(r.Form))
+	case r.BodyFile != "":
+		v.body.SetValue("@" + r.BodyFile)
+	}
+	if q := r.GraphQL; q != nil {
+		v.method = graphQLMethod
+		v.body.SetValue(q.Query)
+		v.variables.SetValue(q.Variables)
+	}
+	v.message, v.completing = "", false
+	v.resize()
+	return v.focus(httpFieldURL)
+}
+
+// attach writes a picked file into the body: as a field of a form, after
+// the name the cursor's line ends with or as a new "file" field, or as
+// the whole body of another request
+func (v *httpView) attach(path string) tea.Cmd {
+	if !v.multipart {
+		v.body.SetValue("@" + path)
+		return v.focus(httpFieldBody)
+	}
+	lines := strings.Split(v.body.Value(), "\n")
+	if line := lines[min(v.body.Line(), len(lines)-1)]; strings.HasSuffix(line, "=") {
+		v.body.CursorEnd()
+		v.body.InsertString("@" + path)
+	} else if body := strings.TrimRight(v.body.Value(), "\n"); body == "" {
+		v.body.SetValue("file=@" + path)
+	} else {
+		v.body.SetValue(body + "\nfile=@" + path)
+	}
+	return v.focus(httpFieldBody)
+}
+
+// graphQL reports whether the editor holds a GraphQL query
+func (v httpView) graphQL() bool {
+	return v.method == graphQLMethod
+}
+
+// resize makes room for the variables below a GraphQL query
+func (v *httpView) resize() {
+	if v.graphQL() {
+		v.body.SetHeight(max(v.bodyHeight-v.variables.Height()-1, 3))
+	} else {
+		v.body.SetHeight(v.bodyHeight)
+	}
+}
+
+// focus moves the editor's focus to field
+func (v *httpView) focus(field httpField) tea.Cmd {
+	v.field = field
+	v.collection.Blur()
+	v.name.Blur()
+	v.url.Blur()
+	v.auth.Blur()
+	v.assert.Blur()
+	v.headers.Blur()
+	v.body.Blur()
+	v.variables.Blur()
+	switch field {
+	case httpFieldCollection:
+		return v.collection.Focus()
+	case httpFieldName:
+		return v.name.Focus()
+	case httpFieldURL:
+		return v.url.Focus()
+	case httpFieldAuth:
+		return v.auth.Focus()
+	case httpFieldAssert:
+		return v.assert.Focus()
+	case httpFieldHeaders:
+		return v.headers.Focus()
+	case httpFieldBody:
+		return v.body.Focus()
+	case httpFieldVariables:
+		return v.variables.Focus()
+	}
+	return nil
+}
+
+// step moves the editor's focus by delta fields, past the variables of
+// a request that is not GraphQL
+func (v *httpView) step(delta int) tea.Cmd {
+	field := v.field
+	for {
+		field = (field + httpField(delta) + httpFieldCount) % httpFieldCount
+		if field != httpFieldVariables || v.graphQL() {
+			return v.focus(field)
+		}
+	}
+}
+
+// request returns the request in the editor
+func (v httpView) request() (httpRequest, error) {
+	r := httpRequest{Name: strings.TrimSpace(v.name.Value()), URL: strings.TrimSpace(v.url.Value())}
+	if v.graphQL() {
+		r.Method = "POST"
+		r.GraphQL = &graphQLQuery{Query: v.body.Value(), Variables: strings.TrimSpace(v.variables.Value())}
+	} else {
+		r.Method = requestMethods[v.method]
+		body := v.body.Value()
+		switch path, file := strings.CutPrefix(strings.TrimSpace(body), "@"); {
+		case v.multipart:
+			form, err := parseForm(body)
+			if err != nil {
+				return r, err
+			}
+			r.Form = form
+		case file && path != "" && !strings.Contains(path, "\n"):
+			r.BodyFile = path
+		default:
+			r.Body = body
+		}
+	}
+	var err error
+	if r.URL == "" {
+		return r, fmt.Errorf("the request needs a URL")
+	}
+	if !strings.Contains(r.URL, "://") && !strings.HasPrefix(r.URL, "{{") {
+		r.URL = "http://" + r.URL
+	}
+	if r.Auth, err = parseAuth(v.auth.Value()); err != nil {
+		return r, err
+	}
+	if r.Assert, err = parseAssertions(v.assert.Value()); err != nil {
+		return r, err
+	}
+	headers, err := parseHeaders(v.headers.Value())
+	r.Headers = headers
+	return r, err
+}
+
+// schemaRequest returns the editor's request resolved in the active
+// environment, as sent to read the schema of its API
+func (v httpView) schemaRequest() (httpRequest, error) {
+	r, err := v.request()
+	if err != nil {
+		return r, err
+	}
+	return v.workspace.Resolve(v.workspace.Inherit(strings.TrimSpace(v.collection.Value()), r), v.env)
+}
+
+// complete lists the fields or arguments that can be written at the
+// cursor of the query, reading the schema first when it is not known
+func (v *httpView) complete() tea.Cmd {
+	r, err := v.schemaRequest()
+	if err != nil {
+		v.message = err.Error()
+		return nil
+	}
+	schema := v.schemas[r.URL]
+	if schema == nil {
+		if !v.introspecting {
+			v.introspecting = true
+			v.message = "Reading the schema of " + r.URL + "…"
+			return introspectCmd(r)
+		}
+		return nil
+	}
+	lines := strings.Split(v.body.Value(), "\n")
+	row := min(v.body.Line(), len(lines)-1)
+	info := v.body.LineInfo()
+	current := []rune(lines[row])
+	before := strings.Join(append(lines[:row:row], string(current[:min(info.StartColumn+info.ColumnOffset, len(current))])), "\n")
+	v.prefix, v.suggestions = schema.Complete(before)
+	v.completing, v.suggestion = len(v.suggestions) > 0, 0
+	if !v.completing {
+		v.message = "Nothing to complete here"
+	}
+	return nil
+}
+
+// updateCompletion handles keys while completions are listed: ↑/↓ pick
+// one, tab or enter writes the rest of its name and any other key
+// closes the list and goes to the query
+func (m Model) updateCompletion(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.http
+	switch msg.Type {
+	case tea.KeyUp:
+		v.suggestion = (v.suggestion + len(v.suggestions) - 1) % len(v.suggestions)
+		return m, nil
+	case tea.KeyDown:
+		v.suggestion = (v.suggestion + 1) % len(v.suggestions)
+		return m, nil
+	case tea.KeyTab, tea.KeyEnter:
+		v.completing = false
+		v.body.InsertString(v.suggestions[v.suggestion].Name[len(v.prefix):])
+		return m, nil
+	case tea.KeyEsc:
+		v.completing = false
+		return m, nil
+	}
+	v.completing = false
+	return m.updateHTTPEditor(msg)
+}
+
+// updateHTTPEditor handles keys while a request is edited: tab moves
+// between the fields, ←/→ pick the method or GraphQL, ctrl+space
+// completes a GraphQL query and ctrl+s sends the request, saving it
+// first to its collection when it has one
+func (m Model) updateHTTPEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.http
+	if v.completing {
+		return m.updateCompletion(msg)
+	}
+	if v.files.active {
+		if path, ok := v.files.update(msg, m.keys); ok {
+			return m, v.attach(path)
+		}
+		return m, nil
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		v.editing = false
+		v.focus(httpFieldMethod)
+		return m, nil
+	case tea.KeyTab:
+		return m, v.step(1)
+	case tea.KeyShiftTab:
+		return m, v.step(-1)
+	case tea.KeyCtrlT:
+		if !v.graphQL() {
+			v.multipart = !v.multipart
+		}
+		return m, nil
+	case tea.KeyCtrlF:
+		if !v.graphQL() {
+			v.files.open(v.root, v.root)
+		}
+		return m, nil
+	case tea.KeyCtrlAt:
+		if v.graphQL() && v.field == httpFieldBody {
+			return m, v.complete()
+		}
+		return m, nil
+	case tea.KeyCtrlR:
+		if !v.graphQL() || v.introspecting {
+			return m, nil
+		}
+		r, err := v.schemaRequest()
+		if err != nil {
+			v.message = err.Error()
+			return m, nil
+		}
+		v.introspecting = true
+		v.message = "Reading the schema of " + r.URL + "…"
+		return m, introspectCmd(r)
+	case tea.KeyCtrlS:
+		r, err := v.request()
+		if err != nil {
+			v.message = err.Error()
+			return m, nil
+		}
+		collection := strings.TrimSpace(v.collection.Value())
+		switch {
+		case v.edited.request >= 0:
+			if collection == "" {
+				collection = defaultCollection
+			}
+			if c := &v.workspace.Collections[v.edited.collection]; c.Name == collection {
+				c.Requests[v.edited.request] = r
+			} else {
+				v.workspace.Remove(v.edited.collection, v.edited.request)
+				v.workspace.Add(collection, r)
+			}
+			v.save()
+		case collection != "":
+			v.workspace.Add(collection, r)
+			v.save()
+		}
+		v.editing = false
+		v.focus(httpFieldMethod)
+		return m, v.send(collection, r)
+	}
+	var cmd tea.Cmd
+	switch v.field {
+	case httpFieldMethod:
+		switch msg.Type {
+		case tea.KeyLeft:
+			v.method = (v.method + graphQLMethod) % (graphQLMethod + 1)
+			v.resize()
+		case tea.KeyRight, tea.KeySpace:
+			v.method = (v.method + 1) % (graphQLMethod + 1)
+			v.resize()
+		case tea.KeyEnter:
+			return m, v.focus(httpFieldURL)
+		}
+	case httpFieldCollection:
+		if msg.Type == tea.KeyEnter {
+			return m, v.focus(httpFieldName)
+		}
+		v.collection, cmd = v.collection.Update(msg)
+	case httpFieldName:
+		if msg.Type == tea.KeyEnter {
+			return m, v.focus(httpFieldMethod)
+		}
+		v.name, cmd = v.name.Update(msg)
+	case httpFieldURL:
+		if msg.Type == tea.KeyEnter {
+			return m, v.focus(httpFieldAuth)
+		}
+		v.url, cmd = v.url.Update(msg)
+	case httpFieldAuth:
+		if msg.Type == tea.KeyEnter {
+			return m, v.focus(httpFieldAssert)
+		}
+		v.auth, cmd = v.auth.Update(msg)
+	case httpFieldAssert:
+		if msg.Type == tea.KeyEnter {
+			return m, v.focus(httpFieldHeaders)
+		}
+		v.assert, cmd = v.assert.Update(msg)
+	case httpFieldHeaders:
+		v.headers, cmd = v.headers.Update(msg)
+	case httpFieldBody:
+		v.body, cmd = v.body.Update(msg)
+	case httpFieldVariables:
+		v.variables, cmd = v.variables.Update(msg)
+	}
+	return m, cmd
+}
+
+// updateHTTP handles input and responses on the request builder
+func (m Model) updateHTTP(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.http
+	if msg, ok := msg.(graphQLSchemaMsg); ok {
+		v.introspecting = false
+		if msg.err != nil {
+			v.message = "Could not read the schema: " + msg.err.Error()
+			return m, nil
+		}
+		v.schemas[msg.url] = msg.schema
+		v.message = fmt.Sprintf("Schema of %s: %d types", msg.url, len(msg.schema.Types))
+		if v.editing && v.field == httpFieldBody && v.graphQL() {
+			return m, v.complete()
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(envComparisonMsg); ok {
+		v.sending = false
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			return m, nil
+		}
+		c := msg.comparison
+		v.history = append([]httpExchange{c.exchanges[1], c.exchanges[0]}, v.history...)
+		if len(v.history) > maxHTTPHistory {
+			v.history = v.history[:maxHTTPHistory]
+		}
+		report, differences := c.Report(v.workspace.Volatile)
+		v.response.SetContent(report)
+		v.response.GotoTop()
+		v.message = fmt.Sprintf("%s and %s answer the same", c.envs[0], c.envs[1])
+		if differences > 0 {
+			v.message = fmt.Sprintf("%s and %s differ: %d differences", c.envs[0], c.envs[1], differences)
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(httpExchangeMsg); ok {
+		v.sending = false
+		v.history = append([]httpExchange{msg.exchange}, v.history...)
+		if len(v.history) > maxHTTPHistory {
+			v.history = v.history[:maxHTTPHistory]
+		}
+		v.section, v.cursors[httpHistory] = httpHistory, 0
+		v.show()
+		v.message = msg.exchange.Outcome()
+		if msg.err != nil {
+			v.message = "Could not log the request: " + msg.err.Error()
+		}
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if v.editing {
+		return m.updateHTTPEditor(keyMsg)
+	}
+	if v.importing {
+		switch keyMsg.Type {
+		case tea.KeyEsc:
+			v.importing = false
+			return m, nil
+		case tea.KeyEnter:
+			v.importing = false
+			path := resolvePath(v.root, strings.TrimSpace(v.path.Value()))
+			requests, err := ImportHAR(path)
+			if err != nil {
+				v.message = err.Error()
+				return m, nil
+			}
+			if len(requests) == 0 {
+				v.message = "No HTTP requests in " + path
+				return m, nil
+			}
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			var row httpRow
+			for _, r := range requests {
+				row.collection, _ = v.workspace.Add(name, r)
+			}
+			v.message = fmt.Sprintf("Imported %d requests from %s into %s", len(requests), path, name)
+			v.save()
+			v.locate(httpRow{row.collection, -1})
+			return m, nil
+		}
+		var cmd tea.Cmd
+		v.path, cmd = v.path.Update(keyMsg)
+		return m, cmd
+	}
+	if v.comparing {
+		others := v.others()
+		switch {
+		case keyMsg.Type == tea.KeyEsc:
+			v.comparing = false
+		case keyMsg.Type == tea.KeyEnter:
+			v.comparing = false
+			row, ok := v.row()
+			if !ok || row.request < 0 || v.sending {
+				return m, nil
+			}
+			v.sending = true
+			r := v.workspace.Inherit(v.workspace.Collections[row.collection].Name, v.saved(row))
+			return m, compareCmd(v.workspace, v.root, r, [2]string{v.env, v.against})
+		case keyMsg.Type == tea.KeyLeft || keyMsg.Type == tea.KeyRight:
+			i := 0
+			for j, name := range others {
+				if name == v.against {
+					i = j
+				}
+			}
+			if keyMsg.Type == tea.KeyRight {
+				i++
+			} else {
+				i += len(others) - 1
+			}
+			v.against = others[i%len(others)]
+		}
+		return m, nil
+	}
+	if v.deleting {
+		v.deleting = false
+		row, ok := v.row()
+		if !key.Matches(keyMsg, m.keys.Confirm) || !ok {
+			v.message = "Nothing deleted"
+			return m, nil
+		}
+		if row.request < 0 {
+			v.message = "Deleted the collection " + v.workspace.Collections[row.collection].Name
+			v.workspace.Collections = append(v.workspace.Collections[:row.collection], v.workspace.Collections[row.collection+1:]...)
+		} else {
+			v.message = "Deleted " + v.saved(row).Label()
+			v.workspace.Remove(row.collection, row.request)
+		}
+		v.cursors[httpSaved] = min(v.cursors[httpSaved], max(v.count(httpSaved)-1, 0))
+		v.save()
+		v.show()
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.ToggleCategory):
+		v.section = (v.section + 1) % httpSection(len(httpSectionNames))
+		v.show()
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursors[v.section] > 0 {
+			v.cursors[v.section]--
+			v.show()
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursors[v.section] < v.count(v.section)-1 {
+			v.cursors[v.section]++
+			v.show()
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if v.sending {
+			return m, nil
+		}
+		if v.section == httpHistory {
+			if cursor := v.cursors[httpHistory]; cursor < len(v.history) {
+				// the history holds requests as they were resolved
+				v.sending = true
+				return m, sendHTTPCmd(v.history[cursor].replayed(), v.history[cursor].Environment)
+			}
+			return m, nil
+		}
+		row, ok := v.row()
+		switch {
+		case !ok:
+		case row.request < 0:
+			name := v.workspace.Collections[row.collection].Name
+			v.collapsed[name] = !v.collapsed[name]
+		default:
+			return m, v.send(v.workspace.Collections[row.collection].Name, v.saved(row))
+		}
+	case key.Matches(keyMsg, m.keys.EditRequest):
+		if r, saved, ok := v.selected(); ok {
+			collection := v.currentCollection()
+			if saved.request < 0 {
+				collection = ""
+			}
+			return m, v.edit(r, saved, collection)
+		}
+	case key.Matches(keyMsg, m.keys.NewRequest):
+		collection := ""
+		if v.section == httpSaved && len(v.workspace.Collections) > 0 {
+			collection = v.currentCollection()
+		}
+		return m, v.edit(httpRequest{Method: "GET"}, httpRow{-1, -1}, collection)
+	case key.Matches(keyMsg, m.keys.Promote):
+		if v.section != httpHistory {
+			return m, nil
+		}
+		if r, _, ok := v.selected(); ok {
+			collection := v.currentCollection()
+			c, i := v.workspace.Add(collection, r)
+			v.message = "Saved " + r.Label() + " to " + collection
+			v.save()
+			v.cursors[httpSaved] = 0
+			for j, row := range v.rows() {
+				if row == (httpRow{c, i}) {
+					v.cursors[httpSaved] = j
+				}
+			}
+		}
+	case key.Matches(keyMsg, m.keys.Environment):
+		names := v.workspace.EnvironmentNames()
+		if len(names) == 0 {
+			v.message = "No environments in " + httpWorkspacePath(v.root) + ", add them under \"environments\""
+			return m, nil
+		}
+		next := names[0]
+		for i, name := range names {
+			if name == v.env {
+				next = ""
+				if i+1 < len(names) {
+					next = names[i+1]
+				}
+			}
+		}
+		v.env = next
+		v.message = "Environment: " + next
+		if next == "" {
+			v.message = "No environment selected"
+		}
+		if err := SaveHTTPEnvironment(v.root, next); err != nil {
+			v.message = "Could not remember the environment: " + err.Error()
+		}
+		v.show()
+	case key.Matches(keyMsg, m.keys.Compare):
+		row, ok := v.row()
+		if !ok || v.section != httpSaved || row.request < 0 {
+			return m, nil
+		}
+		others := v.others()
+		switch {
+		case v.env == "":
+			v.message = "Pick an environment to compare with another one first"
+			return m, nil
+		case len(others) == 0:
+			v.message = "There is no other environment in " + httpWorkspacePath(v.root) + " to compare " + v.env + " with"
+			return m, nil
+		}
+		if !slices.Contains(others, v.against) {
+			v.against = others[0]
+		}
+		v.comparing = true
+	case key.Matches(keyMsg, m.keys.Delete):
+		if _, ok := v.row(); ok && v.section == httpSaved {
+			v.deleting = true
+		}
+	case key.Matches(keyMsg, m.keys.Clean):
+		v.message = fmt.Sprintf("Cleared %d cookies", sharedCookieJar().Clear())
+	case key.Matches(keyMsg, m.keys.Import):
+		v.importing = true
+		v.path.SetValue("")
+		return m, v.path.Focus()
+	case key.Matches(keyMsg, m.keys.Export):
+		if len(v.history) == 0 {
+			v.message = "Nothing sent yet, the history is empty"
+			return m, nil
+		}
+		path, err := ExportHAR(m.exportDir(), v.history, time.Now())
+		if err != nil {
+			v.message = "Could not export the history: " + err.Error()
+		} else {
+			v.message = fmt.Sprintf("Exported %d requests to %s", len(v.history), path)
+		}
+	default:
+		var cmd tea.Cmd
+		v.response, cmd = v.response.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// renderHTTP renders the saved requests and history with the selected
+// exchange, or the request editor
+func (m Model) renderHTTP() string {
+	v := m.http
+	k := m.keys
+	var content strings.Builder
+	title := titleStyle.Render("🌐 Requests")
+	env := v.env
+	if env == "" {
+		env = "none"
+	}
+	summary := fmt.Sprintf("%d saved in %d collections · %d sent · environment: %s", v.workspace.SavedCount(), len(v.workspace.Collections), len(v.history), env)
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	if v.editing {
+		heading := "New request"
+		if v.edited.request >= 0 {
+			heading = "Edit " + v.saved(v.edited).Label()
+		}
+		content.WriteString(descriptionStyle.Bold(true).Render(heading))
+		content.WriteString("\n")
+		method := "GraphQL"
+		if !v.graphQL() {
+			method = requestMethods[v.method]
+		}
+		if v.field == httpFieldMethod {
+			method = selectedItemStyle.Render("◀ " + method + " ▶")
+		}
+		content.WriteString("Collection: " + v.collection.View() + "  Name: " + v.name.View() + "\n")
+		content.WriteString("Method:     " + method + "\n")
+		content.WriteString("URL:        " + v.url.View() + "\n")
+		content.WriteString("Auth:       " + v.auth.View() + "\n")
+		content.WriteString("Assert:     " + v.assert.View() + "\n")
+		content.WriteString(helpStyle.Render("environment: "+env+" · {{name}} is replaced with its value when sent · SECRET is a service of the secrets store") + "\n\n")
+		content.WriteString(helpStyle.Render("Headers") + "\n" + v.headers.View() + "\n")
+		if v.graphQL() {
+			content.WriteString(helpStyle.Render("Query") + "\n" + v.body.View() + "\n")
+			content.WriteString(helpStyle.Render("Variables") + "\n" + v.variables.View() + "\n")
+		} else if v.multipart {
+			content.WriteString(helpStyle.Render("Form · multipart/form-data · name=value or name=@file, one field per line") + "\n" + v.body.View() + "\n")
+		} else {
+			content.WriteString(helpStyle.Render("Body · @file sends the content of a file") + "\n" + v.body.View() + "\n")
+		}
+		switch {
+		case v.files.active:
+			content.WriteString(v.files.view(m.width))
+		case v.completing:
+			names := make([]string, len(v.suggestions))
+			for i, s := range v.suggestions {
+				names[i] = helpStyle.Render(s.Name)
+				if i == v.suggestion {
+					names[i] = selectedItemStyle.Render(s.Name)
+				}
+			}
+			content.WriteString(strings.Join(names, "  ") + "\n")
+			content.WriteString(descriptionStyle.Render(truncate(v.suggestions[v.suggestion].Detail, max(m.width-4, 20))) + "\n")
+		case v.message != "":
+			content.WriteString(warningStyle.Render(v.message))
+			content.WriteString("\n")
+		}
+		hints := "ctrl+s: save to the collection and send | ctrl+t: body/form | ctrl+f: attach a file | tab: next field | ←/→: method | esc: close"
+		switch {
+		case v.files.active:
+			hints = "↑/↓: select | enter: open/attach | ←: parent directory | esc: close"
+		case v.completing:
+			hints = "↑/↓: pick | tab/enter: complete | esc: close"
+		case v.graphQL():
+			hints = "ctrl+s: save to the collection and send | ctrl+space: complete | ctrl+r: reload schema | tab: next field | ←/→: method | esc: close"
+		}
+		content.WriteString(footerStyle.Render(hints))
+		return content.String()
+	}
+
+	var tabs []string
+	for i, name := range httpSectionNames {
+		label := fmt.Sprintf("%s (%d)", name, v.count(httpSection(i)))
+		if httpSection(i) == v.section {
+			tabs = append(tabs, selectedItemStyle.Render(label))
+		} else {
+			tabs = append(tabs, helpStyle.Render(label))
+		}
+	}
+	content.WriteString(strings.Join(tabs, helpStyle.Render(" | ")))
+	content.WriteString("\n")
+	var lines []string
+	if v.section == httpSaved {
+		for _, row := range v.rows() {
+			c := v.workspace.Collections[row.collection]
+			if row.request >= 0 {
+				r := c.Requests[row.request]
+				method := r.Method
+				if r.GraphQL != nil {
+					method = "GQL"
+				}
+				lines = append(lines, fmt.Sprintf("  %-7s %s", method, truncate(r.Label(), max(m.width-18, 40))))
+				continue
+			}
+			marker := "▾ "
+			if v.collapsed[c.Name] {
+				marker = "▸ "
+			}
+			lines = append(lines, descriptionStyle.Bold(true).Render(marker+c.Name)+helpStyle.Render(fmt.Sprintf(" %d", len(c.Requests))))
+		}
+	} else {
+		for _, e := range v.history {
+			method := e.Request.Method
+			if e.Request.GraphQL != nil {
+				method = "GQL"
+			}
+			line := fmt.Sprintf("%-7s %s", method, truncate(e.Request.URL, max(m.width-50, 30)))
+			meta := fmt.Sprintf(" %s · %s · %s", e.Outcome(), e.Duration().Round(time.Millisecond), e.Started.Local().Format("01-02 15:04"))
+			if e.Environment != "" {
+				meta += " · " + e.Environment
+			}
+			lines = append(lines, line+helpStyle.Render(meta))
+		}
+	}
+	cursor := v.cursors[v.section]
+	start := max(0, min(cursor-httpListRows/2, len(lines)-httpListRows))
+	end := min(start+httpListRows, len(lines))
+	content.WriteString(renderMCPList("", lines[start:end], cursor-start, true))
+	content.WriteString(v.response.View())
+	content.WriteString("\n")
+
+	switch {
+	case v.importing:
+		content.WriteString(v.path.View())
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render("enter: import the requests of a HAR file exported from browser devtools | esc: cancel"))
+		content.WriteString("\n")
+	case v.comparing:
+		row, _ := v.row()
+		content.WriteString(fmt.Sprintf("Compare %s in %s with %s\n", v.saved(row).Label(), v.env, selectedItemStyle.Render("◀ "+v.against+" ▶")))
+		content.WriteString(helpStyle.Render("enter: send in both and diff the responses | ←/→: environment | esc: cancel"))
+		content.WriteString("\n")
+	case v.deleting:
+		row, _ := v.row()
+		label := "the collection " + v.workspace.Collections[row.collection].Name + " and its requests"
+		if row.request >= 0 {
+			label = v.saved(row).Label()
+		}
+		content.WriteString(warningStyle.Render(fmt.Sprintf("Delete %s? %s to confirm", label, primaryKey(k.Confirm))))
+		content.WriteString("\n")
+	case v.sending:
+		content.WriteString(commandStyle.Render("⏳ Sending…"))
+		content.WriteString("\n")
+	case v.message != "":
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("requests/history", k.ToggleCategory), hint("send/fold", k.Enter), hint("edit", k.EditRequest), hint("new", k.NewRequest), hint("save", k.Promote), hint("environment", k.Environment), hint("compare environments", k.Compare), hint("delete", k.Delete), hint("clear cookies", k.Clean), hint("import HAR", k.Import), hint("export HAR", k.Export), "pgup/pgdn: scroll", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// installsFile records the outcome of the last install of each extension
+const installsFile = "installs.json"
+
+// installers detect the package manager of an extension by a file it
+// contains, in order of precedence: lockfiles pick the node package
+// manager before package.json falls back to npm
+var installers = []struct {
+	marker, manager, command string
+}{
+	{"pnpm-lock.yaml", "pnpm", "pnpm install"},
+	{"yarn.lock", "yarn", "yarn install"},
+	{"package.json", "npm", "npm install"},
+	{"pyproject.toml", "pip", "pip install -e ."},
+	{"setup.py", "pip", "pip install -e ."},
+	{"requirements.txt", "pip", "pip install -r requirements.txt"},
+	{"go.mod", "go", "go install ./..."},
+}
+
+// InstallRecord is the outcome of installing an extension
+type InstallRecord struct {
+	Manager   string    `json:"manager"`
+	Command   string    `json:"command"`
+	Installed bool      `json:"installed"`
+	Version   string    `json:"version,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Badge renders the install state in place of the declared status
+func (r InstallRecord) Badge() string {
+	if !r.Installed {
+		return "✘ install failed"
+	}
+	if r.Version != "" {
+		return "📦 installed " + r.Version
+	}
+	return "📦 installed"
+}
+
+// Summary describes the install for the detail view
+func (r InstallRecord) Summary() string {
+	if !r.Installed {
+		return fmt.Sprintf("Install with %s failed %s: %s", r.Manager, r.At.Format("2006-01-02 15:04"), r.Error)
+	}
+	version := ""
+	if r.Version != "" {
+		version = " " + r.Version
+	}
+	return fmt.Sprintf("Installed%s with %s %s", version, r.Manager, r.At.Format("2006-01-02 15:04"))
+}
+
+// DetectInstaller returns the package manager of the extension in dir
+// and the command that installs it
+func DetectInstaller(dir string) (manager, command string, err error) {
+	for _, installer := range installers {
+		if _, err := os.Stat(filepath.Join(dir, installer.marker)); err == nil {
+			if _, err := exec.LookPath(installer.manager); err != nil {
+				return "", "", fmt.Errorf("%s needs %s, which is not on PATH", filepath.Base(dir), installer.manager)
+			}
+			return installer.manager, installer.command, nil
+		}
+	}
+	return "", "", fmt.Errorf("no package.json, pyproject.toml, setup.py, requirements.txt or go.mod in %s", filepath.Base(dir))
+}
+
+// extensionVersion returns the version of an installed extension
+func extensionVersion(dir string) string {
+	return versionAt(dir, "")
+}
+
+// versionAt returns the version of the extension in dir at a git
+// revision, or in the working tree when rev is "": the version declared
+// by package.json or pyproject.toml, else the nearest git tag or commit,
+// which is all a go.mod has to offer
+func versionAt(dir, rev string) string {
+	read := func(name string) ([]byte, error) {
+		if rev == "" {
+			return os.ReadFile(filepath.Join(dir, name))
+		}
+		return exec.Command("git", "-C", dir, "show", rev+":"+name).Output()
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if data, err := read("package.json"); err == nil && json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+		return pkg.Version
+	}
+	if data, err := read("pyproject.toml"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			name, value, ok := strings.Cut(line, "=")
+			if ok && strings.TrimSpace(name) == "version" {
+				if version, _, err := parseTOMLString(strings.TrimSpace(value)); err == nil && version != "" {
+					return version
+				}
+			}
+		}
+	}
+	describe := []string{"-C", dir, "describe", "--tags", "--always"}
+	if rev == "" {
+		describe = append(describe, "--dirty")
+	} else {
+		describe = append(describe, rev)
+	}
+	out, err := exec.Command("git", describe...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// LoadInstalls returns the recorded installs by tool key
+func LoadInstalls() map[string]InstallRecord {
+	installs := map[string]InstallRecord{}
+	loadJSON(installsFile, &installs)
+	return installs
+}
+
+// RecordInstall persists the outcome of an install
+func RecordInstall(toolKey string, record InstallRecord) error {
+	installs := LoadInstalls()
+	installs[toolKey] = record
+	return saveJSON(installsFile, installs)
+}
+
+// installSelectedTool installs the extension of the selected tool with
+// its package manager, run in the extension's directory as its Dir. The install
+// runs as a job like any execution, so it is verified, confirmed,
+// snapshotted and streamed the same way.
+func (m *Model) installSelectedTool() tea.Cmd {
+	dir, ok := ExtensionDir(m.selectedTool)
+	if !ok {
+		m.statusMessage = "Not an extension, nothing to install"
+		return nil
+	}
+	manager, command, err := DetectInstaller(dir)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Cannot install: %v", err)
+		return nil
+	}
+	m.presetArgs = nil
+	m.pendingInstall = manager
+	return m.confirmAndRun(command, nil)
+}
+
+// finishInstall records the outcome of an install job
+func (m *Model) finishInstall(run *runningTool, err error) error {
+	record := InstallRecord{Manager: run.installer, Command: run.tool.Command, Installed: err == nil, At: time.Now()}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Version = extensionVersion(run.extensionDir)
+	}
+	m.installs[run.tool.Key()] = record
+	return RecordInstall(run.tool.Key(), record)
+}
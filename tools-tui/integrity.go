@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// integrityFile stores the last verified hash of each extension
+const integrityFile = "integrity.json"
+
+// checksumFiles are the published checksum manifests we look for
+var checksumFiles = []string{"SHA256SUMS", "SHA256SUMS.txt", "checksums.txt", "sha256sums.txt"}
+
+// hashSkipDirs are generated directories excluded from content hashes
+var hashSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, ".venv": true, "venv": true,
+	"__pycache__": true, "dist": true, "build": true, ".cache": true,
+}
+
+// IntegrityRecord is the persisted verification state of an extension
+type IntegrityRecord struct {
+	Hash       string    `json:"hash"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// IntegrityReport summarizes a verification run
+type IntegrityReport struct {
+	Dir          string
+	Hash         string
+	PreviousHash string
+	ChecksumFile string
+	Verified     int
+	Mismatches   []string
+	Signature    string
+	Changed      bool
+}
+
+// ExtensionDir returns the extension directory a tool runs in, by its
+// Dir or a cd in its command
+func ExtensionDir(tool *Tool) (string, bool) {
+	if strings.HasPrefix(filepath.ToSlash(tool.Dir), "extensions/") {
+		return tool.RunDir(), true
+	}
+	fields := strings.Fields(tool.Command)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "cd" && strings.HasPrefix(fields[i+1], "extensions/") {
+			return filepath.Join(tool.WorkDir(), fields[i+1]), true
+		}
+	}
+	return "", false
+}
+
+// HashDirectory computes a stable sha256 over the files in dir,
+// skipping dependency and build directories.
+func HashDirectory(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && hashSkipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, path := range files {
+		rel, _ := filepath.Rel(dir, path)
+		sum, err := hashFile(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s  %s\n", sum, filepath.ToSlash(rel))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile returns the hex sha256 of a single file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyExtension checks published checksums and signatures in dir and
+// compares the content hash with the previously recorded one.
+func VerifyExtension(dir string) (IntegrityReport, error) {
+	report := IntegrityReport{Dir: dir}
+
+	hash, err := HashDirectory(dir)
+	if err != nil {
+		return report, err
+	}
+	report.Hash = hash
+
+	for _, name := range checksumFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		report.ChecksumFile = name
+		verified, mismatches, err := verifyChecksumFile(dir, path)
+		if err != nil {
+			return report, err
+		}
+		report.Verified = verified
+		report.Mismatches = mismatches
+		report.Signature = verifySignature(path)
+		break
+	}
+
+	records := map[string]IntegrityRecord{}
+	if err := loadJSON(integrityFile, &records); err != nil {
+		return report, err
+	}
+	if previous, ok := records[filepath.Base(dir)]; ok {
+		report.PreviousHash = previous.Hash
+		report.Changed = previous.Hash != hash
+	}
+	return report, nil
+}
+
+// RecordExtensionHash stores the verified hash for an extension
+func RecordExtensionHash(dir, hash string) error {
+	records := map[string]IntegrityRecord{}
+	if err := loadJSON(integrityFile, &records); err != nil {
+		return err
+	}
+	records[filepath.Base(dir)] = IntegrityRecord{Hash: hash, VerifiedAt: time.Now()}
+	return saveJSON(integrityFile, records)
+}
+
+// verifyChecksumFile checks every "<sha256>  <file>" line of a manifest
+func verifyChecksumFile(dir, manifest string) (int, []string, error) {
+	f, err := os.Open(manifest)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	verified := 0
+	var mismatches []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || len(fields[0]) != 64 {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "*")
+		sum, err := hashFile(filepath.Join(dir, name))
+		if err != nil || !strings.EqualFold(sum, fields[0]) {
+			mismatches = append(mismatches, name)
+			continue
+		}
+		verified++
+	}
+	return verified, mismatches, scanner.Err()
+}
+
+// verifySignature checks a detached .asc/.sig signature with gpg
+func verifySignature(manifest string) string {
+	for _, ext := range []string{".asc", ".sig"} {
+		sig := manifest + ext
+		if _, err := os.Stat(sig); err != nil {
+			continue
+		}
+		gpg, err := exec.LookPath("gpg")
+		if err != nil {
+			return "present (gpg not installed, not checked)"
+		}
+		if out, err := exec.Command(gpg, "--verify", sig, manifest).CombinedOutput(); err != nil {
+			return "INVALID: " + strings.TrimSpace(string(out))
+		}
+		return "valid"
+	}
+	return "none published"
+}
+
+// Failed reports whether the report contains a hard verification failure
+func (r IntegrityReport) Failed() bool {
+	return len(r.Mismatches) > 0 || strings.HasPrefix(r.Signature, "INVALID")
+}
+
+// Summary renders the report as plain text for the detail view
+func (r IntegrityReport) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Content hash: %s\n", r.Hash)
+	if r.ChecksumFile == "" {
+		b.WriteString("Checksums: none published\n")
+	} else {
+		fmt.Fprintf(&b, "Checksums (%s): %d verified, %d mismatched\n", r.ChecksumFile, r.Verified, len(r.Mismatches))
+		for _, name := range r.Mismatches {
+			fmt.Fprintf(&b, "  ✗ %s\n", name)
+		}
+		fmt.Fprintf(&b, "Signature: %s\n", r.Signature)
+	}
+	if r.Changed {
+		fmt.Fprintf(&b, "⚠ CONTENT CHANGED since last verification (was %s)\n", r.PreviousHash)
+	}
+	return b.String()
+}
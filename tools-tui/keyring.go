@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringLookup reads a secret from the system keyring: the macOS
+// keychain via security(1), elsewhere the Secret Service via
+// secret-tool(1) from libsecret.
+func keyringLookup(service, account string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("no keyring entry for %s/%s (%s: %v)", service, account, cmd.Args[0], err)
+	}
+	secret := strings.TrimRight(string(out), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("keyring entry for %s/%s is empty", service, account)
+	}
+	return secret, nil
+}
+
+// keyringDelete removes a secret from the system keyring
+func keyringDelete(service, account string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "delete-generic-password", "-s", service, "-a", account)
+	} else {
+		cmd = exec.Command("secret-tool", "clear", "service", service, "account", account)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not delete %s/%s from the keyring (%s: %v) %s", service, account, cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// keyringStore saves a secret in the system keyring, replacing an
+// existing entry for the same service and account. The secret goes
+// through stdin, never the command line other users can list with ps:
+// security(1) reads the command from stdin in interactive mode, with
+// the secret hex-encoded so it needs no quoting.
+func keyringStore(service, account, secret string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		if strings.ContainsAny(service+account, "\"'\\\r\n") {
+			return fmt.Errorf("could not store %s/%s in the keyring: quotes, backslashes and line breaks cannot be passed to security", service, account)
+		}
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -X %s\n", service, account, hex.EncodeToString([]byte(secret))))
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not store %s/%s in the keyring (%s: %v) %s", service, account, cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keysFile remaps actions to keys; it takes precedence over the "keys"
+// of config.json
+const keysFile = "keys.toml"
+
+// keysPath returns the location of keys.toml
+func keysPath() string {
+	return filepath.Join(ConfigDir(), keysFile)
+}
+
+// LoadKeyFile reads the bindings of keys.toml by action name. A missing
+// file returns nil without error.
+func LoadKeyFile() (map[string][]string, error) {
+	data, err := os.ReadFile(keysPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := parseKeysTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", keysFile, err)
+	}
+	return bindings, nil
+}
+
+// parseKeysTOML parses the subset of TOML keys.toml uses: comments, an
+// optional [keys] table and lines of the form
+//
+//	action = "key"
+//	action = ["key", "other key"]
+func parseKeysTOML(data string) (map[string][]string, error) {
+	bindings := map[string][]string{}
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if table := strings.TrimSpace(stripComment(line)); table != "[keys]" {
+				return nil, fmt.Errorf("line %d: unknown table %s, only [keys] is supported", i+1, table)
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected action = \"key\"", i+1)
+		}
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		keys, err := parseKeysValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", i+1, name, err)
+		}
+		if _, dup := bindings[name]; dup {
+			return nil, fmt.Errorf("line %d: %s is bound twice", i+1, name)
+		}
+		bindings[name] = keys
+	}
+	return bindings, nil
+}
+
+// parseKeysValue parses a string or an array of strings followed by an
+// optional comment
+func parseKeysValue(value string) ([]string, error) {
+	array := strings.HasPrefix(value, "[")
+	rest := strings.TrimPrefix(value, "[")
+	var keys []string
+	for {
+		rest = strings.TrimSpace(rest)
+		if array && strings.HasPrefix(rest, "]") {
+			rest = rest[1:]
+			break
+		}
+		s, after, err := parseTOMLString(rest)
+		if err != nil {
+			return nil, err
+		}
+		if s == "" {
+			return nil, fmt.Errorf("empty key")
+		}
+		keys = append(keys, s)
+		rest = strings.TrimSpace(after)
+		if !array {
+			break
+		}
+		if strings.HasPrefix(rest, ",") {
+			rest = rest[1:]
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, fmt.Errorf("expected , or ] after %q", s)
+		}
+	}
+	if trailing := strings.TrimSpace(stripComment(rest)); trailing != "" {
+		return nil, fmt.Errorf("unexpected %q", trailing)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys given")
+	}
+	return keys, nil
+}
+
+// parseTOMLString parses a basic "..." or literal '...' string at the
+// start of s and returns it with the remaining text
+func parseTOMLString(s string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case strings.HasPrefix(s, `"`):
+		for end := 1; end < len(s); end++ {
+			switch s[end] {
+			case '\\':
+				end++
+			case '"':
+				unquoted, err := strconv.Unquote(s[:end+1])
+				return unquoted, s[end+1:], err
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+	}
+	return "", "", fmt.Errorf("expected a quoted key, got %q", s)
+}
+
+// stripComment removes a trailing # comment
+func stripComment(s string) string {
+	if i := strings.Index(s, "#"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// globalKeyGroups hold actions whose keys work on every screen, so they
+// conflict with actions of any group
+var globalKeyGroups = map[string]bool{"Navigation": true, "General": true}
+
+// keysConflict reports whether two actions bound to the same key can
+// get in each other's way: they belong to the same group, one of them
+// works on every screen, or both are dispatched on the tool screen
+func keysConflict(a, b action) bool {
+	return a.group == b.group || globalKeyGroups[a.group] || globalKeyGroups[b.group] || (a.run != nil && b.run != nil)
+}
+
+// resolveKeyConflicts restores the default keys of remapped actions
+// that collide with another action and describes each collision.
+// Collisions between default bindings are intended, such as "s" sorting
+// the disk footprint and starting an MCP server, and are not reported.
+func (k *KeyMap) resolveKeyConflicts() []string {
+	defaults := DefaultKeyMap()
+	remapped := func(a action) bool {
+		return strings.Join(a.binding(k).Keys(), " ") != strings.Join(a.binding(&defaults).Keys(), " ")
+	}
+	var conflicts []string
+	revert := map[string]bool{}
+	for i, a := range actions {
+		for _, b := range actions[i+1:] {
+			if (!remapped(a) && !remapped(b)) || !keysConflict(a, b) {
+				continue
+			}
+			for _, shared := range a.binding(k).Keys() {
+				if !containsKey(b.binding(k).Keys(), shared) {
+					continue
+				}
+				conflicts = append(conflicts, fmt.Sprintf("%s is bound to both %s and %s", keyLabel(shared), a.name, b.name))
+				for _, c := range []action{a, b} {
+					if remapped(c) {
+						revert[c.name] = true
+					}
+				}
+				break
+			}
+		}
+	}
+	for _, a := range actions {
+		if revert[a.name] {
+			*a.binding(k) = *a.binding(&defaults)
+		}
+	}
+	return conflicts
+}
+
+// containsKey reports whether keys contains k
+func containsKey(keys []string, k string) bool {
+	for _, key := range keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// keyLabels are the symbols shown for named keys
+var keyLabels = map[string]string{"up": "↑", "down": "↓", "left": "←", "right": "→", " ": "space"}
+
+// keyLabel returns how a key is shown in hints
+func keyLabel(k string) string {
+	if label, ok := keyLabels[k]; ok {
+		return label
+	}
+	return k
+}
+
+// hint renders a footer hint naming the first key of each binding, so
+// remapped keys show up wherever the action is offered
+func hint(label string, bindings ...key.Binding) string {
+	keys := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		keys = append(keys, primaryKey(b))
+	}
+	return strings.Join(keys, "/") + ": " + label
+}
+
+// primaryKey returns the first key of a binding as hints show it
+func primaryKey(b key.Binding) string {
+	if bound := b.Keys(); len(bound) > 0 {
+		return keyLabel(bound[0])
+	}
+	return ""
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// layoutFile remembers how the tool screen is split
+const layoutFile = "layout.json"
+
+// minSplitWidth is the narrowest terminal the tool screen is split on;
+// narrower ones show the list alone
+const minSplitWidth = 100
+
+// Bounds and step of the share of the width given to the list, in
+// percent
+const (
+	defaultListPercent = 45
+	minListPercent     = 25
+	maxListPercent     = 75
+	listPercentStep    = 5
+)
+
+// layoutState is how the tool screen is laid out: on wide terminals the
+// list on the left and the details and output of the tool under the
+// cursor on the right
+type layoutState struct {
+	// Single shows the list alone whatever the width
+	Single bool `json:"single,omitempty"`
+	// ListPercent is the share of the width given to the list
+	ListPercent int `json:"list_percent,omitempty"`
+}
+
+// LoadLayout reads the saved layout, the default split when none is
+func LoadLayout() layoutState {
+	var l layoutState
+	loadJSON(layoutFile, &l)
+	if l.ListPercent < minListPercent || l.ListPercent > maxListPercent {
+		l.ListPercent = defaultListPercent
+	}
+	return l
+}
+
+// SaveLayout remembers the layout for the next start
+func SaveLayout(l layoutState) error {
+	return saveJSON(layoutFile, l)
+}
+
+// split reports whether the tool list is shown next to the preview
+func (m Model) split() bool {
+	return !m.layout.Single && m.width >= minSplitWidth
+}
+
+// toggleSplit turns the split on or off and describes the result
+func (m *Model) toggleSplit() string {
+	m.layout.Single = !m.layout.Single
+	message := "List and preview side by side"
+	switch {
+	case m.layout.Single:
+		message = "List alone"
+	case m.width < minSplitWidth:
+		message = fmt.Sprintf("The list is shown next to the preview from %d columns, this terminal has %d", minSplitWidth, m.width)
+	}
+	if err := SaveLayout(m.layout); err != nil {
+		message += " (not saved: " + err.Error() + ")"
+	}
+	return message
+}
+
+// resizeSplit gives the list delta more percent of the width, within
+// bounds, and describes the result
+func (m *Model) resizeSplit(delta int) string {
+	m.layout.ListPercent = min(max(m.layout.ListPercent+delta, minListPercent), maxListPercent)
+	message := fmt.Sprintf("List %d%% · preview %d%%", m.layout.ListPercent, 100-m.layout.ListPercent)
+	if err := SaveLayout(m.layout); err != nil {
+		message += " (not saved: " + err.Error() + ")"
+	}
+	return message
+}
+
+// renderSplit lays out the tool list, scrolled to keep the cursor's
+// line visible, next to the preview of the tool under the cursor
+func (m Model) renderSplit(list string, cursor, height int) string {
+	listWidth := m.width * m.layout.ListPercent / 100
+	previewWidth := m.width - listWidth - 3
+	lines := strings.Split(strings.TrimRight(list, "\n"), "\n")
+	start := 0
+	if cursor >= 0 {
+		start = max(0, min(cursor-height/2, len(lines)-height))
+	}
+	lines = lines[start:min(start+height, len(lines))]
+	// cut long lines rather than wrap them, which would scroll the
+	// cursor out of sight, then pad them so the preview stays put
+	left := lipgloss.NewStyle().MaxWidth(listWidth).Render(strings.Join(lines, "\n"))
+	left = lipgloss.NewStyle().Width(listWidth).Render(left)
+	box := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, false, true).BorderForeground(helpStyle.GetForeground()).PaddingLeft(1).MaxHeight(height)
+	right := box.Render(m.renderPreview(previewWidth, height))
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, " ", right)
+}
+
+// renderPreview describes the tool under the cursor with the end of the
+// output of its latest run, or the category when it is folded
+func (m Model) renderPreview(width, height int) string {
+	wrap := lipgloss.NewStyle().Width(width).MaxWidth(width)
+	if m.currentCat >= len(m.categories) {
+		return wrap.Render(helpStyle.Render("No tools"))
+	}
+	category := m.categories[m.currentCat]
+	tool := m.cursorTool()
+	if tool == nil || !category.Active {
+		var b strings.Builder
+		b.WriteString(titleStyle.Render(category.Name))
+		b.WriteString("\n\n")
+		b.WriteString(category.Purpose)
+		b.WriteString("\n\n")
+		for _, t := range category.Tools {
+			b.WriteString(fmt.Sprintf("%s %s %s\n", featureStyle.Render("•"), t.Name, helpStyle.Render(m.statusBadge(&t))))
+		}
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(hint("expand", m.keys.ToggleCategory)))
+		return wrap.Render(b.String())
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, titleStyle.Render(tool.Name), "  ", statusStyle.Render(m.statusBadge(tool))))
+	b.WriteString("\n\n")
+	b.WriteString(tool.Purpose)
+	b.WriteString("\n\n")
+	if tool.Description != "" {
+		b.WriteString(descriptionStyle.Render(tool.Description))
+		b.WriteString("\n\n")
+	}
+	b.WriteString(descriptionStyle.Bold(true).Render("Command: "))
+	b.WriteString(commandStyle.Render(tool.Command))
+	b.WriteString("\n")
+	if runs := tool.RunsIn(m.secretIndex); runs != "" {
+		b.WriteString(descriptionStyle.Bold(true).Render("Runs: "))
+		b.WriteString(runs)
+		b.WriteString("\n")
+	}
+	b.WriteString(descriptionStyle.Bold(true).Render("Trust: "))
+	b.WriteString(tool.Trust.Label())
+	b.WriteString("\n")
+	if reason := tool.UnsupportedReason(); reason != "" {
+		b.WriteString(warningStyle.Render("⛔ " + reason))
+		b.WriteString("\n")
+	}
+	if tool.Annotation != "" {
+		b.WriteString(helpStyle.Render("📌 " + tool.Annotation))
+		b.WriteString("\n")
+	}
+	if len(tool.Features) > 0 {
+		b.WriteString("\n")
+		for _, feature := range tool.Features {
+			b.WriteString(fmt.Sprintf("%s %s\n", featureStyle.Render("•"), feature))
+		}
+	}
+	details := wrap.Render(strings.TrimRight(b.String(), "\n"))
+
+	// the latest run of the tool in this session fills the rest
+	var job *runningTool
+	for i := len(m.jobs) - 1; i >= 0 && job == nil; i-- {
+		if m.jobs[i].tool.Key() == tool.Key() {
+			job = m.jobs[i]
+		}
+	}
+	rows := height - lipgloss.Height(details) - 3
+	var output string
+	switch {
+	case job == nil:
+		output = helpStyle.Render("Not run in this session · " + hint("details and run", m.keys.Enter))
+	case rows < 1:
+	default:
+		state := renderRunning(job)
+		if job.done {
+			state = featureStyle.Render(fmt.Sprintf("✓ Last run %s", job.finished.Format("15:04:05")))
+			if job.err != nil {
+				state = warningStyle.Render(fmt.Sprintf("✗ Last run %s: %v", job.finished.Format("15:04:05"), job.err))
+			}
+		}
+		lines := strings.Split(strings.TrimRight(job.display(), "\n"), "\n")
+		if len(lines) > rows {
+			lines = lines[len(lines)-rows:]
+		}
+		for i, line := range lines {
+			lines[i] = truncate(line, width)
+		}
+		output = wrap.Render(state) + "\n" + descriptionStyle.Render(strings.Join(lines, "\n"))
+	}
+	return details + "\n\n" + output
+}
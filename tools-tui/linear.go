@@ -0,0 +1,729 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The API key of the Linear panel is kept in the system keyring under
+// this service and account
+const (
+	linearKeyringService = "tools-tui-linear"
+	linearKeyringAccount = "api-key"
+)
+
+// linearAPI is the GraphQL endpoint of Linear
+const linearAPI = "https://api.linear.app/graphql"
+
+// linearTimeout bounds a request of the Linear panel
+const linearTimeout = 20 * time.Second
+
+// reviewFindingsHeader starts the findings printed by the code reviewer
+const reviewFindingsHeader = "Code review issues found:"
+
+// LinearConfig configures the Linear panel
+type LinearConfig struct {
+	// Team is the key of the team new issues go to, such as ENG; the
+	// selected issue's team when empty
+	Team string `json:"team,omitempty"`
+}
+
+// linearToken returns the API key and where it was found:
+// $LINEAR_API_KEY, the keyring, the secrets store, or Bitwarden's
+// linear_token item like the Linear Manager tool
+func linearToken() (string, string) {
+	if token := os.Getenv("LINEAR_API_KEY"); token != "" {
+		return token, "$LINEAR_API_KEY"
+	}
+	if token, err := keyringLookup(linearKeyringService, linearKeyringAccount); err == nil {
+		return token, "keyring"
+	}
+	if token, err := GetSecret("linear"); err == nil {
+		return token, "secrets store"
+	}
+	if _, err := exec.LookPath("bw"); err == nil {
+		out, err := exec.Command("bw", "get", "password", "linear_token", "--nointeraction").Output()
+		if token := strings.TrimSpace(string(out)); err == nil && token != "" {
+			return token, "Bitwarden"
+		}
+	}
+	return "", ""
+}
+
+// LinearClient calls the GraphQL API of Linear
+type LinearClient struct {
+	API   string
+	Token string
+	HTTP  *http.Client
+}
+
+// newLinearClient returns a client authenticating with token
+func newLinearClient(token string) *LinearClient {
+	return &LinearClient{API: linearAPI, Token: token, HTTP: &http.Client{Timeout: linearTimeout}}
+}
+
+// query runs a GraphQL query or mutation and decodes its data into out
+func (c *LinearClient) query(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.API, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// personal API keys are sent as they are, OAuth tokens as bearer
+	if strings.HasPrefix(c.Token, "lin_api_") {
+		req.Header.Set("Authorization", c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && resp.StatusCode < 300 {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("Linear API: %s", result.Errors[0].Message)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Linear API returned %s", resp.Status)
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// linearState is a workflow state of a team
+type linearState struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Position float64 `json:"position"`
+}
+
+// linearTeam is a team and its workflow states
+type linearTeam struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	States struct {
+		Nodes []linearState `json:"nodes"`
+	} `json:"states"`
+}
+
+// linearIssue is an issue as the panel lists it
+type linearIssue struct {
+	ID            string      `json:"id"`
+	Identifier    string      `json:"identifier"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description"`
+	URL           string      `json:"url"`
+	PriorityLabel string      `json:"priorityLabel"`
+	State         linearState `json:"state"`
+	Team          struct {
+		ID string `json:"id"`
+	} `json:"team"`
+}
+
+// linearIssueFields are the fields of linearIssue in a query
+const linearIssueFields = `id identifier title description url priorityLabel state { id name type position } team { id }`
+
+// linearBoard is what the panel shows: the viewer, their open issues and
+// the teams they can file issues in
+type linearBoard struct {
+	Viewer struct {
+		ID             string `json:"id"`
+		Name           string `json:"name"`
+		AssignedIssues struct {
+			Nodes []linearIssue `json:"nodes"`
+		} `json:"assignedIssues"`
+	} `json:"viewer"`
+	Teams struct {
+		Nodes []linearTeam `json:"nodes"`
+	} `json:"teams"`
+}
+
+// Board lists the open issues assigned to the viewer and the teams
+func (c *LinearClient) Board(ctx context.Context) (linearBoard, error) {
+	var board linearBoard
+	err := c.query(ctx, `query {
+  viewer {
+    id name
+    assignedIssues(first: 100, orderBy: updatedAt, filter: { state: { type: { nin: ["completed", "canceled"] } } }) {
+      nodes { `+linearIssueFields+` }
+    }
+  }
+  teams(first: 100) { nodes { id key name states { nodes { id name type position } } } }
+}`, nil, &board)
+	for i := range board.Teams.Nodes {
+		states := board.Teams.Nodes[i].States.Nodes
+		sort.Slice(states, func(a, b int) bool { return states[a].Position < states[b].Position })
+	}
+	return board, err
+}
+
+// SetState moves an issue to a workflow state
+func (c *LinearClient) SetState(ctx context.Context, issueID, stateID string) (linearIssue, error) {
+	var result struct {
+		IssueUpdate struct {
+			Issue linearIssue `json:"issue"`
+		} `json:"issueUpdate"`
+	}
+	err := c.query(ctx, `mutation($id: String!, $stateId: String!) {
+  issueUpdate(id: $id, input: { stateId: $stateId }) { success issue { `+linearIssueFields+` } }
+}`, map[string]interface{}{"id": issueID, "stateId": stateID}, &result)
+	return result.IssueUpdate.Issue, err
+}
+
+// CreateIssue files an issue in a team, assigned to assignee unless empty
+func (c *LinearClient) CreateIssue(ctx context.Context, teamID, assignee, title, description string) (linearIssue, error) {
+	input := map[string]interface{}{"teamId": teamID, "title": title, "description": description}
+	if assignee != "" {
+		input["assigneeId"] = assignee
+	}
+	var result struct {
+		IssueCreate struct {
+			Issue linearIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	err := c.query(ctx, `mutation($input: IssueCreateInput!) {
+  issueCreate(input: $input) { success issue { `+linearIssueFields+` } }
+}`, map[string]interface{}{"input": input}, &result)
+	return result.IssueCreate.Issue, err
+}
+
+// reviewFindings returns the findings of code reviewer output, or nil
+func reviewFindings(output string) []string {
+	_, after, found := strings.Cut(plainText(output), reviewFindingsHeader)
+	if !found {
+		return nil
+	}
+	var findings []string
+	for _, line := range strings.Split(after, "\n") {
+		if finding, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// issueDraft returns the title and description of an issue about a
+// run: its code review findings as a checklist, else its output
+func issueDraft(toolName, command, output string) (string, string) {
+	findings := reviewFindings(output)
+	if len(findings) == 0 {
+		return toolName + ": ", issueOutput(toolName, command, output)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found by `%s`:\n\n", command)
+	for _, finding := range findings {
+		fmt.Fprintf(&b, "- [ ] %s\n", finding)
+	}
+	return fmt.Sprintf("Code review: %d findings", len(findings)), b.String()
+}
+
+// linearView holds the state of the Linear screen
+type linearView struct {
+	client *LinearClient
+	// source tells where the API key came from
+	source   string
+	viewer   string
+	viewerID string
+	issues   []linearIssue
+	teams    []linearTeam
+	cursor   int
+	body     viewport.Model
+	// picking shows the workflow states of the selected issue's team
+	picking bool
+	state   int
+	form    linearForm
+	token   textinput.Model
+	// askToken asks for an API key to store in the keyring
+	askToken bool
+	busy     string
+	message  string
+}
+
+// linearForm asks for the team, title and description of a new issue
+type linearForm struct {
+	active    bool
+	team      int
+	title     textinput.Model
+	body      textarea.Model
+	focusBody bool
+	err       string
+}
+
+// linearBoardMsg carries the issues and teams
+type linearBoardMsg struct {
+	board linearBoard
+	err   error
+}
+
+// linearIssueMsg carries an issue that was created or moved
+type linearIssueMsg struct {
+	issue   linearIssue
+	created bool
+	err     error
+}
+
+// linearTokenMsg reports the outcome of storing an API key
+type linearTokenMsg struct {
+	viewer string
+	err    error
+}
+
+// loadLinearCmd lists the viewer's issues and the teams
+func loadLinearCmd(client *LinearClient) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), linearTimeout)
+		defer cancel()
+		board, err := client.Board(ctx)
+		return linearBoardMsg{board: board, err: err}
+	}
+}
+
+// setLinearStateCmd moves an issue to a workflow state
+func setLinearStateCmd(client *LinearClient, issueID, stateID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), linearTimeout)
+		defer cancel()
+		issue, err := client.SetState(ctx, issueID, stateID)
+		return linearIssueMsg{issue: issue, err: err}
+	}
+}
+
+// createLinearIssueCmd files an issue
+func createLinearIssueCmd(client *LinearClient, teamID, assignee, title, description string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), linearTimeout)
+		defer cancel()
+		issue, err := client.CreateIssue(ctx, teamID, assignee, title, description)
+		return linearIssueMsg{issue: issue, created: true, err: err}
+	}
+}
+
+// storeLinearTokenCmd checks an API key and stores it in the keyring
+func storeLinearTokenCmd(token string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), linearTimeout)
+		defer cancel()
+		var result struct {
+			Viewer struct {
+				Name string `json:"name"`
+			} `json:"viewer"`
+		}
+		if err := newLinearClient(token).query(ctx, `query { viewer { name } }`, nil, &result); err != nil {
+			return linearTokenMsg{err: err}
+		}
+		return linearTokenMsg{viewer: result.Viewer.Name, err: keyringStore(linearKeyringService, linearKeyringAccount, token)}
+	}
+}
+
+// openLinear shows the open issues assigned to the user. From the
+// details of a tool with output the issue form opens with the output,
+// or the code review findings in it, filled in.
+func (m *Model) openLinear() tea.Cmd {
+	v := &m.linear
+	token, source := linearToken()
+	*v = linearView{client: newLinearClient(token), source: source}
+	v.body = viewport.New(max(m.width-4, 20), max(m.height-22, 5))
+	m.screen = screenLinear
+	var cmd tea.Cmd
+	if hasOutput(*m) {
+		cmd = m.openLinearForm(issueDraft(m.selectedTool.Name, m.selectedTool.Command, m.commandOutput))
+	}
+	if token == "" {
+		v.message = "No API key: set $LINEAR_API_KEY or press " + primaryKey(m.keys.StoreToken) + " to store one in the keyring"
+		return cmd
+	}
+	v.busy = "Listing your issues…"
+	return tea.Batch(cmd, loadLinearCmd(v.client))
+}
+
+// openLinearForm asks for a new issue, starting with title and body
+func (m *Model) openLinearForm(title, body string) tea.Cmd {
+	f := linearForm{active: true, title: newTextInput(), body: newTextArea()}
+	f.title.Prompt = ""
+	f.title.CharLimit = 256
+	f.title.Width = max(m.width-16, 20)
+	f.title.SetValue(title)
+	f.body.CharLimit = 0
+	f.body.SetWidth(max(m.width-4, 20))
+	f.body.SetHeight(max(m.height-17, 5))
+	f.body.SetValue(body)
+	f.body.Blur()
+	m.linear.form = f
+	m.linear.form.team = m.linear.defaultTeam(m.linearConfig)
+	return m.linear.form.title.Focus()
+}
+
+// defaultTeam returns the index of the team new issues go to: the
+// configured one, else the selected issue's
+func (v linearView) defaultTeam(cfg *LinearConfig) int {
+	for i, team := range v.teams {
+		if cfg != nil && strings.EqualFold(team.Key, cfg.Team) {
+			return i
+		}
+	}
+	if issue := v.selectedIssue(); issue != nil {
+		if i := v.teamIndex(issue.Team.ID); i >= 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// teamIndex returns the index of the team with id, or -1
+func (v linearView) teamIndex(id string) int {
+	for i, team := range v.teams {
+		if team.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectedIssue returns the issue under the cursor
+func (v linearView) selectedIssue() *linearIssue {
+	if v.cursor < len(v.issues) {
+		return &v.issues[v.cursor]
+	}
+	return nil
+}
+
+// states returns the workflow states the selected issue can move to
+func (v linearView) states() []linearState {
+	issue := v.selectedIssue()
+	if issue == nil {
+		return nil
+	}
+	if i := v.teamIndex(issue.Team.ID); i >= 0 {
+		return v.teams[i].States.Nodes
+	}
+	return nil
+}
+
+// showIssue renders the description of the selected issue
+func (v *linearView) showIssue() {
+	issue := v.selectedIssue()
+	if issue == nil {
+		v.body.SetContent("")
+		return
+	}
+	description := strings.TrimSpace(issue.Description)
+	if description == "" {
+		description = "_No description._"
+	}
+	v.body.SetContent(renderMarkdown(fmt.Sprintf("# %s %s\n\n%s · %s · %s\n\n%s", issue.Identifier, issue.Title, issue.State.Name, issue.PriorityLabel, issue.URL, description)))
+	v.body.GotoTop()
+}
+
+// updateLinearForm handles keys while an issue is written: tab moves
+// between title and description, ctrl+t picks the next team, ctrl+a
+// attaches the latest output and ctrl+s creates the issue
+func (m Model) updateLinearForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.linear
+	f := &v.form
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.active = false
+		return m, nil
+	case tea.KeyTab, tea.KeyShiftTab:
+		f.focusBody = !f.focusBody
+		if f.focusBody {
+			f.title.Blur()
+			return m, f.body.Focus()
+		}
+		f.body.Blur()
+		return m, f.title.Focus()
+	case tea.KeyCtrlT:
+		if len(v.teams) > 0 {
+			f.team = (f.team + 1) % len(v.teams)
+		}
+		return m, nil
+	case tea.KeyCtrlA:
+		name, command, output, ok := m.latestOutput()
+		if !ok {
+			f.err = "No finished job with output to attach"
+			return m, nil
+		}
+		_, description := issueDraft(name, command, output)
+		f.body.SetValue(strings.TrimRight(f.body.Value(), "\n") + "\n\n" + description)
+		return m, nil
+	case tea.KeyCtrlS:
+		title := strings.TrimSpace(f.title.Value())
+		switch {
+		case title == "":
+			f.err = "The issue needs a title"
+		case f.team >= len(v.teams):
+			f.err = "No team to file the issue in; refresh once the API key works"
+		default:
+			f.active = false
+			v.busy = "Creating the issue…"
+			return m, createLinearIssueCmd(v.client, v.teams[f.team].ID, v.viewerID, title, f.body.Value())
+		}
+		return m, nil
+	case tea.KeyEnter:
+		if !f.focusBody {
+			f.focusBody = true
+			f.title.Blur()
+			return m, f.body.Focus()
+		}
+	}
+	var cmd tea.Cmd
+	if f.focusBody {
+		f.body, cmd = f.body.Update(msg)
+	} else {
+		f.title, cmd = f.title.Update(msg)
+	}
+	f.err = ""
+	return m, cmd
+}
+
+// updateLinear handles input and responses on the Linear screen
+func (m Model) updateLinear(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.linear
+	switch msg := msg.(type) {
+	case linearBoardMsg:
+		v.busy = ""
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			return m, nil
+		}
+		v.viewer, v.viewerID = msg.board.Viewer.Name, msg.board.Viewer.ID
+		v.issues, v.teams = msg.board.Viewer.AssignedIssues.Nodes, msg.board.Teams.Nodes
+		v.cursor = 0
+		if v.form.active {
+			v.form.team = v.defaultTeam(m.linearConfig)
+		}
+		v.message = fmt.Sprintf("%d open issues assigned to you", len(v.issues))
+		v.showIssue()
+		return m, nil
+	case linearIssueMsg:
+		v.busy = ""
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			v.form.active = msg.created
+			return m, nil
+		}
+		if msg.created {
+			v.issues = append([]linearIssue{msg.issue}, v.issues...)
+			v.cursor = 0
+			v.message = fmt.Sprintf("Created %s %s", msg.issue.Identifier, msg.issue.URL)
+		} else {
+			for i := range v.issues {
+				if v.issues[i].ID == msg.issue.ID {
+					v.issues[i] = msg.issue
+				}
+			}
+			v.message = fmt.Sprintf("Moved %s to %s", msg.issue.Identifier, msg.issue.State.Name)
+		}
+		v.showIssue()
+		return m, nil
+	case linearTokenMsg:
+		v.busy = ""
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			return m, nil
+		}
+		v.client.Token, _ = keyringLookup(linearKeyringService, linearKeyringAccount)
+		v.source = "keyring"
+		v.message = "Stored the API key of " + msg.viewer + " in the keyring"
+		v.busy = "Listing your issues…"
+		return m, loadLinearCmd(v.client)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if v.form.active {
+		return m.updateLinearForm(keyMsg)
+	}
+	if v.askToken {
+		switch keyMsg.Type {
+		case tea.KeyEsc:
+			v.askToken = false
+		case tea.KeyEnter:
+			v.askToken = false
+			if token := strings.TrimSpace(v.token.Value()); token != "" {
+				v.busy = "Checking the API key…"
+				return m, storeLinearTokenCmd(token)
+			}
+		default:
+			var cmd tea.Cmd
+			v.token, cmd = v.token.Update(keyMsg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	if v.picking {
+		states := v.states()
+		switch {
+		case key.Matches(keyMsg, m.keys.Back):
+			v.picking = false
+		case key.Matches(keyMsg, m.keys.Up):
+			if v.state > 0 {
+				v.state--
+			}
+		case key.Matches(keyMsg, m.keys.Down):
+			if v.state < len(states)-1 {
+				v.state++
+			}
+		case key.Matches(keyMsg, m.keys.Enter):
+			v.picking = false
+			if v.state < len(states) {
+				v.busy = "Moving " + v.selectedIssue().Identifier + " to " + states[v.state].Name + "…"
+				return m, setLinearStateCmd(v.client, v.selectedIssue().ID, states[v.state].ID)
+			}
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+			v.showIssue()
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.issues)-1 {
+			v.cursor++
+			v.showIssue()
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		if v.client.Token == "" || v.busy != "" {
+			return m, nil
+		}
+		v.busy = "Listing your issues…"
+		return m, loadLinearCmd(v.client)
+	case key.Matches(keyMsg, m.keys.ChangeState):
+		issue := v.selectedIssue()
+		if issue == nil || v.busy != "" || len(v.states()) == 0 {
+			return m, nil
+		}
+		v.picking, v.state = true, 0
+		for i, state := range v.states() {
+			if state.ID == issue.State.ID {
+				v.state = i
+			}
+		}
+	case key.Matches(keyMsg, m.keys.NewIssue):
+		return m, m.openLinearForm("", "")
+	case key.Matches(keyMsg, m.keys.GraphQL):
+		return m, m.openGraphQLConsole(linearAPI, "query {\n  viewer {\n    id\n    name\n  }\n}")
+	case key.Matches(keyMsg, m.keys.StoreToken):
+		v.token = newTextInput()
+		v.token.Prompt = "API key: "
+		v.token.EchoMode = textinput.EchoPassword
+		v.token.Width = 50
+		v.askToken = true
+		return m, v.token.Focus()
+	default:
+		var cmd tea.Cmd
+		v.body, cmd = v.body.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// renderLinear renders the assigned issues, the selected one's
+// description, the state picker and the issue form
+func (m Model) renderLinear() string {
+	v := m.linear
+	var content strings.Builder
+	title := titleStyle.Render("📐 Linear")
+	summary := "not signed in"
+	if v.viewer != "" {
+		summary = "signed in as " + v.viewer + " (" + v.source + ")"
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	if v.form.active {
+		f := v.form
+		team := "no team"
+		if f.team < len(v.teams) {
+			team = v.teams[f.team].Key + " " + v.teams[f.team].Name
+		}
+		content.WriteString(descriptionStyle.Bold(true).Render("New issue in " + team))
+		content.WriteString("\n")
+		content.WriteString("Title: " + f.title.View())
+		content.WriteString("\n\n")
+		content.WriteString(f.body.View())
+		content.WriteString("\n")
+		if f.err != "" {
+			content.WriteString(warningStyle.Render(f.err))
+			content.WriteString("\n")
+		}
+		content.WriteString(footerStyle.Render("ctrl+s: create | tab: title/description | ctrl+t: next team | ctrl+a: attach latest output | esc: cancel"))
+		return content.String()
+	}
+
+	var lines []string
+	for _, issue := range v.issues {
+		lines = append(lines, fmt.Sprintf("%-9s %s", issue.Identifier, truncate(issue.Title, 60))+helpStyle.Render(" "+issue.State.Name+" · "+issue.PriorityLabel))
+	}
+	left := renderMCPList(fmt.Sprintf("Assigned to me (%d)", len(v.issues)), lines, v.cursor, !v.picking)
+	if v.picking {
+		var states []string
+		for _, state := range v.states() {
+			states = append(states, state.Name+helpStyle.Render(" "+state.Type))
+		}
+		left = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(80).Render(left), "  ", renderMCPList("Move to", states, v.state, true))
+	}
+	content.WriteString(left)
+	content.WriteString("\n")
+	content.WriteString(v.body.View())
+	content.WriteString("\n")
+
+	if v.askToken {
+		content.WriteString(v.token.View())
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render("enter: check and store in the keyring | esc: cancel"))
+		content.WriteString("\n")
+	}
+	if v.busy != "" {
+		content.WriteString(commandStyle.Render("⏳ " + v.busy))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	if v.picking {
+		content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("move", k.Enter), hint("cancel", k.Back)}, " | ")))
+		return content.String()
+	}
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("change state", k.ChangeState), hint("new issue", k.NewIssue), hint("refresh", k.Refresh), hint("store API key", k.StoreToken), hint("GraphQL console", k.GraphQL), "pgup/pgdn: scroll", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// terminalSequence matches the escape sequences programs write to a
+// terminal: CSI sequences such as colours and cursor movement, OSC
+// sequences such as titles and links, and two-character escapes
+var terminalSequence = regexp.MustCompile("\x1b(\\[[0-?]*[ -/]*[@-~]|\\][^\x07\x1b]*(\x07|\x1b\\\\)?|[@-Z\\\\-_])")
+
+// unsafeFileChars are replaced in the names of exported logs
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// cleanTerminalOutput prepares output written for a terminal for the
+// log viewer: colours are kept, other escape sequences and control
+// characters are dropped, backspaces erase and a carriage return
+// redraws its line the way progress bars expect
+func cleanTerminalOutput(output string) string {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	output = terminalSequence.ReplaceAllStringFunc(output, func(seq string) string {
+		if strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+			return seq
+		}
+		return ""
+	})
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "\r") {
+			segments := strings.Split(line, "\r")
+			line = ""
+			for _, segment := range segments {
+				if segment != "" {
+					line = segment
+				}
+			}
+		}
+		var clean []rune
+		for _, r := range line {
+			switch {
+			case r == '\b':
+				if len(clean) > 0 {
+					clean = clean[:len(clean)-1]
+				}
+			case r == '\t', r == '\x1b', r >= ' ' && r != 0x7f:
+				clean = append(clean, r)
+			}
+		}
+		lines[i] = string(clean)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripANSI removes the colours left by cleanTerminalOutput
+func stripANSI(s string) string {
+	return ansiSequence.ReplaceAllString(s, "")
+}
+
+// logView holds the state of the log viewer screen
+type logView struct {
+	jobID int
+	// back is the screen the viewer was opened from
+	back     screen
+	viewport viewport.Model
+	lines    []string
+	// offsets are the viewport line each output line starts at, which
+	// differ from the output line numbers once lines wrap
+	offsets   []int
+	wrap      bool
+	searching bool
+	search    textinput.Model
+	query     string
+	matches   []int
+	match     int
+	message   string
+}
+
+// openLog shows the output of a job in the log viewer
+func (m *Model) openLog(job *runningTool) tea.Cmd {
+	search := newTextInput()
+	search.Prompt = "/"
+	search.Placeholder = "search the output"
+	m.logView = logView{jobID: job.id, back: m.screen, wrap: m.logView.wrap, search: search, viewport: viewport.New(0, 0)}
+	m.screen = screenLog
+	m.refreshLog(job)
+	m.logView.viewport.GotoBottom()
+	return nil
+}
+
+// refreshLog renders the output of the job again when the log viewer
+// shows it, following the end unless the user scrolled up
+func (m *Model) refreshLog(job *runningTool) {
+	v := &m.logView
+	if m.screen != screenLog || job.id != v.jobID {
+		return
+	}
+	follow := v.viewport.AtBottom()
+	v.viewport.Width = max(m.width-4, 20)
+	v.viewport.Height = max(m.height-8, 5)
+	v.lines = strings.Split(strings.TrimRight(cleanTerminalOutput(job.output), "\n"), "\n")
+	v.findMatches()
+	v.render()
+	if follow {
+		v.viewport.GotoBottom()
+	}
+}
+
+// findMatches lists the lines containing the query, ignoring case and
+// colours
+func (v *logView) findMatches() {
+	v.matches = nil
+	if v.query == "" {
+		return
+	}
+	query := strings.ToLower(v.query)
+	for i, line := range v.lines {
+		if strings.Contains(strings.ToLower(stripANSI(line)), query) {
+			v.matches = append(v.matches, i)
+		}
+	}
+	if v.match >= len(v.matches) {
+		v.match = 0
+	}
+}
+
+// render lays the output out in the viewport: matching lines are marked
+// in the gutter and long lines wrap or are cut at the viewport's width
+func (v *logView) render() {
+	width := v.viewport.Width - 2
+	current := -1
+	if len(v.matches) > 0 {
+		current = v.matches[v.match]
+	}
+	matched := map[int]bool{}
+	for _, i := range v.matches {
+		matched[i] = true
+	}
+	style := lipgloss.NewStyle().MaxWidth(width)
+	if v.wrap {
+		style = lipgloss.NewStyle().Width(width)
+	}
+	var content strings.Builder
+	v.offsets = make([]int, len(v.lines))
+	row := 0
+	for i, line := range v.lines {
+		gutter := "  "
+		switch {
+		case i == current:
+			gutter = selectedItemStyle.Render("▶ ")
+		case matched[i]:
+			gutter = featureStyle.Render("» ")
+		}
+		rendered := strings.Split(style.Render(line), "\n")
+		v.offsets[i] = row
+		for j, part := range rendered {
+			if j > 0 {
+				gutter = "  "
+			}
+			content.WriteString(gutter + part + "\n")
+			row++
+		}
+	}
+	v.viewport.SetContent(strings.TrimSuffix(content.String(), "\n"))
+}
+
+// showMatch scrolls to the current match
+func (v *logView) showMatch() {
+	if len(v.matches) == 0 {
+		v.message = fmt.Sprintf("No lines match %q", v.query)
+		return
+	}
+	v.render()
+	v.viewport.SetYOffset(v.offsets[v.matches[v.match]])
+	v.message = fmt.Sprintf("Match %d of %d", v.match+1, len(v.matches))
+}
+
+// updateLog handles keys on the log viewer
+func (m Model) updateLog(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.logView
+	job := m.job(v.jobID)
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if job == nil {
+		m.screen = v.back
+		return m, nil
+	}
+	if v.searching {
+		switch keyMsg.Type {
+		case tea.KeyEnter:
+			v.searching = false
+			v.query = v.search.Value()
+			v.match = 0
+			v.findMatches()
+			if v.query == "" {
+				v.render()
+				return m, nil
+			}
+			v.showMatch()
+		case tea.KeyEsc:
+			v.searching = false
+			v.search.Blur()
+		default:
+			var cmd tea.Cmd
+			v.search, cmd = v.search.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	v.message = ""
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = v.back
+	case key.Matches(keyMsg, m.keys.Search):
+		v.searching = true
+		v.search.SetValue(v.query)
+		v.search.CursorEnd()
+		return m, v.search.Focus()
+	case key.Matches(keyMsg, m.keys.NextMatch):
+		if len(v.matches) > 0 {
+			v.match = (v.match + 1) % len(v.matches)
+		}
+		v.showMatch()
+	case key.Matches(keyMsg, m.keys.PrevMatch):
+		if len(v.matches) > 0 {
+			v.match = (v.match + len(v.matches) - 1) % len(v.matches)
+		}
+		v.showMatch()
+	case key.Matches(keyMsg, m.keys.Wrap):
+		v.wrap = !v.wrap
+		top := v.topLine()
+		v.render()
+		v.viewport.SetYOffset(v.offsets[top])
+	case key.Matches(keyMsg, m.keys.Export):
+		if path, err := ExportOutput(m.exportDir(), job.tool.Name, job.output, time.Now()); err != nil {
+			v.message = fmt.Sprintf("Could not export the log: %v", err)
+		} else {
+			v.message = "Exported to " + path
+		}
+	case key.Matches(keyMsg, m.keys.Copy):
+		return m, copyCmd(job.output)
+	default:
+		var cmd tea.Cmd
+		v.viewport, cmd = v.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// topLine returns the output line shown at the top of the viewport
+func (v *logView) topLine() int {
+	top := 0
+	for i, offset := range v.offsets {
+		if offset > v.viewport.YOffset {
+			break
+		}
+		top = i
+	}
+	return top
+}
+
+// renderLog renders the log viewer
+func (m Model) renderLog() string {
+	v := m.logView
+	job := m.job(v.jobID)
+	if job == nil {
+		return "The job's pane was closed"
+	}
+	var content strings.Builder
+	title := titleStyle.Render("📜 " + job.tool.Name)
+	wrap := "cut"
+	if v.wrap {
+		wrap = "wrapped"
+	}
+	state := fmt.Sprintf("%d lines | %s | %d%%", len(v.lines), wrap, int(v.viewport.ScrollPercent()*100))
+	if !job.done {
+		state = "⏳ " + job.runningFor() + " | " + state
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(state)))
+	content.WriteString("\n\n")
+	content.WriteString(v.viewport.View())
+	content.WriteString("\n\n")
+	switch {
+	case v.searching:
+		content.WriteString(v.search.View())
+		content.WriteString("\n")
+	case v.message != "":
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("scroll", k.Up, k.Down), "pgup/pgdn: page", hint("search", k.Search), hint("next/previous match", k.NextMatch, k.PrevMatch), hint("wrap", k.Wrap), hint("export", k.Export), hint("copy", k.Copy), hint("back", k.Back)}, " | ")))
+	return content.String()
+}
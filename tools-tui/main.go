@@ -7,7 +7,20 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// version is set at build time by the release subcommand
+var version = "dev"
+
 func main() {
+	args, err := parseFaultFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	args = parseListenFlag(args)
+	if runSubcommand(args) {
+		return
+	}
+
 	// Check if we're in the right directory
 	if _, err := os.Stat("../cli.py"); os.IsNotExist(err) {
 		fmt.Println("Error: Please run this tool from the tools-tui directory")
@@ -16,8 +29,21 @@ func main() {
 	}
 
 	// Initialize and start the TUI
-	p := tea.NewProgram(InitialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	m := InitialModel()
+	if listenAddr != "" {
+		if err := m.listenWebhooks(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	m.scheduler.Start()
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err = p.Run()
+	m.scheduler.Stop()
+	m.webhooks.Stop()
+	m.supervisor.StopAll()
+	resetTerminal()
+	if err != nil {
 		fmt.Printf("Error running TUI: %v", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Artifact age thresholds used by the orphan scan
+const (
+	staleLogAge = 30 * 24 * time.Hour
+	staleDBAge  = 90 * 24 * time.Hour
+)
+
+// Artifact is a file or directory that looks safe to garbage collect
+type Artifact struct {
+	Path     string
+	Kind     string
+	Reason   string
+	Size     int64
+	Selected bool
+}
+
+// maintenanceView holds the state of the maintenance screen
+type maintenanceView struct {
+	items   []Artifact
+	cursor  int
+	dryRun  bool
+	loading bool
+	confirm bool
+	message string
+}
+
+// orphanMsg delivers a finished orphan scan
+type orphanMsg struct {
+	items []Artifact
+	err   error
+}
+
+// ScanOrphans looks for artifacts left behind by removed tools: venvs
+// and node_modules whose project manifest is gone, old logs and
+// databases, and snapshots of extensions that no longer exist.
+func ScanOrphans() ([]Artifact, error) {
+	var items []Artifact
+	now := time.Now()
+
+	err := filepath.Walk(defaultWorkDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := info.Name()
+		parent := filepath.Dir(path)
+
+		if info.IsDir() {
+			switch {
+			case name == ".git":
+				return filepath.SkipDir
+			case depDirs[name]:
+				if !fileExists(filepath.Join(parent, "package.json")) {
+					items = append(items, Artifact{Path: path, Kind: "node_modules", Reason: "no package.json next to it", Size: dirSize(path)})
+				}
+				return filepath.SkipDir
+			case venvDirs[name] && fileExists(filepath.Join(path, "pyvenv.cfg")):
+				if !hasPythonManifest(parent) {
+					items = append(items, Artifact{Path: path, Kind: "venv", Reason: "no Python project next to it", Size: dirSize(path)})
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		age := now.Sub(info.ModTime())
+		switch ext := filepath.Ext(name); {
+		case ext == ".log" && age > staleLogAge:
+			items = append(items, Artifact{Path: path, Kind: "log", Reason: fmt.Sprintf("untouched for %d days", int(age.Hours()/24)), Size: info.Size()})
+		case (ext == ".db" || ext == ".sqlite" || ext == ".sqlite3") && age > staleDBAge:
+			items = append(items, Artifact{Path: path, Kind: "database", Reason: fmt.Sprintf("untouched for %d days", int(age.Hours()/24)), Size: info.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotRoot := filepath.Join(ConfigDir(), "snapshots")
+	if entries, err := os.ReadDir(snapshotRoot); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && !fileExists(filepath.Join(extensionsDir(), entry.Name())) {
+				path := filepath.Join(snapshotRoot, entry.Name())
+				items = append(items, Artifact{Path: path, Kind: "snapshot", Reason: "extension was removed", Size: dirSize(path)})
+			}
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Size > items[j].Size })
+	return items, nil
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// hasPythonManifest reports whether dir looks like a Python project
+func hasPythonManifest(dir string) bool {
+	for _, name := range []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"} {
+		if fileExists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanOrphansCmd runs the orphan scan in the background
+func scanOrphansCmd() tea.Cmd {
+	return func() tea.Msg {
+		items, err := ScanOrphans()
+		return orphanMsg{items: items, err: err}
+	}
+}
+
+// openMaintenance switches to the maintenance screen and starts a scan
+func (m *Model) openMaintenance() tea.Cmd {
+	m.screen = screenMaintenance
+	m.maintenance.loading = true
+	m.maintenance.dryRun = true
+	m.maintenance.message = ""
+	return scanOrphansCmd()
+}
+
+// selectedArtifacts returns the artifacts marked for removal
+func (v maintenanceView) selectedArtifacts() ([]Artifact, int64) {
+	var selected []Artifact
+	var size int64
+	for _, item := range v.items {
+		if item.Selected {
+			selected = append(selected, item)
+			size += item.Size
+		}
+	}
+	return selected, size
+}
+
+// removeSelected deletes the selected artifacts, or only describes the
+// removal when dry-run is enabled.
+func (v *maintenanceView) removeSelected() {
+	selected, size := v.selectedArtifacts()
+	if v.dryRun {
+		var paths []string
+		for _, item := range selected {
+			paths = append(paths, item.Path)
+		}
+		v.message = fmt.Sprintf("Dry run: would remove %d items (%s): %s", len(selected), formatBytes(size), strings.Join(paths, ", "))
+		return
+	}
+
+	var freed int64
+	var failed []string
+	for _, item := range selected {
+		if err := os.RemoveAll(item.Path); err != nil {
+			failed = append(failed, filepath.Base(item.Path))
+			continue
+		}
+		freed += item.Size
+	}
+	v.message = fmt.Sprintf("Removed %d items, freed %s", len(selected)-len(failed), formatBytes(freed))
+	if len(failed) > 0 {
+		v.message += fmt.Sprintf(" (failed: %s)", strings.Join(failed, ", "))
+	}
+}
+
+// updateMaintenance handles input on the maintenance screen
+func (m Model) updateMaintenance(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.maintenance
+
+	switch msg := msg.(type) {
+	case orphanMsg:
+		v.loading = false
+		if msg.err != nil {
+			v.message = fmt.Sprintf("Scan failed: %v", msg.err)
+			return m, nil
+		}
+		// a rescan keeps the selection of artifacts that are still there
+		selected := map[string]bool{}
+		for _, item := range v.items {
+			selected[item.Path] = item.Selected
+		}
+		v.items = msg.items
+		for i := range v.items {
+			v.items[i].Selected = selected[v.items[i].Path]
+		}
+		if v.cursor >= len(v.items) {
+			v.cursor = 0
+		}
+
+	case tea.KeyMsg:
+		if v.confirm {
+			v.confirm = false
+			if key.Matches(msg, m.keys.Confirm) {
+				v.removeSelected()
+				v.loading = true
+				return m, scanOrphansCmd()
+			}
+			v.message = "Removal cancelled"
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back):
+			m.screen = screenTools
+		case key.Matches(msg, m.keys.Up):
+			if v.cursor > 0 {
+				v.cursor--
+			}
+		case key.Matches(msg, m.keys.Down):
+			if v.cursor < len(v.items)-1 {
+				v.cursor++
+			}
+		case key.Matches(msg, m.keys.Enter):
+			if v.cursor < len(v.items) {
+				v.items[v.cursor].Selected = !v.items[v.cursor].Selected
+			}
+		case key.Matches(msg, m.keys.Refresh):
+			if !v.loading {
+				v.loading = true
+				return m, scanOrphansCmd()
+			}
+		case key.Matches(msg, m.keys.DryRun):
+			v.dryRun = !v.dryRun
+		case key.Matches(msg, m.keys.Delete):
+			if selected, _ := v.selectedArtifacts(); len(selected) == 0 {
+				v.message = "Nothing selected"
+			} else if v.dryRun {
+				v.removeSelected()
+			} else {
+				v.confirm = true
+			}
+		}
+	}
+	return m, nil
+}
+
+// renderMaintenance renders the orphaned artifact list
+func (m Model) renderMaintenance() string {
+	v := m.maintenance
+	var content strings.Builder
+
+	var total int64
+	for _, item := range v.items {
+		total += item.Size
+	}
+	mode := "dry run"
+	if !v.dryRun {
+		mode = "LIVE"
+	}
+	title := titleStyle.Render("🧹 Maintenance")
+	status := statusStyle.Render(fmt.Sprintf("%d orphans | %s reclaimable | %s", len(v.items), formatBytes(total), mode))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	if v.loading {
+		content.WriteString(descriptionStyle.Render("Scanning for orphaned artifacts..."))
+		content.WriteString("\n")
+	} else if len(v.items) == 0 {
+		content.WriteString(descriptionStyle.Render("No orphaned artifacts found"))
+		content.WriteString("\n")
+	}
+
+	var list strings.Builder
+	for i, item := range v.items {
+		check := "[ ]"
+		if item.Selected {
+			check = "[x]"
+		}
+		rel, err := filepath.Rel(defaultWorkDir, item.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = item.Path
+		}
+		line := fmt.Sprintf("%s %-12s %10s  %s", check, item.Kind, formatBytes(item.Size), rel)
+		if i == v.cursor {
+			list.WriteString(selectedItemStyle.Render("▶ "+line) + "  " + descriptionStyle.Render(item.Reason))
+		} else {
+			list.WriteString("  " + line)
+		}
+		list.WriteString("\n")
+	}
+	content.WriteString(renderStale(v.loading, list.String()))
+
+	content.WriteString("\n")
+	if v.confirm {
+		selected, size := v.selectedArtifacts()
+		prompt := fmt.Sprintf("⚠ Permanently delete %d items (%s)? Press '%s' to confirm", len(selected), formatBytes(size), primaryKey(m.keys.Confirm))
+		content.WriteString(warningStyle.Render(prompt))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("select", k.Enter), hint("toggle dry run", k.DryRun), hint("remove", k.Delete), hint("rescan", k.Refresh), hint("back", k.Back), hint("quit", k.Quit)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manifestEnv overrides the location of the inventory manifest
+const manifestEnv = "OPENCODE_TUI_INVENTORY"
+
+// manifestPath returns the inventory manifest of the primary repository
+func manifestPath() string {
+	if path := os.Getenv(manifestEnv); path != "" {
+		return path
+	}
+	return filepath.Join(defaultWorkDir, repoInventoryFile)
+}
+
+// ManifestError lists the problems found in an inventory manifest
+type ManifestError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ManifestError) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Problems[0])
+	}
+	return fmt.Sprintf("%s: %d problems: %s", e.Path, len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// LoadManifest reads an inventory manifest: a JSON list of categories
+// with their tools. A missing file returns nil without error. Invalid
+// entries are dropped and reported in a *ManifestError while the valid
+// ones are still returned.
+func LoadManifest(path string) ([]Category, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(path, data)
+}
+
+// parseManifest decodes and validates the manifest data read from path
+func parseManifest(path string, data []byte) ([]Category, error) {
+	var categories []Category
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&categories); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			err = fmt.Errorf("line %d: %v", lineOf(data, syntaxErr.Offset), err)
+		case errors.As(err, &typeErr):
+			err = fmt.Errorf("line %d: %s must be %s", lineOf(data, typeErr.Offset), typeErr.Field, typeErr.Type)
+		}
+		return nil, &ManifestError{Path: path, Problems: []string{err.Error()}}
+	}
+
+	valid, problems := validateManifest(categories)
+	if len(problems) > 0 {
+		return valid, &ManifestError{Path: path, Problems: problems}
+	}
+	return valid, nil
+}
+
+// lineOf converts a byte offset into a 1-based line number
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// validateManifest checks required fields and known values, returning
+// the categories with invalid entries removed
+func validateManifest(categories []Category) ([]Category, []string) {
+	var problems []string
+	seenCategories := map[string]bool{}
+	seenTools := map[string]string{}
+	knownLanguages := map[string]bool{}
+	for _, lang := range projectManifests {
+		knownLanguages[lang] = true
+	}
+
+	var valid []Category
+	for i, category := range categories {
+		if strings.TrimSpace(category.Name) == "" {
+			problems = append(problems, fmt.Sprintf("category #%d has no name", i+1))
+			continue
+		}
+		if seenCategories[category.Name] {
+			problems = append(problems, fmt.Sprintf("category %q is defined twice", category.Name))
+			continue
+		}
+		seenCategories[category.Name] = true
+
+		tools := category.Tools[:0:0]
+		for j, tool := range category.Tools {
+			where := fmt.Sprintf("%s › tool #%d", category.Name, j+1)
+			if tool.Name != "" {
+				where = fmt.Sprintf("%s › %s", category.Name, tool.Name)
+			}
+			var toolProblems []string
+			if strings.TrimSpace(tool.Name) == "" {
+				toolProblems = append(toolProblems, "name is required")
+			} else if other, ok := seenTools[tool.Name]; ok {
+				toolProblems = append(toolProblems, "name already used in "+other)
+			}
+			if strings.TrimSpace(tool.Command) == "" {
+				toolProblems = append(toolProblems, "command is required")
+			}
+			if tool.Trust != "" && !tool.Trust.Valid() {
+				toolProblems = append(toolProblems, fmt.Sprintf("unknown trust %q (use trusted, reviewed or downloaded)", tool.Trust))
+			}
+			for _, platform := range tool.Platforms {
+				goos, _, _ := strings.Cut(platform, "/")
+				if _, ok := platformNames[goos]; !ok && goos != "*" {
+					toolProblems = append(toolProblems, fmt.Sprintf("unknown platform %q", platform))
+				}
+			}
+			if tool.Schedule != "" {
+				if _, err := ParseCron(tool.Schedule); err != nil {
+					toolProblems = append(toolProblems, err.Error())
+				}
+			}
+			toolProblems = append(toolProblems, validateToolEnv(tool)...)
+			toolProblems = append(toolProblems, validateArgs(tool)...)
+			toolProblems = append(toolProblems, validateExamples(tool)...)
+			toolProblems = append(toolProblems, validateOpenAPIArg(tool)...)
+			for _, lang := range tool.Languages {
+				if !knownLanguages[lang] {
+					toolProblems = append(toolProblems, fmt.Sprintf("unknown language %q", lang))
+				}
+			}
+			if len(toolProblems) > 0 {
+				problems = append(problems, where+": "+strings.Join(toolProblems, ", "))
+				continue
+			}
+			seenTools[tool.Name] = category.Name
+			tools = append(tools, tool)
+		}
+		category.Tools = tools
+		category.Active = true
+		valid = append(valid, category)
+	}
+	return valid, problems
+}
+
+// runInventory implements the inventory subcommand: validate and export
+func runInventory(args []string) error {
+	action := "validate"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	path := manifestPath()
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	switch action {
+	case "import":
+		return importInventory(args[1:])
+	case "validate":
+		categories, err := LoadManifest(path)
+		if err != nil {
+			return err
+		}
+		if categories == nil {
+			return fmt.Errorf("%s does not exist; create it with: tools-tui inventory export", path)
+		}
+		tools := 0
+		for _, category := range categories {
+			tools += len(category.Tools)
+		}
+		fmt.Printf("%s is valid: %d tools in %d categories\n", path, tools, len(categories))
+		return nil
+	case "export":
+		if fileExists(path) {
+			return fmt.Errorf("%s already exists", path)
+		}
+		if err := WriteFileContent(path, string(builtinInventory)); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote the built-in catalog to %s\n", path)
+		return nil
+	default:
+		return fmt.Errorf("unknown inventory action %q (use validate, export or import)", action)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+)
+
+// renderMarkdown applies light terminal styling to markdown text:
+// headings are highlighted, bullets get a coloured marker and fenced
+// code is shown in the command style.
+func renderMarkdown(text string) string {
+	var b strings.Builder
+	inCode := false
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inCode = !inCode
+			continue
+		case inCode:
+			b.WriteString(commandStyle.Render(line))
+		case strings.HasPrefix(trimmed, "#"):
+			b.WriteString(featureStyle.Render(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))))
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			b.WriteString(indent + featureStyle.Render("•") + " " + trimmed[2:])
+		default:
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
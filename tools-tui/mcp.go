@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mcpProtocolVersion is the MCP revision the client speaks; it is the
+// one that defines the stdio and SSE transports
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpSettingsFile lists the repository's local MCP servers
+const mcpSettingsFile = "mcp_settings_local.json"
+
+// mcpConnectTimeout bounds the transport setup and initialize handshake
+const mcpConnectTimeout = 15 * time.Second
+
+// MCPServerConfig describes how to reach an MCP server: a command
+// speaking JSON-RPC over stdio, or the URL of an SSE endpoint
+type MCPServerConfig struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// Dir is the working directory of the command, the repository root
+	// when empty
+	Dir     string            `json:"dir,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// validate reports configs that name no transport or both
+func (c MCPServerConfig) validate() error {
+	switch {
+	case c.Command == "" && c.URL == "":
+		return fmt.Errorf("needs a command or a url")
+	case c.Command != "" && c.URL != "":
+		return fmt.Errorf("has both a command and a url")
+	}
+	return nil
+}
+
+// Transport names the transport used to reach the server
+func (c MCPServerConfig) Transport() string {
+	if c.URL != "" {
+		return "sse"
+	}
+	return "stdio"
+}
+
+// validateMCPServers reports servers configured without a usable transport
+func validateMCPServers(servers map[string]MCPServerConfig) error {
+	var problems []string
+	for name, server := range servers {
+		if err := server.validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", name, err))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("mcp servers: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// LoadMCPServers returns the servers of the repository's
+// mcp_settings_local.json merged with those of config.json, which win
+func LoadMCPServers(configured map[string]MCPServerConfig) map[string]MCPServerConfig {
+	servers := map[string]MCPServerConfig{}
+	var settings struct {
+		MCPServers map[string]MCPServerConfig `json:"mcpServers"`
+	}
+	if data, err := os.ReadFile(filepath.Join(defaultWorkDir, mcpSettingsFile)); err == nil {
+		if json.Unmarshal(data, &settings) == nil {
+			for name, server := range settings.MCPServers {
+				servers[name] = server
+			}
+		}
+	}
+	for name, server := range configured {
+		servers[name] = server
+	}
+	return servers
+}
+
+// mcpTransport carries JSON-RPC messages to and from a server
+type mcpTransport interface {
+	// send writes one message
+	send(ctx context.Context, msg []byte) error
+	// messages delivers incoming messages until the connection ends
+	messages() <-chan []byte
+	close() error
+}
+
+// stdioTransport runs a server as a child process exchanging
+// newline-delimited messages over stdin and stdout
+type stdioTransport struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	mu       sync.Mutex
+	incoming chan []byte
+	stderr   bytes.Buffer
+	// exited is closed once the process has exited, with waitErr set
+	exited  chan struct{}
+	waitErr error
+}
+
+// startStdio launches the server command
+func startStdio(cfg MCPServerConfig) (*stdioTransport, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Dir = cfg.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = defaultWorkDir
+	}
+	cmd.Env = os.Environ()
+	for name, value := range cfg.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	t := &stdioTransport{cmd: cmd, incoming: make(chan []byte, 16), exited: make(chan struct{})}
+	cmd.Stderr = &limitedBuffer{buf: &t.stderr, limit: 4096}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	t.stdin = stdin
+	go func() {
+		defer close(t.incoming)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				t.incoming <- append([]byte(nil), line...)
+			}
+		}
+		t.waitErr = cmd.Wait()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+func (t *stdioTransport) send(ctx context.Context, msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.stdin.Write(append(msg, '\n'))
+	return err
+}
+
+func (t *stdioTransport) messages() <-chan []byte { return t.incoming }
+
+// close ends stdin, which asks the server to exit, and kills it when it
+// does not within a second
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	select {
+	case <-t.exited:
+	case <-time.After(time.Second):
+		t.cmd.Process.Kill()
+		<-t.exited
+	}
+	return nil
+}
+
+// exitReason describes why the process exited, with the start of what
+// it wrote to stderr
+func (t *stdioTransport) exitReason() string {
+	reason := "exited"
+	if t.waitErr != nil {
+		reason = t.waitErr.Error()
+	}
+	if t.stderr.Len() > 0 {
+		reason += ": " + strings.TrimSpace(t.stderr.String())
+	}
+	return reason
+}
+
+// limitedBuffer keeps the first limit bytes written to it
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// sseTransport receives messages as server-sent events and posts
+// requests to the endpoint announced in the stream's first event
+type sseTransport struct {
+	client   *http.Client
+	headers  map[string]string
+	endpoint string
+	ready    chan struct{}
+	incoming chan []byte
+	cancel   context.CancelFunc
+}
+
+// startSSE opens the event stream and waits for the endpoint event
+func startSSE(ctx context.Context, cfg MCPServerConfig) (*sseTransport, error) {
+	base, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s: %s", cfg.URL, resp.Status)
+	}
+
+	t := &sseTransport{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		headers:  cfg.Headers,
+		ready:    make(chan struct{}),
+		incoming: make(chan []byte, 16),
+		cancel:   cancel,
+	}
+	go func() {
+		defer resp.Body.Close()
+		defer close(t.incoming)
+		readEvents(resp.Body, func(event, data string) {
+			switch event {
+			case "endpoint":
+				if t.endpoint != "" {
+					return
+				}
+				if ref, err := base.Parse(strings.TrimSpace(data)); err == nil {
+					t.endpoint = ref.String()
+					close(t.ready)
+				}
+			case "", "message":
+				t.incoming <- []byte(data)
+			}
+		})
+	}()
+
+	select {
+	case <-t.ready:
+		return t, nil
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("%s sent no endpoint event: %v", cfg.URL, ctx.Err())
+	}
+}
+
+// readEvents parses a server-sent event stream, calling dispatch for
+// every complete event
+func readEvents(r io.Reader, dispatch func(event, data string)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				dispatch(event, strings.Join(data, "\n"))
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// comment, used as keep-alive
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+}
+
+func (t *sseTransport) send(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+func (t *sseTransport) messages() <-chan []byte { return t.incoming }
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	return nil
+}
+
+// rpcMessage is a JSON-RPC 2.0 request, notification or response
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error member of a JSON-RPC response
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// MCPServerInfo identifies a connected server
+type MCPServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// MCPTool is a tool offered by a server
+type MCPTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// MCPResource is a resource offered by a server
+type MCPResource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPContent is an item of a tool result or resource read
+type MCPContent struct {
+	Type     string      `json:"type,omitempty"`
+	Text     string      `json:"text,omitempty"`
+	Data     string      `json:"data,omitempty"`
+	MimeType string      `json:"mimeType,omitempty"`
+	URI      string      `json:"uri,omitempty"`
+	Blob     string      `json:"blob,omitempty"`
+	Resource *MCPContent `json:"resource,omitempty"`
+}
+
+// MCPToolResult is the result of calling a tool
+type MCPToolResult struct {
+	Content []MCPContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+// MCPClient is a connection to one MCP server
+type MCPClient struct {
+	Server       MCPServerInfo
+	Instructions string
+	transport    mcpTransport
+	mu           sync.Mutex
+	nextID       int
+	pending      map[string]chan rpcMessage
+	done         chan struct{}
+}
+
+// ConnectMCP starts the server's transport and performs the initialize
+// handshake
+func ConnectMCP(ctx context.Context, cfg MCPServerConfig) (*MCPClient, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, mcpConnectTimeout)
+	defer cancel()
+
+	var transport mcpTransport
+	var err error
+	if cfg.URL != "" {
+		transport, err = startSSE(ctx, cfg)
+	} else {
+		transport, err = startStdio(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &MCPClient{transport: transport, pending: map[string]chan rpcMessage{}, done: make(chan struct{})}
+	go c.readLoop()
+
+	var init struct {
+		ServerInfo   MCPServerInfo `json:"serverInfo"`
+		Instructions string        `json:"instructions"`
+	}
+	params := map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      MCPServerInfo{Name: "opencode-tools-tui", Version: "1.0"},
+	}
+	if err := c.call(ctx, "initialize", params, &init); err != nil {
+		c.Close()
+		if stdio, ok := transport.(*stdioTransport); ok && stdio.stderr.Len() > 0 {
+			err = fmt.Errorf("%v: %s", err, strings.TrimSpace(stdio.stderr.String()))
+		}
+		return nil, err
+	}
+	c.Server, c.Instructions = init.ServerInfo, init.Instructions
+	if err := c.notify(ctx, "notifications/initialized"); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// readLoop routes responses to their callers and answers server requests
+func (c *MCPClient) readLoop() {
+	for raw := range c.transport.messages() {
+		var msg rpcMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			c.answer(msg)
+		case msg.Method == "" && len(msg.ID) > 0:
+			c.mu.Lock()
+			ch, ok := c.pending[string(msg.ID)]
+			delete(c.pending, string(msg.ID))
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		}
+	}
+	close(c.done)
+}
+
+// answer replies to a request sent by the server. Only ping is
+// supported since the client declares no capabilities.
+func (c *MCPClient) answer(req rpcMessage) {
+	resp := rpcMessage{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage("{}")
+	} else {
+		resp.Error = &rpcError{Code: -32601, Message: "method not found"}
+	}
+	if data, err := json.Marshal(resp); err == nil {
+		c.transport.send(context.Background(), data)
+	}
+}
+
+// call sends a request and decodes its result into result
+func (c *MCPClient) call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	ch := make(chan rpcMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if err := c.transport.send(ctx, data); err != nil {
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %v", method, resp.Error)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-c.done:
+		return fmt.Errorf("%s: server closed the connection", method)
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %v", method, ctx.Err())
+	}
+}
+
+// notify sends a notification, which has no response
+func (c *MCPClient) notify(ctx context.Context, method string) error {
+	data, err := json.Marshal(rpcMessage{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	return c.transport.send(ctx, data)
+}
+
+// ListTools returns every tool of the server, following pagination
+func (c *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
+	var tools []MCPTool
+	cursor := ""
+	for {
+		var page struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", cursorParams(cursor), &page); err != nil {
+			return tools, err
+		}
+		tools = append(tools, page.Tools...)
+		if cursor = page.NextCursor; cursor == "" {
+			return tools, nil
+		}
+	}
+}
+
+// ListResources returns every resource of the server, following
+// pagination
+func (c *MCPClient) ListResources(ctx context.Context) ([]MCPResource, error) {
+	var resources []MCPResource
+	cursor := ""
+	for {
+		var page struct {
+			Resources  []MCPResource `json:"resources"`
+			NextCursor string        `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "resources/list", cursorParams(cursor), &page); err != nil {
+			return resources, err
+		}
+		resources = append(resources, page.Resources...)
+		if cursor = page.NextCursor; cursor == "" {
+			return resources, nil
+		}
+	}
+}
+
+// cursorParams returns the params of a paginated list request
+func cursorParams(cursor string) interface{} {
+	if cursor == "" {
+		return nil
+	}
+	return map[string]string{"cursor": cursor}
+}
+
+// CallTool invokes a tool. The raw JSON result is returned along with
+// the decoded one for display.
+func (c *MCPClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (MCPToolResult, json.RawMessage, error) {
+	var raw json.RawMessage
+	err := c.call(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": arguments}, &raw)
+	if err != nil {
+		return MCPToolResult{}, nil, err
+	}
+	var result MCPToolResult
+	err = json.Unmarshal(raw, &result)
+	return result, raw, err
+}
+
+// ReadResource returns the contents of a resource
+func (c *MCPClient) ReadResource(ctx context.Context, uri string) ([]MCPContent, json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.call(ctx, "resources/read", map[string]string{"uri": uri}, &raw); err != nil {
+		return nil, nil, err
+	}
+	var result struct {
+		Contents []MCPContent `json:"contents"`
+	}
+	err := json.Unmarshal(raw, &result)
+	return result.Contents, raw, err
+}
+
+// Close ends the connection
+func (c *MCPClient) Close() error {
+	return c.transport.close()
+}
+
+// Done is closed when the connection has ended; for stdio servers the
+// process has exited by then
+func (c *MCPClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Ping checks that the server still answers
+func (c *MCPClient) Ping(ctx context.Context) error {
+	return c.call(ctx, "ping", nil, nil)
+}
+
+// PID returns the process ID of a stdio server, or 0
+func (c *MCPClient) PID() int {
+	if stdio, ok := c.transport.(*stdioTransport); ok {
+		return stdio.cmd.Process.Pid
+	}
+	return 0
+}
+
+// ExitReason describes why the connection ended
+func (c *MCPClient) ExitReason() string {
+	if stdio, ok := c.transport.(*stdioTransport); ok {
+		<-stdio.exited
+		return stdio.exitReason()
+	}
+	return "connection closed"
+}
+
+// mcpField is an argument of a tool derived from its input schema
+type mcpField struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// mcpFields lists the arguments declared by a tool's input schema,
+// required ones first
+func mcpFields(schema json.RawMessage) []mcpField {
+	var s struct {
+		Properties map[string]struct {
+			Type        interface{} `json:"type"`
+			Description string      `json:"description"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if json.Unmarshal(schema, &s) != nil {
+		return nil
+	}
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	var fields []mcpField
+	for name, prop := range s.Properties {
+		field := mcpField{Name: name, Description: prop.Description, Required: required[name]}
+		switch t := prop.Type.(type) {
+		case string:
+			field.Type = t
+		case []interface{}:
+			// e.g. ["string", "null"]: the first non-null type
+			for _, alt := range t {
+				if alt, ok := alt.(string); ok && alt != "null" {
+					field.Type = alt
+					break
+				}
+			}
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Required != fields[j].Required {
+			return fields[i].Required
+		}
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// mcpArguments converts the entered values to the types the schema
+// declares. Empty optional fields are left out.
+func mcpArguments(fields []mcpField, values []string) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	for i, field := range fields {
+		value := strings.TrimSpace(values[i])
+		if value == "" {
+			if field.Required {
+				return nil, fmt.Errorf("%s is required", field.Name)
+			}
+			continue
+		}
+		var err error
+		switch field.Type {
+		case "string", "":
+			args[field.Name] = value
+		case "integer":
+			args[field.Name], err = strconv.ParseInt(value, 10, 64)
+		case "number":
+			args[field.Name], err = strconv.ParseFloat(value, 64)
+		case "boolean":
+			args[field.Name], err = strconv.ParseBool(value)
+		default:
+			var decoded interface{}
+			err = json.Unmarshal([]byte(value), &decoded)
+			args[field.Name] = decoded
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s must be %s: %v", field.Name, field.Type, err)
+		}
+	}
+	return args, nil
+}
+
+// formatMCPContent renders result items as text; binary items are
+// summarised
+func formatMCPContent(items []MCPContent) string {
+	var parts []string
+	for _, item := range items {
+		switch {
+		case item.Resource != nil:
+			parts = append(parts, fmt.Sprintf("[resource %s]\n%s", item.Resource.URI, formatMCPContent([]MCPContent{*item.Resource})))
+		case item.Text != "":
+			parts = append(parts, item.Text)
+		case item.Data != "" || item.Blob != "":
+			size := len(item.Data) + len(item.Blob)
+			parts = append(parts, fmt.Sprintf("[%s %s, %d bytes base64]", item.Type, item.MimeType, size))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// prettyJSON indents JSON for display, returning the input unchanged
+// when it is not valid JSON
+func prettyJSON(data []byte) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return out.String()
+}
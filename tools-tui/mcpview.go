@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mcpCallTimeout bounds a single request made from the MCP screen
+const mcpCallTimeout = 60 * time.Second
+
+// mcpView holds the state of the MCP servers screen
+type mcpView struct {
+	servers map[string]MCPServerConfig
+	names   []string
+	cursor  int
+	client  *MCPClient
+	// shared marks a client owned by the supervisor, which must not be
+	// closed on disconnect
+	shared    bool
+	connected string
+	busy      string
+	tools     []MCPTool
+	resources []MCPResource
+	// showResources lists resources instead of tools
+	showResources bool
+	// focusItems moves the cursor to the tools or resources column
+	focusItems bool
+	item       int
+	form       mcpForm
+	output     viewport.Model
+	message    string
+	// refreshing dims the tools and resources until they are listed again
+	refreshing bool
+}
+
+// mcpForm asks for the arguments of a tool, one field per property of
+// its input schema
+type mcpForm struct {
+	active bool
+	tool   MCPTool
+	fields []mcpField
+	inputs []textinput.Model
+	focus  int
+	err    string
+}
+
+// mcpConnectedMsg reports the outcome of connecting to a server
+type mcpConnectedMsg struct {
+	name      string
+	client    *MCPClient
+	shared    bool
+	tools     []MCPTool
+	resources []MCPResource
+	err       error
+}
+
+// mcpListedMsg carries the tools and resources of a connected server
+// listed again on refresh
+type mcpListedMsg struct {
+	name      string
+	tools     []MCPTool
+	resources []MCPResource
+	err       error
+}
+
+// mcpResponseMsg carries the exchange of a tool call or resource read
+type mcpResponseMsg struct {
+	server   string
+	request  string
+	response string
+	err      error
+}
+
+// openMCP shows the configured MCP servers
+func (m *Model) openMCP() tea.Cmd {
+	v := &m.mcp
+	v.loadServers(m.mcpServers)
+	v.output = viewport.New(max(m.width-4, 20), max(m.height-20, 5))
+	v.message = ""
+	if len(v.names) == 0 {
+		v.message = fmt.Sprintf("No MCP servers configured: add \"mcp_servers\" to config.json or %s to the repository", mcpSettingsFile)
+	}
+	m.screen = screenMCP
+	return nil
+}
+
+// loadServers reads the configured servers, keeping the cursor on the
+// same server when it is still configured
+func (v *mcpView) loadServers(configured map[string]MCPServerConfig) {
+	selected := v.selectedServer()
+	v.servers = LoadMCPServers(configured)
+	v.names = v.names[:0]
+	for name := range v.servers {
+		v.names = append(v.names, name)
+	}
+	sort.Strings(v.names)
+	v.cursor = 0
+	for i, name := range v.names {
+		if name == selected {
+			v.cursor = i
+		}
+	}
+}
+
+// listMCPCmd lists the tools and resources of a connected server again
+func listMCPCmd(name string, client *MCPClient) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
+		defer cancel()
+		msg := mcpListedMsg{name: name}
+		msg.tools, msg.err = client.ListTools(ctx)
+		msg.resources, _ = client.ListResources(ctx)
+		return msg
+	}
+}
+
+// connectMCPCmd connects to a server and lists its tools and resources.
+// A server run by the supervisor is reached through its connection.
+func connectMCPCmd(name string, cfg MCPServerConfig, supervised *MCPClient) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
+		defer cancel()
+		client, shared := supervised, supervised != nil
+		if !shared {
+			var err error
+			if client, err = ConnectMCP(ctx, cfg); err != nil {
+				return mcpConnectedMsg{name: name, err: err}
+			}
+		}
+		msg := mcpConnectedMsg{name: name, client: client, shared: shared}
+		msg.tools, msg.err = client.ListTools(ctx)
+		// resources are optional, servers without them answer with an error
+		msg.resources, _ = client.ListResources(ctx)
+		return msg
+	}
+}
+
+// callMCPToolCmd invokes a tool and formats the request and response
+func callMCPToolCmd(server string, client *MCPClient, tool string, args map[string]interface{}) tea.Cmd {
+	return func() tea.Msg {
+		request, _ := json.Marshal(map[string]interface{}{"name": tool, "arguments": args})
+		msg := mcpResponseMsg{server: server, request: "tools/call " + prettyJSON(request)}
+		ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
+		defer cancel()
+		result, raw, err := client.CallTool(ctx, tool, args)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		status := "result"
+		if result.IsError {
+			status = "tool reported an error"
+		}
+		msg.response = fmt.Sprintf("%s:\n%s\n\nRaw response:\n%s", status, formatMCPContent(result.Content), prettyJSON(raw))
+		if result.IsError {
+			msg.err = fmt.Errorf("%s failed", tool)
+		}
+		return msg
+	}
+}
+
+// readMCPResourceCmd reads a resource and formats the request and response
+func readMCPResourceCmd(server string, client *MCPClient, uri string) tea.Cmd {
+	return func() tea.Msg {
+		request, _ := json.Marshal(map[string]string{"uri": uri})
+		msg := mcpResponseMsg{server: server, request: "resources/read " + prettyJSON(request)}
+		ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
+		defer cancel()
+		contents, raw, err := client.ReadResource(ctx, uri)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.response = fmt.Sprintf("contents:\n%s\n\nRaw response:\n%s", formatMCPContent(contents), prettyJSON(raw))
+		return msg
+	}
+}
+
+// disconnect closes the connection to the current server
+func (v *mcpView) disconnect() {
+	if v.client != nil && !v.shared {
+		v.client.Close()
+	}
+	v.client, v.shared, v.connected = nil, false, ""
+	v.tools, v.resources = nil, nil
+	v.focusItems, v.item = false, 0
+	v.refreshing = false
+	v.form.active = false
+}
+
+// openMCPForm asks for the arguments of tool, or calls it right away
+// when it takes none
+func (m *Model) openMCPForm(tool MCPTool) tea.Cmd {
+	v := &m.mcp
+	fields := mcpFields(tool.InputSchema)
+	if len(fields) == 0 {
+		v.busy = "Calling " + tool.Name + "…"
+		return callMCPToolCmd(v.connected, v.client, tool.Name, map[string]interface{}{})
+	}
+	form := mcpForm{active: true, tool: tool, fields: fields}
+	for _, field := range fields {
+		input := newTextInput()
+		input.Prompt = ""
+		input.Placeholder = field.Type
+		input.CharLimit = 2000
+		input.Width = 50
+		form.inputs = append(form.inputs, input)
+	}
+	v.form = form
+	return v.form.inputs[0].Focus()
+}
+
+// setFocus moves the cursor to field i
+func (f *mcpForm) setFocus(i int) tea.Cmd {
+	f.inputs[f.focus].Blur()
+	f.focus = (i + len(f.inputs)) % len(f.inputs)
+	return f.inputs[f.focus].Focus()
+}
+
+// updateMCPForm handles keys while tool arguments are entered
+func (m Model) updateMCPForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.mcp
+	f := &v.form
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.active = false
+		return m, nil
+	case tea.KeyTab, tea.KeyDown:
+		return m, f.setFocus(f.focus + 1)
+	case tea.KeyShiftTab, tea.KeyUp:
+		return m, f.setFocus(f.focus - 1)
+	case tea.KeyEnter:
+		values := make([]string, len(f.inputs))
+		for i, input := range f.inputs {
+			values[i] = input.Value()
+		}
+		args, err := mcpArguments(f.fields, values)
+		if err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+		f.active = false
+		v.busy = "Calling " + f.tool.Name + "…"
+		return m, callMCPToolCmd(v.connected, v.client, f.tool.Name, args)
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	f.err = ""
+	return m, cmd
+}
+
+// updateMCP handles input and responses on the MCP screen. Responses
+// arrive here from any screen so connections are never leaked.
+func (m Model) updateMCP(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.mcp
+	switch msg := msg.(type) {
+	case mcpConnectedMsg:
+		v.busy = ""
+		if msg.client != nil && !msg.shared && (m.screen != screenMCP || v.client != nil || msg.err != nil) {
+			msg.client.Close()
+		}
+		if m.screen != screenMCP || v.client != nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			v.message = fmt.Sprintf("%s: %v", msg.name, msg.err)
+			return m, nil
+		}
+		v.client, v.shared, v.connected = msg.client, msg.shared, msg.name
+		v.tools, v.resources = msg.tools, msg.resources
+		v.focusItems, v.item = true, 0
+		v.message = fmt.Sprintf("Connected to %s %s", msg.client.Server.Name, msg.client.Server.Version)
+		v.output.SetContent(msg.client.Instructions)
+		return m, nil
+	case mcpListedMsg:
+		if msg.name != v.connected {
+			return m, nil
+		}
+		v.refreshing = false
+		if msg.err != nil {
+			v.message = fmt.Sprintf("%s: %v", msg.name, msg.err)
+			return m, nil
+		}
+		v.tools, v.resources = msg.tools, msg.resources
+		items := len(v.tools)
+		if v.showResources {
+			items = len(v.resources)
+		}
+		if v.item >= items {
+			v.item = 0
+		}
+		v.message = fmt.Sprintf("Listed %d tools and %d resources of %s", len(v.tools), len(v.resources), msg.name)
+		return m, nil
+	case mcpResponseMsg:
+		if msg.server != v.connected {
+			return m, nil
+		}
+		v.busy = ""
+		v.message = ""
+		content := "→ " + msg.request + "\n\n"
+		if msg.err != nil {
+			v.message = msg.err.Error()
+			content += "← error: " + msg.err.Error() + "\n\n"
+		}
+		content += msg.response
+		v.output.SetContent(content)
+		v.output.GotoTop()
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.screen != screenMCP {
+		return m, nil
+	}
+	if v.form.active {
+		return m.updateMCPForm(keyMsg)
+	}
+
+	items := len(v.tools)
+	if v.showResources {
+		items = len(v.resources)
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		v.disconnect()
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		v.disconnect()
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.ToggleCategory):
+		v.showResources = !v.showResources
+		v.item = 0
+	case key.Matches(keyMsg, m.keys.Left):
+		v.focusItems = false
+	case key.Matches(keyMsg, m.keys.Right):
+		v.focusItems = v.client != nil
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.focusItems && v.item > 0 {
+			v.item--
+		} else if !v.focusItems && v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.focusItems && v.item < items-1 {
+			v.item++
+		} else if !v.focusItems && v.cursor < len(v.names)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		v.loadServers(m.mcpServers)
+		if v.client == nil || v.refreshing {
+			return m, nil
+		}
+		v.refreshing = true
+		return m, listMCPCmd(v.connected, v.client)
+	case key.Matches(keyMsg, m.keys.ServerToggle), key.Matches(keyMsg, m.keys.ServerRestart):
+		if v.focusItems || v.cursor >= len(v.names) {
+			return m, nil
+		}
+		name := v.names[v.cursor]
+		if name == v.connected && v.shared {
+			v.disconnect()
+		}
+		if key.Matches(keyMsg, m.keys.ServerToggle) {
+			v.message = m.toggleServer(name)
+		} else {
+			v.message = m.restartServer(name)
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if v.busy != "" {
+			return m, nil
+		}
+		if !v.focusItems {
+			if v.cursor >= len(v.names) {
+				return m, nil
+			}
+			name := v.names[v.cursor]
+			v.disconnect()
+			v.busy = "Connecting to " + name + "…"
+			v.message = ""
+			v.output.SetContent("")
+			return m, connectMCPCmd(name, v.servers[name], m.supervisor.Client(name))
+		}
+		if v.item >= items {
+			return m, nil
+		}
+		if v.showResources {
+			v.busy = "Reading " + v.resources[v.item].URI + "…"
+			return m, readMCPResourceCmd(v.connected, v.client, v.resources[v.item].URI)
+		}
+		return m, m.openMCPForm(v.tools[v.item])
+	default:
+		var cmd tea.Cmd
+		v.output, cmd = v.output.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// selectedServer returns the name of the server under the cursor
+func (v mcpView) selectedServer() string {
+	if v.cursor < len(v.names) {
+		return v.names[v.cursor]
+	}
+	return ""
+}
+
+// toggleServer starts a stopped server or stops a running one and
+// returns a message describing the outcome
+func (m *Model) toggleServer(name string) string {
+	var err error
+	if m.supervisor.Status(name).Active() {
+		if err = m.supervisor.Stop(name); err == nil {
+			return "Stopped " + name
+		}
+	} else if cfg, ok := LoadMCPServers(m.mcpServers)[name]; !ok {
+		err = fmt.Errorf("%s is not configured in config.json or %s", name, mcpSettingsFile)
+	} else if err = m.supervisor.Start(name, cfg); err == nil {
+		return "Starting " + name + "…"
+	}
+	return err.Error()
+}
+
+// restartServer restarts a server, starting it when it is not running
+func (m *Model) restartServer(name string) string {
+	cfg, ok := LoadMCPServers(m.mcpServers)[name]
+	if !ok {
+		return fmt.Sprintf("%s is not configured in config.json or %s", name, mcpSettingsFile)
+	}
+	if err := m.supervisor.Restart(name, cfg); err != nil {
+		return err.Error()
+	}
+	return "Restarting " + name + "…"
+}
+
+// renderMCPList renders one column of the MCP screen
+func renderMCPList(title string, lines []string, cursor int, focused bool) string {
+	var content strings.Builder
+	style := descriptionStyle.Bold(true)
+	if focused {
+		style = selectedItemStyle
+	}
+	content.WriteString(style.Render(title))
+	content.WriteString("\n")
+	if len(lines) == 0 {
+		content.WriteString(helpStyle.Render("  none"))
+		content.WriteString("\n")
+	}
+	for i, line := range lines {
+		if i == cursor && focused {
+			content.WriteString(selectedItemStyle.Render("▶ " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// renderMCP renders the servers, the tools or resources of the
+// connected one and the last request and response
+func (m Model) renderMCP() string {
+	v := m.mcp
+	var content strings.Builder
+	title := titleStyle.Render("🔌 MCP Servers")
+	summary := fmt.Sprintf("%d configured", len(v.names))
+	if v.connected != "" {
+		summary += " | connected to " + v.connected
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	var servers []string
+	for _, name := range v.names {
+		mark := " "
+		if name == v.connected {
+			mark = "⇄"
+		}
+		state := v.servers[name].Transport()
+		if status := m.supervisor.Status(name); status.State != ServerStopped {
+			state = status.Icon() + " " + status.Label()
+		}
+		servers = append(servers, fmt.Sprintf("%s %-18s %s", mark, name, helpStyle.Render(state)))
+	}
+	var items []string
+	itemsTitle := "Tools"
+	if v.showResources {
+		itemsTitle = "Resources"
+		for _, r := range v.resources {
+			items = append(items, fmt.Sprintf("%s %s", r.Name, helpStyle.Render(r.URI)))
+		}
+	} else {
+		for _, t := range v.tools {
+			items = append(items, fmt.Sprintf("%s %s", t.Name, helpStyle.Render(truncate(t.Description, 50))))
+		}
+	}
+	left := lipgloss.NewStyle().Width(52).Render(renderMCPList("Servers", servers, v.cursor, !v.focusItems))
+	right := renderStale(v.refreshing, renderMCPList(fmt.Sprintf("%s (%d)", itemsTitle, len(items)), items, v.item, v.focusItems))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, "  ", right))
+	content.WriteString("\n")
+
+	if v.form.active {
+		f := v.form
+		content.WriteString(descriptionStyle.Bold(true).Render("Arguments for " + f.tool.Name + ":"))
+		content.WriteString("\n")
+		for i, field := range f.fields {
+			label := field.Name
+			if field.Required {
+				label += "*"
+			}
+			line := fmt.Sprintf("%-16s %s %s", label, f.inputs[i].View(), helpStyle.Render(truncate(field.Description, 40)))
+			if i == f.focus {
+				content.WriteString(selectedItemStyle.Render("▶ ") + line)
+			} else {
+				content.WriteString("  " + line)
+			}
+			content.WriteString("\n")
+		}
+		if f.err != "" {
+			content.WriteString(warningStyle.Render(f.err))
+			content.WriteString("\n")
+		}
+		content.WriteString(helpStyle.Render("enter: call | tab/↑/↓: next field | esc: cancel | objects and arrays as JSON"))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(v.output.View())
+		content.WriteString("\n")
+	}
+
+	if status := m.supervisor.Status(v.selectedServer()); status.Error != "" {
+		content.WriteString(warningStyle.Render(truncate(status.Error, max(m.width-4, 20))))
+		content.WriteString("\n")
+	}
+	if v.busy != "" {
+		content.WriteString(commandStyle.Render("⏳ " + v.busy))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("servers/items", k.Left, k.Right), hint("connect/call/read", k.Enter), hint("start/stop", k.ServerToggle), hint("restart", k.ServerRestart), hint("tools/resources", k.ToggleCategory), hint("refresh", k.Refresh), "pgup/pgdn: scroll response", hint("disconnect and back", k.Back)}, " | ")))
+	return content.String()
+}
+
+// truncate shortens the first line of text to width runes
+func truncate(text string, width int) string {
+	text, _, _ = strings.Cut(text, "\n")
+	if runes := []rune(text); len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// memoryDBName is the database of the Hierarchical Memory tool, created
+// in the directory it runs in
+const memoryDBName = "hierarchical_memory.db"
+
+// basicMemoryDBName is the database of the Memory Manager tool, created
+// in the directory it runs in as well
+const basicMemoryDBName = "memory.db"
+
+// MemoryConfig configures the Memory browser
+type MemoryConfig struct {
+	// DB is the hierarchical memory database; a relative path is taken
+	// from the repository root
+	DB string `json:"db,omitempty"`
+	// BasicDB is the Memory Manager database AI Sessions searches too,
+	// found the same way
+	BasicDB string `json:"basic_db,omitempty"`
+	// RetentionDays is the age of the conversation turns the retention
+	// action deletes, defaultMemoryRetentionDays when unset
+	RetentionDays int `json:"retention_days,omitempty"`
+}
+
+// memoryPath returns the database the Memory browser reads: the
+// configured one, else the one of the current project, else the one in
+// the repository root
+func memoryPath(cfg *MemoryConfig, projectDir string) string {
+	var configured string
+	if cfg != nil {
+		configured = cfg.DB
+	}
+	return findMemoryDB(configured, memoryDBName, projectDir)
+}
+
+// basicMemoryPath returns the Memory Manager database, found like the
+// hierarchical one
+func basicMemoryPath(cfg *MemoryConfig, projectDir string) string {
+	var configured string
+	if cfg != nil {
+		configured = cfg.BasicDB
+	}
+	return findMemoryDB(configured, basicMemoryDBName, projectDir)
+}
+
+// findMemoryDB returns the configured database, else the one called name
+// in the current project, else the one in the repository root
+func findMemoryDB(configured, name, projectDir string) string {
+	if configured != "" {
+		return resolvePath(defaultWorkDir, configured)
+	}
+	if projectDir != "" {
+		if path := filepath.Join(projectDir, name); fileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(defaultWorkDir, name)
+}
+
+// memoryNode is a row of memory_nodes with its place in the hierarchy
+type memoryNode struct {
+	ID          string
+	ParentID    string
+	Type        string
+	Title       string
+	Content     string
+	Metadata    map[string]any
+	Weight      float64
+	AccessCount int64
+	Created     string
+	Updated     string
+	Tags        []string
+	Parent      *memoryNode
+	Children    []*memoryNode
+	// Relations are the typed links of node_relationships from and to
+	// the node, strongest first
+	Relations []memoryRelation
+}
+
+// memoryRelation is a link between two nodes, such as "uses" or "is_a",
+// seen from one of them
+type memoryRelation struct {
+	Type     string
+	Strength float64
+	// Node is the node at the other end
+	Node *memoryNode
+	// Outgoing is set when the link starts at the node it is listed
+	// under, so it reads "node Type Node"
+	Outgoing bool
+}
+
+// Arrow shows the direction of the relation
+func (r memoryRelation) Arrow() string {
+	if r.Outgoing {
+		return "→"
+	}
+	return "←"
+}
+
+// Role returns the speaker of a conversation node
+func (n *memoryNode) Role() string {
+	role, _ := n.Metadata["role"].(string)
+	return role
+}
+
+// memorySession is a row of sessions with its root node
+type memorySession struct {
+	ID         string
+	Title      string
+	Created    string
+	LastActive string
+	Root       *memoryNode
+}
+
+// conversation returns the conversation turns of the session in the
+// order they were added
+func (s *memorySession) conversation() []*memoryNode {
+	if s.Root == nil {
+		return nil
+	}
+	var turns []*memoryNode
+	for _, child := range s.Root.Children {
+		if child.Type == "conversation" {
+			turns = append(turns, child)
+		}
+	}
+	return turns
+}
+
+// taggedNode is a node found by its tag
+type taggedNode struct {
+	Node       *memoryNode
+	Confidence float64
+}
+
+// memorySuggestion is a tag auto-organization proposes for a node,
+// applied once approved in the review queue
+type memorySuggestion struct {
+	Node       *memoryNode
+	Tag        string
+	Reason     string
+	Confidence float64
+	Created    string
+}
+
+// label names the node of the suggestion, by its content when it has
+// no title as conversation turns do
+func (s memorySuggestion) label() string {
+	return valueOr(s.Node.Title, truncate(strings.TrimSpace(s.Node.Content), 30))
+}
+
+// memoryStore is the content of a hierarchical memory database
+type memoryStore struct {
+	Path     string
+	Sessions []*memorySession
+	Nodes    map[string]*memoryNode
+	// Loose are the top-level nodes no session owns, such as concepts
+	Loose []*memoryNode
+	// Tags maps tag names to their nodes, most confident first
+	Tags map[string][]taggedNode
+	// Relationships counts the links between nodes
+	Relationships int
+	// Suggestions are the pending tag suggestions, oldest first
+	Suggestions []memorySuggestion
+}
+
+// loadMemory reads the sessions, nodes and tags of the database at path
+func loadMemory(path string) (*memoryStore, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	if !db.hasTable("memory_nodes") {
+		return nil, fmt.Errorf("%s is not a hierarchical memory database (no memory_nodes table)", path)
+	}
+	store := &memoryStore{Path: path, Nodes: map[string]*memoryNode{}, Tags: map[string][]taggedNode{}}
+	rows, err := db.Rows("memory_nodes")
+	if err != nil {
+		return nil, err
+	}
+	// rows come in insertion order, which orders turns created within
+	// the same second
+	order := make([]*memoryNode, 0, len(rows))
+	for _, row := range rows {
+		node := &memoryNode{
+			ID:          rowString(row, "id"),
+			ParentID:    rowString(row, "parent_id"),
+			Type:        rowString(row, "node_type"),
+			Title:       rowString(row, "title"),
+			Content:     rowString(row, "content"),
+			Weight:      rowFloat(row, "weight"),
+			AccessCount: int64(rowFloat(row, "access_count")),
+			Created:     rowString(row, "created_at"),
+			Updated:     rowString(row, "updated_at"),
+		}
+		json.Unmarshal([]byte(rowString(row, "metadata")), &node.Metadata)
+		store.Nodes[node.ID] = node
+		order = append(order, node)
+	}
+	var tops []*memoryNode
+	for _, node := range order {
+		if parent, ok := store.Nodes[node.ParentID]; ok && parent != node {
+			node.Parent = parent
+			parent.Children = append(parent.Children, node)
+		} else {
+			tops = append(tops, node)
+		}
+	}
+	for _, node := range store.Nodes {
+		sortMemoryNodes(node.Children)
+	}
+
+	owned := map[string]bool{}
+	if db.hasTable("sessions") {
+		rows, err := db.Rows("sessions")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			session := &memorySession{
+				ID:         rowString(row, "id"),
+				Title:      rowString(row, "title"),
+				Created:    rowString(row, "created_at"),
+				LastActive: rowString(row, "last_active"),
+				Root:       store.Nodes[rowString(row, "root_node_id")],
+			}
+			if session.Root != nil {
+				owned[session.Root.ID] = true
+			}
+			store.Sessions = append(store.Sessions, session)
+		}
+	}
+	sort.SliceStable(store.Sessions, func(i, j int) bool {
+		return store.Sessions[i].LastActive > store.Sessions[j].LastActive
+	})
+	for _, node := range tops {
+		if !owned[node.ID] {
+			store.Loose = append(store.Loose, node)
+		}
+	}
+	sortMemoryNodes(store.Loose)
+
+	if db.hasTable("tags") && db.hasTable("node_tags") {
+		tags, err := db.Rows("tags")
+		if err != nil {
+			return nil, err
+		}
+		names := map[string]string{}
+		for _, row := range tags {
+			names[rowString(row, "id")] = rowString(row, "name")
+		}
+		links, err := db.Rows("node_tags")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range links {
+			node, name := store.Nodes[rowString(row, "node_id")], names[rowString(row, "tag_id")]
+			if node == nil || name == "" {
+				continue
+			}
+			node.Tags = append(node.Tags, name)
+			store.Tags[name] = append(store.Tags[name], taggedNode{Node: node, Confidence: rowFloat(row, "confidence")})
+		}
+		for name, nodes := range store.Tags {
+			sort.SliceStable(nodes, func(i, j int) bool {
+				if nodes[i].Confidence != nodes[j].Confidence {
+					return nodes[i].Confidence > nodes[j].Confidence
+				}
+				return nodes[i].Node.Weight > nodes[j].Node.Weight
+			})
+			store.Tags[name] = nodes
+		}
+		for _, node := range store.Nodes {
+			sort.Strings(node.Tags)
+		}
+	}
+	if db.hasTable("node_relationships") {
+		rows, err := db.Rows("node_relationships")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			source, target := store.Nodes[rowString(row, "source_id")], store.Nodes[rowString(row, "target_id")]
+			if source == nil || target == nil {
+				continue
+			}
+			kind, strength := rowString(row, "relationship_type"), rowFloat(row, "strength")
+			source.Relations = append(source.Relations, memoryRelation{Type: kind, Strength: strength, Node: target, Outgoing: true})
+			if target != source {
+				target.Relations = append(target.Relations, memoryRelation{Type: kind, Strength: strength, Node: source})
+			}
+			store.Relationships++
+		}
+		for _, node := range store.Nodes {
+			sort.SliceStable(node.Relations, func(i, j int) bool {
+				return node.Relations[i].Strength > node.Relations[j].Strength
+			})
+		}
+	}
+	if db.hasTable("tag_suggestions") {
+		rows, err := db.Rows("tag_suggestions")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			node := store.Nodes[rowString(row, "node_id")]
+			if node == nil || rowString(row, "status") != "pending" {
+				continue
+			}
+			store.Suggestions = append(store.Suggestions, memorySuggestion{
+				Node:       node,
+				Tag:        rowString(row, "tag_name"),
+				Reason:     rowString(row, "reason"),
+				Confidence: rowFloat(row, "confidence"),
+				Created:    rowString(row, "created_at"),
+			})
+		}
+		sort.SliceStable(store.Suggestions, func(i, j int) bool {
+			return store.Suggestions[i].Created < store.Suggestions[j].Created
+		})
+	}
+	return store, nil
+}
+
+// sortMemoryNodes orders nodes by creation
+func sortMemoryNodes(nodes []*memoryNode) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Created < nodes[j].Created })
+}
+
+// session returns the session whose hierarchy holds node, or nil
+func (s *memoryStore) session(node *memoryNode) *memorySession {
+	for node.Parent != nil {
+		node = node.Parent
+	}
+	for _, session := range s.Sessions {
+		if session.Root == node {
+			return session
+		}
+	}
+	return nil
+}
+
+// searchTags returns the tags containing query, those starting with it
+// first
+func (s *memoryStore) searchTags(query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var prefix, contains []string
+	for name := range s.Tags {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, query):
+			prefix = append(prefix, name)
+		case strings.Contains(lower, query):
+			contains = append(contains, name)
+		}
+	}
+	sort.Strings(prefix)
+	sort.Strings(contains)
+	return append(prefix, contains...)
+}
+
+// rowString returns a column as text, empty for NULL
+func rowString(row map[string]any, column string) string {
+	switch v := row[column].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// rowFloat returns a numeric column, 0 for NULL or text
+func rowFloat(row map[string]any, column string) float64 {
+	switch v := row[column].(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// memoryUndoWindow is how long a bulk edit of the memory database can
+// be undone
+const memoryUndoWindow = 30 * time.Second
+
+// memoryUndoFile is the copy of the memory database taken before the
+// latest bulk edit, in the config directory
+const memoryUndoFile = "memory_undo.db"
+
+// memoryEditScript applies SQL statements read as JSON from stdin to the
+// database in one transaction after copying it to the backup, with
+// "restore" copies the backup back and with "vacuum" rebuilds the
+// database. The sqlite3 backup API copies a consistent database whatever
+// its journal mode.
+const memoryEditScript = `import json, sqlite3, sys
+path, backup, mode = sys.argv[1:4]
+db = sqlite3.connect(path)
+if mode == "vacuum":
+    db.execute("VACUUM")
+    db.close()
+    sys.exit()
+copy = sqlite3.connect(backup)
+if mode == "restore":
+    copy.backup(db)
+else:
+    db.backup(copy)
+    with db:
+        for statement in json.load(sys.stdin):
+            db.execute(statement["sql"], statement["args"])
+copy.close()
+db.close()
+`
+
+// memoryStatement is an SQL statement of a bulk edit with its
+// arguments: a list for ? placeholders or a map for :name ones
+type memoryStatement struct {
+	SQL  string `json:"sql"`
+	Args any    `json:"args"`
+}
+
+// memoryUndo is the latest bulk edit, undoable until its deadline as
+// long as nothing else wrote to the database since
+type memoryUndo struct {
+	label    string
+	backup   string
+	deadline time.Time
+	// modified and size are those of the database after the edit
+	modified time.Time
+	size     int64
+}
+
+// memoryUndoExpiredMsg closes the undo window of the edit made at
+// deadline minus the window
+type memoryUndoExpiredMsg struct {
+	deadline time.Time
+}
+
+// runMemoryScript runs memoryEditScript with the Python the memory tools
+// use
+func runMemoryScript(path, backup, mode string, statements []memoryStatement) error {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		return fmt.Errorf("editing the memory database needs python3 on PATH")
+	}
+	input, err := json.Marshal(statements)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(python, "-c", memoryEditScript, path, backup, mode)
+	cmd.Stdin = strings.NewReader(string(input))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// parseTagEdit reads the tag prompt: names to add, alone or after "+",
+// and names after "-" to remove, separated by spaces or commas
+func parseTagEdit(text string) (add, remove []string) {
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == ',' }) {
+		switch {
+		case strings.HasPrefix(word, "-") && len(word) > 1:
+			remove = append(remove, word[1:])
+		case strings.HasPrefix(word, "+") && len(word) > 1:
+			add = append(add, word[1:])
+		case word != "+" && word != "-":
+			add = append(add, word)
+		}
+	}
+	return add, remove
+}
+
+// tagStatements adds and removes tags of nodes; tags that do not exist
+// yet are created the way the memory tool creates them
+func tagStatements(nodes []*memoryNode, add, remove []string) []memoryStatement {
+	var statements []memoryStatement
+	for _, name := range add {
+		statements = append(statements, memoryStatement{
+			SQL:  "INSERT OR IGNORE INTO tags (id, name, color, description) VALUES (lower(hex(randomblob(16))), ?, '#007acc', '')",
+			Args: []any{name},
+		})
+	}
+	for _, node := range nodes {
+		for _, name := range add {
+			statements = append(statements, memoryStatement{
+				SQL:  "INSERT OR IGNORE INTO node_tags (node_id, tag_id, confidence) SELECT ?, id, 1.0 FROM tags WHERE name = ?",
+				Args: []any{node.ID, name},
+			})
+		}
+		for _, name := range remove {
+			statements = append(statements, memoryStatement{
+				SQL:  "DELETE FROM node_tags WHERE node_id = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)",
+				Args: []any{node.ID, name},
+			})
+		}
+	}
+	return statements
+}
+
+// reviewStatements closes a tag suggestion with status, "approved",
+// "retagged" or "rejected", after tagging its node with tags
+func reviewStatements(s memorySuggestion, status string, tags []string) []memoryStatement {
+	statements := tagStatements([]*memoryNode{s.Node}, tags, nil)
+	return append(statements, memoryStatement{
+		SQL:  "UPDATE tag_suggestions SET status = ?, reviewed_at = CURRENT_TIMESTAMP WHERE node_id = ? AND tag_name = ?",
+		Args: []any{status, s.Node.ID, s.Tag},
+	})
+}
+
+// within reports whether node is ancestor or one of its descendants
+func within(node, ancestor *memoryNode) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// markedAncestor reports whether an ancestor of node is in set
+func markedAncestor(node *memoryNode, set map[*memoryNode]bool) bool {
+	for n := node.Parent; n != nil; n = n.Parent {
+		if set[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// moveStatements moves the subtrees of nodes under parent, or to the top
+// level when parent is nil. A node cannot move into its own subtree and
+// session roots stay where their session expects them.
+func (s *memoryStore) moveStatements(nodes []*memoryNode, parent *memoryNode) ([]memoryStatement, error) {
+	var statements []memoryStatement
+	moved := map[*memoryNode]bool{}
+	for _, node := range nodes {
+		moved[node] = true
+	}
+	for _, node := range nodes {
+		// a marked node inside a marked subtree moves with it
+		if markedAncestor(node, moved) {
+			continue
+		}
+		if parent != nil && within(parent, node) {
+			return nil, fmt.Errorf("cannot move %s under itself or one of its descendants", valueOr(node.Title, node.ID))
+		}
+		if session := s.session(node); session != nil && session.Root == node {
+			return nil, fmt.Errorf("%s is the root of session %s and cannot move", valueOr(node.Title, node.ID), valueOr(session.Title, session.ID))
+		}
+		var parentID any
+		if parent != nil {
+			parentID = parent.ID
+		}
+		statements = append(statements, memoryStatement{
+			SQL:  "UPDATE memory_nodes SET parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			Args: []any{parentID, node.ID},
+		})
+	}
+	return statements, nil
+}
+
+// mergeStatements merges duplicates into target: their children, tags,
+// relations and sessions move to it, their access counts add up, the
+// higher weight is kept and the duplicates are deleted
+func mergeStatements(target *memoryNode, duplicates []*memoryNode) ([]memoryStatement, error) {
+	var statements []memoryStatement
+	for _, d := range duplicates {
+		if within(target, d) {
+			return nil, fmt.Errorf("cannot merge %s into one of its descendants", valueOr(d.Title, d.ID))
+		}
+		for _, step := range []string{
+			"UPDATE memory_nodes SET parent_id = :target WHERE parent_id = :duplicate",
+			"INSERT OR IGNORE INTO node_tags (node_id, tag_id, confidence) SELECT :target, tag_id, confidence FROM node_tags WHERE node_id = :duplicate",
+			"DELETE FROM node_tags WHERE node_id = :duplicate",
+			"UPDATE node_relationships SET source_id = :target WHERE source_id = :duplicate",
+			"UPDATE node_relationships SET target_id = :target WHERE target_id = :duplicate",
+			"DELETE FROM node_relationships WHERE source_id = :target AND target_id = :target",
+			"UPDATE sessions SET root_node_id = :target WHERE root_node_id = :duplicate",
+			"UPDATE memory_nodes SET access_count = access_count + (SELECT access_count FROM memory_nodes WHERE id = :duplicate), weight = max(weight, (SELECT weight FROM memory_nodes WHERE id = :duplicate)), updated_at = CURRENT_TIMESTAMP WHERE id = :target",
+			"DELETE FROM memory_nodes WHERE id = :duplicate",
+		} {
+			statements = append(statements, memoryStatement{
+				SQL:  step,
+				Args: map[string]any{"target": target.ID, "duplicate": d.ID},
+			})
+		}
+	}
+	return statements, nil
+}
+
+// markedNodes returns the marked nodes in tree order
+func (v memoryView) markedNodes() []*memoryNode {
+	var nodes []*memoryNode
+	seen := map[string]bool{}
+	var visit func(node *memoryNode)
+	visit = func(node *memoryNode) {
+		if v.marked[node.ID] && !seen[node.ID] {
+			seen[node.ID] = true
+			nodes = append(nodes, node)
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, session := range v.store.Sessions {
+		if session.Root != nil {
+			visit(session.Root)
+		}
+	}
+	for _, node := range v.store.Loose {
+		visit(node)
+	}
+	return nodes
+}
+
+// targets returns the marked nodes, or the one under the cursor when
+// none is marked
+func (v memoryView) targets() []*memoryNode {
+	if nodes := v.markedNodes(); len(nodes) > 0 {
+		return nodes
+	}
+	if row, ok := v.selected(); ok && row.Node != nil {
+		return []*memoryNode{row.Node}
+	}
+	return nil
+}
+
+// applyEdit runs the statements of a bulk edit, reloads the database
+// and opens the undo window
+func (v *memoryView) applyEdit(label string, statements []memoryStatement) tea.Cmd {
+	backup := filepath.Join(ConfigDir(), memoryUndoFile)
+	if err := os.MkdirAll(ConfigDir(), 0o755); err != nil {
+		v.message = "Could not keep a copy for undo: " + err.Error()
+		return nil
+	}
+	if err := runMemoryScript(v.path, backup, "apply", statements); err != nil {
+		v.message = label + " failed: " + err.Error()
+		return nil
+	}
+	v.marked = map[string]bool{}
+	v.load()
+	deadline := time.Now().Add(memoryUndoWindow)
+	v.undo = &memoryUndo{label: label, backup: backup, deadline: deadline}
+	if info, err := os.Stat(v.path); err == nil {
+		v.undo.modified, v.undo.size = info.ModTime(), info.Size()
+	}
+	v.message = fmt.Sprintf("%s · undo within %s", label, memoryUndoWindow)
+	return tea.Tick(memoryUndoWindow, func(time.Time) tea.Msg {
+		return memoryUndoExpiredMsg{deadline: deadline}
+	})
+}
+
+// undoEdit restores the database as it was before the latest bulk edit,
+// unless something else wrote to it since
+func (v *memoryView) undoEdit() {
+	u := v.undo
+	if u == nil || time.Now().After(u.deadline) {
+		v.message = "Nothing to undo"
+		return
+	}
+	if info, err := os.Stat(v.path); err != nil || !info.ModTime().Equal(u.modified) || info.Size() != u.size {
+		v.message = "The database changed since " + u.label + ", undoing would lose those changes"
+		return
+	}
+	if err := runMemoryScript(v.path, u.backup, "restore", nil); err != nil {
+		v.message = "Undo failed: " + err.Error()
+		return
+	}
+	os.Remove(u.backup)
+	v.undo = nil
+	v.load()
+	v.message = "Undid " + u.label
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// memoryTime parses a CURRENT_TIMESTAMP of a memory database, zero when
+// it is missing
+func memoryTime(value string) time.Time {
+	at, _ := time.ParseInLocation(memoryTimeLayout, value, time.UTC)
+	return at
+}
+
+// readMemoryDB reads the conversations of a memory database as sessions
+// AI Sessions indexes with those of the assistants: a Memory Manager or
+// a hierarchical memory database, told apart by their tables
+func readMemoryDB(path string) ([]*aiSession, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case db.hasTable("memory_nodes"):
+		return readHierarchicalSessions(path)
+	case db.hasTable("conversations"):
+		return readBasicMemorySessions(db, path)
+	}
+	return nil, fmt.Errorf("%s is not a memory database (no conversations or memory_nodes table)", path)
+}
+
+// readBasicMemorySessions groups the turns of a Memory Manager database
+// by their session ID
+func readBasicMemorySessions(db *sqliteDB, path string) ([]*aiSession, error) {
+	rows, err := db.Rows("conversations")
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]*aiSession{}
+	var sessions []*aiSession
+	for _, row := range rows {
+		id := rowString(row, "session_id")
+		s := byID[id]
+		if s == nil {
+			s = &aiSession{Source: "memory", ID: id, Path: path, Project: filepath.Dir(path)}
+			byID[id] = s
+			sessions = append(sessions, s)
+		}
+		s.add(rowString(row, "role"), rowString(row, "content"), memoryTime(rowString(row, "timestamp")))
+	}
+	for _, s := range sessions {
+		s.finish()
+		if s.Title == "" {
+			s.Title = s.ID
+		}
+	}
+	return sessions, nil
+}
+
+// readHierarchicalSessions reads every session of a hierarchical memory
+// database with the nodes under its root, and the nodes no session owns
+// as one more, so facts and concepts are found too
+func readHierarchicalSessions(path string) ([]*aiSession, error) {
+	store, err := loadMemory(path)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*aiSession
+	for _, session := range store.Sessions {
+		s := &aiSession{Source: "hierarchical", ID: session.ID, Path: path, Project: filepath.Dir(path), Title: session.Title}
+		if session.Root != nil {
+			for _, child := range session.Root.Children {
+				addMemoryNodes(s, child)
+			}
+		}
+		s.finish()
+		sessions = append(sessions, s)
+	}
+	if len(store.Loose) > 0 {
+		s := &aiSession{Source: "hierarchical", Path: path, Project: filepath.Dir(path), Title: "Other nodes"}
+		for _, node := range store.Loose {
+			addMemoryNodes(s, node)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// addMemoryNodes adds a node and its descendants to s: conversation
+// turns as said by their role, other nodes by type with their title
+func addMemoryNodes(s *aiSession, node *memoryNode) {
+	if role := node.Role(); node.Type == "conversation" && role != "" {
+		s.add(role, node.Content, memoryTime(node.Created))
+	} else if node.Title != "" && node.Content != "" {
+		s.add(node.Type, node.Title+"\n"+node.Content, memoryTime(node.Created))
+	} else {
+		s.add(node.Type, node.Title+node.Content, memoryTime(node.Created))
+	}
+	for _, child := range node.Children {
+		addMemoryNodes(s, child)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultMemoryRetentionDays is how old conversation turns get before
+// retention deletes them, the data_retention_days default of the Memory
+// Config tool
+const defaultMemoryRetentionDays = 90
+
+// memoryGrowthDays is how many days the growth chart covers
+const memoryGrowthDays = 30
+
+// memoryTimeLayout is how SQLite's CURRENT_TIMESTAMP writes times, in UTC
+const memoryTimeLayout = "2006-01-02 15:04:05"
+
+// memoryCount is a name, such as a node type or tag, with its nodes
+type memoryCount struct {
+	Name  string
+	Count int
+}
+
+// memoryStats describes the size and growth of a memory database
+type memoryStats struct {
+	Size, WALSize int64
+	// FreePages are the pages a vacuum gives back, of PageSize bytes
+	FreePages, PageSize int
+	Types               []memoryCount
+	Tags                []memoryCount
+	// Daily counts the nodes created on each of the last days, oldest
+	// first; Week and Month those of the last 7 and 30 days
+	Daily       []float64
+	Week, Month int
+	// Orphans are the nodes whose parent no longer exists
+	Orphans []*memoryNode
+	// Expired are the conversation turns older than the retention
+	Expired   []*memoryNode
+	Retention int
+}
+
+// retentionDays returns the configured retention, the default when unset
+func (c *MemoryConfig) retentionDays() int {
+	if c != nil && c.RetentionDays > 0 {
+		return c.RetentionDays
+	}
+	return defaultMemoryRetentionDays
+}
+
+// sqliteFreePages reads the page size and the length of the freelist
+// from the header of the database at path
+func sqliteFreePages(path string) (free, pageSize int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	header := make([]byte, 100)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:16]) != sqliteHeader {
+		return 0, 0, fmt.Errorf("%s is not an SQLite database", path)
+	}
+	pageSize = int(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	return int(binary.BigEndian.Uint32(header[36:40])), pageSize, nil
+}
+
+// computeMemoryStats counts the nodes of store by type, tag and day of
+// creation and finds the orphans and the turns retention would delete
+func computeMemoryStats(store *memoryStore, retention int, now time.Time) memoryStats {
+	stats := memoryStats{Retention: retention, Daily: make([]float64, memoryGrowthDays)}
+	if info, err := os.Stat(store.Path); err == nil {
+		stats.Size = info.Size()
+	}
+	if info, err := os.Stat(store.Path + "-wal"); err == nil {
+		stats.WALSize = info.Size()
+	}
+	stats.FreePages, stats.PageSize, _ = sqliteFreePages(store.Path)
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	first := today.AddDate(0, 0, 1-memoryGrowthDays)
+	cutoff := now.AddDate(0, 0, -retention)
+	types := map[string]int{}
+	for _, node := range store.Nodes {
+		types[node.Type]++
+		if node.ParentID != "" && node.Parent == nil {
+			stats.Orphans = append(stats.Orphans, node)
+		}
+		created, err := time.ParseInLocation(memoryTimeLayout, node.Created, time.UTC)
+		if err != nil {
+			continue
+		}
+		if node.Type == "conversation" && created.Before(cutoff) {
+			stats.Expired = append(stats.Expired, node)
+		}
+		if age := now.Sub(created); age < 7*24*time.Hour {
+			stats.Week++
+		}
+		if day := int(created.Local().Sub(first).Hours() / 24); !created.Local().Before(first) && day < memoryGrowthDays {
+			stats.Daily[day]++
+			stats.Month++
+		}
+	}
+	sortMemoryNodes(stats.Orphans)
+	sortMemoryNodes(stats.Expired)
+	stats.Types = sortedCounts(types)
+	tags := map[string]int{}
+	for name, nodes := range store.Tags {
+		tags[name] = len(nodes)
+	}
+	stats.Tags = sortedCounts(tags)
+	return stats
+}
+
+// sortedCounts orders counts from the largest, then by name
+func sortedCounts(counts map[string]int) []memoryCount {
+	var sorted []memoryCount
+	for name, count := range counts {
+		sorted = append(sorted, memoryCount{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// formatCounts lists the first n counts as "name count"
+func formatCounts(counts []memoryCount, prefix string, n int) string {
+	var parts []string
+	for _, c := range counts[:min(n, len(counts))] {
+		parts = append(parts, fmt.Sprintf("%s%s %d", prefix, c.Name, c.Count))
+	}
+	if len(counts) > n {
+		parts = append(parts, fmt.Sprintf("%d more", len(counts)-n))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// memorySubtree selects the IDs of the node given as the only argument
+// and of its descendants
+const memorySubtree = "WITH RECURSIVE subtree(id) AS (SELECT ? UNION ALL SELECT n.id FROM memory_nodes n JOIN subtree ON n.parent_id = subtree.id) SELECT id FROM subtree"
+
+// retentionStatements delete nodes with their descendants and the tags
+// and relations of them all
+func retentionStatements(nodes []*memoryNode) []memoryStatement {
+	var statements []memoryStatement
+	for _, node := range nodes {
+		for _, step := range []string{
+			"DELETE FROM node_tags WHERE node_id IN (" + memorySubtree + ")",
+			"DELETE FROM node_relationships WHERE source_id IN (" + memorySubtree + ")",
+			"DELETE FROM node_relationships WHERE target_id IN (" + memorySubtree + ")",
+			"DELETE FROM memory_nodes WHERE id IN (" + memorySubtree + ")",
+		} {
+			statements = append(statements, memoryStatement{SQL: step, Args: []any{node.ID}})
+		}
+	}
+	return statements
+}
+
+// vacuum rebuilds the database to give its free pages back, after which
+// the latest edit can no longer be undone
+func (v *memoryView) vacuum() {
+	before := v.stats.Size + v.stats.WALSize
+	if err := runMemoryScript(v.path, "", "vacuum", nil); err != nil {
+		v.message = "Vacuum failed: " + err.Error()
+		return
+	}
+	if v.undo != nil {
+		os.Remove(v.undo.backup)
+		v.undo = nil
+	}
+	v.load()
+	v.message = fmt.Sprintf("Vacuumed %s: %s → %s", v.path, formatBytes(before), formatBytes(v.stats.Size+v.stats.WALSize))
+}
+
+// renderMemoryStats describes the size, content and growth of the
+// database with what retention and a vacuum would free
+func (m Model) renderMemoryStats() string {
+	v := m.memory
+	s := v.stats
+	var content strings.Builder
+	row := func(label, value string) {
+		fmt.Fprintf(&content, "%-14s %s\n", descriptionStyle.Bold(true).Render(label), value)
+	}
+	size := formatBytes(s.Size)
+	if s.WALSize > 0 {
+		size += fmt.Sprintf(" + %s write-ahead log", formatBytes(s.WALSize))
+	}
+	if s.FreePages > 0 {
+		size += helpStyle.Render(fmt.Sprintf(" · %s in %d free pages", formatBytes(int64(s.FreePages*s.PageSize)), s.FreePages))
+	}
+	row("Size", size)
+	row("Content", fmt.Sprintf("%d nodes · %d sessions · %d tags · %d relations · %d tag suggestion(s) to review",
+		len(v.store.Nodes), len(v.store.Sessions), len(v.store.Tags), v.store.Relationships, len(v.store.Suggestions)))
+	row("By type", formatCounts(s.Types, "", 6))
+	peak := 0.0
+	for _, n := range s.Daily {
+		peak = max(peak, n)
+	}
+	row("Growth", fmt.Sprintf("%s %s", featureStyle.Render(sparkline(s.Daily, peak)),
+		helpStyle.Render(fmt.Sprintf("+%d this week · +%d in %d days · max %.0f/day", s.Week, s.Month, memoryGrowthDays, peak))))
+	row("Top tags", formatCounts(s.Tags, "#", 8))
+	orphans := "none"
+	if len(s.Orphans) > 0 {
+		var titles []string
+		for _, n := range s.Orphans[:min(3, len(s.Orphans))] {
+			titles = append(titles, truncate(valueOr(n.Title, n.ID), 30))
+		}
+		orphans = fmt.Sprintf("%d whose parent no longer exists", len(s.Orphans)) + helpStyle.Render(": "+strings.Join(titles, ", ")+", listed under Other nodes")
+	}
+	row("Orphaned", orphans)
+	retention := fmt.Sprintf("no conversation turn is older than %d days", s.Retention)
+	if len(s.Expired) > 0 {
+		retention = fmt.Sprintf("%d conversation turn(s) older than %d days, the oldest from %s", len(s.Expired), s.Retention, s.Expired[0].Created)
+	}
+	row("Retention", retention)
+	return content.String()
+}
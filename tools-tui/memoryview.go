@@ -0,0 +1,866 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// memoryListRows is how many rows of the tree are shown around the
+// cursor
+const memoryListRows = 12
+
+// looseGroup is the expanded key of the group of nodes no session owns
+const looseGroup = ""
+
+// memoryRow is a row of the Memory tree: a session, the group of other
+// nodes, or a node under one of them
+type memoryRow struct {
+	Session *memorySession
+	Node    *memoryNode
+	Depth   int
+}
+
+// key returns what the expanded state of the row is kept under
+func (r memoryRow) key() string {
+	if r.Node == nil {
+		return looseGroup
+	}
+	return r.Node.ID
+}
+
+// memoryView holds the state of the Memory screen
+type memoryView struct {
+	store    *memoryStore
+	path     string
+	err      error
+	rows     []memoryRow
+	expanded map[string]bool
+	cursor   int
+	body     viewport.Model
+	// searching reads a tag name; tag lists the nodes carrying the
+	// chosen one until one is picked
+	searching bool
+	search    textinput.Model
+	matches   []string
+	tag       string
+	tagCursor int
+	// graph is the node whose relations are listed instead of the tree,
+	// trail the nodes followed to reach it
+	graph       *memoryNode
+	trail       []*memoryNode
+	graphCursor int
+	// marked are the IDs of the nodes bulk edits apply to; tagging reads
+	// the tags to add and remove
+	marked   map[string]bool
+	tagging  bool
+	tagInput textinput.Model
+	undo     *memoryUndo
+	// review lists the pending tag suggestions instead of the tree
+	review       bool
+	reviewCursor int
+	// showStats shows the statistics of the database instead of the
+	// tree; retention is the age in days of the turns retention deletes
+	showStats bool
+	stats     memoryStats
+	retention int
+	message   string
+}
+
+// openMemory shows the hierarchical memory database of the project
+func (m *Model) openMemory() tea.Cmd {
+	m.memory = memoryView{
+		path:      memoryPath(m.memoryConfig, m.projectDir()),
+		expanded:  map[string]bool{},
+		marked:    map[string]bool{},
+		retention: m.memoryConfig.retentionDays(),
+		body:      viewport.New(max(m.width-4, 20), max(m.height-memoryListRows-12, 5)),
+	}
+	m.memory.load()
+	m.screen = screenMemory
+	return nil
+}
+
+// load reads the database again, keeping the expanded nodes and the
+// cursor on the same row when it still exists
+func (v *memoryView) load() {
+	current, had := v.selected()
+	v.store, v.err = loadMemory(v.path)
+	if v.store != nil {
+		v.stats = computeMemoryStats(v.store, v.retention, time.Now())
+	}
+	for id := range v.marked {
+		if v.store == nil || v.store.Nodes[id] == nil {
+			delete(v.marked, id)
+		}
+	}
+	v.flatten()
+	for i, row := range v.rows {
+		if had && row.key() == current.key() {
+			v.cursor = i
+		}
+	}
+	if v.review {
+		v.showSuggestion()
+		return
+	}
+	v.show()
+}
+
+// flatten lists the rows of the tree as expanded
+func (v *memoryView) flatten() {
+	v.rows = nil
+	if v.store == nil {
+		v.cursor = 0
+		return
+	}
+	var add func(node *memoryNode, depth int)
+	add = func(node *memoryNode, depth int) {
+		v.rows = append(v.rows, memoryRow{Node: node, Depth: depth})
+		if v.expanded[node.ID] {
+			for _, child := range node.Children {
+				add(child, depth+1)
+			}
+		}
+	}
+	for _, session := range v.store.Sessions {
+		v.rows = append(v.rows, memoryRow{Session: session, Node: session.Root})
+		if session.Root != nil && v.expanded[session.Root.ID] {
+			for _, child := range session.Root.Children {
+				add(child, 1)
+			}
+		}
+	}
+	if len(v.store.Loose) > 0 {
+		v.rows = append(v.rows, memoryRow{})
+		if v.expanded[looseGroup] {
+			for _, node := range v.store.Loose {
+				add(node, 1)
+			}
+		}
+	}
+	v.cursor = min(v.cursor, max(len(v.rows)-1, 0))
+}
+
+// selected returns the row under the cursor
+func (v memoryView) selected() (memoryRow, bool) {
+	if v.cursor < len(v.rows) {
+		return v.rows[v.cursor], true
+	}
+	return memoryRow{}, false
+}
+
+// expandable reports whether the row has rows under it
+func (v memoryView) expandable(row memoryRow) bool {
+	if row.Node == nil {
+		return v.store != nil && len(v.store.Loose) > 0
+	}
+	return len(row.Node.Children) > 0
+}
+
+// toggle expands or collapses the selected row
+func (v *memoryView) toggle(expand bool) {
+	row, ok := v.selected()
+	if !ok || !v.expandable(row) {
+		return
+	}
+	v.expanded[row.key()] = expand
+	v.flatten()
+}
+
+// parent moves the cursor to the row the selected one is under
+func (v *memoryView) parent() {
+	row, ok := v.selected()
+	if !ok || row.Depth == 0 {
+		return
+	}
+	for i := v.cursor - 1; i >= 0; i-- {
+		if v.rows[i].Depth < row.Depth {
+			v.cursor = i
+			v.show()
+			return
+		}
+	}
+}
+
+// reveal expands the ancestors of node and moves the cursor to it
+func (v *memoryView) reveal(node *memoryNode) {
+	session := v.store.session(node)
+	top := node
+	for p := node.Parent; p != nil; p = p.Parent {
+		v.expanded[p.ID] = true
+		top = p
+	}
+	if session == nil && top != nil {
+		v.expanded[looseGroup] = true
+	}
+	v.flatten()
+	for i, row := range v.rows {
+		if row.Node == node {
+			v.cursor = i
+		}
+	}
+	v.show()
+}
+
+// show fills the body with the transcript of the selected session or
+// the details of the selected node
+func (v *memoryView) show() {
+	row, ok := v.selected()
+	var content strings.Builder
+	switch {
+	case !ok:
+	case row.Session != nil:
+		s := row.Session
+		fmt.Fprintf(&content, "Session %s · created %s · last active %s\n\n", s.ID, s.Created, s.LastActive)
+		turns := s.conversation()
+		if len(turns) == 0 {
+			content.WriteString("No conversation entries\n")
+		}
+		for _, turn := range turns {
+			fmt.Fprintf(&content, "%s (%s):\n%s\n\n", valueOr(turn.Role(), "unknown"), turn.Created, strings.TrimSpace(turn.Content))
+		}
+	case row.Node == nil:
+		fmt.Fprintf(&content, "%d top-level nodes that belong to no session, such as concepts and imported knowledge\n", len(v.store.Loose))
+	default:
+		content.WriteString(describeMemoryNode(row.Node))
+	}
+	v.body.SetContent(content.String())
+	v.body.GotoTop()
+}
+
+// describeMemoryNode lists the attributes, tags, relations, metadata
+// and content of a node
+func describeMemoryNode(n *memoryNode) string {
+	var content strings.Builder
+	fmt.Fprintf(&content, "%s · weight %.2f · accessed %d times · %d children\n", n.Type, n.Weight, n.AccessCount, len(n.Children))
+	fmt.Fprintf(&content, "Created %s · updated %s\n", n.Created, n.Updated)
+	if len(n.Tags) > 0 {
+		fmt.Fprintf(&content, "Tags: %s\n", strings.Join(n.Tags, ", "))
+	}
+	if len(n.Relations) > 0 {
+		var related []string
+		for _, r := range n.Relations[:min(len(n.Relations), 5)] {
+			related = append(related, r.Arrow()+" "+r.Type+" "+truncate(valueOr(r.Node.Title, r.Node.ID), 30))
+		}
+		if len(n.Relations) > 5 {
+			related = append(related, fmt.Sprintf("%d more", len(n.Relations)-5))
+		}
+		fmt.Fprintf(&content, "Related: %s\n", strings.Join(related, ", "))
+	}
+	var keys []string
+	for k := range n.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&content, "%s: %v\n", k, n.Metadata[k])
+	}
+	content.WriteString("\n")
+	content.WriteString(strings.TrimSpace(n.Content))
+	return content.String()
+}
+
+// openGraph lists the relations of the selected node, or of the root of
+// the selected session
+func (v *memoryView) openGraph() {
+	row, ok := v.selected()
+	if !ok || row.Node == nil {
+		v.message = "Select a node to see its relations"
+		return
+	}
+	v.graph, v.trail, v.graphCursor, v.message = row.Node, nil, 0, ""
+	v.showRelated()
+}
+
+// follow makes the node at the other end of the selected relation the
+// one whose relations are listed, remembering the current one
+func (v *memoryView) follow() {
+	if v.graphCursor >= len(v.graph.Relations) {
+		return
+	}
+	v.trail = append(v.trail, v.graph)
+	v.graph, v.graphCursor = v.graph.Relations[v.graphCursor].Node, 0
+	v.showRelated()
+}
+
+// retrace goes back to the node the current one was reached from, with
+// the cursor on the relation that was followed
+func (v *memoryView) retrace() {
+	if len(v.trail) == 0 {
+		return
+	}
+	from := v.graph
+	v.graph, v.trail = v.trail[len(v.trail)-1], v.trail[:len(v.trail)-1]
+	v.graphCursor = 0
+	for i, r := range v.graph.Relations {
+		if r.Node == from {
+			v.graphCursor = i
+			break
+		}
+	}
+	v.showRelated()
+}
+
+// showRelated fills the body with the details of the node at the other
+// end of the selected relation
+func (v *memoryView) showRelated() {
+	if v.graphCursor < len(v.graph.Relations) {
+		v.body.SetContent(describeMemoryNode(v.graph.Relations[v.graphCursor].Node))
+	} else {
+		v.body.SetContent(describeMemoryNode(v.graph))
+	}
+	v.body.GotoTop()
+}
+
+// suggestion returns the suggestion under the review cursor
+func (v memoryView) suggestion() (memorySuggestion, bool) {
+	if v.store == nil || v.reviewCursor >= len(v.store.Suggestions) {
+		return memorySuggestion{}, false
+	}
+	return v.store.Suggestions[v.reviewCursor], true
+}
+
+// openReview lists the pending tag suggestions
+func (v *memoryView) openReview() {
+	if v.store == nil || len(v.store.Suggestions) == 0 {
+		v.message = `No tag suggestion to review, "hierarchical_memory auto_organize" makes them`
+		return
+	}
+	v.review, v.reviewCursor, v.message = true, 0, ""
+	v.showSuggestion()
+}
+
+// showSuggestion fills the body with why the selected suggestion was
+// made and the node it is for, closing the queue once it is empty
+func (v *memoryView) showSuggestion() {
+	v.reviewCursor = min(v.reviewCursor, max(len(v.store.Suggestions)-1, 0))
+	s, ok := v.suggestion()
+	if !ok {
+		v.review = false
+		v.show()
+		return
+	}
+	where := "no session"
+	if session := v.store.session(s.Node); session != nil {
+		where = valueOr(session.Title, session.ID)
+	}
+	header := fmt.Sprintf("Suggested #%s: %s · confidence %.2f · %s · in %s\n\n", s.Tag, valueOr(s.Reason, "no reason given"), s.Confidence, s.Created, where)
+	v.body.SetContent(header + describeMemoryNode(s.Node))
+	v.body.GotoTop()
+}
+
+// valueOr returns value, or fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// openTagSearch starts reading a tag name
+func (v *memoryView) openTagSearch() tea.Cmd {
+	v.searching, v.tag, v.tagCursor, v.message = true, "", 0, ""
+	v.search = newTextInput()
+	v.search.Prompt = "tag: "
+	v.search.Placeholder = "name"
+	v.matches = v.store.searchTags("")
+	return v.search.Focus()
+}
+
+// updateMemory handles input on the Memory screen
+func (m Model) updateMemory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.memory
+	if expired, ok := msg.(memoryUndoExpiredMsg); ok {
+		if v.undo != nil && v.undo.deadline.Equal(expired.deadline) {
+			os.Remove(v.undo.backup)
+			v.undo, v.message = nil, ""
+		}
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if v.searching {
+		return m.updateMemorySearch(keyMsg)
+	}
+	if v.tagging {
+		return m.updateMemoryTagging(keyMsg)
+	}
+	if v.tag != "" {
+		nodes := v.store.Tags[v.tag]
+		switch {
+		case key.Matches(keyMsg, m.keys.Back):
+			v.tag = ""
+		case key.Matches(keyMsg, m.keys.Up):
+			if v.tagCursor > 0 {
+				v.tagCursor--
+			}
+		case key.Matches(keyMsg, m.keys.Down):
+			if v.tagCursor < len(nodes)-1 {
+				v.tagCursor++
+			}
+		case key.Matches(keyMsg, m.keys.Enter):
+			if v.tagCursor < len(nodes) {
+				v.reveal(nodes[v.tagCursor].Node)
+				v.tag = ""
+			}
+		}
+		return m, nil
+	}
+	if v.showStats {
+		switch {
+		case key.Matches(keyMsg, m.keys.Back), key.Matches(keyMsg, m.keys.Stats):
+			v.showStats = false
+		case key.Matches(keyMsg, m.keys.Retention):
+			if len(v.stats.Expired) == 0 {
+				v.message = fmt.Sprintf("No conversation turn is older than %d days", v.retention)
+				return m, nil
+			}
+			return m, v.applyEdit(fmt.Sprintf("Deleted %d conversation turn(s) older than %d days", len(v.stats.Expired), v.retention), retentionStatements(v.stats.Expired))
+		case key.Matches(keyMsg, m.keys.Vacuum):
+			v.vacuum()
+		case key.Matches(keyMsg, m.keys.Undo):
+			v.undoEdit()
+		case key.Matches(keyMsg, m.keys.Refresh):
+			v.load()
+			v.message = "Reloaded " + v.path
+		}
+		return m, nil
+	}
+	if v.review {
+		s, ok := v.suggestion()
+		switch {
+		case !ok || key.Matches(keyMsg, m.keys.Back):
+			v.review = false
+			v.show()
+		case key.Matches(keyMsg, m.keys.Up):
+			if v.reviewCursor > 0 {
+				v.reviewCursor--
+				v.showSuggestion()
+			}
+		case key.Matches(keyMsg, m.keys.Down):
+			if v.reviewCursor < len(v.store.Suggestions)-1 {
+				v.reviewCursor++
+				v.showSuggestion()
+			}
+		case key.Matches(keyMsg, m.keys.Enter), key.Matches(keyMsg, m.keys.Confirm):
+			return m, v.applyEdit(fmt.Sprintf("Approved #%s for %s", s.Tag, s.label()), reviewStatements(s, "approved", []string{s.Tag}))
+		case key.Matches(keyMsg, m.keys.Reject):
+			return m, v.applyEdit(fmt.Sprintf("Rejected #%s for %s", s.Tag, s.label()), reviewStatements(s, "rejected", nil))
+		case key.Matches(keyMsg, m.keys.Tag):
+			v.tagging, v.message = true, ""
+			v.tagInput = newTextInput()
+			v.tagInput.Prompt = fmt.Sprintf("tags instead of #%s: ", s.Tag)
+			v.tagInput.Placeholder = "names"
+			return m, v.tagInput.Focus()
+		case key.Matches(keyMsg, m.keys.Undo):
+			v.undoEdit()
+		default:
+			var cmd tea.Cmd
+			v.body, cmd = v.body.Update(keyMsg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	if v.graph != nil {
+		switch {
+		case key.Matches(keyMsg, m.keys.Back), key.Matches(keyMsg, m.keys.Graph):
+			v.reveal(v.graph)
+			v.graph, v.trail = nil, nil
+		case key.Matches(keyMsg, m.keys.Up):
+			if v.graphCursor > 0 {
+				v.graphCursor--
+				v.showRelated()
+			}
+		case key.Matches(keyMsg, m.keys.Down):
+			if v.graphCursor < len(v.graph.Relations)-1 {
+				v.graphCursor++
+				v.showRelated()
+			}
+		case key.Matches(keyMsg, m.keys.Enter), key.Matches(keyMsg, m.keys.Right):
+			v.follow()
+		case key.Matches(keyMsg, m.keys.Left), keyMsg.Type == tea.KeyBackspace:
+			v.retrace()
+		default:
+			var cmd tea.Cmd
+			v.body, cmd = v.body.Update(keyMsg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+			v.show()
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.rows)-1 {
+			v.cursor++
+			v.show()
+		}
+	case key.Matches(keyMsg, m.keys.Right):
+		v.toggle(true)
+	case key.Matches(keyMsg, m.keys.Left):
+		if row, ok := v.selected(); ok && v.expanded[row.key()] && v.expandable(row) {
+			v.toggle(false)
+		} else {
+			v.parent()
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if row, ok := v.selected(); ok {
+			v.toggle(!v.expanded[row.key()])
+		}
+	case key.Matches(keyMsg, m.keys.Search):
+		if v.store == nil || len(v.store.Tags) == 0 {
+			v.message = "No tags in the database"
+			return m, nil
+		}
+		return m, v.openTagSearch()
+	case key.Matches(keyMsg, m.keys.Graph):
+		v.openGraph()
+	case key.Matches(keyMsg, m.keys.Review):
+		v.openReview()
+	case key.Matches(keyMsg, m.keys.Stats):
+		v.showStats, v.message = true, ""
+	case key.Matches(keyMsg, m.keys.Mark):
+		if row, ok := v.selected(); ok && row.Node != nil {
+			v.marked[row.Node.ID] = !v.marked[row.Node.ID]
+			if !v.marked[row.Node.ID] {
+				delete(v.marked, row.Node.ID)
+			}
+			if v.cursor < len(v.rows)-1 {
+				v.cursor++
+				v.show()
+			}
+		}
+	case key.Matches(keyMsg, m.keys.Tag):
+		if v.store == nil || len(v.targets()) == 0 {
+			v.message = "Mark nodes or select one to tag"
+			return m, nil
+		}
+		v.tagging, v.message = true, ""
+		v.tagInput = newTextInput()
+		v.tagInput.Prompt = fmt.Sprintf("tags of %d node(s): ", len(v.targets()))
+		v.tagInput.Placeholder = "name +added -removed"
+		return m, v.tagInput.Focus()
+	case key.Matches(keyMsg, m.keys.Reparent):
+		nodes := v.markedNodes()
+		row, ok := v.selected()
+		if len(nodes) == 0 || !ok {
+			v.message = "Mark the nodes to move, then select their new parent"
+			return m, nil
+		}
+		parent, where := row.Node, "the top level"
+		if parent != nil {
+			where = valueOr(parent.Title, parent.ID)
+		}
+		statements, err := v.store.moveStatements(nodes, parent)
+		if err != nil {
+			v.message = err.Error()
+			return m, nil
+		}
+		return m, v.applyEdit(fmt.Sprintf("Moved %d node(s) under %s", len(nodes), where), statements)
+	case key.Matches(keyMsg, m.keys.MergeNodes):
+		row, ok := v.selected()
+		if !ok || row.Node == nil {
+			v.message = "Select the node to merge the marked ones into"
+			return m, nil
+		}
+		var duplicates []*memoryNode
+		for _, node := range v.markedNodes() {
+			if node != row.Node {
+				duplicates = append(duplicates, node)
+			}
+		}
+		if len(duplicates) == 0 {
+			v.message = "Mark the duplicates, then select the node to keep"
+			return m, nil
+		}
+		statements, err := mergeStatements(row.Node, duplicates)
+		if err != nil {
+			v.message = err.Error()
+			return m, nil
+		}
+		return m, v.applyEdit(fmt.Sprintf("Merged %d node(s) into %s", len(duplicates), valueOr(row.Node.Title, row.Node.ID)), statements)
+	case key.Matches(keyMsg, m.keys.Undo):
+		v.undoEdit()
+	case key.Matches(keyMsg, m.keys.Refresh):
+		v.load()
+		v.message = "Reloaded " + v.path
+	default:
+		var cmd tea.Cmd
+		v.body, cmd = v.body.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// updateMemorySearch handles keys while a tag is typed: ↑/↓ pick among
+// the matching tags, enter lists the nodes of the picked one
+func (m Model) updateMemorySearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.memory
+	switch msg.Type {
+	case tea.KeyEsc:
+		v.searching = false
+		return m, nil
+	case tea.KeyUp:
+		if v.tagCursor > 0 {
+			v.tagCursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if v.tagCursor < len(v.matches)-1 {
+			v.tagCursor++
+		}
+		return m, nil
+	case tea.KeyEnter:
+		if v.tagCursor >= len(v.matches) {
+			v.message = "No tag matches " + v.search.Value()
+			return m, nil
+		}
+		v.searching = false
+		v.tag, v.tagCursor = v.matches[v.tagCursor], 0
+		return m, nil
+	}
+	var cmd tea.Cmd
+	v.search, cmd = v.search.Update(msg)
+	v.matches = v.store.searchTags(v.search.Value())
+	v.tagCursor = min(v.tagCursor, max(len(v.matches)-1, 0))
+	return m, cmd
+}
+
+// updateMemoryTagging handles keys while the tags to add and remove are
+// typed: enter applies them to the marked nodes, esc cancels
+func (m Model) updateMemoryTagging(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.memory
+	switch msg.Type {
+	case tea.KeyEsc:
+		v.tagging = false
+		return m, nil
+	case tea.KeyEnter:
+		v.tagging = false
+		add, remove := parseTagEdit(v.tagInput.Value())
+		if len(add)+len(remove) == 0 {
+			return m, nil
+		}
+		if v.review {
+			s, ok := v.suggestion()
+			if !ok || len(add) == 0 {
+				return m, nil
+			}
+			return m, v.applyEdit(fmt.Sprintf("Tagged %s #%s instead of #%s", s.label(), strings.Join(add, " #"), s.Tag), reviewStatements(s, "retagged", add))
+		}
+		nodes := v.targets()
+		var changes []string
+		for _, name := range add {
+			changes = append(changes, "+"+name)
+		}
+		for _, name := range remove {
+			changes = append(changes, "-"+name)
+		}
+		return m, v.applyEdit(fmt.Sprintf("Tagged %d node(s) %s", len(nodes), strings.Join(changes, " ")), tagStatements(nodes, add, remove))
+	}
+	var cmd tea.Cmd
+	v.tagInput, cmd = v.tagInput.Update(msg)
+	return m, cmd
+}
+
+// memoryLabel describes a row of the tree
+func (v memoryView) memoryLabel(row memoryRow) string {
+	marker := "  "
+	if v.expandable(row) {
+		marker = "▸ "
+		if v.expanded[row.key()] {
+			marker = "▾ "
+		}
+	}
+	indent := strings.Repeat("  ", row.Depth)
+	if row.Node != nil && v.marked[row.Node.ID] {
+		indent = featureStyle.Render("◆") + indent[min(len(indent), 1):]
+	}
+	switch {
+	case row.Session != nil:
+		s := row.Session
+		return indent + marker + fmt.Sprintf("%-36s %s", truncate(valueOr(s.Title, s.ID), 36),
+			helpStyle.Render(fmt.Sprintf("%d turn(s) · last active %s", len(s.conversation()), s.LastActive)))
+	case row.Node == nil:
+		return indent + marker + fmt.Sprintf("Other nodes (%d)", len(v.store.Loose))
+	case row.Node.Type == "conversation":
+		return indent + marker + valueOr(row.Node.Role(), "unknown") + ": " + truncate(strings.TrimSpace(row.Node.Content), 60)
+	default:
+		n := row.Node
+		label := indent + marker + helpStyle.Render("["+n.Type+"] ") + truncate(valueOr(n.Title, n.ID), 50)
+		if len(n.Tags) > 0 {
+			label += helpStyle.Render(" #" + strings.Join(n.Tags, " #"))
+		}
+		return label
+	}
+}
+
+// renderMemory renders the session tree, or the tag search, and the
+// transcript or details of the selected row
+func (m Model) renderMemory() string {
+	v := m.memory
+	var content strings.Builder
+	title := titleStyle.Render("🧠 Memory")
+	summary := v.path
+	if v.store != nil {
+		summary = fmt.Sprintf("%s · %d sessions, %d nodes, %d tags, %d relations", v.path, len(v.store.Sessions), len(v.store.Nodes), len(v.store.Tags), v.store.Relationships)
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+	k := m.keys
+
+	if v.err != nil {
+		content.WriteString(warningStyle.Render(v.err.Error()))
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render(`Run "python cli.py hierarchical_memory" to create it, or set "memory": {"db": "<path>"} in config.json`))
+		content.WriteString("\n\n")
+		content.WriteString(footerStyle.Render(strings.Join([]string{hint("reload", k.Refresh), hint("back", k.Back)}, " | ")))
+		return content.String()
+	}
+
+	switch {
+	case v.showStats:
+		content.WriteString(m.renderMemoryStats())
+	case v.searching:
+		content.WriteString(v.search.View())
+		content.WriteString("\n")
+		var lines []string
+		for _, name := range v.matches {
+			lines = append(lines, fmt.Sprintf("%-30s %s", name, helpStyle.Render(fmt.Sprintf("%d nodes", len(v.store.Tags[name])))))
+		}
+		start := max(0, min(v.tagCursor-memoryListRows/2, len(lines)-memoryListRows))
+		end := min(start+memoryListRows, len(lines))
+		content.WriteString(renderMCPList(fmt.Sprintf("Tags (%d)", len(lines)), lines[start:end], v.tagCursor-start, true))
+	case v.tag != "":
+		var lines []string
+		for _, tagged := range v.store.Tags[v.tag] {
+			n := tagged.Node
+			where := "no session"
+			if s := v.store.session(n); s != nil {
+				where = valueOr(s.Title, s.ID)
+			}
+			lines = append(lines, fmt.Sprintf("%-40s %s", helpStyle.Render("["+n.Type+"] ")+truncate(valueOr(n.Title, n.Content), 40),
+				helpStyle.Render(fmt.Sprintf("confidence %.2f · %s", tagged.Confidence, truncate(where, 30)))))
+		}
+		start := max(0, min(v.tagCursor-memoryListRows/2, len(lines)-memoryListRows))
+		end := min(start+memoryListRows, len(lines))
+		content.WriteString(renderMCPList(fmt.Sprintf("Tagged #%s (%d)", v.tag, len(lines)), lines[start:end], v.tagCursor-start, true))
+	case v.review:
+		var lines []string
+		for _, s := range v.store.Suggestions {
+			lines = append(lines, fmt.Sprintf("%-16s %-50s %s", truncate("#"+s.Tag, 16),
+				helpStyle.Render("["+s.Node.Type+"] ")+truncate(valueOr(s.Node.Title, strings.TrimSpace(s.Node.Content)), 40),
+				helpStyle.Render(fmt.Sprintf("%s · confidence %.2f", truncate(s.Reason, 30), s.Confidence))))
+		}
+		start := max(0, min(v.reviewCursor-memoryListRows/2, len(lines)-memoryListRows))
+		end := min(start+memoryListRows, len(lines))
+		content.WriteString(renderMCPList(fmt.Sprintf("Tag suggestions to review (%d)", len(lines)), lines[start:end], v.reviewCursor-start, true))
+		content.WriteString("\n")
+		if v.tagging {
+			content.WriteString(v.tagInput.View())
+			content.WriteString("\n")
+		}
+		content.WriteString(v.body.View())
+	case v.graph != nil:
+		var trail []string
+		for _, n := range append(v.trail, v.graph) {
+			trail = append(trail, truncate(valueOr(n.Title, n.ID), 24))
+		}
+		content.WriteString(helpStyle.Render(truncate(strings.Join(trail, " › "), max(m.width-4, 20))))
+		content.WriteString("\n")
+		var lines []string
+		for _, r := range v.graph.Relations {
+			where := "no session"
+			if s := v.store.session(r.Node); s != nil {
+				where = valueOr(s.Title, s.ID)
+			}
+			lines = append(lines, fmt.Sprintf("%s %-14s %-50s %s", r.Arrow(), truncate(r.Type, 14),
+				helpStyle.Render("["+r.Node.Type+"] ")+truncate(valueOr(r.Node.Title, r.Node.Content), 40),
+				helpStyle.Render(fmt.Sprintf("strength %.2f · %s", r.Strength, truncate(where, 30)))))
+		}
+		if len(lines) == 0 {
+			lines = append(lines, helpStyle.Render("No relations: the node links to no other node and none links to it"))
+		}
+		start := max(0, min(v.graphCursor-memoryListRows/2, len(lines)-memoryListRows))
+		end := min(start+memoryListRows, len(lines))
+		heading := fmt.Sprintf("Relations of [%s] %s (%d)", v.graph.Type, truncate(valueOr(v.graph.Title, v.graph.ID), 40), len(v.graph.Relations))
+		content.WriteString(renderMCPList(heading, lines[start:end], v.graphCursor-start, true))
+		content.WriteString("\n")
+		content.WriteString(v.body.View())
+	default:
+		var lines []string
+		start := max(0, min(v.cursor-memoryListRows/2, len(v.rows)-memoryListRows))
+		end := min(start+memoryListRows, len(v.rows))
+		for _, row := range v.rows[start:end] {
+			lines = append(lines, v.memoryLabel(row))
+		}
+		heading := fmt.Sprintf("Sessions (%d)", len(v.store.Sessions))
+		if len(v.rows) > memoryListRows {
+			heading += fmt.Sprintf(" · rows %d–%d of %d", start+1, end, len(v.rows))
+		}
+		if len(v.marked) > 0 {
+			heading += fmt.Sprintf(" · %d marked", len(v.marked))
+		}
+		if len(v.store.Suggestions) > 0 {
+			heading += fmt.Sprintf(" · %d tag suggestion(s) to review", len(v.store.Suggestions))
+		}
+		content.WriteString(renderMCPList(heading, lines, v.cursor-start, true))
+		content.WriteString("\n")
+		if v.tagging {
+			content.WriteString(v.tagInput.View())
+			content.WriteString("\n")
+		}
+		content.WriteString(v.body.View())
+	}
+	content.WriteString("\n")
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	hints := []string{hint("select", k.Up, k.Down), hint("expand/collapse", k.Left, k.Right), hint("search tags", k.Search), hint("relations", k.Graph), hint("review suggestions", k.Review), hint("statistics", k.Stats),
+		hint("mark", k.Mark), hint("tag", k.Tag), hint("move marked here", k.Reparent), hint("merge marked here", k.MergeNodes), hint("reload", k.Refresh), "pgup/pgdn: scroll", hint("back", k.Back)}
+	if v.undo != nil && time.Now().Before(v.undo.deadline) {
+		hints = append([]string{hint("undo", k.Undo)}, hints...)
+	}
+	switch {
+	case v.tagging && v.review:
+		hints = []string{"enter: tag instead", "esc: cancel"}
+	case v.tagging:
+		hints = []string{"enter: apply", "name or +name: add", "-name: remove", "esc: cancel"}
+	case v.showStats:
+		hints = []string{hint(fmt.Sprintf("delete turns older than %d days", v.retention), k.Retention), hint("vacuum", k.Vacuum), hint("reload", k.Refresh), hint("back to tree", k.Back, k.Stats)}
+		if v.undo != nil && time.Now().Before(v.undo.deadline) {
+			hints = append([]string{hint("undo", k.Undo)}, hints...)
+		}
+	case v.review:
+		hints = []string{hint("select", k.Up, k.Down), hint("approve", k.Enter, k.Confirm), hint("re-tag", k.Tag), hint("reject", k.Reject), "pgup/pgdn: scroll", hint("back to tree", k.Back)}
+		if v.undo != nil && time.Now().Before(v.undo.deadline) {
+			hints = append([]string{hint("undo", k.Undo)}, hints...)
+		}
+	case v.searching:
+		hints = []string{"↑/↓: pick tag", "enter: list its nodes", "esc: cancel"}
+	case v.tag != "":
+		hints = []string{hint("select", k.Up, k.Down), "enter: show in tree", hint("back", k.Back)}
+	case v.graph != nil:
+		hints = []string{hint("select", k.Up, k.Down), hint("follow", k.Enter, k.Right), hint("previous node", k.Left), "pgup/pgdn: scroll", hint("show in tree", k.Back)}
+	}
+	content.WriteString(footerStyle.Render(strings.Join(hints, " | ")))
+	return content.String()
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestBuiltinInventoryMatchesRepository fails when inventory.json was
+// changed without running go generate to copy it into the binary
+func TestBuiltinInventoryMatchesRepository(t *testing.T) {
+	repo, err := os.ReadFile("../" + repoInventoryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(repo, builtinInventory) {
+		t.Fatalf("builtin_inventory.json differs from ../%s; run go generate", repoInventoryFile)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// reducedMotion replaces spinners, blinking cursors and ticking timers
+// with static text. It is set by "reduced_motion" in config.json.
+var reducedMotion bool
+
+// cursorMode returns the cursor mode of text inputs
+func cursorMode() cursor.Mode {
+	if reducedMotion {
+		return cursor.CursorStatic
+	}
+	return cursor.CursorBlink
+}
+
+// newTextInput returns a text input whose cursor blinks unless motion
+// is reduced
+func newTextInput() textinput.Model {
+	input := textinput.New()
+	input.Cursor.SetMode(cursorMode())
+	return input
+}
+
+// newTextArea returns a text area whose cursor blinks unless motion is
+// reduced
+func newTextArea() textarea.Model {
+	editor := textarea.New()
+	editor.Cursor.SetMode(cursorMode())
+	return editor
+}
+
+// spinner returns the frame shown for running tasks, one per second, or
+// a static mark when motion is reduced
+func spinner() string {
+	if reducedMotion {
+		return "●"
+	}
+	return spinnerFrames[time.Now().Unix()%int64(len(spinnerFrames))]
+}
+
+// runningFor describes how long a job has been running. With reduced
+// motion it names the start time, which does not change every second.
+func (r *runningTool) runningFor() string {
+	if reducedMotion && !r.done {
+		return "since " + r.started.Format("15:04:05")
+	}
+	return r.Elapsed().String()
+}
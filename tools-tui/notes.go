@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSnippetLines caps how much command output is appended to notes
+const maxSnippetLines = 40
+
+// notesView holds the state of the scratchpad screen
+type notesView struct {
+	editor  textarea.Model
+	preview bool
+	dirty   bool
+	message string
+}
+
+// notesPath returns the notes file of the current project
+func notesPath() string {
+	name := strings.Trim(strings.ReplaceAll(filepath.Clean(defaultWorkDir), string(filepath.Separator), "_"), "_")
+	return filepath.Join(ConfigDir(), "notes", name+".md")
+}
+
+// LoadNotes reads the project notes, returning "" when there are none
+func LoadNotes() string {
+	content, err := ReadFileContent(notesPath())
+	if err != nil {
+		return ""
+	}
+	return content
+}
+
+// SaveNotes writes the project notes
+func SaveNotes(content string) error {
+	if err := os.MkdirAll(filepath.Dir(notesPath()), 0755); err != nil {
+		return err
+	}
+	return WriteFileContent(notesPath(), content)
+}
+
+// AppendNoteSnippet adds a tool's command and output to the notes
+func AppendNoteSnippet(tool *Tool, output string) error {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > maxSnippetLines {
+		lines = append(lines[:maxSnippetLines], fmt.Sprintf("... (%d more lines)", len(lines)-maxSnippetLines))
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(LoadNotes(), "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "## %s (%s)\n\n```\n$ %s\n%s\n```\n", tool.Name, time.Now().Format("2006-01-02 15:04"), tool.Command, strings.Join(lines, "\n"))
+	return SaveNotes(b.String())
+}
+
+// openNotes switches to the notes screen with the saved notes loaded
+func (m *Model) openNotes() tea.Cmd {
+	editor := newTextArea()
+	editor.Placeholder = "Jot down observations while running tools..."
+	editor.ShowLineNumbers = false
+	editor.CharLimit = 0
+	editor.SetWidth(max(m.width-4, 20))
+	editor.SetHeight(max(m.height-8, 5))
+	editor.SetValue(LoadNotes())
+
+	m.notes = notesView{editor: editor}
+	m.screen = screenNotes
+	return m.notes.editor.Focus()
+}
+
+// save persists the editor content
+func (v *notesView) save() {
+	if err := SaveNotes(v.editor.Value()); err != nil {
+		v.message = fmt.Sprintf("Save failed: %v", err)
+		return
+	}
+	v.dirty = false
+	v.message = "Saved to " + notesPath()
+}
+
+// updateNotes handles input on the notes screen
+func (m Model) updateNotes(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.notes
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			if v.dirty {
+				v.save()
+			}
+			v.editor.Blur()
+			m.screen = screenTools
+			return m, nil
+		case "ctrl+s":
+			v.save()
+			return m, nil
+		case "ctrl+p":
+			v.preview = !v.preview
+			if v.preview {
+				v.editor.Blur()
+				return m, nil
+			}
+			return m, v.editor.Focus()
+		case "ctrl+c":
+			if v.dirty {
+				v.save()
+			}
+			return m, tea.Quit
+		}
+		if v.preview {
+			return m, nil
+		}
+		v.dirty = true
+	}
+
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		v.editor.SetWidth(max(size.Width-4, 20))
+		v.editor.SetHeight(max(size.Height-8, 5))
+	}
+
+	var cmd tea.Cmd
+	v.editor, cmd = v.editor.Update(msg)
+	return m, cmd
+}
+
+// renderNotes renders the editor or the markdown preview
+func (m Model) renderNotes() string {
+	v := m.notes
+	var content strings.Builder
+
+	mode := "editing"
+	if v.preview {
+		mode = "preview"
+	}
+	if v.dirty {
+		mode += " • unsaved"
+	}
+	title := titleStyle.Render("📝 Notes")
+	status := statusStyle.Render(mode)
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	if v.preview {
+		content.WriteString(renderMarkdown(v.editor.Value()))
+	} else {
+		content.WriteString(v.editor.View())
+	}
+	content.WriteString("\n")
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	content.WriteString(footerStyle.Render(strings.Join([]string{"ctrl+s: save", "ctrl+p: toggle preview", "esc: save & back"}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSpecSize skips files too large to be a hand-written spec
+const maxSpecSize = 5 << 20
+
+// specSkipDirs are not searched for specs
+var specSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, ".venv": true, "venv": true,
+	"__pycache__": true, "dist": true, "build": true, "vendor": true,
+}
+
+// httpMethods are the keys of a path item that are operations
+var httpMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// specSummary describes an OpenAPI (or Swagger 2) spec
+type specSummary struct {
+	Version    string
+	Title      string
+	APIVersion string
+	Paths      int
+	Operations int
+	Schemas    int
+	Err        error
+}
+
+// summarizeSpec reads the spec at path, as JSON or as YAML depending on
+// its extension
+func summarizeSpec(path string) specSummary {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return specSummary{Err: fmt.Errorf("no longer exists")}
+	} else if err != nil {
+		return specSummary{Err: err}
+	}
+	if info.Size() > maxSpecSize {
+		return specSummary{Err: fmt.Errorf("larger than %d MB", maxSpecSize>>20)}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return specSummary{Err: err}
+	}
+	var summary specSummary
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		summary = summarizeJSONSpec(data)
+	} else {
+		summary = summarizeYAMLSpec(string(data))
+	}
+	if summary.Err == nil && summary.Version == "" {
+		summary.Err = fmt.Errorf("no openapi or swagger version")
+	}
+	return summary
+}
+
+// summarizeJSONSpec counts the paths, operations and schemas of a JSON
+// spec
+func summarizeJSONSpec(data []byte) specSummary {
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Swagger string `json:"swagger"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return specSummary{Err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	summary := specSummary{
+		Version:    spec.OpenAPI,
+		Title:      spec.Info.Title,
+		APIVersion: spec.Info.Version,
+		Paths:      len(spec.Paths),
+		Schemas:    len(spec.Components.Schemas) + len(spec.Definitions),
+	}
+	if summary.Version == "" {
+		summary.Version = spec.Swagger
+	}
+	for _, item := range spec.Paths {
+		for method := range item {
+			if httpMethods[method] {
+				summary.Operations++
+			}
+		}
+	}
+	return summary
+}
+
+// yamlKeyPattern matches a mapping key and its value on a YAML line
+var yamlKeyPattern = regexp.MustCompile(`^( *)("[^"]*"|'[^']*'|[^\s#'"-][^:#]*?|-[^\s:#][^:#]*?)\s*:(?:\s+(.*))?$`)
+
+// summarizeYAMLSpec counts the paths, operations and schemas of a YAML
+// spec from the indentation of its keys. It covers the block style
+// specs are written in, not every YAML construct.
+func summarizeYAMLSpec(data string) specSummary {
+	type level struct {
+		indent int
+		key    string
+	}
+	var summary specSummary
+	var stack []level
+	blockIndent := -1
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \r\t")
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if blockIndent >= 0 {
+			if indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			continue
+		}
+		match := yamlKeyPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		name, value := strings.Trim(match[2], `"'`), strings.Trim(match[3], `"'`)
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, level{indent, name})
+		keys := make([]string, len(stack))
+		for i, l := range stack {
+			keys[i] = l.key
+		}
+		switch path := strings.Join(keys[:len(keys)-1], "."); {
+		case len(keys) == 1 && (name == "openapi" || name == "swagger"):
+			summary.Version = value
+		case path == "info" && name == "title":
+			summary.Title = value
+		case path == "info" && name == "version":
+			summary.APIVersion = value
+		case path == "paths":
+			summary.Paths++
+		case len(keys) == 3 && keys[0] == "paths" && httpMethods[name]:
+			summary.Operations++
+		case path == "components.schemas", path == "definitions":
+			summary.Schemas++
+		}
+	}
+	return summary
+}
+
+// specFile is a spec found in a repository, relative to its root
+type specFile struct {
+	Path    string
+	Summary specSummary
+}
+
+// findSpecs lists the OpenAPI specs under root: the YAML and JSON files
+// that declare an openapi or swagger version
+func findSpecs(root string) []specFile {
+	var specs []specFile
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && specSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		summary := summarizeSpec(path)
+		if summary.Err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		specs = append(specs, specFile{Path: rel, Summary: summary})
+		return nil
+	})
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Path < specs[j].Path })
+	return specs
+}
+
+// specsMsg carries the specs found in a tool's directory
+type specsMsg struct {
+	root  string
+	specs []specFile
+}
+
+// findSpecsCmd searches root for specs in the background
+func findSpecsCmd(root string) tea.Cmd {
+	return func() tea.Msg {
+		return specsMsg{root: root, specs: findSpecs(root)}
+	}
+}
+
+// specPicker offers the specs a tool's OpenAPI argument can take: the
+// recently used ones, then those found in the directory it runs in.
+// Entry 0 is the argument form with a path typed in.
+type specPicker struct {
+	active   bool
+	root     string
+	recent   []string
+	found    []specFile
+	scanning bool
+	cursor   int
+	// summaries caches the summary of every spec shown, keyed by path
+	summaries map[string]specSummary
+}
+
+// entries lists the specs of the picker, the recent ones first
+func (p specPicker) entries() []string {
+	entries := append([]string(nil), p.recent...)
+	for _, spec := range p.found {
+		if !slices.Contains(p.recent, spec.Path) {
+			entries = append(entries, spec.Path)
+		}
+	}
+	return entries
+}
+
+// openSpecPicker asks which spec to run the selected tool with, and
+// starts looking for specs in the directory the tool runs in
+func (m *Model) openSpecPicker() tea.Cmd {
+	root, _ := scopedCommand(m.selectedTool, m.projectDir())
+	p := specPicker{active: true, root: root, scanning: true, summaries: map[string]specSummary{}}
+	p.recent = LoadArgHistory()[m.selectedTool.Key()][m.selectedTool.OpenAPIArg]
+	if len(p.recent) > 0 {
+		p.cursor = 1
+	}
+	m.specPicker = p
+	m.summarizeSelectedSpec()
+	m.statusMessage = ""
+	return findSpecsCmd(root)
+}
+
+// summarizeSelectedSpec reads the spec under the cursor unless it was
+// read before
+func (m *Model) summarizeSelectedSpec() {
+	p := &m.specPicker
+	entries := p.entries()
+	if p.cursor == 0 || p.cursor > len(entries) {
+		return
+	}
+	path := entries[p.cursor-1]
+	if _, ok := p.summaries[path]; ok {
+		return
+	}
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(p.root, path)
+	}
+	p.summaries[path] = summarizeSpec(full)
+}
+
+// updateSpecs keeps the specs found for the open picker
+func (m Model) updateSpecs(msg specsMsg) (tea.Model, tea.Cmd) {
+	p := &m.specPicker
+	if !p.active || msg.root != p.root {
+		return m, nil
+	}
+	p.found, p.scanning = msg.specs, false
+	for _, spec := range msg.specs {
+		p.summaries[spec.Path] = spec.Summary
+	}
+	if p.cursor == 0 && len(p.entries()) > 0 {
+		p.cursor = 1
+	}
+	m.summarizeSelectedSpec()
+	return m, nil
+}
+
+// updateSpecPicker handles keys while a spec is picked: enter opens the
+// argument form with it filled in
+func (m Model) updateSpecPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.specPicker
+	entries := p.entries()
+	switch {
+	case msg.Type == tea.KeyEsc:
+		p.active = false
+		m.presetArgs = nil
+		m.statusMessage = "Execution cancelled"
+	case key.Matches(msg, m.keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		m.summarizeSelectedSpec()
+	case key.Matches(msg, m.keys.Down):
+		if p.cursor < len(entries) {
+			p.cursor++
+		}
+		m.summarizeSelectedSpec()
+	case key.Matches(msg, m.keys.Enter):
+		p.active = false
+		if p.cursor > 0 {
+			if m.presetArgs == nil {
+				m.presetArgs = map[string]string{}
+			}
+			m.presetArgs[m.selectedTool.OpenAPIArg] = entries[p.cursor-1]
+		}
+		return m, m.openArgsForm(m.selectedTool.placeholders())
+	}
+	return m, nil
+}
+
+// renderSpecPicker renders the specs to pick from and the summary of
+// the one under the cursor
+func (m Model) renderSpecPicker() string {
+	p := m.specPicker
+	var content strings.Builder
+	content.WriteString(descriptionStyle.Bold(true).Render("OpenAPI spec:\n"))
+	entries := append([]string{"Enter a path" + helpStyle.Render("  type it in the form")}, p.entries()...)
+	for i, entry := range entries {
+		if i > 0 && i <= len(p.recent) {
+			entry += helpStyle.Render(" (recent)")
+		}
+		if i > 0 {
+			if summary, ok := p.summaries[p.entries()[i-1]]; ok && summary.Err != nil {
+				entry += " " + warningStyle.Render(summary.Err.Error())
+			}
+		}
+		if i == p.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ ") + entry)
+		} else {
+			content.WriteString("  " + entry)
+		}
+		content.WriteString("\n")
+	}
+	if p.scanning {
+		content.WriteString(helpStyle.Render("  Looking for specs in " + p.root + "…"))
+		content.WriteString("\n")
+	} else if len(p.found) == 0 {
+		content.WriteString(helpStyle.Render("  No specs found in " + p.root))
+		content.WriteString("\n")
+	}
+	if p.cursor > 0 {
+		if summary, ok := p.summaries[p.entries()[p.cursor-1]]; ok && summary.Err == nil {
+			content.WriteString("\n")
+			title := summary.Title
+			if title == "" {
+				title = "untitled"
+			}
+			if summary.APIVersion != "" {
+				title += " " + summary.APIVersion
+			}
+			content.WriteString(fmt.Sprintf("%s %s\n", featureStyle.Render(title), helpStyle.Render("(spec "+summary.Version+")")))
+			content.WriteString(fmt.Sprintf("  Paths: %d   Operations: %d   Schemas: %d\n", summary.Paths, summary.Operations, summary.Schemas))
+		}
+	}
+	content.WriteString("\n")
+	content.WriteString(helpStyle.Render("enter: fill in the arguments | ↑/↓: select | esc: cancel"))
+	content.WriteString("\n\n")
+	return content.String()
+}
+
+// validateOpenAPIArg reports an openapi_arg that names no argument of
+// the command
+func validateOpenAPIArg(tool Tool) []string {
+	if tool.OpenAPIArg == "" {
+		return nil
+	}
+	for _, p := range tool.placeholders() {
+		if p.Name == tool.OpenAPIArg {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("openapi_arg: the command has no argument %q", tool.OpenAPIArg)}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputMode controls how much of a job's output its pane shows
+type OutputMode string
+
+const (
+	// OutputNormal shows the output as the tool writes it
+	OutputNormal OutputMode = "normal"
+	// OutputQuiet hides everything but error lines and a summary until
+	// the tool fails
+	OutputQuiet OutputMode = "quiet"
+	// OutputVerbose adds the command, directory, start time and exit
+	// status around the output
+	OutputVerbose OutputMode = "verbose"
+)
+
+// outputModesFile stores the output mode chosen per tool
+const outputModesFile = "output_modes.json"
+
+// errorLine matches output lines a quiet pane still shows
+var errorLine = regexp.MustCompile(`(?i)\b(error|errors|fail|failed|failure|fatal|panic|exception|traceback)\b`)
+
+// Next cycles normal → quiet → verbose
+func (o OutputMode) Next() OutputMode {
+	switch o {
+	case OutputQuiet:
+		return OutputVerbose
+	case OutputVerbose:
+		return OutputNormal
+	}
+	return OutputQuiet
+}
+
+// Icon is a short marker for pane titles; normal has none
+func (o OutputMode) Icon() string {
+	switch o {
+	case OutputQuiet:
+		return "🔇"
+	case OutputVerbose:
+		return "🔊"
+	}
+	return ""
+}
+
+// validate reports output modes that are not known
+func (o OutputMode) validate() error {
+	switch o {
+	case "", OutputNormal, OutputQuiet, OutputVerbose:
+		return nil
+	}
+	return fmt.Errorf("unknown output mode %q, use normal, quiet or verbose", o)
+}
+
+// LoadOutputModes reads the output modes chosen per tool key
+func LoadOutputModes() map[string]OutputMode {
+	modes := map[string]OutputMode{}
+	loadJSON(outputModesFile, &modes)
+	return modes
+}
+
+// SaveOutputMode persists the output mode of a tool
+func SaveOutputMode(toolKey string, mode OutputMode) error {
+	modes := LoadOutputModes()
+	modes[toolKey] = mode
+	return saveJSON(outputModesFile, modes)
+}
+
+// outputMode returns the mode of a tool: its own choice, else the
+// "output" default of config.json, else normal
+func (m Model) outputMode(tool *Tool) OutputMode {
+	if mode, ok := m.outputModes[tool.Key()]; ok {
+		return mode
+	}
+	if m.defaultOutput != "" {
+		return m.defaultOutput
+	}
+	return OutputNormal
+}
+
+// cycleOutputMode switches a tool to the next output mode and applies it
+// to the tool's jobs
+func (m *Model) cycleOutputMode(tool *Tool) string {
+	mode := m.outputMode(tool).Next()
+	if err := SaveOutputMode(tool.Key(), mode); err != nil {
+		return fmt.Sprintf("Could not save output mode: %v", err)
+	}
+	m.outputModes[tool.Key()] = mode
+	for _, job := range m.jobs {
+		if job.tool.Key() == tool.Key() {
+			job.mode = mode
+			job.viewport.SetContent(job.display())
+			if job.follow {
+				job.viewport.GotoBottom()
+			}
+		}
+	}
+	return fmt.Sprintf("%s output: %s", tool.Name, mode)
+}
+
+// display returns the job's output as its output mode presents it
+func (r *runningTool) display() string {
+	switch r.mode {
+	case OutputQuiet:
+		if r.done && r.err != nil {
+			return r.output
+		}
+		lines := strings.Split(strings.TrimRight(r.output, "\n"), "\n")
+		var shown []string
+		for _, line := range lines {
+			if errorLine.MatchString(line) {
+				shown = append(shown, line)
+			}
+		}
+		summary := fmt.Sprintf("🔇 %d lines hidden", len(lines)-len(shown))
+		if r.output == "" {
+			summary = "🔇 no output yet"
+		}
+		if r.done {
+			summary = fmt.Sprintf("✔ passed in %s, %d lines hidden", r.Elapsed(), len(lines)-len(shown))
+		}
+		return strings.Join(append(shown, summary), "\n")
+	case OutputVerbose:
+		dir, command := scopedCommand(r.tool, r.projectDir)
+		var b strings.Builder
+		fmt.Fprintf(&b, "$ %s\n  in %s\n  started %s\n\n", command, dir, r.started.Format("15:04:05"))
+		b.WriteString(r.output)
+		if r.done {
+			fmt.Fprintf(&b, "\n— exit %d after %s\n", exitCode(r.err), r.Elapsed())
+		}
+		return b.String()
+	}
+	return r.output
+}
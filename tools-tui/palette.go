@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteView holds the state of the command palette
+type paletteView struct {
+	active bool
+	input  textinput.Model
+	cursor int
+}
+
+// paletteEntry is an action or macro offered by the palette
+type paletteEntry struct {
+	name        string
+	description string
+	keys        string
+	run         func(m *Model) tea.Cmd
+	positions   []int
+	score       int
+}
+
+// openPalette shows the command palette
+func (m *Model) openPalette() tea.Cmd {
+	input := newTextInput()
+	input.Placeholder = "Type an action or macro..."
+	input.CharLimit = 100
+	input.Width = 50
+	m.palette = paletteView{active: true, input: input}
+	return m.palette.input.Focus()
+}
+
+// paletteEntries returns the actions available right now and the
+// configured macros that match the query, best first. Navigation keys
+// are left out since running them from a list is pointless.
+func (m Model) paletteEntries(query string) []paletteEntry {
+	var entries []paletteEntry
+	for _, a := range actions {
+		if a.group == "Navigation" || !a.isAvailable(m) {
+			continue
+		}
+		entries = append(entries, paletteEntry{
+			name:        a.name,
+			description: a.description,
+			keys:        strings.Join(a.binding(&m.keys).Keys(), "/"),
+			run:         a.run,
+		})
+	}
+	names := make([]string, 0, len(m.macros))
+	for name := range m.macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		name, steps := name, m.macros[name]
+		entries = append(entries, paletteEntry{
+			name:        "macro:" + name,
+			description: strings.Join(steps, " → "),
+			run:         func(m *Model) tea.Cmd { return m.runMacro(name, steps) },
+		})
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return entries
+	}
+	var matched []paletteEntry
+	for _, entry := range entries {
+		if score, positions, ok := fuzzyMatch(query, entry.name); ok {
+			entry.score, entry.positions = score+10, positions
+			matched = append(matched, entry)
+		} else if score, _, ok := fuzzyMatch(query, entry.description); ok {
+			entry.score = score
+			matched = append(matched, entry)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].score > matched[j].score })
+	return matched
+}
+
+// updatePalette handles keys while the palette is open
+func (m Model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.palette
+	switch msg.Type {
+	case tea.KeyEsc:
+		p.active = false
+		return m, nil
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if p.cursor < len(m.paletteEntries(p.input.Value()))-1 {
+			p.cursor++
+		}
+		return m, nil
+	case tea.KeyEnter:
+		entries := m.paletteEntries(p.input.Value())
+		p.active = false
+		if p.cursor < len(entries) {
+			return m, entries[p.cursor].run(&m)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	p.cursor = 0
+	return m, cmd
+}
+
+// renderPalette lists the matching actions with their keys
+func (m Model) renderPalette() string {
+	var content strings.Builder
+	title := titleStyle.Render("⚡ Command Palette")
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", commandStyle.Render(m.palette.input.View())))
+	content.WriteString("\n\n")
+
+	entries := m.paletteEntries(m.palette.input.Value())
+	if len(entries) == 0 {
+		content.WriteString(descriptionStyle.Render("No matching actions"))
+		content.WriteString("\n")
+	}
+	for i, entry := range entries {
+		if i >= maxSearchResults {
+			content.WriteString(helpStyle.Render(fmt.Sprintf("… %d more", len(entries)-i)))
+			content.WriteString("\n")
+			break
+		}
+		name := highlightMatches(entry.name, entry.positions, 0)
+		line := fmt.Sprintf("%s %s %s", name, descriptionStyle.Render(entry.description), helpStyle.Render(entry.keys))
+		if i == m.palette.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ ") + line)
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(footerStyle.Render(strings.Join([]string{"type: filter", "↑/↓: select", "enter: run", "esc: close"}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultMaxPanes is how many jobs are kept in output panes unless
+// config.json sets "panes"
+const defaultMaxPanes = 4
+
+// panesView holds the state of the tiled output panes screen
+type panesView struct {
+	// focus is the index in Model.jobs of the focused pane
+	focus   int
+	message string
+}
+
+// job returns the job with the given id, or nil
+func (m Model) job(id int) *runningTool {
+	for _, job := range m.jobs {
+		if job.id == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// runningJobs counts the jobs that have not exited yet
+func (m Model) runningJobs() int {
+	n := 0
+	for _, job := range m.jobs {
+		if !job.done {
+			n++
+		}
+	}
+	return n
+}
+
+// toolJob returns the most recent job of a tool, or nil
+func (m Model) toolJob(tool *Tool) *runningTool {
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		if m.jobs[i].tool.Key() == tool.Key() {
+			return m.jobs[i]
+		}
+	}
+	return nil
+}
+
+// currentJob is the job ctrl+c cancels: the focused pane on the panes
+// screen, the selected task on the task list, the job in the log viewer,
+// otherwise the job attached to the detail view
+func (m Model) currentJob() *runningTool {
+	if m.screen == screenTasks {
+		return m.job(m.selectedTaskID())
+	}
+	if m.screen == screenLog {
+		return m.job(m.logView.jobID)
+	}
+	if m.screen == screenPanes {
+		if m.panes.focus < len(m.jobs) {
+			return m.jobs[m.panes.focus]
+		}
+		return nil
+	}
+	return m.job(m.detailJob)
+}
+
+// addJob gives a new job a pane, reusing the pane of the oldest
+// finished job when all are taken. It reports false when every pane
+// shows a running job.
+func (m *Model) addJob(job *runningTool) bool {
+	for len(m.jobs) >= m.maxPanes {
+		oldest := -1
+		for i, old := range m.jobs {
+			if old.done {
+				oldest = i
+				break
+			}
+		}
+		if oldest < 0 {
+			return false
+		}
+		m.removeJob(oldest)
+	}
+	if job.id == 0 {
+		m.nextJobID++
+		job.id = m.nextJobID
+	}
+	job.follow = true
+	job.viewport = viewport.New(0, 0)
+	m.jobs = append(m.jobs, job)
+	m.layoutPanes()
+	return true
+}
+
+// removeJob closes the pane of the job at index i
+func (m *Model) removeJob(i int) {
+	if m.jobs[i].id == m.detailJob {
+		m.detailJob = 0
+	}
+	m.jobs = append(m.jobs[:i], m.jobs[i+1:]...)
+	if m.panes.focus >= len(m.jobs) && m.panes.focus > 0 {
+		m.panes.focus = len(m.jobs) - 1
+	}
+	m.layoutPanes()
+}
+
+// attachDetail shows the output of the selected tool's latest job in
+// the detail view, if it has one, and reads the extension states it
+// shows
+func (m *Model) attachDetail() {
+	m.reloadExtensionStates()
+	m.detailJob = 0
+	m.setOutput("")
+	if job := m.toolJob(m.selectedTool); job != nil {
+		m.detailJob = job.id
+		m.setOutput(job.output)
+		m.viewport.GotoBottom()
+	}
+}
+
+// paneGrid returns the number of columns and rows used to tile n panes
+func paneGrid(n int) (cols, rows int) {
+	switch {
+	case n <= 1:
+		return 1, 1
+	case n == 2:
+		return 2, 1
+	default:
+		cols = 2
+		return cols, (n + cols - 1) / cols
+	}
+}
+
+// layoutPanes sizes the pane viewports to tile the window
+func (m *Model) layoutPanes() {
+	cols, rows := paneGrid(len(m.jobs))
+	// borders take two columns and two rows, the title one more row
+	width := m.width/cols - 2
+	height := (m.height-4)/rows - 3
+	if height < 1 {
+		height = 1
+	}
+	for _, job := range m.jobs {
+		job.viewport.Width = width
+		job.viewport.Height = height
+		job.viewport.SetContent(job.display())
+		if job.follow {
+			job.viewport.GotoBottom()
+		}
+	}
+}
+
+// openPanes shows the output panes of the current jobs
+func (m *Model) openPanes() tea.Cmd {
+	if len(m.jobs) == 0 {
+		m.statusMessage = fmt.Sprintf("No jobs yet, execute a tool with '%s' first", primaryKey(m.keys.Execute))
+		return nil
+	}
+	return m.openPanesAt(m.jobs[len(m.jobs)-1])
+}
+
+// openPanesAt shows the output panes with the pane of job focused
+func (m *Model) openPanesAt(job *runningTool) tea.Cmd {
+	for i, j := range m.jobs {
+		if j == job {
+			m.panes.focus = i
+		}
+	}
+	m.layoutPanes()
+	m.screen = screenPanes
+	return nil
+}
+
+// miniLogLines is how much output the mini log shows
+const miniLogLines = 3
+
+// miniLogJob returns the most recent running job unless its output is
+// already on screen, in its pane, the log viewer or the detail view
+func (m Model) miniLogJob() *runningTool {
+	if m.screen == screenPanes {
+		return nil
+	}
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		job := m.jobs[i]
+		if job.done {
+			continue
+		}
+		if m.screen == screenTools && m.detailMode && job.id == m.detailJob {
+			return nil
+		}
+		if m.screen == screenLog && job.id == m.logView.jobID {
+			return nil
+		}
+		return job
+	}
+	return nil
+}
+
+// renderMiniLog renders a compact live tail of a job's output
+func (m Model) renderMiniLog(job *runningTool) string {
+	width := max(m.width-4, 20)
+	lines := strings.Split(strings.TrimRight(job.display(), "\n"), "\n")
+	if len(lines) > miniLogLines {
+		lines = lines[len(lines)-miniLogLines:]
+	}
+	for len(lines) < miniLogLines {
+		lines = append(lines, "")
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	state := fmt.Sprintf("⏳ %s %s", job.tool.Name, job.runningFor())
+	if others := m.runningJobs() - 1; others > 0 {
+		state += fmt.Sprintf(" (+%d more)", others)
+	}
+	expand := strings.Join(m.keys.ExpandLog.Keys(), "/") + ": expand"
+	title := lipgloss.JoinHorizontal(lipgloss.Center, commandStyle.Render(state), " ", helpStyle.Render(expand))
+	box := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), true, false, false, false).BorderForeground(helpStyle.GetForeground()).Width(width)
+	return box.Render(lipgloss.JoinVertical(lipgloss.Left, title, descriptionStyle.Render(strings.Join(lines, "\n"))))
+}
+
+// updatePanes handles keys on the panes screen: focus cycling, scrolling
+// the focused pane and closing finished jobs
+func (m Model) updatePanes(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if len(m.jobs) == 0 {
+		m.screen = screenTools
+		return m, nil
+	}
+	v := &m.panes
+	job := m.jobs[v.focus]
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.NextPane):
+		v.focus = (v.focus + 1) % len(m.jobs)
+	case keyMsg.Type == tea.KeyShiftTab:
+		v.focus = (v.focus + len(m.jobs) - 1) % len(m.jobs)
+	case key.Matches(keyMsg, m.keys.Up):
+		job.viewport.LineUp(1)
+		job.follow = false
+	case key.Matches(keyMsg, m.keys.Down):
+		job.viewport.LineDown(1)
+		job.follow = job.viewport.AtBottom()
+	case key.Matches(keyMsg, m.keys.Enter):
+		m.selectedTool = job.tool
+		if tool := findTool(m.categories, job.tool.Key()); tool != nil {
+			m.selectedTool = tool
+		}
+		m.detailMode = true
+		m.statusMessage = ""
+		m.warning = ""
+		m.attachDetail()
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.OutputMode):
+		m.panes.message = m.cycleOutputMode(job.tool)
+		return m, nil
+	case key.Matches(keyMsg, m.keys.LogView):
+		return m, m.openLog(job)
+	case key.Matches(keyMsg, m.keys.ClosePane):
+		if !job.done {
+			m.panes.message = fmt.Sprintf("%s is still running, ctrl+c cancels it", job.tool.Name)
+			return m, nil
+		}
+		m.removeJob(v.focus)
+		if len(m.jobs) == 0 {
+			m.screen = screenTools
+		}
+	}
+	m.panes.message = ""
+	return m, nil
+}
+
+// renderPane renders the output of one job with a title line
+func renderPane(job *runningTool, focused bool) string {
+	state := fmt.Sprintf("⏳ %s", job.runningFor())
+	switch {
+	case job.done && job.err != nil:
+		state = fmt.Sprintf("✘ exit %d · %s", exitCode(job.err), job.Elapsed())
+	case job.done:
+		state = fmt.Sprintf("✔ %s", job.Elapsed())
+	case job.cancelled:
+		state = fmt.Sprintf("⏳ cancelling · %s", job.runningFor())
+	}
+	name := job.tool.Name
+	if job.projectDir != "" {
+		name += " @ " + job.projectDir
+	}
+	if icon := job.mode.Icon(); icon != "" {
+		state += " " + icon
+	}
+	title := lipgloss.JoinHorizontal(lipgloss.Center, titleStyle.Render(name), " ", helpStyle.Render(state))
+	title = lipgloss.NewStyle().MaxWidth(job.viewport.Width).Render(title)
+
+	border := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(helpStyle.GetForeground())
+	if focused {
+		border = border.BorderForeground(selectedItemStyle.GetForeground())
+	}
+	body := lipgloss.NewStyle().Width(job.viewport.Width).Height(job.viewport.Height).Render(job.viewport.View())
+	return border.Render(lipgloss.JoinVertical(lipgloss.Left, title, body))
+}
+
+// renderPanes tiles the output panes of all jobs
+func (m Model) renderPanes() string {
+	title := titleStyle.Render("🪟 Output Panes")
+	status := statusStyle.Render(fmt.Sprintf("%d running | %d of %d panes", m.runningJobs(), len(m.jobs), m.maxPanes))
+
+	cols, _ := paneGrid(len(m.jobs))
+	var rows []string
+	for start := 0; start < len(m.jobs); start += cols {
+		var row []string
+		for i := start; i < start+cols && i < len(m.jobs); i++ {
+			row = append(row, renderPane(m.jobs[i], i == m.panes.focus))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	}
+
+	var content strings.Builder
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n")
+	content.WriteString(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	content.WriteString("\n")
+	if m.panes.message != "" {
+		content.WriteString(featureStyle.Render(m.panes.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{primaryKey(k.NextPane) + "/shift+tab: focus", hint("scroll", k.Up, k.Down), hint("details", k.Enter), hint("log", k.LogView), hint("close finished", k.ClosePane), hint("quiet/verbose", k.OutputMode), "ctrl+c: cancel job", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
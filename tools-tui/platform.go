@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// platformNames gives readable names for GOOS values
+var platformNames = map[string]string{
+	"linux":   "Linux",
+	"darwin":  "macOS",
+	"windows": "Windows",
+	"freebsd": "FreeBSD",
+}
+
+// currentPlatform returns the os/arch pair of this binary
+func currentPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// platformMatches reports whether an inventory entry such as "linux" or
+// "darwin/arm64" covers the given os and arch
+func platformMatches(entry, goos, goarch string) bool {
+	entryOS, entryArch, hasArch := strings.Cut(strings.ToLower(strings.TrimSpace(entry)), "/")
+	if entryOS != goos && entryOS != "*" {
+		return false
+	}
+	return !hasArch || entryArch == goarch || entryArch == "*"
+}
+
+// platformLabel renders an inventory entry for humans
+func platformLabel(entry string) string {
+	goos, arch, hasArch := strings.Cut(entry, "/")
+	if name, ok := platformNames[goos]; ok {
+		goos = name
+	}
+	if hasArch {
+		return goos + " (" + arch + ")"
+	}
+	return goos
+}
+
+// UnsupportedReason explains why a tool cannot run on this machine, or
+// returns "" when it can. Tools without platforms run everywhere.
+func (t *Tool) UnsupportedReason() string {
+	return t.unsupportedOn(runtime.GOOS, runtime.GOARCH)
+}
+
+// unsupportedOn is UnsupportedReason for an explicit os and arch
+func (t *Tool) unsupportedOn(goos, goarch string) string {
+	if len(t.Platforms) == 0 {
+		return ""
+	}
+	labels := make([]string, len(t.Platforms))
+	for i, entry := range t.Platforms {
+		if platformMatches(entry, goos, goarch) {
+			return ""
+		}
+		labels[i] = platformLabel(entry)
+	}
+	return fmt.Sprintf("only supported on %s (this is %s)",
+		strings.Join(labels, ", "), platformLabel(goos+"/"+goarch))
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// probeTimeout bounds how long a probe command may run
+const probeTimeout = 10 * time.Second
+
+// probeWorkers is how many probes run at the same time
+const probeWorkers = 8
+
+// StatusProbe declares how to check that a tool works. Every check that
+// is set must pass.
+type StatusProbe struct {
+	// Command must exit successfully, it runs in the tool's directory
+	Command string `json:"command,omitempty"`
+	// Binary must be found on PATH
+	Binary string `json:"binary,omitempty"`
+	// Path must exist, relative to the tool's directory
+	Path string `json:"path,omitempty"`
+}
+
+// ProbeState is the outcome of a status probe
+type ProbeState string
+
+const (
+	ProbeUnknown ProbeState = "unknown"
+	ProbePass    ProbeState = "pass"
+	ProbeFail    ProbeState = "fail"
+)
+
+// ProbeResult is the outcome of probing a tool
+type ProbeResult struct {
+	State   ProbeState
+	Detail  string
+	Checked time.Time
+}
+
+// Badge renders the result for the tool list
+func (r ProbeResult) Badge() string {
+	switch r.State {
+	case ProbePass:
+		return "✔ pass"
+	case ProbeFail:
+		return "✘ fail"
+	}
+	return "? unknown"
+}
+
+// probesMsg carries the results of probing the catalog, keyed by tool
+type probesMsg struct {
+	results map[string]ProbeResult
+}
+
+// interpreters are programs whose first argument is the script they run
+var interpreters = map[string]bool{"python": true, "python3": true, "node": true, "bash": true, "sh": true}
+
+// implicitProbe derives a probe from a tool's command: a leading
+// "cd dir &&" must name an existing directory, the program must be on
+// PATH and a script run by an interpreter must exist
+func implicitProbe(command string) (StatusProbe, string) {
+	var probe StatusProbe
+	dir := ""
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(command), "cd "); ok {
+		target, after, found := strings.Cut(rest, "&&")
+		if !found {
+			return probe, ""
+		}
+		dir = strings.TrimSpace(target)
+		command = after
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return probe, dir
+	}
+	probe.Binary = fields[0]
+	if interpreters[fields[0]] && len(fields) > 1 && !strings.HasPrefix(fields[1], "-") {
+		probe.Path = filepath.Join(dir, fields[1])
+	}
+	return probe, dir
+}
+
+// resolvePath makes a path relative to dir absolute
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// ProbeTool checks whether a tool works using its declared probe, or one
+// derived from its command
+func ProbeTool(tool *Tool) ProbeResult {
+	result := ProbeResult{State: ProbeUnknown, Checked: time.Now()}
+	workDir := tool.RunDir()
+	probe := StatusProbe{}
+	if tool.Probe != nil {
+		probe = *tool.Probe
+	} else {
+		var dir string
+		probe, dir = implicitProbe(tool.Command)
+		if dir != "" {
+			if info, err := os.Stat(resolvePath(workDir, dir)); err != nil || !info.IsDir() {
+				result.State, result.Detail = ProbeFail, dir+" does not exist"
+				return result
+			}
+		}
+	}
+	if probe == (StatusProbe{}) {
+		result.Detail = "nothing to check, declare a probe in the inventory"
+		return result
+	}
+
+	var passed []string
+	if probe.Binary != "" {
+		if _, err := exec.LookPath(probe.Binary); err != nil {
+			result.State, result.Detail = ProbeFail, probe.Binary+" not found on PATH"
+			return result
+		}
+		passed = append(passed, probe.Binary+" found")
+	}
+	if probe.Path != "" {
+		if _, err := os.Stat(resolvePath(workDir, probe.Path)); err != nil {
+			result.State, result.Detail = ProbeFail, probe.Path+" does not exist"
+			return result
+		}
+		passed = append(passed, probe.Path+" exists")
+	}
+	if probe.Command != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", probe.Command)
+		cmd.Dir = workDir
+		cmd.Env = tool.environ()
+		output, err := cmd.CombinedOutput()
+		switch {
+		case ctx.Err() != nil:
+			result.Detail = fmt.Sprintf("%s timed out after %s", probe.Command, probeTimeout)
+			return result
+		case err != nil:
+			result.State = ProbeFail
+			result.Detail = fmt.Sprintf("%s: %v", probe.Command, err)
+			if text := strings.TrimSpace(string(output)); text != "" {
+				result.Detail += ": " + truncate(text, 120)
+			}
+			return result
+		}
+		passed = append(passed, probe.Command+" succeeded")
+	}
+	result.State, result.Detail = ProbePass, strings.Join(passed, ", ")
+	return result
+}
+
+// probeCatalogCmd probes every tool of the catalog concurrently
+func probeCatalogCmd(categories []Category) tea.Cmd {
+	return func() tea.Msg {
+		return probesMsg{results: ProbeCatalog(categories)}
+	}
+}
+
+// ProbeCatalog probes every tool of the catalog concurrently and returns
+// the results keyed by tool
+func ProbeCatalog(categories []Category) map[string]ProbeResult {
+	var tools []Tool
+	for _, category := range categories {
+		if !category.Favorites {
+			tools = append(tools, category.Tools...)
+		}
+	}
+	results := make(map[string]ProbeResult, len(tools))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, probeWorkers)
+	for i := range tools {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(tool *Tool) {
+			defer wg.Done()
+			result := ProbeTool(tool)
+			mu.Lock()
+			results[tool.Key()] = result
+			mu.Unlock()
+			<-sem
+		}(&tools[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// probeCatalog starts probing the catalog unless probes already run
+func (m *Model) probeCatalog() tea.Cmd {
+	if m.probing {
+		return nil
+	}
+	m.probing = true
+	return probeCatalogCmd(m.categories)
+}
+
+// statusBadge renders the install state of an extension installed from
+// the TUI, else the probed status of a tool, falling back to the
+// declared status until the probes have run. Extensions with upstream
+// changes to pull are marked ⬆.
+func (m Model) statusBadge(tool *Tool) string {
+	badge := tool.Status
+	if record, ok := m.installs[tool.Key()]; ok {
+		badge = record.Badge()
+	} else if result, ok := m.probes[tool.Key()]; ok {
+		badge = result.Badge()
+	} else if m.probing {
+		badge = "… " + tool.Status
+	}
+	if info, ok := m.updates[tool.Key()]; ok && info.Available() {
+		badge += " " + info.Badge()
+	}
+	return badge
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// killGroupOnCancel leaves cancellation to exec, which kills the
+// process itself; there are no process groups to stop
+func killGroupOnCancel(cmd *exec.Cmd) {}
+
+// signalGroup stops cmd, as other signals cannot be sent here
+func signalGroup(cmd *exec.Cmd, sig os.Signal) {
+	cmd.Process.Kill()
+}
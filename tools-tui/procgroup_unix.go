@@ -0,0 +1,31 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killGroupOnCancel starts cmd in its own process group and makes the
+// cancellation of its context stop the whole group, so the processes a
+// tool starts, such as tools-tui exec under cli.py and the server it
+// runs, end with it: SIGTERM first, SIGKILL if they are still there
+// after streamWaitDelay
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		group := -cmd.Process.Pid
+		time.AfterFunc(streamWaitDelay, func() { syscall.Kill(group, syscall.SIGKILL) })
+		return syscall.Kill(group, syscall.SIGTERM)
+	}
+}
+
+// signalGroup passes a signal on to the process group of cmd
+func signalGroup(cmd *exec.Cmd, sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok {
+		syscall.Kill(-cmd.Process.Pid, s)
+	}
+}
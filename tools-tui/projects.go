@@ -0,0 +1,291 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxProjectDepth limits how deep nested projects are searched for
+const maxProjectDepth = 4
+
+// projectManifests maps manifest files to the language they indicate
+var projectManifests = map[string]string{
+	"package.json":   "node",
+	"pyproject.toml": "python",
+	"setup.py":       "python",
+	"go.mod":         "go",
+}
+
+// Project is a (sub-)project detected under the repository root
+type Project struct {
+	Name  string
+	Path  string
+	Kinds []string
+}
+
+// projectPickerView holds the state of the project picker screen
+type projectPickerView struct {
+	cursor int
+}
+
+// DetectProjects finds directories under root containing a project
+// manifest. The root itself is always the first entry.
+func DetectProjects(root string) []Project {
+	projects := []Project{{Name: "(root)", Path: root, Kinds: projectKinds(root)}}
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == root {
+			return nil
+		}
+		name := info.Name()
+		if strings.HasPrefix(name, ".") || hashSkipDirs[name] || venvDirs[name] {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(root, path)
+		if strings.Count(rel, string(filepath.Separator)) >= maxProjectDepth {
+			return filepath.SkipDir
+		}
+		if kinds := projectKinds(path); len(kinds) > 0 {
+			projects = append(projects, Project{Name: filepath.ToSlash(rel), Path: path, Kinds: kinds})
+		}
+		return nil
+	})
+
+	sort.SliceStable(projects[1:], func(i, j int) bool { return projects[i+1].Name < projects[j+1].Name })
+	return projects
+}
+
+// projectKinds lists the languages whose manifests exist in dir
+func projectKinds(dir string) []string {
+	seen := map[string]bool{}
+	var kinds []string
+	for manifest, kind := range projectManifests {
+		if !seen[kind] && fileExists(filepath.Join(dir, manifest)) {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Label renders the project name with its languages
+func (p Project) Label() string {
+	if len(p.Kinds) == 0 {
+		return p.Name
+	}
+	return p.Name + " [" + strings.Join(p.Kinds, ", ") + "]"
+}
+
+// cliPyWord matches cli.py as a word of a command
+var cliPyWord = regexp.MustCompile(`(^|\s)cli\.py(\s|$)`)
+
+// scopedCommand returns the directory and command used to run a
+// project-scoped tool inside projectDir. References to cli.py are made
+// absolute so the repository CLI is still found from the sub-project.
+func scopedCommand(tool *Tool, projectDir string) (string, string) {
+	if !tool.Scoped || projectDir == "" || projectDir == tool.WorkDir() {
+		return tool.RunDir(), tool.Command
+	}
+	script := quoteArg(filepath.Join(tool.WorkDir(), "cli.py"))
+	command := cliPyWord.ReplaceAllStringFunc(tool.Command, func(word string) string {
+		return strings.Replace(word, "cli.py", script, 1)
+	})
+	return projectDir, command
+}
+
+// commandLanguages infers a tool's language from the program it runs
+var commandLanguages = map[string]string{
+	"npm":    "node",
+	"npx":    "node",
+	"yarn":   "node",
+	"pnpm":   "node",
+	"pytest": "python",
+	"go":     "go",
+}
+
+// languages returns the declared languages of a tool, falling back to
+// the one implied by its command
+func (t *Tool) languages() []string {
+	if len(t.Languages) > 0 {
+		return t.Languages
+	}
+	if fields := strings.Fields(t.Command); len(fields) > 0 {
+		if lang, ok := commandLanguages[fields[0]]; ok {
+			return []string{lang}
+		}
+	}
+	return nil
+}
+
+// Relevance rates a tool for a project's languages: 1 when it targets
+// one of them, -1 when it only targets others and 0 when it is
+// language-agnostic. The matching languages are returned as well.
+func (t *Tool) Relevance(kinds []string) (int, []string) {
+	langs := t.languages()
+	if len(langs) == 0 || len(kinds) == 0 {
+		return 0, nil
+	}
+	var matched []string
+	for _, lang := range langs {
+		for _, kind := range kinds {
+			if lang == kind {
+				matched = append(matched, lang)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return -1, nil
+	}
+	return 1, matched
+}
+
+// projectKindsInScope returns the languages of the selected sub-project
+func (m Model) projectKindsInScope() []string {
+	if m.currentProject <= 0 || m.currentProject >= len(m.projects) {
+		return nil
+	}
+	return m.projects[m.currentProject].Kinds
+}
+
+// applyProjectRelevance reorders every category so tools relevant to the
+// selected project come first and inapplicable ones last. Without a
+// project the catalog order is restored.
+func (m *Model) applyProjectRelevance() {
+	if m.catalogOrder == nil {
+		m.catalogOrder = map[string]int{}
+		for _, category := range m.categories {
+			for j, tool := range category.Tools {
+				m.catalogOrder[tool.Key()] = j
+			}
+		}
+	}
+	kinds := m.projectKindsInScope()
+	// the history is only read when a category sorts by use
+	var usage map[string]toolUsage
+	if m.sortsBy(SortRecent) || m.sortsBy(SortFrequent) {
+		usage = loadToolUsage()
+	}
+	for i := range m.categories {
+		if m.categories[i].Favorites {
+			continue
+		}
+		tools := m.categories[i].Tools
+		mode := m.sorts[m.categories[i].Name]
+		sort.SliceStable(tools, func(a, b int) bool {
+			ra, _ := tools[a].Relevance(kinds)
+			rb, _ := tools[b].Relevance(kinds)
+			if ra != rb {
+				return ra > rb
+			}
+			return m.sortLess(mode, &tools[a], &tools[b], usage)
+		})
+	}
+	m.currentTool = 0
+}
+
+// visibleTools returns how many of a category's tools are listed.
+// Inapplicable tools sort last, so hiding them truncates the list.
+func (m Model) visibleTools(category Category) int {
+	if m.showInapplicable || category.Favorites {
+		return len(category.Tools)
+	}
+	kinds := m.projectKindsInScope()
+	visible := len(category.Tools)
+	for visible > 0 {
+		if relevance, _ := category.Tools[visible-1].Relevance(kinds); relevance >= 0 {
+			break
+		}
+		visible--
+	}
+	return visible
+}
+
+// projectDir returns the directory of the selected sub-project
+func (m Model) projectDir() string {
+	if m.currentProject <= 0 || m.currentProject >= len(m.projects) {
+		return ""
+	}
+	return m.projects[m.currentProject].Path
+}
+
+// projectLabel describes the active project scope for the header
+func (m Model) projectLabel() string {
+	if m.currentProject <= 0 || m.currentProject >= len(m.projects) {
+		return "📂 (root)"
+	}
+	return "📂 " + m.projects[m.currentProject].Label()
+}
+
+// openProjectPicker detects projects and shows the picker
+func (m *Model) openProjectPicker() {
+	m.projects = DetectProjects(defaultWorkDir)
+	if m.currentProject >= len(m.projects) {
+		m.currentProject = 0
+	}
+	m.projectPicker.cursor = m.currentProject
+	m.screen = screenProjects
+}
+
+// updateProjectPicker handles input on the project picker
+func (m Model) updateProjectPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.projectPicker
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(m.projects)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		m.currentProject = v.cursor
+		m.applyProjectRelevance()
+		m.screen = screenTools
+	}
+	return m, nil
+}
+
+// renderProjectPicker lists detected projects
+func (m Model) renderProjectPicker() string {
+	var content strings.Builder
+	title := titleStyle.Render("📂 Select Project")
+	status := statusStyle.Render(strings.TrimPrefix(m.projectLabel(), "📂 "))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	for i, project := range m.projects {
+		line := project.Label()
+		if i == m.currentProject {
+			line += " ✓"
+		}
+		if i == m.projectPicker.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(helpStyle.Render("Project-scoped tools (tests, analyzers) run inside the selected project; tools for its languages are listed first"))
+	content.WriteString("\n")
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", m.keys.Up, m.keys.Down), hint("select", m.keys.Enter), hint("back", m.keys.Back)}, " | ")))
+	return content.String()
+}
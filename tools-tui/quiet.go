@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxQuietSearch bounds the search for the end of a quiet period
+const maxQuietSearch = 31 * 24 * time.Hour
+
+// QuietHours is a window during which scheduled runs are deferred and
+// dangerous tools need an explicit override. Start and End are "15:04"
+// clock times and may wrap around midnight; Weekdays ("sat", "sun") and
+// Holidays ("2006-01-02") are quiet all day.
+type QuietHours struct {
+	Start    string   `json:"start,omitempty"`
+	End      string   `json:"end,omitempty"`
+	Weekdays []string `json:"weekdays,omitempty"`
+	Holidays []string `json:"holidays,omitempty"`
+}
+
+// parseClock converts "15:04" into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validate reports malformed settings
+func (q *QuietHours) validate() error {
+	if q == nil {
+		return nil
+	}
+	if (q.Start == "") != (q.End == "") {
+		return fmt.Errorf("quiet_hours needs both start and end")
+	}
+	if q.Start != "" {
+		if _, err := parseClock(q.Start); err != nil {
+			return fmt.Errorf("quiet_hours start: %v", err)
+		}
+		if _, err := parseClock(q.End); err != nil {
+			return fmt.Errorf("quiet_hours end: %v", err)
+		}
+	}
+	for _, day := range q.Weekdays {
+		if _, ok := weekdayNames[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("quiet_hours: unknown weekday %q", day)
+		}
+	}
+	for _, day := range q.Holidays {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return fmt.Errorf("quiet_hours: invalid holiday %q (use YYYY-MM-DD)", day)
+		}
+	}
+	return nil
+}
+
+// weekdayNames maps accepted weekday spellings
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// Active reports whether t falls in the quiet window, with the reason.
+// Invalid settings never make a time quiet.
+func (q *QuietHours) Active(t time.Time) (bool, string) {
+	if q == nil || q.validate() != nil {
+		return false, ""
+	}
+	for _, day := range q.Holidays {
+		if t.Format("2006-01-02") == day {
+			return true, "holiday " + day
+		}
+	}
+	for _, day := range q.Weekdays {
+		if weekdayNames[strings.ToLower(day)] == t.Weekday() {
+			return true, "quiet all " + t.Weekday().String()
+		}
+	}
+	if q.Start == "" {
+		return false, ""
+	}
+	start, _ := parseClock(q.Start)
+	end, _ := parseClock(q.End)
+	now := t.Hour()*60 + t.Minute()
+	active := now >= start && now < end
+	if start > end {
+		active = now >= start || now < end
+	}
+	if !active {
+		return false, ""
+	}
+	return true, fmt.Sprintf("quiet hours %s–%s", q.Start, q.End)
+}
+
+// NextAllowed returns the first minute at or after t outside the quiet
+// window, or the zero time if the window never ends
+func (q *QuietHours) NextAllowed(t time.Time) time.Time {
+	limit := t.Add(maxQuietSearch)
+	for t = t.Truncate(time.Minute); t.Before(limit); t = t.Add(time.Minute) {
+		if quiet, _ := q.Active(t); !quiet {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// loadQuietHours returns the configured quiet hours, or nil
+func loadQuietHours() *QuietHours {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil
+	}
+	return cfg.QuietHours
+}
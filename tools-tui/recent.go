@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxRecentTools is how many tools the quick switcher offers
+const maxRecentTools = 30
+
+// recentView holds the state of the quick switcher: the latest run of
+// each recently executed tool, filtered as the query is typed
+type recentView struct {
+	active bool
+	input  textinput.Model
+	cursor int
+	runs   []RunRecord
+	// counts are the runs of each tool in the history
+	counts map[string]int
+}
+
+// recentEntry is a run offered by the quick switcher
+type recentEntry struct {
+	run       RunRecord
+	positions []int
+	score     int
+}
+
+// openRecent shows the quick switcher over the tool list
+func (m *Model) openRecent() tea.Cmd {
+	records, err := LoadHistory()
+	if err != nil {
+		return m.showToast(fmt.Sprintf("Could not read the history: %v", err))
+	}
+	runs, counts := recentRuns(records)
+	if len(runs) == 0 {
+		return m.showToast(fmt.Sprintf("No tool was executed yet, '%s' runs the selected one", primaryKey(m.keys.Execute)))
+	}
+	input := newTextInput()
+	input.Placeholder = "Type a tool, command or argument..."
+	input.CharLimit = 100
+	input.Width = 50
+	m.recent = recentView{active: true, input: input, runs: runs, counts: counts}
+	return m.recent.input.Focus()
+}
+
+// recentRuns returns the latest run of each tool, newest first, and how
+// often each was run. Runs of the exec subcommand are left out as they
+// are not catalog tools.
+func recentRuns(records []RunRecord) ([]RunRecord, map[string]int) {
+	latest := map[string]RunRecord{}
+	counts := map[string]int{}
+	for _, record := range records {
+		if strings.HasPrefix(record.Tool, execToolPrefix) {
+			continue
+		}
+		counts[record.Tool]++
+		if record.Started.After(latest[record.Tool].Started) {
+			latest[record.Tool] = record
+		}
+	}
+	runs := make([]RunRecord, 0, len(latest))
+	for _, run := range latest {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+	if len(runs) > maxRecentTools {
+		runs = runs[:maxRecentTools]
+	}
+	return runs, counts
+}
+
+// entries returns the runs matching the query: fuzzy matches on the tool
+// name first, then runs whose command or arguments match
+func (v recentView) entries() []recentEntry {
+	query := strings.TrimSpace(v.input.Value())
+	var matched []recentEntry
+	for _, run := range v.runs {
+		if query == "" {
+			matched = append(matched, recentEntry{run: run})
+		} else if score, positions, ok := fuzzyMatch(query, run.Tool); ok {
+			matched = append(matched, recentEntry{run: run, positions: positions, score: score + 10})
+		} else if score, _, ok := fuzzyMatch(query, run.Command); ok {
+			matched = append(matched, recentEntry{run: run, score: score})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].score > matched[j].score })
+	return matched
+}
+
+// updateRecent handles keys while the quick switcher is open: enter runs
+// the selected tool again with the arguments of its latest run, tab
+// opens its details instead
+func (m Model) updateRecent(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.recent
+	switch msg.Type {
+	case tea.KeyEsc:
+		v.active = false
+		return m, nil
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyUp:
+		if v.cursor > 0 {
+			v.cursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if v.cursor < len(v.entries())-1 {
+			v.cursor++
+		}
+		return m, nil
+	case tea.KeyEnter, tea.KeyTab:
+		entries := v.entries()
+		v.active = false
+		if v.cursor >= len(entries) {
+			return m, nil
+		}
+		run := entries[v.cursor].run
+		if msg.Type == tea.KeyTab {
+			tool := findTool(m.categories, run.Tool)
+			if tool == nil {
+				return m, m.showToast(fmt.Sprintf("%s is no longer in the catalog", run.Tool))
+			}
+			m.detailMode = true
+			m.selectedTool = tool
+			m.statusMessage = ""
+			m.warning = ""
+			m.attachDetail()
+			return m, nil
+		}
+		cmd, err := m.rerun(run)
+		if err != nil {
+			return m, m.showToast("Cannot run again: " + err.Error())
+		}
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	v.input, cmd = v.input.Update(msg)
+	v.cursor = 0
+	return m, cmd
+}
+
+// renderRecent lists the matching tools with their latest run
+func (m Model) renderRecent() string {
+	v := m.recent
+	var content strings.Builder
+	title := titleStyle.Render("🕘 Recent Tools")
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", commandStyle.Render(v.input.View())))
+	content.WriteString("\n\n")
+
+	entries := v.entries()
+	if len(entries) == 0 {
+		content.WriteString(descriptionStyle.Render("No recent tool matches"))
+		content.WriteString("\n")
+	}
+	now := time.Now()
+	for i, entry := range entries {
+		if i >= maxSearchResults {
+			content.WriteString(helpStyle.Render(fmt.Sprintf("… %d more", len(entries)-i)))
+			content.WriteString("\n")
+			break
+		}
+		run := entry.run
+		mark := "✔"
+		if !run.Success {
+			mark = warningStyle.Render("✘")
+		}
+		var args []string
+		for name, value := range run.Args {
+			args = append(args, name+"="+value)
+		}
+		sort.Strings(args)
+		details := fmt.Sprintf("%s · %d run(s)", age(now.Sub(run.Started)), v.counts[run.Tool])
+		if len(args) > 0 {
+			details += " · " + truncate(strings.Join(args, " "), 50)
+		}
+		line := fmt.Sprintf("%s %s %s", mark, highlightMatches(run.Tool, entry.positions, 0), helpStyle.Render(details))
+		if i == v.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ ") + line)
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(footerStyle.Render(strings.Join([]string{"type: filter", "↑/↓: select", "enter: run again", "tab: details", "esc: close"}, " | ")))
+	return content.String()
+}
+
+// age describes how long ago something happened in its largest unit
+func age(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshingLabel is shown next to a view's title while its data reloads
+const refreshingLabel = "↻ refreshing…"
+
+// ansiSequence matches the terminal escape sequences of styled text
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*[a-zA-Z]")
+
+// renderStale dims data that is being refreshed, so the previous results
+// stay readable until fresh ones replace them
+func renderStale(stale bool, block string) string {
+	if !stale || block == "" {
+		return block
+	}
+	return helpStyle.Render(ansiSequence.ReplaceAllString(block, ""))
+}
+
+// catalogMsg carries a catalog reloaded from the inventory
+type catalogMsg struct {
+	categories []Category
+	secrets    map[string]SecretInfo
+	extensions extensionStates
+	err        error
+}
+
+// loadCatalogCmd reloads the inventory in the background
+func loadCatalogCmd() tea.Cmd {
+	return func() tea.Msg {
+		categories, err := LoadToolsFromInventory()
+		extensions, _ := loadExtensionStates()
+		return catalogMsg{categories: categories, secrets: loadSecretIndex(), extensions: extensions, err: err}
+	}
+}
+
+// refreshCatalog starts reloading the inventory; the list is dimmed until
+// the new catalog arrives
+func (m *Model) refreshCatalog() tea.Cmd {
+	if m.refreshing {
+		return nil
+	}
+	m.refreshing = true
+	return loadCatalogCmd()
+}
+
+// applyCatalog replaces the catalog with a reloaded one, keeping the
+// expanded categories, the favorites and the selection where possible
+func (m *Model) applyCatalog(msg catalogMsg) tea.Cmd {
+	m.refreshing = false
+	expanded := map[string]bool{}
+	for _, category := range m.categories {
+		expanded[category.Name] = category.Active
+	}
+	// the reloaded catalog has no favorites category until it is rebuilt
+	onFavorites := false
+	if len(m.categories) > 0 && m.categories[0].Favorites {
+		if m.currentCat == 0 {
+			onFavorites = true
+		} else {
+			m.currentCat--
+		}
+	}
+	tool := m.currentTool
+
+	m.categories = msg.categories
+	m.secretIndex = msg.secrets
+	m.extensionStates = msg.extensions
+	m.scheduler.SetCatalog(msg.categories)
+	for i := range m.categories {
+		m.categories[i].Active = expanded[m.categories[i].Name]
+	}
+	m.catalogOrder = nil
+	m.applyProjectRelevance()
+	m.refreshFavorites()
+	if onFavorites || m.currentCat >= len(m.categories) {
+		m.currentCat = 0
+	}
+	if m.currentCat < len(m.categories) && tool < m.visibleTools(m.categories[m.currentCat]) {
+		m.currentTool = tool
+	}
+
+	if msg.err != nil {
+		return m.showToast("Inventory error: " + msg.err.Error())
+	}
+	return m.showToast("Catalog refreshed")
+}
+
+// historyMsg carries the run log reloaded from disk
+type historyMsg struct {
+	records []RunRecord
+	notes   map[string]string
+	err     error
+}
+
+// loadHistoryCmd reloads the run log and its annotations in the background
+func loadHistoryCmd() tea.Cmd {
+	return func() tea.Msg {
+		records, err := LoadHistory()
+		return historyMsg{records: records, notes: LoadAnnotations().Runs, err: err}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultReleaseTargets are the GOOS/GOARCH pairs built by default
+const defaultReleaseTargets = "linux/amd64,linux/arm64,darwin/amd64,darwin/arm64,windows/amd64"
+
+// defaultNotesTemplate renders the GitHub release body
+const defaultNotesTemplate = `## {{.Version}}
+
+Released {{.Date}}.
+{{if .Commits}}
+### Changes
+{{range .Commits}}
+- {{.}}{{end}}
+{{end}}
+### Artifacts
+{{range .Artifacts}}
+- ` + "`{{.Name}}`" + ` ({{.Target}}){{end}}
+
+Verify downloads with ` + "`sha256sum -c SHA256SUMS`" + `.
+`
+
+// releaseProject is a Go main package that gets cross-compiled
+type releaseProject struct {
+	Name string
+	Dir  string
+}
+
+// releaseArtifact is one built binary
+type releaseArtifact struct {
+	Name   string
+	Path   string
+	Target string
+}
+
+// releaseNotes is the data available to the notes template
+type releaseNotes struct {
+	Version   string
+	Date      string
+	Commits   []string
+	Artifacts []releaseArtifact
+}
+
+// runRelease implements the release subcommand
+func runRelease(args []string) error {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	ver := fs.String("version", "", "release version, e.g. v1.2.0 (required)")
+	out := fs.String("out", "dist", "output directory for artifacts")
+	targets := fs.String("targets", defaultReleaseTargets, "comma separated GOOS/GOARCH pairs")
+	repo := fs.String("repo", "", "GitHub owner/name (defaults to the origin remote)")
+	notesTmpl := fs.String("notes-template", "", "text/template file for the release notes")
+	github := fs.Bool("github", false, "draft a GitHub release and upload artifacts (needs GITHUB_TOKEN)")
+	extensions := fs.Bool("extensions", true, "also build Go extensions found under ../extensions")
+	fs.Parse(args)
+
+	if *ver == "" {
+		return fmt.Errorf("--version is required")
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	projects := []releaseProject{{Name: "tools-tui", Dir: "."}}
+	if *extensions {
+		projects = append(projects, findGoExtensions(filepath.Join("..", "extensions"))...)
+	}
+
+	var artifacts []releaseArtifact
+	for _, project := range projects {
+		for _, target := range strings.Split(*targets, ",") {
+			target = strings.TrimSpace(target)
+			artifact, err := buildArtifact(project, target, *ver, *out)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", project.Name, target, err)
+			}
+			fmt.Printf("built %s\n", artifact.Path)
+			artifacts = append(artifacts, artifact)
+		}
+	}
+
+	sums, err := writeChecksums(*out, artifacts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n", sums)
+
+	notes, err := renderReleaseNotes(*notesTmpl, *ver, artifacts)
+	if err != nil {
+		return err
+	}
+	notesPath := filepath.Join(*out, "RELEASE_NOTES.md")
+	if err := WriteFileContent(notesPath, notes); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n", notesPath)
+
+	if !*github {
+		return nil
+	}
+	if *repo == "" {
+		*repo = originRepo("..")
+	}
+	if *repo == "" {
+		return fmt.Errorf("could not determine the GitHub repository, pass --repo owner/name")
+	}
+	uploads := append(artifacts, releaseArtifact{Name: "SHA256SUMS", Path: sums})
+	htmlURL, err := draftGitHubRelease(*repo, *ver, notes, uploads)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("drafted release %s\n", htmlURL)
+	return nil
+}
+
+// findGoExtensions returns extension directories that contain a go.mod
+func findGoExtensions(dir string) []releaseProject {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var projects []releaseProject
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() && fileExists(filepath.Join(path, "go.mod")) {
+			projects = append(projects, releaseProject{Name: entry.Name(), Dir: path})
+		}
+	}
+	return projects
+}
+
+// buildArtifact cross-compiles one project for a GOOS/GOARCH target
+func buildArtifact(project releaseProject, target, ver, out string) (releaseArtifact, error) {
+	goos, goarch, ok := strings.Cut(target, "/")
+	if !ok {
+		return releaseArtifact{}, fmt.Errorf("invalid target %q, expected GOOS/GOARCH", target)
+	}
+	name := fmt.Sprintf("%s_%s_%s_%s", project.Name, strings.TrimPrefix(ver, "v"), goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	path, err := filepath.Abs(filepath.Join(out, name))
+	if err != nil {
+		return releaseArtifact{}, err
+	}
+
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags", "-s -w -X main.version="+ver, "-o", path, ".")
+	cmd.Dir = project.Dir
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return releaseArtifact{}, fmt.Errorf("%v\n%s", err, output)
+	}
+	return releaseArtifact{Name: name, Path: path, Target: target}, nil
+}
+
+// writeChecksums writes a SHA256SUMS file covering every artifact
+func writeChecksums(out string, artifacts []releaseArtifact) (string, error) {
+	var b strings.Builder
+	for _, artifact := range artifacts {
+		sum, err := hashFile(artifact.Path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, artifact.Name)
+	}
+	path := filepath.Join(out, "SHA256SUMS")
+	return path, WriteFileContent(path, b.String())
+}
+
+// renderReleaseNotes fills the notes template for a release
+func renderReleaseNotes(tmplPath, ver string, artifacts []releaseArtifact) (string, error) {
+	text := defaultNotesTemplate
+	if tmplPath != "" {
+		content, err := ReadFileContent(tmplPath)
+		if err != nil {
+			return "", err
+		}
+		text = content
+	}
+	tmpl, err := template.New("notes").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := releaseNotes{Version: ver, Date: time.Now().Format("2006-01-02"), Artifacts: artifacts}
+	logRange := "HEAD"
+	if tag, err := exec.Command("git", "describe", "--tags", "--abbrev=0").Output(); err == nil {
+		logRange = strings.TrimSpace(string(tag)) + "..HEAD"
+	}
+	if out, err := exec.Command("git", "log", "--pretty=format:%s", "-n", "50", logRange).Output(); err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				data.Commits = append(data.Commits, line)
+			}
+		}
+	}
+	sort.Slice(data.Artifacts, func(i, j int) bool { return data.Artifacts[i].Name < data.Artifacts[j].Name })
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// originRepo returns owner/name of the GitHub origin remote of dir
+func originRepo(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	match := githubRemote.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if match == nil {
+		return ""
+	}
+	return match[1] + "/" + match[2]
+}
+
+// draftGitHubRelease creates a draft release and uploads its assets
+func draftGitHubRelease(repo, ver, notes string, artifacts []releaseArtifact) (string, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	client := &http.Client{Timeout: 5 * time.Minute}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"tag_name": ver,
+		"name":     ver,
+		"body":     notes,
+		"draft":    true,
+	})
+	req, err := http.NewRequest(http.MethodPost, "https://api.github.com/repos/"+repo+"/releases", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("creating release: GitHub API returned %s", resp.Status)
+	}
+
+	var release struct {
+		HTMLURL   string `json:"html_url"`
+		UploadURL string `json:"upload_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	uploadBase, _, _ := strings.Cut(release.UploadURL, "{")
+
+	for _, artifact := range artifacts {
+		data, err := os.ReadFile(artifact.Path)
+		if err != nil {
+			return "", err
+		}
+		req, err := http.NewRequest(http.MethodPost, uploadBase+"?name="+url.QueryEscape(artifact.Name), bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/octet-stream")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return "", fmt.Errorf("uploading %s: GitHub API returned %s", artifact.Name, resp.Status)
+		}
+		fmt.Printf("uploaded %s\n", artifact.Name)
+	}
+	return release.HTMLURL, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// reposFile lists additional repositories merged into the catalog
+const reposFile = "repos.json"
+
+// repoInventoryFile is the catalog a registered repository can ship
+const repoInventoryFile = "inventory.json"
+
+// cliCommandsLine matches the usage line printed by opencode-style cli.py files
+var cliCommandsLine = regexp.MustCompile(`Commands:\s*([a-z0-9_, -]+)`)
+
+// Repo is an additional opencode-style toolkit shown in the catalog
+type Repo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// LoadRepos returns the registered repositories
+func LoadRepos() ([]Repo, error) {
+	var repos []Repo
+	err := loadJSON(reposFile, &repos)
+	return repos, err
+}
+
+// Key returns the identifier used to persist per-tool settings. Tools of
+// the primary repository keep their plain name.
+func (t *Tool) Key() string {
+	if t.Repo == "" {
+		return t.Name
+	}
+	return t.Repo + "/" + t.Name
+}
+
+// loadRepoCatalog reads a repository's inventory.json, falling back to
+// one tool per command advertised in its cli.py usage line.
+func loadRepoCatalog(repo Repo) ([]Category, error) {
+	categories, err := LoadManifest(filepath.Join(repo.Path, repoInventoryFile))
+	if err != nil {
+		return nil, err
+	}
+	if categories != nil {
+		return categories, nil
+	}
+
+	content, err := ReadFileContent(filepath.Join(repo.Path, "cli.py"))
+	if err != nil {
+		return nil, fmt.Errorf("%s has neither %s nor cli.py", repo.Path, repoInventoryFile)
+	}
+	names := parseCLICommands(content)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s/cli.py does not list its commands", repo.Path)
+	}
+
+	category := Category{Name: "🧰 Commands", Purpose: "Commands discovered in cli.py"}
+	for _, name := range names {
+		category.Tools = append(category.Tools, Tool{
+			Name:        name,
+			Purpose:     "cli.py " + name,
+			Command:     "python cli.py " + name,
+			Status:      "❔ Unknown",
+			Description: fmt.Sprintf("Discovered from %s/cli.py", repo.Name),
+		})
+	}
+	return []Category{category}, nil
+}
+
+// appendRepoCatalogs merges the catalogs of all registered repositories,
+// tagging each tool with its repository so it executes in that root.
+// Repositories that fail to load are reported as a category of their own.
+func appendRepoCatalogs(categories []Category) []Category {
+	repos, err := LoadRepos()
+	if err != nil {
+		return categories
+	}
+	for _, repo := range repos {
+		repoCategories, err := loadRepoCatalog(repo)
+		if err != nil {
+			categories = append(categories, Category{
+				Name:    "📁 " + repo.Name,
+				Purpose: "⚠ " + err.Error(),
+				Active:  true,
+			})
+			continue
+		}
+		for _, category := range repoCategories {
+			category.Name = "📁 " + repo.Name + " › " + category.Name
+			category.Active = true
+			for i := range category.Tools {
+				category.Tools[i].Repo = repo.Name
+				category.Tools[i].RepoRoot = repo.Path
+			}
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// runRepos implements the repos subcommand: list, add and remove
+func runRepos(args []string) error {
+	repos, err := LoadRepos()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || args[0] == "list" {
+		if len(repos) == 0 {
+			fmt.Println("No repositories registered. Add one with: tools-tui repos add <path> [name]")
+		}
+		for _, repo := range repos {
+			fmt.Printf("%-20s %s\n", repo.Name, repo.Path)
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: tools-tui repos add <path> [name]")
+		}
+		path, err := filepath.Abs(args[1])
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		if len(args) > 2 {
+			name = args[2]
+		}
+		for _, repo := range repos {
+			if repo.Name == name {
+				return fmt.Errorf("a repository named %q is already registered", name)
+			}
+		}
+		repo := Repo{Name: name, Path: path}
+		if _, err := loadRepoCatalog(repo); err != nil {
+			return err
+		}
+		repos = append(repos, repo)
+		fmt.Printf("Registered %s (%s)\n", name, path)
+	case "remove":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: tools-tui repos remove <name>")
+		}
+		kept := repos[:0]
+		for _, repo := range repos {
+			if repo.Name != args[1] {
+				kept = append(kept, repo)
+			}
+		}
+		if len(kept) == len(repos) {
+			return fmt.Errorf("no repository named %q", args[1])
+		}
+		repos = kept
+		fmt.Printf("Removed %s\n", args[1])
+	default:
+		return fmt.Errorf("unknown repos action %q (use list, add or remove)", args[0])
+	}
+	return saveJSON(reposFile, repos)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// accessFile maps API tokens to roles
+const accessFile = "access.json"
+
+// Role controls what a remote client may do
+type Role string
+
+const (
+	RoleNone     Role = ""
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+// roleRank orders roles from least to most privileged
+var roleRank = map[Role]int{RoleNone: 0, RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// Allows reports whether r grants at least the privileges of min
+func (r Role) Allows(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+// The least roles allowed to view, to execute and to administer; every
+// endpoint requires one of them
+const (
+	viewRole    = RoleViewer
+	executeRole = RoleOperator
+	adminRole   = RoleAdmin
+)
+
+// CanView reports whether the role may browse the catalog, jobs,
+// history and downloads
+func (r Role) CanView() bool { return r.Allows(viewRole) }
+
+// CanExecute reports whether the role may start and cancel jobs
+func (r Role) CanExecute() bool { return r.Allows(executeRole) }
+
+// CanAdminister reports whether the role may edit the inventory and
+// start and stop the daemon's MCP servers
+func (r Role) CanAdminister() bool { return r.Allows(adminRole) }
+
+// AccessConfig is the content of access.json. Anonymous is the role of
+// clients without credentials; they are denied unless it is set.
+type AccessConfig struct {
+	Anonymous *Role           `json:"anonymous,omitempty"`
+	Tokens    map[string]Role `json:"tokens"`
+	// SSHKeys is refused: no SSH front end serves the tools, so keys
+	// mapped to roles would grant nothing
+	SSHKeys map[string]Role `json:"ssh_keys,omitempty"`
+}
+
+// LoadAccessConfig reads and validates access.json
+func LoadAccessConfig() (AccessConfig, error) {
+	var cfg AccessConfig
+	if err := loadJSON(accessFile, &cfg); err != nil {
+		return cfg, err
+	}
+	check := func(what string, role Role) error {
+		if _, ok := roleRank[role]; !ok || role == RoleNone {
+			return fmt.Errorf("%s: %s has unknown role %q (use viewer, operator or admin)", accessFile, what, role)
+		}
+		return nil
+	}
+	if cfg.Anonymous != nil && *cfg.Anonymous != RoleNone {
+		if err := check("anonymous", *cfg.Anonymous); err != nil {
+			return cfg, fmt.Errorf("%v, or leave it out to deny anonymous clients", err)
+		}
+	}
+	for _, role := range cfg.Tokens {
+		if err := check("a token", role); err != nil {
+			return cfg, err
+		}
+	}
+	if len(cfg.SSHKeys) > 0 {
+		return cfg, fmt.Errorf("%s: ssh_keys is not supported, nothing authenticates SSH keys; give those clients tokens", accessFile)
+	}
+	return cfg, nil
+}
+
+// anonymousRole returns the role of unauthenticated clients
+func (c AccessConfig) anonymousRole() Role {
+	if c.Anonymous == nil {
+		return RoleNone
+	}
+	return *c.Anonymous
+}
+
+// RoleForToken resolves a bearer token to a role
+func (c AccessConfig) RoleForToken(token string) Role {
+	if token == "" {
+		return c.anonymousRole()
+	}
+	for known, role := range c.Tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return role
+		}
+	}
+	return RoleNone
+}
+
+// requestRole resolves the role of an HTTP request from its bearer
+// token, or from ?token= when queryToken allows it
+func (c AccessConfig) requestRole(r *http.Request, queryToken bool) Role {
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if token == "" && queryToken {
+		token = r.URL.Query().Get("token")
+	}
+	return c.RoleForToken(token)
+}
+
+// requireRole wraps a handler so only clients with at least min may call it
+func (c AccessConfig) requireRole(min Role, next http.HandlerFunc) http.HandlerFunc {
+	return c.authorize(min, false, next)
+}
+
+// requireStreamRole is requireRole for server-sent event streams, which
+// also take the token as ?token= since browsers cannot set headers on
+// an EventSource. Query strings end up in logs and browser history, so
+// no other endpoint accepts it.
+func (c AccessConfig) requireStreamRole(min Role, next http.HandlerFunc) http.HandlerFunc {
+	return c.authorize(min, true, next)
+}
+
+// authorize wraps a handler so only clients with at least min may call it
+func (c AccessConfig) authorize(min Role, queryToken bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role := c.requestRole(r, queryToken)
+		if !role.Allows(min) {
+			if role == RoleNone {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "authentication required", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, fmt.Sprintf("role %s may not access this endpoint (needs %s)", role, min), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleWhoami reports the caller's role and permissions
+func (c AccessConfig) handleWhoami(w http.ResponseWriter, r *http.Request) {
+	role := c.requestRole(r, false)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"role":        role,
+		"can_view":    role.CanView(),
+		"can_execute": role.CanExecute(),
+		"can_admin":   role.CanAdminister(),
+	})
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runManifestsDir is the directory of the config directory that run
+// manifests are written to, one file per run
+const runManifestsDir = "manifests"
+
+// runManifestFormat is the version of the run manifest format
+const runManifestFormat = 1
+
+// RunManifest describes one execution precisely enough to repeat it
+// later or on another machine with `tools-tui replay`
+type RunManifest struct {
+	Format int    `json:"format"`
+	RunID  string `json:"run_id"`
+	// Tool is the catalog key, Command the command after placeholders
+	// were filled and Dir the directory it ran in
+	Tool      string            `json:"tool"`
+	Name      string            `json:"name"`
+	Repo      string            `json:"repo,omitempty"`
+	Command   string            `json:"command"`
+	Args      map[string]string `json:"args,omitempty"`
+	Project   string            `json:"project,omitempty"`
+	Dir       string            `json:"dir"`
+	Trust     TrustLevel        `json:"trust,omitempty"`
+	Dangerous bool              `json:"dangerous,omitempty"`
+	// ToolEnv and Shell are the tool's declared environment, before
+	// $NAME references are expanded, and the shell it runs in
+	ToolEnv map[string]string `json:"tool_env,omitempty"`
+	Shell   string            `json:"shell,omitempty"`
+	// Root is the repository the tool belongs to; Dir and Command are
+	// moved along with it when a replay runs in another checkout
+	Root string `json:"root,omitempty"`
+	// Env holds the recorded environment variables; credentials are
+	// only recorded as "(set)"
+	Env         map[string]string `json:"env,omitempty"`
+	GitSHA      string            `json:"git_sha,omitempty"`
+	GitBranch   string            `json:"git_branch,omitempty"`
+	GitWorktree string            `json:"git_worktree,omitempty"`
+	// Versions are the toolchain versions, TUIVersion and Platform
+	// identify the build and machine that ran the tool
+	Versions   map[string]string `json:"versions,omitempty"`
+	TUIVersion string            `json:"tui_version"`
+	Platform   string            `json:"platform"`
+	Started    time.Time         `json:"started"`
+	ExitCode   int               `json:"exit_code"`
+	Success    bool              `json:"success"`
+	// ReplayOf is the run id of the manifest a replay reproduced
+	ReplayOf string `json:"replay_of,omitempty"`
+}
+
+// newRunManifest describes the run recorded in record of tool
+func newRunManifest(record RunRecord, tool *Tool) RunManifest {
+	manifest := RunManifest{
+		Format:      runManifestFormat,
+		RunID:       record.ID,
+		Tool:        record.Tool,
+		Name:        tool.Name,
+		Repo:        tool.Repo,
+		Command:     record.Command,
+		Args:        record.Args,
+		Project:     record.Project,
+		Dir:         record.Dir,
+		Trust:       tool.Trust,
+		Dangerous:   tool.Dangerous,
+		ToolEnv:     tool.Env,
+		Shell:       tool.Shell,
+		Root:        tool.WorkDir(),
+		GitSHA:      record.Env["git:sha"],
+		GitBranch:   record.Env["git:branch"],
+		GitWorktree: record.Env["git:worktree"],
+		TUIVersion:  version,
+		Platform:    runtime.GOOS + "/" + runtime.GOARCH,
+		Started:     record.Started,
+		ExitCode:    record.ExitCode,
+		Success:     record.Success,
+	}
+	for key, value := range record.Env {
+		if name, ok := strings.CutPrefix(key, "env:"); ok {
+			if manifest.Env == nil {
+				manifest.Env = map[string]string{}
+			}
+			manifest.Env[name] = value
+		}
+		if name, ok := strings.CutPrefix(key, "tool:"); ok {
+			if manifest.Versions == nil {
+				manifest.Versions = map[string]string{}
+			}
+			manifest.Versions[name] = value
+		}
+	}
+	return manifest
+}
+
+// replayDivergences compares the recorded run with the environment of
+// a replay. Warnings are differences that can change the outcome: the
+// git revision, uncommitted changes, toolchain versions, the platform
+// and the TUI build. Notes list environment variables that could not be
+// restored, such as PATH and credentials.
+func replayDivergences(r RunManifest, env EnvSnapshot) (warnings, notes []string) {
+	short := func(sha string) string {
+		if len(sha) > 12 {
+			return sha[:12]
+		}
+		return sha
+	}
+	if r.GitSHA != "" && env["git:sha"] != r.GitSHA {
+		here := "not a git checkout"
+		if env["git:sha"] != "" {
+			here = short(env["git:sha"]) + " on " + env["git:branch"]
+		}
+		warnings = append(warnings, fmt.Sprintf("git revision: recorded %s on %s, here %s", short(r.GitSHA), r.GitBranch, here))
+	}
+	switch {
+	case env["git:worktree"] == "" || env["git:worktree"] == "clean":
+	case r.GitWorktree == "clean":
+		warnings = append(warnings, "git worktree: the checkout has uncommitted changes, the recorded one was clean")
+	default:
+		warnings = append(warnings, "git worktree: the checkout has uncommitted changes, they may differ from those of the recorded run")
+	}
+	names := make([]string, 0, len(r.Versions))
+	for name := range r.Versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if here := env["tool:"+name]; here != r.Versions[name] {
+			warnings = append(warnings, fmt.Sprintf("%s: recorded %s, here %s", name, r.Versions[name], here))
+		}
+	}
+	if platform := runtime.GOOS + "/" + runtime.GOARCH; r.Platform != "" && r.Platform != platform {
+		warnings = append(warnings, fmt.Sprintf("platform: recorded %s, here %s", r.Platform, platform))
+	}
+	if r.TUIVersion != "" && r.TUIVersion != version {
+		warnings = append(warnings, fmt.Sprintf("tools-tui: recorded %s, here %s", r.TUIVersion, version))
+	}
+
+	recorded := EnvSnapshot{}
+	for name, value := range r.Env {
+		recorded["env:"+name] = value
+	}
+	for _, change := range DiffEnv(recorded, env) {
+		if name, ok := strings.CutPrefix(change.Key, "env:"); ok {
+			notes = append(notes, fmt.Sprintf("%s not restored: recorded %q, here %q", name, change.Old, change.New))
+		}
+	}
+	return warnings, notes
+}
+
+// rebase moves the run to another checkout of its repository: the
+// directory and the paths of the command that point into the recorded
+// repository are rewritten to point into root
+func (r *RunManifest) rebase(root string) error {
+	if r.Root == "" {
+		return fmt.Errorf("the manifest does not record the repository root, --root needs a manifest written by a newer build")
+	}
+	rel, err := filepath.Rel(r.Root, r.Dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = "."
+	}
+	r.Command = strings.ReplaceAll(r.Command, r.Root, root)
+	r.Dir = filepath.Join(root, rel)
+	r.Root = root
+	return nil
+}
+
+// WriteRunManifest stores a manifest in the manifests directory and
+// returns its path
+func WriteRunManifest(manifest RunManifest) (string, error) {
+	dir := filepath.Join(ConfigDir(), runManifestsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, manifest.RunID+".json")
+	return path, WriteFileContent(path, string(data)+"\n")
+}
+
+// LoadRunManifest reads a run manifest written by this or an older
+// version
+func LoadRunManifest(path string) (RunManifest, error) {
+	var manifest RunManifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("%s: %v", path, err)
+	}
+	switch {
+	case manifest.Format > runManifestFormat:
+		return manifest, fmt.Errorf("%s: manifest format %d is newer than this build supports (%d)", path, manifest.Format, runManifestFormat)
+	case manifest.Command == "" || manifest.Dir == "":
+		return manifest, fmt.Errorf("%s: not a run manifest, command and dir are required", path)
+	}
+	return manifest, nil
+}
+
+// runReplay repeats the run described by a manifest: the recorded
+// command runs in the recorded directory with the recorded environment
+// variables, after reporting how the machine differs from the original
+// one. The same prompts as `run` become flags.
+func runReplay(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("usage: tools-tui replay <manifest> [--root checkout] [--dry-run] [--strict] [--yes] [--override]")
+	}
+	path, args := args[0], args[1:]
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only report the command and the differences to the recorded run")
+	strict := fs.Bool("strict", false, "refuse to run when the git revision, a toolchain version or the platform differs")
+	root := fs.String("root", "", "run in this checkout of the repository instead of the recorded one")
+	yes := fs.Bool("yes", false, "run tools whose trust tier asks for confirmation")
+	override := fs.Bool("override", false, "run dangerous tools during quiet hours")
+	fs.Parse(args)
+
+	manifest, err := LoadRunManifest(path)
+	if err != nil {
+		return err
+	}
+	if *root != "" {
+		abs, err := filepath.Abs(*root)
+		if err != nil {
+			return err
+		}
+		if err := manifest.rebase(abs); err != nil {
+			return err
+		}
+	}
+	tool := &Tool{
+		Name:      manifest.Name,
+		Repo:      manifest.Repo,
+		Command:   manifest.Command,
+		Trust:     manifest.Trust,
+		Dangerous: manifest.Dangerous,
+		Env:       manifest.ToolEnv,
+		Shell:     manifest.Shell,
+		RepoRoot:  manifest.Dir,
+	}
+	if info, err := os.Stat(manifest.Dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s does not exist on this machine, pass --root with a checkout of the repository", manifest.Dir)
+	}
+	if quiet, reason := loadQuietHours().Active(time.Now()); quiet && tool.Dangerous && !*override {
+		return fmt.Errorf("%s is dangerous and it is %s, pass --override to run it anyway", tool.Name, reason)
+	}
+	approval := Attended
+	if *yes {
+		approval = Confirmed
+	}
+	if reason := runApproval(tool, approval); reason != "" {
+		return fmt.Errorf("cannot run %s: %s", tool.Name, reason)
+	}
+
+	// PATH is kept because it names directories of this machine, and
+	// credentials were never recorded
+	for _, name := range envVariables {
+		if name == "PATH" || isSecretName(name) {
+			continue
+		}
+		if value, ok := manifest.Env[name]; ok {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+	env := CaptureEnv(manifest.Dir)
+	fmt.Fprintf(os.Stderr, "Replaying %s from %s\n$ %s\n  in %s\n", manifest.Tool, manifest.Started.Format("2006-01-02 15:04"), manifest.Command, manifest.Dir)
+	warnings, notes := replayDivergences(manifest, env)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+	}
+	for _, note := range notes {
+		fmt.Fprintf(os.Stderr, "  %s\n", note)
+	}
+	if *strict && len(warnings) > 0 {
+		return fmt.Errorf("the environment differs from the recorded run in %d ways, run without --strict to replay anyway", len(warnings))
+	}
+	if *dryRun {
+		return nil
+	}
+
+	started := time.Now()
+	output, runErr := ExecuteTool(tool, "")
+	record := newRunRecord(tool, "", started, env, output, runErr)
+	record.Args = manifest.Args
+	record.Project = manifest.Project
+	record.Output = output
+	if err := AppendHistory(record, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record run history: %v\n", err)
+	}
+	if cfg, _ := LoadConfig(); cfg.Manifests {
+		replay := newRunManifest(record, tool)
+		replay.ReplayOf = manifest.RunID
+		if path, err := WriteRunManifest(replay); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not write run manifest: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Manifest: %s\n", path)
+		}
+	}
+	if err := printJSON(record); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s failed: %v", tool.Name, runErr)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runOutputsDir holds the whole output of recent runs, one directory
+// per tool and one file per run named after its ID
+const runOutputsDir = "outputs"
+
+// maxRunOutput is how much of a run's output is stored, from the end
+const maxRunOutput = 1 << 20
+
+// keptRunOutputs is how many outputs of each tool are kept
+const keptRunOutputs = 20
+
+// runOutputPath returns where the output of a run of tool is stored
+func runOutputPath(tool, id string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(tool, "-"), "-")
+	return filepath.Join(ConfigDir(), runOutputsDir, name, id+".log")
+}
+
+// SaveRunOutput stores the output of a run without colours, then drops
+// the oldest outputs of the tool beyond keptRunOutputs
+func SaveRunOutput(record RunRecord, output string) error {
+	path := runOutputPath(record.Tool, record.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := WriteFileContent(path, truncateOutput(plainText(output), maxRunOutput)); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".log") {
+			names = append(names, entry.Name())
+		}
+	}
+	// run IDs sort by start time
+	sort.Strings(names)
+	for _, name := range names[:max(len(names)-keptRunOutputs, 0)] {
+		os.Remove(filepath.Join(filepath.Dir(path), name))
+	}
+	return nil
+}
+
+// LoadRunOutput returns the stored output of a run, if it is kept
+func LoadRunOutput(record RunRecord) (string, bool) {
+	data, err := os.ReadFile(runOutputPath(record.Tool, record.ID))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// previousRun returns the latest run of the same tool in the same
+// project that started before run and whose output is kept. records
+// are in the order of the log, oldest first.
+func previousRun(records []RunRecord, run RunRecord) (RunRecord, bool) {
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.Tool != run.Tool || r.Project != run.Project || !r.Started.Before(run.Started) {
+			continue
+		}
+		if _, ok := LoadRunOutput(r); ok {
+			return r, true
+		}
+	}
+	return RunRecord{}, false
+}
+
+// runLabel names a run in the header of a diff
+func runLabel(run RunRecord) string {
+	return fmt.Sprintf("%s %s (exit %d)", run.Tool, run.Started.Local().Format("2006-01-02 15:04:05"), run.ExitCode)
+}
+
+// CompareWithPrevious diffs the output of a run with the one of the
+// previous run of its tool, returning "" when they are the same
+func CompareWithPrevious(records []RunRecord, run RunRecord) (string, RunRecord, error) {
+	output, ok := LoadRunOutput(run)
+	if !ok {
+		return "", RunRecord{}, fmt.Errorf("the output of the run of %s is no longer kept", runLabel(run))
+	}
+	prev, ok := previousRun(records, run)
+	if !ok {
+		return "", RunRecord{}, fmt.Errorf("no earlier run of %s has its output kept", run.Tool)
+	}
+	previous, _ := LoadRunOutput(prev)
+	return UnifiedDiff(runLabel(prev), runLabel(run), previous, output), prev, nil
+}
+
+// runDiff prints how the output of the latest run of a tool, or of the
+// run given with --run, differs from the previous run of the tool
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	runID := fs.String("run", "", "compare this run instead of the latest one of the tool")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: tools-tui diff [--run ID] [tool]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if (*runID == "") == (fs.NArg() == 0) {
+		fs.Usage()
+		return fmt.Errorf("name a tool or a run")
+	}
+	records, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	var run RunRecord
+	found := false
+	for i := len(records) - 1; i >= 0; i-- {
+		if r := records[i]; r.ID == *runID || (*runID == "" && r.Tool == fs.Arg(0)) {
+			run, found = r, true
+			break
+		}
+	}
+	switch {
+	case !found && *runID != "":
+		return fmt.Errorf("no run %s in the history", *runID)
+	case !found:
+		return fmt.Errorf("%s has not been run", fs.Arg(0))
+	}
+	diff, prev, err := CompareWithPrevious(records, run)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Fprintf(os.Stderr, "Same output as the run of %s\n", runLabel(prev))
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxScheduledRuns caps the occurrences listed per workflow
+const maxScheduledRuns = 500
+
+// icsEventDuration is the length given to calendar events
+const icsEventDuration = 15 * time.Minute
+
+// ScheduledRun is an upcoming unattended run of a workflow or a tool.
+// Runs that fall in quiet hours are moved to the end of the window and
+// keep their original time in DeferredFrom.
+type ScheduledRun struct {
+	Workflow     string     `json:"workflow,omitempty"`
+	Tool         string     `json:"tool,omitempty"`
+	Schedule     string     `json:"schedule"`
+	At           time.Time  `json:"at"`
+	DeferredFrom *time.Time `json:"deferred_from,omitempty"`
+}
+
+// Name returns the workflow or tool that runs
+func (r ScheduledRun) Name() string {
+	if r.Tool != "" {
+		return r.Tool
+	}
+	return r.Workflow
+}
+
+// UpcomingRuns lists the runs of all scheduled workflows in (from, to],
+// deferring those that fall in quiet hours
+func UpcomingRuns(workflows []Workflow, from, to time.Time, quiet *QuietHours) ([]ScheduledRun, error) {
+	var runs []ScheduledRun
+	for _, wf := range workflows {
+		if wf.Schedule == "" {
+			continue
+		}
+		spec, err := ParseCron(wf.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("workflow %s: %v", wf.Name, err)
+		}
+		for _, at := range spec.Between(from, to, maxScheduledRuns) {
+			run := ScheduledRun{Workflow: wf.Name, Schedule: wf.Schedule, At: at}
+			if active, _ := quiet.Active(at); active {
+				original := at
+				run.At, run.DeferredFrom = quiet.NextAllowed(at), &original
+			}
+			runs = append(runs, run)
+		}
+	}
+	sortRuns(runs)
+	return runs, nil
+}
+
+// sortRuns orders runs by time
+func sortRuns(runs []ScheduledRun) {
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].At.Before(runs[j].At) })
+}
+
+// stepCommands describes what a workflow will execute
+func stepCommands(wf Workflow) []string {
+	commands := make([]string, len(wf.Steps))
+	for i, step := range wf.Steps {
+		commands[i] = step.Tool
+		if step.Command != "" {
+			commands[i] += ": " + step.Command
+		}
+	}
+	return commands
+}
+
+// icsEscape escapes text values for iCalendar
+func icsEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// scheduledCommands maps each scheduled workflow and tool to what it
+// executes
+func scheduledCommands(workflows []Workflow, tools []*Tool) (forWorkflow, forTool map[string][]string) {
+	forWorkflow, forTool = map[string][]string{}, map[string][]string{}
+	for _, wf := range workflows {
+		forWorkflow[wf.Name] = stepCommands(wf)
+	}
+	for _, tool := range tools {
+		forTool[tool.Key()] = []string{tool.Command}
+	}
+	return forWorkflow, forTool
+}
+
+// ScheduleICS renders the runs as an iCalendar feed
+func ScheduleICS(workflows []Workflow, tools []*Tool, runs []ScheduledRun, now time.Time) string {
+	forWorkflow, forTool := scheduledCommands(workflows, tools)
+	const stamp = "20060102T150405Z"
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//opencode_extensions//tools-tui " + version + "//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:tools-tui schedule",
+	}
+	for _, run := range runs {
+		commands := forWorkflow[run.Workflow]
+		if run.Tool != "" {
+			commands = forTool[run.Tool]
+		}
+		start := run.At.UTC()
+		description := fmt.Sprintf("Schedule: %s\n%s", run.Schedule, strings.Join(commands, "\n"))
+		if run.DeferredFrom != nil {
+			description = fmt.Sprintf("Deferred from %s (quiet hours)\n%s", run.DeferredFrom.UTC().Format(time.RFC3339), description)
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s-%s@tools-tui", icsEscape(strings.ReplaceAll(run.Name(), " ", "-")), start.Format(stamp)),
+			"DTSTAMP:"+now.UTC().Format(stamp),
+			"DTSTART:"+start.Format(stamp),
+			"DTEND:"+start.Add(icsEventDuration).Format(stamp),
+			"SUMMARY:"+icsEscape("tools-tui: "+run.Name()),
+			"DESCRIPTION:"+icsEscape(description),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(icsFold(line))
+	}
+	return b.String()
+}
+
+// icsFold splits a content line into 75-octet chunks as RFC 5545
+// requires, continuing each with a leading space
+func icsFold(line string) string {
+	var b strings.Builder
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
+
+// scheduleExport is the JSON form of the schedule
+type scheduleExport struct {
+	Generated time.Time        `json:"generated"`
+	Until     time.Time        `json:"until"`
+	Workflows []scheduledEntry `json:"workflows"`
+	Tools     []scheduledEntry `json:"tools"`
+}
+
+// scheduledEntry describes one scheduled workflow or tool and its next
+// runs
+type scheduledEntry struct {
+	Name     string      `json:"name"`
+	Schedule string      `json:"schedule"`
+	Steps    []string    `json:"steps"`
+	Runs     []time.Time `json:"runs"`
+}
+
+// ScheduleJSON renders the scheduled workflows and tools and their runs
+// as JSON
+func ScheduleJSON(workflows []Workflow, tools []*Tool, runs []ScheduledRun, now, until time.Time) (string, error) {
+	export := scheduleExport{Generated: now, Until: until, Tools: []scheduledEntry{}}
+	for _, wf := range workflows {
+		if wf.Schedule == "" {
+			continue
+		}
+		entry := scheduledEntry{Name: wf.Name, Schedule: wf.Schedule, Steps: stepCommands(wf), Runs: []time.Time{}}
+		for _, run := range runs {
+			if run.Workflow == wf.Name {
+				entry.Runs = append(entry.Runs, run.At)
+			}
+		}
+		export.Workflows = append(export.Workflows, entry)
+	}
+	for _, tool := range tools {
+		entry := scheduledEntry{Name: tool.Key(), Schedule: tool.Schedule, Steps: []string{tool.Command}, Runs: []time.Time{}}
+		for _, run := range runs {
+			if run.Tool == tool.Key() {
+				entry.Runs = append(entry.Runs, run.At)
+			}
+		}
+		export.Tools = append(export.Tools, entry)
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	return string(data) + "\n", err
+}
+
+// runSchedule implements the schedule subcommand: list, export and the
+// daemon running scheduled tools
+func runSchedule(args []string) error {
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("schedule "+action, flag.ExitOnError)
+	days := fs.Int("days", 7, "how many days ahead to include")
+	format := fs.String("format", "ics", "export format: ics or json")
+	out := fs.String("out", "", "write the export to this file instead of stdout")
+	fs.Parse(args)
+	if action == "daemon" {
+		return runScheduleDaemon()
+	}
+
+	workflows, err := LoadWorkflows()
+	if err != nil {
+		return err
+	}
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	tools := scheduledTools(categories)
+	now := time.Now()
+	until := now.AddDate(0, 0, *days)
+	quiet := loadQuietHours()
+	runs, err := UpcomingRuns(workflows, now, until, quiet)
+	if err != nil {
+		return err
+	}
+	runs = append(runs, UpcomingToolRuns(categories, now, until, quiet)...)
+	sortRuns(runs)
+
+	var output string
+	switch action {
+	case "list":
+		if len(runs) == 0 {
+			fmt.Printf("No scheduled runs in the next %d days. Add a \"schedule\" to a workflow in %s or to a tool in %s.\n", *days, workflowsFile, manifestPath())
+			return nil
+		}
+		for _, run := range runs {
+			kind := "workflow"
+			if run.Tool != "" {
+				kind = "tool"
+			}
+			line := fmt.Sprintf("%s  %-8s %s", run.At.Format("Mon 2006-01-02 15:04"), kind, run.Name())
+			if run.DeferredFrom != nil {
+				line += fmt.Sprintf("  (deferred from %s, quiet hours)", run.DeferredFrom.Format("Mon 15:04"))
+			}
+			fmt.Println(line)
+		}
+		return nil
+	case "export":
+		switch *format {
+		case "ics", "ical":
+			output = ScheduleICS(workflows, tools, runs, now)
+		case "json":
+			if output, err = ScheduleJSON(workflows, tools, runs, now, until); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown format %q (use ics or json)", *format)
+		}
+	default:
+		return fmt.Errorf("unknown schedule action %q (use list, export or daemon)", action)
+	}
+
+	if *out == "" {
+		fmt.Print(output)
+		return nil
+	}
+	if err := WriteFileContent(*out, output); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d scheduled runs to %s\n", len(runs), *out)
+	return nil
+}
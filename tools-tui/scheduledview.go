@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// upcomingWindow is how far ahead the Scheduled screen lists runs
+const upcomingWindow = 24 * time.Hour
+
+// maxUpcomingRuns is how many upcoming runs the Scheduled screen lists
+const maxUpcomingRuns = 10
+
+// scheduledRow is a tool or workflow with a schedule
+type scheduledRow struct {
+	Name     string
+	Tool     *Tool
+	Schedule string
+	Steps    []string
+	Next     ScheduledRun
+	Err      error
+}
+
+// scheduledView holds the state of the Scheduled screen
+type scheduledView struct {
+	rows     []scheduledRow
+	upcoming []ScheduledRun
+	state    map[string]scheduleState
+	cursor   int
+	message  string
+}
+
+// openScheduled shows the tools and workflows with a schedule
+func (m *Model) openScheduled() tea.Cmd {
+	m.scheduled.message = ""
+	m.scheduled.reload(m.categories, m.quietHours)
+	m.screen = screenScheduled
+	return nil
+}
+
+// reload reads the schedules, their next runs and the results of the
+// scheduler
+func (v *scheduledView) reload(categories []Category, quiet *QuietHours) {
+	now := time.Now()
+	v.rows = nil
+	v.upcoming = UpcomingToolRuns(categories, now, now.Add(upcomingWindow), quiet)
+	for _, tool := range scheduledTools(categories) {
+		row := scheduledRow{Name: tool.Key(), Tool: tool, Schedule: tool.Schedule, Steps: []string{tool.Command}}
+		if runs := UpcomingToolRuns([]Category{{Tools: []Tool{*tool}}}, now, now.AddDate(1, 0, 0), quiet); len(runs) > 0 {
+			row.Next = runs[0]
+		}
+		v.rows = append(v.rows, row)
+	}
+	workflows, err := LoadWorkflows()
+	if err != nil {
+		v.message = fmt.Sprintf("%s: %v", workflowsFile, err)
+	}
+	for _, wf := range workflows {
+		if wf.Schedule == "" {
+			continue
+		}
+		row := scheduledRow{Name: wf.Name, Schedule: wf.Schedule, Steps: stepCommands(wf)}
+		runs, err := UpcomingRuns([]Workflow{wf}, now, now.AddDate(1, 0, 0), quiet)
+		switch {
+		case err != nil:
+			row.Err = err
+		case len(runs) > 0:
+			row.Next = runs[0]
+		}
+		if upcoming, err := UpcomingRuns([]Workflow{wf}, now, now.Add(upcomingWindow), quiet); err == nil {
+			v.upcoming = append(v.upcoming, upcoming...)
+		}
+		v.rows = append(v.rows, row)
+	}
+	sortRuns(v.upcoming)
+	if len(v.upcoming) > maxUpcomingRuns {
+		v.upcoming = v.upcoming[:maxUpcomingRuns]
+	}
+	if v.state, err = LoadScheduleState(); err != nil {
+		v.message = fmt.Sprintf("%s: %v", scheduleStateFile, err)
+	}
+	v.cursor = min(v.cursor, max(len(v.rows)-1, 0))
+}
+
+// updateScheduled handles input on the Scheduled screen
+func (m Model) updateScheduled(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	v := &m.scheduled
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.rows)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		v.reload(m.categories, m.quietHours)
+		v.message = "Reloaded"
+	}
+	return m, nil
+}
+
+// nextLabel describes when a scheduled run happens, and where quiet
+// hours move it to
+func nextLabel(run ScheduledRun) string {
+	if run.At.IsZero() {
+		return "never"
+	}
+	label := run.At.Format("Mon Jan 2 15:04")
+	if run.DeferredFrom != nil {
+		label = run.DeferredFrom.Format("Mon Jan 2 15:04") + " 🌙 → " + run.At.Format("Mon 15:04")
+	}
+	return label
+}
+
+// renderScheduled renders the schedules with their next and last runs
+func (m Model) renderScheduled() string {
+	v := m.scheduled
+	var content strings.Builder
+	title := titleStyle.Render("⏰ Scheduled")
+	summary := fmt.Sprintf("%d schedules · the TUI runs the tools on time while it is open, or `tools-tui schedule daemon` does", len(v.rows))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	var lines []string
+	for _, row := range v.rows {
+		kind := "workflow"
+		if row.Tool != nil {
+			kind = "tool"
+		}
+		next := nextLabel(row.Next)
+		if row.Err != nil {
+			next = warningStyle.Render(row.Err.Error())
+		}
+		line := fmt.Sprintf("%-24s %-8s %-16s %s", truncate(row.Name, 24), kind, truncate(row.Schedule, 16), next)
+		if st, ok := v.state[row.Name]; ok && row.Tool != nil {
+			switch {
+			case st.Running:
+				line += " " + commandStyle.Render("⏳ running")
+			case st.Deferred:
+				line += " " + helpStyle.Render("🌙 waiting for quiet hours to end")
+			case st.Started.IsZero():
+			case st.Success:
+				line += " " + featureStyle.Render("✔ "+st.Started.Format("01-02 15:04"))
+			default:
+				line += " " + warningStyle.Render("✘ "+st.Started.Format("01-02 15:04"))
+			}
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("Nothing is scheduled. Add a \"schedule\" to a tool in %s or to a workflow in %s.", manifestPath(), workflowsFile)))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(renderMCPList(fmt.Sprintf("Schedules (%d)", len(lines)), lines, v.cursor, true))
+	}
+
+	if v.cursor < len(v.rows) {
+		row := v.rows[v.cursor]
+		content.WriteString("\n")
+		for _, step := range row.Steps {
+			content.WriteString(commandStyle.Render("$ "+step) + "\n")
+		}
+		if row.Tool != nil {
+			if reason := runApproval(row.Tool, Unattended); reason != "" {
+				content.WriteString(warningStyle.Render("⚠ Its runs fail: "+reason) + "\n")
+			}
+			if st := v.state[row.Name]; st.Error != "" && !st.Running {
+				content.WriteString(warningStyle.Render("Last run: "+st.Error) + "\n")
+			}
+		} else {
+			content.WriteString(helpStyle.Render("Workflows run from cron with `tools-tui workflow "+row.Name+"`") + "\n")
+		}
+	}
+
+	if len(v.upcoming) > 0 {
+		content.WriteString("\n")
+		content.WriteString(featureStyle.Render(fmt.Sprintf("Next %d hours", int(upcomingWindow.Hours()))))
+		content.WriteString("\n")
+		for _, run := range v.upcoming {
+			content.WriteString(fmt.Sprintf("  %-28s %s\n", nextLabel(run), run.Name()))
+		}
+	}
+
+	content.WriteString("\n")
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("reload", k.Refresh), hint("back", k.Back), hint("quit", k.Quit)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scheduleStateFile records what the scheduler did for each tool with a
+// schedule, shared by the TUI and the scheduler daemon
+const scheduleStateFile = "schedule_state.json"
+
+// scheduleLockFile guards the state while a process claims a due run,
+// so a tool due while both the TUI and the daemon run starts once
+const scheduleLockFile = "schedule_state.lock"
+
+// staleScheduleLock is how old a lock is when it was left behind by a
+// process that died
+const staleScheduleLock = 30 * time.Second
+
+// staleScheduledRun is how long a run may be marked running before it
+// is taken for one whose process died
+const staleScheduledRun = 6 * time.Hour
+
+// schedulerInterval is how often the scheduler looks for due runs
+const schedulerInterval = 20 * time.Second
+
+// scheduleState is what the scheduler last did for a tool
+type scheduleState struct {
+	// Due is the latest time of the schedule that was handled; runs
+	// missed while nothing was running are caught up once
+	Due time.Time `json:"due"`
+	// Deferred is set while the run due then waits for quiet hours
+	Deferred bool `json:"deferred,omitempty"`
+	// Running is set from the start of the run until its result
+	Running  bool      `json:"running,omitempty"`
+	Started  time.Time `json:"started,omitempty"`
+	RunID    string    `json:"run_id,omitempty"`
+	Success  bool      `json:"success,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Duration int64     `json:"duration_ms,omitempty"`
+}
+
+// ScheduledResult is the outcome of a run started by the scheduler
+type ScheduledResult struct {
+	Tool string
+	Step StepResult
+}
+
+// Label summarizes the result for a notification
+func (r ScheduledResult) Label() string {
+	if r.Step.Success {
+		return fmt.Sprintf("Scheduled run of %s succeeded", r.Tool)
+	}
+	return fmt.Sprintf("Scheduled run of %s failed: %s", r.Tool, r.Step.Error)
+}
+
+// scheduledTools returns the tools of the catalog that have a schedule,
+// sorted by name
+func scheduledTools(categories []Category) []*Tool {
+	var tools []*Tool
+	for i := range categories {
+		if categories[i].Favorites {
+			continue
+		}
+		for j := range categories[i].Tools {
+			if categories[i].Tools[j].Schedule != "" {
+				tools = append(tools, &categories[i].Tools[j])
+			}
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Key() < tools[j].Key() })
+	return tools
+}
+
+// LoadScheduleState returns what the scheduler last did for each tool
+func LoadScheduleState() (map[string]scheduleState, error) {
+	state := map[string]scheduleState{}
+	err := loadJSON(scheduleStateFile, &state)
+	return state, err
+}
+
+// lockScheduleState takes the lock of the state file shared with other
+// processes, waiting a few seconds for it, and returns its release
+func lockScheduleState() (func(), error) {
+	if err := os.MkdirAll(ConfigDir(), 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(ConfigDir(), scheduleLockFile)
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleScheduleLock {
+			os.Remove(path)
+			continue
+		}
+		if attempt == 50 {
+			return nil, fmt.Errorf("%s is held by another process", path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// updateScheduleState changes the state of the tools under the lock
+func updateScheduleState(change func(map[string]scheduleState)) error {
+	unlock, err := lockScheduleState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := LoadScheduleState()
+	if err != nil {
+		return err
+	}
+	change(state)
+	return saveJSON(scheduleStateFile, state)
+}
+
+// Scheduler runs the tools of the catalog on their schedule in the
+// background and reports each result
+type Scheduler struct {
+	mu         sync.Mutex
+	categories []Category
+	quiet      *QuietHours
+	// runner executes a due tool's step, runStep unless the daemon runs
+	// it as one of its jobs
+	runner   func(categories []Category, step StepResult) StepResult
+	results  chan ScheduledResult
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// scheduledMsg carries the result of a scheduled run to the UI
+type scheduledMsg struct {
+	result ScheduledResult
+}
+
+// NewScheduler returns a scheduler for the catalog; Start runs it
+func NewScheduler(categories []Category, quiet *QuietHours) *Scheduler {
+	return &Scheduler{categories: categories, quiet: quiet, results: make(chan ScheduledResult, 16), stop: make(chan struct{})}
+}
+
+// SetCatalog replaces the tools the scheduler knows about
+func (s *Scheduler) SetCatalog(categories []Category) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories = categories
+}
+
+// SetQuietHours replaces the window during which runs are deferred
+func (s *Scheduler) SetQuietHours(quiet *QuietHours) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quiet = quiet
+}
+
+// Start looks for due runs every schedulerInterval until Stop
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(schedulerInterval)
+		defer ticker.Stop()
+		for {
+			s.check(time.Now())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends the scheduler; runs already started finish
+func (s *Scheduler) Stop() {
+	if s != nil {
+		s.stopOnce.Do(func() { close(s.stop) })
+	}
+}
+
+// waitForScheduler delivers the next result of a scheduled run
+func waitForScheduler(s *Scheduler) tea.Cmd {
+	return func() tea.Msg {
+		return scheduledMsg{result: <-s.results}
+	}
+}
+
+// check starts the runs that are due at now. A tool seen for the first
+// time starts at its next scheduled time rather than catching up.
+func (s *Scheduler) check(now time.Time) {
+	s.mu.Lock()
+	categories, quiet := s.categories, s.quiet
+	s.mu.Unlock()
+	if len(scheduledTools(categories)) == 0 {
+		return
+	}
+	var due []*Tool
+	err := updateScheduleState(func(state map[string]scheduleState) {
+		for _, tool := range scheduledTools(categories) {
+			spec, err := ParseCron(tool.Schedule)
+			if err != nil {
+				continue
+			}
+			st, seen := state[tool.Key()]
+			switch {
+			case !seen:
+				state[tool.Key()] = scheduleState{Due: now}
+				continue
+			case st.Running && now.Sub(st.Started) < staleScheduledRun:
+				continue
+			}
+			if next := spec.Next(st.Due); !st.Deferred && (next.IsZero() || next.After(now)) {
+				continue
+			}
+			for next := spec.Next(st.Due); !next.IsZero() && !next.After(now); next = spec.Next(next) {
+				st.Due = next
+			}
+			if active, _ := quiet.Active(now); active {
+				st.Deferred = true
+				state[tool.Key()] = st
+				continue
+			}
+			st.Deferred, st.Running, st.Started = false, true, now
+			state[tool.Key()] = st
+			due = append(due, tool)
+		}
+	})
+	if err != nil {
+		return
+	}
+	for _, tool := range due {
+		go s.run(categories, tool)
+	}
+}
+
+// run executes a due tool as a workflow step would and records the
+// result
+func (s *Scheduler) run(categories []Category, tool *Tool) {
+	step := StepResult{Tool: tool.Key(), Command: tool.Command}
+	if reason := runApproval(tool, Unattended); reason != "" {
+		step.Success, step.Error = false, reason
+	} else if s.runner != nil {
+		step = s.runner(categories, step)
+	} else {
+		step = runStep(categories, step, false)
+	}
+	updateScheduleState(func(state map[string]scheduleState) {
+		st := state[tool.Key()]
+		st.Running = false
+		st.RunID, st.Success, st.Error, st.Duration = step.RunID, step.Success, step.Error, step.DurationMs
+		state[tool.Key()] = st
+	})
+	result := ScheduledResult{Tool: tool.Key(), Step: step}
+	if !step.Success {
+		notifyDesktop("tools-tui", result.Label())
+	}
+	select {
+	case s.results <- result:
+	case <-s.stop:
+	}
+}
+
+// notifyDesktop shows a desktop notification when the platform has a
+// way to, and does nothing otherwise
+func notifyDesktop(title, text string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", text, title))
+	default:
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return
+		}
+		cmd = exec.Command(path, title, text)
+	}
+	cmd.Run()
+}
+
+// UpcomingToolRuns lists the runs of the tools with a schedule in
+// (from, to], deferring those that fall in quiet hours
+func UpcomingToolRuns(categories []Category, from, to time.Time, quiet *QuietHours) []ScheduledRun {
+	var runs []ScheduledRun
+	for _, tool := range scheduledTools(categories) {
+		spec, err := ParseCron(tool.Schedule)
+		if err != nil {
+			continue
+		}
+		for _, at := range spec.Between(from, to, maxScheduledRuns) {
+			run := ScheduledRun{Tool: tool.Key(), Schedule: tool.Schedule, At: at}
+			if active, _ := quiet.Active(at); active {
+				original := at
+				run.At, run.DeferredFrom = quiet.NextAllowed(at), &original
+			}
+			runs = append(runs, run)
+		}
+	}
+	sortRuns(runs)
+	return runs
+}
+
+// runScheduleDaemon runs the scheduled tools without the TUI until
+// interrupted, printing each result
+func runScheduleDaemon() error {
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	tools := scheduledTools(categories)
+	if len(tools) == 0 {
+		return fmt.Errorf("no tool has a \"schedule\" in %s", manifestPath())
+	}
+	s := NewScheduler(categories, loadQuietHours())
+	s.Start()
+	defer s.Stop()
+	fmt.Printf("Scheduling %d tools, press ctrl+c to stop\n", len(tools))
+	for _, tool := range tools {
+		fmt.Printf("  %-24s %s\n", tool.Key(), tool.Schedule)
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	for {
+		select {
+		case result := <-s.results:
+			fmt.Printf("%s  %s\n", time.Now().Format("2006-01-02 15:04:05"), result.Label())
+		case <-interrupt:
+			return nil
+		}
+	}
+}
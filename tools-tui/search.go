@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSearchResults limits how many matches are listed
+const maxSearchResults = 20
+
+// maxMatchSpread rejects fuzzy matches whose runes are scattered over
+// more than this many times the pattern length
+const maxMatchSpread = 3
+
+// searchResult is a tool matching the search query
+type searchResult struct {
+	cat, tool int
+	score     int
+	field     string
+	text      string
+	positions []int
+}
+
+// searchFields returns the searchable texts of a tool by field name, in
+// order of importance
+func searchFields(tool Tool) [][2]string {
+	fields := [][2]string{
+		{"name", tool.Name},
+		{"purpose", tool.Purpose},
+		{"description", tool.Description},
+	}
+	for _, feature := range tool.Features {
+		fields = append(fields, [2]string{"feature", feature})
+	}
+	if tool.SharedAnnotation != "" {
+		fields = append(fields, [2]string{"team note", tool.SharedAnnotation})
+	}
+	if tool.Annotation != "" {
+		fields = append(fields, [2]string{"note", tool.Annotation})
+	}
+	return fields
+}
+
+// fuzzyMatch reports whether every rune of pattern appears in text in
+// order, ignoring case. The score rewards consecutive runs and matches
+// at word starts; positions are the matched rune indexes of text.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+	if len(p) == 0 {
+		return 0, nil, false
+	}
+	positions := make([]int, 0, len(p))
+	score, pi, last := 0, 0, -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if unicode.ToLower(t[ti]) != p[pi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 2
+		}
+		positions = append(positions, ti)
+		last = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, nil, false
+	}
+	span := positions[len(positions)-1] - positions[0] + 1
+	if span > len(p)*maxMatchSpread {
+		return 0, nil, false
+	}
+	// prefer compact matches in short texts
+	score -= (span - len(p)) / 2
+	score -= len(t) / 40
+	return score, positions, true
+}
+
+// searchResults returns the catalog's tools matching the query
+func (m Model) searchResults(query string) []searchResult {
+	return searchCatalog(m.categories, query)
+}
+
+// searchCatalog returns the tools matching the query, best first. Each
+// tool is listed once, under its best matching field; name matches get
+// a bonus so they rank above mentions elsewhere.
+func searchCatalog(categories []Category, query string) []searchResult {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	var results []searchResult
+	for ci, category := range categories {
+		if category.Favorites {
+			continue
+		}
+		for ti, tool := range category.Tools {
+			var best *searchResult
+			for fi, field := range searchFields(tool) {
+				score, positions, ok := fuzzyMatch(query, field[1])
+				if !ok {
+					continue
+				}
+				if fi == 0 {
+					score += 10
+				}
+				if best == nil || score > best.score {
+					best = &searchResult{cat: ci, tool: ti, score: score, field: field[0], text: field[1], positions: positions}
+				}
+			}
+			if best != nil {
+				results = append(results, *best)
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].score > results[j].score })
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+	return results
+}
+
+// highlightMatches renders text with the runes at positions emphasised.
+// Long texts are cut to a window around the first match.
+func highlightMatches(text string, positions []int, width int) string {
+	runes := []rune(text)
+	start, end := 0, len(runes)
+	if width > 0 && len(runes) > width && len(positions) > 0 {
+		start = positions[0] - width/4
+		if start < 0 {
+			start = 0
+		}
+		end = start + width
+		if end > len(runes) {
+			end, start = len(runes), len(runes)-width
+		}
+	}
+	matched := map[int]bool{}
+	for _, p := range positions {
+		matched[p] = true
+	}
+	style := lipgloss.NewStyle().Foreground(selectedItemStyle.GetForeground()).Bold(true).Underline(true)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if matched[i] {
+			b.WriteString(style.Render(string(runes[i])))
+		} else {
+			b.WriteRune(runes[i])
+		}
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// updateSearch handles keys while the search input is focused: typing
+// filters live, up/down pick a result and enter jumps to it
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeSearch()
+		return m, nil
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyUp:
+		if m.searchCursor > 0 {
+			m.searchCursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if m.searchCursor < len(m.searchResults(m.searchInput.Value()))-1 {
+			m.searchCursor++
+		}
+		return m, nil
+	case tea.KeyEnter:
+		results := m.searchResults(m.searchInput.Value())
+		if m.searchCursor < len(results) {
+			m.jumpToTool(results[m.searchCursor].cat, results[m.searchCursor].tool)
+		}
+		m.closeSearch()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	m.searchCursor = 0
+	return m, cmd
+}
+
+// closeSearch leaves search mode and clears the query
+func (m *Model) closeSearch() {
+	m.searchMode = false
+	m.searchCursor = 0
+	m.searchInput.Blur()
+	m.searchInput.SetValue("")
+}
+
+// jumpToTool selects a tool in the list, expanding its category and
+// revealing it if it was hidden as inapplicable
+func (m *Model) jumpToTool(cat, tool int) {
+	m.currentCat = cat
+	m.currentTool = tool
+	m.categories[cat].Active = true
+	if tool >= m.visibleTools(m.categories[cat]) {
+		m.showInapplicable = true
+	}
+}
+
+// renderSearch renders the live search results
+func (m Model) renderSearch() string {
+	var content strings.Builder
+	query := m.searchInput.Value()
+	results := m.searchResults(query)
+
+	content.WriteString(commandStyle.Render(fmt.Sprintf("🔍 %s", m.searchInput.View())))
+	content.WriteString("\n\n")
+	switch {
+	case strings.TrimSpace(query) == "":
+		content.WriteString(helpStyle.Render("Type to search tool names, purposes, descriptions, features and notes"))
+		content.WriteString("\n")
+	case len(results) == 0:
+		content.WriteString(descriptionStyle.Render("No matching tools"))
+		content.WriteString("\n")
+	}
+
+	for i, result := range results {
+		tool := m.categories[result.cat].Tools[result.tool]
+		name := tool.Name
+		if result.field == "name" {
+			name = highlightMatches(tool.Name, result.positions, 0)
+		}
+		line := fmt.Sprintf("%s %s", name, helpStyle.Render("["+m.categories[result.cat].Name+"]"))
+		if result.field != "name" {
+			line += " " + descriptionStyle.Render(result.field+": ") + highlightMatches(result.text, result.positions, 60)
+		}
+		if i == m.searchCursor {
+			content.WriteString(selectedItemStyle.Render("▶ ") + line)
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// secretsIndexFile lists the stored secrets, without their values
+	secretsIndexFile = "secrets.json"
+	// secretsVaultFile holds the values the keyring could not take,
+	// encrypted with a key derived from the vault passphrase
+	secretsVaultFile = "secrets.vault"
+	// secretsKeyFile is the key that sealed vault files before they
+	// took a passphrase; it is removed once the vault is saved again
+	secretsKeyFile = "secrets.key"
+	// vaultPassphraseEnv holds the passphrase of the vault file; the
+	// secrets subcommand asks for it on a terminal when it is unset
+	vaultPassphraseEnv = "OPENCODE_TUI_VAULT_PASSPHRASE"
+	// vaultMagic starts a vault file, followed by the salt of its key
+	vaultMagic = "OCVAULT1"
+	// vaultIterations is the PBKDF2 work factor of the vault key
+	vaultIterations = 600000
+	// vaultSaltSize is the length of the salt of the vault key
+	vaultSaltSize = 16
+	// secretsKeyringService is the keyring service of the values; the
+	// account is the secret's service name
+	secretsKeyringService = "tools-tui-secrets"
+	// secretRotationAge is when a secret is reported as due for rotation
+	secretRotationAge = 90 * 24 * time.Hour
+)
+
+// SecretBackend is where the value of a secret is kept
+type SecretBackend string
+
+const (
+	backendKeyring SecretBackend = "keyring"
+	backendFile    SecretBackend = "file"
+)
+
+// SecretInfo describes a stored secret; the value itself is in the
+// keyring or the vault file
+type SecretInfo struct {
+	Service   string        `json:"service"`
+	Type      string        `json:"type,omitempty"`
+	Backend   SecretBackend `json:"backend"`
+	Created   time.Time     `json:"created"`
+	Updated   time.Time     `json:"updated"`
+	Rotations int           `json:"rotations,omitempty"`
+	// PreviousSHA256 identifies the value replaced by the last rotation
+	PreviousSHA256 string `json:"previous_sha256,omitempty"`
+}
+
+// due reports whether the secret was last set longer ago than the
+// rotation age
+func (s SecretInfo) due(now time.Time) bool {
+	return now.Sub(s.Updated) > secretRotationAge
+}
+
+// loadSecretIndex reads the stored secrets by service
+func loadSecretIndex() map[string]SecretInfo {
+	index := map[string]SecretInfo{}
+	loadJSON(secretsIndexFile, &index)
+	return index
+}
+
+// ListSecrets returns the stored secrets sorted by service
+func ListSecrets() []SecretInfo {
+	var secrets []SecretInfo
+	for _, info := range loadSecretIndex() {
+		secrets = append(secrets, info)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Service < secrets[j].Service })
+	return secrets
+}
+
+// GetSecret returns the value stored for service
+func GetSecret(service string) (string, error) {
+	info, ok := loadSecretIndex()[service]
+	if !ok {
+		return "", fmt.Errorf("no secret stored for %s", service)
+	}
+	if info.Backend == backendKeyring {
+		return keyringLookup(secretsKeyringService, service)
+	}
+	vault, err := loadVault()
+	if err != nil {
+		return "", err
+	}
+	value, ok := vault[service]
+	if !ok {
+		return "", fmt.Errorf("the secret of %s is missing from %s", service, secretsVaultFile)
+	}
+	return value, nil
+}
+
+// SetSecret stores the value of service, in the keyring when there is
+// one and in the vault file otherwise
+func SetSecret(service, typ, value string) error {
+	if err := checkSecret(service, value); err != nil {
+		return err
+	}
+	index := loadSecretIndex()
+	info, ok := index[service]
+	now := time.Now()
+	if !ok {
+		info = SecretInfo{Service: service, Created: now}
+	}
+	if typ != "" {
+		info.Type = typ
+	}
+	info.Updated = now
+	backend, err := storeSecretValue(service, value)
+	if err != nil {
+		return err
+	}
+	info.Backend = backend
+	index[service] = info
+	return saveJSON(secretsIndexFile, index)
+}
+
+// RotateSecret replaces the value of a stored secret, remembering the
+// hash of the old one
+func RotateSecret(service, value string) error {
+	if err := checkSecret(service, value); err != nil {
+		return err
+	}
+	index := loadSecretIndex()
+	info, ok := index[service]
+	if !ok {
+		return fmt.Errorf("no secret stored for %s; set it first", service)
+	}
+	if old, err := GetSecret(service); err == nil {
+		if old == value {
+			return fmt.Errorf("the new value of %s is the current one", service)
+		}
+		sum := sha256.Sum256([]byte(old))
+		info.PreviousSHA256 = hex.EncodeToString(sum[:])
+	}
+	backend, err := storeSecretValue(service, value)
+	if err != nil {
+		return err
+	}
+	info.Backend = backend
+	info.Updated = time.Now()
+	info.Rotations++
+	index[service] = info
+	return saveJSON(secretsIndexFile, index)
+}
+
+// DeleteSecret removes a stored secret from its backend and the index
+func DeleteSecret(service string) error {
+	index := loadSecretIndex()
+	if _, ok := index[service]; !ok {
+		return fmt.Errorf("no secret stored for %s", service)
+	}
+	keyringDelete(secretsKeyringService, service)
+	if err := removeFromVault(service); err != nil {
+		return err
+	}
+	delete(index, service)
+	return saveJSON(secretsIndexFile, index)
+}
+
+// checkSecret rejects empty values and service names that cannot be
+// keyring accounts
+func checkSecret(service, value string) error {
+	switch {
+	case strings.TrimSpace(service) == "" || strings.ContainsAny(service, " \t\n/"):
+		return fmt.Errorf("invalid service name %q", service)
+	case value == "":
+		return fmt.Errorf("the secret of %s is empty", service)
+	}
+	return nil
+}
+
+// storeSecretValue writes value to the keyring, falling back to the
+// vault file, and removes it from the other backend
+func storeSecretValue(service, value string) (SecretBackend, error) {
+	if err := keyringStore(secretsKeyringService, service, value); err == nil {
+		return backendKeyring, removeFromVault(service)
+	}
+	vault, err := loadVault()
+	if err != nil {
+		return "", err
+	}
+	vault[service] = value
+	return backendFile, saveVault(vault)
+}
+
+// removeFromVault drops service from the vault file, if it is there
+func removeFromVault(service string) error {
+	vault, err := loadVault()
+	if err != nil {
+		return err
+	}
+	if _, ok := vault[service]; !ok {
+		return nil
+	}
+	delete(vault, service)
+	return saveVault(vault)
+}
+
+// promptVaultPassphrase asks for the vault passphrase when it is not
+// in the environment; the secrets subcommand sets it on a terminal
+var promptVaultPassphrase func(confirm bool) (string, error)
+
+var (
+	vaultMu sync.Mutex
+	// vaultPassphrase is the passphrase once it was asked for
+	vaultPassphrase string
+	// vaultKeys caches the keys derived for each salt
+	vaultKeys = map[string][]byte{}
+)
+
+// vaultKey derives the key of a vault file with the given salt from the
+// passphrase. confirm asks for a new passphrase twice.
+func vaultKey(salt []byte, confirm bool) ([]byte, error) {
+	vaultMu.Lock()
+	defer vaultMu.Unlock()
+	if key, ok := vaultKeys[string(salt)]; ok {
+		return key, nil
+	}
+	passphrase := os.Getenv(vaultPassphraseEnv)
+	if passphrase == "" {
+		passphrase = vaultPassphrase
+	}
+	if passphrase == "" && promptVaultPassphrase != nil {
+		var err error
+		if passphrase, err = promptVaultPassphrase(confirm); err != nil {
+			return nil, err
+		}
+		vaultPassphrase = passphrase
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("without a keyring secrets are kept in %s, encrypted with a passphrase: set %s", secretsVaultFile, vaultPassphraseEnv)
+	}
+	key := pbkdf2SHA256([]byte(passphrase), salt, vaultIterations, 32)
+	vaultKeys[string(salt)] = key
+	return key, nil
+}
+
+// pbkdf2SHA256 derives a key of keyLen bytes from password and salt
+// with PBKDF2-HMAC-SHA256 (RFC 8018)
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// legacyVaultKey reads the key that sealed vault files written before
+// they took a passphrase
+func legacyVaultKey() ([]byte, error) {
+	path := filepath.Join(ConfigDir(), secretsKeyFile)
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s is not a 256-bit key", path)
+	}
+	return key, nil
+}
+
+// loadVault unseals the values kept in the vault file
+func loadVault() (map[string]string, error) {
+	vault := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(ConfigDir(), secretsVaultFile))
+	if os.IsNotExist(err) {
+		return vault, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var key []byte
+	if rest, ok := bytes.CutPrefix(data, []byte(vaultMagic)); ok && len(rest) >= vaultSaltSize {
+		key, err = vaultKey(rest[:vaultSaltSize], false)
+		data = rest[vaultSaltSize:]
+	} else {
+		key, err = legacyVaultKey()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot unlock %s: %w", secretsVaultFile, err)
+	}
+	gcm, err := newVaultCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", secretsVaultFile)
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s, is the passphrase right? %w", secretsVaultFile, err)
+	}
+	return vault, json.Unmarshal(plain, &vault)
+}
+
+// saveVault seals the values into the vault file under a fresh salt,
+// asking for a new passphrase when the vault had none yet, and drops
+// the key of an older vault
+func saveVault(vault map[string]string) error {
+	dir := ConfigDir()
+	salt := make([]byte, vaultSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	previous, _ := os.ReadFile(filepath.Join(dir, secretsVaultFile))
+	key, err := vaultKey(salt, !bytes.HasPrefix(previous, []byte(vaultMagic)))
+	if err != nil {
+		return err
+	}
+	gcm, err := newVaultCipher(key)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(vault)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	sealed := append(append([]byte(vaultMagic), salt...), gcm.Seal(nonce, nonce, plain, nil)...)
+	if err := os.WriteFile(filepath.Join(dir, secretsVaultFile), sealed, 0600); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, secretsKeyFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// newVaultCipher returns the AES-GCM cipher of the vault file
+func newVaultCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secretEnv returns the tool's secrets as sorted NAME=value entries,
+// leaving out those not stored
+func (t *Tool) secretEnv() []string {
+	names := make([]string, 0, len(t.Secrets))
+	for name := range t.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var env []string
+	for _, name := range names {
+		if value, err := GetSecret(t.Secrets[name]); err == nil {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// fossTokensPath is where configs/foss_token_manager.py keeps its
+// tokens, encrypted with the Fernet key next to it
+func fossTokensPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "foss_tokens.json")
+}
+
+// importFOSSTokens moves the tokens of foss_token_manager.py into the
+// store. Services already stored are kept and reported as skipped.
+func importFOSSTokens(path string) (imported, skipped []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := os.ReadFile(filepath.Join(filepath.Dir(path), ".key"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read the Fernet key: %w", err)
+	}
+	plain, err := fernetDecrypt(strings.TrimSpace(string(key)), strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var tokens map[string]struct {
+		Token string `json:"token"`
+		Type  string `json:"type"`
+	}
+	if err := json.Unmarshal(plain, &tokens); err != nil {
+		return nil, nil, fmt.Errorf("unexpected content in %s: %w", path, err)
+	}
+	index := loadSecretIndex()
+	services := make([]string, 0, len(tokens))
+	for service := range tokens {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		if _, ok := index[service]; ok {
+			skipped = append(skipped, service)
+			continue
+		}
+		if err := SetSecret(service, tokens[service].Type, tokens[service].Token); err != nil {
+			return imported, skipped, fmt.Errorf("%s: %w", service, err)
+		}
+		imported = append(imported, service)
+	}
+	return imported, skipped, nil
+}
+
+// fernetDecrypt opens a Fernet token (as written by Python's
+// cryptography package): AES-128-CBC authenticated with HMAC-SHA256
+func fernetDecrypt(key, token string) ([]byte, error) {
+	rawKey, err := base64.URLEncoding.DecodeString(key)
+	if err != nil || len(rawKey) != 32 {
+		return nil, errors.New("invalid Fernet key")
+	}
+	raw, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid Fernet token")
+	}
+	const header = 1 + 8 + aes.BlockSize
+	if len(raw) < header+aes.BlockSize+sha256.Size || raw[0] != 0x80 {
+		return nil, errors.New("invalid Fernet token")
+	}
+	body, sum := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+	mac := hmac.New(sha256.New, rawKey[:16])
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return nil, errors.New("the Fernet token does not match its key")
+	}
+	// the timestamp at body[1:9] is not checked: the tokens are imported
+	// once, not replayed
+	iv, ciphertext := body[9:header], body[header:]
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid Fernet token")
+	}
+	block, err := aes.NewCipher(rawKey[16:])
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return nil, errors.New("invalid Fernet padding")
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// readSecretValue takes the value of a secret from the arguments, or
+// reads one line from stdin so it stays out of the shell history
+func readSecretValue(args []string, service string) (string, error) {
+	if len(args) > 0 && args[0] != "-" {
+		return args[0], nil
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "Secret for %s: ", service)
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no secret given for %s", service)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readVaultPassphrase asks for the vault passphrase on the terminal,
+// twice when it is a new one
+func readVaultPassphrase(confirm bool) (string, error) {
+	fmt.Fprintf(os.Stderr, "Passphrase of %s: ", secretsVaultFile)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat it: ")
+		again, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return "", errors.New("the passphrases differ")
+		}
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("the passphrase is empty")
+	}
+	return string(passphrase), nil
+}
+
+// runSecrets implements `tools-tui secrets`: list, get, set, rotate and
+// delete the stored secrets, and import those of foss_token_manager.py
+func runSecrets(args []string) error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		promptVaultPassphrase = readVaultPassphrase
+	}
+	usage := "usage: tools-tui secrets list | get <service> | set <service> [value|-] [type] | rotate <service> [value|-] | delete <service> | import [path]"
+	if len(args) == 0 || args[0] == "list" {
+		secrets := ListSecrets()
+		if len(secrets) == 0 {
+			fmt.Println("No secrets stored. Add one with: tools-tui secrets set <service>")
+		}
+		now := time.Now()
+		for _, info := range secrets {
+			line := fmt.Sprintf("%-20s %-10s %-8s updated %s", info.Service, info.Type, info.Backend, info.Updated.Format("2006-01-02"))
+			if info.due(now) {
+				line += "  due for rotation"
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+	action := args[0]
+	if action == "import" {
+		path := fossTokensPath()
+		if len(args) > 1 {
+			path = args[1]
+		}
+		imported, skipped, err := importFOSSTokens(path)
+		if len(imported) > 0 {
+			fmt.Printf("Imported %s\n", strings.Join(imported, ", "))
+		}
+		if len(skipped) > 0 {
+			fmt.Printf("Already stored, skipped %s\n", strings.Join(skipped, ", "))
+		}
+		return err
+	}
+	if len(args) < 2 {
+		return errors.New(usage)
+	}
+	service := args[1]
+	switch action {
+	case "get":
+		value, err := GetSecret(service)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+	case "set", "rotate":
+		value, err := readSecretValue(args[2:], service)
+		if err != nil {
+			return err
+		}
+		if action == "rotate" {
+			err = RotateSecret(service, value)
+		} else {
+			var typ string
+			if len(args) > 3 {
+				typ = args[3]
+			}
+			err = SetSecret(service, typ, value)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Stored the secret of %s (%s)\n", service, loadSecretIndex()[service].Backend)
+	case "delete":
+		if err := DeleteSecret(service); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted the secret of %s\n", service)
+	default:
+		return errors.New(usage)
+	}
+	return nil
+}
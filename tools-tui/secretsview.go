@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// secretEntry is a row of the Secrets screen: a stored secret, or a
+// service tools need that is not stored
+type secretEntry struct {
+	Service string
+	Info    *SecretInfo
+	// UsedBy lists the tools the secret is injected into, as
+	// "Tool (VARIABLE)"
+	UsedBy []string
+}
+
+// secretsView holds the state of the Secrets screen
+type secretsView struct {
+	entries []secretEntry
+	cursor  int
+	// form sets a secret, or rotates the selected one
+	form     bool
+	rotating bool
+	inputs   []textinput.Model
+	focus    int
+	// confirmDelete asks before the selected secret is deleted
+	confirmDelete bool
+	busy          string
+	message       string
+}
+
+// secretsMsg reports the outcome of changing the store
+type secretsMsg struct {
+	done string
+	err  error
+}
+
+// secretEntries lists the stored secrets and the services the tools of
+// the catalog declare, with the tools using each
+func secretEntries(categories []Category) []secretEntry {
+	byService := map[string]*secretEntry{}
+	for _, info := range ListSecrets() {
+		info := info
+		byService[info.Service] = &secretEntry{Service: info.Service, Info: &info}
+	}
+	for _, category := range categories {
+		for _, tool := range category.Tools {
+			for name, service := range tool.Secrets {
+				entry, ok := byService[service]
+				if !ok {
+					entry = &secretEntry{Service: service}
+					byService[service] = entry
+				}
+				entry.UsedBy = append(entry.UsedBy, fmt.Sprintf("%s (%s)", tool.Name, name))
+			}
+		}
+	}
+	entries := make([]secretEntry, 0, len(byService))
+	for _, entry := range byService {
+		sort.Strings(entry.UsedBy)
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Service < entries[j].Service })
+	return entries
+}
+
+// openSecrets shows the secrets store
+func (m *Model) openSecrets() tea.Cmd {
+	m.secrets = secretsView{}
+	m.secrets.reload(m.categories)
+	m.screen = screenSecrets
+	return nil
+}
+
+// reload reads the store again, keeping the cursor in range
+func (v *secretsView) reload(categories []Category) {
+	v.entries = secretEntries(categories)
+	v.cursor = min(v.cursor, max(len(v.entries)-1, 0))
+}
+
+// selected returns the entry under the cursor
+func (v secretsView) selected() *secretEntry {
+	if v.cursor < len(v.entries) {
+		return &v.entries[v.cursor]
+	}
+	return nil
+}
+
+// openForm asks for a secret: its service, type and value, or only the
+// new value when rotating
+func (v *secretsView) openForm(rotating bool) tea.Cmd {
+	v.form, v.rotating, v.focus, v.message = true, rotating, 0, ""
+	fields := []string{"service", "type (api_key, token…)", "value"}
+	if rotating {
+		fields = []string{"new value"}
+	}
+	v.inputs = nil
+	for _, field := range fields {
+		input := newTextInput()
+		input.Prompt = ""
+		input.Placeholder = field
+		input.CharLimit = 4096
+		input.Width = 50
+		v.inputs = append(v.inputs, input)
+	}
+	value := &v.inputs[len(v.inputs)-1]
+	value.EchoMode = textinput.EchoPassword
+	value.EchoCharacter = '•'
+	if e := v.selected(); e != nil && !rotating && e.Info == nil {
+		// a service tools need: fill in its name
+		v.inputs[0].SetValue(e.Service)
+		v.focus = len(v.inputs) - 1
+	}
+	return v.inputs[v.focus].Focus()
+}
+
+// changeSecretCmd runs a change of the store in the background, as the
+// keyring may ask to be unlocked
+func changeSecretCmd(change func() error, done string) tea.Cmd {
+	return func() tea.Msg {
+		return secretsMsg{done: done, err: change()}
+	}
+}
+
+// updateSecrets handles input on the Secrets screen
+func (m Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.secrets
+	if msg, ok := msg.(secretsMsg); ok {
+		v.busy = ""
+		v.message = msg.done
+		if msg.err != nil {
+			v.message = msg.err.Error()
+		}
+		v.reload(m.categories)
+		m.secretIndex = loadSecretIndex()
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if v.form {
+		return m.updateSecretForm(keyMsg)
+	}
+	if v.confirmDelete {
+		v.confirmDelete = false
+		e := v.selected()
+		if e == nil || !key.Matches(keyMsg, m.keys.Confirm) {
+			v.message = "Kept the secret"
+			return m, nil
+		}
+		service := e.Service
+		v.busy = "Deleting " + service + "…"
+		return m, changeSecretCmd(func() error { return DeleteSecret(service) }, "Deleted the secret of "+service)
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.entries)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.StoreToken), key.Matches(keyMsg, m.keys.Enter):
+		return m, v.openForm(false)
+	case key.Matches(keyMsg, m.keys.Rotate):
+		if e := v.selected(); e == nil || e.Info == nil {
+			v.message = "Only stored secrets can be rotated"
+			return m, nil
+		}
+		return m, v.openForm(true)
+	case key.Matches(keyMsg, m.keys.Delete):
+		if e := v.selected(); e == nil || e.Info == nil {
+			v.message = "Nothing stored to delete"
+			return m, nil
+		}
+		v.confirmDelete = true
+	case key.Matches(keyMsg, m.keys.Import):
+		path := fossTokensPath()
+		v.busy = "Importing " + path + "…"
+		return m, func() tea.Msg {
+			imported, skipped, err := importFOSSTokens(path)
+			done := fmt.Sprintf("Imported %d tokens from foss_token_manager.py", len(imported))
+			if len(skipped) > 0 {
+				done += fmt.Sprintf(", kept the stored %s", strings.Join(skipped, ", "))
+			}
+			return secretsMsg{done: done, err: err}
+		}
+	}
+	return m, nil
+}
+
+// updateSecretForm handles keys while a secret is entered: tab moves
+// between fields, enter stores it
+func (m Model) updateSecretForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.secrets
+	switch msg.Type {
+	case tea.KeyEsc:
+		v.form = false
+		v.message = ""
+		return m, nil
+	case tea.KeyTab, tea.KeyShiftTab:
+		v.inputs[v.focus].Blur()
+		delta := 1
+		if msg.Type == tea.KeyShiftTab {
+			delta = -1
+		}
+		v.focus = (v.focus + delta + len(v.inputs)) % len(v.inputs)
+		return m, v.inputs[v.focus].Focus()
+	case tea.KeyEnter:
+		value := v.inputs[len(v.inputs)-1].Value()
+		if v.rotating {
+			service := v.selected().Service
+			if err := checkSecret(service, value); err != nil {
+				v.message = err.Error()
+				return m, nil
+			}
+			v.form = false
+			v.busy = "Rotating " + service + "…"
+			return m, changeSecretCmd(func() error { return RotateSecret(service, value) }, "Rotated the secret of "+service)
+		}
+		service, typ := strings.TrimSpace(v.inputs[0].Value()), strings.TrimSpace(v.inputs[1].Value())
+		if err := checkSecret(service, value); err != nil {
+			v.message = err.Error()
+			return m, nil
+		}
+		v.form = false
+		v.busy = "Storing " + service + "…"
+		return m, changeSecretCmd(func() error { return SetSecret(service, typ, value) }, "Stored the secret of "+service)
+	}
+	var cmd tea.Cmd
+	v.inputs[v.focus], cmd = v.inputs[v.focus].Update(msg)
+	return m, cmd
+}
+
+// renderSecrets renders the stored secrets, the services tools need and
+// the form or details of the selected one
+func (m Model) renderSecrets() string {
+	v := m.secrets
+	var content strings.Builder
+	stored := 0
+	for _, e := range v.entries {
+		if e.Info != nil {
+			stored++
+		}
+	}
+	title := titleStyle.Render("🔑 Secrets")
+	summary := fmt.Sprintf("%d stored in the keyring or the passphrase-encrypted %s, injected into the tools that declare them", stored, secretsVaultFile)
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	now := time.Now()
+	var lines []string
+	for _, e := range v.entries {
+		if e.Info == nil {
+			lines = append(lines, fmt.Sprintf("%-20s %s", e.Service, warningStyle.Render("not stored")))
+			continue
+		}
+		line := fmt.Sprintf("%-20s %-10s %-8s updated %s", e.Service, e.Info.Type, e.Info.Backend, e.Info.Updated.Format("2006-01-02"))
+		if e.Info.due(now) {
+			line += " " + warningStyle.Render("due for rotation")
+		}
+		lines = append(lines, line)
+	}
+	content.WriteString(renderMCPList(fmt.Sprintf("Services (%d)", len(v.entries)), lines, v.cursor, !v.form))
+	content.WriteString("\n")
+
+	k := m.keys
+	switch e := v.selected(); {
+	case v.form:
+		heading := "New secret:"
+		if v.rotating {
+			heading = "New value of " + e.Service + ":"
+		}
+		content.WriteString(descriptionStyle.Bold(true).Render(heading))
+		content.WriteString("\n")
+		for i, input := range v.inputs {
+			if i == v.focus {
+				content.WriteString(selectedItemStyle.Render("▶ ") + input.View())
+			} else {
+				content.WriteString("  " + input.View())
+			}
+			content.WriteString("\n")
+		}
+	case e != nil:
+		if e.Info != nil {
+			content.WriteString(fmt.Sprintf("Created %s · updated %s · rotated %d times\n",
+				e.Info.Created.Format("2006-01-02 15:04"), e.Info.Updated.Format("2006-01-02 15:04"), e.Info.Rotations))
+			if e.Info.PreviousSHA256 != "" {
+				content.WriteString(helpStyle.Render("Previous value sha256 " + e.Info.PreviousSHA256[:12] + "…"))
+				content.WriteString("\n")
+			}
+		}
+		if len(e.UsedBy) > 0 && e.Info == nil {
+			content.WriteString(fmt.Sprintf("Needed by %s: %s stores it", strings.Join(e.UsedBy, ", "), primaryKey(k.StoreToken)))
+		} else if len(e.UsedBy) > 0 {
+			content.WriteString("Injected into " + strings.Join(e.UsedBy, ", "))
+		} else {
+			content.WriteString(helpStyle.Render(`No tool declares it: add "secrets": {"VARIABLE": "` + e.Service + `"} to an inventory entry`))
+		}
+		content.WriteString("\n")
+	}
+	if v.confirmDelete {
+		content.WriteString(warningStyle.Render(fmt.Sprintf("Delete the secret of %s? %s to confirm", v.selected().Service, primaryKey(k.Confirm))))
+		content.WriteString("\n")
+	}
+	if v.busy != "" {
+		content.WriteString(statusStyle.Render(v.busy))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	hints := []string{hint("select", k.Up, k.Down), hint("set", k.StoreToken), hint("rotate", k.Rotate), hint("delete", k.Delete), hint("import foss_token", k.Import), hint("back", k.Back)}
+	if v.form {
+		hints = []string{"enter: store", "tab: next field", "esc: cancel"}
+	}
+	content.WriteString(footerStyle.Render(strings.Join(hints, " | ")))
+	return content.String()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// selfCheck is one step of the selftest subcommand. It returns a short
+// detail shown next to the check name.
+type selfCheck struct {
+	name string
+	run  func(tmp string) (string, error)
+}
+
+// selfChecks exercises every subsystem the TUI depends on
+var selfChecks = []selfCheck{
+	{"config", checkConfig},
+	{"inventory", checkInventory},
+	{"command", checkCommand},
+	{"sandbox", checkSandbox},
+	{"sqlite", checkSQLite},
+	{"http", checkHTTP},
+	{"render", checkRender},
+}
+
+// runSelftest implements the selftest subcommand
+func runSelftest(args []string) error {
+	tmp, err := os.MkdirTemp("", "tools-tui-selftest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	failed := 0
+	for _, check := range selfChecks {
+		detail, err := check.run(tmp)
+		if err != nil {
+			failed++
+			fmt.Printf("✘ %-10s %v\n", check.name, err)
+			continue
+		}
+		fmt.Printf("✔ %-10s %s\n", check.name, detail)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(selfChecks))
+	}
+	fmt.Println("All checks passed")
+	return nil
+}
+
+// checkConfig parses config.json and the access configuration
+func checkConfig(string) (string, error) {
+	if _, err := LoadConfig(); err != nil {
+		return "", err
+	}
+	if _, err := LoadAccessConfig(); err != nil {
+		return "", err
+	}
+	return ConfigDir(), nil
+}
+
+// checkInventory loads the catalog including registered repositories
+func checkInventory(string) (string, error) {
+	categories, err := LoadToolsFromInventory()
+	if err != nil {
+		return "", err
+	}
+	tools := 0
+	for _, category := range categories {
+		if strings.HasPrefix(category.Purpose, "⚠ ") {
+			return "", fmt.Errorf("%s: %s", category.Name, strings.TrimPrefix(category.Purpose, "⚠ "))
+		}
+		tools += len(category.Tools)
+	}
+	if tools == 0 {
+		return "", fmt.Errorf("inventory is empty")
+	}
+	return fmt.Sprintf("%d tools in %d categories", tools, len(categories)), nil
+}
+
+// checkCommand runs a trivial command the way tools are executed
+func checkCommand(tmp string) (string, error) {
+	output, err := ExecuteCommandIn(tmp, "echo selftest")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) != "selftest" {
+		return "", fmt.Errorf("unexpected output %q", output)
+	}
+	return "echo ok", nil
+}
+
+// checkSandbox runs a trivial command inside the downloaded-tier sandbox
+func checkSandbox(tmp string) (string, error) {
+	if currentSandbox() == SandboxRefused {
+		return "bwrap not installed, downloaded tools are refused", nil
+	}
+	output, err := ExecuteSandboxed(tmp, "echo selftest")
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	if currentSandbox() == SandboxEnvOnly {
+		return "ok (bwrap not installed, NOT sandboxed: environment only, allowed by " + unsandboxedEnv + ")", nil
+	}
+	return "ok (bwrap)", nil
+}
+
+// checkSQLite opens a throwaway database with the Python sqlite3 module
+// the memory tools rely on
+func checkSQLite(tmp string) (string, error) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		return "", fmt.Errorf("python3 not found in PATH")
+	}
+	script := "import sqlite3, sys\n" +
+		"db = sqlite3.connect(sys.argv[1])\n" +
+		"db.execute('create table t (x)')\n" +
+		"db.execute('insert into t values (1)')\n" +
+		"print(sqlite3.sqlite_version, db.execute('select count(*) from t').fetchone()[0])\n"
+	output, err := exec.Command(python, "-c", script, filepath.Join(tmp, "selftest.db")).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 || fields[1] != "1" {
+		return "", fmt.Errorf("unexpected output %q", output)
+	}
+	return "sqlite " + fields[0], nil
+}
+
+// checkHTTP serves the serve-mode endpoints on a loopback listener and
+// requests them
+func checkHTTP(tmp string) (string, error) {
+	server := httptest.NewServer(newServeMux(serverOptions{DistDir: tmp, PublicURL: "http://selftest.invalid"}))
+	defer server.Close()
+
+	for _, path := range []string{"/whoami", "/install.sh"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			return "", err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("GET %s: %s", path, resp.Status)
+		}
+	}
+	return "serve endpoints respond", nil
+}
+
+// checkRender builds the initial model and renders a frame off-screen
+func checkRender(string) (string, error) {
+	model, _ := InitialModel().Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	frame := model.View()
+	if strings.TrimSpace(frame) == "" {
+		return "", fmt.Errorf("empty frame")
+	}
+	return fmt.Sprintf("%d lines", strings.Count(frame, "\n")+1), nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// serverOptions configures the HTTP server started by the serve subcommand
+type serverOptions struct {
+	Addr      string
+	DistDir   string
+	PublicURL string
+	Access    AccessConfig
+}
+
+// installScript is served from /install.sh. It detects the platform,
+// downloads the matching prebuilt binary and checks it against SHA256SUMS,
+// authenticating with the bearer token in $OPENCODE_TUI_TOKEN. The token
+// goes to curl on stdin so it does not show in the process list.
+var installScript = template.Must(template.New("install").Parse(`#!/bin/sh
+# Installs tools-tui from {{.BaseURL}}
+set -eu
+
+BASE_URL="{{.BaseURL}}"
+INSTALL_DIR="${INSTALL_DIR:-$HOME/.local/bin}"
+TOKEN="${OPENCODE_TUI_TOKEN:-}"
+
+fetch() {
+  if [ -n "$TOKEN" ]; then
+    printf 'header = "Authorization: Bearer %s"\n' "$TOKEN" | curl -fsSL -K - "$@"
+  else
+    curl -fsSL "$@"
+  fi
+}
+
+case "$(uname -s)" in
+  Linux) OS=linux ;;
+  Darwin) OS=darwin ;;
+  *) echo "unsupported OS: $(uname -s)" >&2; exit 1 ;;
+esac
+case "$(uname -m)" in
+  x86_64|amd64) ARCH=amd64 ;;
+  arm64|aarch64) ARCH=arm64 ;;
+  *) echo "unsupported architecture: $(uname -m)" >&2; exit 1 ;;
+esac
+
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+NAME="$(fetch "$BASE_URL/download/$OS/$ARCH?name=1")"
+echo "Downloading $NAME..."
+fetch -o "$TMP/$NAME" "$BASE_URL/download/$OS/$ARCH"
+fetch -o "$TMP/SHA256SUMS" "$BASE_URL/SHA256SUMS"
+
+cd "$TMP"
+if command -v sha256sum >/dev/null 2>&1; then
+  grep " $NAME\$" SHA256SUMS | sha256sum -c -
+elif command -v shasum >/dev/null 2>&1; then
+  grep " $NAME\$" SHA256SUMS | shasum -a 256 -c -
+else
+  echo "warning: no sha256 tool found, skipping checksum verification" >&2
+fi
+
+mkdir -p "$INSTALL_DIR"
+install -m 0755 "$TMP/$NAME" "$INSTALL_DIR/tools-tui"
+echo "Installed tools-tui to $INSTALL_DIR/tools-tui"
+`))
+
+// runServe implements the serve subcommand
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	opts := serverOptions{}
+	fs.StringVar(&opts.Addr, "addr", ":8080", "address to listen on")
+	fs.StringVar(&opts.DistDir, "dist", "dist", "directory with release artifacts")
+	fs.StringVar(&opts.PublicURL, "public-url", "", "external base URL clients reach the server at, used in install.sh (required)")
+	fs.Parse(args)
+
+	if err := checkPublicURL(opts.PublicURL); err != nil {
+		return err
+	}
+	access, err := LoadAccessConfig()
+	if err != nil {
+		return err
+	}
+	opts.Access = access
+
+	log.Printf("serving on %s (artifacts from %s)", opts.Addr, opts.DistDir)
+	return http.ListenAndServe(opts.Addr, newServeMux(opts))
+}
+
+// newServeMux registers the HTTP endpoints
+func newServeMux(opts serverOptions) *http.ServeMux {
+	access := opts.Access
+	mux := http.NewServeMux()
+	mux.HandleFunc("/install.sh", opts.handleInstallScript)
+	mux.HandleFunc("/whoami", access.handleWhoami)
+	mux.HandleFunc("/download/", access.requireRole(viewRole, opts.handleDownload))
+	mux.HandleFunc("/SHA256SUMS", access.requireRole(viewRole, func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(opts.DistDir, "SHA256SUMS"))
+	}))
+	return mux
+}
+
+// checkPublicURL validates --public-url. It is required rather than
+// taken from the Host header, which clients choose and would let anyone
+// serve an install script pointing at another server.
+func checkPublicURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("serve needs --public-url, the URL clients reach this server at, e.g. http://build-host:8080")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("--public-url %q is not an http or https URL", raw)
+	}
+	return nil
+}
+
+// handleInstallScript renders the install script for this server
+func (o serverOptions) handleInstallScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/x-shellscript; charset=utf-8")
+	installScript.Execute(w, struct{ BaseURL string }{strings.TrimSuffix(o.PublicURL, "/")})
+}
+
+// handleDownload serves the newest tools-tui binary for /download/<os>/<arch>.
+// With ?name=1 only the artifact file name is returned.
+func (o serverOptions) handleDownload(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/download/"), "/"), "/")
+	if len(parts) != 2 {
+		http.Error(w, "expected /download/<os>/<arch>", http.StatusBadRequest)
+		return
+	}
+	name, err := latestArtifact(o.DistDir, "tools-tui", parts[0], parts[1])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("name") != "" {
+		fmt.Fprint(w, name)
+		return
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename="+name)
+	http.ServeFile(w, r, filepath.Join(o.DistDir, name))
+}
+
+// latestArtifact finds the newest release artifact for a platform
+func latestArtifact(dist, project, goos, goarch string) (string, error) {
+	entries, err := os.ReadDir(dist)
+	if err != nil {
+		return "", err
+	}
+	var matches []os.FileInfo
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".exe")
+		if strings.HasPrefix(name, project+"_") && strings.HasSuffix(name, "_"+goos+"_"+goarch) {
+			if info, err := entry.Info(); err == nil {
+				matches = append(matches, info)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no %s build for %s/%s", project, goos, goarch)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ModTime().After(matches[j].ModTime()) })
+	return matches[0].Name(), nil
+}
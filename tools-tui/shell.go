@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// shellOperators are the operators splitCommand recognises outside
+// quotes, longest first
+var shellOperators = []string{"&&", "||", ">>", ";", "|", ">", "<", "&", "`", "$("}
+
+// plainWord matches arguments that need no quoting in sh
+var plainWord = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// commandToken is a word or an operator of a command line
+type commandToken struct {
+	Text string
+	Op   bool
+}
+
+// splitCommand splits a command line into words the way sh does:
+// single quotes keep everything, double quotes and backslashes escape,
+// and operators outside quotes become tokens of their own. Nothing is
+// expanded.
+func splitCommand(command string) ([]commandToken, error) {
+	var tokens []commandToken
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, commandToken{Text: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					break
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated '")
+			}
+			inWord = true
+		case r == '"':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf(`unterminated "`)
+			}
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			op := ""
+			for _, candidate := range shellOperators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				word.WriteRune(r)
+				inWord = true
+				continue
+			}
+			flush()
+			tokens = append(tokens, commandToken{Text: op, Op: true})
+			i += len([]rune(op)) - 1
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// commandChain splits a command into the argv of each step of its &&
+// chain. Other operators need a shell and are reported; errors do not
+// repeat the command.
+func commandChain(command string) ([][]string, error) {
+	tokens, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	chain := [][]string{nil}
+	for _, token := range tokens {
+		switch {
+		case !token.Op:
+			chain[len(chain)-1] = append(chain[len(chain)-1], token.Text)
+		case token.Text == "&&" && len(chain[len(chain)-1]) > 0:
+			chain = append(chain, nil)
+		case token.Text == "&&":
+			return nil, fmt.Errorf("empty step before &&")
+		default:
+			return nil, fmt.Errorf("%s needs a shell: set \"shell\" on the tool", token.Text)
+		}
+	}
+	if len(chain[len(chain)-1]) == 0 {
+		if len(chain) > 1 {
+			return nil, fmt.Errorf("ends with &&")
+		}
+		return nil, fmt.Errorf("empty command")
+	}
+	return chain, nil
+}
+
+// quoteArg quotes s as a single sh word when it needs quoting
+func quoteArg(s string) string {
+	if plainWord.MatchString(s) {
+		return s
+	}
+	return shellQuote(s)
+}
+
+// platformShell returns the shell that runs && chains of tools without
+// a shell of their own
+func platformShell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd"
+	}
+	return "sh"
+}
+
+// quoteFor quotes an argument for shell
+func quoteFor(shell, arg string) string {
+	if shellFlag(shell) != "/C" {
+		return quoteArg(arg)
+	}
+	if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^()%!") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+}
+
+// chainScript joins the steps of an && chain into a command string for
+// shell, quoting every word
+func chainScript(shell string, chain [][]string) string {
+	steps := make([]string, len(chain))
+	for i, argv := range chain {
+		words := make([]string, len(argv))
+		for j, word := range argv {
+			words[j] = quoteFor(shell, word)
+		}
+		steps[i] = strings.Join(words, " ")
+	}
+	return strings.Join(steps, " && ")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	word := func(text string) commandToken { return commandToken{Text: text} }
+	op := func(text string) commandToken { return commandToken{Text: text, Op: true} }
+
+	tests := []struct {
+		name    string
+		command string
+		want    []commandToken
+	}{
+		{"empty", "", nil},
+		{"blanks", " \t\n", nil},
+		{"words", "python cli.py  review", []commandToken{word("python"), word("cli.py"), word("review")}},
+		{"single quotes keep everything", `echo 'a "b" \n $x'`, []commandToken{word("echo"), word(`a "b" \n $x`)}},
+		{"empty single quotes", "echo ''", []commandToken{word("echo"), word("")}},
+		{"empty double quotes", `echo ""`, []commandToken{word("echo"), word("")}},
+		{"double quotes escape", `echo "a \"b\" \\ \$x \` + "`" + `"`, []commandToken{word("echo"), word(`a "b" \ $x ` + "`")}},
+		{"other backslashes stay in double quotes", `echo "a\nb"`, []commandToken{word("echo"), word(`a\nb`)}},
+		{"backslash escapes a blank", `cat my\ file`, []commandToken{word("cat"), word("my file")}},
+		{"backslash escapes an operator", `echo a\;b`, []commandToken{word("echo"), word("a;b")}},
+		{"trailing backslash", `echo a\`, []commandToken{word("echo"), word(`a\`)}},
+		{"quotes join a word", `--name="my tool"'s'`, []commandToken{word("--name=my tools")}},
+		{"operators in quotes", `echo "a && b" '|'`, []commandToken{word("echo"), word("a && b"), word("|")}},
+		{"and chain", "make build && make test", []commandToken{word("make"), word("build"), op("&&"), word("make"), word("test")}},
+		{"operators without blanks", "a&&b||c;d", []commandToken{word("a"), op("&&"), word("b"), op("||"), word("c"), op(";"), word("d")}},
+		{"longest operator first", "a >> log > out < in", []commandToken{word("a"), op(">>"), word("log"), op(">"), word("out"), op("<"), word("in")}},
+		{"pipe and background", "a | b &", []commandToken{word("a"), op("|"), word("b"), op("&")}},
+		{"substitution", "echo $(date) `id`", []commandToken{word("echo"), op("$("), word("date)"), op("`"), word("id"), op("`")}},
+		{"variables are not expanded", "echo $HOME", []commandToken{word("echo"), word("$HOME")}},
+		{"unicode", "echo héllo 'wörld'", []commandToken{word("echo"), word("héllo"), word("wörld")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitCommand(tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommand(%q) = %+v, want %+v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCommandUnterminated(t *testing.T) {
+	for _, command := range []string{`echo 'a`, `echo "a`, `echo "a\"`, `'`} {
+		if tokens, err := splitCommand(command); err == nil {
+			t.Errorf("splitCommand(%q) = %+v, want an error", command, tokens)
+		}
+	}
+}
+
+func TestCommandChain(t *testing.T) {
+	tests := []struct {
+		command string
+		want    [][]string
+		wantErr bool
+	}{
+		{command: "go test ./...", want: [][]string{{"go", "test", "./..."}}},
+		{command: "make && './run tests'", want: [][]string{{"make"}, {"./run tests"}}},
+		{command: "", wantErr: true},
+		{command: "&& make", wantErr: true},
+		{command: "make &&", wantErr: true},
+		{command: "make && && test", wantErr: true},
+		{command: "make | tee log", wantErr: true},
+		{command: "make > log", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := commandChain(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("commandChain(%q) error = %v, want error %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commandChain(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// snapshotsFile records the snapshots kept for each extension
+const snapshotsFile = "snapshots.json"
+
+// maxSnapshots is how many previous versions are kept per extension
+const maxSnapshots = 3
+
+// Snapshot is a previous version of an extension that can be restored
+type Snapshot struct {
+	GitRef    string    `json:"git_ref,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Label describes where the snapshot lives
+func (s Snapshot) Label() string {
+	if s.GitRef != "" {
+		return "git " + s.GitRef[:min(len(s.GitRef), 12)]
+	}
+	return "snapshot " + s.CreatedAt.Format("2006-01-02 15:04")
+}
+
+// extensionState is the persisted update state of one extension
+type extensionState struct {
+	Snapshots   []Snapshot `json:"snapshots"`
+	Quarantined bool       `json:"quarantined"`
+}
+
+// extensionStates is the update state of every extension by name
+type extensionStates map[string]extensionState
+
+// loadExtensionStates reads snapshot state for all extensions
+func loadExtensionStates() (extensionStates, error) {
+	states := extensionStates{}
+	err := loadJSON(snapshotsFile, &states)
+	return states, err
+}
+
+// latest returns the most recent snapshot of the extension in dir
+func (s extensionStates) latest(dir string) (Snapshot, bool) {
+	snaps := s[filepath.Base(dir)].Snapshots
+	if len(snaps) == 0 {
+		return Snapshot{}, false
+	}
+	return snaps[len(snaps)-1], true
+}
+
+// quarantined reports whether the last update of the extension in dir
+// failed
+func (s extensionStates) quarantined(dir string) bool {
+	return s[filepath.Base(dir)].Quarantined
+}
+
+// SnapshotExtension records the current version of an extension before it
+// is updated. Git checkouts keep their HEAD ref, anything else is copied
+// into the config directory.
+func SnapshotExtension(dir string) (Snapshot, error) {
+	snap := Snapshot{CreatedAt: time.Now()}
+
+	hash, err := HashDirectory(dir)
+	if err != nil {
+		return snap, err
+	}
+	snap.Hash = hash
+
+	if ref, err := gitHead(dir); err == nil {
+		snap.GitRef = ref
+	} else {
+		snap.Path = filepath.Join(ConfigDir(), "snapshots", filepath.Base(dir), snap.CreatedAt.Format("20060102-150405"))
+		if err := copyTree(dir, snap.Path, hashSkipDirs); err != nil {
+			return snap, err
+		}
+	}
+
+	states, err := loadExtensionStates()
+	if err != nil {
+		return snap, err
+	}
+	name := filepath.Base(dir)
+	state := states[name]
+	state.Snapshots = append(state.Snapshots, snap)
+	for len(state.Snapshots) > maxSnapshots {
+		if old := state.Snapshots[0]; old.Path != "" {
+			os.RemoveAll(old.Path)
+		}
+		state.Snapshots = state.Snapshots[1:]
+	}
+	states[name] = state
+	return snap, saveJSON(snapshotsFile, states)
+}
+
+// LatestSnapshot returns the most recent snapshot of an extension
+func LatestSnapshot(dir string) (Snapshot, bool) {
+	states, err := loadExtensionStates()
+	if err != nil {
+		return Snapshot{}, false
+	}
+	return states.latest(dir)
+}
+
+// SetQuarantined marks or clears an extension as quarantined
+func SetQuarantined(dir string, quarantined bool) error {
+	states, err := loadExtensionStates()
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(dir)
+	state := states[name]
+	if state.Quarantined == quarantined {
+		return nil
+	}
+	state.Quarantined = quarantined
+	states[name] = state
+	return saveJSON(snapshotsFile, states)
+}
+
+// RollbackExtension restores the latest snapshot of an extension and
+// records its hash as the verified one.
+func RollbackExtension(dir string) (Snapshot, error) {
+	snap, ok := LatestSnapshot(dir)
+	if !ok {
+		return snap, fmt.Errorf("no snapshot recorded for %s", filepath.Base(dir))
+	}
+
+	if snap.GitRef != "" {
+		if out, err := exec.Command("git", "-C", dir, "reset", "--hard", snap.GitRef).CombinedOutput(); err != nil {
+			return snap, fmt.Errorf("git reset failed: %s", strings.TrimSpace(string(out)))
+		}
+	} else {
+		if err := removeContents(dir, hashSkipDirs); err != nil {
+			return snap, err
+		}
+		if err := copyTree(snap.Path, dir, nil); err != nil {
+			return snap, err
+		}
+	}
+
+	if err := SetQuarantined(dir, false); err != nil {
+		return snap, err
+	}
+	return snap, RecordExtensionHash(dir, snap.Hash)
+}
+
+// gitHead returns the commit checked out in dir
+func gitHead(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return "", err
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// sqliteDB reads tables of an SQLite database file without a driver:
+// the file and the committed frames of its write-ahead log are loaded
+// and the table b-trees walked. Indexes, WITHOUT ROWID tables and
+// writes are not supported, which the memory databases do not need.
+type sqliteDB struct {
+	path     string
+	pageSize int
+	usable   int
+	data     []byte
+	// wal holds the pages committed to the write-ahead log but not yet
+	// checkpointed into the file
+	wal    map[int][]byte
+	tables map[string]sqliteTable
+}
+
+// sqliteTable is a table of the schema with the columns of its CREATE
+// TABLE statement
+type sqliteTable struct {
+	Name     string
+	RootPage int
+	Columns  []string
+	// rowidColumn is the INTEGER PRIMARY KEY column stored as the rowid,
+	// or -1
+	rowidColumn int
+	// real marks the columns of REAL affinity, whose integral values
+	// SQLite stores as integers
+	real []bool
+}
+
+// sqliteHeader is the magic string at the start of every database file
+const sqliteHeader = "SQLite format 3\x00"
+
+// openSQLite loads the database at path and reads its schema
+func openSQLite(path string) (*sqliteDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 100 || string(data[:16]) != sqliteHeader {
+		return nil, fmt.Errorf("%s is not an SQLite database", path)
+	}
+	db := &sqliteDB{path: path, data: data}
+	db.pageSize = int(binary.BigEndian.Uint16(data[16:18]))
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	db.usable = db.pageSize - int(data[20])
+	if err := db.readWAL(path + "-wal"); err != nil {
+		return nil, err
+	}
+	if err := db.readSchema(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// readWAL loads the pages of the committed transactions in the
+// write-ahead log, the last version of each page winning
+func (db *sqliteDB) readWAL(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) || len(data) < 32 {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if magic := binary.BigEndian.Uint32(data[0:4]); magic&^1 != 0x377f0682 {
+		return fmt.Errorf("%s is not a write-ahead log", path)
+	}
+	if int(binary.BigEndian.Uint32(data[8:12])) != db.pageSize {
+		return nil
+	}
+	salt := data[16:24]
+	db.wal = map[int][]byte{}
+	pending := map[int][]byte{}
+	for off := 32; off+24+db.pageSize <= len(data); off += 24 + db.pageSize {
+		frame := data[off : off+24]
+		if string(frame[8:16]) != string(salt) {
+			// frames of an earlier generation of the log
+			break
+		}
+		page := int(binary.BigEndian.Uint32(frame[0:4]))
+		pending[page] = data[off+24 : off+24+db.pageSize]
+		if binary.BigEndian.Uint32(frame[4:8]) != 0 {
+			// commit frame: the transaction is complete
+			for number, content := range pending {
+				db.wal[number] = content
+			}
+			pending = map[int][]byte{}
+		}
+	}
+	return nil
+}
+
+// page returns the content of page number n, counting from 1
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	if content, ok := db.wal[n]; ok {
+		return content, nil
+	}
+	start := (n - 1) * db.pageSize
+	if n < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d is outside the file", n)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// readSchema lists the tables of sqlite_schema, the table rooted at
+// page 1
+func (db *sqliteDB) readSchema() error {
+	db.tables = map[string]sqliteTable{}
+	return db.walk(1, func(_ int64, values []any) error {
+		if len(values) < 5 || values[0] != "table" {
+			return nil
+		}
+		name, _ := values[1].(string)
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		if root == 0 || strings.Contains(strings.ToUpper(sql), "WITHOUT ROWID") {
+			return nil
+		}
+		columns, rowid, real := parseColumns(sql)
+		db.tables[strings.ToLower(name)] = sqliteTable{Name: name, RootPage: int(root), Columns: columns, rowidColumn: rowid, real: real}
+		return nil
+	})
+}
+
+// hasTable reports whether the database has a table called name
+func (db *sqliteDB) hasTable(name string) bool {
+	_, ok := db.tables[strings.ToLower(name)]
+	return ok
+}
+
+// Rows returns every row of a table as column name → value, with
+// values of type nil, int64, float64, string or []byte
+func (db *sqliteDB) Rows(table string) ([]map[string]any, error) {
+	t, ok := db.tables[strings.ToLower(table)]
+	if !ok {
+		return nil, fmt.Errorf("%s has no table %s", db.path, table)
+	}
+	var rows []map[string]any
+	err := db.walk(t.RootPage, func(rowid int64, values []any) error {
+		row := make(map[string]any, len(t.Columns))
+		for i, column := range t.Columns {
+			switch {
+			case i == t.rowidColumn:
+				row[column] = rowid
+			case i < len(values):
+				if n, ok := values[i].(int64); ok && t.real[i] {
+					row[column] = float64(n)
+				} else {
+					row[column] = values[i]
+				}
+			default:
+				// added by ALTER TABLE after the row was written
+				row[column] = nil
+			}
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+// walk calls visit with the rowid and values of every row of the table
+// b-tree rooted at page root, in rowid order
+func (db *sqliteDB) walk(root int, visit func(rowid int64, values []any) error) error {
+	return db.walkPage(root, visit, 0)
+}
+
+// walkPage visits the rows under one page of a table b-tree
+func (db *sqliteDB) walkPage(n int, visit func(int64, []any) error, depth int) error {
+	if depth > 64 {
+		return errors.New("table b-tree too deep, the file may be corrupt")
+	}
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	header := 0
+	if n == 1 {
+		header = 100
+	}
+	kind := page[header]
+	cells := int(binary.BigEndian.Uint16(page[header+3 : header+5]))
+	pointers := header + 8
+	if kind == 0x05 {
+		pointers = header + 12
+	}
+	for i := 0; i < cells; i++ {
+		cell := int(binary.BigEndian.Uint16(page[pointers+2*i:]))
+		if cell >= len(page) {
+			return fmt.Errorf("cell %d of page %d is outside the page", i, n)
+		}
+		switch kind {
+		case 0x05:
+			child := int(binary.BigEndian.Uint32(page[cell : cell+4]))
+			if err := db.walkPage(child, visit, depth+1); err != nil {
+				return err
+			}
+		case 0x0d:
+			size, used := sqliteVarint(page[cell:])
+			rowid, used2 := sqliteVarint(page[cell+used:])
+			payload, err := db.payload(page, cell+used+used2, int(size))
+			if err != nil {
+				return err
+			}
+			values, err := decodeRecord(payload)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", rowid, err)
+			}
+			if err := visit(int64(rowid), values); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("page %d is not a table b-tree page (type %#x)", n, kind)
+		}
+	}
+	if kind == 0x05 {
+		right := int(binary.BigEndian.Uint32(page[header+8 : header+12]))
+		return db.walkPage(right, visit, depth+1)
+	}
+	return nil
+}
+
+// payload returns the size bytes of a cell's payload starting at
+// offset start of page, following its overflow pages
+func (db *sqliteDB) payload(page []byte, start, size int) ([]byte, error) {
+	maxLocal := db.usable - 35
+	if size <= maxLocal {
+		if start+size > len(page) {
+			return nil, errors.New("payload runs past its page")
+		}
+		return page[start : start+size], nil
+	}
+	minLocal := (db.usable-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(db.usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	if start+local+4 > len(page) {
+		return nil, errors.New("payload runs past its page")
+	}
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[start:start+local]...)
+	next := int(binary.BigEndian.Uint32(page[start+local:]))
+	for len(payload) < size {
+		if next == 0 {
+			return nil, errors.New("overflow chain ends early")
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := min(size-len(payload), db.usable-4)
+		payload = append(payload, overflow[4:4+chunk]...)
+		next = int(binary.BigEndian.Uint32(overflow[0:4]))
+	}
+	return payload, nil
+}
+
+// decodeRecord splits a record into its values
+func decodeRecord(record []byte) ([]any, error) {
+	headerSize, n := sqliteVarint(record)
+	if int(headerSize) > len(record) || n == 0 {
+		return nil, errors.New("invalid record header")
+	}
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		serial, used := sqliteVarint(record[pos:])
+		if used == 0 {
+			return nil, errors.New("invalid record header")
+		}
+		types = append(types, serial)
+		pos += used
+	}
+	values := make([]any, len(types))
+	body := record[headerSize:]
+	for i, serial := range types {
+		size := serialSize(serial)
+		if size > len(body) {
+			return nil, errors.New("record shorter than its header")
+		}
+		field := body[:size]
+		body = body[size:]
+		switch {
+		case serial == 0:
+			values[i] = nil
+		case serial <= 6:
+			values[i] = sqliteInt(field)
+		case serial == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(field))
+		case serial == 8:
+			values[i] = int64(0)
+		case serial == 9:
+			values[i] = int64(1)
+		case serial >= 12 && serial%2 == 0:
+			values[i] = append([]byte(nil), field...)
+		case serial >= 13:
+			values[i] = string(field)
+		default:
+			return nil, fmt.Errorf("reserved serial type %d", serial)
+		}
+	}
+	return values, nil
+}
+
+// serialSize returns the length of a value of the given serial type
+func serialSize(serial uint64) int {
+	switch {
+	case serial <= 4:
+		return int(serial)
+	case serial == 5:
+		return 6
+	case serial == 6 || serial == 7:
+		return 8
+	case serial < 12:
+		return 0
+	}
+	return int(serial-12) / 2
+}
+
+// sqliteInt decodes a big-endian two's complement integer of 1 to 8
+// bytes
+func sqliteInt(field []byte) int64 {
+	var v int64
+	if len(field) > 0 && field[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, b := range field {
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+// sqliteVarint decodes a variable-length integer, returning it and the
+// number of bytes read (0 when b is too short)
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseColumns lists the column names of a CREATE TABLE statement, the
+// index of its INTEGER PRIMARY KEY column, or -1, and which columns have
+// REAL affinity
+func parseColumns(sql string) ([]string, int, []bool) {
+	open, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if open < 0 || end < open {
+		return nil, -1, nil
+	}
+	var defs []string
+	depth, start := 0, open+1
+	var quote byte
+	for i := open + 1; i < end; i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			defs = append(defs, sql[start:i])
+			start = i + 1
+		}
+	}
+	defs = append(defs, sql[start:end])
+	var columns []string
+	var real []bool
+	rowid := -1
+	for _, def := range defs {
+		def = strings.TrimSpace(def)
+		name, rest := columnName(def)
+		if name == "" {
+			continue
+		}
+		switch strings.ToUpper(name) {
+		case "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "CONSTRAINT":
+			if !strings.ContainsAny(def[:1], "\"'`[") {
+				// a table constraint, not a column
+				continue
+			}
+		}
+		upper := strings.ToUpper(strings.Join(strings.Fields(rest), " "))
+		if strings.HasPrefix(upper, "INTEGER PRIMARY KEY") && !strings.Contains(upper, "DESC") {
+			rowid = len(columns)
+		}
+		columns = append(columns, name)
+		real = append(real, realAffinity(upper))
+	}
+	return columns, rowid, real
+}
+
+// realAffinity reports whether a column definition, in upper case after
+// the name, declares a type of REAL affinity
+func realAffinity(def string) bool {
+	var declared []string
+	for _, word := range strings.Fields(def) {
+		switch word {
+		case "CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK", "DEFAULT", "COLLATE", "REFERENCES", "GENERATED", "AS":
+			return isRealType(strings.Join(declared, " "))
+		}
+		declared = append(declared, word)
+	}
+	return isRealType(strings.Join(declared, " "))
+}
+
+// isRealType applies SQLite's affinity rules to a declared type
+func isRealType(declared string) bool {
+	if strings.Contains(declared, "INT") || strings.Contains(declared, "CHAR") || strings.Contains(declared, "CLOB") ||
+		strings.Contains(declared, "TEXT") || strings.Contains(declared, "BLOB") {
+		return false
+	}
+	return strings.Contains(declared, "REAL") || strings.Contains(declared, "FLOA") || strings.Contains(declared, "DOUB")
+}
+
+// columnName splits a column definition into its name, unquoted, and
+// the rest of the definition
+func columnName(def string) (string, string) {
+	if def == "" {
+		return "", ""
+	}
+	closing := map[byte]byte{'"': '"', '\'': '\'', '`': '`', '[': ']'}[def[0]]
+	if closing == 0 {
+		if i := strings.IndexAny(def, " \t\r\n"); i >= 0 {
+			return def[:i], def[i:]
+		}
+		return def, ""
+	}
+	end := strings.IndexByte(def[1:], closing)
+	if end < 0 {
+		return def[1:], ""
+	}
+	return def[1 : end+1], def[end+2:]
+}
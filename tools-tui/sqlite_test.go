@@ -0,0 +1,256 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeSQLiteScript creates the database with Python's sqlite3. With wal
+// the database is in WAL mode and copied with its write-ahead log before
+// closing, which would checkpoint it.
+const writeSQLiteScript = `
+import shutil, sqlite3, sys
+path, wal, page_size, script = sys.argv[1], sys.argv[2] == "1", int(sys.argv[3]), sys.argv[4]
+source = path + ".source" if wal else path
+con = sqlite3.connect(source, isolation_level=None)
+con.execute("PRAGMA page_size=%d" % page_size)
+if wal:
+    con.execute("PRAGMA journal_mode=WAL")
+    con.execute("PRAGMA wal_autocheckpoint=0")
+con.executescript(script)
+if wal:
+    shutil.copy(source, path)
+    shutil.copy(source + "-wal", path + "-wal")
+con.close()
+`
+
+// pythonSQLite writes a database with the SQL script and opens it
+func pythonSQLite(t *testing.T, wal bool, pageSize int, script string) *sqliteDB {
+	t.Helper()
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is not installed")
+	}
+	path := filepath.Join(t.TempDir(), "test.db")
+	walFlag := "0"
+	if wal {
+		walFlag = "1"
+	}
+	if output, err := exec.Command(python, "-c", writeSQLiteScript, path, walFlag, strconv.Itoa(pageSize), script).CombinedOutput(); err != nil {
+		t.Fatalf("python3: %v\n%s", err, output)
+	}
+	if wal {
+		if info, err := os.Stat(path + "-wal"); err != nil || info.Size() == 0 {
+			t.Fatalf("no write-ahead log to read: %v", err)
+		}
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQLiteRows(t *testing.T) {
+	// numbers inserts the integers 1 to n into t(id, name)
+	numbers := func(n int) string {
+		return `CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ` + strconv.Itoa(n) + `)
+INSERT INTO t SELECT i, 'row ' || i FROM n;`
+	}
+	tests := []struct {
+		name     string
+		wal      bool
+		pageSize int
+		script   string
+		table    string
+		check    func(t *testing.T, rows []map[string]any)
+	}{
+		{
+			name:     "value types",
+			pageSize: 4096,
+			script: `CREATE TABLE "values" (id INTEGER PRIMARY KEY, i INT, r REAL, s TEXT, b BLOB, n);
+INSERT INTO "values" VALUES (1, 0, 1.5, 'héllo', x'00ff', NULL);
+INSERT INTO "values" VALUES (2, 1, -0.25, '', x'', NULL);
+INSERT INTO "values" VALUES (3, -1, 1e300, 'x', x'7f', NULL);
+INSERT INTO "values" VALUES (40, 4611686018427387904, 0.0, 'y', NULL, 127);
+INSERT INTO "values" VALUES (41, -129, 3.0, 'z', NULL, 32768);`,
+			table: "values",
+			check: func(t *testing.T, rows []map[string]any) {
+				want := []map[string]any{
+					{"id": int64(1), "i": int64(0), "r": 1.5, "s": "héllo", "b": []byte{0x00, 0xff}, "n": nil},
+					{"id": int64(2), "i": int64(1), "r": -0.25, "s": "", "b": []byte(nil), "n": nil},
+					{"id": int64(3), "i": int64(-1), "r": 1e300, "s": "x", "b": []byte{0x7f}, "n": nil},
+					{"id": int64(40), "i": int64(4611686018427387904), "r": 0.0, "s": "y", "b": nil, "n": int64(127)},
+					{"id": int64(41), "i": int64(-129), "r": 3.0, "s": "z", "b": nil, "n": int64(32768)},
+				}
+				if !reflect.DeepEqual(rows, want) {
+					t.Errorf("rows = %v, want %v", rows, want)
+				}
+			},
+		},
+		{
+			name:     "added column",
+			pageSize: 4096,
+			script: `CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO t VALUES (1, 'old');
+ALTER TABLE t ADD COLUMN tag TEXT;
+INSERT INTO t VALUES (2, 'new', 'x');`,
+			table: "T",
+			check: func(t *testing.T, rows []map[string]any) {
+				want := []map[string]any{
+					{"id": int64(1), "name": "old", "tag": nil},
+					{"id": int64(2), "name": "new", "tag": "x"},
+				}
+				if !reflect.DeepEqual(rows, want) {
+					t.Errorf("rows = %v, want %v", rows, want)
+				}
+			},
+		},
+		{
+			name:     "overflow pages",
+			pageSize: 1024,
+			script: `CREATE TABLE t (id INTEGER PRIMARY KEY, text TEXT, data BLOB);
+INSERT INTO t VALUES (1, replace(hex(zeroblob(5000)), '0', 'x'), CAST(replace(hex(zeroblob(50000)), '00', 'ab') AS BLOB));
+INSERT INTO t VALUES (2, replace(hex(zeroblob(495)), '0', 'y'), NULL);
+INSERT INTO t VALUES (3, 'short', NULL);`,
+			table: "t",
+			check: func(t *testing.T, rows []map[string]any) {
+				if len(rows) != 3 {
+					t.Fatalf("%d rows, want 3", len(rows))
+				}
+				if rows[0]["text"] != strings.Repeat("x", 10000) {
+					t.Errorf("overflowing text of %d bytes read wrong", len(strings.Repeat("x", 10000)))
+				}
+				if data, _ := rows[0]["data"].([]byte); string(data) != strings.Repeat("ab", 50000) {
+					t.Errorf("overflowing blob read as %d bytes", len(data))
+				}
+				if rows[1]["text"] != strings.Repeat("y", 990) {
+					t.Errorf("text just past the local payload read wrong")
+				}
+				if rows[2]["text"] != "short" {
+					t.Errorf("row after the overflowing ones = %v", rows[2])
+				}
+			},
+		},
+		{
+			name:     "interior pages",
+			pageSize: 512,
+			script:   numbers(20000),
+			table:    "t",
+			check: func(t *testing.T, rows []map[string]any) {
+				if len(rows) != 20000 {
+					t.Fatalf("%d rows, want 20000", len(rows))
+				}
+				for i, row := range rows {
+					if row["id"] != int64(i+1) || row["name"] != "row "+strconv.Itoa(i+1) {
+						t.Fatalf("row %d = %v", i, row)
+					}
+				}
+			},
+		},
+		{
+			name:     "deleted rows",
+			pageSize: 1024,
+			script:   numbers(3000) + `DELETE FROM t WHERE id % 3 != 0;`,
+			table:    "t",
+			check: func(t *testing.T, rows []map[string]any) {
+				if len(rows) != 1000 {
+					t.Fatalf("%d rows, want 1000", len(rows))
+				}
+				if rows[999]["id"] != int64(3000) {
+					t.Errorf("last row = %v", rows[999])
+				}
+			},
+		},
+		{
+			name:     "write-ahead log",
+			wal:      true,
+			pageSize: 1024,
+			script:   numbers(2000) + `UPDATE t SET name = 'changed' WHERE id = 1500; INSERT INTO t VALUES (2001, replace(hex(zeroblob(3000)), '0', 'w'));`,
+			table:    "t",
+			check: func(t *testing.T, rows []map[string]any) {
+				if len(rows) != 2001 {
+					t.Fatalf("%d rows, want 2001", len(rows))
+				}
+				if rows[1499]["name"] != "changed" {
+					t.Errorf("row updated in the log = %v", rows[1499])
+				}
+				if rows[2000]["name"] != strings.Repeat("w", 6000) {
+					t.Errorf("overflowing row added in the log read wrong")
+				}
+			},
+		},
+		{
+			name:     "write-ahead log after a checkpoint",
+			wal:      true,
+			pageSize: 4096,
+			script:   numbers(10) + `PRAGMA wal_checkpoint(TRUNCATE); DELETE FROM t WHERE id > 5; UPDATE t SET name = 'one' WHERE id = 1;`,
+			table:    "t",
+			check: func(t *testing.T, rows []map[string]any) {
+				if len(rows) != 5 || rows[0]["name"] != "one" {
+					t.Errorf("rows = %v", rows)
+				}
+			},
+		},
+		{
+			name:     "table created in the log",
+			wal:      true,
+			pageSize: 4096,
+			script:   `CREATE TABLE other (x); INSERT INTO other VALUES (1);` + numbers(3),
+			table:    "t",
+			check: func(t *testing.T, rows []map[string]any) {
+				if len(rows) != 3 || rows[2]["name"] != "row 3" {
+					t.Errorf("rows = %v", rows)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := pythonSQLite(t, tt.wal, tt.pageSize, tt.script)
+			rows, err := db.Rows(tt.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, rows)
+		})
+	}
+}
+
+func TestSQLiteSchema(t *testing.T) {
+	db := pythonSQLite(t, false, 4096, `CREATE TABLE plain (a, b);
+CREATE TABLE keyed (k TEXT PRIMARY KEY, v) WITHOUT ROWID;
+CREATE INDEX plain_a ON plain (a);
+CREATE VIEW both AS SELECT * FROM plain;`)
+	for name, want := range map[string]bool{"plain": true, "PLAIN": true, "keyed": false, "plain_a": false, "both": false, "missing": false} {
+		if got := db.hasTable(name); got != want {
+			t.Errorf("hasTable(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := db.Rows("missing"); err == nil {
+		t.Error("Rows of a missing table succeeded")
+	}
+}
+
+func TestOpenSQLiteRejectsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"empty":     "",
+		"text":      "not a database at all, just some text that is long enough to have a header of one hundred bytes or more..",
+		"truncated": sqliteHeader,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openSQLite(path); err == nil {
+			t.Errorf("openSQLite of %s succeeded", name)
+		}
+	}
+}
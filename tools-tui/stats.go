@@ -0,0 +1,204 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ToolStats aggregates the runs of one tool
+type ToolStats struct {
+	Tool     string
+	Runs     int
+	Failures int
+	Total    time.Duration
+	Slowest  time.Duration
+	LastRun  time.Time
+}
+
+// Average returns the mean run duration
+func (s ToolStats) Average() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Runs)
+}
+
+// FailureRate returns the share of failed runs in percent
+func (s ToolStats) FailureRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Failures) * 100 / float64(s.Runs)
+}
+
+// UsageStats summarises the run history over a time range
+type UsageStats struct {
+	Since, Until time.Time
+	Runs         int
+	Failures     int
+	Tools        []ToolStats
+	ByWeekday    [7]int
+	ByHour       [24]int
+}
+
+// ComputeStats aggregates run records
+func ComputeStats(records []RunRecord, since, until time.Time) UsageStats {
+	stats := UsageStats{Since: since, Until: until}
+	byTool := map[string]*ToolStats{}
+	for _, record := range records {
+		s, ok := byTool[record.Tool]
+		if !ok {
+			s = &ToolStats{Tool: record.Tool}
+			byTool[record.Tool] = s
+		}
+		s.Runs++
+		s.Total += record.Duration()
+		if record.Duration() > s.Slowest {
+			s.Slowest = record.Duration()
+		}
+		if record.Started.After(s.LastRun) {
+			s.LastRun = record.Started
+		}
+		stats.Runs++
+		if !record.Success {
+			s.Failures++
+			stats.Failures++
+		}
+		local := record.Started.Local()
+		stats.ByWeekday[local.Weekday()]++
+		stats.ByHour[local.Hour()]++
+	}
+	for _, s := range byTool {
+		stats.Tools = append(stats.Tools, *s)
+	}
+	sort.Slice(stats.Tools, func(i, j int) bool {
+		if stats.Tools[i].Runs != stats.Tools[j].Runs {
+			return stats.Tools[i].Runs > stats.Tools[j].Runs
+		}
+		return stats.Tools[i].Tool < stats.Tools[j].Tool
+	})
+	return stats
+}
+
+// rangeLabel describes the time range covered
+func (s UsageStats) rangeLabel() string {
+	from, to := "the first run", "now"
+	if !s.Since.IsZero() {
+		from = s.Since.Format("2006-01-02 15:04")
+	}
+	if !s.Until.IsZero() {
+		to = s.Until.Format("2006-01-02 15:04")
+	}
+	return from + " to " + to
+}
+
+// sortedTools returns a copy of the per-tool stats ordered by less
+func (s UsageStats) sortedTools(less func(a, b ToolStats) bool) []ToolStats {
+	tools := append([]ToolStats(nil), s.Tools...)
+	sort.SliceStable(tools, func(i, j int) bool { return less(tools[i], tools[j]) })
+	return tools
+}
+
+// Markdown renders the full report
+func (s UsageStats) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# tools-tui usage report\n\n")
+	fmt.Fprintf(&b, "Range: %s. Generated locally from `%s`; nothing is sent anywhere.\n\n", s.rangeLabel(), historyFile)
+	if s.Runs == 0 {
+		b.WriteString("No runs recorded in this range.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "- Runs: %d\n- Failures: %d (%.1f%%)\n- Distinct tools: %d\n\n",
+		s.Runs, s.Failures, float64(s.Failures)*100/float64(s.Runs), len(s.Tools))
+
+	b.WriteString("## Most used\n\n| Tool | Runs | Last run |\n|---|---:|---|\n")
+	for _, t := range s.Tools {
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", t.Tool, t.Runs, t.LastRun.Local().Format("2006-01-02 15:04"))
+	}
+
+	b.WriteString("\n## Failure hotspots\n\n")
+	hotspots := s.sortedTools(func(a, b ToolStats) bool { return a.Failures > b.Failures })
+	if hotspots[0].Failures == 0 {
+		b.WriteString("No failures.\n")
+	} else {
+		b.WriteString("| Tool | Failures | Failure rate |\n|---|---:|---:|\n")
+		for _, t := range hotspots {
+			if t.Failures > 0 {
+				fmt.Fprintf(&b, "| %s | %d | %.1f%% |\n", t.Tool, t.Failures, t.FailureRate())
+			}
+		}
+	}
+
+	b.WriteString("\n## Slowest tools\n\n| Tool | Average | Slowest |\n|---|---:|---:|\n")
+	for _, t := range s.sortedTools(func(a, b ToolStats) bool { return a.Average() > b.Average() }) {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", t.Tool, t.Average().Round(time.Millisecond), t.Slowest.Round(time.Millisecond))
+	}
+
+	b.WriteString("\n## When tools run\n\n| Weekday | Runs |\n|---|---:|\n")
+	for day := time.Monday; day < time.Monday+7; day++ {
+		fmt.Fprintf(&b, "| %s | %d |\n", (day % 7).String(), s.ByWeekday[day%7])
+	}
+	busiest := 0
+	for hour, runs := range s.ByHour {
+		if runs > s.ByHour[busiest] {
+			busiest = hour
+		}
+	}
+	fmt.Fprintf(&b, "\nBusiest hour: %02d:00–%02d:00 (%d runs)\n", busiest, (busiest+1)%24, s.ByHour[busiest])
+	return b.String()
+}
+
+// Summary renders a short plain-text overview
+func (s UsageStats) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d runs, %d failed, %s\n", s.Runs, s.Failures, s.rangeLabel())
+	for i, t := range s.Tools {
+		if i == 10 {
+			fmt.Fprintf(&b, "… %d more (use --report for everything)\n", len(s.Tools)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %-32s %4d runs  %5.1f%% failed  avg %s\n", t.Tool, t.Runs, t.FailureRate(), t.Average().Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+// runStats implements the stats subcommand
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	sinceFlag := fs.String("since", "30d", "start of the range (2006-01-02, 7d, 2w, 12h or empty for all)")
+	untilFlag := fs.String("until", "", "end of the range (same formats, defaults to now)")
+	report := fs.Bool("report", false, "print a full markdown report")
+	out := fs.String("out", "", "write the markdown report to this file")
+	fs.Parse(args)
+
+	now := time.Now()
+	since, err := parseTimeBound(*sinceFlag, now)
+	if err != nil {
+		return err
+	}
+	until, err := parseTimeBound(*untilFlag, now)
+	if err != nil {
+		return err
+	}
+	records, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	stats := ComputeStats(filterHistory(records, since, until), since, until)
+
+	switch {
+	case *out != "":
+		if err := WriteFileContent(*out, stats.Markdown()); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", *out)
+	case *report:
+		fmt.Print(stats.Markdown())
+	default:
+		fmt.Print(stats.Summary())
+	}
+	return nil
+}
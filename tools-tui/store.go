@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// ConfigDir returns the directory used for persisted TUI state
+func ConfigDir() string {
+	if dir := os.Getenv("OPENCODE_TUI_CONFIG"); dir != "" {
+		return dir
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		base = filepath.Join(os.TempDir(), "opencode-tui-config")
+	}
+	return filepath.Join(base, "opencode-tui")
+}
+
+// loadJSON decodes a JSON file from the config directory into v.
+// A missing file is not an error and leaves v untouched.
+func loadJSON(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(ConfigDir(), name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveJSON encodes v as indented JSON into the config directory
+func saveJSON(name string, v interface{}) error {
+	if err := os.MkdirAll(ConfigDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileContent(filepath.Join(ConfigDir(), name), string(data)+"\n")
+}
+
+// savePrivateJSON is saveJSON for files only the user may read, such as
+// session cookies; a file written before with wider permissions is
+// narrowed
+func savePrivateJSON(name string, v interface{}) error {
+	if err := os.MkdirAll(ConfigDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(ConfigDir(), name)
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// streamWaitDelay bounds how long a cancelled command may keep its
+// output pipes open, e.g. through a forked child
+const streamWaitDelay = 2 * time.Second
+
+// streamOutputMsg carries output produced by the job with the given id
+type streamOutputMsg struct {
+	id    int
+	chunk string
+}
+
+// streamDoneMsg reports that the job with the given id has exited
+type streamDoneMsg struct {
+	id     int
+	output string
+	err    error
+}
+
+// streamTickMsg refreshes the elapsed time of the job with the given id
+type streamTickMsg struct {
+	id int
+}
+
+// streamWriter forwards everything the process writes as messages and
+// keeps the complete output for the final result
+type streamWriter struct {
+	mu     sync.Mutex
+	output strings.Builder
+	ch     chan<- tea.Msg
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.output.Write(p)
+	w.mu.Unlock()
+	w.ch <- streamOutputMsg{chunk: string(p)}
+	return len(p), nil
+}
+
+// String returns everything written so far
+func (w *streamWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.output.String()
+}
+
+// runningTool is a tool execution, a job, shown in an output pane.
+// Everything needed to finish the run is kept here since the selection
+// may change meanwhile. Finished jobs stay until their pane is reused.
+type runningTool struct {
+	id           int
+	tool         *Tool
+	projectDir   string
+	args         map[string]string
+	extensionDir string
+	report       IntegrityReport
+	// installer is the package manager of an install job, "" otherwise
+	installer string
+	// upgrade marks the pull of an upgrade, followed by an install
+	upgrade   bool
+	env       EnvSnapshot
+	started   time.Time
+	cancel    context.CancelFunc
+	cancelled bool
+	ch        <-chan tea.Msg
+	output    string
+	mode      OutputMode
+	viewport  viewport.Model
+	// follow keeps the pane scrolled to the end of the output
+	follow   bool
+	done     bool
+	err      error
+	finished time.Time
+}
+
+// recordedEnv is set for the tool runs the TUI records in the history,
+// so that the exec subcommand they start (cli.py runs its scripts
+// through it) does not record them a second time
+const recordedEnv = "OPENCODE_TUI_RECORDED"
+
+// toolCommand prepares the process for a tool, sandboxing it when its
+// tier requires. cleanup must be called once the process has exited.
+func toolCommand(ctx context.Context, tool *Tool, projectDir string) (*exec.Cmd, func(), error) {
+	dir, parts, err := tool.argv(scopedCommand(tool, projectDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	recorded := recordedEnv + "=1"
+	if tool.Trust.Sandboxed() {
+		return sandboxCommand(ctx, dir, parts, append(tool.envList(), recorded))
+	}
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(tool.environ(), recorded)
+	return cmd, func() {}, nil
+}
+
+// StreamTool starts a tool and streams its combined output over the
+// returned channel, ending with a streamDoneMsg. Faults are injected
+// into the final result just like for ExecuteTool.
+func StreamTool(ctx context.Context, tool *Tool, projectDir string) <-chan tea.Msg {
+	ch := make(chan tea.Msg, 64)
+	go func() {
+		defer close(ch)
+		cmd, cleanup, err := toolCommand(ctx, tool, projectDir)
+		if err != nil {
+			ch <- streamDoneMsg{err: err}
+			return
+		}
+		defer cleanup()
+		killGroupOnCancel(cmd)
+		w := &streamWriter{ch: ch}
+		cmd.Stdout = w
+		cmd.Stderr = w
+		cmd.WaitDelay = streamWaitDelay
+		err = cmd.Run()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("cancelled")
+		}
+		output, err := injectFault(w.String(), err)
+		ch <- streamDoneMsg{output: output, err: err}
+	}()
+	return ch
+}
+
+// waitForStream delivers the next message of job id, merging output
+// chunks that are already queued so fast writers do not force a redraw
+// per line
+func waitForStream(id int, ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		out, isOutput := msg.(streamOutputMsg)
+		if !isOutput {
+			done := msg.(streamDoneMsg)
+			done.id = id
+			return done
+		}
+		var chunk strings.Builder
+		chunk.WriteString(out.chunk)
+		for chunk.Len() < 64*1024 && len(ch) > 0 {
+			next := <-ch
+			more, ok := next.(streamOutputMsg)
+			if !ok {
+				done := next.(streamDoneMsg)
+				done.id = id
+				return streamBatchMsg{id: id, output: chunk.String(), then: done}
+			}
+			chunk.WriteString(more.chunk)
+		}
+		return streamOutputMsg{id: id, chunk: chunk.String()}
+	}
+}
+
+// streamBatchMsg is output immediately followed by the end of the run
+type streamBatchMsg struct {
+	id     int
+	output string
+	then   tea.Msg
+}
+
+// streamTick schedules the next elapsed-time refresh of job id
+func streamTick(id int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return streamTickMsg{id: id} })
+}
+
+// Elapsed returns how long the tool has been running, or ran
+func (r *runningTool) Elapsed() time.Duration {
+	if r.done {
+		return r.finished.Sub(r.started).Round(time.Second)
+	}
+	return time.Since(r.started).Round(time.Second)
+}
+
+// appendOutput adds streamed output to the job's pane
+func (r *runningTool) appendOutput(chunk string) {
+	r.output += chunk
+	r.viewport.SetContent(r.display())
+	if r.follow {
+		r.viewport.GotoBottom()
+	}
+}
+
+// updateStream handles the messages of running jobs
+func (m Model) updateStream(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case streamTickMsg:
+		if job := m.job(msg.id); job != nil && !job.done {
+			return m, streamTick(job.id)
+		}
+	case streamOutputMsg:
+		if job := m.job(msg.id); job != nil {
+			m.appendJobOutput(job, msg.chunk)
+			return m, waitForStream(job.id, job.ch)
+		}
+	case streamBatchMsg:
+		if job := m.job(msg.id); job != nil {
+			m.appendJobOutput(job, msg.output)
+			return m.updateStream(msg.then)
+		}
+	case streamDoneMsg:
+		if job := m.job(msg.id); job != nil {
+			return m, tea.Batch(m.finishRun(job, msg.output, msg.err), m.startQueued())
+		}
+	}
+	return m, nil
+}
+
+// appendJobOutput adds streamed output to the job's pane, the log viewer
+// when it shows the job and, when the detail view is attached to the
+// job, to its viewport
+func (m *Model) appendJobOutput(job *runningTool, chunk string) {
+	job.appendOutput(chunk)
+	m.refreshLog(job)
+	if job.id == m.detailJob {
+		m.appendOutput(chunk)
+	}
+}
+
+// appendOutput adds streamed output to the viewport, following the end
+// unless the user has scrolled up
+func (m *Model) appendOutput(chunk string) {
+	follow := m.viewport.AtBottom()
+	m.commandOutput += chunk
+	m.viewport.SetContent(m.commandOutput)
+	if follow {
+		m.viewport.GotoBottom()
+	}
+}
+
+// cancelRun stops a running job; its exit is reported as usual
+func (m *Model) cancelRun(r *runningTool) {
+	r.cancelled = true
+	r.cancel()
+	m.statusMessage = fmt.Sprintf("Cancelling %s…", r.tool.Name)
+}
+
+// renderRunning describes a running job for the detail view
+func renderRunning(r *runningTool) string {
+	state := fmt.Sprintf("⏳ Running %s… %s (ctrl+c to cancel)", r.tool.Name, r.runningFor())
+	if r.cancelled {
+		state = fmt.Sprintf("⏳ Cancelling %s… %s", r.tool.Name, r.runningFor())
+	}
+	return commandStyle.Render(state)
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// healthInterval is how often running servers are pinged
+const healthInterval = 15 * time.Second
+
+// healthTimeout is how long a server may take to answer a ping
+const healthTimeout = 5 * time.Second
+
+// maxAutoRestarts is how often a crashed server is restarted before it
+// is left crashed; each attempt waits two seconds longer
+const maxAutoRestarts = 3
+
+// ServerState is the lifecycle state of a supervised MCP server
+type ServerState string
+
+const (
+	ServerStopped   ServerState = "stopped"
+	ServerStarting  ServerState = "starting"
+	ServerRunning   ServerState = "running"
+	ServerUnhealthy ServerState = "unhealthy"
+	ServerCrashed   ServerState = "crashed"
+)
+
+// ServerStatus is a snapshot of a supervised server
+type ServerStatus struct {
+	State    ServerState
+	PID      int
+	Started  time.Time
+	Restarts int
+	Error    string
+}
+
+// Active reports whether the server process is up or coming up
+func (s ServerStatus) Active() bool {
+	return s.State == ServerStarting || s.State == ServerRunning || s.State == ServerUnhealthy
+}
+
+// Label renders the state with PID, uptime and restarts for status
+// columns
+func (s ServerStatus) Label() string {
+	switch s.State {
+	case ServerRunning, ServerUnhealthy:
+		label := fmt.Sprintf("%s pid %d up %s", s.State, s.PID, time.Since(s.Started).Round(time.Second))
+		if s.Restarts > 0 {
+			label += fmt.Sprintf(" ↻%d", s.Restarts)
+		}
+		return label
+	case "":
+		return string(ServerStopped)
+	}
+	return string(s.State)
+}
+
+// Icon is a one-character summary of the state
+func (s ServerStatus) Icon() string {
+	switch s.State {
+	case ServerRunning:
+		return "●"
+	case ServerStarting:
+		return "◌"
+	case ServerUnhealthy:
+		return "◐"
+	case ServerCrashed:
+		return "✘"
+	}
+	return "○"
+}
+
+// supervised is a server under supervision. generation changes whenever
+// the server is stopped or restarted so goroutines of an earlier
+// process notice they are obsolete.
+type supervised struct {
+	cfg          MCPServerConfig
+	client       *MCPClient
+	status       ServerStatus
+	generation   int
+	autoRestarts int
+}
+
+// Supervisor launches configured stdio MCP servers as child processes,
+// checks their health and restarts them when they crash
+type Supervisor struct {
+	mu      sync.Mutex
+	servers map[string]*supervised
+	events  chan struct{}
+}
+
+// supervisorMsg reports that the state of a supervised server changed
+type supervisorMsg struct{}
+
+// NewSupervisor returns a supervisor with no servers running
+func NewSupervisor() *Supervisor {
+	return &Supervisor{servers: map[string]*supervised{}, events: make(chan struct{}, 1)}
+}
+
+// notify signals a state change without blocking
+func (s *Supervisor) notify() {
+	select {
+	case s.events <- struct{}{}:
+	default:
+	}
+}
+
+// waitForSupervisor delivers the next state change to the UI
+func waitForSupervisor(s *Supervisor) tea.Cmd {
+	return func() tea.Msg {
+		<-s.events
+		return supervisorMsg{}
+	}
+}
+
+// Status returns the state of a server; unknown servers are stopped
+func (s *Supervisor) Status(name string) ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sv, ok := s.servers[name]; ok {
+		return sv.status
+	}
+	return ServerStatus{State: ServerStopped}
+}
+
+// Statuses returns the state of every server that was ever started
+func (s *Supervisor) Statuses() map[string]ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make(map[string]ServerStatus, len(s.servers))
+	for name, sv := range s.servers {
+		statuses[name] = sv.status
+	}
+	return statuses
+}
+
+// Client returns the connection to a running server, or nil
+func (s *Supervisor) Client(name string) *MCPClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sv, ok := s.servers[name]; ok && sv.client != nil {
+		return sv.client
+	}
+	return nil
+}
+
+// Start launches a server in the background
+func (s *Supervisor) Start(name string, cfg MCPServerConfig) error {
+	if cfg.URL != "" {
+		return fmt.Errorf("%s is a remote server, there is no process to start", name)
+	}
+	s.mu.Lock()
+	sv, ok := s.servers[name]
+	if !ok {
+		sv = &supervised{}
+		s.servers[name] = sv
+	}
+	if sv.status.Active() {
+		s.mu.Unlock()
+		return fmt.Errorf("%s is already %s", name, sv.status.State)
+	}
+	sv.cfg = cfg
+	sv.autoRestarts = 0
+	sv.generation++
+	sv.status = ServerStatus{State: ServerStarting, Restarts: sv.status.Restarts}
+	gen := sv.generation
+	s.mu.Unlock()
+	s.notify()
+	go s.run(name, sv, gen)
+	return nil
+}
+
+// Stop terminates a server
+func (s *Supervisor) Stop(name string) error {
+	s.mu.Lock()
+	sv, ok := s.servers[name]
+	if !ok || !sv.status.Active() {
+		s.mu.Unlock()
+		return fmt.Errorf("%s is not running", name)
+	}
+	sv.generation++
+	client := sv.client
+	sv.client = nil
+	sv.status = ServerStatus{State: ServerStopped, Restarts: sv.status.Restarts}
+	s.mu.Unlock()
+	s.notify()
+	if client != nil {
+		go client.Close()
+	}
+	return nil
+}
+
+// Restart stops a server if it runs and starts it again
+func (s *Supervisor) Restart(name string, cfg MCPServerConfig) error {
+	if cfg.URL != "" {
+		return fmt.Errorf("%s is a remote server, there is no process to restart", name)
+	}
+	s.mu.Lock()
+	sv, ok := s.servers[name]
+	if !ok {
+		sv = &supervised{}
+		s.servers[name] = sv
+	}
+	sv.generation++
+	old := sv.client
+	sv.client = nil
+	sv.cfg = cfg
+	sv.autoRestarts = 0
+	sv.status = ServerStatus{State: ServerStarting, Restarts: sv.status.Restarts + 1}
+	gen := sv.generation
+	s.mu.Unlock()
+	s.notify()
+	go func() {
+		if old != nil {
+			old.Close()
+		}
+		s.run(name, sv, gen)
+	}()
+	return nil
+}
+
+// StopAll terminates every server and waits for the processes to exit
+func (s *Supervisor) StopAll() {
+	s.mu.Lock()
+	var clients []*MCPClient
+	for _, sv := range s.servers {
+		sv.generation++
+		if sv.client != nil {
+			clients = append(clients, sv.client)
+			sv.client = nil
+		}
+		sv.status.State = ServerStopped
+	}
+	s.mu.Unlock()
+	for _, client := range clients {
+		client.Close()
+	}
+}
+
+// run connects to a freshly launched server and watches it until it
+// exits or generation gen is superseded
+func (s *Supervisor) run(name string, sv *supervised, gen int) {
+	client, err := ConnectMCP(context.Background(), sv.cfg)
+	s.mu.Lock()
+	if sv.generation != gen {
+		s.mu.Unlock()
+		if client != nil {
+			client.Close()
+		}
+		return
+	}
+	if err != nil {
+		sv.status.State, sv.status.Error = ServerCrashed, err.Error()
+		s.mu.Unlock()
+		s.notify()
+		s.scheduleRestart(name, sv, gen)
+		return
+	}
+	sv.client = client
+	sv.status.State, sv.status.PID, sv.status.Started, sv.status.Error = ServerRunning, client.PID(), time.Now(), ""
+	s.mu.Unlock()
+	s.notify()
+
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.Done():
+			reason := client.ExitReason()
+			s.mu.Lock()
+			if sv.generation != gen {
+				s.mu.Unlock()
+				return
+			}
+			sv.client = nil
+			sv.status.State, sv.status.Error = ServerCrashed, reason
+			s.mu.Unlock()
+			s.notify()
+			s.scheduleRestart(name, sv, gen)
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+			err := client.Ping(ctx)
+			cancel()
+			s.mu.Lock()
+			if sv.generation != gen {
+				s.mu.Unlock()
+				return
+			}
+			before := sv.status.State
+			if err != nil {
+				sv.status.State, sv.status.Error = ServerUnhealthy, err.Error()
+			} else {
+				sv.status.State, sv.status.Error = ServerRunning, ""
+			}
+			changed := before != sv.status.State
+			s.mu.Unlock()
+			if changed {
+				s.notify()
+			}
+		}
+	}
+}
+
+// scheduleRestart restarts a crashed server after a growing delay,
+// giving up after maxAutoRestarts attempts
+func (s *Supervisor) scheduleRestart(name string, sv *supervised, gen int) {
+	s.mu.Lock()
+	if sv.autoRestarts >= maxAutoRestarts {
+		s.mu.Unlock()
+		return
+	}
+	sv.autoRestarts++
+	delay := time.Duration(sv.autoRestarts) * 2 * time.Second
+	s.mu.Unlock()
+	time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		if sv.generation != gen || sv.status.State != ServerCrashed {
+			s.mu.Unlock()
+			return
+		}
+		sv.generation++
+		sv.status.State = ServerStarting
+		sv.status.Restarts++
+		next := sv.generation
+		s.mu.Unlock()
+		s.notify()
+		s.run(name, sv, next)
+	})
+}
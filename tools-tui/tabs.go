@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// areaTab is a major area of the TUI with a screen of its own, listed in
+// the tab bar and switched to with the number keys
+type areaTab struct {
+	name string
+	// screens belong to the tab, the first is the one it opens
+	screens []screen
+	open    func(m *Model) tea.Cmd
+}
+
+// areaTabs are the tabs in the order of the keys that switch to them
+var areaTabs = []areaTab{
+	{"Tools", []screen{screenTools}, func(m *Model) tea.Cmd {
+		m.screen = screenTools
+		return nil
+	}},
+	{"MCP", []screen{screenMCP}, (*Model).openMCP},
+	{"Extensions", []screen{screenExtensions}, (*Model).openExtensions},
+	{"History", []screen{screenHistory}, func(m *Model) tea.Cmd {
+		m.openHistory()
+		return nil
+	}},
+	{"Logs", []screen{screenPanes, screenLog}, func(m *Model) tea.Cmd {
+		if len(m.jobs) == 0 {
+			return m.showToast(fmt.Sprintf("No logs yet, execute a tool with '%s' first", primaryKey(m.keys.Execute)))
+		}
+		return m.openPanes()
+	}},
+}
+
+// currentTab returns the tab of the current screen, -1 for screens
+// outside the tabs such as the file manager
+func (m Model) currentTab() int {
+	for i, tab := range areaTabs {
+		for _, s := range tab.screens {
+			if s == m.screen {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// typing reports whether a text input of the current screen has focus,
+// when the number keys are text rather than tab switches
+func (m Model) typing() bool {
+	switch m.screen {
+	case screenTools:
+		return m.searchMode || m.annotating || m.argsForm.active || m.palette.active || m.recent.active ||
+			m.examplePicker.active || m.specPicker.active || m.confirmRun || m.confirmQuiet
+	case screenMCP:
+		return m.mcp.form.active
+	case screenHistory:
+		return m.history.annotating || m.history.promoting || m.history.searching
+	case screenLog:
+		return m.logView.searching
+	}
+	return false
+}
+
+// tabKey returns the tab a key switches to: the nth key of the Tabs
+// binding switches to the nth tab, from any tab screen
+func (m Model) tabKey(msg tea.KeyMsg) (int, bool) {
+	if !key.Matches(msg, m.keys.Tabs) || m.currentTab() < 0 || m.typing() {
+		return 0, false
+	}
+	for i, k := range m.keys.Tabs.Keys() {
+		if k == msg.String() && i < len(areaTabs) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// switchTab opens the screen of a tab; switching to the current tab
+// leaves its screen as it is
+func (m *Model) switchTab(i int) tea.Cmd {
+	if i == m.currentTab() {
+		return nil
+	}
+	return areaTabs[i].open(m)
+}
+
+// tabsHint describes the Tabs binding for footers as its first and
+// last key
+func tabsHint(b key.Binding) string {
+	keys := b.Keys()
+	if len(keys) < 2 {
+		return hint("switch tab", b)
+	}
+	return keyLabel(keys[0]) + "-" + keyLabel(keys[len(keys)-1]) + ": tabs"
+}
+
+// renderTabs renders the tab bar with the current tab highlighted
+func (m Model) renderTabs() string {
+	current := m.currentTab()
+	keys := m.keys.Tabs.Keys()
+	var tabs []string
+	for i, tab := range areaTabs {
+		label := tab.name
+		if i < len(keys) {
+			label = keys[i] + " " + label
+		}
+		if i == current {
+			tabs = append(tabs, selectedItemStyle.Copy().Bold(true).Underline(true).Render(" "+label+" "))
+		} else {
+			tabs = append(tabs, helpStyle.Render(" "+label+" "))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(max(m.width, 20)).Render(strings.Join(tabs, helpStyle.Render("│")))
+}
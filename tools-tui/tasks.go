@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"tools-tui/control"
+)
+
+// spinnerFrames animate running tasks, one frame per elapsed-time tick
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// daemonLogLines is how many of the last lines of a daemon job's output
+// the task list shows
+const daemonLogLines = 10
+
+// tasksView holds the state of the task list screen. The jobs of a
+// running daemon follow the TUI's own tasks, newest first; daemonLog is
+// the output of the one last opened.
+type tasksView struct {
+	cursor    int
+	message   string
+	attached  bool
+	daemon    []control.Job
+	daemonLog string
+}
+
+// daemonJobsMsg carries the jobs of the daemon; attached is false when
+// no daemon listens on the control socket
+type daemonJobsMsg struct {
+	attached bool
+	jobs     []control.Job
+	err      error
+}
+
+// daemonLogsMsg carries the output of a daemon job
+type daemonLogsMsg struct {
+	job    control.Job
+	output string
+	err    error
+}
+
+// daemonJobsCmd asks the daemon for its jobs in the background
+func daemonJobsCmd() tea.Cmd {
+	return func() tea.Msg {
+		client, err := control.Dial(controlSocketPath())
+		if err != nil {
+			return daemonJobsMsg{}
+		}
+		defer client.Close()
+		jobs, err := client.Jobs()
+		for i, j := 0, len(jobs)-1; i < j; i, j = i+1, j-1 {
+			jobs[i], jobs[j] = jobs[j], jobs[i]
+		}
+		return daemonJobsMsg{attached: true, jobs: jobs, err: err}
+	}
+}
+
+// daemonLogsCmd fetches the output of a daemon job so far
+func daemonLogsCmd(id int) tea.Cmd {
+	return func() tea.Msg {
+		client, err := control.Dial(controlSocketPath())
+		if err != nil {
+			return daemonLogsMsg{err: err}
+		}
+		defer client.Close()
+		var output strings.Builder
+		job, err := client.Logs(id, false, func(chunk string) error {
+			output.WriteString(chunk)
+			return nil
+		})
+		return daemonLogsMsg{job: job, output: output.String(), err: err}
+	}
+}
+
+// canStart reports whether a job of tool can start now: a pane is free
+// of running jobs and the tool is not running already
+func (m Model) canStart(tool *Tool) bool {
+	if m.runningJobs() >= m.maxPanes {
+		return false
+	}
+	job := m.toolJob(tool)
+	return job == nil || job.done
+}
+
+// enqueue adds a job to the queue of tasks waiting for a pane
+func (m *Model) enqueue(run *runningTool) {
+	m.nextJobID++
+	run.id = m.nextJobID
+	m.queue = append(m.queue, run)
+}
+
+// dequeue removes the queued task at index i without running it
+func (m *Model) dequeue(i int) {
+	run := m.queue[i]
+	m.queue = append(m.queue[:i], m.queue[i+1:]...)
+	if run.id == m.detailJob {
+		m.detailJob = 0
+	}
+	m.statusMessage = fmt.Sprintf("Removed %s from the queue", run.tool.Name)
+	m.tasks.message = m.statusMessage
+	if m.tasks.cursor >= len(m.taskList()) && m.tasks.cursor > 0 {
+		m.tasks.cursor--
+	}
+}
+
+// startQueued starts queued tasks in order while panes are free, skipping
+// tasks whose tool is still running
+func (m *Model) startQueued() tea.Cmd {
+	var cmds []tea.Cmd
+	waiting := m.queue[:0:0]
+	for _, run := range m.queue {
+		if m.canStart(run.tool) {
+			cmds = append(cmds, m.startJob(run))
+		} else {
+			waiting = append(waiting, run)
+		}
+	}
+	m.queue = waiting
+	return tea.Batch(cmds...)
+}
+
+// queuedIndex returns the position of the task with the given id in the
+// queue, or -1
+func (m Model) queuedIndex(id int) int {
+	for i, run := range m.queue {
+		if run.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// currentQueued is the queued task ctrl+c removes: the selected task on
+// the task list, otherwise the task attached to the detail view. It is
+// -1 when that task is not queued.
+func (m Model) currentQueued() int {
+	switch {
+	case m.screen == screenTasks:
+		return m.queuedIndex(m.selectedTaskID())
+	case m.screen == screenTools && m.detailMode && m.detailJob != 0:
+		return m.queuedIndex(m.detailJob)
+	}
+	return -1
+}
+
+// taskList returns the jobs that have a pane followed by the queued tasks
+func (m Model) taskList() []*runningTool {
+	tasks := append([]*runningTool(nil), m.jobs...)
+	return append(tasks, m.queue...)
+}
+
+// selectedTaskID returns the id of the task under the cursor, or 0
+func (m Model) selectedTaskID() int {
+	tasks := m.taskList()
+	if m.tasks.cursor < len(tasks) {
+		return tasks[m.tasks.cursor].id
+	}
+	return 0
+}
+
+// openTasks shows the task list
+func (m *Model) openTasks() tea.Cmd {
+	if m.tasks.cursor >= len(m.taskList()) {
+		m.tasks.cursor = 0
+	}
+	m.tasks.message = ""
+	m.screen = screenTasks
+	return daemonJobsCmd()
+}
+
+// updateTasks handles keys on the task list; ctrl+c on a task is handled
+// before the screens see it
+func (m Model) updateTasks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	tasks := m.taskList()
+	v := &m.tasks
+	switch msg := msg.(type) {
+	case daemonJobsMsg:
+		// without a daemon the section is left out
+		v.attached, v.daemon = msg.attached, msg.jobs
+		if msg.err != nil {
+			v.message = "Could not list the daemon's jobs: " + msg.err.Error()
+		}
+		v.cursor = min(v.cursor, max(len(tasks)+len(v.daemon)-1, 0))
+		return m, nil
+	case daemonLogsMsg:
+		if msg.err != nil {
+			v.message = "Could not read the daemon's logs: " + msg.err.Error()
+			return m, nil
+		}
+		v.daemonLog = msg.output
+		v.message = fmt.Sprintf("Output of daemon job %d, %s: %s", msg.job.ID, msg.job.Tool, msg.job.State)
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	v.message = ""
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(tasks)+len(v.daemon)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Refresh):
+		return m, daemonJobsCmd()
+	case key.Matches(keyMsg, m.keys.Enter):
+		if i := v.cursor - len(tasks); i >= 0 && i < len(v.daemon) {
+			return m, daemonLogsCmd(v.daemon[i].ID)
+		}
+		if v.cursor >= len(tasks) {
+			return m, nil
+		}
+		if job := m.job(tasks[v.cursor].id); job != nil {
+			return m, m.openPanesAt(job)
+		}
+		v.message = fmt.Sprintf("%s has not started yet", tasks[v.cursor].tool.Name)
+	case key.Matches(keyMsg, m.keys.ClosePane):
+		if v.cursor >= len(tasks) {
+			return m, nil
+		}
+		task := tasks[v.cursor]
+		if !task.done {
+			v.message = fmt.Sprintf("%s has not finished, ctrl+c cancels it", task.tool.Name)
+			return m, nil
+		}
+		for i, job := range m.jobs {
+			if job == task {
+				m.removeJob(i)
+			}
+		}
+		if v.cursor >= len(m.taskList()) && v.cursor > 0 {
+			v.cursor--
+		}
+	}
+	return m, nil
+}
+
+// taskState renders the status of a task for the task list
+func (m Model) taskState(task *runningTool) string {
+	if m.queuedIndex(task.id) >= 0 {
+		return fmt.Sprintf("… queued #%d", m.queuedIndex(task.id)+1)
+	}
+	switch {
+	case task.done && task.err != nil:
+		return fmt.Sprintf("✘ exit %d · %s", exitCode(task.err), task.Elapsed())
+	case task.done:
+		return fmt.Sprintf("✔ done · %s", task.Elapsed())
+	case task.cancelled:
+		return fmt.Sprintf("%s cancelling · %s", spinner(), task.runningFor())
+	}
+	return fmt.Sprintf("%s running · %s", spinner(), task.runningFor())
+}
+
+// renderTasks lists the queued, running and finished tasks with their
+// status and the last line of their output
+func (m Model) renderTasks() string {
+	tasks := m.taskList()
+	var content strings.Builder
+	title := titleStyle.Render("📋 Tasks")
+	status := statusStyle.Render(fmt.Sprintf("%d running | %d queued | %d panes", m.runningJobs(), len(m.queue), m.maxPanes))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	if len(tasks) == 0 {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("No tasks, run a tool with %s to start one", primaryKey(m.keys.Execute))))
+		content.WriteString("\n")
+	}
+	width := max(m.width-6, 20)
+	for i, task := range tasks {
+		name := task.tool.Name
+		if task.projectDir != "" {
+			name += " @ " + task.projectDir
+		}
+		line := fmt.Sprintf("%-28s %s", truncate(name, 28), m.taskState(task))
+		if i == m.tasks.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+		if task.output != "" {
+			lines := strings.Split(strings.TrimRight(task.display(), "\n"), "\n")
+			content.WriteString(helpStyle.Copy().PaddingLeft(4).Render(truncate(lines[len(lines)-1], width)))
+			content.WriteString("\n")
+		}
+	}
+	if m.tasks.attached {
+		content.WriteString(descriptionStyle.Bold(true).Render("Daemon · " + controlSocketPath()))
+		content.WriteString(helpStyle.Render(fmt.Sprintf(" %d jobs", len(m.tasks.daemon))))
+		content.WriteString("\n")
+		for i, job := range m.tasks.daemon {
+			line := fmt.Sprintf("#%-4d %-22s %s", job.ID, truncate(job.Tool, 22), daemonJobState(job))
+			if job.Origin != "" {
+				line += " · " + job.Origin
+			}
+			if len(tasks)+i == m.tasks.cursor {
+				content.WriteString(selectedItemStyle.Render("▶ " + line))
+			} else {
+				content.WriteString("  " + line)
+			}
+			content.WriteString("\n")
+		}
+	}
+	if m.tasks.daemonLog != "" {
+		lines := strings.Split(strings.TrimRight(m.tasks.daemonLog, "\n"), "\n")
+		for _, line := range lines[max(len(lines)-daemonLogLines, 0):] {
+			content.WriteString(helpStyle.Copy().PaddingLeft(4).Render(truncate(line, width)))
+			content.WriteString("\n")
+		}
+	}
+	content.WriteString("\n")
+	if m.tasks.message != "" {
+		content.WriteString(featureStyle.Render(m.tasks.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("open pane", k.Enter), hint("close finished", k.ClosePane), hint("daemon jobs", k.Refresh), "ctrl+c: cancel/unqueue", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
+
+// daemonJobState renders the status of a daemon job for the task list
+func daemonJobState(job control.Job) string {
+	switch {
+	case !job.Done():
+		return fmt.Sprintf("%s running · %s", spinner(), time.Since(job.Started).Round(time.Second))
+	case job.State == control.StateSucceeded:
+		return fmt.Sprintf("✔ done · %s", job.Finished.Sub(job.Started).Round(time.Millisecond))
+	}
+	return fmt.Sprintf("✘ %s · %s", job.State, job.Finished.Sub(job.Started).Round(time.Millisecond))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// screenTitles name the screens in the terminal title
+var screenTitles = map[screen]string{
+	screenTools:       "Tools",
+	screenFootprint:   "Disk footprint",
+	screenMaintenance: "Maintenance",
+	screenFiles:       "Files",
+	screenNotes:       "Notes",
+	screenProjects:    "Projects",
+	screenHistory:     "History",
+	screenWorkflows:   "Workflows",
+	screenCheatSheet:  "Help",
+	screenPanes:       "Output panes",
+	screenMCP:         "MCP servers",
+	screenHealth:      "Health",
+	screenTasks:       "Tasks",
+	screenLog:         "Log",
+	screenGitHub:      "GitHub",
+	screenLinear:      "Linear",
+	screenEvents:      "Events",
+	screenSecrets:     "Secrets",
+	screenMemory:      "Memory",
+	screenAISessions:  "AI Sessions",
+	screenGit:         "Git",
+	screenHTTP:        "Requests",
+	screenScheduled:   "Scheduled",
+}
+
+// progressDelay is how long a job runs before the terminal shows
+// progress, so quick runs do not flash the tab
+const progressDelay = 2 * time.Second
+
+// OSC 9;4 progress states: clear the indicator, or show activity
+// without a percentage. Terminals without support ignore them.
+const (
+	progressNone   = "0"
+	progressActive = "3"
+)
+
+// terminalState is what was last written to the terminal title and
+// progress indicator
+type terminalState struct {
+	title    string
+	progress string
+}
+
+// terminalTitle names the view and the running jobs, so the tab tells
+// what the TUI is doing while the window is in the background
+func (m Model) terminalTitle() string {
+	view := screenTitles[m.screen]
+	if m.screen == screenTools && m.detailMode && m.selectedTool != nil {
+		view = m.selectedTool.Name
+	}
+	title := "tools-tui: " + view
+	var running []string
+	for _, job := range m.jobs {
+		if !job.done {
+			running = append(running, job.tool.Name)
+		}
+	}
+	switch len(running) {
+	case 0:
+	case 1:
+		title = "⏳ " + running[0] + " · " + title
+	default:
+		title = fmt.Sprintf("⏳ %s +%d · %s", running[0], len(running)-1, title)
+	}
+	return title
+}
+
+// terminalProgress returns the progress state for the running jobs.
+// Reduced motion leaves the indicator off, since terminals animate it.
+func (m Model) terminalProgress() string {
+	if reducedMotion {
+		return progressNone
+	}
+	for _, job := range m.jobs {
+		if !job.done && time.Since(job.started) >= progressDelay {
+			return progressActive
+		}
+	}
+	return progressNone
+}
+
+// syncTerminal updates the terminal title and progress indicator when
+// they changed since they were last written
+func (m *Model) syncTerminal() tea.Cmd {
+	var cmds []tea.Cmd
+	if title := m.terminalTitle(); title != m.terminal.title {
+		m.terminal.title = title
+		cmds = append(cmds, tea.SetWindowTitle(title))
+	}
+	if progress := m.terminalProgress(); progress != m.terminal.progress {
+		m.terminal.progress = progress
+		cmds = append(cmds, setProgress(progress))
+	}
+	return tea.Batch(cmds...)
+}
+
+// setProgress writes an OSC 9;4 progress sequence to the terminal
+func setProgress(state string) tea.Cmd {
+	return func() tea.Msg {
+		writeProgress(state)
+		return nil
+	}
+}
+
+// writeProgress writes a progress state to the terminal right away
+func writeProgress(state string) {
+	fmt.Fprintf(os.Stdout, "\x1b]9;4;%s\x07", state)
+}
+
+// resetTerminal clears the title and the progress of jobs that were
+// still running when the TUI exited
+func resetTerminal() {
+	writeProgress(progressNone)
+	fmt.Fprint(os.Stdout, "\x1b]2;\x07")
+}
@@ -0,0 +1,62 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+// Theme holds the colours used by the TUI styles
+type Theme struct {
+	Primary   string `json:"primary"`
+	Accent    string `json:"accent"`
+	Highlight string `json:"highlight"`
+	Text      string `json:"text"`
+	Feature   string `json:"feature"`
+	Command   string `json:"command"`
+	Surface   string `json:"surface"`
+	Muted     string `json:"muted"`
+	Warning   string `json:"warning"`
+}
+
+// defaultTheme matches the styles declared in ui.go
+var defaultTheme = Theme{
+	Primary:   "#7D56F4",
+	Accent:    "#F25D94",
+	Highlight: "#EE6FF8",
+	Text:      "#DFDFDF",
+	Feature:   "#7FD5F2",
+	Command:   "#A8F0A0",
+	Surface:   "#1A1A1A",
+	Muted:     "#626262",
+	Warning:   "#F2C94C",
+}
+
+// withDefaults fills unset colours from the default theme
+func (t Theme) withDefaults() Theme {
+	fill := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	fill(&t.Primary, defaultTheme.Primary)
+	fill(&t.Accent, defaultTheme.Accent)
+	fill(&t.Highlight, defaultTheme.Highlight)
+	fill(&t.Text, defaultTheme.Text)
+	fill(&t.Feature, defaultTheme.Feature)
+	fill(&t.Command, defaultTheme.Command)
+	fill(&t.Surface, defaultTheme.Surface)
+	fill(&t.Muted, defaultTheme.Muted)
+	fill(&t.Warning, defaultTheme.Warning)
+	return t
+}
+
+// applyTheme recolours the package level styles
+func applyTheme(t Theme) {
+	t = t.withDefaults()
+	titleStyle = titleStyle.Copy().Background(lipgloss.Color(t.Primary))
+	statusStyle = statusStyle.Copy().Background(lipgloss.Color(t.Accent))
+	selectedItemStyle = selectedItemStyle.Copy().Foreground(lipgloss.Color(t.Highlight))
+	descriptionStyle = descriptionStyle.Copy().Foreground(lipgloss.Color(t.Text))
+	featureStyle = featureStyle.Copy().Foreground(lipgloss.Color(t.Feature))
+	commandStyle = commandStyle.Copy().Foreground(lipgloss.Color(t.Command)).Background(lipgloss.Color(t.Surface))
+	helpStyle = helpStyle.Copy().Foreground(lipgloss.Color(t.Muted))
+	footerStyle = footerStyle.Copy().Foreground(lipgloss.Color(t.Text)).Background(lipgloss.Color(t.Surface))
+	warningStyle = warningStyle.Copy().Foreground(lipgloss.Color(t.Surface)).Background(lipgloss.Color(t.Warning))
+}
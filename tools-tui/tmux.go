@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TmuxMode launches executions in tmux instead of the output panes
+type TmuxMode string
+
+const (
+	TmuxOff    TmuxMode = ""
+	TmuxPane   TmuxMode = "pane"
+	TmuxWindow TmuxMode = "window"
+)
+
+// tmuxPollInterval is how often the output logged by a tmux job is read
+const tmuxPollInterval = 250 * time.Millisecond
+
+// validate reports an unknown tmux mode
+func (t TmuxMode) validate() error {
+	switch t {
+	case TmuxOff, TmuxPane, TmuxWindow:
+		return nil
+	}
+	return fmt.Errorf("unknown tmux mode %q, use pane or window", t)
+}
+
+// usable reports whether executions go to tmux: the mode is set and the
+// TUI runs inside a tmux session
+func (t TmuxMode) usable() bool {
+	return t != TmuxOff && os.Getenv("TMUX") != ""
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tmuxCommand opens a tmux pane or window running the tool. The command's
+// output is shown there and logged to a file the job follows; the
+// returned process waits for the command and exits with its status.
+// The tool's environment, secrets included, is passed in a private file
+// the pane's shell sources and deletes, so it is not in any argv.
+// cleanup must be called once the process has exited.
+func tmuxCommand(ctx context.Context, tool *Tool, projectDir string, mode TmuxMode) (*exec.Cmd, string, func(), error) {
+	dir, fields, err := tool.argv(scopedCommand(tool, projectDir))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	tmp, err := os.MkdirTemp("", "tools-tui-tmux-")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	logPath, statusPath, envPath := filepath.Join(tmp, "output"), filepath.Join(tmp, "status"), filepath.Join(tmp, "env")
+	channel := filepath.Base(tmp)
+	var exports strings.Builder
+	for _, entry := range append(tool.envList(), recordedEnv+"=1") {
+		exports.WriteString("export " + shellQuote(entry) + "\n")
+	}
+	if err := os.WriteFile(envPath, []byte(exports.String()), 0600); err != nil {
+		os.RemoveAll(tmp)
+		return nil, "", nil, err
+	}
+	for i, field := range fields {
+		fields[i] = shellQuote(field)
+	}
+	script := fmt.Sprintf(`. %s; rm -f %s; { %s; echo $? > %s; } 2>&1 | tee %s; tmux wait-for -S %s; echo "[exit $(cat %s)] enter closes"; read _`,
+		shellQuote(envPath), shellQuote(envPath), strings.Join(fields, " "), shellQuote(statusPath), shellQuote(logPath), channel, shellQuote(statusPath))
+
+	args := []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-c", dir}
+	if mode == TmuxWindow {
+		args = []string{"new-window", "-d", "-P", "-F", "#{pane_id}", "-c", dir, "-n", tool.Name}
+	}
+	out, err := exec.Command("tmux", append(args, "sh", "-c", script)...).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, "", nil, fmt.Errorf("tmux %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	pane := strings.TrimSpace(string(out))
+
+	wait := exec.CommandContext(ctx, "sh", "-c", fmt.Sprintf("tmux wait-for %s; exit $(cat %s 2>/dev/null || echo 1)", channel, shellQuote(statusPath)))
+	cleanup := func() {
+		if ctx.Err() != nil {
+			exec.Command("tmux", "kill-pane", "-t", pane).Run()
+			// releases the wait-for left behind by the killed shell
+			exec.Command("tmux", "wait-for", "-S", channel).Run()
+		}
+		os.RemoveAll(tmp)
+	}
+	return wait, logPath, cleanup, nil
+}
+
+// StreamTmux runs a tool in a new tmux pane or window and streams what
+// it logs to the job, so the job is tracked like any other while the
+// output keeps tmux's native scrollback
+func StreamTmux(ctx context.Context, tool *Tool, projectDir string, mode TmuxMode) <-chan tea.Msg {
+	ch := make(chan tea.Msg, 64)
+	go func() {
+		defer close(ch)
+		cmd, logPath, cleanup, err := tmuxCommand(ctx, tool, projectDir, mode)
+		if err != nil {
+			ch <- streamDoneMsg{err: err}
+			return
+		}
+		defer cleanup()
+		w := &streamWriter{ch: ch}
+		stop, followed := make(chan struct{}), make(chan struct{})
+		go func() {
+			followFile(logPath, w, stop)
+			close(followed)
+		}()
+		err = cmd.Run()
+		close(stop)
+		<-followed
+		if ctx.Err() != nil {
+			err = fmt.Errorf("cancelled")
+		}
+		output, err := injectFault(w.String(), err)
+		ch <- streamDoneMsg{output: output, err: err}
+	}()
+	return ch
+}
+
+// followFile copies what is appended to path to w until stop is closed,
+// then copies the rest
+func followFile(path string, w io.Writer, stop <-chan struct{}) {
+	var offset int64
+	read := func() {
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+		n, _ := io.Copy(w, f)
+		offset += n
+	}
+	ticker := time.NewTicker(tmuxPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			read()
+			return
+		case <-ticker.C:
+			read()
+		}
+	}
+}
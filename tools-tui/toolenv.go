@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RunDir returns the directory the tool's command runs in unless it is
+// scoped to a project: its Dir, relative to the repository root
+func (t *Tool) RunDir() string {
+	if t.Dir == "" {
+		return t.WorkDir()
+	}
+	return resolvePath(t.WorkDir(), t.Dir)
+}
+
+// envList returns the tool's Env as sorted NAME=value entries, with
+// $NAME references in the values expanded from the TUI's environment
+func (t *Tool) envList() []string {
+	names := make([]string, 0, len(t.Env))
+	for name := range t.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make([]string, len(names))
+	for i, name := range names {
+		env[i] = name + "=" + os.ExpandEnv(t.Env[name])
+	}
+	return append(env, t.secretEnv()...)
+}
+
+// environ returns the environment of the tool's command: the TUI's
+// environment with the tool's Env added
+func (t *Tool) environ() []string {
+	return append(os.Environ(), t.envList()...)
+}
+
+// shellFlag returns the flag that makes a shell run a command string
+func shellFlag(shell string) string {
+	switch strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe") {
+	case "cmd":
+		return "/C"
+	case "powershell", "pwsh":
+		return "-Command"
+	}
+	return "-c"
+}
+
+// argv returns the directory, program and arguments that run command
+// in dir. With a Shell the command string is passed to it. Without one
+// the command is split into words honouring quotes; leading `cd dir`
+// steps of an && chain move into that directory and the remaining steps
+// run through the platform shell, each word quoted.
+func (t *Tool) argv(dir, command string) (string, []string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", nil, fmt.Errorf("empty command")
+	}
+	if t.Shell != "" {
+		return dir, []string{t.Shell, shellFlag(t.Shell), command}, nil
+	}
+	chain, err := commandChain(command)
+	if err != nil {
+		return "", nil, fmt.Errorf("%q: %v", command, err)
+	}
+	for len(chain) > 0 && chain[0][0] == "cd" {
+		if len(chain[0]) != 2 {
+			return "", nil, fmt.Errorf("%q: cd takes one directory", command)
+		}
+		dir = resolvePath(dir, chain[0][1])
+		chain = chain[1:]
+	}
+	switch len(chain) {
+	case 0:
+		return "", nil, fmt.Errorf("%q only changes directory", command)
+	case 1:
+		return dir, chain[0], nil
+	}
+	shell := platformShell()
+	return dir, []string{shell, shellFlag(shell), chainScript(shell, chain)}, nil
+}
+
+// RunsIn describes the Dir, Shell and Env of the tool for the detail
+// view, or "" when it has none. Whether its secrets are stored is read
+// from stored, the secret index, so rendering never reaches the keyring.
+func (t *Tool) RunsIn(stored map[string]SecretInfo) string {
+	var parts []string
+	if t.Dir != "" {
+		parts = append(parts, "in "+t.Dir)
+	}
+	if t.Shell != "" {
+		parts = append(parts, "with "+t.Shell)
+	}
+	if len(t.Env) > 0 {
+		names := make([]string, 0, len(t.Env))
+		for name := range t.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts = append(parts, "env "+strings.Join(names, ", "))
+	}
+	if len(t.Secrets) > 0 {
+		names := make([]string, 0, len(t.Secrets))
+		for name, service := range t.Secrets {
+			if _, ok := stored[service]; !ok {
+				service += ", not stored"
+			}
+			names = append(names, fmt.Sprintf("%s (%s)", name, service))
+		}
+		sort.Strings(names)
+		parts = append(parts, "secrets "+strings.Join(names, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// validateToolEnv reports problems with the Env, Dir and Shell of an
+// inventory entry
+func validateToolEnv(tool Tool) []string {
+	var problems []string
+	names := make([]string, 0, len(tool.Env))
+	for name := range tool.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "= \t") {
+			problems = append(problems, fmt.Sprintf("invalid env name %q", name))
+		}
+	}
+	names = names[:0]
+	for name := range tool.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "= \t") {
+			problems = append(problems, fmt.Sprintf("invalid secret env name %q", name))
+		}
+		if tool.Secrets[name] == "" {
+			problems = append(problems, fmt.Sprintf("secret %s names no service", name))
+		}
+		if _, ok := tool.Env[name]; ok {
+			problems = append(problems, fmt.Sprintf("%s is both in env and secrets", name))
+		}
+	}
+	if tool.Dir != "" && !filepath.IsLocal(tool.Dir) {
+		problems = append(problems, fmt.Sprintf("dir %q must be relative to the repository and stay inside it", tool.Dir))
+	}
+	if strings.ContainsAny(tool.Shell, " \t") {
+		problems = append(problems, fmt.Sprintf("shell %q must be a program, not a command line", tool.Shell))
+	}
+	if tool.Shell == "" && tool.Command != "" {
+		// placeholders look like redirects to the splitter
+		if _, err := commandChain(placeholderPattern.ReplaceAllString(tool.Command, "arg")); err != nil {
+			problems = append(problems, "command: "+err.Error())
+		}
+	}
+	return problems
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// toolSortsFile stores the sort mode chosen per category
+const toolSortsFile = "sort.json"
+
+// ToolSort orders the tools of a category after their relevance to the
+// project, which always comes first
+type ToolSort string
+
+const (
+	SortCatalog  ToolSort = ""
+	SortName     ToolSort = "name"
+	SortStatus   ToolSort = "status"
+	SortRecent   ToolSort = "recent"
+	SortFrequent ToolSort = "frequent"
+)
+
+// toolSorts are the modes in the order the sort key cycles through them
+var toolSorts = []ToolSort{SortCatalog, SortName, SortStatus, SortRecent, SortFrequent}
+
+// Next cycles catalog → name → status → recent → frequent
+func (s ToolSort) Next() ToolSort {
+	for i, mode := range toolSorts {
+		if mode == s {
+			return toolSorts[(i+1)%len(toolSorts)]
+		}
+	}
+	return SortCatalog
+}
+
+// Label describes the mode for the category header and toasts
+func (s ToolSort) Label() string {
+	switch s {
+	case SortName:
+		return "by name"
+	case SortStatus:
+		return "by status"
+	case SortRecent:
+		return "last used first"
+	case SortFrequent:
+		return "most used first"
+	}
+	return "catalog order"
+}
+
+// LoadToolSorts reads the sort modes chosen per category name
+func LoadToolSorts() map[string]ToolSort {
+	sorts := map[string]ToolSort{}
+	loadJSON(toolSortsFile, &sorts)
+	return sorts
+}
+
+// SaveToolSort persists the sort mode of a category; the catalog order
+// is the default and is not stored
+func SaveToolSort(category string, mode ToolSort) error {
+	sorts := LoadToolSorts()
+	if mode == SortCatalog {
+		delete(sorts, category)
+	} else {
+		sorts[category] = mode
+	}
+	return saveJSON(toolSortsFile, sorts)
+}
+
+// toolUsage is how often and when a tool was last run, from the history
+type toolUsage struct {
+	runs int
+	last time.Time
+}
+
+// loadToolUsage counts the runs of each tool name in the history
+func loadToolUsage() map[string]toolUsage {
+	usage := map[string]toolUsage{}
+	records, _ := LoadHistory()
+	for _, record := range records {
+		u := usage[record.Tool]
+		u.runs++
+		if record.Started.After(u.last) {
+			u.last = record.Started
+		}
+		usage[record.Tool] = u
+	}
+	return usage
+}
+
+// statusRank orders tools by how ready they are: passing probes and
+// installs first, then unprobed tools, tools still to install, failing
+// ones and last those unsupported on this platform
+func (m Model) statusRank(tool *Tool) int {
+	if tool.UnsupportedReason() != "" {
+		return 4
+	}
+	if record, ok := m.installs[tool.Key()]; ok {
+		if record.Installed {
+			return 0
+		}
+		return 3
+	}
+	if result, ok := m.probes[tool.Key()]; ok {
+		switch result.State {
+		case ProbePass:
+			return 0
+		case ProbeFail:
+			return 3
+		}
+	}
+	if strings.Contains(tool.Status, "Install") {
+		return 2
+	}
+	return 1
+}
+
+// sortLess orders two tools of the same relevance by mode, the catalog
+// order breaking ties
+func (m Model) sortLess(mode ToolSort, a, b *Tool, usage map[string]toolUsage) bool {
+	switch mode {
+	case SortName:
+		if na, nb := strings.ToLower(a.Name), strings.ToLower(b.Name); na != nb {
+			return na < nb
+		}
+	case SortStatus:
+		if ra, rb := m.statusRank(a), m.statusRank(b); ra != rb {
+			return ra < rb
+		}
+	case SortRecent:
+		if la, lb := usage[a.Name].last, usage[b.Name].last; !la.Equal(lb) {
+			return la.After(lb)
+		}
+	case SortFrequent:
+		if ra, rb := usage[a.Name].runs, usage[b.Name].runs; ra != rb {
+			return ra > rb
+		}
+	}
+	return m.catalogOrder[a.Key()] < m.catalogOrder[b.Key()]
+}
+
+// cycleToolSort switches the category under the cursor to the next sort
+// mode, keeping the cursor on its tool, and describes the result
+func (m *Model) cycleToolSort() string {
+	if m.currentCat >= len(m.categories) {
+		return "No category to sort"
+	}
+	category := m.categories[m.currentCat]
+	if category.Favorites {
+		return "Favorites keep the order they were added in"
+	}
+	mode := m.sorts[category.Name].Next()
+	if mode == SortCatalog {
+		delete(m.sorts, category.Name)
+	} else {
+		m.sorts[category.Name] = mode
+	}
+	m.resort()
+	message := fmt.Sprintf("%s: %s", category.Name, mode.Label())
+	if err := SaveToolSort(category.Name, mode); err != nil {
+		message += " (not saved: " + err.Error() + ")"
+	}
+	return message
+}
+
+// resort sorts the categories again, keeping the cursor on its tool
+func (m *Model) resort() {
+	var selected string
+	if tool := m.cursorTool(); tool != nil {
+		selected = tool.Key()
+	}
+	m.applyProjectRelevance()
+	if m.currentCat >= len(m.categories) {
+		return
+	}
+	for j, tool := range m.categories[m.currentCat].Tools {
+		if tool.Key() == selected {
+			m.currentTool = j
+		}
+	}
+}
+
+// sortsBy reports whether a category sorts by mode
+func (m Model) sortsBy(mode ToolSort) bool {
+	for _, s := range m.sorts {
+		if s == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tourFile remembers that the onboarding tour was completed or skipped
+const tourFile = "tour.json"
+
+// tourState is persisted so the tour only starts on the first run
+type tourState struct {
+	Completed bool `json:"completed"`
+}
+
+// tourStep is one stop of the onboarding tour. Region names the part
+// of the screen that is highlighted and setup puts the model into the
+// state the step talks about.
+type tourStep struct {
+	title  string
+	body   string
+	region string
+	keys   []string
+	setup  func(m *Model)
+}
+
+// tourView holds the progress through the tour
+type tourView struct {
+	active bool
+	step   int
+}
+
+// tourSteps is the script of the onboarding tour
+var tourSteps = []tourStep{
+	{
+		title:  "Welcome",
+		body:   "This TUI lists the OpenCode tools and plugins and runs them for you. The header shows how many tools are loaded and which project they run in.",
+		region: "header",
+		setup:  (*Model).tourReset,
+	},
+	{
+		title:  "Categories",
+		body:   "Tools are grouped into categories. Move through the list, collapse a category you do not need and open a tool to see what it does.",
+		region: "list",
+		keys:   []string{"↑/↓ move", "tab collapse", "enter open"},
+		setup:  (*Model).tourReset,
+	},
+	{
+		title:  "Details",
+		body:   "The detail view explains the tool, its trust tier and your notes. Trust tiers decide whether a command runs sandboxed.",
+		region: "detail",
+		keys:   []string{"t trust", "e annotate", "esc back"},
+		setup:  (*Model).tourOpenDetail,
+	},
+	{
+		title:  "Execution",
+		body:   "Running a tool streams its output below the details together with the elapsed time. Downloaded extensions ask for confirmation first.",
+		region: "execute",
+		keys:   []string{"x execute", "ctrl+c cancel a run", "a append output to notes"},
+		setup:  (*Model).tourOpenDetail,
+	},
+	{
+		title:  "Search",
+		body:   "Search filters as you type across names, purposes, descriptions, features and notes. Matches are highlighted; enter jumps to the tool.",
+		region: "search",
+		keys:   []string{"/ search", "↑/↓ select", "enter jump"},
+		setup:  (*Model).tourSearch,
+	},
+	{
+		title:  "That's it",
+		body:   "History, workflows, projects and maintenance are a key away as well. The help lists every key, and the tour can be replayed from there.",
+		region: "footer",
+		keys:   []string{"? help", "T replay tour"},
+		setup:  (*Model).tourReset,
+	},
+}
+
+// tourHighlightStyle frames the region a tour step talks about
+var tourHighlightStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("#FFD700"))
+
+// tourBoxStyle renders the tour's explanation box
+var tourBoxStyle = lipgloss.NewStyle().
+	Border(lipgloss.DoubleBorder()).
+	BorderForeground(lipgloss.Color("#FFD700")).
+	Padding(0, 1)
+
+// tourCompleted reports whether the tour was finished or skipped before
+func tourCompleted() bool {
+	var state tourState
+	loadJSON(tourFile, &state)
+	return state.Completed
+}
+
+// startTour shows the first step of the tour
+func (m *Model) startTour() {
+	m.tour = tourView{active: true}
+	m.screen = screenTools
+	tourSteps[0].setup(m)
+}
+
+// endTour leaves the tour and remembers not to start it again
+func (m *Model) endTour() {
+	m.tourReset()
+	m.tour.active = false
+	if err := saveJSON(tourFile, tourState{Completed: true}); err != nil {
+		m.statusMessage = fmt.Sprintf("Could not save tour state: %v", err)
+	}
+}
+
+// tourReset returns to the tool list
+func (m *Model) tourReset() {
+	m.closeSearch()
+	m.detailMode = false
+}
+
+// tourOpenDetail opens the detail view of the tool under the cursor
+func (m *Model) tourOpenDetail() {
+	m.closeSearch()
+	if m.detailMode || len(m.categories) == 0 {
+		return
+	}
+	category := m.categories[m.currentCat]
+	if m.currentTool < len(category.Tools) {
+		m.selectedTool = &category.Tools[m.currentTool]
+		m.detailMode = true
+		m.reloadExtensionStates()
+	}
+}
+
+// tourSearch shows the live search with a sample query
+func (m *Model) tourSearch() {
+	m.detailMode = false
+	m.searchMode = true
+	m.searchCursor = 0
+	m.searchInput.SetValue("test")
+}
+
+// updateTour handles keys while the tour is shown
+func (m Model) updateTour(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Back):
+		m.endTour()
+	case key.Matches(msg, m.keys.Right), key.Matches(msg, m.keys.Enter):
+		if m.tour.step == len(tourSteps)-1 {
+			m.endTour()
+			break
+		}
+		m.tour.step++
+		tourSteps[m.tour.step].setup(&m)
+	case key.Matches(msg, m.keys.Left):
+		if m.tour.step > 0 {
+			m.tour.step--
+			tourSteps[m.tour.step].setup(&m)
+		}
+	}
+	return m, nil
+}
+
+// tourHighlight frames s when the current tour step points at region
+func (m Model) tourHighlight(region, s string) string {
+	if !m.tour.active || tourSteps[m.tour.step].region != region {
+		return s
+	}
+	return tourHighlightStyle.Render(s)
+}
+
+// renderTour renders the explanation box of the current step
+func (m Model) renderTour() string {
+	step := tourSteps[m.tour.step]
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("🧭 Tour %d/%d · %s", m.tour.step+1, len(tourSteps), step.title)))
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.NewStyle().Width(70).Render(step.body))
+	content.WriteString("\n")
+	if len(step.keys) > 0 {
+		content.WriteString("\n")
+		content.WriteString(commandStyle.Render(strings.Join(step.keys, "  ·  ")))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	next := "→/enter: next"
+	if m.tour.step == len(tourSteps)-1 {
+		next = "→/enter: finish"
+	}
+	content.WriteString(helpStyle.Render(strings.Join([]string{next, "←: back", "esc: skip tour"}, " | ")))
+	return tourBoxStyle.Render(content.String())
+}
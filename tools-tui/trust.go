@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// TrustLevel describes how much a tool's command is trusted
+type TrustLevel string
+
+const (
+	TrustTrusted    TrustLevel = "trusted"
+	TrustReviewed   TrustLevel = "reviewed"
+	TrustDownloaded TrustLevel = "downloaded"
+)
+
+// trustOverridesFile stores tiers edited from the detail view
+const trustOverridesFile = "trust.json"
+
+// unsandboxedEnv lets downloaded tools run without bubblewrap when set
+// to 1, with only a scrubbed environment and a throwaway HOME, which
+// leave them the whole filesystem and the network
+const unsandboxedEnv = "OPENCODE_TUI_ALLOW_UNSANDBOXED"
+
+// errNoSandbox refuses a downloaded tool when bubblewrap is missing
+var errNoSandbox = fmt.Errorf("downloaded tools only run inside bubblewrap (bwrap), which is not installed; install it, or set %s=1 to run them with only a scrubbed environment", unsandboxedEnv)
+
+// SandboxMode is how commands of the downloaded tier run
+type SandboxMode string
+
+const (
+	// SandboxBwrap runs them in bubblewrap with a read-only filesystem
+	SandboxBwrap SandboxMode = "bwrap"
+	// SandboxEnvOnly only scrubs their environment, allowed by
+	// unsandboxedEnv when bubblewrap is missing
+	SandboxEnvOnly SandboxMode = "environment"
+	// SandboxRefused does not run them at all
+	SandboxRefused SandboxMode = ""
+)
+
+// hasBwrap looks bubblewrap up once, as tier labels are rendered often
+var hasBwrap = sync.OnceValue(func() bool { return onPath("bwrap") })
+
+// currentSandbox reports how downloaded tools run
+func currentSandbox() SandboxMode {
+	if hasBwrap() {
+		return SandboxBwrap
+	}
+	if os.Getenv(unsandboxedEnv) == "1" {
+		return SandboxEnvOnly
+	}
+	return SandboxRefused
+}
+
+// trustLevels lists tiers in the order they are cycled through
+var trustLevels = []TrustLevel{TrustTrusted, TrustReviewed, TrustDownloaded}
+
+// Next returns the tier that follows t when cycling
+func (t TrustLevel) Next() TrustLevel {
+	for i, level := range trustLevels {
+		if level == t {
+			return trustLevels[(i+1)%len(trustLevels)]
+		}
+	}
+	return TrustTrusted
+}
+
+// Valid reports whether t is one of the known tiers
+func (t TrustLevel) Valid() bool {
+	for _, level := range trustLevels {
+		if level == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Sandboxed reports whether commands at this tier run in the sandbox
+func (t TrustLevel) Sandboxed() bool {
+	return t == TrustDownloaded
+}
+
+// RequiresConfirmation reports whether execution must be confirmed first
+func (t TrustLevel) RequiresConfirmation() bool {
+	return t == TrustDownloaded
+}
+
+// Label returns a short human readable summary of the tier, saying
+// "sandboxed" only when bubblewrap actually confines the command
+func (t TrustLevel) Label() string {
+	switch t {
+	case TrustDownloaded:
+		switch currentSandbox() {
+		case SandboxBwrap:
+			return "🔒 downloaded (sandboxed, confirm before run)"
+		case SandboxEnvOnly:
+			return "⚠ downloaded (NOT sandboxed: no bwrap, scrubbed environment only, confirm before run)"
+		}
+		return "⛔ downloaded (refused: install bwrap to sandbox it)"
+	case TrustReviewed:
+		return "🔍 reviewed"
+	default:
+		return "✅ trusted"
+	}
+}
+
+// applyTrustDefaults fills in missing tiers and applies saved overrides
+func applyTrustDefaults(categories []Category) {
+	overrides := map[string]TrustLevel{}
+	if err := loadJSON(trustOverridesFile, &overrides); err != nil {
+		overrides = map[string]TrustLevel{}
+	}
+
+	for i := range categories {
+		for j := range categories[i].Tools {
+			tool := &categories[i].Tools[j]
+			if level, ok := overrides[tool.Key()]; ok {
+				tool.Trust = level
+			}
+			if tool.Trust == "" {
+				tool.Trust = TrustTrusted
+			}
+		}
+	}
+}
+
+// SaveTrustOverride persists the tier chosen for a tool
+func SaveTrustOverride(toolName string, level TrustLevel) error {
+	overrides := map[string]TrustLevel{}
+	if err := loadJSON(trustOverridesFile, &overrides); err != nil {
+		return err
+	}
+	overrides[toolName] = level
+	return saveJSON(trustOverridesFile, overrides)
+}
+
+// ExecuteTool runs a tool's command, sandboxing it when its tier requires.
+// Scoped tools run inside projectDir when one is selected.
+func ExecuteTool(tool *Tool, projectDir string) (string, error) {
+	cmd, cleanup, err := toolCommand(context.Background(), tool, projectDir)
+	if err != nil {
+		return injectFault("", err)
+	}
+	defer cleanup()
+	output, err := cmd.CombinedOutput()
+	return injectFault(string(output), err)
+}
+
+// ExecuteSandboxed runs a command in bubblewrap with a read-only
+// filesystem, a scrubbed environment and a throwaway HOME. Without
+// bubblewrap it is refused unless unsandboxedEnv allows the scrubbed
+// environment alone.
+func ExecuteSandboxed(dir, command string) (string, error) {
+	dir, parts, err := (&Tool{}).argv(dir, command)
+	if err != nil {
+		return "", err
+	}
+	cmd, cleanup, err := sandboxCommand(context.Background(), dir, parts, nil)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// sandboxCommand prepares a sandboxed process running parts, or fails
+// with errNoSandbox when bubblewrap is missing and not overridden. Only
+// env, the variables the tool declares, is added to the scrubbed
+// environment. cleanup removes the throwaway HOME and must be called
+// once the process has exited.
+func sandboxCommand(ctx context.Context, dir string, parts, env []string) (*exec.Cmd, func(), error) {
+	mode := currentSandbox()
+	if mode == SandboxRefused {
+		return nil, nil, errNoSandbox
+	}
+	home, err := os.MkdirTemp("", "opencode-sandbox-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(home) }
+
+	if mode == SandboxBwrap {
+		bwrap, _ := exec.LookPath("bwrap")
+		wrapped := []string{
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--bind", home, home,
+			"--bind", dir, dir,
+			"--die-with-parent",
+			"--",
+		}
+		parts = append([]string{bwrap}, append(wrapped, parts...)...)
+	}
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + home,
+		"LANG=" + os.Getenv("LANG"),
+		"TERM=dumb",
+	}
+	cmd.Env = append(cmd.Env, env...)
+	return cmd, cleanup, nil
+}
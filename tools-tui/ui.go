@@ -1,10 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -46,6 +47,12 @@ var (
 	footerStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#DFDFDF")).
 			Background(lipgloss.Color("#1A1A1A"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#1A1A1A")).
+			Background(lipgloss.Color("#F2C94C")).
+			Bold(true).
+			Padding(0, 1)
 )
 
 // KeyMap defines key bindings
@@ -61,20 +68,96 @@ type KeyMap struct {
 	Help           key.Binding
 	Quit           key.Binding
 	ToggleCategory key.Binding
-}
-
-// ShortHelp returns keybindings for the help menu
-func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit}
-}
-
-// FullHelp returns keybindings for the expanded help menu
-func (k KeyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{
-		{k.Up, k.Down, k.Left, k.Right},
-		{k.Enter, k.Back, k.Search, k.Execute},
-		{k.ToggleCategory, k.Help, k.Quit},
-	}
+	Trust          key.Binding
+	Auto           key.Binding
+	Confirm        key.Binding
+	Verify         key.Binding
+	Rollback       key.Binding
+	Changelog      key.Binding
+	Install        key.Binding
+	Upgrade        key.Binding
+	Footprint      key.Binding
+	Sort           key.Binding
+	Clean          key.Binding
+	Maintenance    key.Binding
+	DryRun         key.Binding
+	Delete         key.Binding
+	Files          key.Binding
+	FileCopy       key.Binding
+	FileMove       key.Binding
+	FileRename     key.Binding
+	Unpack         key.Binding
+	Package        key.Binding
+	Notes          key.Binding
+	AppendNote     key.Binding
+	Annotate       key.Binding
+	Project        key.Binding
+	Inapplicable   key.Binding
+	SortTools      key.Binding
+	History        key.Binding
+	Range          key.Binding
+	Filter         key.Binding
+	Compare        key.Binding
+	DiffOutput     key.Binding
+	Promote        key.Binding
+	Workflows      key.Binding
+	Scheduled      key.Binding
+	Retry          key.Binding
+	Override       key.Binding
+	Tour           key.Binding
+	Palette        key.Binding
+	Recent         key.Binding
+	Panes          key.Binding
+	NextPane       key.Binding
+	ClosePane      key.Binding
+	Tasks          key.Binding
+	MCP            key.Binding
+	ExpandLog      key.Binding
+	LogView        key.Binding
+	NextMatch      key.Binding
+	PrevMatch      key.Binding
+	Wrap           key.Binding
+	Export         key.Binding
+	Copy           key.Binding
+	ServerToggle   key.Binding
+	ServerRestart  key.Binding
+	Favorite       key.Binding
+	Refresh        key.Binding
+	OutputMode     key.Binding
+	Health         key.Binding
+	GitHub         key.Binding
+	NewIssue       key.Binding
+	StoreToken     key.Binding
+	Linear         key.Binding
+	ChangeState    key.Binding
+	GraphQL        key.Binding
+	Events         key.Binding
+	Secrets        key.Binding
+	Rotate         key.Binding
+	Import         key.Binding
+	Memory         key.Binding
+	Graph          key.Binding
+	Mark           key.Binding
+	Tag            key.Binding
+	Reparent       key.Binding
+	MergeNodes     key.Binding
+	Undo           key.Binding
+	Review         key.Binding
+	Reject         key.Binding
+	Stats          key.Binding
+	Retention      key.Binding
+	Vacuum         key.Binding
+	AISessions     key.Binding
+	Git            key.Binding
+	CommitRun      key.Binding
+	HTTP           key.Binding
+	EditRequest    key.Binding
+	NewRequest     key.Binding
+	Environment    key.Binding
+	Split          key.Binding
+	Narrower       key.Binding
+	Wider          key.Binding
+	Tabs           key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -114,7 +197,7 @@ func DefaultKeyMap() KeyMap {
 		),
 		Help: key.NewBinding(
 			key.WithKeys("?"),
-			key.WithHelp("?", "toggle help"),
+			key.WithHelp("?", "cheat sheet"),
 		),
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c", "Q"),
@@ -124,30 +207,503 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("tab"),
 			key.WithHelp("tab", "toggle category"),
 		),
+		Trust: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "cycle trust tier"),
+		),
+		Auto: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "toggle auto-approval"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "confirm"),
+		),
+		Verify: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "verify extension"),
+		),
+		Rollback: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "roll back extension"),
+		),
+		Changelog: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "release notes"),
+		),
+		Install: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "install extension"),
+		),
+		Upgrade: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "upgrade extension"),
+		),
+		Footprint: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "disk footprint"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "cycle sort"),
+		),
+		Clean: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "clean caches"),
+		),
+		Maintenance: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "maintenance"),
+		),
+		DryRun: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "toggle dry run"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "delete selected"),
+		),
+		Files: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "file manager"),
+		),
+		FileCopy: key.NewBinding(
+			key.WithKeys("c", "f5"),
+			key.WithHelp("c/f5", "copy to other pane"),
+		),
+		FileMove: key.NewBinding(
+			key.WithKeys("m", "f6"),
+			key.WithHelp("m/f6", "move to other pane"),
+		),
+		FileRename: key.NewBinding(
+			key.WithKeys("n", "f2"),
+			key.WithHelp("n/f2", "rename"),
+		),
+		Unpack: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "unpack archive into extensions/"),
+		),
+		Package: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "package directory"),
+		),
+		Notes: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "notes"),
+		),
+		AppendNote: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "append output to notes"),
+		),
+		Annotate: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit annotation"),
+		),
+		Project: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "select project"),
+		),
+		Inapplicable: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "show inapplicable tools"),
+		),
+		SortTools: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort category"),
+		),
+		History: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "execution history"),
+		),
+		Range: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "cycle time range"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "filter by tool"),
+		),
+		Compare: key.NewBinding(
+			key.WithKeys("="),
+			key.WithHelp("=", "compare run environments"),
+		),
+		DiffOutput: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "diff output with previous run"),
+		),
+		Promote: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "save run as example"),
+		),
+		Workflows: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "workflows"),
+		),
+		Scheduled: key.NewBinding(
+			key.WithKeys("@"),
+			key.WithHelp("@", "scheduled runs"),
+		),
+		Retry: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "retry failed steps"),
+		),
+		Override: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "override quiet hours"),
+		),
+		Tour: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "replay the tour"),
+		),
+		Palette: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "command palette"),
+		),
+		Recent: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "recent tools"),
+		),
+		Panes: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "output panes"),
+		),
+		NextPane: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "focus next pane"),
+		),
+		ClosePane: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "close finished pane"),
+		),
+		Tasks: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "task list"),
+		),
+		MCP: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "MCP servers"),
+		),
+		ExpandLog: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "expand mini log"),
+		),
+		LogView: key.NewBinding(
+			key.WithKeys("V"),
+			key.WithHelp("V", "log viewer"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		Wrap: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "toggle line wrap"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "export output"),
+		),
+		Copy: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy output"),
+		),
+		ServerToggle: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "start/stop MCP server"),
+		),
+		ServerRestart: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "restart MCP server"),
+		),
+		Favorite: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "toggle favorite"),
+		),
+		Refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh"),
+		),
+		OutputMode: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "cycle output mode"),
+		),
+		Health: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "problem details"),
+		),
+		GitHub: key.NewBinding(
+			key.WithKeys("G"),
+			key.WithHelp("G", "GitHub issues and pull requests"),
+		),
+		NewIssue: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new issue"),
+		),
+		StoreToken: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "store API token"),
+		),
+		Linear: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "Linear issues"),
+		),
+		ChangeState: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "change issue state"),
+		),
+		GraphQL: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "GraphQL console"),
+		),
+		Events: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("ctrl+e", "webhook events"),
+		),
+		Secrets: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "secrets store"),
+		),
+		Rotate: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "rotate secret"),
+		),
+		Import: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "import"),
+		),
+		Memory: key.NewBinding(
+			key.WithKeys("Z"),
+			key.WithHelp("Z", "memory browser"),
+		),
+		AISessions: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "search AI sessions"),
+		),
+		Git: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "git dashboard"),
+		),
+		CommitRun: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "commit last run"),
+		),
+		HTTP: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "request builder"),
+		),
+		EditRequest: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit request"),
+		),
+		NewRequest: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new request"),
+		),
+		Environment: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "next environment"),
+		),
+		Graph: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "node relations"),
+		),
+		Mark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark node"),
+		),
+		Tag: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "tag nodes"),
+		),
+		Reparent: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "move marked nodes"),
+		),
+		MergeNodes: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "merge marked nodes"),
+		),
+		Undo: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo"),
+		),
+		Review: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "review tag suggestions"),
+		),
+		Reject: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "reject suggestion"),
+		),
+		Stats: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "memory statistics"),
+		),
+		Retention: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "apply retention"),
+		),
+		Vacuum: key.NewBinding(
+			key.WithKeys("V"),
+			key.WithHelp("V", "vacuum database"),
+		),
+		Split: key.NewBinding(
+			key.WithKeys("|"),
+			key.WithHelp("|", "split list/preview"),
+		),
+		Narrower: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "narrower list"),
+		),
+		Wider: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "wider list"),
+		),
+		Tabs: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5"),
+			key.WithHelp("1-5", "switch tab"),
+		),
 	}
 }
 
+// screen identifies which full-screen view is active
+type screen int
+
+const (
+	screenTools screen = iota
+	screenFootprint
+	screenMaintenance
+	screenFiles
+	screenNotes
+	screenProjects
+	screenHistory
+	screenWorkflows
+	screenCheatSheet
+	screenPanes
+	screenMCP
+	screenHealth
+	screenTasks
+	screenLog
+	screenGitHub
+	screenLinear
+	screenEvents
+	screenSecrets
+	screenMemory
+	screenAISessions
+	screenGit
+	screenHTTP
+	screenScheduled
+	screenExtensions
+)
+
 // Model represents the application state
 type Model struct {
-	categories    []Category
-	currentCat    int
-	currentTool   int
-	searchInput   textinput.Model
-	viewport      viewport.Model
-	help          help.Model
-	keys          KeyMap
-	showHelp      bool
-	searchMode    bool
-	detailMode    bool
-	selectedTool  *Tool
-	commandOutput string
-	width         int
-	height        int
+	screen           screen
+	footprint        footprintView
+	maintenance      maintenanceView
+	files            fileManagerView
+	notes            notesView
+	projectPicker    projectPickerView
+	history          historyView
+	workflows        workflowsView
+	cheatSheet       cheatSheetView
+	projects         []Project
+	currentProject   int
+	catalogOrder     map[string]int
+	showInapplicable bool
+	toast            string
+	toastID          int
+	configMod        time.Time
+	categories       []Category
+	secretIndex      map[string]SecretInfo
+	extensionStates  extensionStates
+	currentCat       int
+	currentTool      int
+	searchInput      textinput.Model
+	viewport         viewport.Model
+	keys             KeyMap
+	searchMode       bool
+	searchCursor     int
+	detailMode       bool
+	selectedTool     *Tool
+	commandOutput    string
+	statusMessage    string
+	warning          string
+	confirmRun       bool
+	pendingCommand   string
+	pendingArgs      map[string]string
+	pendingInstall   string
+	pendingUpgrade   bool
+	presetArgs       map[string]string
+	examplePicker    examplePicker
+	specPicker       specPicker
+	argsForm         argsForm
+	confirmQuiet     bool
+	dryRun           bool
+	jobs             []*runningTool
+	queue            []*runningTool
+	tasks            tasksView
+	logView          logView
+	nextJobID        int
+	detailJob        int
+	maxPanes         int
+	panes            panesView
+	mcp              mcpView
+	github           githubView
+	githubConfig     *GitHubConfig
+	linear           linearView
+	linearConfig     *LinearConfig
+	memoryConfig     *MemoryConfig
+	webhooks         *webhookServer
+	webhookConfig    *WebhookConfig
+	events           eventsView
+	secrets          secretsView
+	memory           memoryView
+	extensions       extensionsView
+	aiSessions       aiSessionsView
+	git              gitView
+	http             httpView
+	mcpServers       map[string]MCPServerConfig
+	supervisor       *Supervisor
+	scheduler        *Scheduler
+	scheduled        scheduledView
+	health           []healthProblem
+	favorites        []string
+	refreshing       bool
+	outputModes      map[string]OutputMode
+	installs         map[string]InstallRecord
+	terminal         terminalState
+	tmux             TmuxMode
+	outputDir        string
+	updates          map[string]UpdateInfo
+	probes           map[string]ProbeResult
+	probing          bool
+	defaultOutput    OutputMode
+	manifests        bool
+	healthView       healthView
+	tour             tourView
+	palette          paletteView
+	recent           recentView
+	macros           map[string][]string
+	quietHours       *QuietHours
+	annotating       bool
+	annotateTeam     bool
+	annotation       textinput.Model
+	// layout splits the tool screen into the list and a preview
+	layout layoutState
+	// sorts is the sort mode of each category by name
+	sorts  map[string]ToolSort
+	width  int
+	height int
 }
 
 // InitialModel returns the initial model
 func InitialModel() Model {
-	si := textinput.New()
+	si := newTextInput()
 	si.Placeholder = "Search tools..."
 	si.CharLimit = 156
 	si.Width = 50
@@ -155,141 +711,312 @@ func InitialModel() Model {
 	v := viewport.New(50, 20)
 	v.SetContent("")
 
-	help := help.New()
-	help.ShowAll = false
+	annotation := newTextInput()
+	annotation.Placeholder = "e.g. needs GITHUB_TOKEN, broken on macOS"
+	annotation.CharLimit = 500
+	annotation.Width = 60
 
-	categories := LoadToolsFromInventory()
+	categories, inventoryErr := LoadToolsFromInventory()
 
-	return Model{
+	m := Model{
 		categories:  categories,
+		secretIndex: loadSecretIndex(),
 		currentCat:  0,
 		currentTool: 0,
 		searchInput: si,
+		annotation:  annotation,
 		viewport:    v,
-		help:        help,
 		keys:        DefaultKeyMap(),
-		showHelp:    false,
 		searchMode:  false,
 		detailMode:  false,
 		width:       100,
 		height:      30,
+		maxPanes:    defaultMaxPanes,
+		supervisor:  NewSupervisor(),
+		scheduler:   NewScheduler(categories, nil),
+		configMod:   configModTime(),
+		favorites:   LoadFavorites(),
+		outputModes: LoadOutputModes(),
+		installs:    LoadInstalls(),
+		layout:      LoadLayout(),
+		sorts:       LoadToolSorts(),
+		terminal:    terminalState{progress: progressNone},
+	}
+	m.refreshFavorites()
+	m.currentCat = 0
+	m.probing = true
+
+	if inventoryErr != nil {
+		m.toast = fmt.Sprintf("Inventory error: %v", inventoryErr)
+	}
+	if cfg, err := LoadConfig(); err != nil {
+		m.toast = fmt.Sprintf("Config error: %v", err)
+	} else if err := m.applyConfig(cfg); err != nil {
+		m.toast = fmt.Sprintf("Config warning: %v", err)
 	}
+	if !tourCompleted() {
+		m.startTour()
+	}
+	return m
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return textinput.Blink
+	cmds := []tea.Cmd{textinput.Blink, watchConfigCmd(), waitForSupervisor(m.supervisor), waitForScheduler(m.scheduler), checkHealthCmd(m.categories, true), probeCatalogCmd(m.categories), checkUpdatesCmd(m.categories)}
+	if m.webhooks != nil {
+		cmds = append(cmds, waitForWebhook(m.webhooks))
+	}
+	return tea.Batch(cmds...)
 }
 
-// Update handles updates to the model
+// Update handles updates to the model and keeps the terminal title and
+// progress indicator in step with it
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	next, ok := updated.(Model)
+	if !ok {
+		return updated, cmd
+	}
+	sync := next.syncTerminal()
+	return next, tea.Batch(cmd, sync)
+}
+
+// update handles a message for the current screen
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width = size.Width
+		m.height = size.Height
+		m.viewport.Width = size.Width - 20
+		m.viewport.Height = size.Height - 15
+		m.searchInput.Width = size.Width - 40
+		m.layoutPanes()
+		if job := m.job(m.logView.jobID); job != nil {
+			m.refreshLog(job)
+		}
+	}
+
 	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		m.viewport.Width = msg.Width - 20
-		m.viewport.Height = msg.Height - 15
-		m.searchInput.Width = msg.Width - 40
+	case configTickMsg:
+		cmds := []tea.Cmd{watchConfigCmd()}
+		if mod := configModTime(); !mod.Equal(m.configMod) {
+			m.configMod = mod
+			cmds = append(cmds, m.reloadConfig())
+		}
+		return m, tea.Batch(cmds...)
 
-	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
-
-		case key.Matches(msg, m.keys.Help):
-			m.showHelp = !m.showHelp
-			m.help.ShowAll = m.showHelp
-
-		case key.Matches(msg, m.keys.Search):
-			m.searchMode = true
-			m.searchInput.Focus()
-			return m, textinput.Blink
-
-		case key.Matches(msg, m.keys.Back):
-			if m.searchMode {
-				m.searchMode = false
-				m.searchInput.Blur()
-				m.searchInput.SetValue("")
-			} else if m.detailMode {
-				m.detailMode = false
-				m.selectedTool = nil
-				m.commandOutput = ""
-			}
+	case clearToastMsg:
+		if msg.id == m.toastID {
+			m.toast = ""
+		}
+		return m, nil
 
-		case key.Matches(msg, m.keys.Execute):
-			if m.detailMode && m.selectedTool != nil {
-				output, err := ExecuteCommand(m.selectedTool.Command)
-				if err != nil {
-					m.commandOutput = fmt.Sprintf("Error: %v\n\nOutput:\n%s", err, output)
-				} else {
-					m.commandOutput = output
-				}
-				m.viewport.SetContent(m.commandOutput)
-				m.viewport.GotoTop()
-			}
+	case streamOutputMsg, streamBatchMsg, streamDoneMsg, streamTickMsg:
+		return m.updateStream(msg)
 
-		case key.Matches(msg, m.keys.Enter):
-			if m.searchMode {
-				m.searchMode = false
-				m.searchInput.Blur()
-				// Search logic would go here
-			} else if !m.detailMode {
-				// Enter detail mode
-				currentCategory := m.categories[m.currentCat]
-				if len(currentCategory.Tools) > 0 {
-					m.selectedTool = &currentCategory.Tools[m.currentTool]
-					m.detailMode = true
-					m.commandOutput = ""
-					m.viewport.SetContent("")
-				}
-			}
+	case mcpConnectedMsg, mcpListedMsg, mcpResponseMsg:
+		return m.updateMCP(msg)
 
-		case key.Matches(msg, m.keys.Up):
-			if !m.detailMode && !m.searchMode {
-				if m.currentTool > 0 {
-					m.currentTool--
-				}
-			}
+	case supervisorMsg:
+		return m, waitForSupervisor(m.supervisor)
 
-		case key.Matches(msg, m.keys.Down):
-			if !m.detailMode && !m.searchMode {
-				currentCategory := m.categories[m.currentCat]
-				if m.currentTool < len(currentCategory.Tools)-1 {
-					m.currentTool++
-				}
+	case scheduledMsg:
+		cmds := []tea.Cmd{waitForScheduler(m.scheduler)}
+		if m.screen == screenScheduled {
+			m.scheduled.reload(m.categories, m.quietHours)
+		}
+		if !msg.result.Step.Success {
+			cmds = append(cmds, m.showToast("⚠ "+msg.result.Label()))
+		}
+		return m, tea.Batch(cmds...)
+
+	case webhookMsg:
+		return m, tea.Batch(m.receiveWebhook(msg.event), waitForWebhook(m.webhooks))
+
+	case healthMsg:
+		m.health = msg.problems
+		m.healthView.refreshing = false
+		if !msg.scheduled {
+			return m, nil
+		}
+		return m, recheckHealthCmd(m.categories)
+
+	case catalogMsg:
+		return m, tea.Batch(m.applyCatalog(msg), m.probeCatalog(), checkUpdatesCmd(m.categories))
+
+	case updatesMsg:
+		m.updates = msg.results
+		return m, nil
+
+	case clipboardMsg:
+		if m.screen == screenLog {
+			m.logView.message = msg.Message()
+		} else {
+			m.statusMessage = msg.Message()
+		}
+		return m, nil
+
+	case probesMsg:
+		m.probes, m.probing = msg.results, false
+		if m.sortsBy(SortStatus) {
+			m.resort()
+		}
+		return m, nil
+
+	case historyMsg:
+		return m.updateHistory(msg)
+
+	case specsMsg:
+		return m.updateSpecs(msg)
+
+	case sessionIndexMsg:
+		return m.updateAISessions(msg)
+
+	case memoryUndoExpiredMsg:
+		return m.updateMemory(msg)
+	case httpExchangeMsg:
+		return m.updateHTTP(msg)
+	case graphQLSchemaMsg:
+		return m.updateHTTP(msg)
+	case envComparisonMsg:
+		return m.updateHTTP(msg)
+	case daemonJobsMsg, daemonLogsMsg:
+		return m.updateTasks(msg)
+
+	case tea.KeyMsg:
+		if job := m.currentJob(); job != nil && !job.done && msg.String() == "ctrl+c" {
+			if !job.cancelled {
+				m.cancelRun(job)
 			}
+			return m, nil
+		}
+		if i := m.currentQueued(); i >= 0 && msg.String() == "ctrl+c" {
+			m.dequeue(i)
+			return m, nil
+		}
+		if job := m.miniLogJob(); job != nil && key.Matches(msg, m.keys.ExpandLog) {
+			return m, m.openPanesAt(job)
+		}
+		if key.Matches(msg, m.keys.Health) && len(m.healthProblems()) > 0 {
+			return m, m.openHealth()
+		}
+		if i, ok := m.tabKey(msg); ok && !m.tour.active {
+			return m, m.switchTab(i)
+		}
+	}
 
-		case key.Matches(msg, m.keys.Left):
-			if !m.detailMode && !m.searchMode {
-				if m.currentCat > 0 {
-					m.currentCat--
-					m.currentTool = 0
-				}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.tour.active {
+		return m.updateTour(keyMsg)
+	}
+
+	switch m.screen {
+	case screenFootprint:
+		return m.updateFootprint(msg)
+	case screenMaintenance:
+		return m.updateMaintenance(msg)
+	case screenFiles:
+		return m.updateFileManager(msg)
+	case screenNotes:
+		return m.updateNotes(msg)
+	case screenProjects:
+		return m.updateProjectPicker(msg)
+	case screenHistory:
+		return m.updateHistory(msg)
+	case screenWorkflows:
+		return m.updateWorkflows(msg)
+	case screenCheatSheet:
+		return m.updateCheatSheet(msg)
+	case screenPanes:
+		return m.updatePanes(msg)
+	case screenMCP:
+		return m.updateMCP(msg)
+	case screenHealth:
+		return m.updateHealth(msg)
+	case screenTasks:
+		return m.updateTasks(msg)
+	case screenLog:
+		return m.updateLog(msg)
+	case screenGitHub:
+		return m.updateGitHub(msg)
+	case screenLinear:
+		return m.updateLinear(msg)
+	case screenEvents:
+		return m.updateEvents(msg)
+	case screenSecrets:
+		return m.updateSecrets(msg)
+	case screenMemory:
+		return m.updateMemory(msg)
+	case screenAISessions:
+		return m.updateAISessions(msg)
+	case screenGit:
+		return m.updateGit(msg)
+	case screenHTTP:
+		return m.updateHTTP(msg)
+	case screenScheduled:
+		return m.updateScheduled(msg)
+	case screenExtensions:
+		return m.updateExtensions(msg)
+	}
+
+	switch msg := msg.(type) {
+	case changelogMsg:
+		if m.detailMode && m.selectedTool != nil && m.selectedTool.Name == msg.tool {
+			if msg.err != nil {
+				m.statusMessage = msg.err.Error()
+			} else {
+				m.statusMessage = ""
+				m.setOutput(renderMarkdown(msg.notes))
 			}
+		}
 
-		case key.Matches(msg, m.keys.Right):
-			if !m.detailMode && !m.searchMode {
-				if m.currentCat < len(m.categories)-1 {
-					m.currentCat++
-					m.currentTool = 0
-				}
+	case tea.KeyMsg:
+		if m.annotating {
+			return m.updateAnnotation(msg)
+		}
+		if m.examplePicker.active {
+			return m.updateExamplePicker(msg)
+		}
+		if m.specPicker.active {
+			return m.updateSpecPicker(msg)
+		}
+		if m.argsForm.active {
+			return m.updateArgsForm(msg)
+		}
+		if m.palette.active {
+			return m.updatePalette(msg)
+		}
+		if m.recent.active {
+			return m.updateRecent(msg)
+		}
+		if m.searchMode {
+			return m.updateSearch(msg)
+		}
+
+		if m.confirmQuiet {
+			m.confirmQuiet = false
+			if key.Matches(msg, m.keys.Override) {
+				return m, m.startSelectedTool()
+			} else {
+				m.statusMessage = "Execution cancelled"
 			}
+			return m, nil
+		}
 
-		case key.Matches(msg, m.keys.ToggleCategory):
-			if !m.detailMode && !m.searchMode {
-				// Toggle category visibility
-				if m.currentCat < len(m.categories) {
-					m.categories[m.currentCat].Active = !m.categories[m.currentCat].Active
-				}
+		if m.confirmRun {
+			m.confirmRun = false
+			if key.Matches(msg, m.keys.Confirm) {
+				return m, m.runSelectedTool()
+			} else {
+				m.pendingInstall, m.pendingUpgrade = "", false
+				m.statusMessage = "Execution cancelled"
 			}
+			return m, nil
 		}
-	}
 
-	// Update search input if in search mode
-	if m.searchMode {
-		m.searchInput, cmd = m.searchInput.Update(msg)
+		if cmd, ok := m.dispatchAction(msg); ok {
+			return m, cmd
+		}
 	}
 
 	// Update viewport for scrolling
@@ -300,27 +1027,434 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// updateAnnotation edits the selected tool's annotation
+func (m Model) updateAnnotation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.annotating = false
+		m.annotation.Blur()
+		text := strings.TrimSpace(m.annotation.Value())
+		if m.annotateTeam {
+			if err := SetSharedAnnotation(m.selectedTool, text); err != nil {
+				m.statusMessage = fmt.Sprintf("Could not save team annotation: %v", err)
+			} else {
+				m.selectedTool.SharedAnnotation = text
+				m.statusMessage = "Team annotation saved to " + sharedMetadataPath(m.selectedTool.WorkDir()) + ", commit it to share"
+			}
+			return m, nil
+		}
+		if err := SetToolAnnotation(m.selectedTool.Key(), text); err != nil {
+			m.statusMessage = fmt.Sprintf("Could not save annotation: %v", err)
+		} else {
+			m.selectedTool.Annotation = text
+			m.statusMessage = "Annotation saved"
+		}
+		return m, nil
+	case tea.KeyTab:
+		m.annotateTeam = !m.annotateTeam
+		if m.annotateTeam {
+			m.annotation.SetValue(m.selectedTool.SharedAnnotation)
+		} else {
+			m.annotation.SetValue(m.selectedTool.Annotation)
+		}
+		m.annotation.CursorEnd()
+		return m, nil
+	case tea.KeyEsc:
+		m.annotating = false
+		m.annotation.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.annotation, cmd = m.annotation.Update(msg)
+	return m, cmd
+}
+
+// executeSelectedTool runs the selected tool unless its platform is
+// unsupported. Dangerous tools need an override during quiet hours,
+// except in a dry run.
+func (m *Model) executeSelectedTool() tea.Cmd {
+	if reason := m.selectedTool.UnsupportedReason(); reason != "" {
+		m.statusMessage = fmt.Sprintf("Cannot run %s: %s", m.selectedTool.Name, reason)
+		return nil
+	}
+	if quiet, _ := m.quietHours.Active(time.Now()); quiet && m.selectedTool.Dangerous && !m.dryRun {
+		m.confirmQuiet = true
+		return nil
+	}
+	return m.startSelectedTool()
+}
+
+// startSelectedTool runs the selected tool, asking for the values of
+// its command's placeholders first, or which of its examples to start
+// from unless a re-run preset them
+func (m *Model) startSelectedTool() tea.Cmd {
+	if placeholders := m.selectedTool.placeholders(); len(placeholders) > 0 {
+		if m.presetArgs == nil && len(m.selectedTool.examples()) > 0 {
+			m.openExamplePicker()
+			return nil
+		}
+		return m.askArgs(placeholders)
+	}
+	m.presetArgs = nil
+	m.pendingInstall, m.pendingUpgrade = "", false
+	return m.confirmAndRun(m.selectedTool.Command, nil)
+}
+
+// askArgs opens the argument form, after the spec picker for a tool
+// taking an OpenAPI spec that was not preset
+func (m *Model) askArgs(placeholders []placeholder) tea.Cmd {
+	if arg := m.selectedTool.OpenAPIArg; arg != "" && m.presetArgs[arg] == "" {
+		return m.openSpecPicker()
+	}
+	return m.openArgsForm(placeholders)
+}
+
+// confirmAndRun runs command for the selected tool, asking for
+// confirmation first when its trust tier requires it or it is
+// destructive. args are the placeholder values the command was built
+// from. In a dry run the resolved command is shown instead.
+func (m *Model) confirmAndRun(command string, args map[string]string) tea.Cmd {
+	m.pendingCommand = command
+	m.pendingArgs = args
+	if m.dryRun {
+		tool := m.pendingTool()
+		m.pendingInstall, m.pendingUpgrade = "", false
+		plan, err := DryRun(&tool, m.projectDir(), args)
+		if err != nil {
+			m.statusMessage = fmt.Sprintf("Cannot run %s: %v", tool.Name, err)
+			return nil
+		}
+		m.detailJob = 0
+		m.setOutput(plan)
+		m.statusMessage = fmt.Sprintf("Dry run: %s was not executed, %s turns dry run off", tool.Name, primaryKey(m.keys.DryRun))
+		return nil
+	}
+	if m.selectedTool.Trust.RequiresConfirmation() || m.selectedTool.Destructive {
+		m.confirmRun = true
+		return nil
+	}
+	return m.runSelectedTool()
+}
+
+// pendingTool returns the selected tool with the pending command, in
+// the extension's directory for an install or upgrade
+func (m Model) pendingTool() Tool {
+	tool := *m.selectedTool
+	tool.Command = m.pendingCommand
+	if m.pendingInstall != "" {
+		tool.Dir, _ = ExtensionDir(m.selectedTool)
+		tool.Scoped = false
+	}
+	return tool
+}
+
+// runSelectedTool starts the pending command of the selected tool as a
+// new job, streaming its output into the viewport and the job's pane,
+// or queues it while the tool already runs or every pane is busy.
+// Extension commands are verified first and refused when published
+// checksums or signatures do not match.
+func (m *Model) runSelectedTool() tea.Cmd {
+	m.statusMessage = ""
+	m.warning = ""
+
+	tool := m.pendingTool()
+	run := &runningTool{tool: &tool, projectDir: m.projectDir(), args: m.pendingArgs, mode: m.outputMode(&tool), installer: m.pendingInstall, upgrade: m.pendingUpgrade}
+	m.pendingInstall, m.pendingUpgrade = "", false
+	if dir, isExtension := ExtensionDir(m.selectedTool); isExtension {
+		report, err := VerifyExtension(dir)
+		if err == nil && report.Failed() {
+			m.warning = "Integrity check FAILED, refusing to run"
+			m.setOutput(report.Summary())
+			return nil
+		}
+		if report.Changed {
+			m.warning = "Extension content changed unexpectedly since last verification"
+		}
+		if _, err := SnapshotExtension(dir); err != nil {
+			m.statusMessage = fmt.Sprintf("Could not snapshot extension: %v", err)
+		}
+		m.reloadExtensionStates()
+		run.extensionDir, run.report = dir, report
+	}
+
+	if !m.canStart(run.tool) {
+		m.enqueue(run)
+		m.detailJob = run.id
+		m.setOutput("")
+		m.statusMessage = fmt.Sprintf("Queued %s, %d tasks waiting (%s shows them)", run.tool.Name, len(m.queue), primaryKey(m.keys.Tasks))
+		return nil
+	}
+	cmd := m.startJob(run)
+	m.detailJob = run.id
+	m.setOutput("")
+	return cmd
+}
+
+// startJob launches a job in a new pane, and in a tmux pane or window
+// as well when configured. Sandboxed tools always run in the TUI.
+func (m *Model) startJob(run *runningTool) tea.Cmd {
+	runDir, _ := scopedCommand(run.tool, run.projectDir)
+	run.env = CaptureEnv(runDir)
+	run.started = time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	run.cancel = cancel
+	if m.tmux.usable() && !run.tool.Trust.Sandboxed() {
+		run.ch = StreamTmux(ctx, run.tool, run.projectDir, m.tmux)
+	} else {
+		run.ch = StreamTool(ctx, run.tool, run.projectDir)
+	}
+	m.addJob(run)
+	return tea.Batch(waitForStream(run.id, run.ch), streamTick(run.id))
+}
+
+// finishRun records a finished job and shows its final output in its
+// pane. The detail view shows the outcome when attached to the job, a
+// toast reports it otherwise.
+func (m *Model) finishRun(run *runningTool, output string, err error) tea.Cmd {
+	run.cancel()
+	run.done, run.err, run.finished = true, err, time.Now()
+
+	var status, warning string
+	record := newRunRecord(run.tool, run.projectDir, run.started, run.env, output, err)
+	record.Args = argValues(run.args)
+	if err := AppendHistory(record, output); err != nil {
+		status = fmt.Sprintf("Could not record run history: %v", err)
+	} else if err != nil {
+		status = fmt.Sprintf("%s failed after %s", run.tool.Name, run.Elapsed())
+	} else {
+		status = fmt.Sprintf("%s finished in %s", run.tool.Name, run.Elapsed())
+	}
+	if run.installer != "" && !run.cancelled {
+		if err := m.finishInstall(run, err); err != nil {
+			status = fmt.Sprintf("Could not record install: %v", err)
+		}
+	}
+	if m.manifests {
+		if _, err := WriteRunManifest(newRunManifest(record, run.tool)); err != nil {
+			status = fmt.Sprintf("Could not write run manifest: %v", err)
+		}
+	}
+
+	dir, isExtension := run.extensionDir, run.extensionDir != ""
+	if err != nil {
+		if isExtension && !run.cancelled {
+			SetQuarantined(dir, true)
+			warning = fmt.Sprintf("Update failed, extension quarantined. Press '%s' to roll back", primaryKey(m.keys.Rollback))
+		}
+		output = fmt.Sprintf("Error: %v\n\nOutput:\n%s", err, output)
+	} else if isExtension {
+		SetQuarantined(dir, false)
+		if run.report.Hash != "" {
+			if err := RecordExtensionHash(dir, run.report.Hash); err != nil {
+				status = fmt.Sprintf("Could not record extension hash: %v", err)
+			}
+			output = run.report.Summary() + "\n" + output
+		}
+	}
+	if isExtension {
+		m.reloadExtensionStates()
+	}
+	var next *runningTool
+	if run.upgrade && err == nil && !run.cancelled {
+		var uerr error
+		if next, uerr = m.finishUpgrade(run); uerr != nil {
+			status = fmt.Sprintf("Could not finish the upgrade: %v", uerr)
+		} else {
+			status = fmt.Sprintf("Pulled %s, installing the new version", run.tool.Name)
+		}
+	}
+	run.output = ""
+	run.appendOutput(output)
+	m.refreshLog(run)
+
+	if run.id != m.detailJob {
+		if warning != "" {
+			status += ": " + warning
+		}
+		return m.showToast(status)
+	}
+	m.statusMessage = status
+	if warning != "" {
+		m.warning = warning
+	}
+	follow := m.viewport.AtBottom()
+	m.setOutput(output)
+	if follow {
+		m.viewport.GotoBottom()
+	}
+	if next != nil {
+		m.detailJob = next.id
+	}
+	return nil
+}
+
+// verifySelectedTool runs an on-demand integrity check of an extension
+func (m *Model) verifySelectedTool() {
+	dir, ok := ExtensionDir(m.selectedTool)
+	if !ok {
+		m.statusMessage = "Not an extension, nothing to verify"
+		return
+	}
+	report, err := VerifyExtension(dir)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Verification failed: %v", err)
+		return
+	}
+	m.warning = ""
+	if report.Failed() {
+		m.warning = "Integrity check FAILED"
+	} else if report.Changed {
+		m.warning = "Extension content changed unexpectedly since last verification"
+	}
+	m.setOutput(report.Summary())
+}
+
+// rollbackSelectedTool restores the previous version of an extension
+func (m *Model) rollbackSelectedTool() {
+	dir, ok := ExtensionDir(m.selectedTool)
+	if !ok {
+		m.statusMessage = "Not an extension, nothing to roll back"
+		return
+	}
+	snap, err := RollbackExtension(dir)
+	m.reloadExtensionStates()
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Rollback failed: %v", err)
+		return
+	}
+	m.warning = ""
+	m.statusMessage = fmt.Sprintf("Rolled back to %s", snap.Label())
+}
+
+// reloadExtensionStates reads the snapshots and quarantine shown in the
+// detail view of an extension, which are kept on the model rather than
+// read on every render
+func (m *Model) reloadExtensionStates() {
+	states, err := loadExtensionStates()
+	if err != nil {
+		states = nil
+	}
+	m.extensionStates = states
+}
+
+// setOutput replaces the command output shown in the detail view
+func (m *Model) setOutput(output string) {
+	m.commandOutput = output
+	m.viewport.SetContent(m.commandOutput)
+	m.viewport.GotoTop()
+}
+
 // View renders the model
 func (m Model) View() string {
+	var content string
+	switch m.screen {
+	case screenFootprint:
+		content = m.renderFootprint()
+	case screenMaintenance:
+		content = m.renderMaintenance()
+	case screenFiles:
+		content = m.renderFileManager()
+	case screenNotes:
+		content = m.renderNotes()
+	case screenProjects:
+		content = m.renderProjectPicker()
+	case screenHistory:
+		content = m.renderHistory()
+	case screenWorkflows:
+		content = m.renderWorkflows()
+	case screenCheatSheet:
+		content = m.renderCheatSheet()
+	case screenPanes:
+		content = m.renderPanes()
+	case screenMCP:
+		content = m.renderMCP()
+	case screenHealth:
+		content = m.renderHealth()
+	case screenTasks:
+		content = m.renderTasks()
+	case screenLog:
+		content = m.renderLog()
+	case screenGitHub:
+		content = m.renderGitHub()
+	case screenLinear:
+		content = m.renderLinear()
+	case screenEvents:
+		content = m.renderEvents()
+	case screenSecrets:
+		content = m.renderSecrets()
+	case screenMemory:
+		content = m.renderMemory()
+	case screenAISessions:
+		content = m.renderAISessions()
+	case screenGit:
+		content = m.renderGit()
+	case screenHTTP:
+		content = m.renderHTTP()
+	case screenScheduled:
+		content = m.renderScheduled()
+	case screenExtensions:
+		content = m.renderExtensions()
+	default:
+		content = m.renderToolsScreen()
+	}
+
+	if m.currentTab() >= 0 {
+		content = lipgloss.JoinVertical(lipgloss.Left, m.renderTabs(), content)
+	}
+	if problems := m.healthProblems(); len(problems) > 0 && m.screen != screenHealth {
+		content = lipgloss.JoinVertical(lipgloss.Left, m.renderHealthBanner(problems), content)
+	}
+	if job := m.miniLogJob(); job != nil {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, "", m.renderMiniLog(job))
+	}
+	if m.tour.active {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, "", m.renderTour())
+	}
+	if m.toast != "" {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, "", statusStyle.Render("🔔 "+m.toast))
+	}
+	return content
+}
+
+// renderToolsScreen renders the tool list or the selected tool's details
+func (m Model) renderToolsScreen() string {
+	if m.palette.active {
+		return m.renderPalette()
+	}
+	if m.recent.active {
+		return m.renderRecent()
+	}
 	if m.detailMode && m.selectedTool != nil {
-		return m.renderDetailView()
+		return m.tourHighlight("detail", m.renderDetailView())
 	}
 
 	// Header
 	title := titleStyle.Render("🛠️  OpenCode Tools & Plugins TUI")
-	status := statusStyle.Render(fmt.Sprintf("%d Tools | %d Categories", m.getTotalTools(), len(m.categories)))
-	header := lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status)
-
-	// Main content
-	mainContent := m.renderMainView()
+	summary := fmt.Sprintf("%d Tools | %d Categories | %s", m.getTotalTools(), len(m.categories), m.projectLabel())
+	if faultsEnabled() {
+		summary += fmt.Sprintf(" | ⚡ faults %.0f%%", faultRate*100)
+	}
+	if m.refreshing {
+		summary += " | " + refreshingLabel
+	}
+	if m.dryRun {
+		summary += " | 🧪 dry run"
+	}
+	status := statusStyle.Render(summary)
+	header := m.tourHighlight("header", lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
 
 	// Footer
-	footer := m.renderFooter()
+	footer := m.tourHighlight("footer", m.renderFooter())
 
-	// Help section
-	helpView := ""
-	if m.showHelp {
-		helpView = m.help.View(m.keys)
+	// Main content
+	list, cursor := m.renderMainView()
+	list = renderStale(m.refreshing, list)
+	if m.split() {
+		list = m.renderSplit(list, cursor, max(m.height-lipgloss.Height(header)-lipgloss.Height(footer)-4, 10))
+	}
+	mainContent := m.tourHighlight("list", list)
+	if m.searchMode {
+		mainContent = m.tourHighlight("search", m.renderSearch())
 	}
 
 	// Combine all sections
@@ -332,68 +1466,99 @@ func (m Model) View() string {
 		footer,
 	)
 
-	if helpView != "" {
-		content = lipgloss.JoinVertical(lipgloss.Left,
-			content,
-			"",
-			helpStyle.Render(helpView),
-		)
-	}
-
 	return content
 }
 
-// renderMainView renders the main list view
-func (m Model) renderMainView() string {
+// renderMainView renders the main list view and returns the line of the
+// cursor, -1 when it is on no visible tool
+func (m Model) renderMainView() (string, int) {
 	var content strings.Builder
+	cursor := -1
+	kinds := m.projectKindsInScope()
 
 	// Categories and tools
 	for i, category := range m.categories {
 		// Category header
 		catStyle := titleStyle
 		if i == m.currentCat && !m.searchMode {
-			catStyle = catStyle.Copy().Background(lipgloss.Color("#EE6FF8"))
+			catStyle = catStyle.Copy().Background(selectedItemStyle.GetForeground())
 		}
 
 		categoryLine := fmt.Sprintf("%s %s (%d tools)",
 			catStyle.Render(category.Name),
 			descriptionStyle.Render("- "+category.Purpose),
 			len(category.Tools))
+		if mode := m.sorts[category.Name]; mode != SortCatalog && !category.Favorites {
+			categoryLine += " " + helpStyle.Render("↕ "+mode.Label())
+		}
 
+		if i == m.currentCat {
+			cursor = strings.Count(content.String(), "\n")
+		}
 		content.WriteString(categoryLine)
 		content.WriteString("\n")
 
 		// Tools in category
 		if category.Active {
-			for j, tool := range category.Tools {
+			visible := m.visibleTools(category)
+			for j, tool := range category.Tools[:visible] {
 				toolPrefix := "  "
+				badge := m.statusBadge(&category.Tools[j])
+				if tool.Repo != "" {
+					tool.Name = helpStyle.Render("["+tool.Repo+"]") + " " + tool.Name
+				}
 				if i == m.currentCat && j == m.currentTool && !m.searchMode {
+					cursor = strings.Count(content.String(), "\n")
 					toolPrefix = "▶ "
 					toolName := selectedItemStyle.Render(tool.Name)
-					toolStatus := statusStyle.Render(tool.Status)
+					toolStatus := statusStyle.Render(badge)
 					toolLine := fmt.Sprintf("%s%s %s - %s",
 						toolPrefix, toolName, toolStatus,
 						descriptionStyle.Render(tool.Purpose))
 					content.WriteString(toolLine)
+				} else if reason := tool.UnsupportedReason(); reason != "" {
+					toolLine := fmt.Sprintf("%s• %s %s - %s",
+						toolPrefix, tool.Name, badge, tool.Purpose)
+					content.WriteString(helpStyle.Render(toolLine))
 				} else {
 					toolLine := fmt.Sprintf("%s• %s %s - %s",
-						toolPrefix, tool.Name, tool.Status,
+						toolPrefix, tool.Name, badge,
 						descriptionStyle.Render(tool.Purpose))
 					content.WriteString(toolLine)
 				}
+				if reason := tool.UnsupportedReason(); reason != "" {
+					content.WriteString(" " + warningStyle.Render("⛔ "+reason))
+				}
+				if tool.MCPServer != "" {
+					status := m.supervisor.Status(tool.MCPServer)
+					content.WriteString(" " + helpStyle.Render(status.Icon()+" "+status.Label()))
+				}
+				if !category.Favorites && m.isFavorite(tool.Key()) {
+					content.WriteString(" ⭐")
+				}
+				if tool.SharedAnnotation != "" {
+					content.WriteString(" " + helpStyle.Render("👥 "+tool.SharedAnnotation))
+				}
+				if tool.Annotation != "" {
+					content.WriteString(" " + helpStyle.Render("📌 "+tool.Annotation))
+				}
+				switch relevance, matched := tool.Relevance(kinds); relevance {
+				case 1:
+					content.WriteString(" " + featureStyle.Render("★ "+strings.Join(matched, ", ")))
+				case -1:
+					content.WriteString(" " + helpStyle.Render("✗ "+strings.Join(tool.languages(), ", ")+" only"))
+				}
+				content.WriteString("\n")
+			}
+			if hidden := len(category.Tools) - visible; hidden > 0 {
+				content.WriteString(helpStyle.Render(fmt.Sprintf("  … %d inapplicable to this project (I to show)", hidden)))
 				content.WriteString("\n")
 			}
 		}
 		content.WriteString("\n")
 	}
 
-	// Search input
-	if m.searchMode {
-		searchLine := fmt.Sprintf("🔍 %s", m.searchInput.View())
-		content.WriteString(commandStyle.Render(searchLine))
-	}
-
-	return content.String()
+	return content.String(), cursor
 }
 
 // renderDetailView renders the detailed view for a selected tool
@@ -406,10 +1571,27 @@ func (m Model) renderDetailView() string {
 
 	// Tool header
 	title := titleStyle.Render(m.selectedTool.Name)
-	status := statusStyle.Render(m.selectedTool.Status)
+	status := statusStyle.Render(m.statusBadge(m.selectedTool))
 	header := lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status)
 	content.WriteString(header)
-	content.WriteString("\n\n")
+	content.WriteString("\n")
+	if result, ok := m.probes[m.selectedTool.Key()]; ok {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("Probed %s: %s (declared %s)", result.Checked.Format("15:04:05"), result.Detail, m.selectedTool.Status)))
+		content.WriteString("\n")
+	}
+	if record, ok := m.installs[m.selectedTool.Key()]; ok {
+		content.WriteString(helpStyle.Render(record.Summary()))
+		content.WriteString("\n")
+	}
+	if info, ok := m.updates[m.selectedTool.Key()]; ok {
+		summary := info.Summary()
+		if info.Available() {
+			summary += ", " + hint("upgrade", m.keys.Upgrade)
+		}
+		content.WriteString(helpStyle.Render(summary))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
 
 	// Tool details
 	content.WriteString(descriptionStyle.Bold(true).Render("Purpose: "))
@@ -424,6 +1606,105 @@ func (m Model) renderDetailView() string {
 	content.WriteString(commandStyle.Render(m.selectedTool.Command))
 	content.WriteString("\n\n")
 
+	if runs := m.selectedTool.RunsIn(m.secretIndex); runs != "" {
+		content.WriteString(descriptionStyle.Bold(true).Render("Runs: "))
+		content.WriteString(runs)
+		content.WriteString("\n\n")
+	}
+
+	if m.selectedTool.Destructive {
+		content.WriteString(warningStyle.Render("💥 Destructive: every run asks for confirmation"))
+		content.WriteString("\n")
+	}
+	if m.dryRun {
+		content.WriteString(featureStyle.Render(fmt.Sprintf("🧪 Dry run: %s shows the resolved command without running it, %s turns dry run off", primaryKey(m.keys.Execute), primaryKey(m.keys.DryRun))))
+		content.WriteString("\n")
+	}
+	if m.selectedTool.Destructive || m.dryRun {
+		content.WriteString("\n")
+	}
+
+	if m.examplePicker.active {
+		content.WriteString(m.renderExamplePicker())
+	} else if m.specPicker.active {
+		content.WriteString(m.renderSpecPicker())
+	} else if m.argsForm.active {
+		content.WriteString(m.renderArgsForm())
+	}
+
+	if m.selectedTool.Repo != "" {
+		content.WriteString(descriptionStyle.Bold(true).Render("Repository: "))
+		content.WriteString(fmt.Sprintf("%s (%s)", m.selectedTool.Repo, m.selectedTool.RepoRoot))
+		content.WriteString("\n\n")
+	}
+
+	if m.annotating {
+		scope := "📌 private"
+		if m.annotateTeam {
+			scope = "👥 team"
+		}
+		content.WriteString(commandStyle.Render(scope + " " + m.annotation.View()))
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render("enter: save | tab: switch private/team | esc: cancel"))
+		content.WriteString("\n\n")
+	} else {
+		if m.selectedTool.SharedAnnotation != "" {
+			content.WriteString(descriptionStyle.Bold(true).Render("👥 Team note: "))
+			content.WriteString(m.selectedTool.SharedAnnotation)
+			content.WriteString("\n\n")
+		}
+		if m.selectedTool.Annotation != "" {
+			content.WriteString(descriptionStyle.Bold(true).Render("📌 Note: "))
+			content.WriteString(m.selectedTool.Annotation)
+			content.WriteString("\n\n")
+		}
+	}
+
+	content.WriteString(descriptionStyle.Bold(true).Render("Trust: "))
+	content.WriteString(m.selectedTool.Trust.Label())
+	content.WriteString("\n")
+	content.WriteString(descriptionStyle.Bold(true).Render("Workflows: "))
+	content.WriteString(m.selectedTool.AutoLabel())
+	content.WriteString(" " + helpStyle.Render("("+hint("toggle", m.keys.Auto)+")"))
+	content.WriteString("\n")
+	content.WriteString(descriptionStyle.Bold(true).Render("Output: "))
+	content.WriteString(string(m.outputMode(m.selectedTool)))
+	content.WriteString(" " + helpStyle.Render("("+hint("cycle normal/quiet/verbose", m.keys.OutputMode)+")"))
+	content.WriteString("\n\n")
+
+	if name := m.selectedTool.MCPServer; name != "" {
+		status := m.supervisor.Status(name)
+		content.WriteString(descriptionStyle.Bold(true).Render("MCP server: "))
+		content.WriteString(fmt.Sprintf("%s %s %s", name, status.Icon(), status.Label()))
+		content.WriteString(" " + helpStyle.Render("("+hint("start/stop", m.keys.ServerToggle)+", "+hint("restart", m.keys.ServerRestart)+")"))
+		if status.Error != "" {
+			content.WriteString("\n")
+			content.WriteString(warningStyle.Render(truncate(status.Error, max(m.width-4, 20))))
+		}
+		content.WriteString("\n\n")
+	}
+
+	if reason := m.selectedTool.UnsupportedReason(); reason != "" {
+		content.WriteString(descriptionStyle.Bold(true).Render("Platform: "))
+		content.WriteString(warningStyle.Render("⛔ " + reason))
+		content.WriteString("\n\n")
+	}
+
+	if dir, ok := ExtensionDir(m.selectedTool); ok {
+		content.WriteString(descriptionStyle.Bold(true).Render("Previous version: "))
+		if snap, ok := m.extensionStates.latest(dir); ok {
+			content.WriteString(snap.Label())
+		} else {
+			content.WriteString("none")
+		}
+		if m.extensionStates.quarantined(dir) {
+			content.WriteString("  " + warningStyle.Render("QUARANTINED"))
+		}
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(m.renderExamples())
+
 	// Features
 	if len(m.selectedTool.Features) > 0 {
 		content.WriteString(descriptionStyle.Bold(true).Render("Features:\n"))
@@ -434,15 +1715,52 @@ func (m Model) renderDetailView() string {
 	}
 
 	// Command output
+	if job := m.job(m.detailJob); job != nil && !job.done {
+		content.WriteString(renderRunning(job))
+		content.WriteString("\n")
+	} else if i := m.queuedIndex(m.detailJob); i >= 0 {
+		content.WriteString(commandStyle.Render(fmt.Sprintf("… Queued as task #%d, starts when a pane is free (ctrl+c removes it)", i+1)))
+		content.WriteString("\n")
+	}
 	if m.commandOutput != "" {
 		content.WriteString(descriptionStyle.Bold(true).Render("Command Output:\n"))
 		content.WriteString(m.viewport.View())
 	}
 
+	if m.warning != "" {
+		content.WriteString("\n")
+		content.WriteString(warningStyle.Render("⚠ " + m.warning))
+		content.WriteString("\n")
+	}
+
+	if m.confirmQuiet {
+		_, reason := m.quietHours.Active(time.Now())
+		prompt := fmt.Sprintf("🌙 %s is a dangerous tool and it is %s. Press '%s' to override, any other key to cancel", m.selectedTool.Name, reason, primaryKey(m.keys.Override))
+		content.WriteString("\n")
+		content.WriteString(warningStyle.Render(prompt))
+		content.WriteString("\n")
+	} else if m.confirmRun {
+		prompt := fmt.Sprintf("⚠ %s is a downloaded extension and will run sandboxed. Press '%s' to run, any other key to cancel", m.selectedTool.Name, primaryKey(m.keys.Confirm))
+		if m.selectedTool.Destructive {
+			tool := m.pendingTool()
+			dir, command := scopedCommand(&tool, m.projectDir())
+			prompt = fmt.Sprintf("💥 %s is destructive and will run %s in %s. Press '%s' to run it, any other key to cancel", tool.Name, command, dir, primaryKey(m.keys.Confirm))
+		}
+		content.WriteString("\n")
+		content.WriteString(warningStyle.Render(prompt))
+		content.WriteString("\n")
+	} else if m.statusMessage != "" {
+		content.WriteString("\n")
+		content.WriteString(featureStyle.Render(m.statusMessage))
+		content.WriteString("\n")
+	}
+
 	// Instructions
-	instructions := "Press 'x' to execute command, 'esc' to go back, '?' for help"
+	k := m.keys
+	instructions := fmt.Sprintf("Press '%s' to execute command, '%s' to change trust tier, '%s' to verify, '%s' for release notes, '%s' to roll back, '%s' to append output to notes, '%s' to annotate, '%s' to go back, '%s' for help",
+		primaryKey(k.Execute), primaryKey(k.Trust), primaryKey(k.Verify), primaryKey(k.Changelog), primaryKey(k.Rollback), primaryKey(k.AppendNote), primaryKey(k.Annotate), primaryKey(k.Back), primaryKey(k.Help))
 	content.WriteString("\n")
-	content.WriteString(helpStyle.Render(instructions))
+	content.WriteString(m.tourHighlight("execute", helpStyle.Render(instructions)))
 
 	return content.String()
 }
@@ -450,15 +1768,22 @@ func (m Model) renderDetailView() string {
 // renderFooter renders the footer
 func (m Model) renderFooter() string {
 	var instructions []string
+	k := m.keys
 
 	if m.detailMode {
-		instructions = []string{"x: execute", "esc: back", "↑/↓: scroll", "?: help", "ctrl+c: quit"}
+		instructions = []string{
+			hint("execute", k.Execute), hint("trust", k.Trust), hint("auto", k.Auto), hint("verify", k.Verify), hint("rollback", k.Rollback), hint("changelog", k.Changelog), hint("install", k.Install), hint("upgrade", k.Upgrade), hint("log", k.LogView), hint("export", k.Export), hint("copy", k.Copy),
+			hint("note", k.AppendNote), hint("annotate", k.Annotate), hint("favorite", k.Favorite), hint("output", k.OutputMode), hint("notes", k.Notes),
+			hint("back", k.Back), hint("scroll", k.Up, k.Down), hint("help", k.Help), hint("quit", k.Quit),
+		}
 	} else if m.searchMode {
-		instructions = []string{"enter: search", "esc: cancel", "?: help", "ctrl+c: quit"}
+		instructions = []string{"type: filter", "↑/↓: select", "enter: jump to tool", "esc: cancel", "ctrl+c: quit"}
 	} else {
 		instructions = []string{
-			"↑/↓: navigate", "←/→: categories", "enter: details",
-			"/: search", "tab: toggle", "x: execute", "?: help", "ctrl+c: quit",
+			hint("navigate", k.Up, k.Down), hint("categories", k.Left, k.Right), hint("details", k.Enter),
+			hint("search", k.Search), hint("toggle", k.ToggleCategory), hint("favorite", k.Favorite), hint("refresh", k.Refresh), hint("footprint", k.Footprint),
+			hint("maintenance", k.Maintenance), hint("files", k.Files), hint("notes", k.Notes), hint("project", k.Project), hint("inapplicable", k.Inapplicable), hint("sort", k.SortTools),
+			hint("history", k.History), hint("workflows", k.Workflows), hint("panes", k.Panes), hint("tasks", k.Tasks), hint("MCP", k.MCP), tabsHint(k.Tabs), hint("split", k.Split), hint("resize", k.Narrower, k.Wider), hint("help", k.Help), hint("quit", k.Quit),
 		}
 	}
 
@@ -469,6 +1794,9 @@ func (m Model) renderFooter() string {
 func (m Model) getTotalTools() int {
 	total := 0
 	for _, category := range m.categories {
+		if category.Favorites {
+			continue
+		}
 		total += len(category.Tools)
 	}
 	return total
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// UpdateInfo compares an extension checkout with its upstream branch
+type UpdateInfo struct {
+	Current string
+	Latest  string
+	// Behind counts the upstream commits that are not pulled yet
+	Behind int
+	Err    string
+}
+
+// Available reports whether upstream has changes to pull
+func (u UpdateInfo) Available() bool {
+	return u.Behind > 0
+}
+
+// Badge renders the update for the tool list
+func (u UpdateInfo) Badge() string {
+	if u.Latest != "" && u.Latest != u.Current {
+		return "⬆ " + u.Latest
+	}
+	return fmt.Sprintf("⬆ %d commits", u.Behind)
+}
+
+// Summary describes the update for the detail view
+func (u UpdateInfo) Summary() string {
+	switch {
+	case u.Err != "":
+		return "Update check failed: " + u.Err
+	case !u.Available():
+		return "Up to date with upstream"
+	case u.Latest != "" && u.Latest != u.Current:
+		return fmt.Sprintf("Update available: %s → %s, %d upstream commits", u.Current, u.Latest, u.Behind)
+	}
+	return fmt.Sprintf("Update available: %d upstream commits", u.Behind)
+}
+
+// CheckUpdate fetches the upstream branch of an extension checkout and
+// compares its version with the installed one
+func CheckUpdate(dir string) UpdateInfo {
+	if _, err := gitHead(dir); err != nil {
+		return UpdateInfo{Err: "not a git checkout"}
+	}
+	if out, err := exec.Command("git", "-C", dir, "fetch", "--quiet").CombinedOutput(); err != nil {
+		return UpdateInfo{Err: "git fetch: " + truncate(strings.TrimSpace(string(out)), 120)}
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-list", "--count", "HEAD..@{u}").Output()
+	if err != nil {
+		return UpdateInfo{Err: "the checkout has no upstream branch"}
+	}
+	behind, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return UpdateInfo{
+		Current: extensionVersion(dir),
+		Latest:  versionAt(dir, "@{u}"),
+		Behind:  behind,
+	}
+}
+
+// updatesMsg carries the update checks of the catalog's extensions
+type updatesMsg struct {
+	results map[string]UpdateInfo
+}
+
+// checkUpdatesCmd checks every extension of the catalog for updates
+// concurrently
+func checkUpdatesCmd(categories []Category) tea.Cmd {
+	dirs := map[string]string{}
+	for _, category := range categories {
+		for i := range category.Tools {
+			if dir, ok := ExtensionDir(&category.Tools[i]); ok && !category.Favorites {
+				dirs[category.Tools[i].Key()] = dir
+			}
+		}
+	}
+	return func() tea.Msg {
+		results := make(map[string]UpdateInfo, len(dirs))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, probeWorkers)
+		for key, dir := range dirs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(key, dir string) {
+				defer wg.Done()
+				info := CheckUpdate(dir)
+				mu.Lock()
+				results[key] = info
+				mu.Unlock()
+				<-sem
+			}(key, dir)
+		}
+		wg.Wait()
+		return updatesMsg{results: results}
+	}
+}
+
+// upgradeSelectedTool pulls the upstream changes of the selected
+// extension as a job; once it succeeds the extension is installed again
+// with its package manager
+func (m *Model) upgradeSelectedTool() tea.Cmd {
+	dir, ok := ExtensionDir(m.selectedTool)
+	if !ok {
+		m.statusMessage = "Not an extension, nothing to upgrade"
+		return nil
+	}
+	info, checked := m.updates[m.selectedTool.Key()]
+	switch {
+	case !checked:
+		m.statusMessage = fmt.Sprintf("No update check yet, %s checks again", primaryKey(m.keys.Refresh))
+		return nil
+	case info.Err != "":
+		m.statusMessage = info.Summary()
+		return nil
+	case !info.Available():
+		m.statusMessage = fmt.Sprintf("%s is up to date", m.selectedTool.Name)
+		return nil
+	}
+	m.presetArgs = nil
+	m.pendingInstall = ""
+	m.pendingUpgrade = true
+	return m.confirmAndRun(fmt.Sprintf("git -C %s pull --ff-only", dir), nil)
+}
+
+// finishUpgrade records a successful pull and queues the install of
+// the new version, which starts as soon as the pull's pane is released
+func (m *Model) finishUpgrade(run *runningTool) (*runningTool, error) {
+	dir := run.extensionDir
+	delete(m.updates, run.tool.Key())
+	if hash, err := HashDirectory(dir); err == nil {
+		if err := RecordExtensionHash(dir, hash); err != nil {
+			return nil, err
+		}
+	}
+	manager, command, err := DetectInstaller(dir)
+	if err != nil {
+		return nil, fmt.Errorf("pulled, but cannot install: %v", err)
+	}
+	tool := *run.tool
+	tool.Command, tool.Dir, tool.Scoped = command, dir, false
+	install := &runningTool{tool: &tool, extensionDir: dir, installer: manager, mode: run.mode}
+	m.enqueue(install)
+	return install, nil
+}
@@ -0,0 +1,643 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listenFlag starts the webhook listener alongside the TUI, on the
+// address given as --listen=addr or the configured one
+const listenFlag = "--listen"
+
+// defaultWebhookAddr is where the webhook listener accepts deliveries
+// unless configured otherwise
+const defaultWebhookAddr = ":8787"
+
+// maxWebhookBody is the largest delivery accepted; GitHub caps payloads
+// at 25 MB but events worth reacting to are far smaller
+const maxWebhookBody = 5 << 20
+
+// maxWebhookEvents is how many events the Events screen keeps
+const maxWebhookEvents = 200
+
+// linearWebhookWindow is how old a Linear delivery may be before it is
+// rejected as a replay
+const linearWebhookWindow = time.Minute
+
+// webhookKeyringService keeps the webhook secrets in the system
+// keyring, one account per source
+const webhookKeyringService = "tools-tui-webhooks"
+
+// webhookSources are the services deliveries are accepted from, each
+// on its own path, with the variable holding its secret
+var webhookSources = map[string]string{
+	"github": "GITHUB_WEBHOOK_SECRET",
+	"gitlab": "GITLAB_WEBHOOK_TOKEN",
+	"linear": "LINEAR_WEBHOOK_SECRET",
+}
+
+// listenAddr is the address of the webhook listener when --listen was
+// given; "-" asks for the configured address
+var listenAddr string
+
+// parseListenFlag removes --listen[=addr] from the arguments
+func parseListenFlag(args []string) []string {
+	rest := args[:0:0]
+	for _, arg := range args {
+		switch {
+		case arg == listenFlag:
+			listenAddr = "-"
+		case strings.HasPrefix(arg, listenFlag+"="):
+			listenAddr = strings.TrimPrefix(arg, listenFlag+"=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// WebhookConfig configures the webhook listener
+type WebhookConfig struct {
+	// Addr is where --listen accepts deliveries, :8787 when empty
+	Addr  string        `json:"addr,omitempty"`
+	Rules []WebhookRule `json:"rules,omitempty"`
+}
+
+// WebhookRule maps events to a tool. Empty fields match anything but
+// the event.
+type WebhookRule struct {
+	Source string `json:"source,omitempty"`
+	// Event is the kind of event in lower case, such as push,
+	// pull_request, merge_request or issue
+	Event  string `json:"event"`
+	Action string `json:"action,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Tool   string `json:"tool"`
+	// Args are the values of the tool's arguments; {repo}, {branch},
+	// {sha} and {action} stand for those of the event
+	Args map[string]string `json:"args,omitempty"`
+	// Auto runs the tool on the event without asking when the tool is
+	// approved for unattended runs
+	Auto bool `json:"auto,omitempty"`
+}
+
+// validate reports rules without an event or tool or with an unknown
+// source
+func (c *WebhookConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	for i, rule := range c.Rules {
+		if _, ok := webhookSources[rule.Source]; rule.Source != "" && !ok {
+			return fmt.Errorf("webhook rule #%d: unknown source %q, use github, gitlab or linear", i+1, rule.Source)
+		}
+		if rule.Event == "" || rule.Tool == "" {
+			return fmt.Errorf("webhook rule #%d needs an event and a tool", i+1)
+		}
+	}
+	return nil
+}
+
+// webhookEvent is a verified delivery as the Events screen shows it
+type webhookEvent struct {
+	Source   string
+	Event    string
+	Action   string
+	Repo     string
+	Branch   string
+	SHA      string
+	Actor    string
+	Summary  string
+	Received time.Time
+	Payload  []byte
+	// Rules are the rules matching the event, Outcomes what became of
+	// each when the event arrived
+	Rules    []WebhookRule
+	Outcomes []string
+}
+
+// Label names the event and its action, such as pull_request/opened
+func (e webhookEvent) Label() string {
+	if e.Action == "" {
+		return e.Event
+	}
+	return e.Event + "/" + e.Action
+}
+
+// matches reports whether the rule applies to an event
+func (r WebhookRule) matches(e webhookEvent) bool {
+	return (r.Source == "" || r.Source == e.Source) &&
+		strings.EqualFold(r.Event, e.Event) &&
+		(r.Action == "" || strings.EqualFold(r.Action, e.Action)) &&
+		(r.Branch == "" || r.Branch == e.Branch)
+}
+
+// values returns the rule's arguments with the event filled in
+func (r WebhookRule) values(e webhookEvent) map[string]string {
+	fill := strings.NewReplacer("{repo}", e.Repo, "{branch}", e.Branch, "{sha}", e.SHA, "{action}", e.Action)
+	values := map[string]string{}
+	for name, value := range r.Args {
+		values[name] = fill.Replace(value)
+	}
+	return values
+}
+
+// webhookSecret returns the secret deliveries from source are signed
+// with: the source's variable, else the keyring
+func webhookSecret(source string) string {
+	if secret := os.Getenv(webhookSources[source]); secret != "" {
+		return secret
+	}
+	secret, _ := keyringLookup(webhookKeyringService, source)
+	return secret
+}
+
+// validHMAC reports whether signature is the hex HMAC-SHA256 of body
+func validHMAC(secret string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// verifyWebhook checks that a delivery comes from source: GitHub and
+// Linear sign the body, GitLab sends the secret token as it is
+func verifyWebhook(source, secret string, header http.Header, body []byte) error {
+	switch source {
+	case "github":
+		signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		if !ok || !validHMAC(secret, body, signature) {
+			return fmt.Errorf("invalid X-Hub-Signature-256")
+		}
+	case "gitlab":
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return fmt.Errorf("invalid X-Gitlab-Token")
+		}
+	case "linear":
+		if !validHMAC(secret, body, header.Get("Linear-Signature")) {
+			return fmt.Errorf("invalid Linear-Signature")
+		}
+		var stamp struct {
+			WebhookTimestamp int64 `json:"webhookTimestamp"`
+		}
+		json.Unmarshal(body, &stamp)
+		if age := time.Since(time.UnixMilli(stamp.WebhookTimestamp)); age > linearWebhookWindow || age < -linearWebhookWindow {
+			return fmt.Errorf("webhookTimestamp is outside the last %s", linearWebhookWindow)
+		}
+	}
+	return nil
+}
+
+// webhookPayload holds the fields of GitHub, GitLab and Linear payloads
+// the Events screen summarizes
+type webhookPayload struct {
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	Action      string `json:"action"`
+	ObjectKind  string `json:"object_kind"`
+	Type        string `json:"type"`
+	UserName    string `json:"user_name"`
+	CheckoutSHA string `json:"checkout_sha"`
+	Repository  struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	HeadCommit struct {
+		Message string `json:"message"`
+	} `json:"head_commit"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Head   struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Issue struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	} `json:"issue"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+	Data struct {
+		Identifier string `json:"identifier"`
+		Title      string `json:"title"`
+		Body       string `json:"body"`
+	} `json:"data"`
+	Actor struct {
+		Name string `json:"name"`
+	} `json:"actor"`
+}
+
+// parseWebhook turns a verified delivery into an event
+func parseWebhook(source string, header http.Header, body []byte) (webhookEvent, error) {
+	var p webhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return webhookEvent{}, fmt.Errorf("payload is not JSON: %v", err)
+	}
+	e := webhookEvent{Source: source, Received: time.Now(), Action: p.Action}
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, body, "", "  ") == nil {
+		e.Payload = pretty.Bytes()
+	}
+	switch source {
+	case "github":
+		e.Event = header.Get("X-GitHub-Event")
+		e.Repo, e.Actor = p.Repository.FullName, p.Sender.Login
+		e.Branch, e.SHA = strings.TrimPrefix(p.Ref, "refs/heads/"), p.After
+		switch {
+		case p.PullRequest.Number > 0:
+			e.Branch, e.SHA = p.PullRequest.Head.Ref, p.PullRequest.Head.SHA
+			e.Summary = fmt.Sprintf("#%d %s", p.PullRequest.Number, p.PullRequest.Title)
+		case p.Issue.Number > 0:
+			e.Summary = fmt.Sprintf("#%d %s", p.Issue.Number, p.Issue.Title)
+		case p.HeadCommit.Message != "":
+			e.Summary = firstLine(p.HeadCommit.Message)
+		}
+	case "gitlab":
+		e.Event = p.ObjectKind
+		e.Repo, e.Actor = p.Project.PathWithNamespace, p.UserName
+		e.Branch, e.SHA = strings.TrimPrefix(p.Ref, "refs/heads/"), p.CheckoutSHA
+		if p.ObjectAttributes.IID > 0 {
+			e.Action = p.ObjectAttributes.Action
+			e.Branch, e.SHA = p.ObjectAttributes.SourceBranch, p.ObjectAttributes.LastCommit.ID
+			e.Summary = fmt.Sprintf("!%d %s", p.ObjectAttributes.IID, p.ObjectAttributes.Title)
+		}
+	case "linear":
+		e.Event, e.Actor = p.Type, p.Actor.Name
+		e.Summary = strings.TrimSpace(p.Data.Identifier + " " + p.Data.Title)
+		if e.Summary == "" {
+			e.Summary = firstLine(p.Data.Body)
+		}
+	}
+	e.Event = strings.ToLower(strings.ReplaceAll(e.Event, " ", "_"))
+	if e.Event == "" {
+		return webhookEvent{}, fmt.Errorf("the delivery names no event")
+	}
+	return e, nil
+}
+
+// firstLine returns the first line of text
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+// webhookServer accepts deliveries and hands verified events to the TUI
+type webhookServer struct {
+	addr   string
+	server *http.Server
+	events chan webhookEvent
+}
+
+// webhookMsg carries an event from the listener
+type webhookMsg struct{ event webhookEvent }
+
+// startWebhooks listens for deliveries on addr
+func startWebhooks(addr string) (*webhookServer, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &webhookServer{addr: l.Addr().String(), events: make(chan webhookEvent, 64)}
+	mux := http.NewServeMux()
+	for source := range webhookSources {
+		mux.HandleFunc("/"+source, s.handle(source))
+	}
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(l)
+	return s, nil
+}
+
+// Stop closes the listener
+func (s *webhookServer) Stop() {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+// handle accepts the deliveries of one source. Deliveries are refused
+// while the source has no secret, so nobody can trigger tools with
+// forged events.
+func (s *webhookServer) handle(source string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxWebhookBody {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		secret := webhookSecret(source)
+		if secret == "" {
+			http.Error(w, "no secret configured for "+source+", set $"+webhookSources[source], http.StatusForbidden)
+			return
+		}
+		if err := verifyWebhook(source, secret, r.Header, body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		event, err := parseWebhook(source, r.Header, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		select {
+		case s.events <- event:
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.Error(w, "too many events waiting", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// waitForWebhook delivers the next event of the listener
+func waitForWebhook(s *webhookServer) tea.Cmd {
+	return func() tea.Msg {
+		return webhookMsg{event: <-s.events}
+	}
+}
+
+// listenWebhooks starts the listener asked for with --listen
+func (m *Model) listenWebhooks() error {
+	addr := listenAddr
+	if addr == "-" {
+		addr = defaultWebhookAddr
+		if m.webhookConfig != nil && m.webhookConfig.Addr != "" {
+			addr = m.webhookConfig.Addr
+		}
+	}
+	server, err := startWebhooks(addr)
+	if err != nil {
+		return fmt.Errorf("webhook listener: %v", err)
+	}
+	m.webhooks = server
+	return nil
+}
+
+// receiveWebhook adds an event to the Events screen and applies the
+// matching rules. Auto rules start tools approved for unattended runs
+// in the background; other tools wait for x on the Events screen.
+func (m *Model) receiveWebhook(e webhookEvent) tea.Cmd {
+	var cmds []tea.Cmd
+	if m.webhookConfig != nil {
+		for _, rule := range m.webhookConfig.Rules {
+			if !rule.matches(e) {
+				continue
+			}
+			e.Rules = append(e.Rules, rule)
+			outcome, cmd := m.triggerRule(rule, e)
+			e.Outcomes = append(e.Outcomes, outcome)
+			cmds = append(cmds, cmd)
+		}
+	}
+	v := &m.events
+	v.events = append([]webhookEvent{e}, v.events...)
+	if len(v.events) > maxWebhookEvents {
+		v.events = v.events[:maxWebhookEvents]
+	}
+	if v.cursor > 0 {
+		v.cursor++
+	}
+	v.showEvent()
+	text := fmt.Sprintf("%s %s %s", e.Source, e.Label(), e.Repo)
+	if len(e.Outcomes) > 0 {
+		text += ": " + e.Outcomes[0]
+	}
+	return tea.Batch(append(cmds, m.showToast(text))...)
+}
+
+// triggerRule runs the tool of a matching auto rule when it may run
+// unattended and reports what happened
+func (m *Model) triggerRule(rule WebhookRule, e webhookEvent) (string, tea.Cmd) {
+	tool := findTool(m.categories, rule.Tool)
+	switch {
+	case tool == nil:
+		return rule.Tool + " is not in the catalog", nil
+	case !rule.Auto:
+		return fmt.Sprintf("%s can be started with %s", tool.Name, primaryKey(m.keys.Execute)), nil
+	}
+	if reason := runApproval(tool, Unattended); reason != "" {
+		return fmt.Sprintf("%s not started: %s", tool.Name, reason), nil
+	}
+	if quiet, reason := m.quietHours.Active(time.Now()); quiet && tool.Dangerous {
+		return fmt.Sprintf("%s is dangerous and it is %s, %s starts it", tool.Name, reason, primaryKey(m.keys.Execute)), nil
+	}
+	values := rule.values(e)
+	for _, p := range tool.placeholders() {
+		if _, ok := values[p.Name]; !ok {
+			values[p.Name] = p.Default
+		}
+		if err := p.checkArg(values[p.Name]); err != nil {
+			return fmt.Sprintf("%s not started: %v", tool.Name, err), nil
+		}
+	}
+	run := *tool
+	run.Command = tool.fillCommand(values)
+	job := &runningTool{tool: &run, projectDir: m.projectDir(), args: values, mode: m.outputMode(&run)}
+	if !m.canStart(job.tool) {
+		m.enqueue(job)
+		return fmt.Sprintf("%s queued", tool.Name), nil
+	}
+	return fmt.Sprintf("%s started", tool.Name), m.startJob(job)
+}
+
+// eventsView holds the state of the Events screen
+type eventsView struct {
+	events []webhookEvent
+	cursor int
+	// rule is the rule of the selected event x starts
+	rule    int
+	payload viewport.Model
+	message string
+}
+
+// openEvents shows the webhook events received since the start
+func (m *Model) openEvents() tea.Cmd {
+	v := &m.events
+	v.payload = viewport.New(max(m.width-4, 20), max(m.height-20, 5))
+	v.message = ""
+	v.showEvent()
+	m.screen = screenEvents
+	return nil
+}
+
+// selectedEvent returns the event under the cursor
+func (v eventsView) selectedEvent() *webhookEvent {
+	if v.cursor < len(v.events) {
+		return &v.events[v.cursor]
+	}
+	return nil
+}
+
+// showEvent shows the rules and payload of the selected event
+func (v *eventsView) showEvent() {
+	v.rule = 0
+	e := v.selectedEvent()
+	if e == nil {
+		v.payload.SetContent("")
+		return
+	}
+	var content strings.Builder
+	for i, rule := range e.Rules {
+		fmt.Fprintf(&content, "→ %s: %s\n", rule.Tool, e.Outcomes[i])
+	}
+	if len(e.Rules) > 0 {
+		content.WriteString("\n")
+	}
+	content.Write(e.Payload)
+	v.payload.SetContent(content.String())
+	v.payload.GotoTop()
+}
+
+// runEventRule opens the tool of a rule of the selected event in the
+// detail view and starts it like x does there, with the rule's
+// arguments filled into its form
+func (m *Model) runEventRule() tea.Cmd {
+	v := &m.events
+	e := v.selectedEvent()
+	if e == nil || len(e.Rules) == 0 {
+		v.message = "No rule maps this event to a tool; add one to \"webhooks\" in config.json"
+		return nil
+	}
+	rule := e.Rules[v.rule]
+	tool := findTool(m.categories, rule.Tool)
+	if tool == nil {
+		v.message = rule.Tool + " is not in the catalog"
+		return nil
+	}
+	m.screen = screenTools
+	m.detailMode = true
+	m.selectedTool = tool
+	m.statusMessage = ""
+	m.warning = ""
+	m.attachDetail()
+	m.presetArgs = rule.values(*e)
+	return m.executeSelectedTool()
+}
+
+// updateEvents handles input on the Events screen
+func (m Model) updateEvents(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	v := &m.events
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+			v.showEvent()
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.events)-1 {
+			v.cursor++
+			v.showEvent()
+		}
+	case key.Matches(keyMsg, m.keys.Left):
+		if v.rule > 0 {
+			v.rule--
+		}
+	case key.Matches(keyMsg, m.keys.Right):
+		if e := v.selectedEvent(); e != nil && v.rule < len(e.Rules)-1 {
+			v.rule++
+		}
+	case key.Matches(keyMsg, m.keys.Execute):
+		return m, m.runEventRule()
+	default:
+		var cmd tea.Cmd
+		v.payload, cmd = v.payload.Update(keyMsg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// renderEvents renders the received events and the selected one's
+// rules and payload
+func (m Model) renderEvents() string {
+	v := m.events
+	var content strings.Builder
+	title := titleStyle.Render("📨 Events")
+	summary := "not listening: start the TUI with " + listenFlag + "[=addr]"
+	if m.webhooks != nil {
+		var paths []string
+		for source := range webhookSources {
+			paths = append(paths, "/"+source)
+		}
+		sort.Strings(paths)
+		summary = fmt.Sprintf("listening on %s (%s)", m.webhooks.addr, strings.Join(paths, ", "))
+	}
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", statusStyle.Render(summary)))
+	content.WriteString("\n\n")
+
+	var lines []string
+	for _, e := range v.events {
+		line := fmt.Sprintf("%s %-7s %-28s %s", e.Received.Format("15:04:05"), e.Source, truncate(e.Label(), 28), truncate(e.Summary, 50))
+		meta := " " + e.Repo
+		if e.Branch != "" {
+			meta += "@" + e.Branch
+		}
+		if len(e.Rules) > 0 {
+			meta += " → " + e.Rules[0].Tool
+		}
+		lines = append(lines, line+helpStyle.Render(meta))
+	}
+	content.WriteString(renderMCPList(fmt.Sprintf("Received (%d)", len(v.events)), lines, v.cursor, true))
+	content.WriteString("\n")
+	if e := v.selectedEvent(); e != nil && len(e.Rules) > 1 {
+		content.WriteString(commandStyle.Render(fmt.Sprintf("%s starts %s (rule %d of %d)", primaryKey(m.keys.Execute), e.Rules[v.rule].Tool, v.rule+1, len(e.Rules))))
+		content.WriteString("\n")
+	}
+	content.WriteString(v.payload.View())
+	content.WriteString("\n")
+	if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("select", k.Up, k.Down), hint("rule", k.Left, k.Right), hint("run mapped tool", k.Execute), "pgup/pgdn: scroll", hint("back", k.Back)}, " | ")))
+	return content.String()
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// workflowsFile defines named sequences of tools run as one batch
+const workflowsFile = "workflows.json"
+
+// batchesFile keeps the results of recent workflow runs
+const batchesFile = "batches.json"
+
+// maxBatches is how many workflow runs are kept
+const maxBatches = 50
+
+// maxStepOutput limits the output stored per step
+const maxStepOutput = 4000
+
+// WorkflowStep names a tool and optionally overrides its command
+type WorkflowStep struct {
+	Tool    string `json:"tool"`
+	Command string `json:"command,omitempty"`
+}
+
+// Workflow is a named list of steps. Schedule is an optional cron
+// expression for unattended runs.
+type Workflow struct {
+	Name     string         `json:"name"`
+	Schedule string         `json:"schedule,omitempty"`
+	Steps    []WorkflowStep `json:"steps"`
+}
+
+// StepResult is the outcome of one workflow step. Command and Project
+// are what the step ran with, so a retry repeats it exactly.
+type StepResult struct {
+	Tool       string `json:"tool"`
+	Command    string `json:"command"`
+	Project    string `json:"project,omitempty"`
+	RunID      string `json:"run_id,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Output     string `json:"output,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Attempts   int    `json:"attempts"`
+}
+
+// BatchRun records one execution of a workflow
+type BatchRun struct {
+	ID       string       `json:"id"`
+	Workflow string       `json:"workflow"`
+	Started  time.Time    `json:"started"`
+	Updated  time.Time    `json:"updated"`
+	Steps    []StepResult `json:"steps"`
+}
+
+// Failed returns the number of failed steps
+func (b BatchRun) Failed() int {
+	failed := 0
+	for _, step := range b.Steps {
+		if !step.Success {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Label summarises the batch for lists
+func (b BatchRun) Label() string {
+	status := "✔ passed"
+	if failed := b.Failed(); failed > 0 {
+		status = fmt.Sprintf("✘ %d/%d failed", failed, len(b.Steps))
+	}
+	return fmt.Sprintf("%s  %-20s %s", b.Started.Local().Format("2006-01-02 15:04"), b.Workflow, status)
+}
+
+// LoadWorkflows returns the configured workflows
+func LoadWorkflows() ([]Workflow, error) {
+	var workflows []Workflow
+	err := loadJSON(workflowsFile, &workflows)
+	return workflows, err
+}
+
+// LoadBatches returns recorded workflow runs, newest first
+func LoadBatches() ([]BatchRun, error) {
+	var batches []BatchRun
+	err := loadJSON(batchesFile, &batches)
+	return batches, err
+}
+
+// SaveBatch inserts or replaces a batch and trims old ones
+func SaveBatch(batch BatchRun) error {
+	batches, err := LoadBatches()
+	if err != nil {
+		return err
+	}
+	kept := []BatchRun{batch}
+	for _, b := range batches {
+		if b.ID != batch.ID {
+			kept = append(kept, b)
+		}
+	}
+	if len(kept) > maxBatches {
+		kept = kept[:maxBatches]
+	}
+	return saveJSON(batchesFile, kept)
+}
+
+// findTool looks a tool up by its key
+func findTool(categories []Category, key string) *Tool {
+	for i := range categories {
+		if categories[i].Favorites {
+			continue
+		}
+		for j := range categories[i].Tools {
+			if categories[i].Tools[j].Key() == key {
+				return &categories[i].Tools[j]
+			}
+		}
+	}
+	return nil
+}
+
+// runStep executes a step and records it in the run history. Tools that
+// are not auto-approved only run when the workflow was confirmed.
+func runStep(categories []Category, step StepResult, confirmed bool) StepResult {
+	run, defaults, step, ok := prepareStep(categories, step, confirmed)
+	if !ok {
+		return step
+	}
+	dir, _ := scopedCommand(&run, step.Project)
+	env := CaptureEnv(dir)
+	started := time.Now()
+	output, err := ExecuteTool(&run, step.Project)
+	record := newRunRecord(&run, step.Project, started, env, output, err)
+	record.Args = argValues(defaults)
+	AppendHistory(record, output)
+
+	step.RunID = record.ID
+	step.DurationMs = record.DurationMs
+	step.Success = err == nil
+	step.Error = record.Error
+	step.Output = truncateOutput(output, maxStepOutput)
+	return step
+}
+
+// prepareStep counts an attempt of a step and checks that it may run.
+// It returns the tool with the step's command and the values of its
+// arguments, or reports false with the step failed and the reason.
+func prepareStep(categories []Category, step StepResult, confirmed bool) (Tool, map[string]string, StepResult, bool) {
+	step.Attempts++
+	tool := findTool(categories, step.Tool)
+	if tool == nil {
+		step.Success, step.Error = false, "tool not found in the catalog"
+		return Tool{}, nil, step, false
+	}
+	approval := Unattended
+	if confirmed {
+		approval = Confirmed
+	}
+	if reason := runApproval(tool, approval); reason != "" {
+		step.Success, step.Error = false, reason
+		return Tool{}, nil, step, false
+	}
+	if quiet, reason := loadQuietHours().Active(time.Now()); quiet && tool.Dangerous {
+		step.Success, step.Error = false, "dangerous tool skipped during "+reason
+		return Tool{}, nil, step, false
+	}
+
+	defaults := map[string]string{}
+	var missing []string
+	for _, p := range parsePlaceholders(step.Command) {
+		if !p.Optional && p.Default == "" {
+			missing = append(missing, "<"+p.Name+">")
+		}
+		defaults[p.Name] = p.Default
+	}
+	if len(missing) > 0 {
+		step.Success, step.Error = false, "command needs arguments: "+strings.Join(missing, " ")+", set them in the step's command"
+		return Tool{}, nil, step, false
+	}
+
+	run := *tool
+	run.Command = substitutePlaceholders(step.Command, defaults)
+	return run, defaults, step, true
+}
+
+// RunWorkflow executes every step of a workflow in order. Unless the run
+// was confirmed, steps of tools that are not auto-approved fail.
+func RunWorkflow(categories []Category, wf Workflow, projectDir string, confirmed bool) BatchRun {
+	started := time.Now()
+	batch := BatchRun{ID: newRunID(started), Workflow: wf.Name, Started: started}
+	for _, s := range wf.Steps {
+		step := StepResult{Tool: s.Tool, Command: s.Command, Project: projectDir}
+		if step.Command == "" {
+			if tool := findTool(categories, s.Tool); tool != nil {
+				step.Command = tool.Command
+			}
+		}
+		batch.Steps = append(batch.Steps, runStep(categories, step, confirmed))
+	}
+	batch.Updated = time.Now()
+	return batch
+}
+
+// RetryFailed re-executes only the failed steps of a batch with the same
+// command and project, merging the new results into the batch
+func RetryFailed(categories []Category, batch BatchRun, confirmed bool) BatchRun {
+	for i, step := range batch.Steps {
+		if !step.Success {
+			batch.Steps[i] = runStep(categories, step, confirmed)
+		}
+	}
+	batch.Updated = time.Now()
+	return batch
+}
+
+// workflowsView holds the state of the workflow screen. The cursor
+// walks the workflows first and the recorded batches after them.
+type workflowsView struct {
+	workflows []Workflow
+	batches   []BatchRun
+	cursor    int
+	message   string
+	// confirm holds the tools that are not auto-approved while the run
+	// or retry under the cursor waits for confirmation
+	confirm []string
+}
+
+// openWorkflows loads workflows and past batches
+func (m *Model) openWorkflows() {
+	v := &m.workflows
+	v.message = ""
+	var err error
+	if v.workflows, err = LoadWorkflows(); err != nil {
+		v.message = fmt.Sprintf("Could not read %s: %v", workflowsFile, err)
+	}
+	if v.batches, err = LoadBatches(); err != nil {
+		v.message = fmt.Sprintf("Could not read %s: %v", batchesFile, err)
+	}
+	if v.cursor >= len(v.workflows)+len(v.batches) {
+		v.cursor = 0
+	}
+	m.screen = screenWorkflows
+}
+
+// selectedBatch returns the batch under the cursor, if any
+func (v workflowsView) selectedBatch() (int, bool) {
+	i := v.cursor - len(v.workflows)
+	return i, i >= 0 && i < len(v.batches)
+}
+
+// storeBatch saves a batch and shows it at the top of the list
+func (v *workflowsView) storeBatch(batch BatchRun) {
+	if err := SaveBatch(batch); err != nil {
+		v.message = fmt.Sprintf("Could not save results: %v", err)
+	}
+	v.batches, _ = LoadBatches()
+	v.cursor = len(v.workflows)
+}
+
+// updateWorkflows handles input on the workflow screen
+func (m Model) updateWorkflows(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := &m.workflows
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if len(v.confirm) > 0 {
+		v.confirm = nil
+		switch {
+		case !key.Matches(keyMsg, m.keys.Confirm):
+			v.message = "Run cancelled"
+		case v.cursor < len(v.workflows):
+			m.runWorkflow(true)
+		default:
+			m.retryBatch(true)
+		}
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Back):
+		m.screen = screenTools
+	case key.Matches(keyMsg, m.keys.Up):
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if v.cursor < len(v.workflows)+len(v.batches)-1 {
+			v.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if v.cursor < len(v.workflows) {
+			m.runWorkflow(false)
+		}
+	case key.Matches(keyMsg, m.keys.Retry):
+		m.retryBatch(false)
+	}
+	return m, nil
+}
+
+// runWorkflow runs the workflow under the cursor. Unless confirmed, it
+// first asks to run the tools that are not auto-approved.
+func (m *Model) runWorkflow(confirmed bool) {
+	v := &m.workflows
+	wf := v.workflows[v.cursor]
+	if !confirmed {
+		tools := make([]string, len(wf.Steps))
+		for i, step := range wf.Steps {
+			tools[i] = step.Tool
+		}
+		if v.confirm = unapprovedTools(m.categories, tools); len(v.confirm) > 0 {
+			return
+		}
+	}
+	batch := RunWorkflow(m.categories, wf, m.projectDir(), confirmed)
+	v.storeBatch(batch)
+	v.message = fmt.Sprintf("%s finished: %d of %d steps failed", batch.Workflow, batch.Failed(), len(batch.Steps))
+}
+
+// retryBatch retries the failed steps of the batch under the cursor.
+// Unless confirmed, it first asks to run the tools that are not
+// auto-approved.
+func (m *Model) retryBatch(confirmed bool) {
+	v := &m.workflows
+	i, ok := v.selectedBatch()
+	if !ok {
+		return
+	}
+	failed := v.batches[i].Failed()
+	if failed == 0 {
+		v.message = "Nothing to retry, every step passed"
+		return
+	}
+	if !confirmed {
+		var tools []string
+		for _, step := range v.batches[i].Steps {
+			if !step.Success {
+				tools = append(tools, step.Tool)
+			}
+		}
+		if v.confirm = unapprovedTools(m.categories, tools); len(v.confirm) > 0 {
+			return
+		}
+	}
+	batch := RetryFailed(m.categories, v.batches[i], confirmed)
+	v.storeBatch(batch)
+	v.message = fmt.Sprintf("Retried %d failed steps, %d still failing", failed, batch.Failed())
+}
+
+// renderWorkflows lists workflows, recorded batches and the steps of
+// the selected batch
+func (m Model) renderWorkflows() string {
+	v := m.workflows
+	var content strings.Builder
+	title := titleStyle.Render("🔁 Workflows")
+	status := statusStyle.Render(fmt.Sprintf("%d workflows | %d recorded runs | %s", len(v.workflows), len(v.batches), m.projectLabel()))
+	content.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", status))
+	content.WriteString("\n\n")
+
+	line := func(i int, text string) {
+		if i == v.cursor {
+			content.WriteString(selectedItemStyle.Render("▶ " + text))
+		} else {
+			content.WriteString("  " + text)
+		}
+		content.WriteString("\n")
+	}
+
+	if len(v.workflows) == 0 {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("No workflows defined. Add them to %s in the config directory.", workflowsFile)))
+		content.WriteString("\n")
+	}
+	for i, wf := range v.workflows {
+		names := make([]string, len(wf.Steps))
+		for j, step := range wf.Steps {
+			names[j] = step.Tool
+		}
+		text := fmt.Sprintf("%-20s %s", wf.Name, descriptionStyle.Render(strings.Join(names, " → ")))
+		if wf.Schedule != "" {
+			if spec, err := ParseCron(wf.Schedule); err != nil {
+				text += " " + warningStyle.Render(err.Error())
+			} else if next := spec.Next(time.Now()); !next.IsZero() {
+				label := "⏰ " + next.Format("Mon Jan 2 15:04")
+				if quiet, _ := m.quietHours.Active(next); quiet {
+					label += " 🌙 → " + m.quietHours.NextAllowed(next).Format("Mon 15:04")
+				}
+				text += " " + helpStyle.Render(label)
+			}
+		}
+		line(i, text)
+	}
+
+	if len(v.batches) > 0 {
+		content.WriteString("\n")
+		content.WriteString(featureStyle.Render("Recent runs"))
+		content.WriteString("\n")
+	}
+	for i, batch := range v.batches {
+		line(len(v.workflows)+i, batch.Label())
+	}
+
+	if i, ok := v.selectedBatch(); ok {
+		content.WriteString("\n")
+		for _, step := range v.batches[i].Steps {
+			mark := featureStyle.Render("✔")
+			if !step.Success {
+				mark = warningStyle.Render("✘")
+			}
+			detail := fmt.Sprintf("%s %-24s %8s", mark, step.Tool, (time.Duration(step.DurationMs) * time.Millisecond).Round(time.Millisecond))
+			if step.Attempts > 1 {
+				detail += helpStyle.Render(fmt.Sprintf("  attempt %d", step.Attempts))
+			}
+			if step.Error != "" {
+				detail += " " + helpStyle.Render(step.Error)
+			}
+			content.WriteString("  " + detail + "\n")
+		}
+	}
+
+	content.WriteString("\n")
+	if len(v.confirm) > 0 {
+		content.WriteString(warningStyle.Render(confirmPrompt(v.confirm, primaryKey(m.keys.Confirm))))
+		content.WriteString("\n")
+	} else if v.message != "" {
+		content.WriteString(featureStyle.Render(v.message))
+		content.WriteString("\n")
+	}
+	k := m.keys
+	content.WriteString(footerStyle.Render(strings.Join([]string{hint("navigate", k.Up, k.Down), hint("run workflow", k.Enter), hint("retry failed", k.Retry), hint("back", k.Back), hint("quit", k.Quit)}, " | ")))
+	return content.String()
+}
+
+// runWorkflowSubcommand runs a workflow without the TUI, as cron runs
+// scheduled workflows, and prints the batch as JSON. Only auto-approved
+// tools run unless --yes confirms the others.
+func runWorkflowSubcommand(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("usage: tools-tui workflow <name> [--project dir] [--yes]")
+	}
+	name, args := args[0], args[1:]
+	fs := flag.NewFlagSet("workflow", flag.ExitOnError)
+	project := fs.String("project", "", "sub-project directory scoped tools run in")
+	yes := fs.Bool("yes", false, "also run tools that are not auto-approved")
+	fs.Parse(args)
+
+	workflows, err := LoadWorkflows()
+	if err != nil {
+		return fmt.Errorf("%s: %v", workflowsFile, err)
+	}
+	var wf *Workflow
+	for i := range workflows {
+		if workflows[i].Name == name {
+			wf = &workflows[i]
+		}
+	}
+	if wf == nil {
+		return fmt.Errorf("no workflow named %q in %s", name, workflowsFile)
+	}
+	categories, err := loadCatalogStrict()
+	if err != nil {
+		return err
+	}
+	batch := RunWorkflow(categories, *wf, *project, *yes)
+	if err := SaveBatch(batch); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save results: %v\n", err)
+	}
+	if err := printJSON(batch); err != nil {
+		return err
+	}
+	if failed := batch.Failed(); failed > 0 {
+		return fmt.Errorf("%s: %d of %d steps failed", wf.Name, failed, len(batch.Steps))
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+#!/usr/bin/env python3
+"""Contract testing: fetch a live endpoint and validate its response
+against the schema its OpenAPI spec declares for it
+
+Combines the data fetcher and the OpenAPI validator. The URL's path is
+matched to a path of the spec (after the path of its servers or its
+basePath), and the body to the schema of the response for the returned
+status, falling back to the status class (2XX) and default. Exits
+non-zero when the spec is invalid or the response does not match.
+"""
+
+import json
+import os
+import re
+import sys
+from urllib.parse import urlparse
+
+sys.path.insert(0, os.path.dirname(os.path.abspath(__file__)))
+
+from data_fetcher import fetch_response
+from openapi_validator import load_openapi, validate_openapi
+
+TYPES = {
+    "object": dict,
+    "array": list,
+    "string": str,
+    "boolean": bool,
+    "null": type(None),
+}
+
+
+def resolve(spec, node):
+    """Follow local $refs such as #/components/schemas/Pet"""
+    seen = set()
+    while isinstance(node, dict) and "$ref" in node:
+        ref = node["$ref"]
+        if not ref.startswith("#/") or ref in seen:
+            raise ValueError(f"cannot resolve $ref {ref}")
+        seen.add(ref)
+        node = spec
+        for part in ref[2:].split("/"):
+            node = node[part.replace("~1", "/").replace("~0", "~")]
+    return node
+
+
+def base_paths(spec):
+    """Path prefixes of the API: those of its servers, or the Swagger 2 basePath"""
+    bases = [urlparse(server.get("url", "")).path.rstrip("/") for server in spec.get("servers", [])]
+    bases.append(spec.get("basePath", "").rstrip("/"))
+    return sorted({base for base in bases if base}, key=len, reverse=True) + [""]
+
+
+def find_operation(spec, url, method):
+    """Return the path template and operation of the spec that url calls, preferring literal paths over templated ones"""
+    path = urlparse(url).path or "/"
+    matches = []
+    for base in base_paths(spec):
+        if not path.startswith(base):
+            continue
+        rest = path[len(base):] or "/"
+        for template, item in spec.get("paths", {}).items():
+            pattern = "^" + re.sub(r"\\\{[^/]+?\\\}", "[^/]+", re.escape(template.rstrip("/") or "/")) + "/?$"
+            if re.match(pattern, rest):
+                matches.append((template.count("{"), template, item))
+        if matches:
+            break
+    if not matches:
+        return None, None
+    _, template, item = min(matches, key=lambda match: match[0])
+    return template, resolve(spec, item).get(method.lower())
+
+
+def response_schema(spec, operation, status):
+    """Return the response key (200, 2XX or default) and JSON schema declared for status"""
+    responses = operation.get("responses", {})
+    for key in (str(status), f"{str(status)[0]}XX", "default"):
+        if key in responses:
+            response = resolve(spec, responses[key])
+            if "schema" in response:  # Swagger 2
+                return key, response["schema"]
+            for media, content in response.get("content", {}).items():
+                if media == "application/json" or media.endswith("+json"):
+                    return key, content.get("schema")
+            return key, None
+    return None, None
+
+
+def type_matches(value, expected):
+    if expected == "integer":
+        return isinstance(value, int) and not isinstance(value, bool)
+    if expected == "number":
+        return isinstance(value, (int, float)) and not isinstance(value, bool)
+    return isinstance(value, TYPES.get(expected, object))
+
+
+def check(spec, schema, value, where="$"):
+    """Return the mismatches of value against schema, each prefixed with its JSON path"""
+    schema = resolve(spec, schema or {})
+    errors = []
+    types = schema.get("type")
+    types = types if isinstance(types, list) else [types] if types else []
+    if value is None and (schema.get("nullable") or "null" in types):
+        return errors
+    for sub in schema.get("allOf", []):
+        errors += check(spec, sub, value, where)
+    for keyword in ("anyOf", "oneOf"):
+        if keyword in schema:
+            matching = sum(1 for sub in schema[keyword] if not check(spec, sub, value, where))
+            if matching == 0 or (keyword == "oneOf" and matching > 1):
+                errors.append(f"{where}: matches {matching} of the {keyword} schemas")
+    if types and not any(type_matches(value, t) for t in types):
+        errors.append(f"{where}: expected {' or '.join(types)}, got {type(value).__name__}")
+        return errors
+    if "enum" in schema and value not in schema["enum"]:
+        errors.append(f"{where}: {value!r} is not one of {schema['enum']}")
+    if isinstance(value, dict):
+        properties = schema.get("properties", {})
+        for name in schema.get("required", []):
+            if name not in value:
+                errors.append(f"{where}: missing required property {name!r}")
+        extra = schema.get("additionalProperties", True)
+        for name, item in value.items():
+            if name in properties:
+                errors += check(spec, properties[name], item, f"{where}.{name}")
+            elif extra is False:
+                errors.append(f"{where}: unexpected property {name!r}")
+            elif isinstance(extra, dict):
+                errors += check(spec, extra, item, f"{where}.{name}")
+    elif isinstance(value, list):
+        if "minItems" in schema and len(value) < schema["minItems"]:
+            errors.append(f"{where}: {len(value)} items, at least {schema['minItems']} expected")
+        if "maxItems" in schema and len(value) > schema["maxItems"]:
+            errors.append(f"{where}: {len(value)} items, at most {schema['maxItems']} expected")
+        if "items" in schema:
+            for i, item in enumerate(value):
+                errors += check(spec, schema["items"], item, f"{where}[{i}]")
+    elif isinstance(value, str):
+        if "minLength" in schema and len(value) < schema["minLength"]:
+            errors.append(f"{where}: shorter than {schema['minLength']} characters")
+        if "maxLength" in schema and len(value) > schema["maxLength"]:
+            errors.append(f"{where}: longer than {schema['maxLength']} characters")
+        if "pattern" in schema and not re.search(schema["pattern"], value):
+            errors.append(f"{where}: {value!r} does not match {schema['pattern']}")
+    elif isinstance(value, (int, float)) and not isinstance(value, bool):
+        if "minimum" in schema and value < schema["minimum"]:
+            errors.append(f"{where}: {value} is below the minimum {schema['minimum']}")
+        if "maximum" in schema and value > schema["maximum"]:
+            errors.append(f"{where}: {value} is above the maximum {schema['maximum']}")
+    return errors
+
+
+def contract_test(spec_path, url, method="GET", headers=None):
+    """Fetch url and return the mismatches with the spec, with a line describing what was checked"""
+    spec = load_openapi(spec_path)
+    issues = validate_openapi(spec)
+    if issues:
+        return "Invalid spec", issues
+    template, operation = find_operation(spec, url, method)
+    if template is None:
+        return f"{method} {url}", [f"no path of the spec matches {urlparse(url).path or '/'}"]
+    if operation is None:
+        return f"{method} {template}", [f"{template} declares no {method} operation"]
+    status, response_headers, body = fetch_response(url, headers, method)
+    name = operation.get("operationId", f"{method} {template}")
+    key, schema = response_schema(spec, operation, status)
+    checked = f"{method} {url} → {status} ({name}, response {key})"
+    if key is None:
+        return checked, [f"status {status} is not a declared response ({', '.join(operation.get('responses', {}))})"]
+    if schema is None:
+        return checked, []
+    try:
+        value = json.loads(body)
+    except ValueError:
+        content_type = response_headers.get("Content-Type", "no content type")
+        return checked, [f"the body is not JSON ({content_type})"]
+    return checked, check(spec, schema, value)
+
+
+if __name__ == "__main__":
+    if len(sys.argv) < 3:
+        print("Usage: python contract_tester.py <openapi_file> <url> [method] [headers_json]")
+        sys.exit(1)
+    method = sys.argv[3].upper() if len(sys.argv) > 3 else "GET"
+    headers = json.loads(sys.argv[4]) if len(sys.argv) > 4 else None
+    try:
+        checked, mismatches = contract_test(sys.argv[1], sys.argv[2], method, headers)
+    except Exception as e:
+        print(f"Error: {e}")
+        sys.exit(2)
+    print(checked)
+    if mismatches:
+        print("Contract mismatches:")
+        for mismatch in mismatches:
+            print(f"- {mismatch}")
+        sys.exit(1)
+    print("Response matches the spec.")
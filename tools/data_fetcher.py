@@ -1,22 +1,247 @@
 #!/usr/bin/env python3
 
+import hashlib
+import os
+import re
+import urllib.error
 import urllib.request
 import json
 import sys
+import time
+from urllib.parse import unquote, urlparse
+
+# How much of a download is read at a time
+CHUNK = 64 * 1024
+
+# Seconds between progress lines when the output is not a terminal
+PROGRESS_INTERVAL = 2
 
 def fetch_data(url, headers=None):
     req = urllib.request.Request(url, headers=headers or {})
     with urllib.request.urlopen(req) as response:
         return json.loads(response.read().decode())
 
+def fetch_response(url, headers=None, method="GET"):
+    """Fetch url and return its status, headers and body, error statuses included"""
+    req = urllib.request.Request(url, headers=headers or {}, method=method)
+    try:
+        with urllib.request.urlopen(req) as response:
+            return response.status, dict(response.headers), response.read().decode()
+    except urllib.error.HTTPError as e:
+        return e.code, dict(e.headers), e.read().decode()
+
+def format_size(size):
+    if size < 1024:
+        return f"{size} B"
+    for unit in ("KB", "MB", "GB"):
+        size /= 1024
+        if size < 1024 or unit == "GB":
+            return f"{size:.1f} {unit}"
+
+def is_json(response):
+    """Whether a response carries JSON or text rather than a file to save,
+    judging by its Content-Type"""
+    content_type = response.headers.get_content_type()
+    return "json" in content_type or content_type.startswith("text/")
+
+def download_name(url, response):
+    """The file name a response should be saved as: the one of its
+    Content-Disposition, else the last segment of the URL"""
+    disposition = response.headers.get("Content-Disposition", "")
+    match = re.search(r"filename\*=UTF-8''([^;]+)|filename=\"?([^\";]+)\"?", disposition)
+    if match:
+        name = unquote(match.group(1) or match.group(2))
+    else:
+        name = unquote(os.path.basename(urlparse(url).path))
+    return os.path.basename(name) or "download"
+
+def parse_checksum(checksum):
+    """Split ALGO:HEX, sha256 when only the digest is given"""
+    algo, _, digest = checksum.rpartition(":")
+    algo = (algo or "sha256").lower()
+    if algo not in hashlib.algorithms_available:
+        raise ValueError(f"unknown checksum algorithm {algo}")
+    return algo, digest.lower()
+
+class Progress:
+    """Progress bar on stderr, redrawn in place on a terminal and printed
+    as a line every few seconds otherwise, such as in a TUI pane"""
+
+    def __init__(self, total, done=0):
+        self.total, self.done, self.start = total, done, time.time()
+        self.resumed, self.last = done, 0
+        self.tty = sys.stderr.isatty()
+
+    def update(self, n):
+        self.done += n
+        now = time.time()
+        if self.tty or now - self.last >= PROGRESS_INTERVAL:
+            self.last = now
+            self.draw(now)
+
+    def draw(self, now):
+        rate = (self.done - self.resumed) / max(now - self.start, 0.001)
+        line = f"{format_size(self.done)}"
+        if self.total:
+            filled = int(30 * self.done / self.total)
+            line = f"[{'#' * filled}{'.' * (30 - filled)}] {100 * self.done // self.total:3d}% {line}/{format_size(self.total)}"
+        line += f" {format_size(int(rate))}/s"
+        if self.tty:
+            sys.stderr.write("\r" + line)
+        else:
+            sys.stderr.write(line + "\n")
+        sys.stderr.flush()
+
+    def finish(self):
+        self.draw(time.time())
+        if self.tty:
+            sys.stderr.write("\n")
+
+def validator(response):
+    """The ETag of a response, else its Last-Modified date: what tells a
+    later request whether the file is still the same"""
+    return response.headers.get("ETag") or response.headers.get("Last-Modified")
+
+def read_meta(path):
+    try:
+        with open(path) as f:
+            return json.load(f)
+    except (OSError, ValueError):
+        return {}
+
+def discard(*paths):
+    for path in paths:
+        if os.path.exists(path):
+            os.remove(path)
+
+def download(url, output=None, checksum=None, headers=None, response=None):
+    """Save url to output, a file or a directory (the current one by
+    default). The data goes to output.part first, with the validator of
+    the file in output.part.json, so an interrupted download resumes from
+    where it stopped when the server supports ranges and the file did
+    not change, and is moved into place once the checksum, if any,
+    matches. response is an already opened response to url to start
+    from. Returns the path of the file."""
+    algo, expected = parse_checksum(checksum) if checksum else (None, None)
+    req = urllib.request.Request(url, headers=headers or {})
+    # open once without a range to learn the name, size and version of
+    # the file
+    if response is None:
+        response = urllib.request.urlopen(req)
+    try:
+        if output is None or os.path.isdir(output):
+            output = os.path.join(output or ".", download_name(url, response))
+        part, meta_path = output + ".part", output + ".part.json"
+        offset = os.path.getsize(part) if os.path.exists(part) else 0
+        total = response.headers.get("Content-Length")
+        total = int(total) if total else None
+        current = validator(response)
+        meta = read_meta(meta_path)
+        if offset and (not current or meta.get("validator") != current or meta.get("total") != total or (total and offset > total)):
+            # the file changed or cannot be told apart from the one the
+            # .part was started from, so start over
+            print(f"{part} does not match the current {url}, downloading it again")
+            discard(part, meta_path)
+            offset = 0
+        if offset and offset == total:
+            print(f"{part} is already complete")
+            response.close()
+            response = None
+        elif offset and response.headers.get("Accept-Ranges") == "bytes":
+            response.close()
+            req.add_header("Range", f"bytes={offset}-")
+            # answered with the whole file should it have changed since
+            req.add_header("If-Range", current)
+            try:
+                response = urllib.request.urlopen(req)
+            except urllib.error.HTTPError as e:
+                if e.code != 416:
+                    raise
+                # the range is past the end of the file: start over
+                print(f"{url} is shorter than {part}, downloading it again")
+                discard(part, meta_path)
+                req.remove_header("Range")
+                req.remove_header("If-Range")
+                response = urllib.request.urlopen(req)
+            if response.status == 206:
+                print(f"Resuming {output} at {format_size(offset)}")
+        if response is not None:
+            if response.status != 206:
+                offset = 0
+                with open(meta_path, "w") as f:
+                    json.dump({"validator": validator(response), "total": total}, f)
+            progress = Progress(total, offset)
+            with open(part, "ab" if offset else "wb") as f:
+                for chunk in iter(lambda: response.read(CHUNK), b""):
+                    f.write(chunk)
+                    progress.update(len(chunk))
+            progress.finish()
+    finally:
+        if response is not None:
+            response.close()
+    if total and os.path.getsize(part) < total:
+        raise IOError(f"download stopped at {format_size(os.path.getsize(part))} of {format_size(total)}, run it again to resume")
+    if total and os.path.getsize(part) > total:
+        discard(part, meta_path)
+        raise IOError(f"the download is larger than the {format_size(total)} announced and was deleted, run it again")
+    if algo:
+        digest = hashlib.new(algo)
+        with open(part, "rb") as f:
+            for block in iter(lambda: f.read(CHUNK), b""):
+                digest.update(block)
+        if digest.hexdigest() != expected:
+            discard(part, meta_path)
+            raise ValueError(f"{algo} mismatch: expected {expected}, got {digest.hexdigest()}; the download was deleted")
+        print(f"{algo} verified: {expected}")
+    os.replace(part, output)
+    discard(meta_path)
+    return output
+
+def pop_flag(args, flag):
+    """Remove --flag VALUE or --flag=VALUE from args and return VALUE"""
+    for i, arg in enumerate(args):
+        if arg == flag and i + 1 < len(args):
+            value = args[i + 1]
+            del args[i:i + 2]
+            return value
+        if arg.startswith(flag + "="):
+            del args[i]
+            return arg[len(flag) + 1:]
+    return None
+
 if __name__ == "__main__":
+    if len(sys.argv) > 1 and sys.argv[1] == "download":
+        args = sys.argv[2:]
+        checksum = pop_flag(args, "--checksum")
+        if not args:
+            print("Usage: python data_fetcher.py download <url> [output] [--checksum ALGO:HEX]")
+            sys.exit(1)
+        try:
+            path = download(args[0], args[1] if len(args) > 1 else None, checksum)
+        except Exception as e:
+            print(f"Error downloading: {e}")
+            sys.exit(1)
+        print(f"Saved {path} ({format_size(os.path.getsize(path))})")
+        sys.exit(0)
     if len(sys.argv) < 2:
         print("Usage: python data_fetcher.py <url> [headers_json]")
+        print("       python data_fetcher.py download <url> [output] [--checksum ALGO:HEX]")
         sys.exit(1)
     url = sys.argv[1]
     headers = json.loads(sys.argv[2]) if len(sys.argv) > 2 else None
     try:
-        data = fetch_data(url, headers)
-        print(json.dumps(data, indent=2))
+        # one request: its Content-Type decides whether to print or save it
+        response = urllib.request.urlopen(urllib.request.Request(url, headers=headers or {}))
+        if is_json(response):
+            with response:
+                body = response.read().decode()
+            try:
+                print(json.dumps(json.loads(body), indent=2))
+            except ValueError:
+                print(body)
+        else:
+            # such as an archive: save it rather than print it
+            path = download(url, headers=headers, response=response)
+            print(f"The response is not JSON, saved it to {path}")
     except Exception as e:
-        print(f"Error fetching data: {e}")
\ No newline at end of file
+        print(f"Error fetching data: {e}")
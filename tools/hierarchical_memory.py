@@ -71,6 +71,21 @@ class HierarchicalMemoryManager:
             )
         ''')
         
+        # Tags suggested by auto-organization, applied once reviewed
+        cursor.execute('''
+            CREATE TABLE IF NOT EXISTS tag_suggestions (
+                node_id TEXT NOT NULL,
+                tag_name TEXT NOT NULL,
+                reason TEXT,
+                confidence REAL DEFAULT 0.5,
+                status TEXT DEFAULT 'pending',
+                created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                reviewed_at DATETIME,
+                PRIMARY KEY (node_id, tag_name),
+                FOREIGN KEY (node_id) REFERENCES memory_nodes(id) ON DELETE CASCADE
+            )
+        ''')
+        
         # Sessions for conversation tracking
         cursor.execute('''
             CREATE TABLE IF NOT EXISTS sessions (
@@ -399,8 +414,58 @@ class HierarchicalMemoryManager:
             "relationships": relationships
         }
     
-    def auto_organize_memory(self):
-        """Automatically organize memory based on content and relationships"""
+    def suggest_tag(self, node_id: str, tag_name: str, reason: str = "",
+                    confidence: float = 0.5) -> bool:
+        """Queue a tag for review in the TUI; tags the node already has
+        and suggestions made before, reviewed or not, are skipped"""
+        conn = sqlite3.connect(self.db_path)
+        cursor = conn.cursor()
+        
+        cursor.execute('''
+            INSERT OR IGNORE INTO tag_suggestions (node_id, tag_name, reason, confidence)
+            SELECT ?, ?, ?, ?
+            WHERE NOT EXISTS (
+                SELECT 1 FROM node_tags nt JOIN tags t ON nt.tag_id = t.id
+                WHERE nt.node_id = ? AND t.name = ?
+            )
+        ''', (node_id, tag_name, reason, confidence, node_id, tag_name))
+        added = cursor.rowcount > 0
+        
+        conn.commit()
+        conn.close()
+        return added
+    
+    def pending_suggestions(self) -> List[Dict[str, Any]]:
+        """List the tag suggestions waiting for review"""
+        conn = sqlite3.connect(self.db_path)
+        cursor = conn.cursor()
+        
+        cursor.execute('''
+            SELECT s.node_id, n.title, s.tag_name, s.reason, s.confidence, s.created_at
+            FROM tag_suggestions s
+            JOIN memory_nodes n ON n.id = s.node_id
+            WHERE s.status = 'pending'
+            ORDER BY s.created_at
+        ''')
+        
+        results = []
+        for row in cursor.fetchall():
+            results.append({
+                "node_id": row[0],
+                "title": row[1],
+                "tag": row[2],
+                "reason": row[3],
+                "confidence": row[4],
+                "created_at": row[5]
+            })
+        
+        conn.close()
+        return results
+    
+    def auto_organize_memory(self, review: bool = True) -> int:
+        """Automatically organize memory based on content and relationships.
+        With review the tags are suggested for review instead of applied.
+        Returns how many tags were suggested or applied."""
         conn = sqlite3.connect(self.db_path)
         cursor = conn.cursor()
         
@@ -413,30 +478,42 @@ class HierarchicalMemoryManager:
         ''')
         
         unorganized = cursor.fetchall()
+        conn.close()
         
+        # Tags and the keywords that suggest them, first match wins
+        categories = [
+            ("debugging", ['error', 'bug', 'fix']),
+            ("testing", ['test', 'spec', 'assert']),
+            ("deployment", ['deploy', 'production', 'release']),
+            ("api", ['api', 'endpoint', 'request']),
+        ]
+        
+        count = 0
         for node_id, content in unorganized:
             # Simple content analysis for organization
-            content_lower = content.lower()
+            content_lower = (content or "").lower()
             
             # Categorize based on keywords
-            if any(keyword in content_lower for keyword in ['error', 'bug', 'fix']):
-                self.add_tag_to_node(node_id, "debugging")
-            elif any(keyword in content_lower for keyword in ['test', 'spec', 'assert']):
-                self.add_tag_to_node(node_id, "testing")
-            elif any(keyword in content_lower for keyword in ['deploy', 'production', 'release']):
-                self.add_tag_to_node(node_id, "deployment")
-            elif any(keyword in content_lower for keyword in ['api', 'endpoint', 'request']):
-                self.add_tag_to_node(node_id, "api")
-        
-        conn.commit()
-        conn.close()
+            for tag, keywords in categories:
+                matched = [keyword for keyword in keywords if keyword in content_lower]
+                if not matched:
+                    continue
+                if not review:
+                    self.add_tag_to_node(node_id, tag)
+                    count += 1
+                elif self.suggest_tag(node_id, tag, "mentions " + ", ".join(matched),
+                                      min(1.0, 0.4 + 0.2 * len(matched))):
+                    count += 1
+                break
+        
+        return count
 
 if __name__ == "__main__":
     import sys
     
     if len(sys.argv) < 2:
         print("Usage: python hierarchical_memory.py <action> [args...]")
-        print("Actions: create_session, add_conversation, create_concept, get_hierarchy, search_tag, auto_organize")
+        print("Actions: create_session, add_conversation, create_concept, get_hierarchy, search_tag, auto_organize [--apply], suggestions")
         sys.exit(1)
     
     action = sys.argv[1]
@@ -473,8 +550,15 @@ if __name__ == "__main__":
         print(json.dumps(nodes, indent=2))
     
     elif action == "auto_organize":
-        memory.auto_organize_memory()
-        print("Memory auto-organization completed")
+        if "--apply" in sys.argv[2:]:
+            count = memory.auto_organize_memory(review=False)
+            print(f"Memory auto-organization completed: {count} tags applied")
+        else:
+            count = memory.auto_organize_memory()
+            print(f"Memory auto-organization completed: {count} tags suggested for review in the TUI's Memory screen")
+    
+    elif action == "suggestions":
+        print(json.dumps(memory.pending_suggestions(), indent=2))
     
     else:
         print(f"Unknown action: {action}")
\ No newline at end of file
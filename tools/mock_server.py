@@ -0,0 +1,201 @@
+#!/usr/bin/env python3
+"""Mock HTTP server generated from an OpenAPI spec
+
+Validates the spec with the OpenAPI validator, then answers every
+operation it declares with the example of its response: the example of
+the media type, the first of its examples, the example of the schema, or
+a value generated from the schema. The first declared 2XX response is
+served unless the request asks for another status with a
+`Prefer: code=404` header or a `__status=404` query parameter. Requests
+are logged one per line, so the server can run as a TUI job.
+"""
+
+import json
+import os
+import sys
+from datetime import datetime
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from urllib.parse import parse_qs, urlparse
+
+sys.path.insert(0, os.path.dirname(os.path.abspath(__file__)))
+
+from contract_tester import find_operation, resolve
+from openapi_validator import load_openapi, validate_openapi
+
+METHODS = ("get", "put", "post", "delete", "options", "head", "patch", "trace")
+
+FORMATS = {
+    "date-time": "2024-01-01T00:00:00Z",
+    "date": "2024-01-01",
+    "email": "user@example.com",
+    "uuid": "00000000-0000-4000-8000-000000000000",
+    "uri": "https://example.com",
+    "hostname": "example.com",
+    "ipv4": "192.0.2.1",
+}
+
+
+def example_value(spec, schema, depth=0):
+    """Generate a value matching schema, preferring the examples and defaults it declares"""
+    schema = resolve(spec, schema or {})
+    for keyword in ("example", "default"):
+        if keyword in schema:
+            return schema[keyword]
+    if schema.get("examples") and isinstance(schema["examples"], list):
+        return schema["examples"][0]
+    if "enum" in schema and schema["enum"]:
+        return schema["enum"][0]
+    if depth > 8:
+        return None
+    if "allOf" in schema:
+        merged = {}
+        for sub in schema["allOf"]:
+            value = example_value(spec, sub, depth + 1)
+            if isinstance(value, dict):
+                merged.update(value)
+        return merged
+    for keyword in ("oneOf", "anyOf"):
+        if schema.get(keyword):
+            return example_value(spec, schema[keyword][0], depth + 1)
+    kind = schema.get("type")
+    if isinstance(kind, list):
+        kind = next((k for k in kind if k != "null"), None)
+    if kind is None:
+        kind = "object" if "properties" in schema else "array" if "items" in schema else None
+    if kind == "object":
+        return {name: example_value(spec, prop, depth + 1) for name, prop in schema.get("properties", {}).items()}
+    if kind == "array":
+        return [example_value(spec, schema.get("items", {}), depth + 1) for _ in range(max(schema.get("minItems", 1), 1))]
+    if kind == "string":
+        value = FORMATS.get(schema.get("format"), "string")
+        return value.ljust(schema.get("minLength", 0), "x")
+    if kind == "integer":
+        return int(schema.get("minimum", 0))
+    if kind == "number":
+        return schema.get("minimum", 0.0)
+    if kind == "boolean":
+        return True
+    return None
+
+
+def pick_response(operation, wanted=None):
+    """Return the status to answer with and the response declared for it"""
+    responses = operation.get("responses", {})
+    if wanted:
+        for key in (wanted, f"{wanted[0]}XX", "default"):
+            if key in responses:
+                return int(wanted), responses[key]
+        return None, None
+    for key in responses:
+        if str(key).startswith("2"):
+            return int(str(key).replace("X", "0")), responses[key]
+    if "default" in responses:
+        return 200, responses["default"]
+    for key in responses:
+        return int(str(key).replace("X", "0")), responses[key]
+    return 204, {}
+
+
+def response_body(spec, response):
+    """Return the media type and example body of a response, or None for an empty one"""
+    response = resolve(spec, response)
+    if "schema" in response:  # Swagger 2
+        examples = response.get("examples", {})
+        if "application/json" in examples:
+            return "application/json", examples["application/json"]
+        return "application/json", example_value(spec, response["schema"])
+    for media, content in response.get("content", {}).items():
+        if "example" in content:
+            return media, content["example"]
+        if content.get("examples"):
+            first = resolve(spec, next(iter(content["examples"].values())))
+            return media, first.get("value")
+        return media, example_value(spec, content.get("schema"))
+    return None, None
+
+
+def operations(spec):
+    """List the method and path of every operation"""
+    found = []
+    for path, item in spec.get("paths", {}).items():
+        for method in METHODS:
+            if method in resolve(spec, item):
+                found.append((method.upper(), path))
+    return found
+
+
+def make_handler(spec):
+    class MockHandler(BaseHTTPRequestHandler):
+        def handle_any(self):
+            url = urlparse(self.path)
+            template, operation = find_operation(spec, self.path, self.command)
+            wanted = self.headers.get("Prefer", "").partition("code=")[2].split(",")[0].strip()
+            wanted = wanted or parse_qs(url.query).get("__status", [""])[0]
+            if template is None:
+                self.reply(404, "application/json", {"error": f"no path of the spec matches {url.path}"})
+                return
+            if operation is None:
+                self.reply(405, "application/json", {"error": f"{template} declares no {self.command} operation"})
+                return
+            status, response = pick_response(operation, wanted or None)
+            if status is None:
+                self.reply(400, "application/json", {"error": f"{self.command} {template} declares no {wanted} response"})
+                return
+            media, body = response_body(spec, response)
+            self.reply(status, media, body)
+
+        def reply(self, status, media, body):
+            if self.command != "HEAD":
+                length = int(self.headers.get("Content-Length") or 0)
+                if length:
+                    self.rfile.read(length)
+            data = b""
+            if media is not None:
+                data = json.dumps(body, indent=2).encode() if "json" in media else str(body).encode()
+            self.send_response(status)
+            if media is not None:
+                self.send_header("Content-Type", media)
+            self.send_header("Content-Length", str(len(data)))
+            self.end_headers()
+            if self.command != "HEAD":
+                self.wfile.write(data)
+
+        def log_message(self, format, *args):
+            print(f"{datetime.now():%H:%M:%S} {self.command} {self.path} → {args[1] if len(args) > 1 else '-'}", flush=True)
+
+    for method in METHODS:
+        setattr(MockHandler, f"do_{method.upper()}", MockHandler.handle_any)
+    return MockHandler
+
+
+def serve(spec_path, port=4010, host="127.0.0.1"):
+    spec = load_openapi(spec_path)
+    issues = validate_openapi(spec)
+    if issues:
+        print("Invalid spec, not starting the mock server:")
+        for issue in issues:
+            print(f"- {issue}")
+        sys.exit(1)
+    server = ThreadingHTTPServer((host, port), make_handler(spec))
+    title = spec.get("info", {}).get("title", spec_path)
+    print(f"Mocking {title} on http://{host}:{server.server_port}", flush=True)
+    for method, path in operations(spec):
+        print(f"  {method:7} {path}", flush=True)
+    print("Prefer: code=<status> or ?__status=<status> selects another response", flush=True)
+    try:
+        server.serve_forever()
+    except KeyboardInterrupt:
+        pass
+    finally:
+        server.server_close()
+
+
+if __name__ == "__main__":
+    if len(sys.argv) < 2:
+        print("Usage: python mock_server.py <openapi_file> [port]")
+        sys.exit(1)
+    try:
+        serve(sys.argv[1], int(sys.argv[2]) if len(sys.argv) > 2 else 4010)
+    except Exception as e:
+        print(f"Error: {e}")
+        sys.exit(2)
@@ -5,6 +5,9 @@ import sys
 
 def load_openapi(file_path):
     with open(file_path, 'r') as f:
+        if file_path.endswith(('.yaml', '.yml')):
+            import yaml  # PyYAML, only needed for YAML specs
+            return yaml.safe_load(f)
         return json.load(f)
 
 def validate_openapi(spec):
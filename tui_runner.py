@@ -0,0 +1,155 @@
+#!/usr/bin/env python3
+"""Run commands through the Go runner of tools-tui (`tools-tui exec --json`)
+
+Commands run this way stream their output, honour a timeout and are
+recorded in the run history of the TUI like the tools it runs. When the
+tools-tui binary is not available the command runs with subprocess
+instead, without the history.
+"""
+
+import json
+import os
+import shutil
+import subprocess
+import sys
+import threading
+from dataclasses import dataclass, field
+
+ROOT = os.path.dirname(os.path.abspath(__file__))
+
+# Outcome of probing each binary for the exec subcommand, keyed by path
+# with its modification time and size so a rebuild is probed again
+PROBE_CACHE = os.path.join(os.environ.get("XDG_CACHE_HOME") or os.path.expanduser("~/.cache"), "opencode-tui", "runner_probe.json")
+
+# Exit status of a command that timed out, as reported by tools-tui
+TIMEOUT_STATUS = 124
+
+
+@dataclass
+class RunResult:
+    """Outcome of a command run"""
+    returncode: int
+    output: str = ""
+    record: dict = field(default_factory=dict)
+
+    @property
+    def ok(self):
+        return self.returncode == 0
+
+
+_runner = None
+
+
+def find_runner():
+    """Locate a tools-tui binary with the exec subcommand: $TOOLS_TUI, PATH, then the build next to this file"""
+    global _runner
+    if _runner is not None:
+        return _runner or None
+    _runner = ""
+    candidates = [os.environ.get("TOOLS_TUI"), shutil.which("tools-tui"), os.path.join(ROOT, "tools-tui", "tools-tui")]
+    for path in candidates:
+        if path and os.path.isfile(path) and os.access(path, os.X_OK) and _supports_exec(path):
+            _runner = path
+            break
+    return _runner or None
+
+
+def _supports_exec(path):
+    """Older builds start the TUI for unknown commands, so check the usage
+    first; the answer is cached until the binary changes"""
+    stat = os.stat(path)
+    key = f"{os.path.realpath(path)}:{stat.st_mtime_ns}:{stat.st_size}"
+    try:
+        with open(PROBE_CACHE) as f:
+            cache = json.load(f)
+    except (OSError, ValueError):
+        cache = {}
+    if key in cache:
+        return cache[key]
+    try:
+        usage = subprocess.run([path, "help"], capture_output=True, text=True, timeout=10).stdout
+    except (OSError, subprocess.TimeoutExpired):
+        return False
+    supported = any(line.split()[:1] == ["exec"] for line in usage.splitlines())
+    cache = {k: v for k, v in cache.items() if not k.startswith(os.path.realpath(path) + ":")}
+    cache[key] = supported
+    try:
+        os.makedirs(os.path.dirname(PROBE_CACHE), exist_ok=True)
+        with open(PROBE_CACHE, "w") as f:
+            json.dump(cache, f)
+    except OSError:
+        pass
+    return supported
+
+
+def run(argv, cwd=None, env=None, timeout=None, name=None, on_output=None):
+    """Run argv and return a RunResult
+
+    on_output is called with each chunk of the combined output as it is
+    written; without it the output is echoed to stdout. timeout is in
+    seconds.
+    """
+    if on_output is None:
+        on_output = _echo
+    runner = find_runner()
+    if runner is None:
+        return _run_subprocess(argv, cwd, env, timeout, on_output)
+
+    cmd = [runner, "exec", "--json"]
+    if cwd:
+        cmd += ["--dir", cwd]
+    if timeout:
+        cmd += ["--timeout", f"{timeout}s"]
+    if name:
+        cmd += ["--name", name]
+    for key, value in (env or {}).items():
+        cmd += ["--env", f"{key}={value}"]
+    cmd += ["--"] + list(argv)
+
+    chunks, record = [], {}
+    # stdin is inherited and passed on, for commands that read it such as
+    # handle_webhook
+    proc = subprocess.Popen(cmd, stdout=subprocess.PIPE, text=True)
+    for line in proc.stdout:
+        try:
+            event = json.loads(line)
+        except json.JSONDecodeError:
+            continue
+        if event.get("event") == "output":
+            chunks.append(event.get("data", ""))
+            on_output(event.get("data", ""))
+        elif event.get("event") == "finished":
+            record = event.get("record", {})
+    returncode = proc.wait()
+    return RunResult(returncode, "".join(chunks), record)
+
+
+def _echo(chunk):
+    sys.stdout.write(chunk)
+    sys.stdout.flush()
+
+
+def _run_subprocess(argv, cwd, env, timeout, on_output):
+    """Fallback without tools-tui: same streaming and timeout, no history"""
+    merged = dict(os.environ, **(env or {}))
+    proc = subprocess.Popen(argv, cwd=cwd, env=merged, stdout=subprocess.PIPE, stderr=subprocess.STDOUT, text=True)
+    timer = threading.Timer(timeout, proc.kill) if timeout else None
+    if timer:
+        timer.start()
+    chunks = []
+    for line in proc.stdout:
+        chunks.append(line)
+        on_output(line)
+    returncode = proc.wait()
+    if timer:
+        if not timer.is_alive():
+            returncode = TIMEOUT_STATUS
+        timer.cancel()
+    return RunResult(returncode, "".join(chunks))
+
+
+if __name__ == "__main__":
+    if len(sys.argv) < 2:
+        print("Usage: python tui_runner.py <program> [args...]")
+        sys.exit(1)
+    sys.exit(run(sys.argv[1:]).returncode)